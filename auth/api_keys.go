@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// APIKeyPrefix marks workspace API keys so other tokens never hit the key lookup
+const APIKeyPrefix = "stk_"
+
+// APIKeyContextKey holds the resolved *APIKey for requests made with a workspace API key
+var APIKeyContextKey = contextKey("api_key")
+
+// APIKey is a resolved workspace API key, requests made with it act as the
+// key creator but are limited to the key's workspace and scopes
+type APIKey struct {
+	ID            string
+	WorkspaceUuid string
+	Pubkey        string
+	Scopes        []string
+}
+
+// APIKeyResolver looks up a plaintext key, it is set at startup because the
+// keys live in the database which auth cannot import
+var APIKeyResolver func(ctx context.Context, token string) (*APIKey, error)
+
+// APIKeyWorkspaceResolver returns the workspaces a request made with an API
+// key acts on, it is set at startup like APIKeyResolver. A key may only be
+// used on routes the resolver finds a workspace for, every other route is
+// closed to keys.
+var APIKeyWorkspaceResolver func(r *http.Request) ([]string, error)
+
+type apiKeyResource struct {
+	prefix string
+	name   string
+}
+
+// apiKeyResources maps mounted route prefixes to the resource part of a scope
+var apiKeyResources = []apiKeyResource{
+	{"/bounties/ticket", "tickets"},
+	{"/activities", "activities"},
+	{"/features", "features"},
+	{"/workspaces", "workspaces"},
+	{"/hivechat", "chats"},
+	{"/gobounties", "bounties"},
+	{"/snippet", "snippets"},
+	{"/codespace", "codespaces"},
+}
+
+// APIKeyScopes lists every scope that can be granted to a key
+func APIKeyScopes() []string {
+	scopes := []string{}
+	for _, resource := range apiKeyResources {
+		scopes = append(scopes, resource.name+":read", resource.name+":write")
+	}
+	return scopes
+}
+
+func IsValidAPIKeyScope(scope string) bool {
+	for _, s := range APIKeyScopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyScopeForRequest returns the scope a key needs for the request,
+// reads need the read scope and every other method needs write
+func APIKeyScopeForRequest(r *http.Request) string {
+	action := "write"
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		action = "read"
+	}
+
+	for _, resource := range apiKeyResources {
+		if r.URL.Path == resource.prefix || strings.HasPrefix(r.URL.Path, resource.prefix+"/") {
+			return resource.name + ":" + action
+		}
+	}
+	return ""
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	if scope == "" {
+		return false
+	}
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateAPIKey returns a new plaintext key, only its hash should be stored
+func GenerateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return APIKeyPrefix + hex.EncodeToString(b), nil
+}
+
+func HashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func APIKeyFromContext(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(APIKeyContextKey).(*APIKey)
+	return key
+}
+
+// APIKeyAllowsWorkspace is true for requests not made with an API key, or
+// when the key belongs to the given workspace
+func APIKeyAllowsWorkspace(ctx context.Context, workspaceUuid string) bool {
+	key := APIKeyFromContext(ctx)
+	return key == nil || key.WorkspaceUuid == workspaceUuid
+}
+
+// APIKeyAllowsRequest is true when every workspace the request acts on is
+// the key's workspace, requests whose workspace cannot be found are refused
+func APIKeyAllowsRequest(key *APIKey, r *http.Request) bool {
+	if APIKeyWorkspaceResolver == nil {
+		return false
+	}
+
+	workspaces, err := APIKeyWorkspaceResolver(r)
+	if err != nil || len(workspaces) == 0 {
+		return false
+	}
+	for _, workspace := range workspaces {
+		if workspace != key.WorkspaceUuid {
+			return false
+		}
+	}
+	return true
+}
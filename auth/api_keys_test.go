@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyScopeForRequest(t *testing.T) {
+	tests := []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{http.MethodGet, "/bounties/ticket/plan/abc", "tickets:read"},
+		{http.MethodPost, "/bounties/ticket/abc", "tickets:write"},
+		{http.MethodPost, "/activities", "activities:write"},
+		{http.MethodDelete, "/activities/abc", "activities:write"},
+		{http.MethodGet, "/workspaces/abc/api-keys", "workspaces:read"},
+		{http.MethodGet, "/person/abc", ""},
+		{http.MethodGet, "/activitiesx", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			assert.Equal(t, tt.expected, APIKeyScopeForRequest(req))
+		})
+	}
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, err := GenerateAPIKey()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, APIKeyPrefix))
+
+	other, err := GenerateAPIKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, other)
+
+	assert.Len(t, HashAPIKey(key), 64)
+	assert.Equal(t, HashAPIKey(key), HashAPIKey(key))
+	assert.NotEqual(t, HashAPIKey(key), HashAPIKey(other))
+}
+
+func TestAPIKeyAllowsWorkspace(t *testing.T) {
+	assert.True(t, APIKeyAllowsWorkspace(context.Background(), "any"))
+
+	ctx := context.WithValue(context.Background(), APIKeyContextKey, &APIKey{WorkspaceUuid: "ws"})
+	assert.True(t, APIKeyAllowsWorkspace(ctx, "ws"))
+	assert.False(t, APIKeyAllowsWorkspace(ctx, "other"))
+}
+
+func TestCombinedAuthContextWithAPIKey(t *testing.T) {
+	originalResolver, originalWorkspaceResolver := APIKeyResolver, APIKeyWorkspaceResolver
+	defer func() { APIKeyResolver, APIKeyWorkspaceResolver = originalResolver, originalWorkspaceResolver }()
+
+	validKey := APIKeyPrefix + "valid"
+	APIKeyResolver = func(ctx context.Context, token string) (*APIKey, error) {
+		if token != validKey {
+			return nil, errors.New("api key not found")
+		}
+		return &APIKey{ID: "key-id", WorkspaceUuid: "ws", Pubkey: "creator", Scopes: []string{"tickets:write"}}, nil
+	}
+	APIKeyWorkspaceResolver = func(r *http.Request) ([]string, error) {
+		if workspace := r.URL.Query().Get("workspace"); workspace != "" {
+			return []string{workspace}, nil
+		}
+		return nil, nil
+	}
+
+	tests := []struct {
+		name           string
+		token          string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{"valid key with scope", validKey, http.MethodPost, "/bounties/ticket/abc?workspace=ws", http.StatusOK},
+		{"valid key without scope", validKey, http.MethodGet, "/bounties/ticket/abc?workspace=ws", http.StatusForbidden},
+		{"valid key on unscoped route", validKey, http.MethodPost, "/person?workspace=ws", http.StatusForbidden},
+		{"valid key on another workspace", validKey, http.MethodPost, "/bounties/ticket/abc?workspace=other", http.StatusForbidden},
+		{"valid key on a route naming no workspace", validKey, http.MethodPost, "/bounties/ticket/abc", http.StatusForbidden},
+		{"unknown key", APIKeyPrefix + "unknown", http.MethodPost, "/bounties/ticket/abc?workspace=ws", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pubkey string
+			var key *APIKey
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				pubkey, _ = r.Context().Value(ContextKey).(string)
+				key = APIKeyFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("x-api-token", tt.token)
+			rr := httptest.NewRecorder()
+			CombinedAuthContext(next).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "creator", pubkey)
+				assert.Equal(t, "ws", key.WorkspaceUuid)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAllowsRequestWithoutWorkspaceResolver(t *testing.T) {
+	originalWorkspaceResolver := APIKeyWorkspaceResolver
+	defer func() { APIKeyWorkspaceResolver = originalWorkspaceResolver }()
+	APIKeyWorkspaceResolver = nil
+
+	req := httptest.NewRequest(http.MethodGet, "/features/abc", nil)
+	assert.False(t, APIKeyAllowsRequest(&APIKey{WorkspaceUuid: "ws"}, req))
+}
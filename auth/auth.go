@@ -33,6 +33,12 @@ type contextKey string
 // ContextKey ...
 var ContextKey = contextKey("key")
 
+// withPubkey stores the authenticated pubkey in the request context, where
+// the request's logger picks it up as well
+func withPubkey(ctx context.Context, pubkey string) context.Context {
+	return logger.WithFields(context.WithValue(ctx, ContextKey, pubkey), "pubkey", pubkey)
+}
+
 // PubKeyContext godoc
 //
 //	@Summary					Authentication middleware that extracts public key from token
@@ -48,6 +54,11 @@ func PubKeyContext(next http.Handler) http.Handler {
 			token = r.Header.Get("x-jwt")
 		}
 
+		if token == "" && IsNostrAuthRequest(r) {
+			nostrAuthContext(next, w, r)
+			return
+		}
+
 		if token == "" {
 			logger.Log.Info("[auth] no token")
 			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
@@ -60,20 +71,28 @@ func PubKeyContext(next http.Handler) http.Handler {
 			claims, err := DecodeJwt(token)
 
 			if err != nil {
-				fmt.Println("JWT error =================================", err)
-				logger.Log.Info("Failed to parse JWT", token)
+				logger.Log.Info("Failed to parse JWT: %v", err)
 				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 				return
 			}
 
 			if claims.VerifyExpiresAt(time.Now().UnixNano(), true) {
-				fmt.Println("Token has expired =================================")
 				logger.Log.Info("Token has expired")
 				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), ContextKey, claims["pubkey"])
+			if IsJwtRevoked(claims) {
+				logger.Log.Info("[auth] token has been revoked")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			pubkey, _ := claims["pubkey"].(string)
+			ctx := withPubkey(r.Context(), ResolvePubkey(pubkey))
+			if sessionID, ok := claims["sid"].(string); ok {
+				ctx = context.WithValue(ctx, SessionContextKey, sessionID)
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		} else {
 			pubkey, err := VerifyTribeUUID(token, true)
@@ -87,12 +106,24 @@ func PubKeyContext(next http.Handler) http.Handler {
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), ContextKey, pubkey)
+			ctx := withPubkey(r.Context(), ResolvePubkey(pubkey))
 			next.ServeHTTP(w, r.WithContext(ctx))
 		}
 	})
 }
 
+// OptionalPubKeyContext authenticates requests that carry a token like
+// PubKeyContext does and lets the others through without a pubkey
+func OptionalPubKeyContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("token") == "" && r.Header.Get("x-jwt") == "" && !IsNostrAuthRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		PubKeyContext(next).ServeHTTP(w, r)
+	})
+}
+
 // PubKeyContextSuperAdmin godoc
 //
 //	@Summary					Super admin authentication middleware
@@ -125,8 +156,7 @@ func PubKeyContextSuperAdmin(next http.Handler) http.Handler {
 			claims, err := DecodeJwt(token)
 
 			if err != nil {
-				fmt.Println("JWT error =================================", err)
-				logger.Log.Info("Failed to parse JWT", token)
+				logger.Log.Info("Failed to parse JWT: %v", err)
 				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 				return
 			}
@@ -137,6 +167,12 @@ func PubKeyContextSuperAdmin(next http.Handler) http.Handler {
 				return
 			}
 
+			if IsJwtRevoked(claims) {
+				logger.Log.Info("[auth] token has been revoked")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
 			pubkey := fmt.Sprintf("%v", claims["pubkey"])
 			if !IsFreePass() && !AdminCheck(pubkey) {
 				logger.Log.Info("Not a super admin")
@@ -164,7 +200,7 @@ func PubKeyContextSuperAdmin(next http.Handler) http.Handler {
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), ContextKey, pubkey)
+			ctx := withPubkey(r.Context(), pubkey)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		}
 	})
@@ -188,9 +224,35 @@ func CombinedAuthContext(next http.Handler) http.Handler {
 				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
+
+			if strings.HasPrefix(tokenHeader, APIKeyPrefix) && APIKeyResolver != nil {
+				key, err := APIKeyResolver(r.Context(), tokenHeader)
+				if err != nil || key == nil {
+					logger.Log.Info("[auth] invalid api key")
+					http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+					return
+				}
+
+				if !key.HasScope(APIKeyScopeForRequest(r)) {
+					logger.Log.Info("[auth] api key %s is missing scope for %s %s", key.ID, r.Method, r.URL.Path)
+					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					return
+				}
+
+				if !APIKeyAllowsRequest(key, r) {
+					logger.Log.Info("[auth] api key %s has no access to the workspace of %s %s", key.ID, r.Method, r.URL.Path)
+					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					return
+				}
+
+				ctx := withPubkey(r.Context(), key.Pubkey)
+				ctx = context.WithValue(ctx, APIKeyContextKey, key)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
 		}
 
-		if token != "" {
+		if token != "" || IsNostrAuthRequest(r) {
 			pubKeyHandler := PubKeyContext(next)
 			pubKeyHandler.ServeHTTP(w, r)
 			return
@@ -380,6 +442,7 @@ func EncodeJwt(pubkey string) (string, error) {
 
 	claims := jwt.MapClaims{
 		"pubkey": pubkey,
+		"iat":    time.Now().Unix(),
 		"exp":    exp,
 	}
 
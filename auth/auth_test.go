@@ -2025,6 +2025,62 @@ func TestPubKeyContext(t *testing.T) {
 	})
 }
 
+func TestOptionalPubKeyContext(t *testing.T) {
+	config.InitConfig()
+	InitJwt()
+	privKey, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	pubKeyHex := hex.EncodeToString(privKey.PubKey().SerializeCompressed())
+	_, validToken, _ := TokenAuth.Encode(map[string]interface{}{
+		"pubkey": pubKeyHex,
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	})
+
+	tests := []struct {
+		name           string
+		setupToken     func(r *http.Request)
+		expectedStatus int
+		expectedPubKey string
+	}{
+		{
+			name:           "No Token",
+			setupToken:     func(r *http.Request) {},
+			expectedStatus: http.StatusOK,
+			expectedPubKey: "",
+		},
+		{
+			name: "Valid JWT Token in Query",
+			setupToken: func(r *http.Request) {
+				r.URL.RawQuery = "token=" + validToken
+			},
+			expectedStatus: http.StatusOK,
+			expectedPubKey: pubKeyHex,
+		},
+		{
+			name: "Invalid JWT Token",
+			setupToken: func(r *http.Request) {
+				r.Header.Set("x-jwt", "invalid.jwt.token")
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pubKey string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				pubKey, _ = r.Context().Value(ContextKey).(string)
+				w.WriteHeader(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/websocket", nil)
+			tt.setupToken(req)
+			rr := httptest.NewRecorder()
+			OptionalPubKeyContext(next).ServeHTTP(rr, req)
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedPubKey, pubKey)
+		})
+	}
+}
+
 func TestCombinedAuthContext(t *testing.T) {
 	// Initialize configuration and override the expected x-api-token value.
 	originalEnv := os.Getenv("SWAUTH")
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// Key types, they match the identity types stored in the database
+const (
+	KeyTypeNode  = "node"
+	KeyTypeNostr = "nostr"
+)
+
+// KeyProofWindow is how long a signed link challenge stays valid
+const KeyProofWindow = 10 * time.Minute
+
+// LinkedPubkeyResolver maps a linked node or nostr key to the pubkey of the
+// person it belongs to, it is set at startup because people live in the database
+var LinkedPubkeyResolver func(pubkey string) string
+
+var linkedPubkeyCache = cache.New(30*time.Second, time.Minute)
+
+var (
+	nodeKeyPattern  = regexp.MustCompile(`^0[23][0-9a-f]{64}$`)
+	nostrKeyPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// KeyType tells node pubkeys (33 byte compressed) from nostr pubkeys (32 byte x-only)
+func KeyType(pubkey string) string {
+	switch {
+	case nodeKeyPattern.MatchString(pubkey):
+		return KeyTypeNode
+	case nostrKeyPattern.MatchString(pubkey):
+		return KeyTypeNostr
+	}
+	return ""
+}
+
+// ResolvePubkey returns the canonical pubkey of the person a key is linked
+// to, or the key itself when it is not linked
+func ResolvePubkey(pubkey string) string {
+	if LinkedPubkeyResolver == nil || pubkey == "" {
+		return pubkey
+	}
+
+	if cached, found := linkedPubkeyCache.Get(pubkey); found {
+		return cached.(string)
+	}
+
+	resolved := pubkey
+	if KeyType(pubkey) != "" {
+		if linked := LinkedPubkeyResolver(pubkey); linked != "" {
+			resolved = linked
+		}
+	}
+	linkedPubkeyCache.Set(pubkey, resolved, cache.DefaultExpiration)
+	return resolved
+}
+
+// ClearLinkedPubkeyCache is called when keys are linked, unlinked or merged
+func ClearLinkedPubkeyCache() {
+	linkedPubkeyCache.Flush()
+}
+
+// VerifyKeyProof checks that pubkey signed the challenge. Node keys sign it
+// either as an LNURL-auth DER signature or as a Sphinx signed message, nostr
+// keys sign a kind 22242 event with a challenge tag
+func VerifyKeyProof(pubkey string, signature string, event *NostrEvent, challenge string) error {
+	switch KeyType(pubkey) {
+	case KeyTypeNode:
+		if signature == "" {
+			return errors.New("signature is required")
+		}
+		if _, err := hex.DecodeString(signature); err == nil {
+			if valid, err := VerifyDerSig(signature, challenge, pubkey); err == nil && valid {
+				return nil
+			}
+		}
+		signer, err := VerifyArbitrary(signature, challenge)
+		if err != nil || signer != pubkey {
+			return errors.New("signature verification failed")
+		}
+		return nil
+	case KeyTypeNostr:
+		if event == nil || event.Pubkey != pubkey {
+			return errors.New("a nostr event signed by the key is required")
+		}
+		return VerifyNostrChallengeEvent(event, challenge, KeyProofWindow)
+	}
+	return errors.New("unsupported key")
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	btcecdsa "github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyType(t *testing.T) {
+	assert.Equal(t, KeyTypeNode, KeyType("02"+strings.Repeat("a", 64)))
+	assert.Equal(t, KeyTypeNode, KeyType("03"+strings.Repeat("1", 64)))
+	assert.Equal(t, KeyTypeNostr, KeyType(strings.Repeat("b", 64)))
+	assert.Equal(t, "", KeyType("04"+strings.Repeat("a", 64)))
+	assert.Equal(t, "", KeyType(strings.Repeat("B", 64)))
+	assert.Equal(t, "", KeyType("github_user"))
+}
+
+func TestResolvePubkey(t *testing.T) {
+	linked := "02" + strings.Repeat("a", 64)
+	calls := 0
+	LinkedPubkeyResolver = func(pubkey string) string {
+		calls++
+		if pubkey == linked {
+			return "canonical"
+		}
+		return ""
+	}
+	defer func() {
+		LinkedPubkeyResolver = nil
+		ClearLinkedPubkeyCache()
+	}()
+	ClearLinkedPubkeyCache()
+
+	assert.Equal(t, "canonical", ResolvePubkey(linked))
+	assert.Equal(t, "canonical", ResolvePubkey(linked))
+	assert.Equal(t, 1, calls)
+
+	unlinked := strings.Repeat("c", 64)
+	assert.Equal(t, unlinked, ResolvePubkey(unlinked))
+	assert.Equal(t, "not_a_key", ResolvePubkey("not_a_key"))
+	assert.Equal(t, 2, calls)
+
+	ClearLinkedPubkeyCache()
+	ResolvePubkey(linked)
+	assert.Equal(t, 3, calls)
+}
+
+func TestVerifyKeyProof(t *testing.T) {
+	challenge := hex.EncodeToString([]byte(strings.Repeat("x", 32)))
+	nodeKey, _ := btcec.NewPrivateKey()
+	nodePubkey := hex.EncodeToString(nodeKey.PubKey().SerializeCompressed())
+
+	t.Run("should accept a DER signature from a node key", func(t *testing.T) {
+		msg, _ := hex.DecodeString(challenge)
+		sig := btcecdsa.Sign(nodeKey, msg)
+		assert.NoError(t, VerifyKeyProof(nodePubkey, hex.EncodeToString(sig.Serialize()), nil, challenge))
+	})
+
+	t.Run("should accept a signed message from a node key", func(t *testing.T) {
+		sig, err := Sign([]byte(challenge), nodeKey)
+		assert.NoError(t, err)
+		assert.NoError(t, VerifyKeyProof(nodePubkey, base64.URLEncoding.EncodeToString(sig), nil, challenge))
+	})
+
+	t.Run("should reject a signature from another node key", func(t *testing.T) {
+		otherKey, _ := btcec.NewPrivateKey()
+		sig, _ := Sign([]byte(challenge), otherKey)
+		assert.Error(t, VerifyKeyProof(nodePubkey, base64.URLEncoding.EncodeToString(sig), nil, challenge))
+		assert.Error(t, VerifyKeyProof(nodePubkey, "", nil, challenge))
+	})
+
+	t.Run("should accept a nostr event answering the challenge", func(t *testing.T) {
+		nostrKey, _ := btcec.NewPrivateKey()
+		event := signTestNostrEvent(t, nostrKey, NostrEvent{
+			CreatedAt: time.Now().Unix(),
+			Kind:      NostrAuthKind,
+			Tags:      [][]string{{"challenge", challenge}},
+		})
+		assert.NoError(t, VerifyKeyProof(event.Pubkey, "", &event, challenge))
+
+		other := hex.EncodeToString(schnorr.SerializePubKey(nodeKey.PubKey()))
+		assert.Error(t, VerifyKeyProof(other, "", &event, challenge))
+		assert.Error(t, VerifyKeyProof(event.Pubkey, "", nil, challenge))
+	})
+
+	t.Run("should reject unsupported keys", func(t *testing.T) {
+		assert.Error(t, VerifyKeyProof("github_user", "sig", nil, challenge))
+	})
+}
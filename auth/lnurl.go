@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"errors"
 	"strings"
 
 	lnurl "github.com/fiatjaf/go-lnurl"
@@ -16,11 +17,16 @@ type LnEncodeData struct {
 
 var EncodeLNURLFunc = EncodeLNURL
 
-func EncodeLNURL(host string) (LnEncodeData, error) {
-	hostUrl := config.Host
-	if !strings.Contains(host, "localhost") {
-		hostUrl = "https://" + host
+// LnurlHostURL is the base URL wallets use to call back into the server
+func LnurlHostURL(host string) string {
+	if strings.Contains(host, "localhost") {
+		return config.Host
 	}
+	return "https://" + host
+}
+
+func EncodeLNURL(host string) (LnEncodeData, error) {
+	hostUrl := LnurlHostURL(host)
 	k1 := generate32Bytes()
 	url := hostUrl + "/" + "lnauth_login?tag=login&k1=" + k1 + "&action=login"
 
@@ -33,6 +39,23 @@ func EncodeLNURL(host string) (LnEncodeData, error) {
 	return LnEncodeData{Encode: encode, K1: k1}, nil
 }
 
+// EncodeLNURLWithdraw creates a one-time LNURL-withdraw code, K1 identifies
+// the payout when a wallet redeems it
+func EncodeLNURLWithdraw(host string) (LnEncodeData, error) {
+	k1 := generate32Bytes()
+	if k1 == "" {
+		return LnEncodeData{}, errors.New("could not generate k1")
+	}
+	url := LnurlHostURL(host) + "/lnurl/withdraw?k1=" + k1
+
+	encode, err := lnurl.Encode(url)
+	if err != nil {
+		return LnEncodeData{}, err
+	}
+
+	return LnEncodeData{Encode: encode, K1: k1}, nil
+}
+
 func generate32Bytes() string {
 	key := make([]byte, 32)
 	_, err := rand.Read(key)
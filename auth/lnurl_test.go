@@ -1,6 +1,7 @@
 package auth
 
 import (
+	lnurl "github.com/fiatjaf/go-lnurl"
 	"github.com/stretchr/testify/assert"
 	"strings"
 	"testing"
@@ -73,3 +74,28 @@ func TestEncodeLNURL(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeLNURLWithdraw(t *testing.T) {
+	t.Run("Encodes the withdraw url with a fresh k1", func(t *testing.T) {
+		result, err := EncodeLNURLWithdraw("example.com")
+		assert.NoError(t, err)
+		assert.Len(t, result.K1, 64)
+
+		decoded, err := lnurl.LNURLDecode(result.Encode)
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/lnurl/withdraw?k1="+result.K1, decoded)
+
+		other, err := EncodeLNURLWithdraw("example.com")
+		assert.NoError(t, err)
+		assert.NotEqual(t, result.K1, other.K1)
+	})
+
+	t.Run("Uses the configured host on localhost", func(t *testing.T) {
+		result, err := EncodeLNURLWithdraw("localhost:5002")
+		assert.NoError(t, err)
+
+		decoded, err := lnurl.LNURLDecode(result.Encode)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasSuffix(decoded, "/lnurl/withdraw?k1="+result.K1))
+	})
+}
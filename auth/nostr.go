@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/patrickmn/go-cache"
+	"github.com/stakwork/sphinx-tribes/logger"
+)
+
+const (
+	// NostrAuthKind is the NIP-42 client authentication event kind, used to
+	// answer a login or link challenge
+	NostrAuthKind = 22242
+	// NostrHTTPAuthKind is the NIP-98 HTTP auth event kind
+	NostrHTTPAuthKind = 27235
+
+	NostrAuthScheme = "Nostr "
+
+	// NostrHTTPAuthWindow is how far a NIP-98 event's created_at may be from now
+	NostrHTTPAuthWindow = 60 * time.Second
+)
+
+// usedNostrEvents remembers accepted NIP-98 events so they cannot be replayed
+var usedNostrEvents = cache.New(2*NostrHTTPAuthWindow, 5*time.Minute)
+
+type NostrEvent struct {
+	ID        string     `json:"id"`
+	Pubkey    string     `json:"pubkey"`
+	CreatedAt int64      `json:"created_at"`
+	Kind      int        `json:"kind"`
+	Tags      [][]string `json:"tags"`
+	Content   string     `json:"content"`
+	Sig       string     `json:"sig"`
+}
+
+// writeNostrString escapes a string the way NIP-01 serializes event ids
+func writeNostrString(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	for _, c := range []byte(s) {
+		switch c {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+}
+
+// Serialize returns [0,pubkey,created_at,kind,tags,content] as defined by NIP-01
+func (e *NostrEvent) Serialize() []byte {
+	var b bytes.Buffer
+	b.WriteString(`[0,`)
+	writeNostrString(&b, e.Pubkey)
+	b.WriteString("," + strconv.FormatInt(e.CreatedAt, 10) + "," + strconv.Itoa(e.Kind) + ",[")
+	for i, tag := range e.Tags {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('[')
+		for j, value := range tag {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			writeNostrString(&b, value)
+		}
+		b.WriteByte(']')
+	}
+	b.WriteString("],")
+	writeNostrString(&b, e.Content)
+	b.WriteByte(']')
+	return b.Bytes()
+}
+
+func (e *NostrEvent) ComputeID() string {
+	sum := sha256.Sum256(e.Serialize())
+	return hex.EncodeToString(sum[:])
+}
+
+// Tag returns the first value of the named tag
+func (e *NostrEvent) Tag(name string) string {
+	for _, tag := range e.Tags {
+		if len(tag) >= 2 && tag[0] == name {
+			return tag[1]
+		}
+	}
+	return ""
+}
+
+// VerifySignature checks the event id and its BIP-340 schnorr signature
+func (e *NostrEvent) VerifySignature() error {
+	id := e.ComputeID()
+	if e.ID != id {
+		return errors.New("nostr event id does not match its content")
+	}
+
+	pubkeyBytes, err := hex.DecodeString(e.Pubkey)
+	if err != nil || len(pubkeyBytes) != 32 {
+		return errors.New("invalid nostr pubkey")
+	}
+	pubkey, err := schnorr.ParsePubKey(pubkeyBytes)
+	if err != nil {
+		return fmt.Errorf("invalid nostr pubkey: %w", err)
+	}
+
+	sigBytes, err := hex.DecodeString(e.Sig)
+	if err != nil {
+		return errors.New("invalid nostr signature")
+	}
+	sig, err := schnorr.ParseSignature(sigBytes)
+	if err != nil {
+		return fmt.Errorf("invalid nostr signature: %w", err)
+	}
+
+	idBytes, _ := hex.DecodeString(id)
+	if !sig.Verify(idBytes, pubkey) {
+		return errors.New("nostr signature verification failed")
+	}
+	return nil
+}
+
+func nostrEventIsFresh(e *NostrEvent, window time.Duration, now time.Time) bool {
+	created := time.Unix(e.CreatedAt, 0)
+	return created.After(now.Add(-window)) && created.Before(now.Add(window))
+}
+
+// VerifyNostrChallengeEvent checks a NIP-42 style event signed in answer to a
+// challenge issued by the server
+func VerifyNostrChallengeEvent(e *NostrEvent, challenge string, window time.Duration) error {
+	if e == nil {
+		return errors.New("nostr event is required")
+	}
+	if e.Kind != NostrAuthKind {
+		return fmt.Errorf("nostr event kind must be %d", NostrAuthKind)
+	}
+	if challenge == "" || e.Tag("challenge") != challenge {
+		return errors.New("nostr event does not answer the challenge")
+	}
+	if !nostrEventIsFresh(e, window, time.Now()) {
+		return errors.New("nostr event is too old")
+	}
+	return e.VerifySignature()
+}
+
+// IsNostrAuthRequest is true when the request carries a NIP-98 Authorization header
+func IsNostrAuthRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Authorization"), NostrAuthScheme)
+}
+
+// requestMatchesNostrURL compares the event's u tag with the request, the
+// scheme is ignored because TLS is usually terminated before the request gets here
+func requestMatchesNostrURL(r *http.Request, u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	return parsed.Host == r.Host && parsed.RequestURI() == r.URL.RequestURI()
+}
+
+// VerifyNostrHTTPAuth checks a NIP-98 Authorization header and returns the
+// nostr pubkey that signed it
+func VerifyNostrHTTPAuth(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, NostrAuthScheme) {
+		return "", errors.New("missing nostr authorization")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(header, NostrAuthScheme)))
+	if err != nil {
+		return "", errors.New("nostr authorization is not valid base64")
+	}
+
+	var event NostrEvent
+	if err := json.Unmarshal(raw, &event); err != nil {
+		return "", errors.New("nostr authorization is not a valid event")
+	}
+
+	if event.Kind != NostrHTTPAuthKind {
+		return "", fmt.Errorf("nostr event kind must be %d", NostrHTTPAuthKind)
+	}
+	if !nostrEventIsFresh(&event, NostrHTTPAuthWindow, time.Now()) {
+		return "", errors.New("nostr event is too old")
+	}
+	if !strings.EqualFold(event.Tag("method"), r.Method) {
+		return "", errors.New("nostr event method does not match the request")
+	}
+	if !requestMatchesNostrURL(r, event.Tag("u")) {
+		return "", errors.New("nostr event url does not match the request")
+	}
+
+	if payload := event.Tag("payload"); payload != "" {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return "", err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		if !strings.EqualFold(payload, hex.EncodeToString(sum[:])) {
+			return "", errors.New("nostr event payload does not match the request body")
+		}
+	}
+
+	if err := event.VerifySignature(); err != nil {
+		return "", err
+	}
+
+	if err := usedNostrEvents.Add(event.ID, true, cache.DefaultExpiration); err != nil {
+		return "", errors.New("nostr event has already been used")
+	}
+
+	return event.Pubkey, nil
+}
+
+func nostrAuthContext(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	nostrPubkey, err := VerifyNostrHTTPAuth(r)
+	if err != nil {
+		logger.Log.Info("[auth] invalid nostr authorization: %v", err)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	ctx := withPubkey(r.Context(), ResolvePubkey(nostrPubkey))
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/stretchr/testify/assert"
+)
+
+func signTestNostrEvent(t *testing.T, key *btcec.PrivateKey, event NostrEvent) NostrEvent {
+	event.Pubkey = hex.EncodeToString(schnorr.SerializePubKey(key.PubKey()))
+	event.ID = event.ComputeID()
+	id, _ := hex.DecodeString(event.ID)
+	sig, err := schnorr.Sign(key, id)
+	assert.NoError(t, err)
+	event.Sig = hex.EncodeToString(sig.Serialize())
+	return event
+}
+
+func nostrAuthHeader(event NostrEvent) string {
+	raw, _ := json.Marshal(event)
+	return NostrAuthScheme + base64.StdEncoding.EncodeToString(raw)
+}
+
+func TestNostrEventSerialize(t *testing.T) {
+	event := NostrEvent{
+		Pubkey:    "abc",
+		CreatedAt: 1700000000,
+		Kind:      1,
+		Tags:      [][]string{{"e", "1"}, {"p", "2", "wss://relay"}},
+		Content:   "line\n\"quoted\" <tag> & \\",
+	}
+
+	assert.Equal(t,
+		`[0,"abc",1700000000,1,[["e","1"],["p","2","wss://relay"]],"line\n\"quoted\" <tag> & \\"]`,
+		string(event.Serialize()))
+
+	event.Tags = nil
+	assert.Equal(t, `[0,"abc",1700000000,1,[],"line\n\"quoted\" <tag> & \\"]`, string(event.Serialize()))
+}
+
+func TestVerifyNostrChallengeEvent(t *testing.T) {
+	key, _ := btcec.NewPrivateKey()
+	newEvent := func(challenge string, createdAt time.Time) NostrEvent {
+		return signTestNostrEvent(t, key, NostrEvent{
+			CreatedAt: createdAt.Unix(),
+			Kind:      NostrAuthKind,
+			Tags:      [][]string{{"relay", "wss://people.sphinx.chat"}, {"challenge", challenge}},
+		})
+	}
+
+	t.Run("should accept a signed answer to the challenge", func(t *testing.T) {
+		event := newEvent("challenge", time.Now())
+		assert.NoError(t, VerifyNostrChallengeEvent(&event, "challenge", time.Minute))
+	})
+
+	t.Run("should reject another challenge", func(t *testing.T) {
+		event := newEvent("other", time.Now())
+		assert.Error(t, VerifyNostrChallengeEvent(&event, "challenge", time.Minute))
+	})
+
+	t.Run("should reject old events", func(t *testing.T) {
+		event := newEvent("challenge", time.Now().Add(-2*time.Minute))
+		assert.Error(t, VerifyNostrChallengeEvent(&event, "challenge", time.Minute))
+	})
+
+	t.Run("should reject tampered events", func(t *testing.T) {
+		event := newEvent("challenge", time.Now())
+		event.Content = "changed"
+		assert.Error(t, VerifyNostrChallengeEvent(&event, "challenge", time.Minute))
+	})
+
+	t.Run("should reject a signature from another key", func(t *testing.T) {
+		event := newEvent("challenge", time.Now())
+		otherKey, _ := btcec.NewPrivateKey()
+		event.Pubkey = hex.EncodeToString(schnorr.SerializePubKey(otherKey.PubKey()))
+		event.ID = event.ComputeID()
+		assert.Error(t, VerifyNostrChallengeEvent(&event, "challenge", time.Minute))
+	})
+
+	t.Run("should reject other kinds", func(t *testing.T) {
+		event := signTestNostrEvent(t, key, NostrEvent{
+			CreatedAt: time.Now().Unix(),
+			Kind:      1,
+			Tags:      [][]string{{"challenge", "challenge"}},
+		})
+		assert.Error(t, VerifyNostrChallengeEvent(&event, "challenge", time.Minute))
+	})
+}
+
+func TestPubKeyContextNostrHTTPAuth(t *testing.T) {
+	key, _ := btcec.NewPrivateKey()
+	nostrPubkey := hex.EncodeToString(schnorr.SerializePubKey(key.PubKey()))
+
+	newRequest := func(method string, target string, tags [][]string) *http.Request {
+		event := signTestNostrEvent(t, key, NostrEvent{
+			CreatedAt: time.Now().Unix(),
+			Kind:      NostrHTTPAuthKind,
+			Tags:      tags,
+		})
+		req := httptest.NewRequest(method, target, strings.NewReader(`{"name":"test"}`))
+		req.Header.Set("Authorization", nostrAuthHeader(event))
+		return req
+	}
+
+	var contextPubkey string
+	handler := PubKeyContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contextPubkey, _ = r.Context().Value(ContextKey).(string)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Run("should authenticate a valid NIP-98 event", func(t *testing.T) {
+		LinkedPubkeyResolver = nil
+		req := newRequest(http.MethodGet, "http://example.com/person?x=1", [][]string{
+			{"u", "https://example.com/person?x=1"}, {"method", "GET"},
+		})
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, nostrPubkey, contextPubkey)
+	})
+
+	t.Run("should resolve a linked nostr key to the person", func(t *testing.T) {
+		LinkedPubkeyResolver = func(pubkey string) string { return "owner_pubkey" }
+		defer func() {
+			LinkedPubkeyResolver = nil
+			ClearLinkedPubkeyCache()
+		}()
+
+		req := newRequest(http.MethodGet, "http://example.com/person", [][]string{
+			{"u", "http://example.com/person"}, {"method", "GET"},
+		})
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "owner_pubkey", contextPubkey)
+	})
+
+	t.Run("should reject a replayed event", func(t *testing.T) {
+		req := newRequest(http.MethodGet, "http://example.com/replay", [][]string{
+			{"u", "http://example.com/replay"}, {"method", "GET"},
+		})
+		header := req.Header.Get("Authorization")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		replay := httptest.NewRequest(http.MethodGet, "http://example.com/replay", nil)
+		replay.Header.Set("Authorization", header)
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, replay)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should reject a mismatched url or method", func(t *testing.T) {
+		req := newRequest(http.MethodPost, "http://example.com/person", [][]string{
+			{"u", "http://example.com/other"}, {"method", "POST"},
+		})
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+		req = newRequest(http.MethodPost, "http://example.com/person", [][]string{
+			{"u", "http://example.com/person"}, {"method", "GET"},
+		})
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should check the payload hash", func(t *testing.T) {
+		req := newRequest(http.MethodPost, "http://example.com/person", [][]string{
+			{"u", "http://example.com/person"}, {"method", "POST"}, {"payload", strings.Repeat("0", 64)},
+		})
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+		req = newRequest(http.MethodPost, "http://example.com/person", [][]string{
+			{"u", "http://example.com/person"}, {"method", "POST"},
+			{"payload", "7d9fd2051fc32b32feab10946fab6bb91426ab7e39aa5439289ed892864aa91d"},
+		})
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("should reject stale events", func(t *testing.T) {
+		event := signTestNostrEvent(t, key, NostrEvent{
+			CreatedAt: time.Now().Add(-5 * time.Minute).Unix(),
+			Kind:      NostrHTTPAuthKind,
+			Tags:      [][]string{{"u", "http://example.com/person"}, {"method", "GET"}},
+		})
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/person", nil)
+		req.Header.Set("Authorization", nostrAuthHeader(event))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
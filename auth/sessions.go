@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/patrickmn/go-cache"
+	"github.com/stakwork/sphinx-tribes/config"
+)
+
+// SessionContextKey holds the session id of the JWT used for the request
+var SessionContextKey = contextKey("session")
+
+// SessionRevoked reports whether a token has been revoked, it is set at startup
+// because sessions live in the database which auth cannot import
+var SessionRevoked func(pubkey string, sessionID string, issuedAt time.Time) bool
+
+// revocationCache keeps revocation lookups off the database for every request,
+// entries are short lived so revocations on other instances apply quickly
+var revocationCache = cache.New(30*time.Second, time.Minute)
+
+// EncodeSessionJwt issues a short lived access token bound to a session
+func EncodeSessionJwt(pubkey string, sessionID string) (string, error) {
+	if pubkey == "" || strings.ContainsAny(pubkey, "!@#$%^&*()") {
+		return "", errors.New("invalid public key")
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"pubkey": pubkey,
+		"iat":    now.Unix(),
+		"exp":    now.Add(time.Duration(config.JwtAccessTokenMinutes) * time.Minute).Unix(),
+	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
+
+	_, tokenString, err := TokenAuth.Encode(claims)
+	if err != nil {
+		return "", err
+	}
+
+	return tokenString, nil
+}
+
+func IsJwtRevoked(claims jwt.MapClaims) bool {
+	if SessionRevoked == nil {
+		return false
+	}
+
+	pubkey := fmt.Sprintf("%v", claims["pubkey"])
+	sessionID, _ := claims["sid"].(string)
+	var issuedAt int64
+	if iat, ok := claims["iat"].(float64); ok {
+		issuedAt = int64(iat)
+	}
+
+	key := fmt.Sprintf("%s:%s:%d", pubkey, sessionID, issuedAt)
+	if revoked, found := revocationCache.Get(key); found {
+		return revoked.(bool)
+	}
+
+	revoked := SessionRevoked(pubkey, sessionID, time.Unix(issuedAt, 0))
+	revocationCache.SetDefault(key, revoked)
+	return revoked
+}
+
+// ClearRevocationCache drops cached lookups so a revocation applies immediately on this instance
+func ClearRevocationCache() {
+	revocationCache.Flush()
+}
+
+// GenerateRefreshToken returns a new refresh token for a session, the session
+// id prefix lets a reused token be traced back to its session
+func GenerateRefreshToken(sessionID string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return sessionID + "." + hex.EncodeToString(b), nil
+}
+
+func ParseRefreshToken(token string) (string, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0], true
+}
+
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeSessionJwt(t *testing.T) {
+	config.InitConfig()
+	InitJwt()
+
+	token, err := EncodeSessionJwt("validPublicKey", "session-id")
+	assert.NoError(t, err)
+
+	claims, err := DecodeJwt(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "validPublicKey", claims["pubkey"])
+	assert.Equal(t, "session-id", claims["sid"])
+	assert.NotNil(t, claims["iat"])
+
+	exp := int64(claims["exp"].(float64))
+	expected := time.Now().Add(time.Duration(config.JwtAccessTokenMinutes) * time.Minute).Unix()
+	assert.InDelta(t, expected, exp, 5)
+
+	_, err = EncodeSessionJwt("invalidPublicKey!", "session-id")
+	assert.Error(t, err)
+}
+
+func TestGenerateRefreshToken(t *testing.T) {
+	token, err := GenerateRefreshToken("session-id")
+	assert.NoError(t, err)
+
+	sessionID, ok := ParseRefreshToken(token)
+	assert.True(t, ok)
+	assert.Equal(t, "session-id", sessionID)
+
+	other, err := GenerateRefreshToken("session-id")
+	assert.NoError(t, err)
+	assert.NotEqual(t, HashRefreshToken(token), HashRefreshToken(other))
+
+	for _, invalid := range []string{"", "nodot", ".secret", "session.", "a.b.c"} {
+		_, ok := ParseRefreshToken(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestPubKeyContextRevokedToken(t *testing.T) {
+	config.InitConfig()
+	InitJwt()
+
+	originalRevoked := SessionRevoked
+	defer func() {
+		SessionRevoked = originalRevoked
+		ClearRevocationCache()
+	}()
+
+	revokedSession := "revoked-session"
+	lookups := 0
+	SessionRevoked = func(pubkey string, sessionID string, issuedAt time.Time) bool {
+		lookups++
+		return sessionID == revokedSession
+	}
+
+	tests := []struct {
+		name           string
+		sessionID      string
+		expectedStatus int
+	}{
+		{"active session", "active-session", http.StatusOK},
+		{"revoked session", revokedSession, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := EncodeSessionJwt("validPublicKey", tt.sessionID)
+			assert.NoError(t, err)
+
+			var sessionID string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sessionID, _ = r.Context().Value(SessionContextKey).(string)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Header.Set("x-jwt", token)
+				rr := httptest.NewRecorder()
+				PubKeyContext(next).ServeHTTP(rr, req)
+
+				assert.Equal(t, tt.expectedStatus, rr.Code)
+			}
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.sessionID, sessionID)
+			}
+		})
+	}
+
+	assert.Equal(t, 2, lookups, "revocation lookups should be cached")
+}
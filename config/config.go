@@ -9,6 +9,7 @@ import (
 	"math/rand"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -48,6 +49,38 @@ var V2BotToken string
 var IsV2Payment bool = false
 var FfWebsocket bool = false
 var SWAuth string
+var WorkspaceRestoreDays int
+var JwtAccessTokenMinutes int
+var SessionDays int
+
+// RateLimitEnabled is off unless RATE_LIMIT_ENABLED=true. Behind a load
+// balancer set RATE_LIMIT_TRUSTED_PROXIES to the number of proxies in front
+// of the server, otherwise every anonymous caller shares the proxy's IP and
+// so its bucket.
+var RateLimitEnabled bool
+var RateLimitTrustedProxies int
+
+var GithubClientID string
+var GithubClientSecret string
+var GithubOAuthAuthorizeURL string
+var GithubOAuthTokenURL string
+var GithubAPIURL string
+var GithubOAuthRedirectURL string
+var GithubOAuthReturnURL string
+var LnurlWithdrawExpiryHours int
+var InvoiceWebhookSecret string
+var InvoiceSettlementFake bool
+var OtelExporter string
+var OtelServiceName string
+var OpsMetricsToken string
+var ErrorReporter string
+var ErrorReportFile string
+var PosthogUrl string
+var PosthogKey string
+var SentryDsn string
+var WebhookSigningSecret string
+var ChatBackend string
+var ChatFixtures string
 
 func InitConfig() {
 	Host = os.Getenv("LN_SERVER_BASE_URL")
@@ -71,6 +104,32 @@ func InitConfig() {
 	FfWebsocket = os.Getenv("FF_WEBSOCKET") == "true"
 	LogLevel = strings.ToUpper(os.Getenv("LOG_LEVEL"))
 	SWAuth = os.Getenv("SWAUTH")
+	WorkspaceRestoreDays, _ = strconv.Atoi(os.Getenv("WORKSPACE_RESTORE_DAYS"))
+	JwtAccessTokenMinutes, _ = strconv.Atoi(os.Getenv("JWT_ACCESS_TOKEN_MINUTES"))
+	SessionDays, _ = strconv.Atoi(os.Getenv("SESSION_DAYS"))
+	RateLimitEnabled = os.Getenv("RATE_LIMIT_ENABLED") == "true"
+	RateLimitTrustedProxies, _ = strconv.Atoi(os.Getenv("RATE_LIMIT_TRUSTED_PROXIES"))
+	GithubClientID = os.Getenv("GITHUB_CLIENT_ID")
+	GithubClientSecret = os.Getenv("GITHUB_CLIENT_SECRET")
+	GithubOAuthAuthorizeURL = os.Getenv("GITHUB_OAUTH_AUTHORIZE_URL")
+	GithubOAuthTokenURL = os.Getenv("GITHUB_OAUTH_TOKEN_URL")
+	GithubAPIURL = os.Getenv("GITHUB_API_URL")
+	GithubOAuthRedirectURL = os.Getenv("GITHUB_OAUTH_REDIRECT_URL")
+	GithubOAuthReturnURL = os.Getenv("GITHUB_OAUTH_RETURN_URL")
+	LnurlWithdrawExpiryHours, _ = strconv.Atoi(os.Getenv("LNURL_WITHDRAW_EXPIRY_HOURS"))
+	InvoiceWebhookSecret = os.Getenv("INVOICE_WEBHOOK_SECRET")
+	InvoiceSettlementFake = os.Getenv("INVOICE_SETTLEMENT_FAKE") == "true"
+	OtelExporter = strings.ToLower(os.Getenv("OTEL_EXPORTER"))
+	OtelServiceName = os.Getenv("OTEL_SERVICE_NAME")
+	OpsMetricsToken = os.Getenv("OPS_METRICS_TOKEN")
+	ErrorReporter = strings.ToLower(os.Getenv("ERROR_REPORTER"))
+	ErrorReportFile = os.Getenv("ERROR_REPORT_FILE")
+	PosthogUrl = os.Getenv("POSTHOG_URL")
+	PosthogKey = os.Getenv("POSTHOG_KEY")
+	SentryDsn = os.Getenv("SENTRY_DSN")
+	WebhookSigningSecret = os.Getenv("WEBHOOK_SIGNING_SECRET")
+	ChatBackend = strings.ToLower(os.Getenv("CHAT_BACKEND"))
+	ChatFixtures = os.Getenv("CHAT_FIXTURES")
 
 	// Add to super admins
 	SuperAdmins = StripSuperAdmins(AdminStrings)
@@ -147,6 +206,62 @@ func InitConfig() {
 	if LogLevel == "" {
 		LogLevel = "DEBUG"
 	}
+
+	if WorkspaceRestoreDays <= 0 {
+		WorkspaceRestoreDays = 30
+	}
+
+	if JwtAccessTokenMinutes <= 0 {
+		JwtAccessTokenMinutes = 15
+	}
+
+	if SessionDays <= 0 {
+		SessionDays = 30
+	}
+
+	if LnurlWithdrawExpiryHours <= 0 {
+		LnurlWithdrawExpiryHours = 72
+	}
+
+	if GithubOAuthAuthorizeURL == "" {
+		GithubOAuthAuthorizeURL = "https://github.com/login/oauth/authorize"
+	}
+
+	if GithubOAuthTokenURL == "" {
+		GithubOAuthTokenURL = "https://github.com/login/oauth/access_token"
+	}
+
+	if GithubAPIURL == "" {
+		GithubAPIURL = "https://api.github.com"
+	}
+
+	if GithubOAuthRedirectURL == "" {
+		GithubOAuthRedirectURL = Host + "/github/oauth/callback"
+	}
+
+	if GithubOAuthReturnURL == "" {
+		GithubOAuthReturnURL = Host
+	}
+
+	if OtelExporter == "" {
+		OtelExporter = "none"
+	}
+
+	if OtelServiceName == "" {
+		OtelServiceName = "sphinx-tribes"
+	}
+
+	// errors went to Jarvis before the reporter could be picked
+	if ErrorReporter == "" {
+		ErrorReporter = "none"
+		if JarvisUrl != "" && JarvisToken != "" {
+			ErrorReporter = "jarvis"
+		}
+	}
+
+	if ErrorReportFile == "" {
+		ErrorReportFile = "error_reports.jsonl"
+	}
 }
 
 func StripSuperAdmins(adminStrings string) []string {
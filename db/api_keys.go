@@ -0,0 +1,71 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (db database) CreateWorkspaceAPIKey(key *WorkspaceAPIKey) error {
+	if key.WorkspaceUuid == "" {
+		return errors.New("workspace uuid is required")
+	}
+	if key.KeyHash == "" {
+		return errors.New("key hash is required")
+	}
+
+	if key.ID == uuid.Nil {
+		key.ID = uuid.New()
+	}
+	now := time.Now()
+	key.CreatedAt = now
+	key.UpdatedAt = now
+
+	if err := db.db.Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+func (db database) GetWorkspaceAPIKeys(workspace_uuid string) ([]WorkspaceAPIKey, error) {
+	var keys []WorkspaceAPIKey
+	if err := db.db.Where("workspace_uuid = ?", workspace_uuid).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (db database) GetWorkspaceAPIKeyByHash(hash string) (WorkspaceAPIKey, error) {
+	var key WorkspaceAPIKey
+	if err := db.db.Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return key, errors.New("api key not found")
+		}
+		return key, fmt.Errorf("failed to fetch api key: %w", err)
+	}
+	return key, nil
+}
+
+func (db database) RevokeWorkspaceAPIKey(workspace_uuid string, id string) error {
+	now := time.Now()
+	result := db.db.Model(&WorkspaceAPIKey{}).
+		Where("id = ? AND workspace_uuid = ? AND revoked_at IS NULL", id, workspace_uuid).
+		Updates(map[string]interface{}{"revoked_at": now, "updated_at": now})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke api key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("api key not found")
+	}
+	return nil
+}
+
+func (db database) UpdateWorkspaceAPIKeyLastUsed(id string, lastUsed time.Time) error {
+	if err := db.db.Model(&WorkspaceAPIKey{}).Where("id = ?", id).Update("last_used_at", lastUsed).Error; err != nil {
+		return fmt.Errorf("failed to update api key last used: %w", err)
+	}
+	return nil
+}
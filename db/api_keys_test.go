@@ -0,0 +1,50 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkspaceAPIKeys(t *testing.T) {
+	InitTestDB()
+	defer CloseTestDB()
+
+	workspaceUuid := uuid.New().String()
+	key := WorkspaceAPIKey{
+		WorkspaceUuid: workspaceUuid,
+		Name:          "ci",
+		KeyHash:       uuid.New().String(),
+		Scopes:        []string{"tickets:write"},
+		CreatedBy:     "test_api_key_owner",
+	}
+
+	assert.NoError(t, TestDB.CreateWorkspaceAPIKey(&key))
+	assert.NotEqual(t, uuid.Nil, key.ID)
+
+	found, err := TestDB.GetWorkspaceAPIKeyByHash(key.KeyHash)
+	assert.NoError(t, err)
+	assert.Equal(t, key.ID, found.ID)
+	assert.Equal(t, []string{"tickets:write"}, []string(found.Scopes))
+
+	_, err = TestDB.GetWorkspaceAPIKeyByHash("missing")
+	assert.Error(t, err)
+
+	now := time.Now()
+	assert.NoError(t, TestDB.UpdateWorkspaceAPIKeyLastUsed(key.ID.String(), now))
+
+	keys, err := TestDB.GetWorkspaceAPIKeys(workspaceUuid)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].LastUsedAt)
+
+	assert.Error(t, TestDB.RevokeWorkspaceAPIKey(uuid.New().String(), key.ID.String()))
+	assert.NoError(t, TestDB.RevokeWorkspaceAPIKey(workspaceUuid, key.ID.String()))
+	assert.Error(t, TestDB.RevokeWorkspaceAPIKey(workspaceUuid, key.ID.String()))
+
+	revoked, err := TestDB.GetWorkspaceAPIKeyByHash(key.KeyHash)
+	assert.NoError(t, err)
+	assert.NotNil(t, revoked.RevokedAt)
+}
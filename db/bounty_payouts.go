@@ -0,0 +1,141 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateBountyPayout stores a new LNURL-withdraw payout and marks the bounty
+// payment as pending so it cannot be paid twice
+func (db database) CreateBountyPayout(payout *BountyPayout) error {
+	if payout.K1 == "" || payout.BountyID == 0 || payout.WorkspaceUuid == "" || payout.Amount == 0 {
+		return errors.New("k1, bounty, workspace and amount are required")
+	}
+
+	if payout.ID == uuid.Nil {
+		payout.ID = uuid.New()
+	}
+	payout.Status = PayoutPending
+	payout.CreatedAt = time.Now()
+
+	return db.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(payout).Error; err != nil {
+			return fmt.Errorf("failed to create bounty payout: %w", err)
+		}
+		if err := tx.Model(&NewBounty{}).Where("id = ?", payout.BountyID).Update("payment_pending", true).Error; err != nil {
+			return fmt.Errorf("failed to update bounty: %w", err)
+		}
+		return nil
+	})
+}
+
+func (db database) GetBountyPayoutByK1(k1 string) (BountyPayout, error) {
+	var payout BountyPayout
+	if err := db.db.Where("k1 = ?", k1).First(&payout).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return payout, errors.New("bounty payout not found")
+		}
+		return payout, fmt.Errorf("failed to fetch bounty payout: %w", err)
+	}
+	return payout, nil
+}
+
+// GetBountyPayout returns the latest payout created for a bounty
+func (db database) GetBountyPayout(bountyID uint) (BountyPayout, error) {
+	var payout BountyPayout
+	if err := db.db.Where("bounty_id = ?", bountyID).Order("created_at DESC").First(&payout).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return payout, errors.New("bounty payout not found")
+		}
+		return payout, fmt.Errorf("failed to fetch bounty payout: %w", err)
+	}
+	return payout, nil
+}
+
+// GetWorkspaceReservedPayouts sums the payouts that can still be redeemed,
+// that part of the budget is not available for other payments
+func (db database) GetWorkspaceReservedPayouts(workspaceUuid string) uint {
+	var total uint
+	db.db.Model(&BountyPayout{}).
+		Where("workspace_uuid = ? AND status IN ?", workspaceUuid, []string{PayoutPending, PayoutRedeeming}).
+		Select("COALESCE(SUM(amount), 0)").
+		Row().Scan(&total)
+	return total
+}
+
+// ClaimBountyPayout moves an unexpired pending payout to redeeming, only one
+// wallet callback can claim a payout at a time. The payment hash is kept to
+// look the payment up on our node later.
+func (db database) ClaimBountyPayout(k1 string, paymentRequest string, paymentHash string, now time.Time) error {
+	result := db.db.Model(&BountyPayout{}).
+		Where("k1 = ? AND status = ? AND expires_at > ?", k1, PayoutPending, now).
+		Updates(map[string]interface{}{
+			"status":          PayoutRedeeming,
+			"payment_request": paymentRequest,
+			"payment_hash":    paymentHash,
+			"error":           "",
+			"claimed_at":      now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to claim bounty payout: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("bounty payout is not redeemable")
+	}
+	return nil
+}
+
+func (db database) UpdateBountyPayoutStatus(id uuid.UUID, status string, errMsg string) error {
+	updates := map[string]interface{}{
+		"status": status,
+		"error":  errMsg,
+	}
+	if status == PayoutRedeemed {
+		updates["redeemed_at"] = time.Now()
+	}
+	if err := db.db.Model(&BountyPayout{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update bounty payout: %w", err)
+	}
+	return nil
+}
+
+// GetClaimedBountyPayouts returns the payouts claimed by a wallet before the
+// given time whose payment never reported back, their budget stays reserved
+// until they are reconciled
+func (db database) GetClaimedBountyPayouts(before time.Time) ([]BountyPayout, error) {
+	var payouts []BountyPayout
+	if err := db.db.Where("status = ? AND (claimed_at IS NULL OR claimed_at <= ?)", PayoutRedeeming, before).Find(&payouts).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch claimed bounty payouts: %w", err)
+	}
+	return payouts, nil
+}
+
+// ExpireBountyPayouts expires pending payouts that were not redeemed in time,
+// which releases the reserved budget and lets the bounty be paid again
+func (db database) ExpireBountyPayouts(now time.Time) ([]BountyPayout, error) {
+	var payouts []BountyPayout
+
+	err := db.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("status = ? AND expires_at <= ?", PayoutPending, now).Find(&payouts).Error; err != nil {
+			return fmt.Errorf("failed to fetch expired bounty payouts: %w", err)
+		}
+
+		for _, payout := range payouts {
+			if err := tx.Model(&BountyPayout{}).Where("id = ?", payout.ID).Update("status", PayoutExpired).Error; err != nil {
+				return fmt.Errorf("failed to expire bounty payout: %w", err)
+			}
+			if err := tx.Model(&NewBounty{}).Where("id = ? AND paid = false", payout.BountyID).Update("payment_pending", false).Error; err != nil {
+				return fmt.Errorf("failed to update bounty: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payouts, nil
+}
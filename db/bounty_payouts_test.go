@@ -0,0 +1,64 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBountyPayouts(t *testing.T) {
+	InitTestDB()
+	defer CloseTestDB()
+
+	workspaceUuid := uuid.New().String()
+	bounty, err := TestDB.CreateOrEditBounty(NewBounty{
+		Type:          "coding",
+		Title:         "payout bounty",
+		Description:   "payout bounty",
+		OwnerID:       "payout_owner",
+		Assignee:      "payout_hunter",
+		Price:         1000,
+		WorkspaceUuid: workspaceUuid,
+		Created:       time.Now().UnixNano(),
+	})
+	assert.NoError(t, err)
+
+	payout := BountyPayout{
+		K1:             uuid.New().String(),
+		BountyID:       bounty.ID,
+		WorkspaceUuid:  workspaceUuid,
+		ReceiverPubKey: "payout_hunter",
+		Amount:         1000,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+	assert.NoError(t, TestDB.CreateBountyPayout(&payout))
+	assert.True(t, TestDB.GetBounty(bounty.ID).PaymentPending)
+	assert.Equal(t, uint(1000), TestDB.GetWorkspaceReservedPayouts(workspaceUuid))
+
+	found, err := TestDB.GetBountyPayout(bounty.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, payout.ID, found.ID)
+
+	assert.NoError(t, TestDB.ClaimBountyPayout(payout.K1, "pr", "hash", time.Now()))
+	assert.Error(t, TestDB.ClaimBountyPayout(payout.K1, "pr", "hash", time.Now()))
+
+	claimed, err := TestDB.GetClaimedBountyPayouts(time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	claimed, err = TestDB.GetClaimedBountyPayouts(time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.Empty(t, claimed, "payouts claimed since are still being paid")
+
+	assert.NoError(t, TestDB.UpdateBountyPayoutStatus(payout.ID, PayoutPending, "failed"))
+	expired, err := TestDB.ExpireBountyPayouts(time.Now().Add(2 * time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, expired, 1)
+
+	found, err = TestDB.GetBountyPayoutByK1(payout.K1)
+	assert.NoError(t, err)
+	assert.Equal(t, PayoutExpired, found.Status)
+	assert.False(t, TestDB.GetBounty(bounty.ID).PaymentPending)
+	assert.Equal(t, uint(0), TestDB.GetWorkspaceReservedPayouts(workspaceUuid))
+}
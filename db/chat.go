@@ -67,6 +67,9 @@ func (db database) GetChatByChatID(chatID string) (Chat, error) {
 	return chat, nil
 }
 
+// AddChatMessage adds a message to its chat. A message without a parent
+// follows the end of the active branch, and a message following the end of
+// the active branch becomes its new end.
 func (db database) AddChatMessage(chatMessage *ChatMessage) (ChatMessage, error) {
 	if chatMessage.ID == "" {
 		return ChatMessage{}, errors.New("message ID is required")
@@ -75,7 +78,28 @@ func (db database) AddChatMessage(chatMessage *ChatMessage) (ChatMessage, error)
 	now := time.Now()
 	chatMessage.Timestamp = now
 
-	if err := db.db.Create(&chatMessage).Error; err != nil {
+	err := db.db.Transaction(func(tx *gorm.DB) error {
+		chat, err := lockChat(tx, chatMessage.ChatID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// the messages of a chat that does not exist are not threaded
+			return tx.Create(chatMessage).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if chatMessage.ParentID == "" {
+			chatMessage.ParentID = chat.ActiveMessageID
+		}
+		if err := tx.Create(chatMessage).Error; err != nil {
+			return err
+		}
+		if chatMessage.ParentID == chat.ActiveMessageID {
+			return setActiveChatMessage(tx, chat.ID, chatMessage.ID)
+		}
+		return nil
+	})
+	if err != nil {
 		return ChatMessage{}, fmt.Errorf("failed to create chat message: %w", err)
 	}
 
@@ -111,6 +135,136 @@ func (db database) UpdateChatMessage(chatMessage *ChatMessage) (ChatMessage, err
 	return existingMessage, nil
 }
 
+var (
+	ErrMessageNotStreaming = errors.New("message is not streaming")
+	ErrChunkOutOfOrder     = errors.New("chunk is ahead of the stream")
+	ErrChatMessageNotFound = errors.New("message not found in chat")
+)
+
+// GetChatMessageStream returns the reply streamed to a message of a chat
+func (db database) GetChatMessageStream(chatID string, parentID string) (ChatMessage, error) {
+	var reply ChatMessage
+	err := db.db.Where("chat_id = ? AND stream_parent_id = ?", chatID, parentID).First(&reply).Error
+	if err != nil {
+		return ChatMessage{}, fmt.Errorf("failed to fetch reply to message %s: %w", parentID, err)
+	}
+	return reply, nil
+}
+
+// StartChatMessageStream creates the reply streamed to a message of a chat,
+// or returns the one created before and false. The chat stays locked while
+// the reply is looked up, so chunks arriving together on several instances
+// create it once, and the unique stream parent backs that up.
+func (db database) StartChatMessageStream(reply *ChatMessage) (ChatMessage, bool, error) {
+	if reply.ID == "" || reply.ParentID == "" {
+		return ChatMessage{}, false, errors.New("message ID and parent ID are required")
+	}
+
+	var existing ChatMessage
+	created := false
+
+	err := db.db.Transaction(func(tx *gorm.DB) error {
+		chat, err := lockChat(tx, reply.ChatID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("chat %s: %w", reply.ChatID, ErrChatMessageNotFound)
+		}
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&ChatMessage{}).Where("id = ? AND chat_id = ?", reply.ParentID, reply.ChatID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to fetch parent message: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("message %s of chat %s: %w", reply.ParentID, reply.ChatID, ErrChatMessageNotFound)
+		}
+
+		err = tx.Where("stream_parent_id = ?", reply.ParentID).First(&existing).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to fetch streamed reply: %w", err)
+		}
+
+		parentID := reply.ParentID
+		reply.StreamParentID = &parentID
+		reply.Timestamp = time.Now()
+		if err := tx.Create(reply).Error; err != nil {
+			return fmt.Errorf("failed to create chat message: %w", err)
+		}
+		existing = *reply
+		created = true
+
+		if reply.ParentID == chat.ActiveMessageID {
+			return setActiveChatMessage(tx, chat.ID, reply.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return ChatMessage{}, false, err
+	}
+
+	return existing, created, nil
+}
+
+// AppendChatMessageChunk appends the chunk at index to a streaming message.
+// Chunks are appended in order and once, it returns false for a chunk that
+// was appended before and ErrChunkOutOfOrder for one whose predecessors
+// did not arrive yet.
+func (db database) AppendChatMessageChunk(id string, index int, chunk string) (bool, error) {
+	result := db.db.Model(&ChatMessage{}).
+		Where("id = ? AND status = ? AND stream_chunks = ?", id, SendingStatus, index).
+		Updates(map[string]interface{}{
+			"message":       gorm.Expr("message || ?", chunk),
+			"stream_chunks": gorm.Expr("stream_chunks + 1"),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to append chunk to chat message: %w", result.Error)
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+
+	var message ChatMessage
+	if err := db.db.First(&message, "id = ?", id).Error; err != nil {
+		return false, fmt.Errorf("message not found: %w", err)
+	}
+	if index < message.StreamChunks {
+		return false, nil
+	}
+	if message.Status != SendingStatus {
+		return false, ErrMessageNotStreaming
+	}
+	return false, ErrChunkOutOfOrder
+}
+
+// FinishChatMessageStream marks a streaming message as sent, a non empty
+// text replaces the streamed one
+func (db database) FinishChatMessageStream(id string, text string) (ChatMessage, error) {
+	updates := map[string]interface{}{"status": SentStatus}
+	if text != "" {
+		updates["message"] = text
+	}
+
+	result := db.db.Model(&ChatMessage{}).
+		Where("id = ? AND status = ?", id, SendingStatus).
+		Updates(updates)
+	if result.Error != nil {
+		return ChatMessage{}, fmt.Errorf("failed to finish chat message stream: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ChatMessage{}, ErrMessageNotStreaming
+	}
+
+	var message ChatMessage
+	if err := db.db.First(&message, "id = ?", id).Error; err != nil {
+		return ChatMessage{}, fmt.Errorf("message not found: %w", err)
+	}
+	return message, nil
+}
+
 func (db database) GetChatMessagesForChatID(chatID string) ([]ChatMessage, error) {
 	var chatMessages []ChatMessage
 
@@ -295,6 +449,7 @@ func (db database) CreateOrEditChatWorkflow(workflow *ChatWorkflow) (*ChatWorkfl
 
 	existing.URL = workflow.URL
 	existing.StackworkID = workflow.StackworkID
+	existing.Backend = workflow.Backend
 	existing.UpdatedAt = now
 
 	if err := db.db.Save(&existing).Error; err != nil {
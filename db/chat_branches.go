@@ -0,0 +1,277 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const chatBranchPreviewLength = 100
+
+// ChatBranchPath returns the messages from the start of the chat to the
+// given message, following the parents
+func ChatBranchPath(messages []ChatMessage, leafID string) []ChatMessage {
+	byID := make(map[string]ChatMessage, len(messages))
+	for _, message := range messages {
+		byID[message.ID] = message
+	}
+
+	path := []ChatMessage{}
+	for id := leafID; id != ""; {
+		message, ok := byID[id]
+		if !ok {
+			break
+		}
+		// a message is only visited once, even if the parents loop
+		delete(byID, id)
+		path = append(path, message)
+		id = message.ParentID
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// chatMessageChildren groups the messages by their parent, oldest first
+func chatMessageChildren(messages []ChatMessage) map[string][]ChatMessage {
+	children := make(map[string][]ChatMessage)
+	for _, message := range messages {
+		children[message.ParentID] = append(children[message.ParentID], message)
+	}
+	for _, siblings := range children {
+		sort.SliceStable(siblings, func(i, j int) bool {
+			return siblings[i].Timestamp.Before(siblings[j].Timestamp)
+		})
+	}
+	return children
+}
+
+func chatBranchLeaf(children map[string][]ChatMessage, fromID string) string {
+	seen := map[string]bool{}
+	id := fromID
+	for !seen[id] {
+		seen[id] = true
+		next := children[id]
+		if len(next) == 0 {
+			break
+		}
+		id = next[len(next)-1].ID
+	}
+	return id
+}
+
+// ChatBranchLeaf follows the latest message after each message, from the
+// given one to the end of its branch
+func ChatBranchLeaf(messages []ChatMessage, fromID string) string {
+	return chatBranchLeaf(chatMessageChildren(messages), fromID)
+}
+
+// ChatForks lists the messages followed by more than one branch, in the
+// order of the conversation. Chats that were never threaded have none.
+func ChatForks(messages []ChatMessage, activeID string) []ChatFork {
+	forks := []ChatFork{}
+	if activeID == "" {
+		return forks
+	}
+
+	active := map[string]bool{}
+	for _, message := range ChatBranchPath(messages, activeID) {
+		active[message.ID] = true
+	}
+
+	children := chatMessageChildren(messages)
+	parents := []string{""}
+	for _, message := range messages {
+		parents = append(parents, message.ID)
+	}
+
+	for _, parent := range parents {
+		siblings := children[parent]
+		if len(siblings) < 2 {
+			continue
+		}
+
+		fork := ChatFork{ParentID: parent}
+		for _, message := range siblings {
+			preview := []rune(message.Message)
+			if len(preview) > chatBranchPreviewLength {
+				preview = preview[:chatBranchPreviewLength]
+			}
+			fork.Branches = append(fork.Branches, ChatBranch{
+				MessageID: message.ID,
+				Role:      message.Role,
+				Preview:   string(preview),
+				LeafID:    chatBranchLeaf(children, message.ID),
+				Active:    active[message.ID],
+				CreatedAt: message.Timestamp,
+			})
+		}
+		forks = append(forks, fork)
+	}
+	return forks
+}
+
+// lockChat locks the chat for the rest of the transaction. The messages of
+// a chat that was never threaded are chained in the order they were sent
+// first, the last of them becoming the end of the active branch.
+func lockChat(tx *gorm.DB, chatID string) (Chat, error) {
+	var chat Chat
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", chatID).First(&chat).Error; err != nil {
+		return Chat{}, err
+	}
+	if chat.ActiveMessageID != "" {
+		return chat, nil
+	}
+
+	var messages []ChatMessage
+	if err := tx.Where("chat_id = ?", chatID).Order("timestamp ASC, id ASC").Find(&messages).Error; err != nil {
+		return Chat{}, fmt.Errorf("failed to fetch chat messages: %w", err)
+	}
+	if len(messages) == 0 {
+		return chat, nil
+	}
+
+	for i := 1; i < len(messages); i++ {
+		if messages[i].ParentID != "" {
+			continue
+		}
+		if err := tx.Model(&ChatMessage{}).Where("id = ?", messages[i].ID).Update("parent_id", messages[i-1].ID).Error; err != nil {
+			return Chat{}, fmt.Errorf("failed to thread chat messages: %w", err)
+		}
+	}
+
+	chat.ActiveMessageID = messages[len(messages)-1].ID
+	if err := setActiveChatMessage(tx, chatID, chat.ActiveMessageID); err != nil {
+		return Chat{}, err
+	}
+	return chat, nil
+}
+
+func setActiveChatMessage(tx *gorm.DB, chatID string, messageID string) error {
+	if err := tx.Model(&Chat{}).Where("id = ?", chatID).Update("active_message_id", messageID).Error; err != nil {
+		return fmt.Errorf("failed to update active branch: %w", err)
+	}
+	return nil
+}
+
+// GetChatBranch returns the messages of the branch ending at leafID, or of
+// the active branch when leafID is empty. A chat that was never threaded is
+// a single branch of all its messages, it is threaded first when a branch
+// to one of its messages is asked for.
+func (db database) GetChatBranch(chatID string, leafID string) ([]ChatMessage, error) {
+	var chat Chat
+	if err := db.db.Where("id = ?", chatID).First(&chat).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to fetch chat: %w", err)
+	}
+
+	if chat.ID != "" && chat.ActiveMessageID == "" && leafID != "" {
+		err := db.db.Transaction(func(tx *gorm.DB) error {
+			_, err := lockChat(tx, chatID)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	messages, err := db.GetChatMessagesForChatID(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	if leafID == "" {
+		leafID = chat.ActiveMessageID
+	}
+	if leafID == "" {
+		return messages, nil
+	}
+
+	branch := ChatBranchPath(messages, leafID)
+	if len(branch) == 0 {
+		return nil, fmt.Errorf("message %s of chat %s: %w", leafID, chatID, ErrChatMessageNotFound)
+	}
+	return branch, nil
+}
+
+// AddChatMessageBranch adds an edited message next to the one it replaces,
+// under the same parent, and makes it the active branch of the chat
+func (db database) AddChatMessageBranch(chatMessage *ChatMessage) (ChatMessage, error) {
+	if chatMessage.ID == "" {
+		return ChatMessage{}, errors.New("message ID is required")
+	}
+	chatMessage.Timestamp = time.Now()
+
+	err := db.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockChat(tx, chatMessage.ChatID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("chat %s not found: %w", chatMessage.ChatID, err)
+			}
+			return err
+		}
+
+		if chatMessage.ParentID != "" {
+			var count int64
+			if err := tx.Model(&ChatMessage{}).Where("id = ? AND chat_id = ?", chatMessage.ParentID, chatMessage.ChatID).Count(&count).Error; err != nil {
+				return fmt.Errorf("failed to fetch parent message: %w", err)
+			}
+			if count == 0 {
+				return fmt.Errorf("message %s of chat %s: %w", chatMessage.ParentID, chatMessage.ChatID, ErrChatMessageNotFound)
+			}
+		}
+
+		if err := tx.Create(chatMessage).Error; err != nil {
+			return fmt.Errorf("failed to create chat message: %w", err)
+		}
+		return setActiveChatMessage(tx, chatMessage.ChatID, chatMessage.ID)
+	})
+	if err != nil {
+		return ChatMessage{}, err
+	}
+	return *chatMessage, nil
+}
+
+// SwitchChatBranch shows the branch going through the given message, down
+// to the latest end of it, and returns that last message
+func (db database) SwitchChatBranch(chatID string, messageID string) (ChatMessage, error) {
+	var leaf ChatMessage
+
+	err := db.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockChat(tx, chatID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("chat %s not found: %w", chatID, err)
+			}
+			return err
+		}
+
+		var messages []ChatMessage
+		if err := tx.Where("chat_id = ?", chatID).Order("timestamp ASC").Find(&messages).Error; err != nil {
+			return fmt.Errorf("failed to fetch chat messages: %w", err)
+		}
+
+		found := false
+		for _, message := range messages {
+			found = found || message.ID == messageID
+		}
+		if !found {
+			return fmt.Errorf("message %s of chat %s: %w", messageID, chatID, ErrChatMessageNotFound)
+		}
+
+		leafID := ChatBranchLeaf(messages, messageID)
+		for _, message := range messages {
+			if message.ID == leafID {
+				leaf = message
+			}
+		}
+		return setActiveChatMessage(tx, chatID, leafID)
+	})
+	if err != nil {
+		return ChatMessage{}, err
+	}
+	return leaf, nil
+}
@@ -0,0 +1,207 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testChatTree is a chat whose first question was edited once, the edited
+// question having been answered twice
+//
+//	q1 - a1 - q2 - a2
+//	q1'- a1'
+//	   \ a1''
+func testChatTree() []ChatMessage {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	return []ChatMessage{
+		{ID: "q1", Message: "first question", Role: UserRole, Timestamp: at(0)},
+		{ID: "a1", ParentID: "q1", Role: AssistantRole, Timestamp: at(1)},
+		{ID: "q2", ParentID: "a1", Role: UserRole, Timestamp: at(2)},
+		{ID: "a2", ParentID: "q2", Role: AssistantRole, Timestamp: at(3)},
+		{ID: "q1-edit", Message: "first question, edited", Role: UserRole, Timestamp: at(4)},
+		{ID: "a1-edit", ParentID: "q1-edit", Role: AssistantRole, Timestamp: at(5)},
+		{ID: "a1-retry", ParentID: "q1-edit", Role: AssistantRole, Timestamp: at(6)},
+	}
+}
+
+func messageIDs(messages []ChatMessage) []string {
+	ids := []string{}
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
+
+func TestChatBranchPath(t *testing.T) {
+	messages := testChatTree()
+
+	assert.Equal(t, []string{"q1", "a1", "q2", "a2"}, messageIDs(ChatBranchPath(messages, "a2")))
+	assert.Equal(t, []string{"q1-edit", "a1-edit"}, messageIDs(ChatBranchPath(messages, "a1-edit")))
+	assert.Empty(t, ChatBranchPath(messages, "unknown"))
+
+	looping := []ChatMessage{{ID: "a", ParentID: "b"}, {ID: "b", ParentID: "a"}}
+	assert.Equal(t, []string{"a", "b"}, messageIDs(ChatBranchPath(looping, "b")))
+}
+
+func TestChatBranchLeaf(t *testing.T) {
+	messages := testChatTree()
+
+	assert.Equal(t, "a2", ChatBranchLeaf(messages, "q1"))
+	assert.Equal(t, "a1-retry", ChatBranchLeaf(messages, "q1-edit"))
+	assert.Equal(t, "a2", ChatBranchLeaf(messages, "a2"))
+}
+
+func TestChatForks(t *testing.T) {
+	messages := testChatTree()
+
+	forks := ChatForks(messages, "a1-edit")
+	require.Len(t, forks, 2)
+
+	assert.Equal(t, "", forks[0].ParentID)
+	require.Len(t, forks[0].Branches, 2)
+	assert.Equal(t, "q1", forks[0].Branches[0].MessageID)
+	assert.Equal(t, "a2", forks[0].Branches[0].LeafID)
+	assert.Equal(t, "first question", forks[0].Branches[0].Preview)
+	assert.False(t, forks[0].Branches[0].Active)
+	assert.Equal(t, "q1-edit", forks[0].Branches[1].MessageID)
+	assert.True(t, forks[0].Branches[1].Active)
+
+	assert.Equal(t, "q1-edit", forks[1].ParentID)
+	assert.Equal(t, []bool{true, false}, []bool{forks[1].Branches[0].Active, forks[1].Branches[1].Active})
+
+	assert.Empty(t, ChatForks(messages, ""), "chats that were never threaded have no forks")
+}
+
+func TestChatMessageBranches(t *testing.T) {
+	InitTestDB()
+	DeleteAllChatMessages()
+	DeleteAllChats()
+
+	chat := Chat{ID: "branching-chat", WorkspaceID: "workspace", Title: "Branches", Status: ActiveStatus}
+	TestDB.db.Create(&chat)
+
+	// a chat from before branching, its messages have no parents
+	legacy := ChatMessage{ID: "legacy", ChatID: chat.ID, Message: "legacy", Role: UserRole, Timestamp: time.Now().Add(-time.Hour)}
+	TestDB.db.Create(&legacy)
+
+	add := func(message ChatMessage) ChatMessage {
+		created, err := TestDB.AddChatMessage(&message)
+		require.NoError(t, err)
+		return created
+	}
+
+	q1 := add(ChatMessage{ID: "q1", ChatID: chat.ID, Message: "question", Role: UserRole})
+	assert.Equal(t, "legacy", q1.ParentID, "a message follows the end of the active branch")
+	add(ChatMessage{ID: "a1", ChatID: chat.ID, ParentID: "q1", Role: AssistantRole})
+
+	branch, err := TestDB.GetChatBranch(chat.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"legacy", "q1", "a1"}, messageIDs(branch))
+
+	t.Run("an edited message starts the active branch", func(t *testing.T) {
+		_, err := TestDB.AddChatMessageBranch(&ChatMessage{ID: "q1-edit", ChatID: chat.ID, ParentID: "legacy", Role: UserRole})
+		require.NoError(t, err)
+
+		// the late reply to the replaced question stays on its own branch
+		add(ChatMessage{ID: "a1-late", ChatID: chat.ID, ParentID: "a1", Role: AssistantRole})
+
+		branch, err := TestDB.GetChatBranch(chat.ID, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"legacy", "q1-edit"}, messageIDs(branch))
+	})
+
+	t.Run("switching branches goes to the latest message of the branch", func(t *testing.T) {
+		leaf, err := TestDB.SwitchChatBranch(chat.ID, "q1")
+		require.NoError(t, err)
+		assert.Equal(t, "a1-late", leaf.ID)
+
+		updated, err := TestDB.GetChatByChatID(chat.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "a1-late", updated.ActiveMessageID)
+	})
+
+	t.Run("messages of other chats are refused", func(t *testing.T) {
+		_, err := TestDB.SwitchChatBranch(chat.ID, "unknown")
+		assert.ErrorIs(t, err, ErrChatMessageNotFound)
+
+		_, err = TestDB.AddChatMessageBranch(&ChatMessage{ID: "q-other", ChatID: chat.ID, ParentID: "unknown", Role: UserRole})
+		assert.ErrorIs(t, err, ErrChatMessageNotFound)
+
+		_, err = TestDB.GetChatBranch(chat.ID, "unknown")
+		assert.ErrorIs(t, err, ErrChatMessageNotFound)
+	})
+}
+
+func TestEditLegacyChatMessage(t *testing.T) {
+	InitTestDB()
+	DeleteAllChatMessages()
+	DeleteAllChats()
+
+	chat := Chat{ID: "legacy-chat", WorkspaceID: "workspace", Title: "Legacy", Status: ActiveStatus}
+	TestDB.db.Create(&chat)
+
+	// a chat from before branching, none of its messages has a parent
+	start := time.Now().Add(-time.Hour)
+	for i, id := range []string{"q1", "a1", "q2", "a2"} {
+		role := UserRole
+		if i%2 == 1 {
+			role = AssistantRole
+		}
+		TestDB.db.Create(&ChatMessage{ID: id, ChatID: chat.ID, Role: role, Timestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+
+	branch, err := TestDB.GetChatBranch(chat.ID, "q2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"q1", "a1", "q2"}, messageIDs(branch), "the edited message keeps the history before it")
+
+	edited := branch[len(branch)-1]
+	_, err = TestDB.AddChatMessageBranch(&ChatMessage{ID: "q2-edit", ChatID: chat.ID, ParentID: edited.ParentID, Role: UserRole})
+	require.NoError(t, err)
+
+	branch, err = TestDB.GetChatBranch(chat.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"q1", "a1", "q2-edit"}, messageIDs(branch))
+}
+
+func TestStartChatMessageStream(t *testing.T) {
+	InitTestDB()
+	DeleteAllChatMessages()
+	DeleteAllChats()
+
+	chat := Chat{ID: "streaming-chat", WorkspaceID: "workspace", Title: "Streams", Status: ActiveStatus}
+	other := Chat{ID: "other-chat", WorkspaceID: "workspace", Title: "Other", Status: ActiveStatus}
+	TestDB.db.Create(&chat)
+	TestDB.db.Create(&other)
+
+	_, err := TestDB.AddChatMessage(&ChatMessage{ID: "question", ChatID: chat.ID, Role: UserRole})
+	require.NoError(t, err)
+
+	reply, created, err := TestDB.StartChatMessageStream(&ChatMessage{ID: "reply", ChatID: chat.ID, ParentID: "question", Role: AssistantRole, Status: SendingStatus})
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "reply", reply.ID)
+
+	t.Run("a later chunk gets the same reply", func(t *testing.T) {
+		again, created, err := TestDB.StartChatMessageStream(&ChatMessage{ID: "reply-2", ChatID: chat.ID, ParentID: "question", Role: AssistantRole, Status: SendingStatus})
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, "reply", again.ID)
+
+		stream, err := TestDB.GetChatMessageStream(chat.ID, "question")
+		require.NoError(t, err)
+		assert.Equal(t, "reply", stream.ID)
+	})
+
+	t.Run("messages of other chats are refused", func(t *testing.T) {
+		_, _, err := TestDB.StartChatMessageStream(&ChatMessage{ID: "reply-3", ChatID: other.ID, ParentID: "question", Role: AssistantRole, Status: SendingStatus})
+		assert.ErrorIs(t, err, ErrChatMessageNotFound)
+
+		_, err = TestDB.GetChatMessageStream(other.ID, "question")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// The search expressions are the ones the indexes are built on, postgres
+// only uses an expression index for the very same expression
+const (
+	chatMessageSearchVector = "to_tsvector('english', m.message)"
+	artifactSearchText      = "coalesce(a.content->>'content', '')"
+	artifactSearchVector    = "to_tsvector('english', " + artifactSearchText + ")"
+	// the matches are marked with control characters that survive HTML
+	// escaping, the text around them is escaped before they become <mark>
+	chatSearchStartSel = "\x02"
+	chatSearchStopSel  = "\x03"
+	chatSearchHeadline = "StartSel=\"" + chatSearchStartSel + "\", StopSel=\"" + chatSearchStopSel + "\", MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=\" ... \""
+)
+
+// highlightChatSearchSnippet escapes a ts_headline snippet for HTML and
+// wraps its matches in <mark>
+func highlightChatSearchSnippet(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, chatSearchStartSel, "<mark>")
+	return strings.ReplaceAll(snippet, chatSearchStopSel, "</mark>")
+}
+
+// createChatSearchIndexes indexes the text of the chat messages and of the
+// artifacts for SearchChatHistory
+func createChatSearchIndexes(db *gorm.DB) {
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_chat_messages_search ON chat_messages USING GIN (to_tsvector('english', message))")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_artifacts_search ON artifacts USING GIN (to_tsvector('english', coalesce(content->>'content', '')))")
+}
+
+// SearchChatHistory searches the messages and the artifacts of every chat
+// of a workspace, archived ones included, best matches first. It returns
+// a page of the results with the number of results in all.
+func (db database) SearchChatHistory(params ChatSearchParams) ([]ChatSearchResult, int64, error) {
+	if params.WorkspaceID == "" {
+		return nil, 0, errors.New("workspace ID is required")
+	}
+	if strings.TrimSpace(params.Query) == "" {
+		return nil, 0, errors.New("search query is required")
+	}
+
+	// every part of the union filters its rows the same way
+	filter := func(vector string, timestamp string) (string, []interface{}) {
+		conditions := []string{"c.workspace_id = ?", vector + " @@ q.query"}
+		args := []interface{}{params.WorkspaceID}
+		if params.ChatID != "" {
+			conditions = append(conditions, "c.id = ?")
+			args = append(args, params.ChatID)
+		}
+		if params.Role != "" {
+			conditions = append(conditions, "m.role = ?")
+			args = append(args, string(params.Role))
+		}
+		if params.From != nil {
+			conditions = append(conditions, timestamp+" >= ?")
+			args = append(args, *params.From)
+		}
+		if params.To != nil {
+			conditions = append(conditions, timestamp+" <= ?")
+			args = append(args, *params.To)
+		}
+		return strings.Join(conditions, " AND "), args
+	}
+
+	parts := []string{}
+	args := []interface{}{params.Query}
+
+	if params.ArtifactType == "" {
+		where, whereArgs := filter(chatMessageSearchVector, "m.timestamp")
+		parts = append(parts, fmt.Sprintf(`SELECT 'message' AS kind, c.id AS chat_id, c.title AS chat_title, c.status AS chat_status,
+			m.id AS message_id, m.role, NULL::uuid AS artifact_id, '' AS artifact_type,
+			ts_headline('english', m.message, q.query, '%s') AS snippet,
+			ts_rank(%s, q.query) AS rank, m.timestamp AS created_at
+			FROM chat_messages m JOIN chats c ON c.id = m.chat_id, q
+			WHERE %s`, chatSearchHeadline, chatMessageSearchVector, where))
+		args = append(args, whereArgs...)
+	}
+
+	where, whereArgs := filter(artifactSearchVector, "a.created_at")
+	if params.ArtifactType != "" {
+		where += " AND a.type = ?"
+		whereArgs = append(whereArgs, string(params.ArtifactType))
+	}
+	parts = append(parts, fmt.Sprintf(`SELECT 'artifact' AS kind, c.id AS chat_id, c.title AS chat_title, c.status AS chat_status,
+		m.id AS message_id, m.role, a.id AS artifact_id, a.type AS artifact_type,
+		ts_headline('english', %s, q.query, '%s') AS snippet,
+		ts_rank(%s, q.query) AS rank, a.created_at
+		FROM artifacts a JOIN chat_messages m ON m.id = a.message_id JOIN chats c ON c.id = m.chat_id, q
+		WHERE %s`, artifactSearchText, chatSearchHeadline, artifactSearchVector, where))
+	args = append(args, whereArgs...)
+
+	results := fmt.Sprintf("WITH q AS (SELECT websearch_to_tsquery('english', ?) AS query) %s",
+		strings.Join(parts, " UNION ALL "))
+
+	var total int64
+	if err := db.db.Raw("SELECT count(*) FROM ("+results+") results", args...).Scan(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count chat search results: %w", err)
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	found := []ChatSearchResult{}
+	err := db.db.Raw(results+" ORDER BY rank DESC, created_at DESC LIMIT ? OFFSET ?", append(args, limit, params.Offset)...).
+		Scan(&found).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search chat history: %w", err)
+	}
+	for i := range found {
+		found[i].Snippet = highlightChatSearchSnippet(found[i].Snippet)
+	}
+
+	return found, total, nil
+}
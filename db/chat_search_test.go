@@ -0,0 +1,107 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchChatHistory(t *testing.T) {
+	InitTestDB()
+	DeleteAllChats()
+	DeleteAllArtifacts()
+
+	now := time.Now()
+	workspace := uuid.New().String()
+
+	chats := []Chat{
+		{ID: "search-active", WorkspaceID: workspace, Title: "Rate limits", Status: ActiveStatus},
+		{ID: "search-archived", WorkspaceID: workspace, Title: "Old decisions", Status: ArchiveStatus},
+		{ID: "search-other", WorkspaceID: uuid.New().String(), Title: "Elsewhere", Status: ActiveStatus},
+	}
+	for _, chat := range chats {
+		TestDB.db.Create(&chat)
+	}
+
+	messages := []ChatMessage{
+		{ID: "search-1", ChatID: "search-active", Message: "How should the rate limiter count requests?", Role: UserRole, Timestamp: now.Add(-48 * time.Hour)},
+		{ID: "search-2", ChatID: "search-active", Message: "The rate limiter counts requests per key in a sliding window, rate limiting rate limiting.", Role: AssistantRole, Timestamp: now.Add(-47 * time.Hour)},
+		{ID: "search-3", ChatID: "search-archived", Message: "We decided to drop the rate limiter for webhooks.", Role: AssistantRole, Timestamp: now.Add(-24 * time.Hour)},
+		{ID: "search-4", ChatID: "search-other", Message: "Another workspace talks about the rate limiter too.", Role: AssistantRole, Timestamp: now},
+	}
+	for _, message := range messages {
+		TestDB.db.Create(&message)
+	}
+
+	artifact := Artifact{
+		ID:        uuid.New(),
+		MessageID: "search-2",
+		Type:      TextArtifact,
+		Content:   PropertyMap{"text_type": "code", "content": "limiter := NewRateLimiter(window)"},
+		CreatedAt: now.Add(-47 * time.Hour),
+	}
+	TestDB.db.Create(&artifact)
+
+	t.Run("should rank the matches of the workspace, archived chats included", func(t *testing.T) {
+		results, total, err := TestDB.SearchChatHistory(ChatSearchParams{WorkspaceID: workspace, Query: "rate limiter"})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		require.Len(t, results, 3)
+		assert.Equal(t, "search-2", results[0].MessageID)
+
+		ids := map[string]bool{}
+		for _, result := range results {
+			ids[result.MessageID] = true
+			assert.Equal(t, MessageSearchResult, result.Kind)
+			assert.Contains(t, result.Snippet, "<mark>")
+		}
+		assert.True(t, ids["search-3"], "archived chats are searched")
+		assert.False(t, ids["search-4"], "other workspaces are not")
+	})
+
+	t.Run("should search the text of the artifacts", func(t *testing.T) {
+		results, total, err := TestDB.SearchChatHistory(ChatSearchParams{WorkspaceID: workspace, Query: "NewRateLimiter", ArtifactType: TextArtifact})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, results, 1)
+		assert.Equal(t, ArtifactSearchResult, results[0].Kind)
+		assert.Equal(t, artifact.ID, *results[0].ArtifactID)
+		assert.Equal(t, "search-2", results[0].MessageID)
+	})
+
+	t.Run("should apply the filters", func(t *testing.T) {
+		from := now.Add(-30 * time.Hour)
+		results, _, err := TestDB.SearchChatHistory(ChatSearchParams{WorkspaceID: workspace, Query: "rate limiter", From: &from})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "search-3", results[0].MessageID)
+
+		results, _, err = TestDB.SearchChatHistory(ChatSearchParams{WorkspaceID: workspace, Query: "rate limiter", Role: UserRole})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "search-1", results[0].MessageID)
+
+		results, total, err := TestDB.SearchChatHistory(ChatSearchParams{WorkspaceID: workspace, Query: "rate limiter", ChatID: "search-active", Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, results, 1)
+	})
+
+	t.Run("should require a workspace and a query", func(t *testing.T) {
+		_, _, err := TestDB.SearchChatHistory(ChatSearchParams{Query: "rate"})
+		assert.Error(t, err)
+
+		_, _, err = TestDB.SearchChatHistory(ChatSearchParams{WorkspaceID: workspace, Query: "  "})
+		assert.Error(t, err)
+	})
+}
+
+func TestHighlightChatSearchSnippet(t *testing.T) {
+	snippet := highlightChatSearchSnippet("<img src=x onerror=alert(1)> the " + chatSearchStartSel + "rate" + chatSearchStopSel + " & limit")
+	assert.Equal(t, "&lt;img src=x onerror=alert(1)&gt; the <mark>rate</mark> &amp; limit", snippet)
+}
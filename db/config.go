@@ -1,12 +1,14 @@
 package db
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
 	"os"
 
 	"github.com/rs/xid"
 	"github.com/stakwork/sphinx-tribes/logger"
+	"github.com/stakwork/sphinx-tribes/tracing"
 	"gopkg.in/go-playground/validator.v9"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -39,6 +41,11 @@ func NewDatabaseConfig(db *gorm.DB) *database {
 // DB is the object
 var DB database
 
+// SqlDB is the connection pool GORM runs its queries on
+func (db database) SqlDB() (*sql.DB, error) {
+	return db.db.DB()
+}
+
 func InitDB() {
 	dbURL := os.Getenv("DATABASE_URL")
 	logger.Log.Info("db url : %v", dbURL)
@@ -67,6 +74,10 @@ func InitDB() {
 		panic(err)
 	}
 
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		panic(err)
+	}
+
 	DB.db = db
 	logger.Log.Info("db connected")
 
@@ -105,12 +116,23 @@ func InitDB() {
 	db.AutoMigrate(&Skill{})
 	db.AutoMigrate(&SkillInstall{})
 	db.AutoMigrate(&SSEMessageLog{})
+	db.AutoMigrate(&SSESubscription{})
+	db.AutoMigrate(&WebhookDeadLetter{})
 	db.AutoMigrate(&CodeSpaceMap{})
 	db.AutoMigrate(&BountyStake{})
 	db.AutoMigrate(&ChatWorkflowStatus{})
+	db.AutoMigrate(&WorkspaceAPIKey{})
+	db.AutoMigrate(&UserSession{})
+	db.AutoMigrate(&TokenRevocation{})
+	db.AutoMigrate(&PersonIdentity{})
+	db.AutoMigrate(&BountyPayout{})
+
+	createChatSearchIndexes(db)
 
 	DB.MigrateTablesWithOrgUuid()
 	DB.MigrateOrganizationToWorkspace()
+	DB.MigrateNostrPubkeysToIdentities()
+	DB.MigrateGithubIdentitiesToIDs()
 
 	people := DB.GetAllPeople()
 	for _, p := range people {
@@ -408,6 +430,10 @@ func CheckUser(userRoles []WorkspaceUserRoles, pubkey string) bool {
 
 func UserHasAccess(pubKeyFromAuth string, uuid string, role string) bool {
 	org := DB.GetWorkspaceByUuid(uuid)
+	// a deleted workspace grants nobody access until it is restored
+	if org.Deleted {
+		return false
+	}
 	var hasRole bool = false
 	if pubKeyFromAuth != org.OwnerPubKey {
 		userRoles := DB.GetUserRoles(uuid, pubKeyFromAuth)
@@ -419,6 +445,9 @@ func UserHasAccess(pubKeyFromAuth string, uuid string, role string) bool {
 
 func (db database) UserHasAccess(pubKeyFromAuth string, uuid string, role string) bool {
 	org := db.getWorkspaceByUuid(uuid)
+	if org.Deleted {
+		return false
+	}
 	var hasRole bool = false
 	if pubKeyFromAuth != org.OwnerPubKey {
 		userRoles := db.getUserRoles(uuid, pubKeyFromAuth)
@@ -430,6 +459,9 @@ func (db database) UserHasAccess(pubKeyFromAuth string, uuid string, role string
 
 func (ch configHandler) UserHasAccess(pubKeyFromAuth string, uuid string, role string) bool {
 	org := ch.db.GetWorkspaceByUuid(uuid)
+	if org.Deleted {
+		return false
+	}
 	var hasRole bool = false
 	if pubKeyFromAuth != org.OwnerPubKey {
 		userRoles := ch.db.GetUserRoles(uuid, pubKeyFromAuth)
@@ -442,6 +474,9 @@ func (ch configHandler) UserHasAccess(pubKeyFromAuth string, uuid string, role s
 func (ch configHandler) UserHasManageBountyRoles(pubKeyFromAuth string, uuid string) bool {
 	var manageRolesCount = len(ManageBountiesGroup)
 	org := ch.db.GetWorkspaceByUuid(uuid)
+	if org.Deleted {
+		return false
+	}
 	if pubKeyFromAuth != org.OwnerPubKey {
 		userRoles := ch.db.GetUserRoles(uuid, pubKeyFromAuth)
 
@@ -463,6 +498,9 @@ func (ch configHandler) UserHasManageBountyRoles(pubKeyFromAuth string, uuid str
 func (db database) UserHasManageBountyRoles(pubKeyFromAuth string, uuid string) bool {
 	var manageRolesCount = len(ManageBountiesGroup)
 	org := db.getWorkspaceByUuid(uuid)
+	if org.Deleted {
+		return false
+	}
 	if pubKeyFromAuth != org.OwnerPubKey {
 		userRoles := db.getUserRoles(uuid, pubKeyFromAuth)
 
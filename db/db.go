@@ -184,21 +184,6 @@ func (db database) AddUuidToPerson(id uint, uuid string) {
 	})
 }
 
-func (db database) GetUnconfirmedGithub() []Person {
-	ms := []Person{}
-	db.db.Raw(`SELECT * FROM people where extras -> 'github' IS NOT NULL and github_confirmed = 'f';`).Find(&ms)
-	return ms
-}
-
-func (db database) UpdateGithubConfirmed(id uint, confirmed bool) {
-	if id == 0 {
-		return
-	}
-	db.db.Model(&Person{}).Where("id = ?", id).Updates(map[string]interface{}{
-		"github_confirmed": confirmed,
-	})
-}
-
 func (db database) UpdateGithubIssues(id uint, issues map[string]interface{}) {
 	db.db.Model(&Person{}).Where("id = ?", id).Updates(map[string]interface{}{
 		"github_issues": issues,
@@ -1843,6 +1828,18 @@ func (db database) UpdateInvoice(payment_request string) NewInvoiceList {
 	return ms
 }
 
+// MarkInvoicePaid sets a pending invoice as paid, it returns false when the
+// invoice is unknown or another settlement got to it first
+func (db database) MarkInvoicePaid(payment_request string) (bool, error) {
+	result := db.db.Model(&NewInvoiceList{}).
+		Where("payment_request = ? AND status = ?", payment_request, false).
+		Update("status", true)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to mark invoice as paid: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
 func (db database) AddInvoice(invoice NewInvoiceList) NewInvoiceList {
 	db.db.Create(&invoice)
 	return invoice
@@ -1854,6 +1851,12 @@ func (db database) DeleteInvoice(payment_request string) NewInvoiceList {
 	return ms
 }
 
+func (db database) GetPendingInvoices() []NewInvoiceList {
+	ms := []NewInvoiceList{}
+	db.db.Where("status = ?", false).Order("created ASC").Find(&ms)
+	return ms
+}
+
 func (db database) AddUserInvoiceData(userData UserInvoiceData) UserInvoiceData {
 	db.db.Create(&userData)
 	return userData
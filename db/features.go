@@ -453,6 +453,12 @@ func (db database) GetNotificationsByStatus(status string) []Notification {
 	return notifications
 }
 
+func (db database) GetNotificationsByStatusCount(status string) int64 {
+	var count int64
+	db.db.Model(&Notification{}).Where("status = ?", status).Count(&count)
+	return count
+}
+
 func (db database) IncrementNotificationRetry(notificationUUID string) {
 	db.db.Model(&Notification{}).Where("uuid = ?", notificationUUID).
 		Update("retries", gorm.Expr("retries + 1"))
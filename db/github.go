@@ -0,0 +1,48 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+func (db database) GetPersonByGithubID(githubID int64) Person {
+	m := Person{}
+	if githubID == 0 {
+		return m
+	}
+	db.db.Where("github_id = ? AND (deleted = false OR deleted is null)", githubID).Find(&m)
+
+	return m
+}
+
+// UpdatePersonGithub stores a GitHub account verified through OAuth, the
+// verified login also replaces the github name in the person's extras
+func (db database) UpdatePersonGithub(pubkey string, login string, githubID int64) error {
+	return db.db.Transaction(func(tx *gorm.DB) error {
+		var person Person
+		if err := tx.Where("owner_pub_key = ? AND (deleted = false OR deleted is null)", pubkey).First(&person).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("person not found")
+			}
+			return fmt.Errorf("failed to fetch person: %w", err)
+		}
+
+		extras := person.Extras
+		if extras == nil {
+			extras = PropertyMap{}
+		}
+		extras["github"] = []interface{}{map[string]interface{}{"value": login}}
+
+		err := tx.Model(&Person{}).Where("id = ?", person.ID).Updates(map[string]interface{}{
+			"github_login": login,
+			"github_id":    githubID,
+			"extras":       extras,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update github account: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdatePersonGithub(t *testing.T) {
+	InitTestDB()
+	defer CloseTestDB()
+
+	pubkey := "test_github_" + uuid.New().String()
+	_, err := TestDB.CreateLnUser(pubkey)
+	assert.NoError(t, err)
+
+	githubID := int64(uuid.New().ID())
+	assert.Empty(t, TestDB.GetPersonByGithubID(githubID).OwnerPubKey)
+
+	assert.NoError(t, TestDB.UpdatePersonGithub(pubkey, "octocat", githubID))
+
+	person := TestDB.GetPersonByGithubID(githubID)
+	assert.Equal(t, pubkey, person.OwnerPubKey)
+	assert.Equal(t, "octocat", person.GithubLogin)
+	assert.Equal(t, []interface{}{map[string]interface{}{"value": "octocat"}}, person.Extras["github"])
+
+	assert.Error(t, TestDB.UpdatePersonGithub("missing_"+pubkey, "octocat", githubID))
+}
@@ -0,0 +1,197 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stakwork/sphinx-tribes/logger"
+	"gorm.io/gorm"
+)
+
+// CreatePersonIdentity links an identity to a person, linking an identity the
+// person already has only updates whether it is verified
+func (db database) CreatePersonIdentity(identity *PersonIdentity) error {
+	if identity.OwnerPubKey == "" || identity.Type == "" || identity.Value == "" {
+		return errors.New("owner pubkey, type and value are required")
+	}
+
+	existing, err := db.GetPersonIdentity(identity.Type, identity.Value)
+	if err == nil {
+		if existing.OwnerPubKey != identity.OwnerPubKey {
+			return errors.New("identity is already linked to another person")
+		}
+		if identity.Verified && !existing.Verified {
+			if err := db.db.Model(&existing).Update("verified", true).Error; err != nil {
+				return fmt.Errorf("failed to verify identity: %w", err)
+			}
+			existing.Verified = true
+		}
+		*identity = existing
+		return nil
+	}
+
+	if identity.ID == uuid.Nil {
+		identity.ID = uuid.New()
+	}
+	identity.CreatedAt = time.Now()
+
+	if err := db.db.Create(identity).Error; err != nil {
+		return fmt.Errorf("failed to create identity: %w", err)
+	}
+	return nil
+}
+
+func (db database) GetPersonIdentity(identityType string, value string) (PersonIdentity, error) {
+	var identity PersonIdentity
+	if err := db.db.Where("type = ? AND value = ?", identityType, value).First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return identity, errors.New("identity not found")
+		}
+		return identity, fmt.Errorf("failed to fetch identity: %w", err)
+	}
+	return identity, nil
+}
+
+func (db database) GetPersonIdentities(pubkey string) ([]PersonIdentity, error) {
+	var identities []PersonIdentity
+	if err := db.db.Where("owner_pubkey = ?", pubkey).Order("created_at ASC").Find(&identities).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch identities: %w", err)
+	}
+	return identities, nil
+}
+
+func (db database) DeletePersonIdentity(pubkey string, id string) error {
+	result := db.db.Where("id = ? AND owner_pubkey = ?", id, pubkey).Delete(&PersonIdentity{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete identity: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("identity not found")
+	}
+	return nil
+}
+
+// MigrateNostrPubkeysToIdentities moves the nostr keys still stored on people
+// into person_identities and drops the old column once they are all copied
+func (db database) MigrateNostrPubkeysToIdentities() {
+	if !db.db.Migrator().HasColumn(&Person{}, "nostr_pub_key") {
+		return
+	}
+
+	var people []struct {
+		OwnerPubKey string
+		NostrPubKey string
+	}
+	if err := db.db.Model(&Person{}).
+		Select("owner_pub_key, nostr_pub_key").
+		Where("nostr_pub_key IS NOT NULL AND nostr_pub_key <> '' AND (deleted = false OR deleted is null)").
+		Scan(&people).Error; err != nil {
+		logger.Log.Error("[db] could not read nostr pubkeys to migrate: %v", err)
+		return
+	}
+
+	for _, p := range people {
+		if existing, err := db.GetPersonIdentity(IdentityNostr, p.NostrPubKey); err == nil && existing.OwnerPubKey != p.OwnerPubKey {
+			logger.Log.Warning("[db] nostr pubkey of %s is already linked to %s, not migrating it", p.OwnerPubKey, existing.OwnerPubKey)
+			continue
+		}
+		// keys were only stored after the owner signed for them
+		identity := PersonIdentity{
+			OwnerPubKey: p.OwnerPubKey,
+			Type:        IdentityNostr,
+			Value:       p.NostrPubKey,
+			Verified:    true,
+		}
+		if err := db.CreatePersonIdentity(&identity); err != nil {
+			// keep the column so nothing is lost, the next start tries again
+			logger.Log.Error("[db] could not migrate nostr pubkey of %s: %v", p.OwnerPubKey, err)
+			return
+		}
+	}
+
+	if err := db.db.Migrator().DropColumn(&Person{}, "nostr_pub_key"); err != nil {
+		logger.Log.Error("[db] could not drop people.nostr_pub_key: %v", err)
+	}
+}
+
+// MigrateGithubIdentitiesToIDs rewrites github identities that were stored
+// by login to the GitHub account id verified on the person
+func (db database) MigrateGithubIdentitiesToIDs() {
+	err := db.db.Exec(`UPDATE person_identities SET value = CAST(people.github_id AS text)
+		FROM people
+		WHERE person_identities.type = ? AND person_identities.owner_pubkey = people.owner_pub_key
+		AND people.github_id > 0 AND person_identities.value <> CAST(people.github_id AS text)`, IdentityGithub).Error
+	if err != nil {
+		logger.Log.Error("[db] could not migrate github identities to account ids: %v", err)
+	}
+}
+
+// MergePeople moves everything owned by the source person to the target, links
+// the source pubkey to the target and deletes the source person
+func (db database) MergePeople(sourcePubkey string, targetPubkey string, sourceIdentityType string) error {
+	if sourcePubkey == "" || targetPubkey == "" || sourcePubkey == targetPubkey {
+		return errors.New("two different people are required")
+	}
+
+	return db.db.Transaction(func(tx *gorm.DB) error {
+		var source, target Person
+		if err := tx.Where("owner_pub_key = ? AND (deleted = false OR deleted is null)", sourcePubkey).First(&source).Error; err != nil {
+			return errors.New("source person not found")
+		}
+		if err := tx.Where("owner_pub_key = ? AND (deleted = false OR deleted is null)", targetPubkey).First(&target).Error; err != nil {
+			return errors.New("target person not found")
+		}
+
+		// memberships and roles the target already has would be duplicated by
+		// the move, the source ones are dropped instead
+		if err := tx.Where("owner_pub_key = ? AND workspace_uuid IN (?)", sourcePubkey,
+			tx.Model(&WorkspaceUsers{}).Select("workspace_uuid").Where("owner_pub_key = ?", targetPubkey)).
+			Delete(&WorkspaceUsers{}).Error; err != nil {
+			return fmt.Errorf("failed to drop duplicate workspace users: %w", err)
+		}
+		if err := tx.Where("owner_pub_key = ? AND EXISTS (SELECT 1 FROM workspace_user_roles t WHERE t.owner_pub_key = ? AND t.workspace_uuid = workspace_user_roles.workspace_uuid AND t.role = workspace_user_roles.role)", sourcePubkey, targetPubkey).
+			Delete(&WorkspaceUserRoles{}).Error; err != nil {
+			return fmt.Errorf("failed to drop duplicate workspace user roles: %w", err)
+		}
+
+		updates := []struct {
+			model  interface{}
+			column string
+		}{
+			{&NewBounty{}, "owner_id"},
+			{&NewBounty{}, "assignee"},
+			{&Workspace{}, "owner_pub_key"},
+			{&WorkspaceUsers{}, "owner_pub_key"},
+			{&WorkspaceUserRoles{}, "owner_pub_key"},
+			{&NewPaymentHistory{}, "sender_pub_key"},
+			{&NewPaymentHistory{}, "receiver_pub_key"},
+			{&BountyPayout{}, "sender_pub_key"},
+			{&BountyPayout{}, "receiver_pub_key"},
+			{&PersonIdentity{}, "owner_pubkey"},
+		}
+		for _, u := range updates {
+			if err := tx.Model(u.model).Where(u.column+" = ?", sourcePubkey).Update(u.column, targetPubkey).Error; err != nil {
+				return fmt.Errorf("failed to move %s: %w", u.column, err)
+			}
+		}
+
+		identity := PersonIdentity{
+			ID:          uuid.New(),
+			OwnerPubKey: targetPubkey,
+			Type:        sourceIdentityType,
+			Value:       sourcePubkey,
+			Verified:    true,
+			CreatedAt:   time.Now(),
+		}
+		if err := tx.Create(&identity).Error; err != nil {
+			return fmt.Errorf("failed to link source pubkey: %w", err)
+		}
+
+		if err := tx.Model(&source).Updates(map[string]interface{}{"deleted": true, "unlisted": true}).Error; err != nil {
+			return fmt.Errorf("failed to delete source person: %w", err)
+		}
+		return nil
+	})
+}
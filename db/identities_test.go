@@ -0,0 +1,127 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersonIdentities(t *testing.T) {
+	InitTestDB()
+	defer CloseTestDB()
+
+	pubkey := "test_identity_" + uuid.New().String()
+	value := "nostr_" + uuid.New().String()
+
+	identity := PersonIdentity{OwnerPubKey: pubkey, Type: IdentityNostr, Value: value}
+	assert.NoError(t, TestDB.CreatePersonIdentity(&identity))
+
+	again := PersonIdentity{OwnerPubKey: pubkey, Type: IdentityNostr, Value: value, Verified: true}
+	assert.NoError(t, TestDB.CreatePersonIdentity(&again))
+	assert.Equal(t, identity.ID, again.ID)
+	assert.True(t, again.Verified)
+
+	other := PersonIdentity{OwnerPubKey: "other_" + pubkey, Type: IdentityNostr, Value: value}
+	assert.Error(t, TestDB.CreatePersonIdentity(&other))
+
+	found, err := TestDB.GetPersonIdentity(IdentityNostr, value)
+	assert.NoError(t, err)
+	assert.Equal(t, pubkey, found.OwnerPubKey)
+
+	identities, err := TestDB.GetPersonIdentities(pubkey)
+	assert.NoError(t, err)
+	assert.Len(t, identities, 1)
+
+	assert.Error(t, TestDB.DeletePersonIdentity("other_"+pubkey, identity.ID.String()))
+	assert.NoError(t, TestDB.DeletePersonIdentity(pubkey, identity.ID.String()))
+	_, err = TestDB.GetPersonIdentity(IdentityNostr, value)
+	assert.Error(t, err)
+}
+
+func TestMergePeople(t *testing.T) {
+	InitTestDB()
+	defer CloseTestDB()
+
+	source := "test_merge_source_" + uuid.New().String()
+	target := "test_merge_target_" + uuid.New().String()
+	_, err := TestDB.CreateLnUser(source)
+	assert.NoError(t, err)
+	_, err = TestDB.CreateLnUser(target)
+	assert.NoError(t, err)
+
+	workspace := Workspace{Uuid: uuid.New().String(), Name: "merge_" + uuid.New().String()[:8], OwnerPubKey: source}
+	assert.NoError(t, TestDB.db.Create(&workspace).Error)
+
+	// both are members with the same role, the target keeps one of each
+	for _, pubkey := range []string{source, target} {
+		assert.NoError(t, TestDB.db.Create(&WorkspaceUsers{OwnerPubKey: pubkey, WorkspaceUuid: workspace.Uuid}).Error)
+		assert.NoError(t, TestDB.db.Create(&WorkspaceUserRoles{OwnerPubKey: pubkey, WorkspaceUuid: workspace.Uuid, Role: "VIEW REPORT"}).Error)
+	}
+	assert.NoError(t, TestDB.db.Create(&WorkspaceUserRoles{OwnerPubKey: source, WorkspaceUuid: workspace.Uuid, Role: "PAY BOUNTY"}).Error)
+
+	payment := NewPaymentHistory{WorkspaceUuid: workspace.Uuid, SenderPubKey: source, ReceiverPubKey: source, Amount: 10}
+	assert.NoError(t, TestDB.db.Create(&payment).Error)
+
+	assert.Error(t, TestDB.MergePeople(source, source, IdentityNode))
+	assert.NoError(t, TestDB.MergePeople(source, target, IdentityNode))
+
+	assert.Equal(t, target, TestDB.GetWorkspaceByUuid(workspace.Uuid).OwnerPubKey)
+
+	var members int64
+	TestDB.db.Model(&WorkspaceUsers{}).Where("workspace_uuid = ?", workspace.Uuid).Count(&members)
+	assert.Equal(t, int64(1), members)
+	var roles []WorkspaceUserRoles
+	TestDB.db.Where("workspace_uuid = ?", workspace.Uuid).Order("role").Find(&roles)
+	assert.Len(t, roles, 2)
+	for _, role := range roles {
+		assert.Equal(t, target, role.OwnerPubKey)
+	}
+
+	var moved NewPaymentHistory
+	TestDB.db.First(&moved, payment.ID)
+	assert.Equal(t, target, moved.SenderPubKey)
+	assert.Equal(t, target, moved.ReceiverPubKey)
+	assert.Empty(t, TestDB.GetPersonByPubkey(source).OwnerPubKey)
+
+	identity, err := TestDB.GetPersonIdentity(IdentityNode, source)
+	assert.NoError(t, err)
+	assert.Equal(t, target, identity.OwnerPubKey)
+
+	assert.Error(t, TestDB.MergePeople(source, target, IdentityNode))
+}
+
+func TestMigrateNostrPubkeysToIdentities(t *testing.T) {
+	InitTestDB()
+	defer CloseTestDB()
+
+	pubkey := "test_nostr_migration_" + uuid.New().String()
+	nostrPubkey := "nostr_" + uuid.New().String()
+
+	TestDB.db.Create(&Person{Uuid: uuid.New().String(), OwnerPubKey: pubkey, OwnerAlias: "alias"})
+	assert.NoError(t, TestDB.db.Exec("ALTER TABLE people ADD COLUMN IF NOT EXISTS nostr_pub_key text").Error)
+	assert.NoError(t, TestDB.db.Exec("UPDATE people SET nostr_pub_key = ? WHERE owner_pub_key = ?", nostrPubkey, pubkey).Error)
+
+	TestDB.MigrateNostrPubkeysToIdentities()
+
+	identity, err := TestDB.GetPersonIdentity(IdentityNostr, nostrPubkey)
+	assert.NoError(t, err)
+	assert.Equal(t, pubkey, identity.OwnerPubKey)
+	assert.True(t, identity.Verified)
+	assert.False(t, TestDB.db.Migrator().HasColumn(&Person{}, "nostr_pub_key"))
+}
+
+func TestMigrateGithubIdentitiesToIDs(t *testing.T) {
+	InitTestDB()
+	defer CloseTestDB()
+
+	pubkey := "test_github_migration_" + uuid.New().String()
+	TestDB.db.Create(&Person{Uuid: uuid.New().String(), OwnerPubKey: pubkey, OwnerAlias: "alias", GithubLogin: "octocat", GithubID: 583231})
+	assert.NoError(t, TestDB.CreatePersonIdentity(&PersonIdentity{OwnerPubKey: pubkey, Type: IdentityGithub, Value: "octocat", Verified: true}))
+
+	TestDB.MigrateGithubIdentitiesToIDs()
+
+	identity, err := TestDB.GetPersonIdentity(IdentityGithub, "583231")
+	assert.NoError(t, err)
+	assert.Equal(t, pubkey, identity.OwnerPubKey)
+}
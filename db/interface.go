@@ -14,8 +14,6 @@ type Database interface {
 	CreateOrEditPerson(m Person) (Person, error)
 	GetUnconfirmedTwitter() []Person
 	UpdateTwitterConfirmed(id uint, confirmed bool)
-	GetUnconfirmedGithub() []Person
-	UpdateGithubConfirmed(id uint, confirmed bool)
 	UpdateGithubIssues(id uint, issues map[string]interface{})
 	UpdateTribe(uuid string, u map[string]interface{}) bool
 	UpdateChannel(id uint, u map[string]interface{}) bool
@@ -102,6 +100,7 @@ type Database interface {
 	CreateUserRoles(roles []WorkspaceUserRoles, uuid string, pubkey string) []WorkspaceUserRoles
 	GetUserRoles(uuid string, pubkey string) []WorkspaceUserRoles
 	GetUserCreatedWorkspaces(pubkey string) []Workspace
+	GetUserDeletedWorkspaces(pubkey string) []Workspace
 	GetUserAssignedWorkspaces(pubkey string) []WorkspaceUsers
 	AddBudgetHistory(budget BudgetHistory) BudgetHistory
 	CreateWorkspaceBudget(budget NewBountyBudget) NewBountyBudget
@@ -120,8 +119,10 @@ type Database interface {
 	GetWorkspaceInvoices(workspace_uuid string) []NewInvoiceList
 	GetWorkspaceInvoicesCount(workspace_uuid string) int64
 	UpdateInvoice(payment_request string) NewInvoiceList
+	MarkInvoicePaid(payment_request string) (bool, error)
 	AddInvoice(invoice NewInvoiceList) NewInvoiceList
 	DeleteInvoice(payment_request string) NewInvoiceList
+	GetPendingInvoices() []NewInvoiceList
 	AddUserInvoiceData(userData UserInvoiceData) UserInvoiceData
 	ProcessAddInvoice(invoice NewInvoiceList, userData UserInvoiceData) error
 	ProcessBudgetInvoice(paymentHistory NewPaymentHistory, newInvoice NewInvoiceList) error
@@ -130,6 +131,41 @@ type Database interface {
 	ChangeWorkspaceDeleteStatus(workspace_uuid string, status bool) Workspace
 	UpdateWorkspaceForDeletion(uuid string) error
 	ProcessDeleteWorkspace(workspace_uuid string) error
+	RestoreWorkspace(workspace_uuid string) (Workspace, error)
+	GetWorkspacesPendingPurge(deletedBefore time.Time) []Workspace
+	FlagWorkspacePurge(workspace_uuid string) error
+	PurgeWorkspace(workspace_uuid string) error
+	GetWorkspaceArchive(workspace_uuid string) (WorkspaceArchive, error)
+	ImportWorkspaceArchive(archive WorkspaceArchive) error
+	CreateWorkspaceAPIKey(key *WorkspaceAPIKey) error
+	GetWorkspaceAPIKeys(workspace_uuid string) ([]WorkspaceAPIKey, error)
+	GetWorkspaceAPIKeyByHash(hash string) (WorkspaceAPIKey, error)
+	RevokeWorkspaceAPIKey(workspace_uuid string, id string) error
+	UpdateWorkspaceAPIKeyLastUsed(id string, lastUsed time.Time) error
+	CreateUserSession(session *UserSession) error
+	GetUserSession(id string) (UserSession, error)
+	GetActiveUserSessions(pubkey string) ([]UserSession, error)
+	RotateUserSession(id string, oldHash string, newHash string, expiresAt time.Time) error
+	RevokeUserSession(pubkey string, id string, reason string) error
+	RevokeAllUserSessions(pubkey string, reason string) error
+	IsTokenRevoked(pubkey string, sessionID string, issuedAt time.Time) bool
+	CreatePersonIdentity(identity *PersonIdentity) error
+	GetPersonIdentity(identityType string, value string) (PersonIdentity, error)
+	GetPersonIdentities(pubkey string) ([]PersonIdentity, error)
+	DeletePersonIdentity(pubkey string, id string) error
+	MergePeople(sourcePubkey string, targetPubkey string, sourceIdentityType string) error
+	GetPersonByGithubID(githubID int64) Person
+	UpdatePersonGithub(pubkey string, login string, githubID int64) error
+	CreateBountyPayout(payout *BountyPayout) error
+	GetBountyPayoutByK1(k1 string) (BountyPayout, error)
+	GetBountyPayout(bountyID uint) (BountyPayout, error)
+	GetWorkspaceReservedPayouts(workspaceUuid string) uint
+	ClaimBountyPayout(k1 string, paymentRequest string, paymentHash string, now time.Time) error
+	UpdateBountyPayoutStatus(id uuid.UUID, status string, errMsg string) error
+	GetClaimedBountyPayouts(before time.Time) ([]BountyPayout, error)
+	ExpireBountyPayouts(now time.Time) ([]BountyPayout, error)
+	GetWorkspaceByLightningAddress(name string) Workspace
+	SetWorkspaceLightningAddress(uuid string, name string) (Workspace, error)
 	GetLastWithdrawal(workspace_uuid string) NewPaymentHistory
 	GetSumOfDeposits(workspace_uuid string) uint
 	GetSumOfWithdrawal(workspace_uuid string) uint
@@ -177,6 +213,7 @@ type Database interface {
 	GetBountiesByPhaseUuid(phaseUuid string) []Bounty
 	GetFeaturePhasesBountiesCount(bountyType string, phaseUuid string) int64
 	GetPendingPaymentHistory() []NewPaymentHistory
+	GetPendingPaymentHistoryCount() int64
 	GetPaymentByBountyId(bountyId uint) NewPaymentHistory
 	SetPaymentAsComplete(tag string) bool
 	SetPaymentStatusByBountyId(bountyId uint, tagResult V2TagRes) bool
@@ -213,6 +250,14 @@ type Database interface {
 	AddChatMessage(message *ChatMessage) (ChatMessage, error)
 	UpdateChatMessage(message *ChatMessage) (ChatMessage, error)
 	GetChatMessagesForChatID(chatID string) ([]ChatMessage, error)
+	GetChatMessageStream(chatID string, parentID string) (ChatMessage, error)
+	StartChatMessageStream(reply *ChatMessage) (ChatMessage, bool, error)
+	AppendChatMessageChunk(id string, index int, chunk string) (bool, error)
+	FinishChatMessageStream(id string, text string) (ChatMessage, error)
+	SearchChatHistory(params ChatSearchParams) ([]ChatSearchResult, int64, error)
+	GetChatBranch(chatID string, leafID string) ([]ChatMessage, error)
+	AddChatMessageBranch(chatMessage *ChatMessage) (ChatMessage, error)
+	SwitchChatBranch(chatID string, messageID string) (ChatMessage, error)
 	GetChatsForWorkspace(workspaceID string, chatStatus string) ([]Chat, error)
 	GetCodeGraphByUUID(uuid string) (WorkspaceCodeGraph, error)
 	GetCodeGraphByWorkspaceUuid(workspace_uuid string) (WorkspaceCodeGraph, error)
@@ -288,6 +333,7 @@ type Database interface {
 	ResumeBountyTiming(bountyID uint) error
 	SaveNotification(pubkey, event, content, status string) error
 	GetNotificationsByStatus(status string) []Notification
+	GetNotificationsByStatusCount(status string) int64
 	IncrementNotificationRetry(notificationUUID string)
 	UpdateNotificationStatus(notificationUUID string, status string)
 	CreateOrEditTicketPlan(plan *TicketPlan) (*TicketPlan, error)
@@ -341,6 +387,19 @@ type Database interface {
 	GetSSEMessageLogByID(id uuid.UUID) (*SSEMessageLog, error)
 	GetSSEMessageLogsByChatID(chatID string) ([]SSEMessageLog, error)
 	GetNewSSEMessageLogsByChatID(chatID string) ([]SSEMessageLog, error)
+	ClaimNewSSEMessageLogs(chatID string, deliveryID uuid.UUID) ([]SSEMessageLog, error)
+	GetStaleSendingSSEMessageLogs(before time.Time) ([]SSEMessageLog, error)
+	SaveSSESubscription(subscription *SSESubscription) (*SSESubscription, error)
+	UpdateSSESubscription(chatID, url string, updates map[string]interface{}) error
+	GetSSESubscriptions(includeEnded bool) ([]SSESubscription, error)
+	ClaimSSESubscriptions(owner string, staleBefore time.Time) ([]SSESubscription, error)
+	TouchSSESubscriptions(owner string) error
+	ReleaseSSESubscriptions(owner string) error
+	SaveWebhookDeadLetter(letter *WebhookDeadLetter) (*WebhookDeadLetter, error)
+	GetWebhookDeadLetters(status string, limit, offset int) ([]WebhookDeadLetter, int64, error)
+	GetWebhookDeadLetterByID(id uuid.UUID) (*WebhookDeadLetter, error)
+	UpdateWebhookDeadLetter(id uuid.UUID, updates map[string]interface{}) error
+	ResetStaleWebhookDeadLetters(before time.Time) (int64, error)
 	CreateCodeSpaceMap(codeSpace CodeSpaceMap) (CodeSpaceMap, error)
 	GetCodeSpaceMaps() ([]CodeSpaceMap, error)
 	GetCodeSpaceMapByWorkspace(workspaceID string) ([]CodeSpaceMap, error)
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewUserSession builds the session of a login with its plaintext refresh
+// token, only the hash of the token is kept on the session
+func NewUserSession(pubkey string, userAgent string, ipAddress string) (UserSession, string, error) {
+	session := UserSession{
+		ID:          uuid.New(),
+		OwnerPubKey: pubkey,
+		UserAgent:   userAgent,
+		IPAddress:   ipAddress,
+		ExpiresAt:   time.Now().Add(time.Duration(config.SessionDays) * 24 * time.Hour),
+	}
+
+	refreshToken, err := auth.GenerateRefreshToken(session.ID.String())
+	if err != nil {
+		return session, "", err
+	}
+	session.RefreshTokenHash = auth.HashRefreshToken(refreshToken)
+
+	return session, refreshToken, nil
+}
+
+func (s UserSession) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+func (db database) CreateUserSession(session *UserSession) error {
+	if session.OwnerPubKey == "" {
+		return errors.New("owner pubkey is required")
+	}
+
+	if session.ID == uuid.Nil {
+		session.ID = uuid.New()
+	}
+	now := time.Now()
+	session.CreatedAt = now
+	session.LastUsedAt = now
+
+	if err := db.db.Create(session).Error; err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+func (db database) GetUserSession(id string) (UserSession, error) {
+	var session UserSession
+	if err := db.db.Where("id = ?", id).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return session, errors.New("session not found")
+		}
+		return session, fmt.Errorf("failed to fetch session: %w", err)
+	}
+	return session, nil
+}
+
+func (db database) GetActiveUserSessions(pubkey string) ([]UserSession, error) {
+	var sessions []UserSession
+	err := db.db.Where("owner_pubkey = ? AND revoked_at IS NULL AND expires_at > ?", pubkey, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// RotateUserSession swaps the refresh token hash only if the old hash is still
+// current, so two requests racing with the same refresh token cannot both succeed
+func (db database) RotateUserSession(id string, oldHash string, newHash string, expiresAt time.Time) error {
+	result := db.db.Model(&UserSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", id, oldHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": newHash,
+			"last_used_at":       time.Now(),
+			"expires_at":         expiresAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to rotate session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("session refresh token is no longer valid")
+	}
+	return nil
+}
+
+func (db database) RevokeUserSession(pubkey string, id string, reason string) error {
+	result := db.db.Model(&UserSession{}).
+		Where("id = ? AND owner_pubkey = ? AND revoked_at IS NULL", id, pubkey).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("session not found")
+	}
+	return nil
+}
+
+// RevokeAllUserSessions revokes every session of a pubkey and every token issued
+// to it so far, including tokens that were issued without a session
+func (db database) RevokeAllUserSessions(pubkey string, reason string) error {
+	now := time.Now()
+	return db.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&UserSession{}).
+			Where("owner_pubkey = ? AND revoked_at IS NULL", pubkey).
+			Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": reason}).Error; err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+
+		revocation := TokenRevocation{OwnerPubKey: pubkey, RevokedBefore: now}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "owner_pubkey"}},
+			DoUpdates: clause.AssignmentColumns([]string{"revoked_before"}),
+		}).Create(&revocation).Error; err != nil {
+			return fmt.Errorf("failed to revoke tokens: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (db database) IsTokenRevoked(pubkey string, sessionID string, issuedAt time.Time) bool {
+	var revocation TokenRevocation
+	err := db.db.Where("owner_pubkey = ?", pubkey).First(&revocation).Error
+	if err == nil && issuedAt.Unix() <= revocation.RevokedBefore.Unix() {
+		return true
+	}
+
+	if sessionID == "" {
+		return false
+	}
+
+	session, err := db.GetUserSession(sessionID)
+	if err != nil {
+		return true
+	}
+	return session.OwnerPubKey != pubkey || !session.IsActive(time.Now())
+}
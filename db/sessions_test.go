@@ -0,0 +1,46 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserSessions(t *testing.T) {
+	InitTestDB()
+	defer CloseTestDB()
+
+	pubkey := "test_session_" + uuid.New().String()
+	session := UserSession{
+		OwnerPubKey:      pubkey,
+		RefreshTokenHash: "hash_1",
+		ExpiresAt:        time.Now().Add(time.Hour),
+	}
+	assert.NoError(t, TestDB.CreateUserSession(&session))
+
+	sessions, err := TestDB.GetActiveUserSessions(pubkey)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	assert.NoError(t, TestDB.RotateUserSession(session.ID.String(), "hash_1", "hash_2", time.Now().Add(time.Hour)))
+	assert.Error(t, TestDB.RotateUserSession(session.ID.String(), "hash_1", "hash_3", time.Now().Add(time.Hour)))
+
+	issuedAt := time.Now().Add(-time.Minute)
+	assert.False(t, TestDB.IsTokenRevoked(pubkey, session.ID.String(), issuedAt))
+	assert.True(t, TestDB.IsTokenRevoked(pubkey, uuid.New().String(), issuedAt))
+
+	assert.Error(t, TestDB.RevokeUserSession("other_pubkey", session.ID.String(), SessionRevokedByUser))
+	assert.NoError(t, TestDB.RevokeUserSession(pubkey, session.ID.String(), SessionRevokedByUser))
+	assert.True(t, TestDB.IsTokenRevoked(pubkey, session.ID.String(), issuedAt))
+
+	sessions, err = TestDB.GetActiveUserSessions(pubkey)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 0)
+
+	assert.False(t, TestDB.IsTokenRevoked(pubkey, "", issuedAt))
+	assert.NoError(t, TestDB.RevokeAllUserSessions(pubkey, SessionRevokedByAdmin))
+	assert.True(t, TestDB.IsTokenRevoked(pubkey, "", issuedAt))
+	assert.False(t, TestDB.IsTokenRevoked(pubkey, "", time.Now().Add(time.Minute)))
+}
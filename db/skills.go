@@ -31,7 +31,7 @@ func (db database) CreateSkill(skill *Skill) (*Skill, error) {
 	}
 
 	if err := db.db.Create(skill).Error; err != nil {
-		logger.Log.Error("failed to create skill", "error", err)
+		logger.Log.With("error", err).Error("failed to create skill")
 		return nil, fmt.Errorf("failed to create skill: %w", err)
 	}
 
@@ -41,7 +41,7 @@ func (db database) CreateSkill(skill *Skill) (*Skill, error) {
 func (db database) GetAllSkills() ([]Skill, error) {
 	var skills []Skill
 	if err := db.db.Find(&skills).Error; err != nil {
-		logger.Log.Error("failed to get all skills", "error", err)
+		logger.Log.With("error", err).Error("failed to get all skills")
 		return nil, fmt.Errorf("failed to get all skills: %w", err)
 	}
 	return skills, nil
@@ -53,7 +53,7 @@ func (db database) GetSkillByID(id uuid.UUID) (*Skill, error) {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("skill not found with ID: %s", id)
 		}
-		logger.Log.Error("failed to get skill by ID", "error", err, "id", id)
+		logger.Log.With("error", err, "id", id).Error("failed to get skill by ID")
 		return nil, fmt.Errorf("failed to get skill: %w", err)
 	}
 	return &skill, nil
@@ -70,7 +70,7 @@ func (db database) UpdateSkillByID(skill *Skill) (*Skill, error) {
 	}
 
 	if err := db.db.Model(&existingSkill).Updates(skill).Error; err != nil {
-		logger.Log.Error("failed to update skill", "error", err, "id", skill.ID)
+		logger.Log.With("error", err, "id", skill.ID).Error("failed to update skill")
 		return nil, fmt.Errorf("failed to update skill: %w", err)
 	}
 
@@ -93,7 +93,7 @@ func (db database) DeleteSkillByID(id uuid.UUID) error {
 	}
 
 	if err := db.db.Delete(&Skill{ID: id}).Error; err != nil {
-		logger.Log.Error("failed to delete skill", "error", err, "id", id)
+		logger.Log.With("error", err, "id", id).Error("failed to delete skill")
 		return fmt.Errorf("failed to delete skill: %w", err)
 	}
 
@@ -119,7 +119,7 @@ func (db database) CreateSkillInstall(install *SkillInstall) (*SkillInstall, err
 	}
 
 	if err := db.db.Create(install).Error; err != nil {
-		logger.Log.Error("failed to create skill installation", "error", err)
+		logger.Log.With("error", err).Error("failed to create skill installation")
 		return nil, fmt.Errorf("failed to create skill installation: %w", err)
 	}
 
@@ -129,7 +129,7 @@ func (db database) CreateSkillInstall(install *SkillInstall) (*SkillInstall, err
 func (db database) GetSkillInstallBySkillsID(skillID uuid.UUID) ([]SkillInstall, error) {
 	var installs []SkillInstall
 	if err := db.db.Where("skill_id = ?", skillID).Find(&installs).Error; err != nil {
-		logger.Log.Error("failed to get skill installations", "error", err, "skill_id", skillID)
+		logger.Log.With("error", err, "skill_id", skillID).Error("failed to get skill installations")
 		return nil, fmt.Errorf("failed to get skill installations: %w", err)
 	}
 	return installs, nil
@@ -141,7 +141,7 @@ func (db database) GetSkillInstallByID(id uuid.UUID) (*SkillInstall, error) {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("skill installation not found with ID: %s", id)
 		}
-		logger.Log.Error("failed to get skill installation by ID", "error", err, "id", id)
+		logger.Log.With("error", err, "id", id).Error("failed to get skill installation by ID")
 		return nil, fmt.Errorf("failed to get skill installation: %w", err)
 	}
 	return &install, nil
@@ -158,7 +158,7 @@ func (db database) UpdateSkillInstallByID(install *SkillInstall) (*SkillInstall,
 	}
 
 	if err := db.db.Model(&existingInstall).Updates(install).Error; err != nil {
-		logger.Log.Error("failed to update skill installation", "error", err, "id", install.ID)
+		logger.Log.With("error", err, "id", install.ID).Error("failed to update skill installation")
 		return nil, fmt.Errorf("failed to update skill installation: %w", err)
 	}
 
@@ -181,7 +181,7 @@ func (db database) DeleteSkillInstallByID(id uuid.UUID) error {
 	}
 
 	if err := db.db.Delete(&SkillInstall{ID: id}).Error; err != nil {
-		logger.Log.Error("failed to delete skill installation", "error", err, "id", id)
+		logger.Log.With("error", err, "id", id).Error("failed to delete skill installation")
 		return fmt.Errorf("failed to delete skill installation: %w", err)
 	}
 
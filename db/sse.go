@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func (db database) CreateSSEMessageLog(event map[string]interface{}, chatID, from, to string) (*SSEMessageLog, error) {
@@ -151,6 +152,61 @@ func (db database) GetNewSSEMessageLogsByChatID(chatID string) ([]SSEMessageLog,
 	return messageLogs, nil
 }
 
+// ClaimNewSSEMessageLogs moves the new events of a chat to sending in the
+// batch of deliveryID, events claimed by a concurrent batch are left out
+func (db database) ClaimNewSSEMessageLogs(chatID string, deliveryID uuid.UUID) ([]SSEMessageLog, error) {
+	if chatID == "" {
+		return nil, errors.New("chat ID is required")
+	}
+
+	var messageLogs []SSEMessageLog
+	err := db.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("chat_id = ? AND status = ?", chatID, SSEStatusNew).
+			Order("created_at DESC").
+			Find(&messageLogs).Error; err != nil {
+			return err
+		}
+		if len(messageLogs) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(messageLogs))
+		for i := range messageLogs {
+			ids[i] = messageLogs[i].ID
+			messageLogs[i].Status = SSEStatusSending
+			messageLogs[i].DeliveryID = &deliveryID
+		}
+		return tx.Model(&SSEMessageLog{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":      SSEStatusSending,
+				"delivery_id": deliveryID,
+				"updated_at":  time.Now(),
+			}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim new SSE message logs for chat %s: %w", chatID, err)
+	}
+
+	return messageLogs, nil
+}
+
+// GetStaleSendingSSEMessageLogs returns the events still sending since
+// before, whose batch was lost with the process sending it. Batches kept
+// as dead letters are left to be replayed.
+func (db database) GetStaleSendingSSEMessageLogs(before time.Time) ([]SSEMessageLog, error) {
+	var messageLogs []SSEMessageLog
+	if err := db.db.Where("status = ? AND updated_at < ?", SSEStatusSending, before).
+		Where("delivery_id IS NOT NULL AND delivery_id NOT IN (?)", db.db.Model(&WebhookDeadLetter{}).Select("id")).
+		Order("created_at DESC").
+		Find(&messageLogs).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve stale sending SSE message logs: %w", err)
+	}
+
+	return messageLogs, nil
+}
+
 func (db database) GetSSEMessagesByChatID(chatID string, limit int, offset int, status string) ([]SSEMessageLog, int64, error) {
 	var messages []SSEMessageLog
 	var total int64
@@ -189,3 +245,108 @@ func (db database) DeleteOldSSEMessageLogs(maxAge time.Duration) (int64, error)
 
 	return result.RowsAffected, nil
 }
+
+// endedSSEStates are the states of subscriptions that are not resumed
+var endedSSEStates = []SSESubscriptionState{SSESubscriptionStopped, SSESubscriptionExpired}
+
+// SaveSSESubscription records a stream being followed, a stream of the chat
+// that was followed before keeps its last event ID so it resumes from there
+func (db database) SaveSSESubscription(subscription *SSESubscription) (*SSESubscription, error) {
+	if subscription.ChatID == "" {
+		return nil, errors.New("chat ID is required")
+	}
+	if subscription.URL == "" {
+		return nil, errors.New("SSE URL is required")
+	}
+
+	now := time.Now()
+	if subscription.ID == uuid.Nil {
+		subscription.ID = uuid.New()
+	}
+	subscription.CreatedAt = now
+	subscription.UpdatedAt = now
+
+	err := db.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chat_id"}, {Name: "url"}},
+		DoUpdates: clause.AssignmentColumns([]string{"webhook_url", "state", "owner", "heartbeat_at", "updated_at"}),
+	}).Create(subscription).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to save SSE subscription: %w", err)
+	}
+
+	var saved SSESubscription
+	if err := db.db.Where("chat_id = ? AND url = ?", subscription.ChatID, subscription.URL).First(&saved).Error; err != nil {
+		return nil, fmt.Errorf("failed to load SSE subscription: %w", err)
+	}
+	return &saved, nil
+}
+
+func (db database) UpdateSSESubscription(chatID, url string, updates map[string]interface{}) error {
+	values := map[string]interface{}{"updated_at": time.Now()}
+	for column, value := range updates {
+		values[column] = value
+	}
+
+	result := db.db.Model(&SSESubscription{}).
+		Where("chat_id = ? AND url = ?", chatID, url).
+		Updates(values)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update SSE subscription: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("no SSE subscription found for chat %s and URL %s", chatID, url)
+	}
+	return nil
+}
+
+func (db database) GetSSESubscriptions(includeEnded bool) ([]SSESubscription, error) {
+	var subscriptions []SSESubscription
+
+	query := db.db.Model(&SSESubscription{})
+	if !includeEnded {
+		query = query.Where("state NOT IN ?", endedSSEStates)
+	}
+	if err := query.Order("updated_at DESC").Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve SSE subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// ClaimSSESubscriptions makes owner the instance of the running
+// subscriptions nobody has a heartbeat for since staleBefore and returns
+// them
+func (db database) ClaimSSESubscriptions(owner string, staleBefore time.Time) ([]SSESubscription, error) {
+	var subscriptions []SSESubscription
+
+	err := db.db.Model(&subscriptions).
+		Clauses(clause.Returning{}).
+		Where("state NOT IN ?", endedSSEStates).
+		Where("owner = '' OR owner IS NULL OR heartbeat_at IS NULL OR heartbeat_at < ?", staleBefore).
+		Updates(map[string]interface{}{"owner": owner, "heartbeat_at": time.Now()}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim SSE subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+func (db database) TouchSSESubscriptions(owner string) error {
+	err := db.db.Model(&SSESubscription{}).
+		Where("owner = ? AND state NOT IN ?", owner, endedSSEStates).
+		Update("heartbeat_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to touch SSE subscriptions: %w", err)
+	}
+	return nil
+}
+
+// ReleaseSSESubscriptions lets other instances take over the subscriptions
+// of owner right away, without waiting for its heartbeat to go stale
+func (db database) ReleaseSSESubscriptions(owner string) error {
+	err := db.db.Model(&SSESubscription{}).
+		Where("owner = ?", owner).
+		Updates(map[string]interface{}{"owner": "", "heartbeat_at": nil}).Error
+	if err != nil {
+		return fmt.Errorf("failed to release SSE subscriptions: %w", err)
+	}
+	return nil
+}
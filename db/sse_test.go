@@ -732,4 +732,46 @@ func TestGetNewSSEMessageLogsByChatID(t *testing.T) {
 			}
 		})
 	}
-} 
\ No newline at end of file
+} 
+func TestClaimNewSSEMessageLogs(t *testing.T) {
+	InitTestDB()
+	TestDB.db.Exec("DELETE FROM sse_message_logs")
+
+	chatID := "chat-claimed"
+	for _, status := range []SSEMessageStatus{SSEStatusNew, SSEStatusNew, SSEStatusSent} {
+		TestDB.db.Create(&SSEMessageLog{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Event:     map[string]interface{}{"type": "message"},
+			ChatID:    chatID,
+			From:      "https://source.com/sse",
+			To:        "https://target.com/webhook",
+			Status:    status,
+		})
+	}
+
+	deliveryID := uuid.New()
+	claimed, err := TestDB.ClaimNewSSEMessageLogs(chatID, deliveryID)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 2)
+	for _, log := range claimed {
+		assert.Equal(t, SSEStatusSending, log.Status)
+		assert.Equal(t, deliveryID, *log.DeliveryID)
+	}
+
+	again, err := TestDB.ClaimNewSSEMessageLogs(chatID, uuid.New())
+	assert.NoError(t, err)
+	assert.Empty(t, again, "claimed events are not batched again")
+
+	stale, err := TestDB.GetStaleSendingSSEMessageLogs(time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, stale, 2)
+
+	TestDB.db.Create(&WebhookDeadLetter{ID: deliveryID, URL: "https://target.com/webhook", Payload: "{}"})
+	defer TestDB.db.Exec("DELETE FROM webhook_dead_letters WHERE id = ?", deliveryID)
+
+	stale, err = TestDB.GetStaleSendingSSEMessageLogs(time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Empty(t, stale, "batches kept as dead letters are left to be replayed")
+}
@@ -102,6 +102,30 @@ func (s StoreData) GetBudgetInvoiceCache() ([]BudgetStoreData, error) {
 	return c, nil
 }
 
+func (s StoreData) SetInvoiceSubscription(paymentRequest string, host string) error {
+	// Kept for as long as an invoice usually stays payable
+	s.Cache.Set(invoiceSubscriptionKey(paymentRequest), host, time.Hour)
+	return nil
+}
+
+func (s StoreData) GetInvoiceSubscription(paymentRequest string) (string, error) {
+	value, found := s.Cache.Get(invoiceSubscriptionKey(paymentRequest))
+	c, _ := value.(string)
+	if !found || c == "" {
+		return "", errors.New("Invoice subscription not found")
+	}
+	return c, nil
+}
+
+func (s StoreData) DeleteInvoiceSubscription(paymentRequest string) error {
+	s.Cache.Delete(invoiceSubscriptionKey(paymentRequest))
+	return nil
+}
+
+func invoiceSubscriptionKey(paymentRequest string) string {
+	return "invoice_subscription_" + paymentRequest
+}
+
 func (s StoreData) SetSocketConnections(value Client) error {
 	// The websocket in cache should not expire unless when deleted
 	s.Cache.Set(value.Host, value, cache.NoExpiration)
@@ -163,6 +187,7 @@ type VerifyPayload struct {
 	VerificationSignature string                 `json:"verification_signature"`
 	Extras                map[string]interface{} `json:"extras"`
 	TribeJWT              string                 `json:"tribe_jwt"`
+	TribeRefreshToken     string                 `json:"tribe_refresh_token,omitempty"`
 }
 
 // Verify godoc
@@ -272,8 +297,18 @@ func Poll(w http.ResponseWriter, r *http.Request) {
 		"last_login": time.Now().Unix(),
 	})
 
-	tribeJWT, _ := auth.EncodeJwt(pld.Pubkey)
+	session, refreshToken, err := NewUserSession(pld.Pubkey, r.UserAgent(), r.RemoteAddr)
+	if err == nil {
+		err = DB.CreateUserSession(&session)
+	}
+	if err != nil {
+		logger.FromContext(r.Context()).Error("[store] error creating session: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	tribeJWT, _ := auth.EncodeSessionJwt(pld.Pubkey, session.ID.String())
 	pld.TribeJWT = tribeJWT
+	pld.TribeRefreshToken = refreshToken
 
 	// store.DeleteChallenge(challenge)
 
@@ -67,3 +67,27 @@ func TestSetLnCache(t *testing.T) {
 		t.Error("Could not set cache item")
 	}
 }
+
+func TestInvoiceSubscription(t *testing.T) {
+	var paymentRequest = "lnbc_invoice"
+	var host = "websocket_token"
+
+	InitCache()
+	Store.SetInvoiceSubscription(paymentRequest, host)
+	cacheValue, err := Store.GetInvoiceSubscription(paymentRequest)
+
+	if err != nil {
+		t.Error("Cache error thrown")
+	}
+
+	if cacheValue != host {
+		t.Error("Could not set invoice subscription")
+	}
+
+	Store.DeleteInvoiceSubscription(paymentRequest)
+	_, errD := Store.GetInvoiceSubscription(paymentRequest)
+
+	if errD == nil {
+		t.Error("Could not delete invoice subscription")
+	}
+}
@@ -122,6 +122,8 @@ type Person struct {
 	ReferredBy       uint           `json:"referred_by"`
 	Extras           PropertyMap    `json:"extras", type: jsonb not null default '{}'::jsonb`
 	GithubIssues     PropertyMap    `json:"github_issues", type: jsonb not null default '{}'::jsonb`
+	GithubLogin      string         `json:"github_login"`
+	GithubID         int64          `gorm:"index" json:"github_id"`
 }
 
 type GormDataTypeInterface interface {
@@ -569,6 +571,12 @@ type Workspace struct {
 	Tactics      string     `json:"tactics"`
 	SchematicUrl string     `json:"schematic_url"`
 	SchematicImg string     `json:"schematic_img"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	PurgedAt     *time.Time `json:"purged_at,omitempty"`
+	PurgeFlagged bool       `gorm:"default:false" json:"purge_flagged,omitempty"`
+
+	// LightningAddress is the name part of the workspace's Lightning Address
+	LightningAddress *string `gorm:"uniqueIndex" json:"lightning_address,omitempty"`
 }
 
 type WorkspaceShort struct {
@@ -713,6 +721,7 @@ type StatusBudget struct {
 	OrgUuid             string `json:"org_uuid"`
 	WorkspaceUuid       string `json:"workspace_uuid"`
 	CurrentBudget       uint   `json:"current_budget"`
+	ReservedBudget      uint   `json:"reserved_budget"`
 	OpenBudget          uint   `json:"open_budget"`
 	OpenCount           int64  `json:"open_count"`
 	OpenDifference      int    `json:"open_difference"`
@@ -807,6 +816,7 @@ type NewPaymentHistory struct {
 	Tag            string      `json:"tag,omitempty"`
 	PaymentStatus  string      `json:"payment_status,omitempty"`
 	Error          string      `json:"error,omitempty"`
+	Comment        string      `json:"comment,omitempty"`
 	Created        *time.Time  `json:"created"`
 	Updated        *time.Time  `json:"updated"`
 	Status         bool        `json:"status"`
@@ -1165,6 +1175,15 @@ type ChatMessage struct {
 	ContextTags []ContextTag      `json:"contextTags" gorm:"type:jsonb"`
 	Status      ChatMessageStatus `json:"status"`
 	Source      ChatSource        `json:"source"`
+	// ParentID is the message this one follows on its branch, a reply
+	// follows the message it answers and an edited message follows the
+	// parent of the message it replaces
+	ParentID string `json:"parentId,omitempty" gorm:"index"`
+	// StreamChunks is the number of chunks appended to a streamed reply
+	StreamChunks int `json:"-" gorm:"default:0"`
+	// StreamParentID is set on a streamed reply to the message it answers,
+	// a message has at most one
+	StreamParentID *string `json:"-" gorm:"uniqueIndex"`
 }
 
 type ChatStatus string
@@ -1179,8 +1198,67 @@ type Chat struct {
 	WorkspaceID string     `json:"workspaceId" gorm:"index"`
 	Title       string     `json:"title"`
 	Status      ChatStatus `json:"status" gorm:"default:active"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
+	// ActiveMessageID is the last message of the branch the chat shows,
+	// empty for chats whose messages were never threaded into branches
+	ActiveMessageID string    `json:"activeMessageId,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// ChatFork is a message that was followed by more than one message, one
+// branch for each time a message after it was edited
+type ChatFork struct {
+	ParentID string       `json:"parentId"`
+	Branches []ChatBranch `json:"branches"`
+}
+
+// ChatBranch starts at the message following the fork and ends at LeafID,
+// the end of its latest sub-branch
+type ChatBranch struct {
+	MessageID string    `json:"messageId"`
+	Role      ChatRole  `json:"role"`
+	Preview   string    `json:"preview"`
+	LeafID    string    `json:"leafId"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ChatSearchParams narrow a search of the chat history of a workspace,
+// an artifact type leaves the messages themselves out
+type ChatSearchParams struct {
+	WorkspaceID  string
+	Query        string
+	ChatID       string
+	Role         ChatRole
+	ArtifactType ArtifactType
+	From         *time.Time
+	To           *time.Time
+	Limit        int
+	Offset       int
+}
+
+type ChatSearchKind string
+
+const (
+	MessageSearchResult  ChatSearchKind = "message"
+	ArtifactSearchResult ChatSearchKind = "artifact"
+)
+
+// ChatSearchResult is a message or an artifact matching a search, with the
+// matching part of its text in the snippet
+type ChatSearchResult struct {
+	Kind         ChatSearchKind `json:"kind"`
+	ChatID       string         `json:"chatId"`
+	ChatTitle    string         `json:"chatTitle"`
+	ChatStatus   ChatStatus     `json:"chatStatus"`
+	MessageID    string         `json:"messageId"`
+	Role         ChatRole       `json:"role"`
+	ArtifactID   *uuid.UUID     `json:"artifactId,omitempty"`
+	ArtifactType ArtifactType   `json:"artifactType,omitempty"`
+	Snippet      string         `json:"snippet"`
+	Rank         float64        `json:"rank"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	Link         string         `json:"link" gorm:"-"`
 }
 
 type ChatWorkflowStatus struct {
@@ -1511,6 +1589,7 @@ type ChatWorkflow struct {
 	WorkspaceID string    `json:"workspaceId" gorm:"index;not null"`
 	URL         string    `json:"url" gorm:"type:text;not null"`
 	StackworkID string    `json:"stackworkId" gorm:"column:stackwork_id"`
+	Backend     string    `json:"backend" gorm:"type:varchar(20);default:''"`
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
@@ -1567,8 +1646,11 @@ type SkillInstall struct {
 type SSEMessageStatus string
 
 const (
-	SSEStatusNew  SSEMessageStatus = "new"
-	SSEStatusSent SSEMessageStatus = "sent"
+	SSEStatusNew SSEMessageStatus = "new"
+	// SSEStatusSending is an event in a batch handed to the webhook
+	// dispatcher that is not delivered yet
+	SSEStatusSending SSEMessageStatus = "sending"
+	SSEStatusSent    SSEMessageStatus = "sent"
 )
 
 type SSEMessageLog struct {
@@ -1580,6 +1662,65 @@ type SSEMessageLog struct {
 	From      string           `gorm:"not null" json:"from"`
 	To        string           `gorm:"not null" json:"to"`
 	Status    SSEMessageStatus `gorm:"type:varchar(10);default:'new'" json:"status"`
+	// DeliveryID is the webhook delivery the event was batched in, it is
+	// kept when the batch is sent again
+	DeliveryID *uuid.UUID `gorm:"type:uuid;index" json:"delivery_id,omitempty"`
+}
+
+type SSESubscriptionState string
+
+const (
+	SSESubscriptionConnecting SSESubscriptionState = "connecting"
+	SSESubscriptionConnected  SSESubscriptionState = "connected"
+	SSESubscriptionRetrying   SSESubscriptionState = "retrying"
+	SSESubscriptionStopped    SSESubscriptionState = "stopped"
+	SSESubscriptionExpired    SSESubscriptionState = "expired"
+)
+
+// SSESubscription is a Stakwork stream followed for a chat. It outlives
+// the process so the stream is resumed after a restart, Owner is the
+// instance running it and HeartbeatAt when that instance last said so.
+type SSESubscription struct {
+	ID          uuid.UUID            `gorm:"primaryKey;type:uuid" json:"id"`
+	ChatID      string               `gorm:"uniqueIndex:idx_sse_subscription_chat_url;not null" json:"chat_id"`
+	URL         string               `gorm:"uniqueIndex:idx_sse_subscription_chat_url;not null" json:"url"`
+	WebhookURL  string               `json:"webhook_url"`
+	LastEventID string               `json:"last_event_id"`
+	LastEventAt *time.Time           `json:"last_event_at"`
+	State       SSESubscriptionState `gorm:"type:varchar(20);index" json:"state"`
+	ErrorCount  int                  `gorm:"default:0" json:"error_count"`
+	LastError   string               `json:"last_error"`
+	Owner       string               `gorm:"index" json:"owner"`
+	HeartbeatAt *time.Time           `json:"heartbeat_at"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+type WebhookDeadLetterStatus string
+
+const (
+	WebhookDeadLetterDead      WebhookDeadLetterStatus = "dead"
+	WebhookDeadLetterReplaying WebhookDeadLetterStatus = "replaying"
+	WebhookDeadLetterDelivered WebhookDeadLetterStatus = "delivered"
+)
+
+// WebhookDeadLetter is an outbound webhook delivery that failed every
+// attempt, kept with its payload so it can be replayed
+type WebhookDeadLetter struct {
+	ID          uuid.UUID               `gorm:"primaryKey;type:uuid" json:"id"`
+	Kind        string                  `gorm:"type:varchar(50);index" json:"kind"`
+	URL         string                  `gorm:"not null" json:"url"`
+	Payload     string                  `gorm:"type:text;not null" json:"payload"`
+	Refs        pq.StringArray          `gorm:"type:text[]" json:"refs"`
+	Attempts    int                     `gorm:"default:0" json:"attempts"`
+	LastStatus  int                     `json:"last_status"`
+	LastError   string                  `gorm:"type:text" json:"last_error"`
+	Status      WebhookDeadLetterStatus `gorm:"type:varchar(20);index;default:'dead'" json:"status"`
+	Replays     int                     `gorm:"default:0" json:"replays"`
+	ReplayedAt  *time.Time              `json:"replayed_at"`
+	DeliveredAt *time.Time              `json:"delivered_at"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
 }
 
 type CodeSpaceMap struct {
@@ -1618,6 +1759,162 @@ type BountyStake struct {
 	UpdatedAt    time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
+const WorkspaceArchiveVersion = 1
+
+type WorkspaceArchiveManifest struct {
+	Version       int            `json:"version"`
+	ExportedAt    time.Time      `json:"exported_at"`
+	SourceHost    string         `json:"source_host"`
+	WorkspaceUuid string         `json:"workspace_uuid"`
+	Counts        map[string]int `json:"counts"`
+}
+
+type WorkspaceArchive struct {
+	Manifest     WorkspaceArchiveManifest `json:"manifest"`
+	Workspace    Workspace                `json:"workspace"`
+	Repositories []WorkspaceRepositories  `json:"repositories"`
+	CodeGraphs   []WorkspaceCodeGraph     `json:"code_graphs"`
+	Features     []WorkspaceFeatures      `json:"features"`
+	Phases       []FeaturePhase           `json:"phases"`
+	Stories      []FeatureStory           `json:"stories"`
+	Tickets      []Tickets                `json:"tickets"`
+	TicketPlans  []TicketPlan             `json:"ticket_plans"`
+	Snippets     []TextSnippet            `json:"snippets"`
+	Activities   []Activity               `json:"activities"`
+	Chats        []Chat                   `json:"chats"`
+	ChatMessages []ChatMessage            `json:"chat_messages"`
+	Artifacts    []Artifact               `json:"artifacts"`
+	FileAssets   []FileAsset              `json:"file_assets"`
+
+	// Files holds the contents of the file assets keyed by their hash
+	Files map[string][]byte `json:"-"`
+}
+
+type WorkspaceImportConflict struct {
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+	Message  string `json:"message"`
+	Blocking bool   `json:"blocking"`
+}
+
+type WorkspaceImportReport struct {
+	DryRun        bool                      `json:"dry_run"`
+	Imported      bool                      `json:"imported"`
+	WorkspaceUuid string                    `json:"workspace_uuid"`
+	WorkspaceName string                    `json:"workspace_name"`
+	Counts        map[string]int            `json:"counts"`
+	Conflicts     []WorkspaceImportConflict `json:"conflicts"`
+}
+
+type WorkspaceAPIKey struct {
+	ID            uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
+	WorkspaceUuid string         `gorm:"type:varchar(255);index;not null" json:"workspace_uuid"`
+	Name          string         `gorm:"type:varchar(255);not null" json:"name"`
+	Prefix        string         `gorm:"type:varchar(20)" json:"prefix"`
+	KeyHash       string         `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	Scopes        pq.StringArray `gorm:"type:text[];not null;default:'{}'" json:"scopes"`
+	CreatedBy     string         `gorm:"type:varchar(255)" json:"created_by"`
+	ExpiresAt     *time.Time     `json:"expires_at,omitempty"`
+	LastUsedAt    *time.Time     `json:"last_used_at,omitempty"`
+	RevokedAt     *time.Time     `json:"revoked_at,omitempty"`
+	CreatedAt     time.Time      `gorm:"type:timestamp;default:current_timestamp" json:"created_at"`
+	UpdatedAt     time.Time      `gorm:"type:timestamp;default:current_timestamp" json:"updated_at"`
+}
+
+type CreateWorkspaceAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type CreateWorkspaceAPIKeyResponse struct {
+	Key    string          `json:"key"`
+	APIKey WorkspaceAPIKey `json:"api_key"`
+}
+
+const (
+	SessionRevokedByUser   = "user"
+	SessionRevokedByAdmin  = "admin"
+	SessionRevokedForReuse = "refresh_token_reuse"
+)
+
+type UserSession struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	OwnerPubKey      string     `gorm:"column:owner_pubkey;type:varchar(255);index;not null" json:"owner_pubkey"`
+	RefreshTokenHash string     `gorm:"type:varchar(64);not null" json:"-"`
+	UserAgent        string     `gorm:"type:text" json:"user_agent"`
+	IPAddress        string     `gorm:"type:varchar(64)" json:"ip_address"`
+	CreatedAt        time.Time  `gorm:"type:timestamp;default:current_timestamp" json:"created_at"`
+	LastUsedAt       time.Time  `gorm:"type:timestamp;default:current_timestamp" json:"last_used_at"`
+	ExpiresAt        time.Time  `gorm:"type:timestamp;index" json:"expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason    string     `gorm:"type:varchar(50)" json:"revoked_reason,omitempty"`
+	Current          bool       `gorm:"-" json:"current"`
+}
+
+// TokenRevocation rejects every token for a pubkey issued before RevokedBefore,
+// including tokens that were issued without a session
+type TokenRevocation struct {
+	OwnerPubKey   string    `gorm:"column:owner_pubkey;type:varchar(255);primaryKey" json:"owner_pubkey"`
+	RevokedBefore time.Time `gorm:"type:timestamp" json:"revoked_before"`
+}
+
+const (
+	IdentityNode    = "node"
+	IdentityNostr   = "nostr"
+	IdentityGithub  = "github"
+	IdentityTwitter = "twitter"
+)
+
+// PersonIdentity is an extra key or handle linked to a person, OwnerPubKey is
+// always the person's canonical pubkey
+type PersonIdentity struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	OwnerPubKey string    `gorm:"column:owner_pubkey;type:varchar(255);index;not null" json:"owner_pubkey"`
+	Type        string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_person_identity_type_value" json:"type"`
+	Value       string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_person_identity_type_value" json:"value"`
+	Verified    bool      `gorm:"default:false" json:"verified"`
+	CreatedAt   time.Time `gorm:"type:timestamp;default:current_timestamp" json:"created_at"`
+}
+
+type MergePeopleRequest struct {
+	SourcePubKey string `json:"source_pubkey"`
+	TargetPubKey string `json:"target_pubkey"`
+}
+
+const (
+	PayoutPending   = "pending"
+	PayoutRedeeming = "redeeming"
+	PayoutRedeemed  = "redeemed"
+	PayoutExpired   = "expired"
+)
+
+// BountyPayout is a one-time LNURL-withdraw code for a bounty's hunter, the
+// amount stays reserved in the workspace budget until it is redeemed or expires
+type BountyPayout struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	K1             string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	LNURL          string     `gorm:"type:text" json:"-"`
+	BountyID       uint       `gorm:"index;not null" json:"bounty_id"`
+	WorkspaceUuid  string     `gorm:"type:varchar(255);index;not null" json:"workspace_uuid"`
+	SenderPubKey   string     `gorm:"type:varchar(255)" json:"sender_pubkey"`
+	ReceiverPubKey string     `gorm:"type:varchar(255);index" json:"receiver_pubkey"`
+	Amount         uint       `gorm:"not null" json:"amount"`
+	Status         string     `gorm:"type:varchar(20);index;not null" json:"status"`
+	PaymentRequest string     `gorm:"type:text" json:"payment_request,omitempty"`
+	PaymentHash    string     `gorm:"type:varchar(64);index" json:"payment_hash,omitempty"`
+	Error          string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt      time.Time  `gorm:"type:timestamp;default:current_timestamp" json:"created_at"`
+	ExpiresAt      time.Time  `gorm:"type:timestamp;index" json:"expires_at"`
+	ClaimedAt      *time.Time `gorm:"type:timestamp" json:"claimed_at,omitempty"`
+	RedeemedAt     *time.Time `gorm:"type:timestamp" json:"redeemed_at,omitempty"`
+}
+
+type BountyPayoutResponse struct {
+	BountyPayout
+	LNURL string `json:"lnurl,omitempty"`
+}
+
 func (Person) TableName() string {
 	return "people"
 }
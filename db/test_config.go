@@ -90,9 +90,17 @@ func InitTestDB() {
 	db.AutoMigrate(&Skill{})
 	db.AutoMigrate(&SkillInstall{})
 	db.AutoMigrate(&SSEMessageLog{})
+	db.AutoMigrate(&WebhookDeadLetter{})
 	db.AutoMigrate(&CodeSpaceMap{})
 	db.AutoMigrate(&BountyStake{})
 	db.AutoMigrate(&ChatWorkflowStatus{})
+	db.AutoMigrate(&WorkspaceAPIKey{})
+	db.AutoMigrate(&UserSession{})
+	db.AutoMigrate(&TokenRevocation{})
+	db.AutoMigrate(&PersonIdentity{})
+	db.AutoMigrate(&BountyPayout{})
+
+	createChatSearchIndexes(db)
 	
 	people := TestDB.GetAllPeople()
 	for _, p := range people {
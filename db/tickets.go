@@ -241,7 +241,7 @@ func (db database) CreateBountyFromTicket(ticket Tickets, pubkey string) (*NewBo
 	}
 
 	if err := db.db.Create(bounty).Error; err != nil {
-		logger.Log.Error("failed to create bounty", "error", err, "ticket_id", ticket.UUID)
+		logger.Log.With("error", err, "ticket_id", ticket.UUID).Error("failed to create bounty")
 		return nil, fmt.Errorf("failed to create bounty: %w", err)
 	}
 
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveWebhookDeadLetter records a failed delivery, a replay that failed
+// again updates the dead letter it came from
+func (db database) SaveWebhookDeadLetter(letter *WebhookDeadLetter) (*WebhookDeadLetter, error) {
+	if letter.URL == "" {
+		return nil, errors.New("webhook URL is required")
+	}
+	if letter.ID == uuid.Nil {
+		letter.ID = uuid.New()
+	}
+	if letter.Status == "" {
+		letter.Status = WebhookDeadLetterDead
+	}
+
+	now := time.Now()
+	letter.UpdatedAt = now
+	if letter.CreatedAt.IsZero() {
+		letter.CreatedAt = now
+	}
+
+	err := db.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"attempts", "last_status", "last_error", "status", "updated_at"}),
+	}).Create(letter).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to save webhook dead letter: %w", err)
+	}
+	return letter, nil
+}
+
+func (db database) GetWebhookDeadLetters(status string, limit, offset int) ([]WebhookDeadLetter, int64, error) {
+	var letters []WebhookDeadLetter
+	var total int64
+
+	query := db.db.Model(&WebhookDeadLetter{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook dead letters: %w", err)
+	}
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&letters).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve webhook dead letters: %w", err)
+	}
+	return letters, total, nil
+}
+
+func (db database) GetWebhookDeadLetterByID(id uuid.UUID) (*WebhookDeadLetter, error) {
+	var letter WebhookDeadLetter
+	if err := db.db.First(&letter, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("webhook dead letter with ID %s not found", id)
+		}
+		return nil, fmt.Errorf("failed to retrieve webhook dead letter: %w", err)
+	}
+	return &letter, nil
+}
+
+// ResetStaleWebhookDeadLetters puts back the dead letters replaying since
+// before, their replay was lost with the process running it
+func (db database) ResetStaleWebhookDeadLetters(before time.Time) (int64, error) {
+	result := db.db.Model(&WebhookDeadLetter{}).
+		Where("status = ? AND replayed_at < ?", WebhookDeadLetterReplaying, before).
+		Updates(map[string]interface{}{
+			"status":     WebhookDeadLetterDead,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to reset stale webhook dead letters: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (db database) UpdateWebhookDeadLetter(id uuid.UUID, updates map[string]interface{}) error {
+	values := map[string]interface{}{"updated_at": time.Now()}
+	for column, value := range updates {
+		values[column] = value
+	}
+
+	result := db.db.Model(&WebhookDeadLetter{}).Where("id = ?", id).Updates(values)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update webhook dead letter: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("webhook dead letter with ID %s not found", id)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (db database) GetWorkspaceArchive(workspace_uuid string) (WorkspaceArchive, error) {
+	archive := WorkspaceArchive{}
+
+	if err := db.db.Model(&Workspace{}).Where("uuid = ?", workspace_uuid).First(&archive.Workspace).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return archive, errors.New("workspace not found")
+		}
+		return archive, fmt.Errorf("failed to fetch workspace: %w", err)
+	}
+
+	featureUuids := db.db.Model(&WorkspaceFeatures{}).Select("uuid").Where("workspace_uuid = ?", workspace_uuid)
+	chatIds := db.db.Model(&Chat{}).Select("id").Where("workspace_id = ?", workspace_uuid)
+	messageIds := db.db.Model(&ChatMessage{}).Select("id").Where("chat_id IN (?)", chatIds)
+
+	queries := []struct {
+		dest  interface{}
+		query string
+		arg   interface{}
+		order string
+	}{
+		{&archive.Repositories, "workspace_uuid = ?", workspace_uuid, "created"},
+		{&archive.CodeGraphs, "workspace_uuid = ?", workspace_uuid, "created"},
+		{&archive.Features, "workspace_uuid = ?", workspace_uuid, "created"},
+		{&archive.Phases, "feature_uuid IN (?)", featureUuids, "priority"},
+		{&archive.Stories, "feature_uuid IN (?)", featureUuids, "priority"},
+		{&archive.Tickets, "workspace_uuid = ?", workspace_uuid, "created_at"},
+		{&archive.TicketPlans, "workspace_uuid = ?", workspace_uuid, "created_at"},
+		{&archive.Snippets, "workspace_uuid = ?", workspace_uuid, "date_created"},
+		{&archive.Activities, "workspace = ?", workspace_uuid, "time_created"},
+		{&archive.Chats, "workspace_id = ?", workspace_uuid, "created_at"},
+		{&archive.ChatMessages, "chat_id IN (?)", chatIds, "timestamp"},
+		{&archive.Artifacts, "message_id IN (?)", messageIds, "created_at"},
+	}
+
+	for _, q := range queries {
+		if err := db.db.Where(q.query, q.arg).Order(q.order).Find(q.dest).Error; err != nil {
+			return archive, fmt.Errorf("failed to export workspace data: %w", err)
+		}
+	}
+
+	if err := db.db.Where("workspace_id = ?", workspace_uuid).Where("status != ?", DeletedFileStatus).Order("upload_time").Find(&archive.FileAssets).Error; err != nil {
+		return archive, fmt.Errorf("failed to export workspace files: %w", err)
+	}
+
+	archive.Manifest = WorkspaceArchiveManifest{
+		Version:       WorkspaceArchiveVersion,
+		ExportedAt:    time.Now(),
+		WorkspaceUuid: workspace_uuid,
+		Counts:        archive.Counts(),
+	}
+
+	return archive, nil
+}
+
+// ImportWorkspaceArchive stores an already remapped archive, every row is
+// inserted as new so UUIDs must not collide with existing records
+func (db database) ImportWorkspaceArchive(archive WorkspaceArchive) error {
+	if archive.Workspace.Uuid == "" {
+		return errors.New("archive has no workspace")
+	}
+
+	return db.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		archive.Workspace.ID = 0
+		archive.Workspace.Updated = &now
+		archive.Workspace.Deleted = false
+		archive.Workspace.DeletedAt = nil
+		archive.Workspace.PurgedAt = nil
+
+		if err := tx.Create(&archive.Workspace).Error; err != nil {
+			return fmt.Errorf("failed to import workspace: %w", err)
+		}
+
+		collections := []struct {
+			name string
+			rows interface{}
+			size int
+		}{
+			{"repositories", &archive.Repositories, len(archive.Repositories)},
+			{"code graphs", &archive.CodeGraphs, len(archive.CodeGraphs)},
+			{"features", &archive.Features, len(archive.Features)},
+			{"phases", &archive.Phases, len(archive.Phases)},
+			{"stories", &archive.Stories, len(archive.Stories)},
+			{"tickets", &archive.Tickets, len(archive.Tickets)},
+			{"ticket plans", &archive.TicketPlans, len(archive.TicketPlans)},
+			{"snippets", &archive.Snippets, len(archive.Snippets)},
+			{"activities", &archive.Activities, len(archive.Activities)},
+			{"chats", &archive.Chats, len(archive.Chats)},
+			{"chat messages", &archive.ChatMessages, len(archive.ChatMessages)},
+			{"artifacts", &archive.Artifacts, len(archive.Artifacts)},
+			{"file assets", &archive.FileAssets, len(archive.FileAssets)},
+		}
+
+		for _, c := range collections {
+			if c.size == 0 {
+				continue
+			}
+			if err := tx.Omit(clause.Associations).CreateInBatches(c.rows, 100).Error; err != nil {
+				return fmt.Errorf("failed to import %s: %w", c.name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (a WorkspaceArchive) Counts() map[string]int {
+	return map[string]int{
+		"repositories":  len(a.Repositories),
+		"code_graphs":   len(a.CodeGraphs),
+		"features":      len(a.Features),
+		"phases":        len(a.Phases),
+		"stories":       len(a.Stories),
+		"tickets":       len(a.Tickets),
+		"ticket_plans":  len(a.TicketPlans),
+		"snippets":      len(a.Snippets),
+		"activities":    len(a.Activities),
+		"chats":         len(a.Chats),
+		"chat_messages": len(a.ChatMessages),
+		"artifacts":     len(a.Artifacts),
+		"file_assets":   len(a.FileAssets),
+	}
+}
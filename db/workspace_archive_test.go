@@ -0,0 +1,58 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkspaceArchiveExportImport(t *testing.T) {
+	InitTestDB()
+	defer CloseTestDB()
+
+	workspace := Workspace{
+		Uuid:        uuid.New().String(),
+		Name:        fmt.Sprintf("Test Workspace Export %s", uuid.New().String()),
+		OwnerPubKey: "test_export_owner",
+	}
+	TestDB.db.Create(&workspace)
+
+	feature := WorkspaceFeatures{Uuid: uuid.New().String(), WorkspaceUuid: workspace.Uuid, Name: "test_export_feature"}
+	TestDB.db.Create(&feature)
+	TestDB.db.Create(&FeaturePhase{Uuid: uuid.New().String(), FeatureUuid: feature.Uuid, Name: "test_export_phase"})
+
+	chat := Chat{ID: uuid.New().String(), WorkspaceID: workspace.Uuid, Title: "test_export_chat"}
+	TestDB.db.Create(&chat)
+	TestDB.db.Create(&ChatMessage{ID: uuid.New().String(), ChatID: chat.ID, Message: "hello"})
+
+	archive, err := TestDB.GetWorkspaceArchive(workspace.Uuid)
+	assert.NoError(t, err)
+	assert.Equal(t, WorkspaceArchiveVersion, archive.Manifest.Version)
+	assert.Equal(t, 1, archive.Manifest.Counts["features"])
+	assert.Equal(t, 1, archive.Manifest.Counts["phases"])
+	assert.Equal(t, 1, archive.Manifest.Counts["chat_messages"])
+
+	_, err = TestDB.GetWorkspaceArchive(uuid.New().String())
+	assert.Error(t, err)
+
+	archive.Workspace.Uuid = uuid.New().String()
+	archive.Workspace.Name = fmt.Sprintf("Test Workspace Import %s", uuid.New().String())
+	archive.Features[0].ID = 0
+	archive.Features[0].Uuid = uuid.New().String()
+	archive.Features[0].WorkspaceUuid = archive.Workspace.Uuid
+	archive.Phases[0].Uuid = uuid.New().String()
+	archive.Phases[0].FeatureUuid = archive.Features[0].Uuid
+	archive.Chats[0].ID = uuid.New().String()
+	archive.Chats[0].WorkspaceID = archive.Workspace.Uuid
+	archive.ChatMessages[0].ID = uuid.New().String()
+	archive.ChatMessages[0].ChatID = archive.Chats[0].ID
+
+	assert.NoError(t, TestDB.ImportWorkspaceArchive(archive))
+
+	imported, err := TestDB.GetWorkspaceArchive(archive.Workspace.Uuid)
+	assert.NoError(t, err)
+	assert.Equal(t, archive.Workspace.Name, imported.Workspace.Name)
+	assert.Equal(t, archive.Counts(), imported.Counts())
+}
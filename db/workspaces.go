@@ -9,8 +9,14 @@ import (
 	"time"
 
 	"github.com/stakwork/sphinx-tribes/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var ErrLightningAddressTaken = errors.New("lightning address is taken by another workspace")
+
+var ErrInvoiceAlreadyPaid = errors.New("cannot process already paid invoice")
+
 func (db database) GetWorkspaces(r *http.Request) []Workspace {
 	ms := []Workspace{}
 	offset, limit, sortBy, direction, search := utils.GetPaginationParams(r)
@@ -47,11 +53,55 @@ func (db database) GetWorkspaceByName(name string) Workspace {
 	return ms
 }
 
+// GetWorkspaceByLightningAddress finds the workspace a Lightning Address
+// name was assigned to
+func (db database) GetWorkspaceByLightningAddress(name string) Workspace {
+	ms := Workspace{}
+
+	db.db.Model(&Workspace{}).
+		Where("lightning_address = ?", name).
+		Where("deleted != ?", true).
+		Find(&ms)
+
+	return ms
+}
+
+// SetWorkspaceLightningAddress assigns a Lightning Address name to a
+// workspace. Names stay taken by deleted workspaces too, so that a payment to
+// an old address never reaches another workspace.
+func (db database) SetWorkspaceLightningAddress(uuid string, name string) (Workspace, error) {
+	ms := Workspace{}
+
+	err := db.db.Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		if err := tx.Model(&Workspace{}).
+			Where("lightning_address = ?", name).
+			Where("uuid != ?", uuid).
+			Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrLightningAddressTaken
+		}
+
+		// the unique index still refuses a name assigned concurrently
+		if err := tx.Model(&Workspace{}).Where("uuid = ?", uuid).Update("lightning_address", name).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Workspace{}).Where("uuid = ?", uuid).First(&ms).Error
+	})
+
+	return ms, err
+}
+
 func (db database) CreateOrEditWorkspace(m Workspace) (Workspace, error) {
 	if m.OwnerPubKey == "" {
 		return Workspace{}, errors.New("no pub key")
 	}
 
+	// the Lightning Address is only assigned through SetWorkspaceLightningAddress
+	m.LightningAddress = nil
+
 	if db.db.Model(&m).Where("uuid = ?", m.Uuid).Updates(&m).RowsAffected == 0 {
 		db.db.Create(&m)
 	}
@@ -172,6 +222,14 @@ func (db database) GetUserCreatedWorkspaces(pubkey string) []Workspace {
 	return ms
 }
 
+// GetUserDeletedWorkspaces returns the deleted workspaces of an owner that
+// have not been purged yet
+func (db database) GetUserDeletedWorkspaces(pubkey string) []Workspace {
+	ms := []Workspace{}
+	db.db.Where("owner_pub_key = ?", pubkey).Where("deleted = ?", true).Where("purged_at IS NULL").Order("deleted_at DESC").Find(&ms)
+	return ms
+}
+
 func (db database) GetUserAssignedWorkspaces(pubkey string) []WorkspaceUsers {
 	ms := []WorkspaceUsers{}
 	db.db.Where("owner_pub_key = ?", pubkey).Find(&ms)
@@ -216,34 +274,43 @@ func (db database) DeleteWorkspaceBudget() error {
 func (db database) GetWorkspaceStatusBudget(workspace_uuid string) StatusBudget {
 	workspaceBudget := db.GetWorkspaceBudget(workspace_uuid)
 
+	// the amount reserved for payouts is not available to the bounties, and
+	// the bounties it covers no longer need the budget
+	reservedBudget := db.GetWorkspaceReservedPayouts(workspace_uuid)
+	availableBudget := workspaceBudget.TotalBudget - reservedBudget
+	reservedBounties := db.db.Model(&BountyPayout{}).
+		Where("workspace_uuid = ? AND status IN ?", workspace_uuid, []string{PayoutPending, PayoutRedeeming}).
+		Select("bounty_id")
+
 	var openBudget uint
-	db.db.Model(&NewBounty{}).Where("workspace_uuid = ?", workspace_uuid).Where("assignee = '' ").Where("paid != ?", true).Select("SUM(price)").Row().Scan(&openBudget)
+	db.db.Model(&NewBounty{}).Where("workspace_uuid = ?", workspace_uuid).Where("assignee = '' ").Where("paid != ?", true).Where("id NOT IN (?)", reservedBounties).Select("SUM(price)").Row().Scan(&openBudget)
 
 	var openCount int64
-	db.db.Model(&NewBounty{}).Where("workspace_uuid = ?", workspace_uuid).Where("assignee = '' ").Where("paid != ?", true).Count(&openCount)
+	db.db.Model(&NewBounty{}).Where("workspace_uuid = ?", workspace_uuid).Where("assignee = '' ").Where("paid != ?", true).Where("id NOT IN (?)", reservedBounties).Count(&openCount)
 
-	var openDifference int = int(workspaceBudget.TotalBudget - openBudget)
+	var openDifference int = int(availableBudget - openBudget)
 
 	var assignedBudget uint
-	db.db.Model(&NewBounty{}).Where("workspace_uuid = ?", workspace_uuid).Where("assignee != '' ").Where("paid != ?", true).Where("completed != ?", true).Select("SUM(price)").Row().Scan(&assignedBudget)
+	db.db.Model(&NewBounty{}).Where("workspace_uuid = ?", workspace_uuid).Where("assignee != '' ").Where("paid != ?", true).Where("completed != ?", true).Where("id NOT IN (?)", reservedBounties).Select("SUM(price)").Row().Scan(&assignedBudget)
 
 	var assignedCount int64
-	db.db.Model(&NewBounty{}).Where("workspace_uuid = ?", workspace_uuid).Where("assignee != '' ").Where("paid != ?", true).Where("completed != ?", true).Count(&assignedCount)
+	db.db.Model(&NewBounty{}).Where("workspace_uuid = ?", workspace_uuid).Where("assignee != '' ").Where("paid != ?", true).Where("completed != ?", true).Where("id NOT IN (?)", reservedBounties).Count(&assignedCount)
 
-	var assignedDifference int = int(workspaceBudget.TotalBudget - assignedBudget)
+	var assignedDifference int = int(availableBudget - assignedBudget)
 
 	var completedBudget uint
-	db.db.Model(&NewBounty{}).Where("workspace_uuid = ?", workspace_uuid).Where("completed = ?", true).Where("paid != ?", true).Select("SUM(price)").Row().Scan(&completedBudget)
+	db.db.Model(&NewBounty{}).Where("workspace_uuid = ?", workspace_uuid).Where("completed = ?", true).Where("paid != ?", true).Where("id NOT IN (?)", reservedBounties).Select("SUM(price)").Row().Scan(&completedBudget)
 
 	var completedCount int64
-	db.db.Model(&NewBounty{}).Where("workspace_uuid = ?", workspace_uuid).Where("completed = ?", true).Where("paid != ?", true).Count(&completedCount)
+	db.db.Model(&NewBounty{}).Where("workspace_uuid = ?", workspace_uuid).Where("completed = ?", true).Where("paid != ?", true).Where("id NOT IN (?)", reservedBounties).Count(&completedCount)
 
-	var completedDifference int = int(workspaceBudget.TotalBudget - completedBudget)
+	var completedDifference int = int(availableBudget - completedBudget)
 
 	statusBudget := StatusBudget{
 		OrgUuid:             workspace_uuid,
 		WorkspaceUuid:       workspace_uuid,
 		CurrentBudget:       workspaceBudget.TotalBudget,
+		ReservedBudget:      reservedBudget,
 		OpenBudget:          openBudget,
 		OpenCount:           openCount,
 		OpenDifference:      openDifference,
@@ -284,12 +351,14 @@ func (db database) ProcessUpdateBudget(non_tx_invoice NewInvoiceList) error {
 	created := non_tx_invoice.Created
 	workspace_uuid := non_tx_invoice.WorkspaceUuid
 
+	// the row lock makes every other settlement of the invoice wait for this
+	// one and then see it paid, on any instance
 	invoice := NewInvoiceList{}
-	tx.Where("payment_request = ?", non_tx_invoice.PaymentRequest).Find(&invoice)
+	tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("payment_request = ?", non_tx_invoice.PaymentRequest).Find(&invoice)
 
 	if invoice.Status {
 		tx.Rollback()
-		return errors.New("cannot process already paid invoice")
+		return ErrInvoiceAlreadyPaid
 	}
 
 	if workspace_uuid == "" {
@@ -310,7 +379,7 @@ func (db database) ProcessUpdateBudget(non_tx_invoice NewInvoiceList) error {
 
 		// get Workspace budget and add payment to total budget
 		workspaceBudget := NewBountyBudget{}
-		tx.Model(&NewBountyBudget{}).Where("workspace_uuid = ?", workspace_uuid).Find(&workspaceBudget)
+		tx.Model(&NewBountyBudget{}).Clauses(clause.Locking{Strength: "UPDATE"}).Where("workspace_uuid = ?", workspace_uuid).Find(&workspaceBudget)
 
 		if workspaceBudget.WorkspaceUuid == "" {
 			now := time.Now()
@@ -376,10 +445,11 @@ func (db database) AddAndUpdateBudget(invoice NewInvoiceList) NewPaymentHistory
 	tx.Model(&NewPaymentHistory{}).Where("created = ?", created).Where("workspace_uuid = ? ", workspace_uuid).Find(&paymentHistory)
 
 	dbInvoice := NewInvoiceList{}
-	tx.Where("payment_request = ?", invoice.PaymentRequest).Find(&dbInvoice)
+	tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("payment_request = ?", invoice.PaymentRequest).Find(&dbInvoice)
 
-	if invoice.Status {
+	if invoice.Status || dbInvoice.Status {
 		tx.Rollback()
+		return paymentHistory
 	}
 
 	if paymentHistory.WorkspaceUuid != "" && paymentHistory.Amount != 0 {
@@ -388,7 +458,7 @@ func (db database) AddAndUpdateBudget(invoice NewInvoiceList) NewPaymentHistory
 
 		// get Workspace budget and add payment to total budget
 		workspaceBudget := NewBountyBudget{}
-		tx.Model(&NewBountyBudget{}).Where("workspace_uuid = ?", workspace_uuid).Find(&workspaceBudget)
+		tx.Model(&NewBountyBudget{}).Clauses(clause.Locking{Strength: "UPDATE"}).Where("workspace_uuid = ?", workspace_uuid).Find(&workspaceBudget)
 
 		if workspaceBudget.WorkspaceUuid == "" {
 			now := time.Now()
@@ -569,6 +639,14 @@ func (db database) GetPendingPaymentHistory() []NewPaymentHistory {
 	return paymentHistories
 }
 
+func (db database) GetPendingPaymentHistoryCount() int64 {
+	var count int64
+	db.db.Model(&NewPaymentHistory{}).
+		Where("payment_status = ? AND status = true AND payment_type = ?", PaymentPending, Payment).
+		Count(&count)
+	return count
+}
+
 func (db database) GetPaymentByBountyId(bountyId uint) NewPaymentHistory {
 	paymentHistories := NewPaymentHistory{}
 
@@ -634,49 +712,131 @@ func (db database) UpdateWorkspaceForDeletion(uuid string) error {
 }
 
 func (db database) ProcessDeleteWorkspace(workspace_uuid string) error {
-	tx := db.db.Begin()
-	var err error
-
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	if err = tx.Error; err != nil {
-		return err
+	if workspace_uuid == "" {
+		return errors.New("no workspace uuid provided")
 	}
 
-	updates := map[string]interface{}{
-		"website":     "",
-		"github":      "",
-		"description": "",
-		"show":        false,
+	// Only mark the workspace as deleted, members, roles and features are
+	// kept until the restore window expires and the purge job runs
+	now := time.Now()
+	result := db.db.Model(&Workspace{}).Where("uuid = ?", workspace_uuid).Updates(map[string]interface{}{
+		"deleted":    true,
+		"deleted_at": &now,
+	})
+	if result.Error != nil {
+		return result.Error
 	}
-
-	// Update workspace
-	if err = tx.Model(&Workspace{}).Where("uuid = ?", workspace_uuid).Updates(updates).Error; err != nil {
-		tx.Rollback()
+	if result.RowsAffected == 0 {
+		return errors.New("workspace not found")
 	}
 
-	// Delete all users associated with the Workspace
-	if err = tx.Where("workspace_uuid = ?", workspace_uuid).Delete(&WorkspaceUsers{}).Error; err != nil {
-		tx.Rollback()
-	}
+	return nil
+}
 
-	// Delete all user roles associated with the Workspace
-	if err = tx.Where("workspace_uuid = ?", workspace_uuid).Delete(&WorkspaceUserRoles{}).Error; err != nil {
-		tx.Rollback()
+func (db database) RestoreWorkspace(workspace_uuid string) (Workspace, error) {
+	ms := Workspace{}
+
+	result := db.db.Model(&Workspace{}).Where("uuid = ?", workspace_uuid).Where("deleted = ?", true).Where("purged_at IS NULL").Updates(map[string]interface{}{
+		"deleted":       false,
+		"deleted_at":    nil,
+		"purge_flagged": false,
+	})
+	if result.Error != nil {
+		return ms, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ms, errors.New("workspace is not restorable")
 	}
 
-	// Change delete status to true
-	if err = tx.Model(&Workspace{}).Where("uuid", workspace_uuid).Updates(map[string]interface{}{
-		"deleted": true,
-	}).Error; err != nil {
-		tx.Rollback()
+	db.db.Model(&Workspace{}).Where("uuid = ?", workspace_uuid).Find(&ms)
+
+	return ms, nil
+}
+
+func (db database) GetWorkspacesPendingPurge(deletedBefore time.Time) []Workspace {
+	ms := []Workspace{}
+
+	db.db.Model(&Workspace{}).Where("deleted = ?", true).Where("deleted_at < ?", deletedBefore).Where("purged_at IS NULL").Find(&ms)
+
+	return ms
+}
+
+func (db database) FlagWorkspacePurge(workspace_uuid string) error {
+	return db.db.Model(&Workspace{}).Where("uuid = ?", workspace_uuid).Updates(map[string]interface{}{
+		"purge_flagged": true,
+	}).Error
+}
+
+func (db database) PurgeWorkspace(workspace_uuid string) error {
+	if workspace_uuid == "" {
+		return errors.New("no workspace uuid provided")
 	}
 
-	return tx.Commit().Error
+	return db.db.Transaction(func(tx *gorm.DB) error {
+		featureUuids := tx.Model(&WorkspaceFeatures{}).Select("uuid").Where("workspace_uuid = ?", workspace_uuid)
+		chatIds := tx.Model(&Chat{}).Select("id").Where("workspace_id = ?", workspace_uuid)
+		messageIds := tx.Model(&ChatMessage{}).Select("id").Where("chat_id IN (?)", chatIds)
+
+		deletes := []struct {
+			model interface{}
+			query string
+			arg   interface{}
+		}{
+			{&Artifact{}, "message_id IN (?)", messageIds},
+			{&ChatMessage{}, "chat_id IN (?)", chatIds},
+			{&ChatWorkflowStatus{}, "chat_id IN (?)", chatIds},
+			{&SSEMessageLog{}, "chat_id IN (?)", chatIds},
+			{&Chat{}, "workspace_id = ?", workspace_uuid},
+			{&ChatWorkflow{}, "workspace_id = ?", workspace_uuid},
+			{&FeatureCall{}, "workspace_id = ?", workspace_uuid},
+			{&Tickets{}, "workspace_uuid = ?", workspace_uuid},
+			{&TicketPlan{}, "workspace_uuid = ?", workspace_uuid},
+			{&Activity{}, "workspace = ?", workspace_uuid},
+			{&FeatureStory{}, "feature_uuid IN (?)", featureUuids},
+			{&FeaturePhase{}, "feature_uuid IN (?)", featureUuids},
+			{&WorkspaceFeatures{}, "workspace_uuid = ?", workspace_uuid},
+			{&TextSnippet{}, "workspace_uuid = ?", workspace_uuid},
+			{&WorkspaceRepositories{}, "workspace_uuid = ?", workspace_uuid},
+			{&WorkspaceCodeGraph{}, "workspace_uuid = ?", workspace_uuid},
+			{&CodeSpaceMap{}, "workspace_id = ?", workspace_uuid},
+			{&WorkspaceUserRoles{}, "workspace_uuid = ?", workspace_uuid},
+			{&WorkspaceUsers{}, "workspace_uuid = ?", workspace_uuid},
+		}
+
+		for _, d := range deletes {
+			if err := tx.Unscoped().Where(d.query, d.arg).Delete(d.model).Error; err != nil {
+				return err
+			}
+		}
+
+		// File assets are kept as tombstones since the uploads live on the
+		// meme server, only the identifying metadata is removed
+		now := time.Now()
+		if err := tx.Model(&FileAsset{}).Where("workspace_id = ?", workspace_uuid).Updates(map[string]interface{}{
+			"status":          DeletedFileStatus,
+			"origin_filename": "",
+			"uploaded_by":     "",
+			"storage_path":    "",
+			"deleted_at":      &now,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&Workspace{}).Where("uuid = ?", workspace_uuid).Updates(map[string]interface{}{
+			"name":          "deleted-" + workspace_uuid,
+			"img":           "",
+			"website":       "",
+			"github":        "",
+			"description":   "",
+			"mission":       "",
+			"tactics":       "",
+			"schematic_url": "",
+			"schematic_img": "",
+			"show":          false,
+			"purge_flagged": false,
+			"purged_at":     &now,
+		}).Error
+	})
 }
 
 func (db database) DeleteAllUsersFromWorkspace(workspace_uuid string) error {
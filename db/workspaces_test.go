@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -162,6 +163,70 @@ func TestProcessUpdateBudget(t *testing.T) {
 	})
 }
 
+func TestSettleInvoiceConcurrently(t *testing.T) {
+	InitTestDB()
+	defer CloseTestDB()
+
+	workspace := Workspace{
+		OwnerPubKey: "test_user_settle_concurrently",
+		Uuid:        uuid.New().String(),
+		Name:        fmt.Sprintf("Test Workspace Settle %d", rand.Intn(1000)),
+	}
+	TestDB.db.Create(&workspace)
+
+	amount := uint(50000)
+
+	t.Run("should credit a budget invoice once", func(t *testing.T) {
+		now := time.Now()
+		invoice := NewInvoiceList{
+			WorkspaceUuid:  workspace.Uuid,
+			PaymentRequest: "test_settle_budget_" + uuid.New().String(),
+			Type:           Budget,
+			Created:        &now,
+		}
+		TestDB.db.Create(&invoice)
+		TestDB.db.Create(&NewPaymentHistory{
+			WorkspaceUuid: workspace.Uuid,
+			Amount:        amount,
+			PaymentType:   Deposit,
+			Created:       &now,
+		})
+
+		errs := make([]error, 2)
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = TestDB.ProcessUpdateBudget(invoice)
+			}(i)
+		}
+		wg.Wait()
+
+		assert.ElementsMatch(t, []error{nil, ErrInvoiceAlreadyPaid}, errs)
+		assert.Equal(t, amount, TestDB.GetWorkspaceBudget(workspace.Uuid).TotalBudget)
+	})
+
+	t.Run("should mark an invoice paid once", func(t *testing.T) {
+		invoice := NewInvoiceList{PaymentRequest: "test_settle_invoice_" + uuid.New().String()}
+		TestDB.db.Create(&invoice)
+
+		marked := make([]bool, 2)
+		var wg sync.WaitGroup
+		for i := range marked {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				marked[i], _ = TestDB.MarkInvoicePaid(invoice.PaymentRequest)
+			}(i)
+		}
+		wg.Wait()
+
+		assert.ElementsMatch(t, []bool{true, false}, marked)
+		assert.True(t, TestDB.GetInvoice(invoice.PaymentRequest).Status)
+	})
+}
+
 func TestAddAndUpdateBudget(t *testing.T) {
 	InitTestDB()
 	defer CloseTestDB()
@@ -407,3 +472,140 @@ func TestGetUserCreatedWorkspaces(t *testing.T) {
 		assert.Less(t, duration.Milliseconds(), int64(1000), "Query should complete within 1 second")
 	})
 }
+
+func TestPurgeWorkspace(t *testing.T) {
+	InitTestDB()
+	defer CloseTestDB()
+
+	workspace := Workspace{
+		Uuid:        uuid.New().String(),
+		Name:        fmt.Sprintf("Test Workspace Purge %s", uuid.New().String()),
+		OwnerPubKey: "test_purge_owner",
+		Description: "test_purge_description",
+	}
+	TestDB.db.Create(&workspace)
+
+	feature := WorkspaceFeatures{
+		Uuid:          uuid.New().String(),
+		WorkspaceUuid: workspace.Uuid,
+		Name:          "test_purge_feature",
+	}
+	TestDB.db.Create(&feature)
+	TestDB.db.Create(&FeaturePhase{Uuid: uuid.New().String(), FeatureUuid: feature.Uuid, Name: "test_purge_phase"})
+
+	chat := Chat{ID: uuid.New().String(), WorkspaceID: workspace.Uuid, Title: "test_purge_chat"}
+	TestDB.db.Create(&chat)
+	TestDB.db.Create(&ChatMessage{ID: uuid.New().String(), ChatID: chat.ID, Message: "hello"})
+
+	TestDB.db.Create(&TextSnippet{WorkspaceUUID: workspace.Uuid, Title: "snippet", Snippet: "snippet"})
+	TestDB.db.Create(&WorkspaceUsers{OwnerPubKey: "test_purge_member", WorkspaceUuid: workspace.Uuid})
+
+	t.Run("Should only return workspaces deleted before the cutoff", func(t *testing.T) {
+		assert.NoError(t, TestDB.ProcessDeleteWorkspace(workspace.Uuid))
+
+		pending := TestDB.GetWorkspacesPendingPurge(time.Now().Add(-time.Hour))
+		for _, w := range pending {
+			assert.NotEqual(t, workspace.Uuid, w.Uuid)
+		}
+
+		pending = TestDB.GetWorkspacesPendingPurge(time.Now().Add(time.Hour))
+		found := false
+		for _, w := range pending {
+			if w.Uuid == workspace.Uuid {
+				found = true
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("Should remove workspace data and anonymize the workspace", func(t *testing.T) {
+		assert.NoError(t, TestDB.PurgeWorkspace(workspace.Uuid))
+
+		var count int64
+		TestDB.db.Model(&WorkspaceFeatures{}).Where("workspace_uuid = ?", workspace.Uuid).Count(&count)
+		assert.Equal(t, int64(0), count)
+
+		TestDB.db.Model(&FeaturePhase{}).Where("feature_uuid = ?", feature.Uuid).Count(&count)
+		assert.Equal(t, int64(0), count)
+
+		TestDB.db.Model(&ChatMessage{}).Where("chat_id = ?", chat.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+
+		TestDB.db.Model(&TextSnippet{}).Where("workspace_uuid = ?", workspace.Uuid).Count(&count)
+		assert.Equal(t, int64(0), count)
+
+		TestDB.db.Model(&WorkspaceUsers{}).Where("workspace_uuid = ?", workspace.Uuid).Count(&count)
+		assert.Equal(t, int64(0), count)
+
+		purged := TestDB.GetWorkspaceByUuid(workspace.Uuid)
+		assert.NotNil(t, purged.PurgedAt)
+		assert.Equal(t, "", purged.Description)
+		assert.Equal(t, "deleted-"+workspace.Uuid, purged.Name)
+	})
+
+	t.Run("Should not restore a purged workspace", func(t *testing.T) {
+		_, err := TestDB.RestoreWorkspace(workspace.Uuid)
+		assert.Error(t, err)
+	})
+}
+
+func TestWorkspaceLightningAddress(t *testing.T) {
+	InitTestDB()
+	defer CloseTestDB()
+
+	suffix := uuid.New().String()[:8]
+	newWorkspace := func(name string) Workspace {
+		workspace := Workspace{
+			Uuid:        uuid.New().String(),
+			Name:        name + " " + suffix,
+			OwnerPubKey: "test_lightning_address_owner",
+		}
+		TestDB.db.Create(&workspace)
+		return workspace
+	}
+	workspace := newWorkspace("Lightning Address")
+	other := newWorkspace("Lightning-Address")
+
+	name := "lightning-address-" + suffix
+	updated, err := TestDB.SetWorkspaceLightningAddress(workspace.Uuid, name)
+	assert.NoError(t, err)
+	assert.Equal(t, name, *updated.LightningAddress)
+
+	_, err = TestDB.SetWorkspaceLightningAddress(other.Uuid, name)
+	assert.ErrorIs(t, err, ErrLightningAddressTaken)
+
+	found := TestDB.GetWorkspaceByLightningAddress(name)
+	assert.Equal(t, workspace.Uuid, found.Uuid)
+
+	TestDB.db.Model(&Workspace{}).Where("uuid = ?", workspace.Uuid).Update("deleted", true)
+	found = TestDB.GetWorkspaceByLightningAddress(name)
+	assert.Empty(t, found.Uuid)
+
+	_, err = TestDB.SetWorkspaceLightningAddress(other.Uuid, name)
+	assert.ErrorIs(t, err, ErrLightningAddressTaken, "addresses of deleted workspaces stay taken")
+}
+
+func TestDeletedWorkspaceAccess(t *testing.T) {
+	InitTestDB()
+	defer CloseTestDB()
+
+	workspace := Workspace{
+		Uuid:        uuid.New().String(),
+		Name:        fmt.Sprintf("Test Workspace Access %s", uuid.New().String()),
+		OwnerPubKey: "test_deleted_owner",
+	}
+	TestDB.db.Create(&workspace)
+
+	assert.True(t, TestDB.UserHasAccess(workspace.OwnerPubKey, workspace.Uuid, EditOrg))
+	assert.True(t, TestDB.UserHasManageBountyRoles(workspace.OwnerPubKey, workspace.Uuid))
+	assert.Empty(t, TestDB.GetUserDeletedWorkspaces(workspace.OwnerPubKey))
+
+	assert.NoError(t, TestDB.ProcessDeleteWorkspace(workspace.Uuid))
+
+	assert.False(t, TestDB.UserHasAccess(workspace.OwnerPubKey, workspace.Uuid, EditOrg))
+	assert.False(t, TestDB.UserHasManageBountyRoles(workspace.OwnerPubKey, workspace.Uuid))
+	deleted := TestDB.GetUserDeletedWorkspaces(workspace.OwnerPubKey)
+	assert.Len(t, deleted, 1)
+	assert.Equal(t, workspace.Uuid, deleted[0].Uuid)
+	assert.Empty(t, TestDB.GetUserDeletedWorkspaces("someone_else"))
+}
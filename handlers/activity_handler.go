@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stakwork/sphinx-tribes/auth"
 	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/websocket"
 )
 
 type activityHandler struct {
@@ -135,6 +137,11 @@ func (ah *activityHandler) CreateActivity(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if !auth.APIKeyAllowsWorkspace(ctx, req.Workspace) {
+		http.Error(w, "API key does not have access to this workspace", http.StatusForbidden)
+		return
+	}
+
 	activity := &db.Activity{
 		ID:          uuid.New(),
 		Title:       req.Title,
@@ -158,6 +165,8 @@ func (ah *activityHandler) CreateActivity(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	publishActivity(r.Context(), createdActivity)
+
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(ActivityResponse{
 		Success: true,
@@ -246,6 +255,11 @@ func (ah *activityHandler) CreateActivityThread(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if !auth.APIKeyAllowsWorkspace(ctx, req.Workspace) {
+		http.Error(w, "API key does not have access to this workspace", http.StatusForbidden)
+		return
+	}
+
 	sourceID := r.URL.Query().Get("source_id")
 	if sourceID == "" {
 		http.Error(w, "source_id query parameter is required", http.StatusBadRequest)
@@ -270,6 +284,8 @@ func (ah *activityHandler) CreateActivityThread(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	publishActivity(r.Context(), createdActivity)
+
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(ActivityResponse{
 		Success: true,
@@ -800,9 +816,19 @@ func (ah *activityHandler) ReceiveActivity(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	publishActivity(r.Context(), createdActivity)
+
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(WebhookResponse{
 		Success:    true,
 		ActivityID: createdActivity.ID.String(),
 	})
 }
+
+// publishActivity tells the subscribers of the workspace about a new activity
+func publishActivity(ctx context.Context, activity *db.Activity) {
+	if activity == nil || activity.Workspace == "" {
+		return
+	}
+	publishEvent(ctx, websocket.WorkspaceTopic(activity.Workspace), "activity_created", activity)
+}
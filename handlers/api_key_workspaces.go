@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// apiKeyNoWorkspace stands for something that exists outside of every
+// workspace, no key is allowed to act on it
+const apiKeyNoWorkspace = "-"
+
+// apiKeyWorkspaceSource finds a workspace the request acts on, or "" when
+// the part of the request it looks at names nothing that exists
+type apiKeyWorkspaceSource func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string
+
+// apiKeyWorkspaceRoutes lists the routes API keys may use, by route pattern,
+// with where each finds its workspaces. Routes missing here refuse keys.
+var apiKeyWorkspaceRoutes = map[string][]apiKeyWorkspaceSource{
+	// tickets
+	"/bounties/ticket/feature/{feature_uuid}/phase/{phase_uuid}": {featureParam("feature_uuid")},
+	"/bounties/ticket/{uuid}":                                    {ticketParam("uuid"), bodyTicketFeature},
+	"/bounties/ticket/{ticket_uuid}/bounty":                      {ticketParam("ticket_uuid")},
+	"/bounties/ticket/group/{group_uuid}":                        {ticketGroupParam("group_uuid")},
+	"/bounties/ticket/workspace/{workspace_uuid}/draft":          {workspaceParam("workspace_uuid")},
+	"/bounties/ticket/workspace/{workspace_uuid}/draft/{uuid}":   {workspaceParam("workspace_uuid")},
+	"/bounties/ticket/plan/{uuid}":                               {ticketPlanParam("uuid")},
+	"/bounties/ticket/plan/feature/{feature_uuid}":               {featureParam("feature_uuid")},
+	"/bounties/ticket/plan/phase/{phase_uuid}":                   {phaseParam("phase_uuid")},
+	"/bounties/ticket/plan/workspace/{workspace_uuid}":           {workspaceParam("workspace_uuid")},
+
+	// activities
+	"/activities/":                             {bodyWorkspace("workspace")},
+	"/activities/thread":                       {bodyWorkspace("workspace")},
+	"/activities/{id}":                         {activityParam("id")},
+	"/activities/{id}/actions":                 {activityParam("id")},
+	"/activities/{id}/questions":               {activityParam("id")},
+	"/activities/{id}/actions/{action_id}":     {activityParam("id")},
+	"/activities/{id}/questions/{question_id}": {activityParam("id")},
+	"/activities/feature/{feature_uuid}":       {featureParam("feature_uuid")},
+	"/activities/phase/{phase_uuid}":           {phaseParam("phase_uuid")},
+	"/activities/workspace/{workspace}":        {workspaceParam("workspace")},
+
+	// features
+	"/features/":                                               {bodyWorkspace("workspace_uuid"), bodyFeature("uuid")},
+	"/features/{uuid}":                                         {featureParam("uuid")},
+	"/features/{uuid}/status":                                  {featureParam("uuid")},
+	"/features/forworkspace/{workspace_uuid}":                  {workspaceParam("workspace_uuid")},
+	"/features/workspace/count/{uuid}":                         {workspaceParam("uuid")},
+	"/features/phase":                                          {bodyFeature("feature_uuid")},
+	"/features/story":                                          {bodyFeature("feature_uuid")},
+	"/features/{feature_uuid}/phase":                           {featureParam("feature_uuid")},
+	"/features/{feature_uuid}/phase/{phase_uuid}":              {featureParam("feature_uuid")},
+	"/features/{feature_uuid}/story":                           {featureParam("feature_uuid")},
+	"/features/{feature_uuid}/story/{story_uuid}":              {featureParam("feature_uuid")},
+	"/features/{feature_uuid}/phase/{phase_uuid}/bounty":       {featureParam("feature_uuid")},
+	"/features/{feature_uuid}/phase/{phase_uuid}/bounty/count": {featureParam("feature_uuid")},
+	"/features/{feature_uuid}/quick-bounties":                  {featureParam("feature_uuid")},
+	"/features/{feature_uuid}/quick-tickets":                   {featureParam("feature_uuid")},
+	"/features/call/{workspace_uuid}":                          {workspaceParam("workspace_uuid")},
+
+	// chats
+	"/hivechat/":                           {queryWorkspace("workspace_id"), bodyWorkspace("workspaceId")},
+	"/hivechat/search":                     {queryWorkspace("workspace_id")},
+	"/hivechat/send":                       {bodyChat("chat_id"), bodyWorkspace("workspaceUUID")},
+	"/hivechat/send/action":                {bodyChat("chatId")},
+	"/hivechat/{chat_id}":                  {chatParam("chat_id")},
+	"/hivechat/{chat_id}/archive":          {chatParam("chat_id")},
+	"/hivechat/{chat_id}/branches":         {chatParam("chat_id")},
+	"/hivechat/{chat_id}/branch":           {chatParam("chat_id")},
+	"/hivechat/history/{uuid}":             {chatParam("uuid")},
+	"/hivechat/artefacts/chat/{chatId}":    {chatParam("chatId")},
+	"/hivechat/chatworkflow":               {bodyWorkspace("workspaceId")},
+	"/hivechat/chatworkflow/{workspaceId}": {workspaceParam("workspaceId")},
+	"/hivechat/sse/{chat_id}":              {chatParam("chat_id")},
+	"/hivechat/sse/all/{chat_id}":          {chatParam("chat_id")},
+	"/hivechat/status/{chat_id}":           {chatParam("chat_id")},
+	"/hivechat/status/{chat_id}/latest":    {chatParam("chat_id")},
+
+	// workspaces
+	"/workspaces/mission":                            {bodyWorkspace("uuid")},
+	"/workspaces/tactics":                            {bodyWorkspace("uuid")},
+	"/workspaces/schematicurl":                       {bodyWorkspace("uuid")},
+	"/workspaces/users/{uuid}":                       {workspaceParam("uuid")},
+	"/workspaces/users/role/{uuid}/{user}":           {workspaceParam("uuid")},
+	"/workspaces/foruser/{uuid}":                     {workspaceParam("uuid")},
+	"/workspaces/budget/{uuid}":                      {workspaceParam("uuid")},
+	"/workspaces/budget/history/{uuid}":              {workspaceParam("uuid")},
+	"/workspaces/payments/{uuid}":                    {workspaceParam("uuid")},
+	"/workspaces/invoices/count/{uuid}":              {workspaceParam("uuid")},
+	"/workspaces/repositories/{uuid}":                {workspaceParam("uuid")},
+	"/workspaces/{workspace_uuid}/features":          {workspaceParam("workspace_uuid")},
+	"/workspaces/{workspace_uuid}/repository/{uuid}": {workspaceParam("workspace_uuid")},
+	"/workspaces/{workspace_uuid}/codegraph":         {workspaceParam("workspace_uuid")},
+	"/workspaces/{workspace_uuid}/codegraph/{uuid}":  {workspaceParam("workspace_uuid")},
+	"/workspaces/{workspace_uuid}/lastwithdrawal":    {workspaceParam("workspace_uuid")},
+	"/workspaces/{workspace_uuid}/export":            {workspaceParam("workspace_uuid")},
+	"/workspaces/codegraph/refresh/{workspace_uuid}": {workspaceParam("workspace_uuid")},
+
+	// codespaces
+	"/codespace/workspaces":                                 {bodyWorkspace("workspaceID")},
+	"/codespace/workspaces/workspace/{workspaceID}":         {workspaceParam("workspaceID")},
+	"/codespace/workspaces/{workspaceID}/user/{userPubkey}": {workspaceParam("workspaceID")},
+	"/codespace/workspaces/{id}":                            {codeSpaceParam("id"), bodyWorkspace("workspaceID")},
+
+	// bounties
+	"/gobounties/":                             {bodyWorkspace("workspace_uuid"), bodyBounty("id")},
+	"/gobounties/pay/{id}":                     {bountyParam("id")},
+	"/gobounties/payment/status/{id}":          {bountyParam("id")},
+	"/gobounties/payment/{bountyId}":           {bountyParam("bountyId")},
+	"/gobounties/payout/{id}":                  {bountyParam("id")},
+	"/gobounties/{id}/proof":                   {bountyParam("id")},
+	"/gobounties/{id}/proofs":                  {bountyParam("id")},
+	"/gobounties/{id}/proofs/{proofId}":        {bountyParam("id")},
+	"/gobounties/{id}/proofs/{proofId}/status": {bountyParam("id")},
+	"/gobounties/{id}/timing":                  {bountyParam("id")},
+	"/gobounties/{id}/timing/start":            {bountyParam("id")},
+	"/gobounties/{id}/timing/close":            {bountyParam("id")},
+}
+
+// ResolveAPIKeyWorkspace is used as auth.APIKeyWorkspaceResolver, it returns
+// every workspace the request names directly or through what it acts on
+func (ah *apiKeyHandler) ResolveAPIKeyWorkspace(r *http.Request) ([]string, error) {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return nil, nil
+	}
+	// the root route of a mounted router comes out as "/features//"
+	pattern := strings.ReplaceAll(rctx.RoutePattern(), "//", "/")
+	sources, ok := apiKeyWorkspaceRoutes[pattern]
+	if !ok {
+		return nil, nil
+	}
+
+	var body map[string]interface{}
+	if r.Body != nil && r.Body != http.NoBody {
+		raw, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(raw))
+		// bodies that are not JSON objects name no workspace
+		json.Unmarshal(raw, &body)
+	}
+
+	workspaces := []string{}
+	for _, source := range sources {
+		if workspace := source(ah, r, body); workspace != "" {
+			workspaces = append(workspaces, workspace)
+		}
+	}
+	return workspaces, nil
+}
+
+// orNoWorkspace is the workspace of something that exists
+func orNoWorkspace(workspace string) string {
+	if workspace == "" {
+		return apiKeyNoWorkspace
+	}
+	return workspace
+}
+
+func bodyString(body map[string]interface{}, field string) string {
+	switch value := body[field].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return ""
+}
+
+func workspaceParam(name string) apiKeyWorkspaceSource {
+	return func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+		return chi.URLParam(r, name)
+	}
+}
+
+func queryWorkspace(name string) apiKeyWorkspaceSource {
+	return func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+		return r.URL.Query().Get(name)
+	}
+}
+
+func bodyWorkspace(field string) apiKeyWorkspaceSource {
+	return func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+		return bodyString(body, field)
+	}
+}
+
+func (ah *apiKeyHandler) featureWorkspace(featureUuid string) string {
+	if featureUuid == "" {
+		return ""
+	}
+	return ah.db.GetFeatureByUuid(featureUuid).WorkspaceUuid
+}
+
+func featureParam(name string) apiKeyWorkspaceSource {
+	return func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+		return ah.featureWorkspace(chi.URLParam(r, name))
+	}
+}
+
+func bodyFeature(field string) apiKeyWorkspaceSource {
+	return func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+		return ah.featureWorkspace(bodyString(body, field))
+	}
+}
+
+func phaseParam(name string) apiKeyWorkspaceSource {
+	return func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+		phase, err := ah.db.GetPhaseByUuid(chi.URLParam(r, name))
+		if err != nil {
+			return ""
+		}
+		return ah.featureWorkspace(phase.FeatureUuid)
+	}
+}
+
+func (ah *apiKeyHandler) chatWorkspace(chatID string) string {
+	if chatID == "" {
+		return ""
+	}
+	chat, err := ah.db.GetChatByChatID(chatID)
+	if err != nil {
+		return ""
+	}
+	return orNoWorkspace(chat.WorkspaceID)
+}
+
+func chatParam(name string) apiKeyWorkspaceSource {
+	return func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+		return ah.chatWorkspace(chi.URLParam(r, name))
+	}
+}
+
+func bodyChat(field string) apiKeyWorkspaceSource {
+	return func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+		return ah.chatWorkspace(bodyString(body, field))
+	}
+}
+
+func ticketParam(name string) apiKeyWorkspaceSource {
+	return func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+		ticket, err := ah.db.GetTicket(chi.URLParam(r, name))
+		if err != nil {
+			return ""
+		}
+		if ticket.WorkspaceUuid != "" {
+			return ticket.WorkspaceUuid
+		}
+		return orNoWorkspace(ah.featureWorkspace(ticket.FeatureUUID))
+	}
+}
+
+// bodyTicketFeature is the feature a ticket is saved to by UpdateTicket
+func bodyTicketFeature(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+	ticket, _ := body["ticket"].(map[string]interface{})
+	return ah.featureWorkspace(bodyString(ticket, "feature_uuid"))
+}
+
+func ticketGroupParam(name string) apiKeyWorkspaceSource {
+	return func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+		tickets, err := ah.db.GetTicketsByGroup(chi.URLParam(r, name))
+		if err != nil || len(tickets) == 0 {
+			return ""
+		}
+		if tickets[0].WorkspaceUuid != "" {
+			return tickets[0].WorkspaceUuid
+		}
+		return orNoWorkspace(ah.featureWorkspace(tickets[0].FeatureUUID))
+	}
+}
+
+func ticketPlanParam(name string) apiKeyWorkspaceSource {
+	return func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+		plan, err := ah.db.GetTicketPlan(chi.URLParam(r, name))
+		if err != nil || plan == nil {
+			return ""
+		}
+		return orNoWorkspace(plan.WorkspaceUuid)
+	}
+}
+
+func activityParam(name string) apiKeyWorkspaceSource {
+	return func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+		activity, err := ah.db.GetActivity(chi.URLParam(r, name))
+		if err != nil || activity == nil {
+			return ""
+		}
+		return orNoWorkspace(activity.Workspace)
+	}
+}
+
+func codeSpaceParam(name string) apiKeyWorkspaceSource {
+	return func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+		id, err := uuid.Parse(chi.URLParam(r, name))
+		if err != nil {
+			return ""
+		}
+		codeSpace, err := ah.db.GetCodeSpaceMapByID(id)
+		if err != nil {
+			return ""
+		}
+		return orNoWorkspace(codeSpace.WorkspaceID)
+	}
+}
+
+func (ah *apiKeyHandler) bountyWorkspace(id string) string {
+	bountyID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil || bountyID == 0 {
+		return ""
+	}
+	bounty := ah.db.GetBounty(uint(bountyID))
+	if bounty.ID == 0 {
+		return ""
+	}
+	return orNoWorkspace(bounty.WorkspaceUuid)
+}
+
+func bountyParam(name string) apiKeyWorkspaceSource {
+	return func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+		return ah.bountyWorkspace(chi.URLParam(r, name))
+	}
+}
+
+func bodyBounty(field string) apiKeyWorkspaceSource {
+	return func(ah *apiKeyHandler, r *http.Request, body map[string]interface{}) string {
+		return ah.bountyWorkspace(bodyString(body, field))
+	}
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+)
+
+// apiKeyLastUsedInterval limits how often last_used_at is written for busy keys
+const apiKeyLastUsedInterval = time.Minute
+
+type apiKeyHandler struct {
+	db            db.Database
+	userHasAccess func(pubKeyFromAuth string, uuid string, role string) bool
+}
+
+func NewAPIKeyHandler(database db.Database) *apiKeyHandler {
+	configHandler := db.NewConfigHandler(database)
+	return &apiKeyHandler{
+		db:            database,
+		userHasAccess: configHandler.UserHasAccess,
+	}
+}
+
+// ResolveAPIKey is used as auth.APIKeyResolver, it rejects revoked and expired
+// keys and keys whose workspace was deleted
+func (ah *apiKeyHandler) ResolveAPIKey(ctx context.Context, token string) (*auth.APIKey, error) {
+	key, err := ah.db.GetWorkspaceAPIKeyByHash(auth.HashAPIKey(token))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil {
+		return nil, errors.New("api key has been revoked")
+	}
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return nil, errors.New("api key has expired")
+	}
+
+	workspace := ah.db.GetWorkspaceByUuid(key.WorkspaceUuid)
+	if workspace.Uuid == "" || workspace.Deleted {
+		return nil, errors.New("api key workspace not found")
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyLastUsedInterval {
+		if err := ah.db.UpdateWorkspaceAPIKeyLastUsed(key.ID.String(), now); err != nil {
+			logger.FromContext(ctx).Error("[api_keys] %v", err)
+		}
+	}
+
+	return &auth.APIKey{
+		ID:            key.ID.String(),
+		WorkspaceUuid: key.WorkspaceUuid,
+		Pubkey:        key.CreatedBy,
+		Scopes:        key.Scopes,
+	}, nil
+}
+
+// authorize checks that the request was made by a user, not an API key, with
+// permission to manage the workspace
+func (ah *apiKeyHandler) authorize(w http.ResponseWriter, r *http.Request, workspaceUuid string) (string, bool) {
+	pubKeyFromAuth, _ := r.Context().Value(auth.ContextKey).(string)
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[api_keys] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return "", false
+	}
+
+	if auth.APIKeyFromContext(r.Context()) != nil {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode("API keys cannot manage API keys")
+		return "", false
+	}
+
+	if !ah.userHasAccess(pubKeyFromAuth, workspaceUuid, db.EditOrg) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Don't have access to manage API keys for this workspace")
+		return "", false
+	}
+
+	return pubKeyFromAuth, true
+}
+
+// CreateWorkspaceAPIKey godoc
+//
+//	@Summary		Create workspace API key
+//	@Description	Create a scoped API key, the plaintext key is only returned once
+//	@Tags			Workspaces
+//	@Accept			json
+//	@Produce		json
+//	@Param			workspace_uuid	path		string								true	"Workspace UUID"
+//	@Param			request			body		db.CreateWorkspaceAPIKeyRequest		true	"API key"
+//	@Success		201				{object}	db.CreateWorkspaceAPIKeyResponse
+//	@Security		PubKeyContextAuth
+//	@Router			/workspaces/{workspace_uuid}/api-keys [post]
+func (ah *apiKeyHandler) CreateWorkspaceAPIKey(w http.ResponseWriter, r *http.Request) {
+	workspaceUuid := chi.URLParam(r, "workspace_uuid")
+	pubKeyFromAuth, ok := ah.authorize(w, r, workspaceUuid)
+	if !ok {
+		return
+	}
+
+	var request db.CreateWorkspaceAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Invalid request body")
+		return
+	}
+
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Name is required")
+		return
+	}
+
+	if len(request.Scopes) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("At least one scope is required")
+		return
+	}
+	for _, scope := range request.Scopes {
+		if !auth.IsValidAPIKeyScope(scope) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode("Invalid scope: " + scope)
+			return
+		}
+	}
+
+	if request.ExpiresAt != nil && request.ExpiresAt.Before(time.Now()) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Expiry must be in the future")
+		return
+	}
+
+	token, err := auth.GenerateAPIKey()
+	if err != nil {
+		logger.FromContext(r.Context()).Error("[api_keys] %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	key := db.WorkspaceAPIKey{
+		WorkspaceUuid: workspaceUuid,
+		Name:          request.Name,
+		Prefix:        token[:len(auth.APIKeyPrefix)+8],
+		KeyHash:       auth.HashAPIKey(token),
+		Scopes:        request.Scopes,
+		CreatedBy:     pubKeyFromAuth,
+		ExpiresAt:     request.ExpiresAt,
+	}
+
+	if err := ah.db.CreateWorkspaceAPIKey(&key); err != nil {
+		logger.FromContext(r.Context()).Error("[api_keys] %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Error creating API key")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(db.CreateWorkspaceAPIKeyResponse{Key: token, APIKey: key})
+}
+
+// GetWorkspaceAPIKeys godoc
+//
+//	@Summary		List workspace API keys
+//	@Description	List the API keys of a workspace, including revoked and expired keys
+//	@Tags			Workspaces
+//	@Produce		json
+//	@Param			workspace_uuid	path	string	true	"Workspace UUID"
+//	@Success		200				{array}	db.WorkspaceAPIKey
+//	@Security		PubKeyContextAuth
+//	@Router			/workspaces/{workspace_uuid}/api-keys [get]
+func (ah *apiKeyHandler) GetWorkspaceAPIKeys(w http.ResponseWriter, r *http.Request) {
+	workspaceUuid := chi.URLParam(r, "workspace_uuid")
+	if _, ok := ah.authorize(w, r, workspaceUuid); !ok {
+		return
+	}
+
+	keys, err := ah.db.GetWorkspaceAPIKeys(workspaceUuid)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("[api_keys] %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(keys)
+}
+
+// RevokeWorkspaceAPIKey godoc
+//
+//	@Summary		Revoke workspace API key
+//	@Description	Revoke an API key, requests using it are rejected immediately
+//	@Tags			Workspaces
+//	@Param			workspace_uuid	path	string	true	"Workspace UUID"
+//	@Param			id				path	string	true	"API key ID"
+//	@Success		200
+//	@Security		PubKeyContextAuth
+//	@Router			/workspaces/{workspace_uuid}/api-keys/{id} [delete]
+func (ah *apiKeyHandler) RevokeWorkspaceAPIKey(w http.ResponseWriter, r *http.Request) {
+	workspaceUuid := chi.URLParam(r, "workspace_uuid")
+	if _, ok := ah.authorize(w, r, workspaceUuid); !ok {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if err := ah.db.RevokeWorkspaceAPIKey(workspaceUuid, id); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode("API key revoked")
+}
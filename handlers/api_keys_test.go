@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/db"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newURLParamRequest(ctx context.Context, method string, params map[string]string, body []byte) *http.Request {
+	rctx := chi.NewRouteContext()
+	for key, value := range params {
+		rctx.URLParams.Add(key, value)
+	}
+	req, _ := http.NewRequestWithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx), method, "/", bytes.NewReader(body))
+	return req
+}
+
+func TestCreateWorkspaceAPIKey(t *testing.T) {
+	ctx := context.WithValue(context.Background(), auth.ContextKey, "owner")
+	params := map[string]string{"workspace_uuid": "ws"}
+
+	t.Run("should return 401 when the user cannot manage the workspace", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		aHandler := NewAPIKeyHandler(mockDb)
+		aHandler.userHasAccess = func(pubKeyFromAuth string, uuid string, role string) bool { return false }
+
+		rr := httptest.NewRecorder()
+		aHandler.CreateWorkspaceAPIKey(rr, newURLParamRequest(ctx, http.MethodPost, params, []byte(`{}`)))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should not let an API key create keys", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		aHandler := NewAPIKeyHandler(mockDb)
+		keyCtx := context.WithValue(ctx, auth.APIKeyContextKey, &auth.APIKey{WorkspaceUuid: "ws"})
+
+		rr := httptest.NewRecorder()
+		aHandler.CreateWorkspaceAPIKey(rr, newURLParamRequest(keyCtx, http.MethodPost, params, []byte(`{}`)))
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("should reject unknown scopes", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		aHandler := NewAPIKeyHandler(mockDb)
+		aHandler.userHasAccess = func(pubKeyFromAuth string, uuid string, role string) bool { return true }
+
+		rr := httptest.NewRecorder()
+		body := []byte(`{"name":"ci","scopes":["tickets:admin"]}`)
+		aHandler.CreateWorkspaceAPIKey(rr, newURLParamRequest(ctx, http.MethodPost, params, body))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("should store only the hash and return the key once", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		aHandler := NewAPIKeyHandler(mockDb)
+		aHandler.userHasAccess = func(pubKeyFromAuth string, uuid string, role string) bool { return true }
+
+		var stored *db.WorkspaceAPIKey
+		mockDb.On("CreateWorkspaceAPIKey", mock.AnythingOfType("*db.WorkspaceAPIKey")).Run(func(args mock.Arguments) {
+			stored = args.Get(0).(*db.WorkspaceAPIKey)
+		}).Return(nil)
+
+		rr := httptest.NewRecorder()
+		body := []byte(`{"name":"ci","scopes":["tickets:write","activities:write"]}`)
+		aHandler.CreateWorkspaceAPIKey(rr, newURLParamRequest(ctx, http.MethodPost, params, body))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		var response db.CreateWorkspaceAPIKeyResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.True(t, strings.HasPrefix(response.Key, auth.APIKeyPrefix))
+		assert.Equal(t, auth.HashAPIKey(response.Key), stored.KeyHash)
+		assert.Equal(t, "ws", stored.WorkspaceUuid)
+		assert.Equal(t, "owner", stored.CreatedBy)
+		assert.True(t, strings.HasPrefix(response.Key, stored.Prefix))
+		assert.NotContains(t, rr.Body.String(), stored.KeyHash)
+	})
+}
+
+func TestRevokeWorkspaceAPIKey(t *testing.T) {
+	ctx := context.WithValue(context.Background(), auth.ContextKey, "owner")
+	params := map[string]string{"workspace_uuid": "ws", "id": "key-id"}
+
+	t.Run("should revoke the key", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		aHandler := NewAPIKeyHandler(mockDb)
+		aHandler.userHasAccess = func(pubKeyFromAuth string, uuid string, role string) bool { return true }
+		mockDb.On("RevokeWorkspaceAPIKey", "ws", "key-id").Return(nil)
+
+		rr := httptest.NewRecorder()
+		aHandler.RevokeWorkspaceAPIKey(rr, newURLParamRequest(ctx, http.MethodDelete, params, nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("should return 404 for an unknown key", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		aHandler := NewAPIKeyHandler(mockDb)
+		aHandler.userHasAccess = func(pubKeyFromAuth string, uuid string, role string) bool { return true }
+		mockDb.On("RevokeWorkspaceAPIKey", "ws", "key-id").Return(errors.New("api key not found"))
+
+		rr := httptest.NewRecorder()
+		aHandler.RevokeWorkspaceAPIKey(rr, newURLParamRequest(ctx, http.MethodDelete, params, nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestResolveAPIKey(t *testing.T) {
+	token := auth.APIKeyPrefix + "token"
+	hash := auth.HashAPIKey(token)
+	past := time.Now().Add(-time.Hour)
+
+	t.Run("should resolve an active key and record its use", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		aHandler := NewAPIKeyHandler(mockDb)
+
+		key := db.WorkspaceAPIKey{ID: uuid.New(), WorkspaceUuid: "ws", CreatedBy: "owner", Scopes: []string{"tickets:write"}}
+		mockDb.On("GetWorkspaceAPIKeyByHash", hash).Return(key, nil)
+		mockDb.On("GetWorkspaceByUuid", "ws").Return(db.Workspace{Uuid: "ws"})
+		mockDb.On("UpdateWorkspaceAPIKeyLastUsed", key.ID.String(), mock.AnythingOfType("time.Time")).Return(nil)
+
+		resolved, err := aHandler.ResolveAPIKey(context.Background(), token)
+		assert.NoError(t, err)
+		assert.Equal(t, "owner", resolved.Pubkey)
+		assert.Equal(t, "ws", resolved.WorkspaceUuid)
+		assert.True(t, resolved.HasScope("tickets:write"))
+	})
+
+	t.Run("should reject revoked keys", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		aHandler := NewAPIKeyHandler(mockDb)
+
+		mockDb.On("GetWorkspaceAPIKeyByHash", hash).Return(db.WorkspaceAPIKey{WorkspaceUuid: "ws", RevokedAt: &past}, nil)
+
+		_, err := aHandler.ResolveAPIKey(context.Background(), token)
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject expired keys", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		aHandler := NewAPIKeyHandler(mockDb)
+
+		mockDb.On("GetWorkspaceAPIKeyByHash", hash).Return(db.WorkspaceAPIKey{WorkspaceUuid: "ws", ExpiresAt: &past}, nil)
+
+		_, err := aHandler.ResolveAPIKey(context.Background(), token)
+		assert.Error(t, err)
+	})
+}
+
+func TestResolveAPIKeyWorkspace(t *testing.T) {
+	originalResolver, originalWorkspaceResolver := auth.APIKeyResolver, auth.APIKeyWorkspaceResolver
+	defer func() {
+		auth.APIKeyResolver, auth.APIKeyWorkspaceResolver = originalResolver, originalWorkspaceResolver
+	}()
+
+	mockDb := dbMocks.NewDatabase(t)
+	aHandler := NewAPIKeyHandler(mockDb)
+	auth.APIKeyResolver = func(ctx context.Context, token string) (*auth.APIKey, error) {
+		return &auth.APIKey{ID: "key", WorkspaceUuid: "ws", Pubkey: "creator", Scopes: auth.APIKeyScopes()}, nil
+	}
+	auth.APIKeyWorkspaceResolver = aHandler.ResolveAPIKeyWorkspace
+
+	// the routes are mounted the way routes.NewRouter mounts them
+	var received string
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.WriteHeader(http.StatusOK)
+	}
+	subrouter := func(register func(r chi.Router)) chi.Router {
+		r := chi.NewRouter()
+		r.Group(func(r chi.Router) {
+			r.Use(auth.CombinedAuthContext)
+			register(r)
+		})
+		return r
+	}
+	router := chi.NewRouter()
+	router.Mount("/features", subrouter(func(r chi.Router) {
+		r.Post("/", ok)
+		r.Get("/{uuid}", ok)
+		r.Get("/bounty/roles", ok)
+	}))
+	router.Mount("/hivechat", subrouter(func(r chi.Router) {
+		r.Get("/", ok)
+		r.Post("/send", ok)
+	}))
+	router.Mount("/bounties/ticket", subrouter(func(r chi.Router) {
+		r.Post("/{uuid}", ok)
+	}))
+
+	mockDb.On("GetFeatureByUuid", "feature-ws").Return(db.WorkspaceFeatures{Uuid: "feature-ws", WorkspaceUuid: "ws"})
+	mockDb.On("GetFeatureByUuid", "feature-other").Return(db.WorkspaceFeatures{Uuid: "feature-other", WorkspaceUuid: "other"})
+	mockDb.On("GetChatByChatID", "chat-other").Return(db.Chat{ID: "chat-other", WorkspaceID: "other"}, nil)
+	mockDb.On("GetTicket", "ticket-new").Return(db.Tickets{}, errors.New("not found"))
+	mockDb.On("GetTicket", "ticket-other").Return(db.Tickets{WorkspaceUuid: "other"}, nil)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"a feature of the key's workspace", http.MethodGet, "/features/feature-ws", "", http.StatusOK},
+		{"a feature of another workspace", http.MethodGet, "/features/feature-other", "", http.StatusForbidden},
+		{"a new feature of the key's workspace", http.MethodPost, "/features/", `{"workspace_uuid":"ws"}`, http.StatusOK},
+		{"an existing feature moved into the key's workspace", http.MethodPost, "/features/", `{"workspace_uuid":"ws","uuid":"feature-other"}`, http.StatusForbidden},
+		{"a route closed to keys", http.MethodGet, "/features/bounty/roles", "", http.StatusForbidden},
+		{"the chats of the key's workspace", http.MethodGet, "/hivechat/?workspace_id=ws", "", http.StatusOK},
+		{"the chats of another workspace", http.MethodGet, "/hivechat/?workspace_id=other", "", http.StatusForbidden},
+		{"a message to a chat of another workspace", http.MethodPost, "/hivechat/send", `{"chat_id":"chat-other","workspaceUUID":"ws"}`, http.StatusForbidden},
+		{"a new ticket of a feature of the key's workspace", http.MethodPost, "/bounties/ticket/ticket-new", `{"ticket":{"feature_uuid":"feature-ws"}}`, http.StatusOK},
+		{"a ticket of another workspace", http.MethodPost, "/bounties/ticket/ticket-other", `{"ticket":{"feature_uuid":"feature-ws"}}`, http.StatusForbidden},
+		{"a request naming no workspace", http.MethodPost, "/bounties/ticket/ticket-new", `{}`, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = ""
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("x-api-token", auth.APIKeyPrefix+"key")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.body, received, "the handler still reads the body")
+			}
+		})
+	}
+}
@@ -2,10 +2,10 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strconv"
 
@@ -15,14 +15,16 @@ import (
 	"github.com/stakwork/sphinx-tribes/db"
 	"github.com/stakwork/sphinx-tribes/logger"
 	"github.com/stakwork/sphinx-tribes/utils"
+	"github.com/stakwork/sphinx-tribes/websocket"
 )
 
 // AuthHandler struct
 type AuthHandler struct {
 	db                        db.Database
-	makeConnectionCodeRequest func(inviter_pubkey string, inviter_route_hint string, msats_amount uint64) string
+	makeConnectionCodeRequest func(ctx context.Context, inviter_pubkey string, inviter_route_hint string, msats_amount uint64) string
 	decodeJwt                 func(token string) (jwt.MapClaims, error)
-	encodeJwt                 func(pubkey string) (string, error)
+	encodeJwt                 func(pubkey string, sessionID string) (string, error)
+	createSession             func(pubkey string, r *http.Request) (db.UserSession, string, error)
 }
 
 func NewAuthHandler(db db.Database) *AuthHandler {
@@ -30,7 +32,8 @@ func NewAuthHandler(db db.Database) *AuthHandler {
 		db:                        db,
 		makeConnectionCodeRequest: MakeConnectionCodeRequest,
 		decodeJwt:                 auth.DecodeJwt,
-		encodeJwt:                 auth.EncodeJwt,
+		encodeJwt:                 auth.EncodeSessionJwt,
+		createSession:             NewSessionHandler(db).CreateSession,
 	}
 }
 
@@ -41,10 +44,11 @@ type LnAuthResponse struct {
 }
 
 type RefreshTokenResponse struct {
-	K1     string    `json:"k1,omitempty"`
-	Status bool      `json:"status"`
-	JWT    string    `json:"jwt"`
-	User   db.Person `json:"user"`
+	K1           string    `json:"k1,omitempty"`
+	Status       bool      `json:"status"`
+	JWT          string    `json:"jwt"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	User         db.Person `json:"user"`
 }
 
 type ConnectionCodesListResponse struct {
@@ -118,7 +122,7 @@ func (ah *AuthHandler) CreateConnectionCode(w http.ResponseWriter, r *http.Reque
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		logger.Log.Error("ReadAll Error: %v", err)
+		logger.FromContext(r.Context()).Error("ReadAll Error: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -127,7 +131,7 @@ func (ah *AuthHandler) CreateConnectionCode(w http.ResponseWriter, r *http.Reque
 	err = json.Unmarshal(body, &codeBody)
 
 	if err != nil {
-		logger.Log.Error("Could not unmarshal connection code body")
+		logger.FromContext(r.Context()).Error("Could not unmarshal connection code body")
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -151,7 +155,7 @@ func (ah *AuthHandler) CreateConnectionCode(w http.ResponseWriter, r *http.Reque
 	}
 
 	for i := 0; i < int(codeBody.Number); i++ {
-		code := ah.makeConnectionCodeRequest(codeBody.Pubkey, codeBody.RouteHint, codeBody.SatsAmount)
+		code := ah.makeConnectionCodeRequest(r.Context(), codeBody.Pubkey, codeBody.RouteHint, codeBody.SatsAmount)
 
 		if code != "" {
 			newCode := db.ConnectionCodes{
@@ -165,7 +169,7 @@ func (ah *AuthHandler) CreateConnectionCode(w http.ResponseWriter, r *http.Reque
 	_, err = ah.db.CreateConnectionCode(codeArr)
 
 	if err != nil {
-		logger.Log.Error("[auth] => ERR create connection code: %v", err)
+		logger.FromContext(r.Context()).Error("[auth] => ERR create connection code: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -173,7 +177,7 @@ func (ah *AuthHandler) CreateConnectionCode(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode("Codes created successfully")
 }
 
-func MakeConnectionCodeRequest(inviter_pubkey string, inviter_route_hint string, msats_amount uint64) string {
+func MakeConnectionCodeRequest(ctx context.Context, inviter_pubkey string, inviter_route_hint string, msats_amount uint64) string {
 	url := fmt.Sprintf("%s/invite", config.V2BotUrl)
 	client := http.Client{}
 
@@ -181,14 +185,14 @@ func MakeConnectionCodeRequest(inviter_pubkey string, inviter_route_hint string,
 	bodyData := utils.BuildV2ConnectionCodes(msats_amount, "new_user", inviter_pubkey, inviter_route_hint)
 	jsonBody := []byte(bodyData)
 
-	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonBody))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonBody))
 	req.Header.Set("x-admin-token", config.V2BotToken)
 	req.Header.Set("Content-Type", "application/json")
 
 	res, err := client.Do(req)
 
 	if err != nil {
-		log.Printf("[Invite] Request Failed: %s", err)
+		logger.FromContext(ctx).Error("[Invite] Request Failed: %s", err)
 		return ""
 	}
 
@@ -197,14 +201,14 @@ func MakeConnectionCodeRequest(inviter_pubkey string, inviter_route_hint string,
 	body, err := io.ReadAll(res.Body)
 
 	if err != nil {
-		log.Printf("Could not read invite body: %s", err)
+		logger.FromContext(ctx).Error("Could not read invite body: %s", err)
 	}
 
 	inviteReponse := db.InviteReponse{}
 	err = json.Unmarshal(body, &inviteReponse)
 
 	if err != nil {
-		logger.Log.Error("Could not get connection code")
+		logger.FromContext(ctx).Error("Could not get connection code")
 		return ""
 	}
 
@@ -262,6 +266,12 @@ func GetLnurlAuth(w http.ResponseWriter, r *http.Request) {
 		Host: encodeData.K1[0:20],
 		Conn: socket.Conn,
 	})
+	// the wallet may call back on another instance than the one holding the socket
+	if socketKey != "" {
+		if err := websocket.WebsocketPool.Alias(encodeData.K1[0:20], socketKey); err != nil {
+			logger.FromContext(r.Context()).Error("[auth] could not share the LNURL-auth socket: %v", err)
+		}
+	}
 
 	responseData["k1"] = encodeData.K1
 	responseData["encode"] = encodeData.Encode
@@ -293,7 +303,7 @@ func ReceiveLnAuthData(w http.ResponseWriter, r *http.Request) {
 
 	exVerify, err := auth.VerifyDerSig(sig, k1, userKey)
 	if err != nil || !exVerify {
-		logger.Log.Error("[auth] Error signing signature")
+		logger.FromContext(r.Context()).Error("[auth] Error signing signature")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(err.Error())
 		return
@@ -302,23 +312,28 @@ func ReceiveLnAuthData(w http.ResponseWriter, r *http.Request) {
 	responseMsg := LnAuthResponse{}
 
 	if userKey != "" {
+		// A key linked to a person logs in as that person
+		pubkey := auth.ResolvePubkey(userKey)
+
 		// Save in DB if the user does not exists already
-		db.DB.CreateLnUser(userKey)
+		if pubkey == userKey {
+			db.DB.CreateLnUser(userKey)
+		}
 
 		// Set store data to true
 		db.Store.SetLnCache(k1, db.LnStore{K1: k1, Key: userKey, Status: true})
 
 		// Send socket message
-		tokenString, err := auth.EncodeJwt(userKey)
+		tokenString, refreshToken, err := NewSessionHandler(db.DB).StartSession(pubkey, r)
 
 		if err != nil {
-			logger.Log.Error("[auth] error creating LNAUTH JWT")
+			logger.FromContext(r.Context()).Error("[auth] error creating LNAUTH JWT")
 			w.WriteHeader(http.StatusNotAcceptable)
 			json.NewEncoder(w).Encode(err.Error())
 			return
 		}
 
-		person := db.DB.GetPersonByPubkey(userKey)
+		person := db.DB.GetPersonByPubkey(pubkey)
 		user := returnUserMap(person)
 
 		socketMsg := make(map[string]interface{})
@@ -327,16 +342,16 @@ func ReceiveLnAuthData(w http.ResponseWriter, r *http.Request) {
 		socketMsg["k1"] = k1
 		socketMsg["status"] = true
 		socketMsg["jwt"] = tokenString
+		socketMsg["refresh_token"] = refreshToken
 		socketMsg["user"] = user
 		socketMsg["msg"] = "lnauth_success"
 
-		socket, err := db.Store.GetSocketConnections(k1[0:20])
+		err = websocket.WebsocketPool.SendJSON(k1[0:20], socketMsg)
 
 		if err == nil {
-			socket.Conn.WriteJSON(socketMsg)
 			db.Store.DeleteCache(k1[0:20])
 		} else {
-			logger.Log.Error("[auth] Socket Error: %v", err)
+			logger.FromContext(r.Context()).Error("[auth] Socket Error: %v", err)
 		}
 
 		responseMsg.Status = "OK"
@@ -352,7 +367,7 @@ func ReceiveLnAuthData(w http.ResponseWriter, r *http.Request) {
 // RefreshToken godoc
 //
 //	@Summary		Refresh JWT token
-//	@Description	Refresh the JWT token using a valid existing token.
+//	@Description	Move a token issued before sessions existed onto a new session. Session tokens are refused, they are refreshed with their refresh token at /refresh_session.
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
@@ -365,7 +380,7 @@ func (ah *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	token := r.Header.Get("x-jwt")
 
 	if token == "" {
-		logger.Log.Error("[auth] Missing JWT token")
+		logger.FromContext(r.Context()).Error("[auth] Missing JWT token")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode("Missing JWT token")
 		return
@@ -375,7 +390,7 @@ func (ah *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	claims, err := ah.decodeJwt(token)
 
 	if err != nil {
-		logger.Log.Error("[auth] Failed to parse JWT", err)
+		logger.FromContext(r.Context()).Error("[auth] Failed to parse JWT: %v", err)
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(err.Error())
 		return
@@ -383,20 +398,43 @@ func (ah *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 
 	pubkey, ok := claims["pubkey"].(string)
 	if !ok || pubkey == "" {
-		logger.Log.Error("[auth] Missing pubkey claim in JWT")
+		logger.FromContext(r.Context()).Error("[auth] Missing pubkey claim in JWT")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode("Missing pubkey claim in JWT")
 		return
 	}
 
+	if auth.IsJwtRevoked(claims) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Token has been revoked")
+		return
+	}
+
+	// Session tokens are only renewed with their refresh token, tokens
+	// issued before sessions existed are moved onto a new session
+	if sessionID, _ := claims["sid"].(string); sessionID != "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Session tokens are refreshed with their refresh token at /refresh_session")
+		return
+	}
+
 	userCount := ah.db.GetLnUser(pubkey)
 
 	if userCount > 0 {
+		session, refreshToken, err := ah.createSession(pubkey, r)
+		if err != nil {
+			logger.FromContext(r.Context()).Error("[auth] error creating session: %v", err)
+			w.WriteHeader(http.StatusNotAcceptable)
+			json.NewEncoder(w).Encode(err.Error())
+			return
+		}
+		sessionID := session.ID.String()
+
 		// Generate a new token
-		tokenString, err := ah.encodeJwt(pubkey)
+		tokenString, err := ah.encodeJwt(pubkey, sessionID)
 
 		if err != nil {
-			logger.Log.Error("[auth] error creating refresh JWT")
+			logger.FromContext(r.Context()).Error("[auth] error creating refresh JWT")
 			w.WriteHeader(http.StatusNotAcceptable)
 			json.NewEncoder(w).Encode(err.Error())
 			return
@@ -408,6 +446,7 @@ func (ah *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		responseData["k1"] = ""
 		responseData["status"] = true
 		responseData["jwt"] = tokenString
+		responseData["refresh_token"] = refreshToken
 		responseData["user"] = user
 
 		w.WriteHeader(http.StatusOK)
@@ -632,7 +632,7 @@ func TestRefreshToken(t *testing.T) {
 
 		// Mock JWT encoding
 		mockEncodedToken := "encoded_mock_token"
-		mockEncodeJwt := func(pubkey string) (string, error) {
+		mockEncodeJwt := func(pubkey string, sessionID string) (string, error) {
 			return mockEncodedToken, nil
 		}
 		aHandler.encodeJwt = mockEncodeJwt
@@ -660,6 +660,7 @@ func TestRefreshToken(t *testing.T) {
 		}
 		assert.Equal(t, true, responseData["status"])
 		assert.Equal(t, mockEncodedToken, responseData["jwt"])
+		assert.NotEmpty(t, responseData["refresh_token"])
 		assert.EqualValues(t, person, fetchedPerson)
 	})
 
@@ -708,6 +709,22 @@ func TestRefreshToken(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, rr.Code)
 	})
 
+	t.Run("Session JWT Token", func(t *testing.T) {
+		aHandler.decodeJwt = func(token string) (jwt.MapClaims, error) {
+			return jwt.MapClaims{"pubkey": "your_pubkey", "sid": uuid.New().String()}, nil
+		}
+
+		req, err := http.NewRequest("GET", "/refresh_jwt", nil)
+		assert.NoError(t, err)
+		req.Header.Set("x-jwt", "session_token")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(aHandler.RefreshToken)
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
 	t.Run("Error During JWT Encoding", func(t *testing.T) {
 		mockToken := "mock_token"
 		person := db.Person{
@@ -719,7 +736,7 @@ func TestRefreshToken(t *testing.T) {
 		aHandler.decodeJwt = func(token string) (jwt.MapClaims, error) {
 			return jwt.MapClaims{"pubkey": person.OwnerPubKey}, nil
 		}
-		aHandler.encodeJwt = func(pubkey string) (string, error) {
+		aHandler.encodeJwt = func(pubkey string, sessionID string) (string, error) {
 			return "", fmt.Errorf("encoding error")
 		}
 
@@ -742,7 +759,7 @@ func TestCreateConnectionCode(t *testing.T) {
 
 	aHandler := NewAuthHandler(db.TestDB)
 
-	aHandler.makeConnectionCodeRequest = func(inviter_pubkey string, inviter_route_hint string, msats_amount uint64) string {
+	aHandler.makeConnectionCodeRequest = func(ctx context.Context, inviter_pubkey string, inviter_route_hint string, msats_amount uint64) string {
 		return "22222222222222222"
 	}
 
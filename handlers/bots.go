@@ -47,7 +47,7 @@ func (bt *botHandler) CreateOrEditBot(w http.ResponseWriter, r *http.Request) {
 	r.Body.Close()
 	err = json.Unmarshal(body, &bot)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -61,7 +61,7 @@ func (bt *botHandler) CreateOrEditBot(w http.ResponseWriter, r *http.Request) {
 
 	extractedPubkey, err := bt.verifyTribeUUID(bot.UUID, false)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -81,7 +81,7 @@ func (bt *botHandler) CreateOrEditBot(w http.ResponseWriter, r *http.Request) {
 
 	_, err = bt.db.CreateOrEditBot(bot)
 	if err != nil {
-		logger.Log.Error("=> ERR createOrEditBot: %v", err)
+		logger.FromContext(r.Context()).Error("=> ERR createOrEditBot: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -186,7 +186,7 @@ func (bt *botHandler) DeleteBot(w http.ResponseWriter, r *http.Request) {
 
 	uuid := chi.URLParam(r, "uuid")
 
-	logger.Log.Info("uuid: %s", uuid)
+	logger.FromContext(r.Context()).Info("uuid: %s", uuid)
 
 	if uuid == "" {
 		w.WriteHeader(http.StatusUnauthorized)
@@ -195,7 +195,7 @@ func (bt *botHandler) DeleteBot(w http.ResponseWriter, r *http.Request) {
 
 	extractedPubkey, err := bt.verifyTribeUUID(uuid, false)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -2,10 +2,11 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"math/rand"
 	"net/http"
 	"net/url"
@@ -22,6 +23,7 @@ import (
 	"github.com/stakwork/sphinx-tribes/db"
 	"github.com/stakwork/sphinx-tribes/logger"
 	"github.com/stakwork/sphinx-tribes/utils"
+	"github.com/stakwork/sphinx-tribes/websocket"
 	"gorm.io/gorm"
 )
 
@@ -40,12 +42,13 @@ type BountyTimingResponse struct {
 type bountyHandler struct {
 	httpClient               HttpClient
 	db                       db.Database
-	getSocketConnections     func(host string) (db.Client, error)
+	sendSocketMessage        func(host string, message interface{}) error
 	generateBountyResponse   func(bounties []db.NewBounty) []db.BountyResponse
 	userHasAccess            func(pubKeyFromAuth string, uuid string, role string) bool
-	getInvoiceStatusByTag    func(tag string) db.V2TagRes
+	getInvoiceStatusByTag    func(ctx context.Context, tag string) db.V2TagRes
 	getHoursDifference       func(createdDate int64, endDate *time.Time) int64
 	userHasManageBountyRoles func(pubKeyFromAuth string, uuid string) bool
+	settleInvoice            func(ctx context.Context, paymentRequest string) bool
 	m                        sync.Mutex
 }
 
@@ -54,11 +57,12 @@ func NewBountyHandler(httpClient HttpClient, database db.Database) *bountyHandle
 	return &bountyHandler{
 		httpClient:               httpClient,
 		db:                       database,
-		getSocketConnections:     db.Store.GetSocketConnections,
+		sendSocketMessage:        websocket.WebsocketPool.SendJSON,
 		userHasAccess:            dbConf.UserHasAccess,
 		getInvoiceStatusByTag:    GetInvoiceStatusByTag,
 		getHoursDifference:       utils.GetHoursDifference,
 		userHasManageBountyRoles: dbConf.UserHasManageBountyRoles,
+		settleInvoice:            newInvoiceSettlement(database).SettleInvoice,
 	}
 }
 
@@ -67,8 +71,8 @@ type TimingError struct {
 	Error     string `json:"error"`
 }
 
-func handleTimingError(w http.ResponseWriter, operation string, err error) {
-	logger.Log.Error("[bounty_timing] %s failed: %v", operation, err)
+func handleTimingError(w http.ResponseWriter, r *http.Request, operation string, err error) {
+	logger.FromContext(r.Context()).Error("[bounty_timing] %s failed: %v", operation, err)
 	w.WriteHeader(http.StatusInternalServerError)
 	json.NewEncoder(w).Encode(TimingError{
 		Operation: operation,
@@ -107,7 +111,7 @@ func (h *bountyHandler) GetBountyById(w http.ResponseWriter, r *http.Request) {
 	bounties, err := h.db.GetBountyById(bountyId)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		logger.Log.Error("[bounty] Error: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] Error: %v", err)
 	} else {
 		var bountyResponse []db.BountyResponse = h.GenerateBountyResponse(bounties)
 		w.WriteHeader(http.StatusOK)
@@ -127,7 +131,7 @@ func (h *bountyHandler) GetNextBountyByCreated(w http.ResponseWriter, r *http.Re
 	bounties, err := h.db.GetNextBountyByCreated(r)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		logger.Log.Error("[bounty] Error: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] Error: %v", err)
 	} else {
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(bounties)
@@ -146,7 +150,7 @@ func (h *bountyHandler) GetPreviousBountyByCreated(w http.ResponseWriter, r *htt
 	bounties, err := h.db.GetPreviousBountyByCreated(r)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		logger.Log.Error("[bounty] Error: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] Error: %v", err)
 	} else {
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(bounties)
@@ -166,7 +170,7 @@ func (h *bountyHandler) GetWorkspaceNextBountyByCreated(w http.ResponseWriter, r
 	bounties, err := h.db.GetNextWorkspaceBountyByCreated(r)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		logger.Log.Error("[bounty] Error: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] Error: %v", err)
 	} else {
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(bounties)
@@ -186,7 +190,7 @@ func (h *bountyHandler) GetWorkspacePreviousBountyByCreated(w http.ResponseWrite
 	bounties, err := h.db.GetPreviousWorkspaceBountyByCreated(r)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		logger.Log.Error("[bounty] Error: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] Error: %v", err)
 	} else {
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(bounties)
@@ -228,7 +232,7 @@ func (h *bountyHandler) GetBountyByCreated(w http.ResponseWriter, r *http.Reques
 	bounties, err := h.db.GetBountyDataByCreated(created)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		logger.Log.Error("[bounty] Error: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] Error: %v", err)
 	} else {
 		var bountyResponse []db.BountyResponse = h.GenerateBountyResponse(bounties)
 
@@ -277,7 +281,7 @@ func (h *bountyHandler) GetPersonCreatedBounties(w http.ResponseWriter, r *http.
 
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		logger.Log.Error("[bounty] Error: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] Error: %v", err)
 	} else {
 		var bountyResponse []db.BountyResponse = h.GenerateBountyResponse(bounties)
 
@@ -290,7 +294,7 @@ func (h *bountyHandler) GetPersonAssignedBounties(w http.ResponseWriter, r *http
 	bounties, err := h.db.GetAssignedBounties(r)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		logger.Log.Error("[bounty] Error: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] Error: %v", err)
 	} else {
 		var bountyResponse []db.BountyResponse = h.GenerateBountyResponse(bounties)
 		w.WriteHeader(http.StatusOK)
@@ -298,9 +302,9 @@ func (h *bountyHandler) GetPersonAssignedBounties(w http.ResponseWriter, r *http
 	}
 }
 
-func getContactKey(pubkey string) (*string, error) {
+func getContactKey(ctx context.Context, pubkey string) (*string, error) {
 	url := fmt.Sprintf("%s/get_contact/%s", config.V2BotUrl, pubkey)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %v", err)
 	}
@@ -323,13 +327,15 @@ func getContactKey(pubkey string) (*string, error) {
 	return contactResp.ContactKey, nil
 }
 
-func ProcessWaitingNotifications() {
+func ProcessWaitingNotifications() error {
+	var errs []error
+	ctx := context.Background()
 	notifications := db.DB.GetNotificationsByStatus("WAITING_KEY_EXCHANGE")
 
 	for _, n := range notifications {
-		contactKey, err := getContactKey(n.PubKey)
+		contactKey, err := getContactKey(ctx, n.PubKey)
 		if err != nil {
-			logger.Log.Error("Error checking contact key for pubkey %s: %v", n.PubKey, err)
+			errs = append(errs, fmt.Errorf("could not check contact key of %s: %w", n.PubKey, err))
 			db.DB.IncrementNotificationRetry(n.UUID)
 			continue
 		}
@@ -340,12 +346,16 @@ func ProcessWaitingNotifications() {
 		}
 
 		// Contact key is available, proceed with sending
-		sendRespStatus := sendNotification(n.PubKey, n.Content)
+		sendRespStatus := sendNotification(ctx, n.PubKey, n.Content)
 		db.DB.UpdateNotificationStatus(n.UUID, sendRespStatus)
+		if sendRespStatus == "FAILED" {
+			errs = append(errs, fmt.Errorf("could not send notification %s", n.UUID))
+		}
 	}
+	return errors.Join(errs...)
 }
 
-func sendNotification(pubkey, content string) string {
+func sendNotification(ctx context.Context, pubkey, content string) string {
 	sendURL := fmt.Sprintf("%s/send", config.V2BotUrl)
 	msgBody, _ := json.Marshal(map[string]interface{}{
 		"dest":     pubkey,
@@ -355,9 +365,9 @@ func sendNotification(pubkey, content string) string {
 		"wait":     true,
 	})
 
-	req, err := http.NewRequest(http.MethodPost, sendURL, bytes.NewBuffer(msgBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sendURL, bytes.NewBuffer(msgBody))
 	if err != nil {
-		logger.Log.Error("Error creating send request: %v", err)
+		logger.FromContext(ctx).Error("Error creating send request: %v", err)
 		return "FAILED"
 	}
 	req.Header.Set("Content-Type", "application/json")
@@ -365,7 +375,7 @@ func sendNotification(pubkey, content string) string {
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		logger.Log.Error("Error sending notification: %v", err)
+		logger.FromContext(ctx).Error("Error sending notification: %v", err)
 		return "FAILED"
 	}
 	defer resp.Body.Close()
@@ -374,39 +384,39 @@ func sendNotification(pubkey, content string) string {
 		Status string `json:"status"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&sendResp); err != nil {
-		logger.Log.Error("Error decoding send response: %v", err)
+		logger.FromContext(ctx).Error("Error decoding send response: %v", err)
 		return "FAILED"
 	}
 
 	return sendResp.Status
 }
 
-func processNotification(pubkey, event, content, alias string, route_hint string) string {
-	contactKey, err := getContactKey(pubkey)
+func processNotification(ctx context.Context, pubkey, event, content, alias string, route_hint string) string {
+	contactKey, err := getContactKey(ctx, pubkey)
 	if err != nil {
-		logger.Log.Error("Error checking contact key: %v", err)
+		logger.FromContext(ctx).Error("Error checking contact key: %v", err)
 		return "FAILED"
 	}
 
 	if contactKey == nil {
 
 		contact_info := fmt.Sprintf("%s_%s", pubkey, route_hint)
-		logger.Log.Info("Sending contact info: %v", contact_info)
+		logger.FromContext(ctx).Info("Sending contact info: %v", contact_info)
 		addContactURL := fmt.Sprintf("%s/add_contact", config.V2BotUrl)
 		body, _ := json.Marshal(map[string]string{"contact_info": contact_info, "alias": alias})
-		req, _ := http.NewRequest(http.MethodPost, addContactURL, bytes.NewBuffer(body))
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, addContactURL, bytes.NewBuffer(body))
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("x-admin-token", config.V2BotToken)
 		http.DefaultClient.Do(req)
 
-		contactKey, err = getContactKey(pubkey)
+		contactKey, err = getContactKey(ctx, pubkey)
 		if err != nil || contactKey == nil {
 			db.DB.SaveNotification(pubkey, event, content, "WAITING_KEY_EXCHANGE")
 			return "FAILED"
 		}
 	}
 
-	return sendNotification(pubkey, content)
+	return sendNotification(ctx, pubkey, content)
 }
 
 // CreateOrEditBounty godoc
@@ -442,14 +452,14 @@ func (h *bountyHandler) CreateOrEditBounty(w http.ResponseWriter, r *http.Reques
 	r.Body.Close()
 
 	if err != nil {
-		logger.Log.Error("[bounty] Read error: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] Read error: %v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
 
 	err = json.Unmarshal(body, &bounty)
 	if err != nil {
-		logger.Log.Error("[bounty] Unmarshal error: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] Unmarshal error: %v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -499,7 +509,7 @@ func (h *bountyHandler) CreateOrEditBounty(w http.ResponseWriter, r *http.Reques
 
 		if bounty.ID != 0 {
 			if err := h.db.StartBountyTiming(bounty.ID); err != nil {
-				handleTimingError(w, "start_timing", err)
+				handleTimingError(w, r, "start_timing", err)
 			}
 		}
 
@@ -528,7 +538,7 @@ func (h *bountyHandler) CreateOrEditBounty(w http.ResponseWriter, r *http.Reques
 		// check if the bounty has a pending payment
 		if dbBounty.PaymentPending {
 			msg := "You cannot update a bounty with a pending payment"
-			logger.Log.Info("[bounty]: %v", msg)
+			logger.FromContext(r.Context()).Info("[bounty]: %v", msg)
 			w.WriteHeader(http.StatusBadRequest)
 			json.NewEncoder(w).Encode(msg)
 			return
@@ -541,14 +551,14 @@ func (h *bountyHandler) CreateOrEditBounty(w http.ResponseWriter, r *http.Reques
 				hasBountyRoles := h.userHasManageBountyRoles(pubKeyFromAuth, bounty.WorkspaceUuid)
 				if !hasBountyRoles {
 					msg := "You don't have the right permission ton update bounty"
-					logger.Log.Info("[bounty]: %v", msg)
+					logger.FromContext(r.Context()).Info("[bounty]: %v", msg)
 					w.WriteHeader(http.StatusBadRequest)
 					json.NewEncoder(w).Encode(msg)
 					return
 				}
 			} else {
 				msg := "Cannot edit another user's bounty"
-				logger.Log.Info("[bounty]: %v", msg)
+				logger.FromContext(r.Context()).Info("[bounty]: %v", msg)
 				w.WriteHeader(http.StatusBadRequest)
 				json.NewEncoder(w).Encode(msg)
 				return
@@ -572,14 +582,14 @@ func (h *bountyHandler) CreateOrEditBounty(w http.ResponseWriter, r *http.Reques
 	existingBounty := h.db.GetBounty(bounty.ID)
 	b, err := h.db.CreateOrEditBounty(bounty)
 	if err != nil {
-		logger.Log.Error("[bounty] Error: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] Error: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
 	if bounty.ID == 0 && bounty.Assignee != "" {
 		if err := h.db.StartBountyTiming(b.ID); err != nil {
-			handleTimingError(w, "start_timing", err)
+			handleTimingError(w, r, "start_timing", err)
 		}
 	}
 
@@ -594,7 +604,7 @@ func (h *bountyHandler) CreateOrEditBounty(w http.ResponseWriter, r *http.Reques
 
 		if assigneePubkey != "" {
 			person := db.DB.GetPersonByPubkey(assigneePubkey)
-			processNotification(assigneePubkey, "bounty_assigned", msg, person.OwnerAlias, person.OwnerRouteHint)
+			processNotification(r.Context(), assigneePubkey, "bounty_assigned", msg, person.OwnerAlias, person.OwnerRouteHint)
 		}
 
 	}
@@ -625,7 +635,7 @@ func (h *bountyHandler) DeleteBounty(w http.ResponseWriter, r *http.Request) {
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Error("[bounty] no pubkey from auth")
+		logger.FromContext(r.Context()).Error("[bounty] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -634,12 +644,12 @@ func (h *bountyHandler) DeleteBounty(w http.ResponseWriter, r *http.Request) {
 	pubkey := chi.URLParam(r, "pubkey")
 
 	if pubkey == "" {
-		logger.Log.Error("[bounty] no pubkey from route")
+		logger.FromContext(r.Context()).Error("[bounty] no pubkey from route")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 	if created == "" {
-		logger.Log.Error("[bounty] no created timestamp from route")
+		logger.FromContext(r.Context()).Error("[bounty] no created timestamp from route")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -648,14 +658,14 @@ func (h *bountyHandler) DeleteBounty(w http.ResponseWriter, r *http.Request) {
 	createdUint, _ := utils.ConvertStringToUint(created)
 	createdBounty, err := h.db.GetBountyByCreated(createdUint)
 	if err != nil {
-		logger.Log.Error("[bounty] failed to delete bounty: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] failed to delete bounty: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode("failed to delete bounty")
 		return
 	}
 
 	if createdBounty.ID == 0 {
-		logger.Log.Error("[bounty] failed to delete bounty")
+		logger.FromContext(r.Context()).Error("[bounty] failed to delete bounty")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode("failed to delete bounty")
 		return
@@ -663,7 +673,7 @@ func (h *bountyHandler) DeleteBounty(w http.ResponseWriter, r *http.Request) {
 
 	b, err := h.db.DeleteBounty(pubkey, created)
 	if err != nil {
-		logger.Log.Error("[bounty] failed to delete bounty: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] failed to delete bounty: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode("failed to delete bounty")
 		return
@@ -895,14 +905,14 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 
 	id, err := utils.ConvertStringToUint(idParam)
 	if err != nil {
-		logger.Log.Error("[bounty] could not parse id")
+		logger.FromContext(r.Context()).Error("[bounty] could not parse id")
 		w.WriteHeader(http.StatusForbidden)
 		h.m.Unlock()
 		return
 	}
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Error("[bounty] no pubkey from auth")
+		logger.FromContext(r.Context()).Error("[bounty] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		h.m.Unlock()
 		return
@@ -948,8 +958,10 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 
 	// check if the workspace bounty balance
 	// is greater than the amount
+	// minus what is reserved for unredeemed LNURL-withdraw payouts
 	orgBudget := h.db.GetWorkspaceBudget(bounty.WorkspaceUuid)
-	if orgBudget.TotalBudget < amount {
+	reserved := h.db.GetWorkspaceReservedPayouts(bounty.WorkspaceUuid)
+	if orgBudget.TotalBudget < amount+reserved {
 		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode("workspace budget is not enough to pay the amount")
 		h.m.Unlock()
@@ -960,7 +972,7 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 	body, err := io.ReadAll(r.Body)
 	r.Body.Close()
 	if err != nil {
-		logger.Log.Error("[bounty] Read body error: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] Read body error: %v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		h.m.Unlock()
 		return
@@ -968,7 +980,7 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 
 	err = json.Unmarshal(body, &request)
 	if err != nil {
-		logger.Log.Error("[bounty] Unmarshal error: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] Unmarshal error: %v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		h.m.Unlock()
 		return
@@ -985,39 +997,39 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 	if config.IsV2Payment {
 		url := fmt.Sprintf("%s/pay", config.V2BotUrl)
 
-		logger.Log.Info("IS V2 PAYMENT ====")
+		logger.FromContext(r.Context()).Info("IS V2 PAYMENT ====")
 
 		// Build v2 keysend payment data
 		bodyData := utils.BuildV2KeysendBodyData(amount, assignee.OwnerPubKey, assignee.OwnerRouteHint, memoText)
 		jsonBody := []byte(bodyData)
 
-		log.Println("Payment Body Data", bodyData)
+		logger.FromContext(r.Context()).Info("Payment Body Data %v", bodyData)
 
-		req, _ := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonBody))
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, url, bytes.NewBuffer(jsonBody))
 		req.Header.Set("x-admin-token", config.V2BotToken)
 		req.Header.Set("Content-Type", "application/json")
-		log.Printf("[bounty] Making Bounty V2 Payment: amount: %d, pubkey: %s, route_hint: %s", amount, assignee.OwnerPubKey, assignee.OwnerRouteHint)
+		logger.FromContext(r.Context()).Info("[bounty] Making Bounty V2 Payment: amount: %d, pubkey: %s, route_hint: %s", amount, assignee.OwnerPubKey, assignee.OwnerRouteHint)
 
 		res, err := h.httpClient.Do(req)
 
 		if err != nil {
-			log.Printf("[bounty] Request Failed: %s", err)
+			logger.FromContext(r.Context()).Error("[bounty] Request Failed: %s", err)
 			h.m.Unlock()
 			return
 		}
 
-		log.Printf("[bounty] After Making Bounty V2 Payment: amount: %d, pubkey: %s, route_hint: %s", amount, assignee.OwnerPubKey, assignee.OwnerRouteHint)
+		logger.FromContext(r.Context()).Info("[bounty] After Making Bounty V2 Payment: amount: %d, pubkey: %s, route_hint: %s", amount, assignee.OwnerPubKey, assignee.OwnerRouteHint)
 
 		defer res.Body.Close()
 		body, err = io.ReadAll(res.Body)
 		if err != nil {
-			log.Println("[read body failed]", err)
+			logger.FromContext(r.Context()).Error("[read body failed] %v", err)
 			w.WriteHeader(http.StatusNotAcceptable)
 			h.m.Unlock()
 			return
 		}
 
-		log.Println("[bounty] After Reading Keysend V2 Payment Body ===")
+		logger.FromContext(r.Context()).Info("[bounty] After Reading Keysend V2 Payment Body ===")
 
 		msg := make(map[string]interface{})
 		// payment is successful add to payment history
@@ -1043,13 +1055,13 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 			err = json.Unmarshal(body, &v2KeysendRes)
 
 			if err != nil {
-				logger.Log.Error("[Unmarshal failed]: %v", err)
+				logger.FromContext(r.Context()).Error("[Unmarshal failed]: %v", err)
 				w.WriteHeader(http.StatusNotAcceptable)
 				h.m.Unlock()
 				return
 			}
 
-			log.Printf("[bounty] V2 Status After Making Bounty V2 Payment: amount: %d, pubkey: %s, route_hint: %s is : %s", amount, assignee.OwnerPubKey, assignee.OwnerRouteHint, v2KeysendRes.Status)
+			logger.FromContext(r.Context()).Info("[bounty] V2 Status After Making Bounty V2 Payment: amount: %d, pubkey: %s, route_hint: %s is : %s", amount, assignee.OwnerPubKey, assignee.OwnerRouteHint, v2KeysendRes.Status)
 
 			// if the payment has a completed status
 			if v2KeysendRes.Status == db.PaymentComplete {
@@ -1069,10 +1081,8 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 				msg["msg"] = "keysend_success"
 				msg["invoice"] = ""
 
-				socket, err := h.getSocketConnections(request.Websocket_token)
-				if err == nil {
-					socket.Conn.WriteJSON(msg)
-				}
+				h.sendSocketMessage(request.Websocket_token, msg)
+				publishEvent(ctx, websocket.BountyTopic(bounty.ID), "bounty_paid", bounty)
 
 				h.m.Unlock()
 
@@ -1081,7 +1091,7 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 				return
 			} else if v2KeysendRes.Status == db.PaymentPending {
 				// Send payment status
-				log.Printf("[bounty] V2 Status is pending:  %s", v2KeysendRes.Status)
+				logger.FromContext(r.Context()).Info("[bounty] V2 Status is pending:  %s", v2KeysendRes.Status)
 				bounty.Paid = false
 				bounty.PaymentFailed = false
 				bounty.PaymentPending = true
@@ -1098,10 +1108,7 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 				msg["msg"] = "keysend_pending"
 				msg["invoice"] = ""
 
-				socket, err := h.getSocketConnections(request.Websocket_token)
-				if err == nil {
-					socket.Conn.WriteJSON(msg)
-				}
+				h.sendSocketMessage(request.Websocket_token, msg)
 
 				h.m.Unlock()
 
@@ -1110,7 +1117,7 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 				return
 			} else {
 				// Send payment status
-				log.Printf("[bounty] V2 Status Was not completed:  %s", v2KeysendRes.Status)
+				logger.FromContext(r.Context()).Info("[bounty] V2 Status Was not completed:  %s", v2KeysendRes.Status)
 
 				bounty.Paid = false
 				bounty.PaymentPending = false
@@ -1124,14 +1131,11 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 				h.db.AddPaymentHistory(paymentHistory)
 				h.db.UpdateBounty(bounty)
 
-				log.Println("Keysend payment not completed ===")
+				logger.FromContext(r.Context()).Info("Keysend payment not completed ===")
 				msg["msg"] = "keysend_failed"
 				msg["invoice"] = ""
 
-				socket, err := h.getSocketConnections(request.Websocket_token)
-				if err == nil {
-					socket.Conn.WriteJSON(msg)
-				}
+				h.sendSocketMessage(request.Websocket_token, msg)
 
 				h.m.Unlock()
 
@@ -1140,7 +1144,7 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 				return
 			}
 		} else { // Send Payment error
-			log.Println("Keysend payment error: Failed to send ===")
+			logger.FromContext(r.Context()).Error("Keysend payment error: Failed to send ===")
 			msg["msg"] = "keysend_error"
 			msg["invoice"] = ""
 
@@ -1154,10 +1158,7 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 			h.db.AddPaymentHistory(paymentHistory)
 			h.db.UpdateBounty(bounty)
 
-			socket, err := h.getSocketConnections(request.Websocket_token)
-			if err == nil {
-				socket.Conn.WriteJSON(msg)
-			}
+			h.sendSocketMessage(request.Websocket_token, msg)
 
 			h.m.Unlock()
 
@@ -1171,16 +1172,16 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 		bodyData := utils.BuildKeysendBodyData(amount, assignee.OwnerPubKey, assignee.OwnerRouteHint, memoText)
 		jsonBody := []byte(bodyData)
 
-		log.Println("Payment Body Data", bodyData)
+		logger.FromContext(r.Context()).Info("Payment Body Data %v", bodyData)
 
-		req, _ := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonBody))
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, url, bytes.NewBuffer(jsonBody))
 		req.Header.Set("x-user-token", config.RelayAuthKey)
 		req.Header.Set("Content-Type", "application/json")
-		log.Printf("[bounty] Making Bounty Payment: amount: %d, pubkey: %s, route_hint: %s", amount, assignee.OwnerPubKey, assignee.OwnerRouteHint)
+		logger.FromContext(r.Context()).Info("[bounty] Making Bounty Payment: amount: %d, pubkey: %s, route_hint: %s", amount, assignee.OwnerPubKey, assignee.OwnerRouteHint)
 		res, err := h.httpClient.Do(req)
 
 		if err != nil {
-			log.Printf("[bounty] Request Failed: %s", err)
+			logger.FromContext(r.Context()).Error("[bounty] Request Failed: %s", err)
 			h.m.Unlock()
 			return
 		}
@@ -1188,7 +1189,7 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 		defer res.Body.Close()
 		body, err = io.ReadAll(res.Body)
 		if err != nil {
-			logger.Log.Error("[bounty] Read body error: %v", err)
+			logger.FromContext(r.Context()).Error("[bounty] Read body error: %v", err)
 			w.WriteHeader(http.StatusNotAcceptable)
 			h.m.Unlock()
 			return
@@ -1204,7 +1205,7 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 			err = json.Unmarshal(body, &keysendRes)
 
 			if err != nil {
-				logger.Log.Error("[bounty] Unmarshal error: %v", err)
+				logger.FromContext(r.Context()).Error("[bounty] Unmarshal error: %v", err)
 				w.WriteHeader(http.StatusNotAcceptable)
 				h.m.Unlock()
 				return
@@ -1234,20 +1235,15 @@ func (h *bountyHandler) MakeBountyPayment(w http.ResponseWriter, r *http.Request
 			msg["msg"] = "keysend_success"
 			msg["invoice"] = ""
 
-			socket, err := h.getSocketConnections(request.Websocket_token)
-			if err == nil {
-				socket.Conn.WriteJSON(msg)
-			}
+			h.sendSocketMessage(request.Websocket_token, msg)
+			publishEvent(ctx, websocket.BountyTopic(bounty.ID), "bounty_paid", bounty)
 			h.m.Unlock()
 			return
 		} else {
 			msg["msg"] = "keysend_error"
 			msg["invoice"] = ""
 
-			socket, err := h.getSocketConnections(request.Websocket_token)
-			if err == nil {
-				socket.Conn.WriteJSON(msg)
-			}
+			h.sendSocketMessage(request.Websocket_token, msg)
 
 			h.m.Unlock()
 			return
@@ -1272,13 +1268,13 @@ func (h *bountyHandler) GetBountyPaymentStatus(w http.ResponseWriter, r *http.Re
 
 	id, err := utils.ConvertStringToUint(idParam)
 	if err != nil {
-		logger.Log.Error("[bounty] could not parse id")
+		logger.FromContext(r.Context()).Error("[bounty] could not parse id")
 		w.WriteHeader(http.StatusForbidden)
 		return
 	}
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Error("[bounty] no pubkey from auth")
+		logger.FromContext(r.Context()).Error("[bounty] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1326,13 +1322,13 @@ func (h *bountyHandler) UpdateBountyPaymentStatus(w http.ResponseWriter, r *http
 
 	id, err := utils.ConvertStringToUint(idParam)
 	if err != nil {
-		logger.Log.Error("[bounty] could not parse id")
+		logger.FromContext(r.Context()).Error("[bounty] could not parse id")
 		w.WriteHeader(http.StatusForbidden)
 		return
 	}
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Error("[bounty] no pubkey from auth")
+		logger.FromContext(r.Context()).Error("[bounty] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1366,7 +1362,7 @@ func (h *bountyHandler) UpdateBountyPaymentStatus(w http.ResponseWriter, r *http
 	if payment.Tag != "" {
 		tag := payment.Tag
 
-		tagResult := h.getInvoiceStatusByTag(tag)
+		tagResult := h.getInvoiceStatusByTag(r.Context(), tag)
 
 		msg := map[string]string{
 			"payment_status": tagResult.Status,
@@ -1398,7 +1394,7 @@ func (h *bountyHandler) UpdateBountyPaymentStatus(w http.ResponseWriter, r *http
 			err = h.db.ProcessReversePayments(payment.ID)
 
 			if err != nil {
-				log.Printf("Could not reverse bounty payment : Bounty ID - %d, Payment ID - %d, Error - %s", bounty.ID, payment.ID, err)
+				logger.FromContext(r.Context()).Error("Could not reverse bounty payment : Bounty ID - %d, Payment ID - %d, Error - %s", bounty.ID, payment.ID, err)
 			}
 
 			w.WriteHeader(http.StatusOK)
@@ -1415,7 +1411,7 @@ func (h *bountyHandler) UpdateBountyPaymentStatus(w http.ResponseWriter, r *http
 
 					err = h.db.ProcessReversePayments(payment.ID)
 					if err != nil {
-						log.Printf("Could not reverse bounty payment after 7 days : Bounty ID - %d, Payment ID - %d, Error - %s", bounty.ID, payment.ID, err)
+						logger.FromContext(r.Context()).Error("Could not reverse bounty payment after 7 days : Bounty ID - %d, Payment ID - %d, Error - %s", bounty.ID, payment.ID, err)
 					}
 				}
 			}
@@ -1449,7 +1445,7 @@ func (h *bountyHandler) BountyBudgetWithdraw(w http.ResponseWriter, r *http.Requ
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Error("[bounty] no pubkey from auth")
+		logger.FromContext(r.Context()).Error("[bounty] no pubkey from auth")
 		h.m.Unlock()
 
 		w.WriteHeader(http.StatusUnauthorized)
@@ -1490,13 +1486,13 @@ func (h *bountyHandler) BountyBudgetWithdraw(w http.ResponseWriter, r *http.Requ
 
 			w.WriteHeader(http.StatusUnauthorized)
 			errMsg := formatPayError("Your last withdrawal is  not more than an hour ago")
-			log.Println("Your last withdrawal is not more than an hour ago", hoursDiff, lastWithdrawal.Created, request.WorkspaceUuid)
+			logger.FromContext(r.Context()).Info("Your last withdrawal is not more than an hour ago %v %v %v", hoursDiff, lastWithdrawal.Created, request.WorkspaceUuid)
 			json.NewEncoder(w).Encode(errMsg)
 			return
 		}
 	}
 
-	log.Printf("[bounty] [BountyBudgetWithdraw] Logging body: workspace_uuid: %s, pubkey: %s, invoice: %s", request.WorkspaceUuid, pubKeyFromAuth, request.PaymentRequest)
+	logger.FromContext(r.Context()).Info("[bounty] [BountyBudgetWithdraw] Logging body: workspace_uuid: %s, pubkey: %s, invoice: %s", request.WorkspaceUuid, pubKeyFromAuth, request.PaymentRequest)
 
 	// check if user is the admin of the workspace
 	// or has a withdraw bounty budget role
@@ -1515,8 +1511,10 @@ func (h *bountyHandler) BountyBudgetWithdraw(w http.ResponseWriter, r *http.Requ
 	if amount > 0 {
 		// check if the workspace bounty balance
 		// is greater than the amount
+		// minus what is reserved for unredeemed LNURL-withdraw payouts
 		orgBudget := h.db.GetWorkspaceBudget(request.WorkspaceUuid)
-		if amount > orgBudget.TotalBudget {
+		reserved := h.db.GetWorkspaceReservedPayouts(request.WorkspaceUuid)
+		if amount+reserved > orgBudget.TotalBudget {
 			h.m.Unlock()
 
 			w.WriteHeader(http.StatusForbidden)
@@ -1538,7 +1536,7 @@ func (h *bountyHandler) BountyBudgetWithdraw(w http.ResponseWriter, r *http.Requ
 			return
 		}
 
-		paymentSuccess, paymentError := h.PayLightningInvoice(request.PaymentRequest)
+		paymentSuccess, paymentError := h.PayLightningInvoice(r.Context(), request.PaymentRequest)
 		if paymentSuccess.Success {
 			// withdraw amount from workspace budget
 			h.db.WithdrawBudget(pubKeyFromAuth, request.WorkspaceUuid, amount)
@@ -1569,26 +1567,26 @@ func formatPayError(errorMsg string) db.InvoicePayError {
 	}
 }
 
-func (h *bountyHandler) GetLightningInvoice(payment_request string) (db.InvoiceResult, db.InvoiceError) {
+func (h *bountyHandler) GetLightningInvoice(ctx context.Context, payment_request string) (db.InvoiceResult, db.InvoiceError) {
 	if config.IsV2Payment {
-		return h.GetV2LightningInvoice(payment_request)
+		return h.GetV2LightningInvoice(ctx, payment_request)
 	} else {
-		return h.GetV1LightningInvoice(payment_request)
+		return h.GetV1LightningInvoice(ctx, payment_request)
 	}
 }
 
-func (h *bountyHandler) GetV1LightningInvoice(payment_request string) (db.InvoiceResult, db.InvoiceError) {
+func (h *bountyHandler) GetV1LightningInvoice(ctx context.Context, payment_request string) (db.InvoiceResult, db.InvoiceError) {
 	url := fmt.Sprintf("%s/invoice?payment_request=%s", config.RelayUrl, payment_request)
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 
 	req.Header.Set("x-user-token", config.RelayAuthKey)
 	req.Header.Set("Content-Type", "application/json")
-	res, _ := h.httpClient.Do(req)
+	res, err := h.httpClient.Do(req)
 
 	if err != nil {
-		log.Printf("[bounty] Request Failed: %s", err)
-		return db.InvoiceResult{}, db.InvoiceError{}
+		logger.FromContext(ctx).Error("[bounty] Request Failed: %s", err)
+		return db.InvoiceResult{}, db.InvoiceError{Success: false, Error: err.Error()}
 	}
 
 	defer res.Body.Close()
@@ -1596,7 +1594,7 @@ func (h *bountyHandler) GetV1LightningInvoice(payment_request string) (db.Invoic
 	body, err := io.ReadAll(res.Body)
 
 	if err != nil {
-		log.Printf("Error reading: %s", err)
+		logger.FromContext(ctx).Error("Error reading: %s", err)
 		return db.InvoiceResult{}, db.InvoiceError{Success: false, Error: err.Error()}
 	}
 
@@ -1606,7 +1604,7 @@ func (h *bountyHandler) GetV1LightningInvoice(payment_request string) (db.Invoic
 		err = json.Unmarshal(body, &invoiceErr)
 
 		if err != nil {
-			log.Printf("[bounty] Reading Invoice body failed: %s", err)
+			logger.FromContext(ctx).Error("[bounty] Reading Invoice body failed: %s", err)
 			return db.InvoiceResult{}, invoiceErr
 		}
 
@@ -1617,7 +1615,7 @@ func (h *bountyHandler) GetV1LightningInvoice(payment_request string) (db.Invoic
 		err = json.Unmarshal(body, &invoiceRes)
 
 		if err != nil {
-			log.Printf("[bounty] Reading Invoice body failed: %s", err)
+			logger.FromContext(ctx).Error("[bounty] Reading Invoice body failed: %s", err)
 			return invoiceRes, db.InvoiceError{}
 		}
 
@@ -1625,7 +1623,7 @@ func (h *bountyHandler) GetV1LightningInvoice(payment_request string) (db.Invoic
 	}
 }
 
-func (h *bountyHandler) GetV2LightningInvoice(payment_request string) (db.InvoiceResult, db.InvoiceError) {
+func (h *bountyHandler) GetV2LightningInvoice(ctx context.Context, payment_request string) (db.InvoiceResult, db.InvoiceError) {
 	url := fmt.Sprintf("%s/check_invoice", config.V2BotUrl)
 
 	invoiceBody := db.V2InvoiceBody{
@@ -1634,13 +1632,13 @@ func (h *bountyHandler) GetV2LightningInvoice(payment_request string) (db.Invoic
 
 	jsonBody, _ := json.Marshal(invoiceBody)
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonBody))
 	req.Header.Set("x-admin-token", config.V2BotToken)
 	req.Header.Set("Content-Type", "application/json")
-	res, _ := h.httpClient.Do(req)
+	res, err := h.httpClient.Do(req)
 
 	if err != nil {
-		log.Printf("[bounty] Request Failed: %s", err)
+		logger.FromContext(ctx).Error("[bounty] Request Failed: %s", err)
 		return db.InvoiceResult{}, db.InvoiceError{Success: false, Error: err.Error()}
 	}
 
@@ -1649,7 +1647,7 @@ func (h *bountyHandler) GetV2LightningInvoice(payment_request string) (db.Invoic
 	body, err := io.ReadAll(res.Body)
 
 	if err != nil {
-		log.Printf("[bounty] Reading Invoice body failed: %s", err)
+		logger.FromContext(ctx).Error("[bounty] Reading Invoice body failed: %s", err)
 		return db.InvoiceResult{}, db.InvoiceError{Success: false, Error: err.Error()}
 	}
 
@@ -1659,7 +1657,7 @@ func (h *bountyHandler) GetV2LightningInvoice(payment_request string) (db.Invoic
 		err = json.Unmarshal(body, &invoiceErr)
 
 		if err != nil {
-			log.Printf("[bounty] Unmarshalling Invoice body failed: %s", err)
+			logger.FromContext(ctx).Error("[bounty] Unmarshalling Invoice body failed: %s", err)
 			return db.InvoiceResult{}, invoiceErr
 		}
 
@@ -1670,7 +1668,7 @@ func (h *bountyHandler) GetV2LightningInvoice(payment_request string) (db.Invoic
 		err = json.Unmarshal(body, &invoiceRes)
 
 		if err != nil {
-			log.Printf("[bounty] Reading Invoice body failed: %s", err)
+			logger.FromContext(ctx).Error("[bounty] Reading Invoice body failed: %s", err)
 			return db.InvoiceResult{}, db.InvoiceError{}
 		}
 
@@ -1693,23 +1691,23 @@ func (h *bountyHandler) GetV2LightningInvoice(payment_request string) (db.Invoic
 	}
 }
 
-func (h *bountyHandler) PayLightningInvoice(payment_request string) (db.InvoicePaySuccess, db.InvoicePayError) {
+func (h *bountyHandler) PayLightningInvoice(ctx context.Context, payment_request string) (db.InvoicePaySuccess, db.InvoicePayError) {
 	if config.IsV2Payment {
-		return h.PayV2LightningInvoice(payment_request)
+		return h.PayV2LightningInvoice(ctx, payment_request)
 	} else {
-		return h.PayV1LightningInvoice(payment_request)
+		return h.PayV1LightningInvoice(ctx, payment_request)
 	}
 }
 
-func (h *bountyHandler) PayV1LightningInvoice(payment_request string) (db.InvoicePaySuccess, db.InvoicePayError) {
+func (h *bountyHandler) PayV1LightningInvoice(ctx context.Context, payment_request string) (db.InvoicePaySuccess, db.InvoicePayError) {
 	url := fmt.Sprintf("%s/invoices", config.RelayUrl)
 	bodyData := fmt.Sprintf(`{"payment_request": "%s"}`, payment_request)
 	jsonBody := []byte(bodyData)
 
-	req, err := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewBuffer(jsonBody))
 
 	if err != nil {
-		log.Printf("Error paying invoice: %s", err)
+		logger.FromContext(ctx).Error("Error paying invoice: %s", err)
 	}
 
 	req.Header.Set("x-user-token", config.RelayAuthKey)
@@ -1717,7 +1715,7 @@ func (h *bountyHandler) PayV1LightningInvoice(payment_request string) (db.Invoic
 	res, err := h.httpClient.Do(req)
 
 	if err != nil {
-		log.Printf("[bounty] Request Failed: %s", err)
+		logger.FromContext(ctx).Error("[bounty] Request Failed: %s", err)
 		return db.InvoicePaySuccess{}, db.InvoicePayError{}
 	}
 
@@ -1726,7 +1724,7 @@ func (h *bountyHandler) PayV1LightningInvoice(payment_request string) (db.Invoic
 	body, err := io.ReadAll(res.Body)
 
 	if err != nil {
-		log.Printf("Error could not read body: %s", err)
+		logger.FromContext(ctx).Error("Error could not read body: %s", err)
 	}
 
 	if res.StatusCode != 200 {
@@ -1734,7 +1732,7 @@ func (h *bountyHandler) PayV1LightningInvoice(payment_request string) (db.Invoic
 		err = json.Unmarshal(body, &invoiceError)
 
 		if err != nil {
-			log.Printf("[bounty] Reading Invoice pay error body failed: %s", err)
+			logger.FromContext(ctx).Error("[bounty] Reading Invoice pay error body failed: %s", err)
 			return db.InvoicePaySuccess{}, db.InvoicePayError{}
 		}
 
@@ -1744,7 +1742,7 @@ func (h *bountyHandler) PayV1LightningInvoice(payment_request string) (db.Invoic
 		err = json.Unmarshal(body, &invoiceSuccess)
 
 		if err != nil {
-			log.Printf("[bounty] Reading Invoice pay success body failed: %s", err)
+			logger.FromContext(ctx).Error("[bounty] Reading Invoice pay success body failed: %s", err)
 			return db.InvoicePaySuccess{}, db.InvoicePayError{}
 		}
 
@@ -1752,15 +1750,15 @@ func (h *bountyHandler) PayV1LightningInvoice(payment_request string) (db.Invoic
 	}
 }
 
-func (h *bountyHandler) PayV2LightningInvoice(payment_request string) (db.InvoicePaySuccess, db.InvoicePayError) {
+func (h *bountyHandler) PayV2LightningInvoice(ctx context.Context, payment_request string) (db.InvoicePaySuccess, db.InvoicePayError) {
 	url := fmt.Sprintf("%s/pay_invoice", config.V2BotUrl)
 	bodyData := fmt.Sprintf(`{"bolt11": "%s", "wait": true}`, payment_request)
 	jsonBody := []byte(bodyData)
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonBody))
 
 	if err != nil {
-		log.Printf("Error paying invoice: %s", err)
+		logger.FromContext(ctx).Error("Error paying invoice: %s", err)
 		return db.InvoicePaySuccess{}, db.InvoicePayError{}
 	}
 
@@ -1770,7 +1768,7 @@ func (h *bountyHandler) PayV2LightningInvoice(payment_request string) (db.Invoic
 	res, err := h.httpClient.Do(req)
 
 	if err != nil {
-		log.Printf("[bounty] Request Failed: %s", err)
+		logger.FromContext(ctx).Error("[bounty] Request Failed: %s", err)
 		return db.InvoicePaySuccess{}, db.InvoicePayError{}
 	}
 
@@ -1779,7 +1777,7 @@ func (h *bountyHandler) PayV2LightningInvoice(payment_request string) (db.Invoic
 	body, err := io.ReadAll(res.Body)
 
 	if err != nil {
-		log.Printf("Error could not read body: %s", err)
+		logger.FromContext(ctx).Error("Error could not read body: %s", err)
 	}
 
 	if res.StatusCode != 200 {
@@ -1787,7 +1785,7 @@ func (h *bountyHandler) PayV2LightningInvoice(payment_request string) (db.Invoic
 		err = json.Unmarshal(body, &invoiceError)
 
 		if err != nil {
-			log.Printf("[bounty] Reading Invoice pay error body failed: %s", err)
+			logger.FromContext(ctx).Error("[bounty] Reading Invoice pay error body failed: %s", err)
 			return db.InvoicePaySuccess{}, db.InvoicePayError{}
 		}
 
@@ -1797,7 +1795,7 @@ func (h *bountyHandler) PayV2LightningInvoice(payment_request string) (db.Invoic
 		err = json.Unmarshal(body, &invoiceRes)
 
 		if err != nil {
-			log.Printf("[bounty] Reading Invoice pay success body failed: %s", err)
+			logger.FromContext(ctx).Error("[bounty] Reading Invoice pay success body failed: %s", err)
 			return db.InvoicePaySuccess{}, db.InvoicePayError{}
 		}
 
@@ -1831,7 +1829,7 @@ func (h *bountyHandler) PayV2LightningInvoice(payment_request string) (db.Invoic
 //	@Router			/gobounties/invoice/{paymentRequest} [get]
 func (h *bountyHandler) GetInvoiceData(w http.ResponseWriter, r *http.Request) {
 	paymentRequest := chi.URLParam(r, "paymentRequest")
-	invoiceData, invoiceErr := h.GetLightningInvoice(paymentRequest)
+	invoiceData, invoiceErr := h.GetLightningInvoice(r.Context(), paymentRequest)
 
 	if invoiceErr.Error != "" {
 		w.WriteHeader(http.StatusForbidden)
@@ -1860,12 +1858,12 @@ func (h *bountyHandler) PollInvoice(w http.ResponseWriter, r *http.Request) {
 	paymentRequest := chi.URLParam(r, "paymentRequest")
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Error("[bounty] no pubkey from auth")
+		logger.FromContext(r.Context()).Error("[bounty] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
-	invoiceRes, invoiceErr := h.GetLightningInvoice(paymentRequest)
+	invoiceRes, invoiceErr := h.GetLightningInvoice(r.Context(), paymentRequest)
 
 	if invoiceErr.Error != "" {
 		w.WriteHeader(http.StatusForbidden)
@@ -1874,18 +1872,7 @@ func (h *bountyHandler) PollInvoice(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if invoiceRes.Response.Settled {
-		// Todo if an invoice is settled
-		invoice := h.db.GetInvoice(paymentRequest)
-		dbInvoice := h.db.GetInvoice(paymentRequest)
-
-		// Make any change only if the invoice has not been settled
-		if !dbInvoice.Status {
-			if invoice.Type == "BUDGET" {
-				h.db.AddAndUpdateBudget(invoice)
-			}
-			// Update the invoice status
-			h.db.UpdateInvoice(paymentRequest)
-		}
+		h.settleInvoice(r.Context(), paymentRequest)
 	} else {
 		// Cheeck if time has expired
 		isInvoiceExpired := utils.GetInvoiceExpired(paymentRequest)
@@ -1933,9 +1920,9 @@ func (h *bountyHandler) GetBountyCards(w http.ResponseWriter, r *http.Request) {
 
 	bountyCardResponse := h.GenerateBountyCardResponse(bounties)
 
-	ticketCards, err := h.GenerateTicketCardResponse(workspaceUuid)
+	ticketCards, err := h.GenerateTicketCardResponse(r.Context(), workspaceUuid)
 	if err != nil {
-		logger.Log.Error("failed to generate ticket cards", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to generate ticket cards")
 	} else {
 		bountyCardResponse = append(bountyCardResponse, ticketCards...)
 	}
@@ -1944,7 +1931,7 @@ func (h *bountyHandler) GetBountyCards(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(bountyCardResponse)
 }
 
-func (h *bountyHandler) GenerateTicketCardResponse(workspaceUuid string) ([]db.BountyCard, error) {
+func (h *bountyHandler) GenerateTicketCardResponse(ctx context.Context, workspaceUuid string) ([]db.BountyCard, error) {
 	var ticketCards []db.BountyCard
 
 	ticketGroups, err := h.db.GetAllTicketGroups(workspaceUuid)
@@ -1955,7 +1942,7 @@ func (h *bountyHandler) GenerateTicketCardResponse(workspaceUuid string) ([]db.B
 	for _, group := range ticketGroups {
 		ticket, err := h.db.GetLatestTicketByGroup(group)
 		if err != nil {
-			logger.Log.Error("failed to get latest ticket", "group", group, "error", err)
+			logger.FromContext(ctx).With("group", group, "error", err).Error("failed to get latest ticket")
 			continue
 		}
 
@@ -2097,11 +2084,11 @@ func (h *bountyHandler) AddProofOfWork(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.db.PauseBountyTiming(proof.BountyID); err != nil {
-		handleTimingError(w, "pause_timing", err)
+		handleTimingError(w, r, "pause_timing", err)
 	}
 
 	if err := h.db.UpdateBountyTimingOnProof(proof.BountyID); err != nil {
-		handleTimingError(w, "update_timing_on_proof", err)
+		handleTimingError(w, r, "update_timing_on_proof", err)
 	}
 
 	if err := h.db.IncrementProofCount(proof.BountyID); err != nil {
@@ -2112,7 +2099,7 @@ func (h *bountyHandler) AddProofOfWork(w http.ResponseWriter, r *http.Request) {
 	bounties, err := h.db.GetBountyById(bountyID)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		logger.Log.Error("[bounty] Error: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] Error: %v", err)
 	} else {
 		var bountyResponse []db.BountyResponse = h.GenerateBountyResponse(bounties)
 
@@ -2126,7 +2113,7 @@ func (h *bountyHandler) AddProofOfWork(w http.ResponseWriter, r *http.Request) {
 		msg := fmt.Sprintf("%s has submitted PoW on Bounty %s/bounty/%d. %s", assineeAlias, os.Getenv("HOST"), bountyId, bountyTitle)
 
 		if ownerPubKey != "" {
-			processNotification(ownerPubKey, "bounty_assigned", msg, ownerAlias, ownerRouteHint)
+			processNotification(r.Context(), ownerPubKey, "bounty_assigned", msg, ownerAlias, ownerRouteHint)
 		}
 
 	}
@@ -2249,7 +2236,7 @@ func (h *bountyHandler) UpdateProofStatus(w http.ResponseWriter, r *http.Request
 		}
 
 		if err := h.db.ResumeBountyTiming(id); err != nil {
-			logger.Log.Error(fmt.Sprintf("Failed to resume timing for bounty ID %d: %v", id, err))
+			logger.FromContext(r.Context()).Error(fmt.Sprintf("Failed to resume timing for bounty ID %d: %v", id, err))
 		}
 
 	case db.AcceptedStatus:
@@ -2260,7 +2247,7 @@ func (h *bountyHandler) UpdateProofStatus(w http.ResponseWriter, r *http.Request
 		}
 
 		if err := h.db.CloseBountyTiming(id); err != nil {
-			logger.Log.Error(fmt.Sprintf("Failed to close timing for bounty ID %d: %v", id, err))
+			logger.FromContext(r.Context()).Error(fmt.Sprintf("Failed to close timing for bounty ID %d: %v", id, err))
 		}
 	}
 
@@ -2328,12 +2315,12 @@ func (h *bountyHandler) DeleteBountyAssignee(w http.ResponseWriter, r *http.Requ
 		h.db.UpdateBounty(b)
 
 		if err := h.db.CloseBountyTiming(b.ID); err != nil {
-			handleTimingError(w, "close_timing", err)
+			handleTimingError(w, r, "close_timing", err)
 		}
 
 		deletedAssignee = true
 	} else {
-		log.Printf("Could not delete bounty assignee")
+		logger.FromContext(r.Context()).Error("Could not delete bounty assignee")
 
 		deletedAssignee = false
 
@@ -2653,7 +2640,7 @@ func (h *bountyHandler) DeleteBountyTiming(w http.ResponseWriter, r *http.Reques
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -2667,14 +2654,14 @@ func (h *bountyHandler) DeleteBountyTiming(w http.ResponseWriter, r *http.Reques
 
 	_, err = h.db.GetBountyTiming(id)
 	if err != nil {
-		logger.Log.Error(fmt.Sprintf("No bounty timing found for bounty ID %d: %v", id, err))
+		logger.FromContext(r.Context()).Error(fmt.Sprintf("No bounty timing found for bounty ID %d: %v", id, err))
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": "No timing record found"})
 		return
 	}
 
 	if err := h.db.DeleteBountyTiming(id); err != nil {
-		logger.Log.Error(fmt.Sprintf("Failed to delete bounty timing for bounty ID %d: %v", id, err))
+		logger.FromContext(r.Context()).Error(fmt.Sprintf("Failed to delete bounty timing for bounty ID %d: %v", id, err))
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to delete bounty timing"})
 		return
@@ -2729,7 +2716,7 @@ func (h *bountyHandler) GetBountiesByWorkspaceTime(w http.ResponseWriter, r *htt
 
 	bounties, err := h.db.GetBountiesByWorkspaceAndTimeRange(workspaceId, startDate, endDate)
 	if err != nil {
-		logger.Log.Error("[bounty] Error retrieving bounties: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty] Error retrieving bounties: %v", err)
 		http.Error(w, "Error retrieving bounties", http.StatusInternalServerError)
 		return
 	}
@@ -2772,7 +2759,7 @@ func (h *bountyHandler) CreateBountyStake(w http.ResponseWriter, r *http.Request
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	
 	if pubKeyFromAuth == "" {
-		logger.Log.Error("[bounty_stake] no pubkey from auth")
+		logger.FromContext(r.Context()).Error("[bounty_stake] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -2780,7 +2767,7 @@ func (h *bountyHandler) CreateBountyStake(w http.ResponseWriter, r *http.Request
 	
 	var stake db.BountyStake
 	if err := json.NewDecoder(r.Body).Decode(&stake); err != nil {
-		logger.Log.Error("[bounty_stake] invalid request body: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty_stake] invalid request body: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
 		return
@@ -2790,7 +2777,7 @@ func (h *bountyHandler) CreateBountyStake(w http.ResponseWriter, r *http.Request
 	
 	createdStake, err := h.db.CreateBountyStake(stake)
 	if err != nil {
-		logger.Log.Error("[bounty_stake] failed to create stake: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty_stake] failed to create stake: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -2812,7 +2799,7 @@ func (h *bountyHandler) CreateBountyStake(w http.ResponseWriter, r *http.Request
 func (h *bountyHandler) GetAllBountyStakes(w http.ResponseWriter, r *http.Request) {
 	stakes, err := h.db.GetAllBountyStakes()
 	if err != nil {
-		logger.Log.Error("[bounty_stake] failed to get all stakes: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty_stake] failed to get all stakes: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to retrieve stakes"})
 		return
@@ -2837,7 +2824,7 @@ func (h *bountyHandler) GetBountyStakesByBountyID(w http.ResponseWriter, r *http
 	bountyIDStr := chi.URLParam(r, "bountyId")
 	bountyID, err := utils.ConvertStringToUint(bountyIDStr)
 	if err != nil {
-		logger.Log.Error("[bounty_stake] invalid bounty ID: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty_stake] invalid bounty ID: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid bounty ID"})
 		return
@@ -2845,7 +2832,7 @@ func (h *bountyHandler) GetBountyStakesByBountyID(w http.ResponseWriter, r *http
 	
 	stakes, err := h.db.GetBountyStakesByBountyID(bountyID)
 	if err != nil {
-		logger.Log.Error("[bounty_stake] failed to get stakes by bounty ID: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty_stake] failed to get stakes by bounty ID: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to retrieve stakes"})
 		return
@@ -2871,7 +2858,7 @@ func (h *bountyHandler) GetBountyStakeByID(w http.ResponseWriter, r *http.Reques
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		logger.Log.Error("[bounty_stake] invalid stake ID: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty_stake] invalid stake ID: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid stake ID"})
 		return
@@ -2879,7 +2866,7 @@ func (h *bountyHandler) GetBountyStakeByID(w http.ResponseWriter, r *http.Reques
 	
 	stake, err := h.db.GetBountyStakeByID(id)
 	if err != nil {
-		logger.Log.Error("[bounty_stake] failed to get stake by ID: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty_stake] failed to get stake by ID: %v", err)
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Stake not found"})
 		return
@@ -2904,7 +2891,7 @@ func (h *bountyHandler) GetBountyStakesByHunterPubKey(w http.ResponseWriter, r *
 	
 	stakes, err := h.db.GetBountyStakesByHunterPubKey(hunterPubKey)
 	if err != nil {
-		logger.Log.Error("[bounty_stake] failed to get stakes by hunter pubkey: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty_stake] failed to get stakes by hunter pubkey: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to retrieve stakes"})
 		return
@@ -2935,7 +2922,7 @@ func (h *bountyHandler) UpdateBountyStake(w http.ResponseWriter, r *http.Request
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	
 	if pubKeyFromAuth == "" {
-		logger.Log.Error("[bounty_stake] no pubkey from auth")
+		logger.FromContext(r.Context()).Error("[bounty_stake] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -2944,7 +2931,7 @@ func (h *bountyHandler) UpdateBountyStake(w http.ResponseWriter, r *http.Request
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		logger.Log.Error("[bounty_stake] invalid stake ID: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty_stake] invalid stake ID: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid stake ID"})
 		return
@@ -2952,7 +2939,7 @@ func (h *bountyHandler) UpdateBountyStake(w http.ResponseWriter, r *http.Request
 	
 	existingStake, err := h.db.GetBountyStakeByID(id)
 	if err != nil {
-		logger.Log.Error("[bounty_stake] stake not found: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty_stake] stake not found: %v", err)
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Stake not found"})
 		return
@@ -2960,7 +2947,7 @@ func (h *bountyHandler) UpdateBountyStake(w http.ResponseWriter, r *http.Request
 	
 	bounty := h.db.GetBounty(existingStake.BountyID)
 	if existingStake.HunterPubKey != pubKeyFromAuth && bounty.OwnerID != pubKeyFromAuth {
-		logger.Log.Error("[bounty_stake] unauthorized update attempt")
+		logger.FromContext(r.Context()).Error("[bounty_stake] unauthorized update attempt")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "You are not authorized to update this stake"})
 		return
@@ -2968,7 +2955,7 @@ func (h *bountyHandler) UpdateBountyStake(w http.ResponseWriter, r *http.Request
 	
 	var updates map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		logger.Log.Error("[bounty_stake] invalid request body: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty_stake] invalid request body: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
 		return
@@ -2976,7 +2963,7 @@ func (h *bountyHandler) UpdateBountyStake(w http.ResponseWriter, r *http.Request
 	
 	updatedStake, err := h.db.UpdateBountyStake(id, updates)
 	if err != nil {
-		logger.Log.Error("[bounty_stake] failed to update stake: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty_stake] failed to update stake: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -3005,7 +2992,7 @@ func (h *bountyHandler) DeleteBountyStake(w http.ResponseWriter, r *http.Request
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	
 	if pubKeyFromAuth == "" {
-		logger.Log.Error("[bounty_stake] no pubkey from auth")
+		logger.FromContext(r.Context()).Error("[bounty_stake] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -3014,7 +3001,7 @@ func (h *bountyHandler) DeleteBountyStake(w http.ResponseWriter, r *http.Request
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		logger.Log.Error("[bounty_stake] invalid stake ID: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty_stake] invalid stake ID: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid stake ID"})
 		return
@@ -3022,7 +3009,7 @@ func (h *bountyHandler) DeleteBountyStake(w http.ResponseWriter, r *http.Request
 	
 	existingStake, err := h.db.GetBountyStakeByID(id)
 	if err != nil {
-		logger.Log.Error("[bounty_stake] stake not found: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty_stake] stake not found: %v", err)
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Stake not found"})
 		return
@@ -3030,7 +3017,7 @@ func (h *bountyHandler) DeleteBountyStake(w http.ResponseWriter, r *http.Request
 	
 	bounty := h.db.GetBounty(existingStake.BountyID)
 	if existingStake.HunterPubKey != pubKeyFromAuth && bounty.OwnerID != pubKeyFromAuth {
-		logger.Log.Error("[bounty_stake] unauthorized delete attempt")
+		logger.FromContext(r.Context()).Error("[bounty_stake] unauthorized delete attempt")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "You are not authorized to delete this stake"})
 		return
@@ -3038,7 +3025,7 @@ func (h *bountyHandler) DeleteBountyStake(w http.ResponseWriter, r *http.Request
 	
 	err = h.db.DeleteBountyStake(id)
 	if err != nil {
-		logger.Log.Error("[bounty_stake] failed to delete stake: %v", err)
+		logger.FromContext(r.Context()).Error("[bounty_stake] failed to delete stake: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
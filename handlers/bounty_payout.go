@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	lnurl "github.com/fiatjaf/go-lnurl"
+	"github.com/go-chi/chi"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+	"github.com/stakwork/sphinx-tribes/utils"
+)
+
+// payoutReconcileAfter is how long a claimed payout is left to its payment
+// before its invoice is checked
+const payoutReconcileAfter = 10 * time.Minute
+
+// CreateBountyPayout godoc
+//
+//	@Summary		Create an LNURL-withdraw payout
+//	@Description	Approve a bounty for payment with a one-time LNURL-withdraw code the assignee can redeem with any wallet. The amount is reserved in the workspace budget and only debited when the code is redeemed.
+//	@Tags			Bounties - Payment
+//	@Produce		json
+//	@Security		PubKeyContextAuth
+//	@Param			id	path		string	true	"Bounty ID"
+//	@Success		201	{object}	db.BountyPayoutResponse
+//	@Router			/gobounties/payout/{id} [post]
+func (h *bountyHandler) CreateBountyPayout(w http.ResponseWriter, r *http.Request) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	pubKeyFromAuth, _ := r.Context().Value(auth.ContextKey).(string)
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[bounty_payout] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	id, err := utils.ConvertStringToUint(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Invalid bounty id")
+		return
+	}
+
+	bounty := h.db.GetBounty(id)
+	if bounty.ID != id {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if bounty.WorkspaceUuid == "" && bounty.OrgUuid != "" {
+		bounty.WorkspaceUuid = bounty.OrgUuid
+	}
+
+	if bounty.Paid {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode("Bounty has already been paid")
+		return
+	}
+	if bounty.PaymentPending {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Bounty payment is pending")
+		return
+	}
+	if bounty.Assignee == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Bounty has no assignee")
+		return
+	}
+
+	if !h.userHasAccess(pubKeyFromAuth, bounty.WorkspaceUuid, db.PayBounty) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("You don't have appropriate permissions to pay bounties")
+		return
+	}
+
+	budget := h.db.GetWorkspaceBudget(bounty.WorkspaceUuid)
+	reserved := h.db.GetWorkspaceReservedPayouts(bounty.WorkspaceUuid)
+	if budget.TotalBudget < bounty.Price+reserved {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode("workspace budget is not enough to pay the amount")
+		return
+	}
+
+	encodeData, err := auth.EncodeLNURLWithdraw(r.Host)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("[bounty_payout] %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	payout := db.BountyPayout{
+		K1:             encodeData.K1,
+		LNURL:          encodeData.Encode,
+		BountyID:       bounty.ID,
+		WorkspaceUuid:  bounty.WorkspaceUuid,
+		SenderPubKey:   pubKeyFromAuth,
+		ReceiverPubKey: bounty.Assignee,
+		Amount:         bounty.Price,
+		ExpiresAt:      now.Add(time.Duration(config.LnurlWithdrawExpiryHours) * time.Hour),
+	}
+	if err := h.db.CreateBountyPayout(&payout); err != nil {
+		logger.FromContext(r.Context()).Error("[bounty_payout] %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("[bounty_payout] %s created payout %s of %d sats for bounty %d", pubKeyFromAuth, payout.ID, payout.Amount, payout.BountyID)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(db.BountyPayoutResponse{BountyPayout: payout, LNURL: payout.LNURL})
+}
+
+// GetBountyPayout godoc
+//
+//	@Summary		Get a bounty's LNURL-withdraw payout
+//	@Description	Get the latest payout of a bounty, the LNURL is included while the payout can still be redeemed
+//	@Tags			Bounties - Payment
+//	@Produce		json
+//	@Security		PubKeyContextAuth
+//	@Param			id	path		string	true	"Bounty ID"
+//	@Success		200	{object}	db.BountyPayoutResponse
+//	@Router			/gobounties/payout/{id} [get]
+func (h *bountyHandler) GetBountyPayout(w http.ResponseWriter, r *http.Request) {
+	pubKeyFromAuth, _ := r.Context().Value(auth.ContextKey).(string)
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[bounty_payout] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	id, err := utils.ConvertStringToUint(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Invalid bounty id")
+		return
+	}
+
+	payout, err := h.db.GetBountyPayout(id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+
+	if payout.ReceiverPubKey != pubKeyFromAuth && !h.userHasAccess(pubKeyFromAuth, payout.WorkspaceUuid, db.PayBounty) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	response := db.BountyPayoutResponse{BountyPayout: payout}
+	if payout.Status == db.PayoutPending && time.Now().Before(payout.ExpiresAt) {
+		response.LNURL = payout.LNURL
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// LnurlWithdraw godoc
+//
+//	@Summary		LNURL-withdraw request
+//	@Description	Called by the hunter's wallet after scanning a payout LNURL, returns the withdrawRequest for the exact bounty amount
+//	@Tags			Bounties - Payment
+//	@Produce		json
+//	@Param			k1	query		string	true	"Payout k1"
+//	@Success		200	{object}	lnurl.LNURLWithdrawResponse
+//	@Router			/lnurl/withdraw [get]
+func (h *bountyHandler) LnurlWithdraw(w http.ResponseWriter, r *http.Request) {
+	payout, err := h.db.GetBountyPayoutByK1(r.URL.Query().Get("k1"))
+	if err != nil {
+		json.NewEncoder(w).Encode(lnurl.ErrorResponse("Unknown withdraw code"))
+		return
+	}
+
+	if payout.Status != db.PayoutPending || !time.Now().Before(payout.ExpiresAt) {
+		json.NewEncoder(w).Encode(lnurl.ErrorResponse("Withdraw code has expired or was already used"))
+		return
+	}
+
+	bounty := h.db.GetBounty(payout.BountyID)
+	msat := int64(payout.Amount) * 1000
+
+	json.NewEncoder(w).Encode(lnurl.LNURLWithdrawResponse{
+		Tag:                "withdrawRequest",
+		K1:                 payout.K1,
+		Callback:           auth.LnurlHostURL(r.Host) + "/lnurl/withdraw/callback",
+		MinWithdrawable:    msat,
+		MaxWithdrawable:    msat,
+		DefaultDescription: fmt.Sprintf("Payment For: %s", bounty.Title),
+	})
+}
+
+// LnurlWithdrawCallback godoc
+//
+//	@Summary		LNURL-withdraw callback
+//	@Description	Called by the hunter's wallet with an invoice for the payout amount. The invoice is paid from the workspace budget and the bounty is marked as paid.
+//	@Tags			Bounties - Payment
+//	@Produce		json
+//	@Param			k1	query		string	true	"Payout k1"
+//	@Param			pr	query		string	true	"Invoice for the payout amount"
+//	@Success		200	{object}	lnurl.LNURLResponse
+//	@Router			/lnurl/withdraw/callback [get]
+func (h *bountyHandler) LnurlWithdrawCallback(w http.ResponseWriter, r *http.Request) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	k1 := r.URL.Query().Get("k1")
+	pr := r.URL.Query().Get("pr")
+
+	payout, err := h.db.GetBountyPayoutByK1(k1)
+	if err != nil {
+		json.NewEncoder(w).Encode(lnurl.ErrorResponse("Unknown withdraw code"))
+		return
+	}
+
+	now := time.Now()
+	if payout.Status != db.PayoutPending || !now.Before(payout.ExpiresAt) {
+		json.NewEncoder(w).Encode(lnurl.ErrorResponse("Withdraw code has expired or was already used"))
+		return
+	}
+
+	if amount := utils.GetInvoiceAmount(pr); amount != payout.Amount {
+		json.NewEncoder(w).Encode(lnurl.ErrorResponse(fmt.Sprintf("Invoice amount must be %d sats", payout.Amount)))
+		return
+	}
+
+	// the reservations include this payout, the budget has to cover all of
+	// them or another payout was already paid from it
+	budget := h.db.GetWorkspaceBudget(payout.WorkspaceUuid)
+	if reserved := h.db.GetWorkspaceReservedPayouts(payout.WorkspaceUuid); budget.TotalBudget < reserved {
+		json.NewEncoder(w).Encode(lnurl.ErrorResponse("Workspace budget is not enough to pay the amount"))
+		return
+	}
+
+	if err := h.db.ClaimBountyPayout(k1, pr, utils.GetInvoicePaymentHash(pr), now); err != nil {
+		json.NewEncoder(w).Encode(lnurl.ErrorResponse("Withdraw code has expired or was already used"))
+		return
+	}
+
+	paySuccess, payError := h.PayLightningInvoice(r.Context(), pr)
+	if !paySuccess.Success && payError.Error == "" {
+		// the backend did not answer whether it paid, the invoice may still
+		// be paid so the payout stays claimed until it is reconciled
+		logger.FromContext(r.Context()).Error("[bounty_payout] payout %s has an unknown payment status", payout.ID)
+		json.NewEncoder(w).Encode(lnurl.ErrorResponse("Payment is pending"))
+		return
+	}
+	if !paySuccess.Success {
+		logger.FromContext(r.Context()).Error("[bounty_payout] payout %s failed: %s", payout.ID, payError.Error)
+		// the backend refused the payment, the code stays redeemable until
+		// it expires
+		if err := h.db.UpdateBountyPayoutStatus(payout.ID, db.PayoutPending, payError.Error); err != nil {
+			logger.FromContext(r.Context()).Error("[bounty_payout] %v", err)
+		}
+		json.NewEncoder(w).Encode(lnurl.ErrorResponse("Payment failed"))
+		return
+	}
+
+	h.completeBountyPayout(r.Context(), payout)
+
+	json.NewEncoder(w).Encode(lnurl.OkResponse())
+}
+
+// completeBountyPayout records a payout whose invoice was paid, the bounty
+// is marked as paid and the amount debited from the workspace budget
+func (h *bountyHandler) completeBountyPayout(ctx context.Context, payout db.BountyPayout) {
+	bounty := h.db.GetBounty(payout.BountyID)
+	paidAt := time.Now()
+	bounty.PaymentFailed = false
+	bounty.PaymentPending = false
+	bounty.Paid = true
+	bounty.PaidDate = &paidAt
+	bounty.Completed = true
+	bounty.CompletionDate = &paidAt
+
+	paymentHistory := db.NewPaymentHistory{
+		Amount:         payout.Amount,
+		SenderPubKey:   payout.SenderPubKey,
+		ReceiverPubKey: payout.ReceiverPubKey,
+		WorkspaceUuid:  payout.WorkspaceUuid,
+		BountyId:       payout.BountyID,
+		Created:        &paidAt,
+		Updated:        &paidAt,
+		Status:         true,
+		PaymentType:    "payment",
+		PaymentStatus:  db.PaymentComplete,
+	}
+	if err := h.db.ProcessBountyPayment(paymentHistory, bounty); err != nil {
+		logger.FromContext(ctx).Error("[bounty_payout] payout %s was paid but recording it failed: %v", payout.ID, err)
+	}
+	if err := h.db.UpdateBountyPayoutStatus(payout.ID, db.PayoutRedeemed, ""); err != nil {
+		logger.FromContext(ctx).Error("[bounty_payout] %v", err)
+	}
+
+	logger.FromContext(ctx).Info("[bounty_payout] payout %s of %d sats for bounty %d redeemed", payout.ID, payout.Amount, payout.BountyID)
+}
+
+// ExpireBountyPayouts expires payouts that were not redeemed in time, their
+// amount is no longer reserved and the bounty can be paid again. Payouts
+// whose payment never reported back are reconciled first.
+func (h *bountyHandler) ExpireBountyPayouts() error {
+	ctx := logger.WithFields(context.Background(), "cron", "bounty_payouts")
+	cronLog := logger.FromContext(ctx)
+	reconcileErr := h.reconcileBountyPayouts(ctx)
+
+	payouts, err := h.db.ExpireBountyPayouts(time.Now())
+	if err != nil {
+		return errors.Join(reconcileErr, err)
+	}
+
+	for _, payout := range payouts {
+		cronLog.Info("[bounty_payout] payout %s of %d sats for bounty %d expired", payout.ID, payout.Amount, payout.BountyID)
+	}
+	return reconcileErr
+}
+
+// reconcileBountyPayouts looks up the outgoing payments of payouts that
+// stayed claimed on our node. A completed payment completes the payout, a
+// failed one or one never sent before the invoice expired makes the payout
+// redeemable again, or expires with the others when its code did.
+func (h *bountyHandler) reconcileBountyPayouts(ctx context.Context) error {
+	cronLog := logger.FromContext(ctx)
+	payouts, err := h.db.GetClaimedBountyPayouts(time.Now().Add(-payoutReconcileAfter))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, payout := range payouts {
+		paymentHash := payout.PaymentHash
+		if paymentHash == "" {
+			paymentHash = utils.GetInvoicePaymentHash(payout.PaymentRequest)
+		}
+
+		status, err := h.GetPaymentStatus(ctx, paymentHash)
+		if err != nil {
+			cronLog.Warning("[bounty_payout] could not look up the payment of payout %s: %v", payout.ID, err)
+			continue
+		}
+
+		switch {
+		case status == db.PaymentComplete:
+			h.completeBountyPayout(ctx, payout)
+		case status == db.PaymentFailed:
+			cronLog.Info("[bounty_payout] payment of payout %s failed", payout.ID)
+			if err := h.db.UpdateBountyPayoutStatus(payout.ID, db.PayoutPending, "payment failed"); err != nil {
+				errs = append(errs, err)
+			}
+		case status == db.PaymentNotFound && utils.GetInvoiceExpired(payout.PaymentRequest):
+			cronLog.Info("[bounty_payout] payout %s was not paid before its invoice expired", payout.ID)
+			if err := h.db.UpdateBountyPayoutStatus(payout.ID, db.PayoutPending, "invoice expired unpaid"); err != nil {
+				errs = append(errs, err)
+			}
+		default:
+			cronLog.Info("[bounty_payout] payout %s is still being paid", payout.ID)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetPaymentStatus looks up an outgoing payment of our node by its payment
+// hash, the status is db.PaymentNotFound when the node never sent it
+func (h *bountyHandler) GetPaymentStatus(ctx context.Context, paymentHash string) (string, error) {
+	if paymentHash == "" {
+		return "", errors.New("payment hash is empty")
+	}
+	if !config.IsV2Payment {
+		return "", errors.New("payment lookup needs the V2 bot")
+	}
+
+	url := fmt.Sprintf("%s/payment/%s", config.V2BotUrl, paymentHash)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("x-admin-token", config.V2BotToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := h.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return db.PaymentNotFound, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("payment lookup returned status %d", res.StatusCode)
+	}
+
+	var payment db.V2TagRes
+	if err := json.NewDecoder(res.Body).Decode(&payment); err != nil {
+		return "", fmt.Errorf("could not read payment status: %w", err)
+	}
+	if payment.Status == "" {
+		return db.PaymentNotFound, nil
+	}
+	return payment.Status, nil
+}
@@ -0,0 +1,450 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	lnurl "github.com/fiatjaf/go-lnurl"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/handlers/mocks"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stakwork/sphinx-tribes/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// payoutInvoice is an invoice for 10000 sats
+const payoutInvoice = "lnbc100u1png0l8ypp5hna5vnd2hcskpf69rt5y9dly2p202lejcacj53md32wx87vc2mnqdqzvscqzpgxqyz5vqrzjqwnw5tv745sjpvft6e3f9w62xqk826vrm3zaev4nvj6xr3n065aukqqqqyqqpmgqqyqqqqqqqqqqqqqqqqsp5cdg0c2qhuewz4j8680pf5va0l9a382qa5sakg4uga4nv4wnuf5qs9qrssqpdddmqtflxz3553gm5xq8ptdpl2t3ew49hgjnta0v0eyz747drkkhmnk5yxg676kvmgyugm35cts9dmrnt9mcgejg64kwk9nwxqg43cqcvxm44"
+
+func newPayoutRequest(method string, target string, bountyID string, pubkey string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", bountyID)
+	ctx := context.WithValue(context.Background(), auth.ContextKey, pubkey)
+	ctx = context.WithValue(ctx, chi.RouteCtxKey, rctx)
+	return httptest.NewRequest(method, target, nil).WithContext(ctx)
+}
+
+func TestCreateBountyPayout(t *testing.T) {
+	config.LnurlWithdrawExpiryHours = 72
+	bounty := db.NewBounty{
+		ID:            1,
+		Price:         10000,
+		Title:         "Fix the bug",
+		Assignee:      "hunter_pubkey",
+		WorkspaceUuid: "workspace_uuid",
+	}
+	hasAccess := func(pubKeyFromAuth string, uuid string, role string) bool {
+		return role == db.PayBounty
+	}
+
+	t.Run("should reserve the amount and return an LNURL", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		bHandler := NewBountyHandler(mocks.NewHttpClient(t), mockDb)
+		bHandler.userHasAccess = hasAccess
+
+		mockDb.On("GetBounty", uint(1)).Return(bounty)
+		mockDb.On("GetWorkspaceBudget", "workspace_uuid").Return(db.NewBountyBudget{TotalBudget: 15000})
+		mockDb.On("GetWorkspaceReservedPayouts", "workspace_uuid").Return(uint(5000))
+		mockDb.On("CreateBountyPayout", mock.MatchedBy(func(payout *db.BountyPayout) bool {
+			return len(payout.K1) == 64 && payout.LNURL != "" && payout.BountyID == 1 &&
+				payout.Amount == 10000 && payout.ReceiverPubKey == "hunter_pubkey" &&
+				payout.SenderPubKey == "owner_pubkey" && payout.ExpiresAt.After(time.Now())
+		})).Return(nil)
+
+		rr := httptest.NewRecorder()
+		bHandler.CreateBountyPayout(rr, newPayoutRequest(http.MethodPost, "/gobounties/payout/1", "1", "owner_pubkey"))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var response db.BountyPayoutResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.NotEmpty(t, response.LNURL)
+		assert.Equal(t, uint(10000), response.Amount)
+	})
+
+	t.Run("should not reserve more than the available budget", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		bHandler := NewBountyHandler(mocks.NewHttpClient(t), mockDb)
+		bHandler.userHasAccess = hasAccess
+
+		mockDb.On("GetBounty", uint(1)).Return(bounty)
+		mockDb.On("GetWorkspaceBudget", "workspace_uuid").Return(db.NewBountyBudget{TotalBudget: 15000})
+		mockDb.On("GetWorkspaceReservedPayouts", "workspace_uuid").Return(uint(5001))
+
+		rr := httptest.NewRecorder()
+		bHandler.CreateBountyPayout(rr, newPayoutRequest(http.MethodPost, "/gobounties/payout/1", "1", "owner_pubkey"))
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("should reject bounties with a pending payment", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		bHandler := NewBountyHandler(mocks.NewHttpClient(t), mockDb)
+		bHandler.userHasAccess = hasAccess
+
+		pending := bounty
+		pending.PaymentPending = true
+		mockDb.On("GetBounty", uint(1)).Return(pending)
+
+		rr := httptest.NewRecorder()
+		bHandler.CreateBountyPayout(rr, newPayoutRequest(http.MethodPost, "/gobounties/payout/1", "1", "owner_pubkey"))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("should require the pay bounty role", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		bHandler := NewBountyHandler(mocks.NewHttpClient(t), mockDb)
+		bHandler.userHasAccess = func(pubKeyFromAuth string, uuid string, role string) bool { return false }
+
+		mockDb.On("GetBounty", uint(1)).Return(bounty)
+
+		rr := httptest.NewRecorder()
+		bHandler.CreateBountyPayout(rr, newPayoutRequest(http.MethodPost, "/gobounties/payout/1", "1", "hunter_pubkey"))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestGetBountyPayout(t *testing.T) {
+	payout := db.BountyPayout{
+		ID:             uuid.New(),
+		LNURL:          "lnurl1dp68gurn8ghj7",
+		BountyID:       1,
+		WorkspaceUuid:  "workspace_uuid",
+		ReceiverPubKey: "hunter_pubkey",
+		Amount:         10000,
+		Status:         db.PayoutPending,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+
+	t.Run("should return the LNURL to the hunter", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		bHandler := NewBountyHandler(mocks.NewHttpClient(t), mockDb)
+
+		mockDb.On("GetBountyPayout", uint(1)).Return(payout, nil)
+
+		rr := httptest.NewRecorder()
+		bHandler.GetBountyPayout(rr, newPayoutRequest(http.MethodGet, "/gobounties/payout/1", "1", "hunter_pubkey"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response db.BountyPayoutResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, payout.LNURL, response.LNURL)
+	})
+
+	t.Run("should hide the LNURL once redeemed", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		bHandler := NewBountyHandler(mocks.NewHttpClient(t), mockDb)
+
+		redeemed := payout
+		redeemed.Status = db.PayoutRedeemed
+		mockDb.On("GetBountyPayout", uint(1)).Return(redeemed, nil)
+
+		rr := httptest.NewRecorder()
+		bHandler.GetBountyPayout(rr, newPayoutRequest(http.MethodGet, "/gobounties/payout/1", "1", "hunter_pubkey"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response db.BountyPayoutResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Empty(t, response.LNURL)
+	})
+
+	t.Run("should not show the payout to others", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		bHandler := NewBountyHandler(mocks.NewHttpClient(t), mockDb)
+		bHandler.userHasAccess = func(pubKeyFromAuth string, uuid string, role string) bool { return false }
+
+		mockDb.On("GetBountyPayout", uint(1)).Return(payout, nil)
+
+		rr := httptest.NewRecorder()
+		bHandler.GetBountyPayout(rr, newPayoutRequest(http.MethodGet, "/gobounties/payout/1", "1", "other_pubkey"))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestLnurlWithdraw(t *testing.T) {
+	payout := db.BountyPayout{
+		ID:            uuid.New(),
+		K1:            "k1",
+		BountyID:      1,
+		WorkspaceUuid: "workspace_uuid",
+		Amount:        10000,
+		Status:        db.PayoutPending,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}
+
+	t.Run("should return a withdraw request for the exact amount", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		bHandler := NewBountyHandler(mocks.NewHttpClient(t), mockDb)
+
+		mockDb.On("GetBountyPayoutByK1", "k1").Return(payout, nil)
+		mockDb.On("GetBounty", uint(1)).Return(db.NewBounty{ID: 1, Title: "Fix the bug"})
+
+		rr := httptest.NewRecorder()
+		bHandler.LnurlWithdraw(rr, httptest.NewRequest(http.MethodGet, "http://example.com/lnurl/withdraw?k1=k1", nil))
+
+		var response lnurl.LNURLWithdrawResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "withdrawRequest", response.Tag)
+		assert.Equal(t, "k1", response.K1)
+		assert.Equal(t, "https://example.com/lnurl/withdraw/callback", response.Callback)
+		assert.Equal(t, int64(10000000), response.MinWithdrawable)
+		assert.Equal(t, int64(10000000), response.MaxWithdrawable)
+	})
+
+	t.Run("should reject expired codes", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		bHandler := NewBountyHandler(mocks.NewHttpClient(t), mockDb)
+
+		expired := payout
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		mockDb.On("GetBountyPayoutByK1", "k1").Return(expired, nil)
+
+		rr := httptest.NewRecorder()
+		bHandler.LnurlWithdraw(rr, httptest.NewRequest(http.MethodGet, "/lnurl/withdraw?k1=k1", nil))
+
+		var response lnurl.LNURLResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "ERROR", response.Status)
+	})
+}
+
+func TestLnurlWithdrawCallback(t *testing.T) {
+	payout := db.BountyPayout{
+		ID:             uuid.New(),
+		K1:             "k1",
+		BountyID:       1,
+		WorkspaceUuid:  "workspace_uuid",
+		SenderPubKey:   "owner_pubkey",
+		ReceiverPubKey: "hunter_pubkey",
+		Amount:         10000,
+		Status:         db.PayoutPending,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+	target := "/lnurl/withdraw/callback?k1=k1&pr=" + payoutInvoice
+
+	decodeStatus := func(t *testing.T, rr *httptest.ResponseRecorder) lnurl.LNURLResponse {
+		var response lnurl.LNURLResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("should pay the invoice and debit the budget", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockHttpClient := mocks.NewHttpClient(t)
+		bHandler := NewBountyHandler(mockHttpClient, mockDb)
+
+		mockDb.On("GetBountyPayoutByK1", "k1").Return(payout, nil)
+		mockDb.On("GetWorkspaceBudget", "workspace_uuid").Return(db.NewBountyBudget{TotalBudget: 10000})
+		mockDb.On("GetWorkspaceReservedPayouts", "workspace_uuid").Return(uint(10000))
+		mockDb.On("ClaimBountyPayout", "k1", payoutInvoice, utils.GetInvoicePaymentHash(payoutInvoice), mock.Anything).Return(nil)
+		mockHttpClient.On("Do", mock.Anything).Return(&http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"success": true, "response": {"settled": true}}`))),
+		}, nil)
+		mockDb.On("GetBounty", uint(1)).Return(db.NewBounty{ID: 1, Created: 1234})
+		mockDb.On("ProcessBountyPayment", mock.MatchedBy(func(payment db.NewPaymentHistory) bool {
+			return payment.Amount == 10000 && payment.ReceiverPubKey == "hunter_pubkey" && payment.PaymentStatus == db.PaymentComplete
+		}), mock.MatchedBy(func(bounty db.NewBounty) bool {
+			return bounty.Paid && !bounty.PaymentPending
+		})).Return(nil)
+		mockDb.On("UpdateBountyPayoutStatus", payout.ID, db.PayoutRedeemed, "").Return(nil)
+
+		rr := httptest.NewRecorder()
+		bHandler.LnurlWithdrawCallback(rr, httptest.NewRequest(http.MethodGet, target, nil))
+
+		assert.Equal(t, "OK", decodeStatus(t, rr).Status)
+	})
+
+	t.Run("should keep the code redeemable when the payment fails", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockHttpClient := mocks.NewHttpClient(t)
+		bHandler := NewBountyHandler(mockHttpClient, mockDb)
+
+		mockDb.On("GetBountyPayoutByK1", "k1").Return(payout, nil)
+		mockDb.On("GetWorkspaceBudget", "workspace_uuid").Return(db.NewBountyBudget{TotalBudget: 10000})
+		mockDb.On("GetWorkspaceReservedPayouts", "workspace_uuid").Return(uint(10000))
+		mockDb.On("ClaimBountyPayout", "k1", payoutInvoice, utils.GetInvoicePaymentHash(payoutInvoice), mock.Anything).Return(nil)
+		mockHttpClient.On("Do", mock.Anything).Return(&http.Response{
+			StatusCode: 400,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"success": false, "error": "no route"}`))),
+		}, nil)
+		mockDb.On("UpdateBountyPayoutStatus", payout.ID, db.PayoutPending, "no route").Return(nil)
+
+		rr := httptest.NewRecorder()
+		bHandler.LnurlWithdrawCallback(rr, httptest.NewRequest(http.MethodGet, target, nil))
+
+		assert.Equal(t, "ERROR", decodeStatus(t, rr).Status)
+	})
+
+	t.Run("should keep the code claimed when the payment status is unknown", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockHttpClient := mocks.NewHttpClient(t)
+		bHandler := NewBountyHandler(mockHttpClient, mockDb)
+
+		mockDb.On("GetBountyPayoutByK1", "k1").Return(payout, nil)
+		mockDb.On("GetWorkspaceBudget", "workspace_uuid").Return(db.NewBountyBudget{TotalBudget: 10000})
+		mockDb.On("GetWorkspaceReservedPayouts", "workspace_uuid").Return(uint(10000))
+		mockDb.On("ClaimBountyPayout", "k1", payoutInvoice, utils.GetInvoicePaymentHash(payoutInvoice), mock.Anything).Return(nil)
+		mockHttpClient.On("Do", mock.Anything).Return(nil, errors.New("timeout"))
+
+		rr := httptest.NewRecorder()
+		bHandler.LnurlWithdrawCallback(rr, httptest.NewRequest(http.MethodGet, target, nil))
+
+		assert.Equal(t, "ERROR", decodeStatus(t, rr).Status)
+		mockDb.AssertNotCalled(t, "UpdateBountyPayoutStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should not pay from a budget reserved for other payouts", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		bHandler := NewBountyHandler(mocks.NewHttpClient(t), mockDb)
+
+		mockDb.On("GetBountyPayoutByK1", "k1").Return(payout, nil)
+		mockDb.On("GetWorkspaceBudget", "workspace_uuid").Return(db.NewBountyBudget{TotalBudget: 15000})
+		mockDb.On("GetWorkspaceReservedPayouts", "workspace_uuid").Return(uint(20000))
+
+		rr := httptest.NewRecorder()
+		bHandler.LnurlWithdrawCallback(rr, httptest.NewRequest(http.MethodGet, target, nil))
+
+		assert.Equal(t, "ERROR", decodeStatus(t, rr).Status)
+		mockDb.AssertNotCalled(t, "ClaimBountyPayout", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject invoices for another amount", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		bHandler := NewBountyHandler(mocks.NewHttpClient(t), mockDb)
+
+		other := payout
+		other.Amount = 500
+		mockDb.On("GetBountyPayoutByK1", "k1").Return(other, nil)
+
+		rr := httptest.NewRecorder()
+		bHandler.LnurlWithdrawCallback(rr, httptest.NewRequest(http.MethodGet, target, nil))
+
+		assert.Equal(t, "ERROR", decodeStatus(t, rr).Status)
+	})
+
+	t.Run("should not pay a code that is already claimed", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		bHandler := NewBountyHandler(mocks.NewHttpClient(t), mockDb)
+
+		claimed := payout
+		claimed.Status = db.PayoutRedeeming
+		mockDb.On("GetBountyPayoutByK1", "k1").Return(claimed, nil)
+
+		rr := httptest.NewRecorder()
+		bHandler.LnurlWithdrawCallback(rr, httptest.NewRequest(http.MethodGet, target, nil))
+
+		assert.Equal(t, "ERROR", decodeStatus(t, rr).Status)
+	})
+}
+
+func TestExpireBountyPayouts(t *testing.T) {
+	config.IsV2Payment = true
+	defer func() { config.IsV2Payment = false }()
+
+	claimed := db.BountyPayout{
+		ID:             uuid.New(),
+		BountyID:       1,
+		WorkspaceUuid:  "workspace_uuid",
+		ReceiverPubKey: "hunter_pubkey",
+		Amount:         10000,
+		Status:         db.PayoutRedeeming,
+		PaymentRequest: payoutInvoice,
+		PaymentHash:    "payment_hash",
+	}
+	paymentStatus := func(statusCode int, body string) *http.Response {
+		return &http.Response{StatusCode: statusCode, Body: io.NopCloser(bytes.NewReader([]byte(body)))}
+	}
+	lookupOf := func(hash string) interface{} {
+		return mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/payment/"+hash)
+		})
+	}
+
+	t.Run("should complete claimed payouts whose payment completed", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockHttpClient := mocks.NewHttpClient(t)
+		bHandler := NewBountyHandler(mockHttpClient, mockDb)
+
+		mockDb.On("GetClaimedBountyPayouts", mock.Anything).Return([]db.BountyPayout{claimed}, nil)
+		mockHttpClient.On("Do", lookupOf("payment_hash")).Return(paymentStatus(200, `{"status": "COMPLETE"}`), nil)
+		mockDb.On("GetBounty", uint(1)).Return(db.NewBounty{ID: 1})
+		mockDb.On("ProcessBountyPayment", mock.Anything, mock.MatchedBy(func(bounty db.NewBounty) bool {
+			return bounty.Paid
+		})).Return(nil)
+		mockDb.On("UpdateBountyPayoutStatus", claimed.ID, db.PayoutRedeemed, "").Return(nil)
+		mockDb.On("ExpireBountyPayouts", mock.Anything).Return([]db.BountyPayout{}, nil)
+
+		assert.NoError(t, bHandler.ExpireBountyPayouts())
+	})
+
+	t.Run("should release claimed payouts whose payment failed", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockHttpClient := mocks.NewHttpClient(t)
+		bHandler := NewBountyHandler(mockHttpClient, mockDb)
+
+		mockDb.On("GetClaimedBountyPayouts", mock.Anything).Return([]db.BountyPayout{claimed}, nil)
+		mockHttpClient.On("Do", lookupOf("payment_hash")).Return(paymentStatus(200, `{"status": "FAILED"}`), nil)
+		mockDb.On("UpdateBountyPayoutStatus", claimed.ID, db.PayoutPending, "payment failed").Return(nil)
+		mockDb.On("ExpireBountyPayouts", mock.Anything).Return([]db.BountyPayout{}, nil)
+
+		assert.NoError(t, bHandler.ExpireBountyPayouts())
+	})
+
+	t.Run("should release claimed payouts never sent before their invoice expired", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockHttpClient := mocks.NewHttpClient(t)
+		bHandler := NewBountyHandler(mockHttpClient, mockDb)
+
+		mockDb.On("GetClaimedBountyPayouts", mock.Anything).Return([]db.BountyPayout{claimed}, nil)
+		mockHttpClient.On("Do", lookupOf("payment_hash")).Return(paymentStatus(404, `{}`), nil)
+		mockDb.On("UpdateBountyPayoutStatus", claimed.ID, db.PayoutPending, "invoice expired unpaid").Return(nil)
+		mockDb.On("ExpireBountyPayouts", mock.Anything).Return([]db.BountyPayout{}, nil)
+
+		assert.NoError(t, bHandler.ExpireBountyPayouts())
+	})
+
+	t.Run("should look up payouts claimed without a hash by the hash of their invoice", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockHttpClient := mocks.NewHttpClient(t)
+		bHandler := NewBountyHandler(mockHttpClient, mockDb)
+
+		withoutHash := claimed
+		withoutHash.PaymentHash = ""
+		mockDb.On("GetClaimedBountyPayouts", mock.Anything).Return([]db.BountyPayout{withoutHash}, nil)
+		mockHttpClient.On("Do", lookupOf(utils.GetInvoicePaymentHash(payoutInvoice))).Return(paymentStatus(200, `{"status": "PENDING"}`), nil)
+		mockDb.On("ExpireBountyPayouts", mock.Anything).Return([]db.BountyPayout{}, nil)
+
+		assert.NoError(t, bHandler.ExpireBountyPayouts())
+
+		mockDb.AssertNotCalled(t, "UpdateBountyPayoutStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should leave claimed payouts whose status cannot be checked", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockHttpClient := mocks.NewHttpClient(t)
+		bHandler := NewBountyHandler(mockHttpClient, mockDb)
+
+		mockDb.On("GetClaimedBountyPayouts", mock.Anything).Return([]db.BountyPayout{claimed}, nil)
+		mockHttpClient.On("Do", mock.Anything).Return(nil, errors.New("timeout"))
+		mockDb.On("ExpireBountyPayouts", mock.Anything).Return([]db.BountyPayout{}, nil)
+
+		assert.NoError(t, bHandler.ExpireBountyPayouts())
+
+		mockDb.AssertNotCalled(t, "UpdateBountyPayoutStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
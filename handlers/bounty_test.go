@@ -19,7 +19,6 @@ import (
 	"testing"
 	"time"
 
-	"github.com/gorilla/websocket"
 	"github.com/stakwork/sphinx-tribes/logger"
 	"github.com/stakwork/sphinx-tribes/utils"
 
@@ -375,7 +374,7 @@ func TestPayLightningInvoice(t *testing.T) {
 			})).Return(nil, errors.New("some-error")).Once()
 		}
 
-		success, invoicePayErr := handler.PayLightningInvoice("req-id")
+		success, invoicePayErr := handler.PayLightningInvoice(context.Background(), "req-id")
 
 		assert.Empty(t, invoicePayErr)
 		assert.Empty(t, success)
@@ -406,7 +405,7 @@ func TestPayLightningInvoice(t *testing.T) {
 			}, nil)
 		}
 
-		success, invoicePayErr := handler.PayLightningInvoice("req-id")
+		success, invoicePayErr := handler.PayLightningInvoice(context.Background(), "req-id")
 
 		assert.False(t, invoicePayErr.Success)
 		assert.Empty(t, success)
@@ -437,7 +436,7 @@ func TestPayLightningInvoice(t *testing.T) {
 				Body:       r,
 			}, nil).Once()
 		}
-		success, invoicePayErr := handler.PayLightningInvoice("req-id")
+		success, invoicePayErr := handler.PayLightningInvoice(context.Background(), "req-id")
 
 		assert.Equal(t, invoicePayErr.Error, "internal server error")
 		assert.Empty(t, success)
@@ -468,7 +467,7 @@ func TestPayLightningInvoice(t *testing.T) {
 			}, nil).Once()
 		}
 
-		success, invoicePayErr := handler.PayLightningInvoice("req-id")
+		success, invoicePayErr := handler.PayLightningInvoice(context.Background(), "req-id")
 
 		assert.False(t, success.Success)
 		assert.Empty(t, invoicePayErr)
@@ -515,7 +514,7 @@ func TestPayLightningInvoice(t *testing.T) {
 				Body:       rv3,
 			}, nil)
 
-			success, invoicePayErr := handler.PayLightningInvoice("req-id")
+			success, invoicePayErr := handler.PayLightningInvoice(context.Background(), "req-id")
 
 			assert.Empty(t, invoicePayErr)
 			assert.EqualValues(t, expectedV2SuccessMsg, success)
@@ -529,7 +528,7 @@ func TestPayLightningInvoice(t *testing.T) {
 				Body:       r,
 			}, nil).Once()
 
-			success, invoicePayErr := handler.PayLightningInvoice("req")
+			success, invoicePayErr := handler.PayLightningInvoice(context.Background(), "req")
 
 			assert.Empty(t, invoicePayErr)
 			assert.EqualValues(t, expectedSuccessMsg, success)
@@ -1245,29 +1244,6 @@ func TestGetAllBounties(t *testing.T) {
 	})
 }
 
-func MockNewWSServer(t *testing.T) (*httptest.Server, *websocket.Conn) {
-
-	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var upgrader = websocket.Upgrader{}
-
-		upgrader.CheckOrigin = func(r *http.Request) bool { return true }
-		ws, err := upgrader.Upgrade(w, r, nil)
-		if err != nil {
-			logger.Log.Error("upgrade error: %v", err)
-			return
-		}
-		defer ws.Close()
-	}))
-	wsURL := "ws" + strings.TrimPrefix(s.URL, "http")
-
-	ws, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	return s, ws
-}
-
 func TestMakeBountyPayment(t *testing.T) {
 	ctx := context.Background()
 
@@ -1281,17 +1257,10 @@ func TestMakeBountyPayment(t *testing.T) {
 	mockUserHasAccessFalse := func(pubKeyFromAuth string, uuid string, role string) bool {
 		return false
 	}
-	mockGetSocketConnections := func(host string) (db.Client, error) {
-		s, ws := MockNewWSServer(t)
-		defer s.Close()
-		defer ws.Close()
-
-		mockClient := db.Client{
-			Host: "mocked_host",
-			Conn: ws,
-		}
-
-		return mockClient, nil
+	var socketMessages []interface{}
+	mockSendSocketMessage := func(host string, message interface{}) error {
+		socketMessages = append(socketMessages, message)
+		return nil
 	}
 	bHandler := NewBountyHandler(mockHttpClient, db.TestDB)
 
@@ -1428,7 +1397,7 @@ func TestMakeBountyPayment(t *testing.T) {
 		mockHttpClient := &mocks.HttpClient{}
 
 		bHandler2 := NewBountyHandler(mockHttpClient, db.TestDB)
-		bHandler2.getSocketConnections = mockGetSocketConnections
+		bHandler2.sendSocketMessage = mockSendSocketMessage
 		bHandler2.userHasAccess = mockUserHasAccessTrue
 
 		memoData := fmt.Sprintf("Payment For: %ss", bounty.Title)
@@ -1477,7 +1446,8 @@ func TestMakeBountyPayment(t *testing.T) {
 
 	t.Run("Should test that a successful WebSocket message is sent if the payment is successful", func(t *testing.T) {
 
-		bHandler.getSocketConnections = mockGetSocketConnections
+		socketMessages = nil
+		bHandler.sendSocketMessage = mockSendSocketMessage
 		bHandler.userHasAccess = mockUserHasAccessTrue
 
 		memoData := fmt.Sprintf("Payment For: %ss", bounty.Title)
@@ -1527,6 +1497,7 @@ func TestMakeBountyPayment(t *testing.T) {
 
 		updatedBounty := db.TestDB.GetBounty(bountyId)
 		assert.True(t, updatedBounty.Paid, "Expected bounty to be marked as paid")
+		assert.NotEmpty(t, socketMessages, "Expected a websocket message for the payment")
 
 		updatedWorkspaceBudget := db.TestDB.GetWorkspaceBudget(bounty.WorkspaceUuid)
 		assert.Equal(t, budgetAmount-bountyAmount, updatedWorkspaceBudget.TotalBudget, "Expected workspace budget to be reduced by bounty amount")
@@ -1592,7 +1563,7 @@ func TestUpdateBountyPaymentStatus(t *testing.T) {
 
 	paymentTag := "update_tag"
 
-	mockPendingGetInvoiceStatusByTag := func(tag string) db.V2TagRes {
+	mockPendingGetInvoiceStatusByTag := func(ctx context.Context, tag string) db.V2TagRes {
 		return db.V2TagRes{
 			Status: db.PaymentPending,
 			Tag:    paymentTag,
@@ -1600,7 +1571,7 @@ func TestUpdateBountyPaymentStatus(t *testing.T) {
 		}
 
 	}
-	mockCompleteGetInvoiceStatusByTag := func(tag string) db.V2TagRes {
+	mockCompleteGetInvoiceStatusByTag := func(ctx context.Context, tag string) db.V2TagRes {
 		return db.V2TagRes{
 			Status: db.PaymentComplete,
 			Tag:    paymentTag,
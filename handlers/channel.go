@@ -43,13 +43,13 @@ func (ch *channelHandler) DeleteChannel(w http.ResponseWriter, r *http.Request)
 	idString := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(idString)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
 	if id == 0 {
-		logger.Log.Info("id is 0")
+		logger.FromContext(r.Context()).Info("id is 0")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -57,12 +57,12 @@ func (ch *channelHandler) DeleteChannel(w http.ResponseWriter, r *http.Request)
 	existing := ch.db.GetChannel(uint(id))
 	existingTribe := ch.db.GetTribe(existing.TribeUUID)
 	if existing.ID == 0 {
-		logger.Log.Info("existing id is 0")
+		logger.FromContext(r.Context()).Info("existing id is 0")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 	if existingTribe.OwnerPubKey != pubKeyFromAuth {
-		logger.Log.Info("keys dont match")
+		logger.FromContext(r.Context()).Info("keys dont match")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -98,7 +98,7 @@ func (ch *channelHandler) CreateChannel(w http.ResponseWriter, r *http.Request)
 	r.Body.Close()
 	err = json.Unmarshal(body, &channel)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -106,7 +106,7 @@ func (ch *channelHandler) CreateChannel(w http.ResponseWriter, r *http.Request)
 	//check that the tribe has the same pubKeyFromAuth
 	tribe := ch.db.GetTribe(channel.TribeUUID)
 	if tribe.OwnerPubKey != pubKeyFromAuth {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -114,7 +114,7 @@ func (ch *channelHandler) CreateChannel(w http.ResponseWriter, r *http.Request)
 	tribeChannels := ch.db.GetChannelsByTribe(channel.TribeUUID)
 	for _, tribeChannel := range tribeChannels {
 		if tribeChannel.Name == channel.Name {
-			logger.Log.Info("Channel name already in use")
+			logger.FromContext(r.Context()).Info("Channel name already in use")
 			w.WriteHeader(http.StatusNotAcceptable)
 			return
 
@@ -123,7 +123,7 @@ func (ch *channelHandler) CreateChannel(w http.ResponseWriter, r *http.Request)
 
 	channel, err = ch.db.CreateChannel(channel)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -2,13 +2,13 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"math"
 	"net/http"
 	"os"
@@ -28,12 +28,15 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/stakwork/sphinx-tribes/db"
 	"github.com/stakwork/sphinx-tribes/sse"
+	"github.com/stakwork/sphinx-tribes/webhooks"
 )
 
 // ChatHandler handles chat-related requests
 type ChatHandler struct {
-	httpClient *http.Client
-	db         db.Database
+	httpClient     *http.Client
+	db             db.Database
+	backends       map[string]ChatBackend
+	startSSEClient func(ctx context.Context, database db.Database, artifact ChatMessageArtifact, chatID string)
 }
 
 // ChatResponse is the response format for chat requests
@@ -87,6 +90,9 @@ type SendMessageRequest struct {
 	SourceWebsocketID string `json:"sourceWebsocketId"`
 	WorkspaceUUID     string `json:"workspaceUUID"`
 	Mode              string `json:"mode,omitempty"`
+	// EditMessageID is the user message this one replaces, the edited
+	// message starts a new branch from the point the replaced one was sent
+	EditMessageID string `json:"editMessageId,omitempty"`
 }
 
 type BuildMessageRequest struct {
@@ -94,13 +100,36 @@ type BuildMessageRequest struct {
 }
 
 type ChatResponseRequest struct {
-	Value struct {
-		ChatID            string                `json:"chatId"`
-		MessageID         string                `json:"messageId"`
-		Response          string                `json:"response"`
-		SourceWebsocketID string                `json:"sourceWebsocketId"`
-		Artifacts         []ChatMessageArtifact `json:"artifacts,omitempty"`
-	} `json:"value"`
+	Value ChatResponseValue `json:"value"`
+}
+
+type ChatResponseValue struct {
+	ChatID            string                `json:"chatId"`
+	MessageID         string                `json:"messageId"`
+	Response          string                `json:"response"`
+	SourceWebsocketID string                `json:"sourceWebsocketId"`
+	Artifacts         []ChatMessageArtifact `json:"artifacts,omitempty"`
+}
+
+// ChatResponseChunkRequest is one part of an assistant reply streamed by
+// the workflow, chunks are numbered from 0 and the last one has done set
+type ChatResponseChunkRequest struct {
+	ChatID            string                `json:"chatId"`
+	MessageID         string                `json:"messageId"`
+	Index             int                   `json:"index"`
+	Chunk             string                `json:"chunk"`
+	Done              bool                  `json:"done"`
+	Response          string                `json:"response,omitempty"`
+	SourceWebsocketID string                `json:"sourceWebsocketId"`
+	Artifacts         []ChatMessageArtifact `json:"artifacts,omitempty"`
+}
+
+type ChatMessageChunkEvent struct {
+	ChatID    string `json:"chatId"`
+	MessageID string `json:"messageId"`
+	ParentID  string `json:"parentId"`
+	Index     int    `json:"index"`
+	Chunk     string `json:"chunk"`
 }
 
 type ChatMessageArtifact struct {
@@ -151,6 +180,7 @@ type ChatWorkflowRequest struct {
 	WorkspaceID string `json:"workspaceId"`
 	URL         string `json:"url"`
 	StackworkID string `json:"stackworkId,omitempty"`
+	Backend     string `json:"backend,omitempty"`
 }
 
 type ChatWorkflowResponse struct {
@@ -190,6 +220,12 @@ type SSEMaintenanceResponse struct {
 	LogsRemoved    int64  `json:"logs_removed"`
 }
 
+type SSEClientsResponse struct {
+	Instance      string               `json:"instance"`
+	Clients       []sse.ClientStatus   `json:"clients"`
+	Subscriptions []db.SSESubscription `json:"subscriptions"`
+}
+
 type WebhookPayload struct {
 	ProjectStatus string `json:"project_status"`
 	Error         *struct {
@@ -204,8 +240,10 @@ type ChatStatusWebhookResponse struct {
 
 func NewChatHandler(httpClient *http.Client, database db.Database) *ChatHandler {
 	return &ChatHandler{
-		httpClient: httpClient,
-		db:         database,
+		httpClient:     httpClient,
+		db:             database,
+		backends:       NewChatBackends(httpClient),
+		startSSEClient: HandleSSEConnectionArtifact,
 	}
 }
 
@@ -416,6 +454,7 @@ func buildVarsPayload(request SendMessageRequest, createdMessage *db.ChatMessage
 		"contextTags":       context,
 		"sourceWebsocketId": request.SourceWebsocketID,
 		"webhook_url":       fmt.Sprintf("%s/hivechat/response", os.Getenv("HOST")),
+		"stream_url":        fmt.Sprintf("%s/hivechat/response/stream", os.Getenv("HOST")),
 		"alias":             user.OwnerAlias,
 		"pdf_url":           request.PDFURL,
 		"modelSelection":    request.ModelSelection,
@@ -465,7 +504,7 @@ func (ch *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -473,7 +512,7 @@ func (ch *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 	user := ch.db.GetPersonByPubkey(pubKeyFromAuth)
 
 	if user.OwnerPubKey != pubKeyFromAuth {
-		logger.Log.Info("Person not exists")
+		logger.FromContext(r.Context()).Info("Person not exists")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -507,7 +546,15 @@ func (ch *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	history, err := ch.db.GetChatMessagesForChatID(request.ChatID)
+	history, err := ch.db.GetChatBranch(request.ChatID, request.EditMessageID)
+	if errors.Is(err, db.ErrChatMessageNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ChatResponse{
+			Success: false,
+			Message: "Edited message not found in chat",
+		})
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ChatResponse{
@@ -517,6 +564,22 @@ func (ch *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the edited message is replaced, the history is what preceded it
+	parentID := ""
+	if request.EditMessageID != "" {
+		edited := history[len(history)-1]
+		if edited.ID != request.EditMessageID || edited.Role != db.UserRole {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ChatResponse{
+				Success: false,
+				Message: "Only messages sent by the user can be edited",
+			})
+			return
+		}
+		history = history[:len(history)-1]
+		parentID = edited.ParentID
+	}
+
 	start := 0
 	if len(history) > 20 {
 		start = len(history) - 20
@@ -550,9 +613,15 @@ func (ch *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		Timestamp: time.Now(),
 		Status:    "sending",
 		Source:    "user",
+		ParentID:  parentID,
 	}
 
-	createdMessage, err := ch.db.AddChatMessage(message)
+	var createdMessage db.ChatMessage
+	if request.EditMessageID != "" {
+		createdMessage, err = ch.db.AddChatMessageBranch(message)
+	} else {
+		createdMessage, err = ch.db.AddChatMessage(message)
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ChatResponse{
@@ -585,41 +654,26 @@ func (ch *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 
 	vars := buildVarsPayload(request, &createdMessage, messageHistory, context, &user, codeGraph, codeSpace, mode)
 
-	stakworkPayload := StakworkChatPayload{
-		Name:       "Hive Chat Processor",
-		WorkflowID: 38842,
-		WorkflowParams: map[string]interface{}{
-			"set_var": map[string]interface{}{
-				"attributes": map[string]interface{}{
-					"vars": vars,
-				},
-			},
-		},
-		WebhookURL: fmt.Sprintf("https://community.sphinx.chat/hivechat/%s/update", request.ChatID),
-	}
-
-	if mode == "Build" {
-		stakworkPayload.Name = "hive_autogen"
-		stakworkPayload.WorkflowID = 43859
-	}
-
-	apiKeyEnv := "SWWFKEY"
-	if mode == "Build" {
-		//apiKeyEnv = "SWWFSWKEY"
-		apiKeyEnv = "SWPR"
-	}
-
-	apiKey := os.Getenv(apiKeyEnv)
-	if apiKey == "" {
+	backend, err := ch.chatBackend(request.WorkspaceUUID)
+	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ChatResponse{
 			Success: false,
-			Message: "environment variable is not set",
+			Message: err.Error(),
 		})
 		return
 	}
 
-	projectID, err := ch.sendToStakwork(stakworkPayload, apiKey)
+	result, err := backend.Send(r.Context(), ChatBackendRequest{
+		ChatID:            request.ChatID,
+		MessageID:         createdMessage.ID,
+		Message:           request.Message,
+		Mode:              mode,
+		WorkspaceUUID:     request.WorkspaceUUID,
+		SourceWebsocketID: request.SourceWebsocketID,
+		History:           messageHistory,
+		Vars:              vars,
+	})
 	if err != nil {
 		createdMessage.Status = "error"
 		ch.db.UpdateChatMessage(&createdMessage)
@@ -632,17 +686,21 @@ func (ch *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	projectMsg := websocket.TicketMessage{
-		BroadcastType:   "direct",
-		SourceSessionID: request.SourceWebsocketID,
-		Message:         fmt.Sprintf("https://jobs.stakwork.com/admin/projects/%d", projectID),
-		Action:          "swrun",
-	}
+	if result.RunURL != "" {
+		projectMsg := websocket.TicketMessage{
+			BroadcastType:   "direct",
+			SourceSessionID: request.SourceWebsocketID,
+			Message:         result.RunURL,
+			Action:          "swrun",
+		}
 
-	if err := websocket.WebsocketPool.SendTicketMessage(projectMsg); err != nil {
-		log.Printf("Failed to send Stakwork project WebSocket message: %v", err)
+		if err := websocket.WebsocketPool.SendTicketMessage(projectMsg); err != nil {
+			logger.FromContext(r.Context()).Error("Failed to send Stakwork project WebSocket message: %v", err)
+		}
 	}
 
+	publishEvent(r.Context(), websocket.ChatTopic(request.ChatID), "chat_message", createdMessage)
+
 	wsMessage := websocket.TicketMessage{
 		BroadcastType:   "direct",
 		SourceSessionID: request.SourceWebsocketID,
@@ -652,7 +710,13 @@ func (ch *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := websocket.WebsocketPool.SendTicketMessage(wsMessage); err != nil {
-		log.Printf("Failed to send websocket message: %v", err)
+		logger.FromContext(r.Context()).Error("Failed to send websocket message: %v", err)
+	}
+
+	if result.Reply != nil {
+		if err := ch.deliverReply(r.Context(), request.ChatID, createdMessage.ID, request.SourceWebsocketID, result.Reply); err != nil {
+			logger.FromContext(r.Context()).Error("[chat_backend] %s could not reply to message %s: %v", backend.Name(), createdMessage.ID, err)
+		}
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -663,44 +727,6 @@ func (ch *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (ch *ChatHandler) sendToStakwork(payload StakworkChatPayload, apiKey string) (int64, error) {
-
-	payloadJSON, err := json.Marshal(payload)
-	if err != nil {
-		return 0, fmt.Errorf("error marshaling payload: %v", err)
-	}
-
-	req, err := http.NewRequest(
-		http.MethodPost,
-		"https://api.stakwork.com/api/v1/projects",
-		bytes.NewBuffer(payloadJSON),
-	)
-	if err != nil {
-		return 0, fmt.Errorf("error creating request: %v", err)
-	}
-
-	req.Header.Set("Authorization", "Token token="+apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := ch.httpClient.Do(req)
-	if err != nil {
-		return 0, fmt.Errorf("error sending request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("stakwork API error: %s", string(body))
-	}
-
-	var stakworkResp StakworkResponse
-	if err := json.NewDecoder(resp.Body).Decode(&stakworkResp); err != nil {
-		return 0, fmt.Errorf("error decoding response: %v", err)
-	}
-
-	return stakworkResp.Data.ProjectID, nil
-}
-
 // GetChat retrieves chats for a workspace
 //
 //	@Summary		Retrieve chats for a workspace
@@ -747,11 +773,12 @@ func (ch *ChatHandler) GetChat(w http.ResponseWriter, r *http.Request) {
 // GetChatHistory retrieves the history of a chat
 //
 //	@Summary		Retrieve chat history
-//	@Description	Retrieve the history of a chat with the given ID
+//	@Description	Retrieve the messages of the active branch of a chat with the given ID, or of every branch with all set
 //	@Tags			Hive Chat
 //	@Produce		json
 //	@Security		PubKeyContextAuth
 //	@Param			uuid	path		string	true	"Chat ID"
+//	@Param			all		query		bool	false	"Return the messages of every branch"
 //	@Success		200		{object}	HistoryChatResponse
 //	@Failure		400		{object}	ChatResponse
 //	@Failure		500		{object}	ChatResponse
@@ -767,7 +794,13 @@ func (ch *ChatHandler) GetChatHistory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	messages, err := ch.db.GetChatMessagesForChatID(chatID)
+	var messages []db.ChatMessage
+	var err error
+	if r.URL.Query().Get("all") == "true" {
+		messages, err = ch.db.GetChatMessagesForChatID(chatID)
+	} else {
+		messages, err = ch.db.GetChatBranch(chatID, "")
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ChatResponse{
@@ -808,108 +841,301 @@ func (ch *ChatHandler) ProcessChatResponse(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if request.Value.ChatID == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ChatResponse{
+	status, response := ch.processResponse(r.Context(), request.Value)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// processResponse saves a complete assistant reply, whether it was posted
+// by the workflow or answered by the chat backend itself
+func (ch *ChatHandler) processResponse(ctx context.Context, value ChatResponseValue) (int, ChatResponse) {
+	if value.ChatID == "" {
+		return http.StatusBadRequest, ChatResponse{
 			Success: false,
 			Message: "ChatID is required for message creation",
-		})
-		return
+		}
 	}
 
-	existingMessages, err := ch.db.GetChatMessagesForChatID(request.Value.ChatID)
+	// the whole response of a reply that was streamed completes it
+	if value.MessageID != "" {
+		if reply, err := ch.db.GetChatMessageStream(value.ChatID, value.MessageID); err == nil && reply.Status == db.SendingStatus {
+			return ch.finishStreamedReply(ctx, reply, value.Response, value.Artifacts, value.SourceWebsocketID)
+		}
+	}
+
+	existingMessages, err := ch.db.GetChatMessagesForChatID(value.ChatID)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ChatResponse{
+		return http.StatusInternalServerError, ChatResponse{
 			Success: false,
 			Message: fmt.Sprintf("Failed to check existing messages: %v", err),
-		})
-		return
+		}
 	}
 
+	parentID := value.MessageID
 	for _, msg := range existingMessages {
 		if msg.Role == "assistant" &&
-			msg.Message == request.Value.Response &&
+			msg.Message == value.Response &&
 			time.Since(msg.Timestamp) < 5*time.Second {
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(ChatResponse{
+			return http.StatusOK, ChatResponse{
 				Success: true,
 				Message: "Similar message already processed recently",
-			})
-			return
+			}
+		}
+		// an action webhook answers with the assistant message offering
+		// the action, its reply follows the option picked on the branch
+		if msg.ID == value.MessageID && msg.Role == db.AssistantRole {
+			parentID = ""
 		}
 	}
 
 	message := &db.ChatMessage{
 		ID:        xid.New().String(),
-		ChatID:    request.Value.ChatID,
-		Message:   request.Value.Response,
+		ChatID:    value.ChatID,
+		Message:   value.Response,
 		Role:      "assistant",
 		Timestamp: time.Now(),
 		Status:    "sent",
 		Source:    "agent",
+		ParentID:  parentID,
 	}
 
 	createdMessage, err := ch.db.AddChatMessage(message)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ChatResponse{
+		return http.StatusInternalServerError, ChatResponse{
 			Success: false,
 			Message: fmt.Sprintf("Failed to save response message: %v", err),
-		})
-		return
+		}
+	}
+
+	artifacts := ch.createResponseArtifacts(ctx, createdMessage, value.Artifacts)
+	ch.announceResponse(ctx, "chat_message", createdMessage, artifacts, value.SourceWebsocketID)
+
+	return http.StatusOK, ChatResponse{
+		Success:   true,
+		Message:   "Response processed successfully",
+		Data:      createdMessage,
+		Artifacts: artifacts,
 	}
+}
 
+// createResponseArtifacts stores the artifacts of an assistant reply and
+// starts the SSE clients they ask for
+func (ch *ChatHandler) createResponseArtifacts(ctx context.Context, message db.ChatMessage, requested []ChatMessageArtifact) []db.Artifact {
 	var artifacts []db.Artifact
-	if len(request.Value.Artifacts) > 0 {
-		for _, artifact := range request.Value.Artifacts {
-			content := db.PropertyMap{}
-			if contentMap, ok := artifact.Content.(map[string]interface{}); ok {
-				content = db.PropertyMap(contentMap)
-			}
+	for _, artifact := range requested {
+		content := db.PropertyMap{}
+		if contentMap, ok := artifact.Content.(map[string]interface{}); ok {
+			content = db.PropertyMap(contentMap)
+		}
 
-			newArtifact := &db.Artifact{
-				ID:        uuid.New(),
-				MessageID: createdMessage.ID,
-				Type:      artifact.Type,
-				Content:   content,
-				CreatedAt: time.Now(),
-				UpdatedAt: time.Now(),
-			}
+		newArtifact := &db.Artifact{
+			ID:        uuid.New(),
+			MessageID: message.ID,
+			Type:      artifact.Type,
+			Content:   content,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
 
-			processedArtifact, err := ch.db.CreateArtifact(newArtifact)
-			if err != nil {
-				log.Printf("Error processing artifact: %v", err)
-				continue
-			}
-			artifacts = append(artifacts, *processedArtifact)
+		processedArtifact, err := ch.db.CreateArtifact(newArtifact)
+		if err != nil {
+			logger.FromContext(ctx).Error("Error processing artifact: %v", err)
+			continue
+		}
+		artifacts = append(artifacts, *processedArtifact)
 
-			if artifact.Type == db.SSEArtifact {
-				go HandleSSEConnectionArtifact(ch.db, artifact, request.Value.ChatID)
-			}
+		if artifact.Type == db.SSEArtifact {
+			go ch.startSSEClient(ctx, ch.db, artifact, message.ChatID)
 		}
 	}
+	return artifacts
+}
+
+// announceResponse sends a complete assistant reply to the subscribers of
+// the chat and to the session that asked
+func (ch *ChatHandler) announceResponse(ctx context.Context, event string, message db.ChatMessage, artifacts []db.Artifact, sourceWebsocketID string) {
+	publishEvent(ctx, websocket.ChatTopic(message.ChatID), event, map[string]interface{}{
+		"message":   message,
+		"artifacts": artifacts,
+	})
 
 	wsMessage := websocket.TicketMessage{
 		BroadcastType:   "direct",
-		SourceSessionID: request.Value.SourceWebsocketID,
+		SourceSessionID: sourceWebsocketID,
 		Message:         "Response received",
 		Action:          "message",
-		ChatMessage:     createdMessage,
+		ChatMessage:     message,
 		Artifacts:       artifacts,
 	}
 
 	if err := websocket.WebsocketPool.SendTicketMessage(wsMessage); err != nil {
-		log.Printf("Failed to send websocket message: %v", err)
+		logger.FromContext(ctx).Error("Failed to send websocket message: %v", err)
 	}
+}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(ChatResponse{
+// streamedReply returns the assistant reply streamed for a message of the
+// chat, created by its first chunk
+func (ch *ChatHandler) streamedReply(ctx context.Context, chatID string, messageID string) (db.ChatMessage, error) {
+	reply, created, err := ch.db.StartChatMessageStream(&db.ChatMessage{
+		ID:       xid.New().String(),
+		ChatID:   chatID,
+		Role:     db.AssistantRole,
+		Status:   db.SendingStatus,
+		Source:   db.AgentSource,
+		ParentID: messageID,
+	})
+	if err != nil {
+		return db.ChatMessage{}, err
+	}
+
+	if created {
+		publishEvent(ctx, websocket.ChatTopic(chatID), "chat_message", reply)
+	}
+	return reply, nil
+}
+
+// finishStreamedReply completes a streamed reply with its artifacts and
+// announces it, a reply that was already complete is left as it is
+func (ch *ChatHandler) finishStreamedReply(ctx context.Context, reply db.ChatMessage, response string, requested []ChatMessageArtifact, sourceWebsocketID string) (int, ChatResponse) {
+	finished, err := ch.db.FinishChatMessageStream(reply.ID, response)
+	if errors.Is(err, db.ErrMessageNotStreaming) {
+		return http.StatusOK, ChatResponse{
+			Success: true,
+			Message: "Response already completed",
+			Data:    reply,
+		}
+	}
+	if err != nil {
+		return http.StatusInternalServerError, ChatResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to complete response message: %v", err),
+		}
+	}
+
+	artifacts := ch.createResponseArtifacts(ctx, finished, requested)
+	ch.announceResponse(ctx, "chat_message_done", finished, artifacts, sourceWebsocketID)
+
+	return http.StatusOK, ChatResponse{
 		Success:   true,
 		Message:   "Response processed successfully",
-		Data:      createdMessage,
+		Data:      finished,
 		Artifacts: artifacts,
-	})
+	}
+}
+
+// ProcessChatResponseChunk processes a part of a streamed chat response
+//
+//	@Summary		Process a chat response chunk
+//	@Description	Append a chunk of a streamed assistant reply to the message it answers and push it to the chat subscribers. A message that is not part of the chat is refused with 404. Chunks are numbered from 0, retried chunks are ignored and a chunk whose predecessors are missing is refused with 409. The chunk with done set completes the reply with its artifacts.
+//	@Tags			Hive Chat
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		ChatResponseChunkRequest	true	"Chat response chunk"
+//	@Success		200		{object}	ChatResponse
+//	@Failure		400		{object}	ChatResponse
+//	@Failure		404		{object}	ChatResponse
+//	@Failure		409		{object}	ChatResponse
+//	@Failure		500		{object}	ChatResponse
+//	@Router			/hivechat/response/stream [post]
+func (ch *ChatHandler) ProcessChatResponseChunk(w http.ResponseWriter, r *http.Request) {
+	var request ChatResponseChunkRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ChatResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+
+	status, response := ch.processChunk(r.Context(), request)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// processChunk appends a chunk to the reply streamed for a message and
+// completes the reply with the last one
+func (ch *ChatHandler) processChunk(ctx context.Context, request ChatResponseChunkRequest) (int, ChatResponse) {
+	if request.ChatID == "" || request.MessageID == "" {
+		return http.StatusBadRequest, ChatResponse{
+			Success: false,
+			Message: "chatId and messageId are required",
+		}
+	}
+	if request.Index < 0 {
+		return http.StatusBadRequest, ChatResponse{
+			Success: false,
+			Message: "index must not be negative",
+		}
+	}
+
+	reply, err := ch.streamedReply(ctx, request.ChatID, request.MessageID)
+	if errors.Is(err, db.ErrChatMessageNotFound) {
+		return http.StatusNotFound, ChatResponse{
+			Success: false,
+			Message: "Message not found in chat",
+		}
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("Failed to get streamed reply to message %s: %v", request.MessageID, err)
+		return http.StatusInternalServerError, ChatResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to save response message: %v", err),
+		}
+	}
+
+	if request.Chunk != "" {
+		appended, err := ch.db.AppendChatMessageChunk(reply.ID, request.Index, request.Chunk)
+		switch {
+		case errors.Is(err, db.ErrMessageNotStreaming):
+			return http.StatusConflict, ChatResponse{
+				Success: false,
+				Message: "Response already completed",
+			}
+		case errors.Is(err, db.ErrChunkOutOfOrder):
+			return http.StatusConflict, ChatResponse{
+				Success: false,
+				Message: fmt.Sprintf("Chunk %d arrived before the chunks preceding it", request.Index),
+			}
+		case err != nil:
+			return http.StatusInternalServerError, ChatResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to append chunk: %v", err),
+			}
+		}
+
+		if appended {
+			chunk := ChatMessageChunkEvent{
+				ChatID:    request.ChatID,
+				MessageID: reply.ID,
+				ParentID:  request.MessageID,
+				Index:     request.Index,
+				Chunk:     request.Chunk,
+			}
+			publishEvent(ctx, websocket.ChatTopic(request.ChatID), "chat_message_chunk", chunk)
+
+			wsMessage := websocket.TicketMessage{
+				BroadcastType:   "direct",
+				SourceSessionID: request.SourceWebsocketID,
+				Message:         request.Chunk,
+				Action:          "stream",
+				ChatMessage:     reply,
+			}
+			if err := websocket.WebsocketPool.SendTicketMessage(wsMessage); err != nil {
+				logger.FromContext(ctx).Error("Failed to send websocket message: %v", err)
+			}
+		}
+	}
+
+	if request.Done {
+		return ch.finishStreamedReply(ctx, reply, request.Response, request.Artifacts, request.SourceWebsocketID)
+	}
+
+	return http.StatusOK, ChatResponse{
+		Success: true,
+		Message: "Chunk processed successfully",
+		Data:    reply,
+	}
 }
 
 // UploadFile uploads a file to a chat
@@ -983,9 +1209,9 @@ func (ch *ChatHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 
 	uploadFilename := uuid.New().String() + filepath.Ext(header.Filename)
 
-	challenge := GetMemeChallenge()
-	signer := SignChallenge(challenge.Challenge)
-	mErr, mToken := GetMemeToken(challenge.Id, signer.Response.Sig)
+	challenge := GetMemeChallenge(r.Context())
+	signer := SignChallenge(r.Context(), challenge.Challenge)
+	mErr, mToken := GetMemeToken(r.Context(), challenge.Id, signer.Response.Sig)
 
 	if mErr != "" {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -996,7 +1222,7 @@ func (ch *ChatHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err, uploadURL := UploadMemeImage(file, mToken.Token, header.Filename)
+	err, uploadURL := UploadMemeImage(r.Context(), file, mToken.Token, header.Filename)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ChatResponse{
@@ -1275,7 +1501,7 @@ func (ch *ChatHandler) SendBuildMessage(w http.ResponseWriter, r *http.Request)
 	}
 
 	stakworkURL := "https://api.stakwork.com/api/v1/projects"
-	reqStakwork, err := http.NewRequest("POST", stakworkURL, bytes.NewBuffer(payloadBytes))
+	reqStakwork, err := http.NewRequestWithContext(r.Context(), "POST", stakworkURL, bytes.NewBuffer(payloadBytes))
 	if err != nil {
 		http.Error(w, "Error creating Stakwork request", http.StatusInternalServerError)
 		return
@@ -1469,7 +1695,7 @@ func (ch *ChatHandler) SendActionMessage(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	history, err := ch.db.GetChatMessagesForChatID(request.ChatID)
+	history, err := ch.db.GetChatBranch(request.ChatID, "")
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ChatResponse{
@@ -1551,6 +1777,11 @@ func (ch *ChatHandler) SendActionMessage(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	if request.ActionWebhook == LocalActionWebhook {
+		ch.answerLocalAction(w, r, request, createdMessage, messageHistory, chat.WorkspaceID)
+		return
+	}
+
 	payload := ActionPayload{
 		ChatID:            request.ChatID,
 		MessageID:         request.MessageID,
@@ -1583,7 +1814,7 @@ func (ch *ChatHandler) SendActionMessage(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	req, err := http.NewRequest("POST", request.ActionWebhook, bytes.NewBuffer(payloadBytes))
+	req, err := http.NewRequestWithContext(r.Context(), "POST", request.ActionWebhook, bytes.NewBuffer(payloadBytes))
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ChatResponse{
@@ -1615,6 +1846,8 @@ func (ch *ChatHandler) SendActionMessage(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	publishEvent(r.Context(), websocket.ChatTopic(request.ChatID), "chat_message", createdMessage)
+
 	wsMessage := websocket.TicketMessage{
 		BroadcastType:   "direct",
 		SourceSessionID: request.SourceWebsocketID,
@@ -1624,7 +1857,7 @@ func (ch *ChatHandler) SendActionMessage(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := websocket.WebsocketPool.SendTicketMessage(wsMessage); err != nil {
-		log.Printf("Failed to send websocket message: %v", err)
+		logger.FromContext(r.Context()).Error("Failed to send websocket message: %v", err)
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -1634,11 +1867,63 @@ func (ch *ChatHandler) SendActionMessage(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// answerLocalAction answers the option of an action the local backend
+// offered, the option response is the message it answers
+func (ch *ChatHandler) answerLocalAction(w http.ResponseWriter, r *http.Request, request ActionMessageRequest, createdMessage db.ChatMessage, messageHistory []map[string]string, workspaceUUID string) {
+	backend := ch.backends[LocalChatBackendName]
+	result, err := backend.Send(r.Context(), ChatBackendRequest{
+		ChatID:            request.ChatID,
+		MessageID:         createdMessage.ID,
+		Message:           request.Message,
+		WorkspaceUUID:     workspaceUUID,
+		SourceWebsocketID: request.SourceWebsocketID,
+		History:           messageHistory,
+	})
+	if err != nil {
+		createdMessage.Status = "error"
+		ch.db.UpdateChatMessage(&createdMessage)
+
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ChatResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to process message: %v", err),
+		})
+		return
+	}
+
+	publishEvent(r.Context(), websocket.ChatTopic(request.ChatID), "chat_message", createdMessage)
+
+	wsMessage := websocket.TicketMessage{
+		BroadcastType:   "direct",
+		SourceSessionID: request.SourceWebsocketID,
+		Message:         "Message sent",
+		Action:          "process",
+		ChatMessage:     createdMessage,
+	}
+
+	if err := websocket.WebsocketPool.SendTicketMessage(wsMessage); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to send websocket message: %v", err)
+	}
+
+	if result.Reply != nil {
+		if err := ch.deliverReply(r.Context(), request.ChatID, createdMessage.ID, request.SourceWebsocketID, result.Reply); err != nil {
+			logger.FromContext(r.Context()).Error("[chat_backend] %s could not reply to message %s: %v", backend.Name(), createdMessage.ID, err)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ChatResponse{
+		Success: true,
+		Message: "Message answered by the local chat backend",
+		Data:    createdMessage,
+	})
+}
+
 func (ch *ChatHandler) CreateOrEditChatWorkflow(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1653,7 +1938,8 @@ func (ch *ChatHandler) CreateOrEditChatWorkflow(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	if request.WorkspaceID == "" || request.URL == "" {
+	request.Backend = strings.ToLower(request.Backend)
+	if request.WorkspaceID == "" || (request.URL == "" && request.Backend != LocalChatBackendName) {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(ChatWorkflowResponse{
 			Success: false,
@@ -1662,6 +1948,15 @@ func (ch *ChatHandler) CreateOrEditChatWorkflow(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if _, ok := ch.backends[request.Backend]; request.Backend != "" && !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ChatWorkflowResponse{
+			Success: false,
+			Message: fmt.Sprintf("Unknown chat backend %q", request.Backend),
+		})
+		return
+	}
+
 	workspace := ch.db.GetWorkspaceByUuid(request.WorkspaceID)
 	if workspace.Uuid == "" {
 		w.WriteHeader(http.StatusNotFound)
@@ -1672,10 +1967,23 @@ func (ch *ChatHandler) CreateOrEditChatWorkflow(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	// the workflow picks where the workspace chats are sent, only its
+	// admins may change it
+	if !ch.db.UserHasManageBountyRoles(pubKeyFromAuth, request.WorkspaceID) {
+		logger.FromContext(r.Context()).Info("user is not allowed to manage the chat workflow of workspace %s", request.WorkspaceID)
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ChatWorkflowResponse{
+			Success: false,
+			Message: "You don't have permission to manage this workspace's chat workflow",
+		})
+		return
+	}
+
 	workflow := &db.ChatWorkflow{
 		WorkspaceID: request.WorkspaceID,
 		URL:         request.URL,
 		StackworkID: request.StackworkID,
+		Backend:     request.Backend,
 	}
 
 	result, err := ch.db.CreateOrEditChatWorkflow(workflow)
@@ -1729,6 +2037,13 @@ func (ch *ChatHandler) GetChatWorkflow(w http.ResponseWriter, r *http.Request) {
 }
 
 func (ch *ChatHandler) DeleteChatWorkflow(w http.ResponseWriter, r *http.Request) {
+	pubKeyFromAuth, _ := r.Context().Value(auth.ContextKey).(string)
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
 	workspaceID := chi.URLParam(r, "workspaceId")
 	if workspaceID == "" {
 		w.WriteHeader(http.StatusBadRequest)
@@ -1739,6 +2054,15 @@ func (ch *ChatHandler) DeleteChatWorkflow(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if !ch.db.UserHasManageBountyRoles(pubKeyFromAuth, workspaceID) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ChatWorkflowResponse{
+			Success: false,
+			Message: "You don't have permission to manage this workspace's chat workflow",
+		})
+		return
+	}
+
 	if err := ch.db.DeleteChatWorkflow(workspaceID); err != nil {
 		status := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "not found") {
@@ -1783,7 +2107,7 @@ func (ch *ChatHandler) StopSSEClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if sse.ClientRegistry.Unregister(request.SSEURL, request.ChatID) {
+	if sse.ClientRegistry.Unregister(r.Context(), request.SSEURL, request.ChatID) {
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(ChatResponse{
 			Success: true,
@@ -1878,7 +2202,7 @@ func (ch *ChatHandler) StartSSEClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if sse.ClientRegistry.HasClient(request.SSEURL, request.ChatID) {
+	if sse.ClientRegistry.HasClient(r.Context(), request.SSEURL, request.ChatID) {
 		w.WriteHeader(http.StatusConflict)
 		json.NewEncoder(w).Encode(ChatResponse{
 			Success: false,
@@ -1913,23 +2237,23 @@ func (ch *ChatHandler) StartSSEClient(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func HandleSSEConnectionArtifact(database db.Database, artifact ChatMessageArtifact, chatID string) {
+func HandleSSEConnectionArtifact(ctx context.Context, database db.Database, artifact ChatMessageArtifact, chatID string) {
 
 	content, ok := artifact.Content.(map[string]interface{})
 	if !ok {
-		log.Printf("Invalid SSE connection artifact content format")
+		logger.FromContext(ctx).Error("Invalid SSE connection artifact content format")
 		return
 	}
 
 	sseURL, ok := content["sse_url"].(string)
 	if !ok || sseURL == "" {
-		log.Printf("Missing or invalid sse_url in SSE connection artifact")
+		logger.FromContext(ctx).Error("Missing or invalid sse_url in SSE connection artifact")
 		return
 	}
 
 	webhookURL, ok := content["webhook_url"].(string)
 	if !ok || webhookURL == "" {
-		log.Printf("Missing or invalid webhook_url in SSE connection artifact")
+		logger.FromContext(ctx).Error("Missing or invalid webhook_url in SSE connection artifact")
 		return
 	}
 
@@ -1948,10 +2272,10 @@ func HandleSSEConnectionArtifact(database db.Database, artifact ChatMessageArtif
 
 	go client.Start()
 
-	log.Printf("Started SSE client for chatID %s connecting to %s", chatID, sseURL)
+	logger.FromContext(ctx).Info("Started SSE client for chatID %s connecting to %s", chatID, sseURL)
 
 	if delayMs > 0 {
-		log.Printf("Triggering webhook payload with delay: %dms", delayMs)
+		logger.FromContext(ctx).Info("Triggering webhook payload with delay: %dms", delayMs)
 		go func() {
 			time.Sleep(time.Duration(delayMs) * time.Millisecond)
 			SendEventPayloadToWebhook(database, chatID, webhookURL, delayMs)
@@ -1959,27 +2283,84 @@ func HandleSSEConnectionArtifact(database db.Database, artifact ChatMessageArtif
 	}
 }
 
+// SSEEventsWebhook is the kind of the deliveries carrying the events an
+// SSE client stored for a chat
+const SSEEventsWebhook = "sse.events"
+
+// staleSSEDeliveryAfter is how long a batch of SSE events may stay sending
+// before it is taken as lost with the process that was sending it
+const staleSSEDeliveryAfter = 15 * time.Minute
+
 func SendEventPayloadToWebhook(database db.Database, chatID string, webhookURL string, delayMs int64) {
 	if delayMs > 0 {
 		time.Sleep(time.Duration(delayMs) * time.Millisecond)
 	}
 
-	unsentEvents, err := database.GetNewSSEMessageLogsByChatID(chatID)
+	// the events are claimed as sending in one batch, a concurrent call
+	// does not send them again
+	deliveryID := uuid.New()
+	unsentEvents, err := database.ClaimNewSSEMessageLogs(chatID, deliveryID)
 	if err != nil {
-		log.Printf("Error retrieving unsent events for chatID %s: %v", chatID, err)
+		logger.Log.Error("Error retrieving unsent events for chatID %s: %v", chatID, err)
+		return
+	}
+
+	if err := sendSSEEventBatch(deliveryID, chatID, webhookURL, unsentEvents); err != nil {
+		logger.Log.Error("Error queuing events for webhook %s: %v", webhookURL, err)
 		return
 	}
 
+	logger.Log.Info("Queued %d events for chatID %s to webhook %s", len(unsentEvents), chatID, webhookURL)
+}
+
+// ResendStaleSSEEvents sends again the batches of SSE events that stayed
+// sending because the process sending them stopped, each with its own
+// delivery ID so receivers can drop the ones they already got
+func ResendStaleSSEEvents(database db.Database) error {
+	staleEvents, err := database.GetStaleSendingSSEMessageLogs(time.Now().Add(-staleSSEDeliveryAfter))
+	if err != nil {
+		return err
+	}
+
+	batches := make(map[uuid.UUID][]db.SSEMessageLog)
+	var deliveryIDs []uuid.UUID
+	for _, event := range staleEvents {
+		if event.DeliveryID == nil {
+			continue
+		}
+		if _, ok := batches[*event.DeliveryID]; !ok {
+			deliveryIDs = append(deliveryIDs, *event.DeliveryID)
+		}
+		batches[*event.DeliveryID] = append(batches[*event.DeliveryID], event)
+	}
+
+	var errs []error
+	for _, deliveryID := range deliveryIDs {
+		events := batches[deliveryID]
+		if err := sendSSEEventBatch(deliveryID, events[0].ChatID, events[0].To, events); err != nil {
+			errs = append(errs, fmt.Errorf("delivery %s: %w", deliveryID, err))
+			continue
+		}
+		logger.Log.Info("Resent %d stale events for chatID %s to webhook %s", len(events), events[0].ChatID, events[0].To)
+	}
+	return errors.Join(errs...)
+}
+
+// sendSSEEventBatch queues the delivery of a batch of SSE events, they
+// stay sending until the dispatcher delivered them, see MarkSSEEventsSent
+func sendSSEEventBatch(deliveryID uuid.UUID, chatID string, webhookURL string, unsentEvents []db.SSEMessageLog) error {
 	sseURL := ""
 	if len(unsentEvents) > 0 {
 		sseURL = unsentEvents[0].From
 	}
 
 	eventsList := make([]map[string]interface{}, len(unsentEvents))
+	eventIDs := make([]string, len(unsentEvents))
 	for i, event := range unsentEvents {
 		eventsList[i] = map[string]interface{}{
 			"event": event.Event,
 		}
+		eventIDs[i] = event.ID.String()
 	}
 
 	payload := map[string]interface{}{
@@ -1990,35 +2371,41 @@ func SendEventPayloadToWebhook(database db.Database, chatID string, webhookURL s
 
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshaling webhook payload: %v", err)
-		return
+		return fmt.Errorf("error marshaling webhook payload: %w", err)
 	}
 
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(payloadJSON))
-	if err != nil {
-		log.Printf("Error sending events to webhook %s: %v", webhookURL, err)
-		return
-	}
-	defer resp.Body.Close()
+	return webhooks.Send(webhooks.Delivery{
+		ID:      deliveryID,
+		Kind:    SSEEventsWebhook,
+		URL:     webhookURL,
+		Payload: payloadJSON,
+		Refs:    eventIDs,
+	})
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Webhook returned non-success status %d: %s", resp.StatusCode, string(body))
-		return
-	}
+// MarkSSEEventsSent marks the events of a delivered SSE events webhook as
+// sent, it is the hook of SSEEventsWebhook deliveries
+func MarkSSEEventsSent(database db.Database) webhooks.DeliveredFunc {
+	return func(delivery webhooks.Delivery) error {
+		if len(delivery.Refs) == 0 {
+			return nil
+		}
 
-	var eventIDs []uuid.UUID
-	for _, event := range unsentEvents {
-		eventIDs = append(eventIDs, event.ID)
-	}
+		eventIDs := make([]uuid.UUID, 0, len(delivery.Refs))
+		for _, ref := range delivery.Refs {
+			id, err := uuid.Parse(ref)
+			if err != nil {
+				return fmt.Errorf("invalid SSE event id %q: %w", ref, err)
+			}
+			eventIDs = append(eventIDs, id)
+		}
 
-	err = database.UpdateSSEMessageLogStatusBatch(eventIDs)
-	if err != nil {
-		log.Printf("Error updating event status: %v", err)
-		return
+		if err := database.UpdateSSEMessageLogStatusBatch(eventIDs); err != nil {
+			return err
+		}
+		logger.Log.Info("Successfully sent %d events to webhook %s", len(eventIDs), delivery.URL)
+		return nil
 	}
-
-	log.Printf("Successfully sent %d events for chatID %s to webhook %s", len(unsentEvents), chatID, webhookURL)
 }
 
 func (ch *ChatHandler) GetAllSSEMessagesByChatID(w http.ResponseWriter, r *http.Request) {
@@ -2094,7 +2481,7 @@ func (ch *ChatHandler) GetAllChatStatus(w http.ResponseWriter, r *http.Request)
 
 	statuses, err := ch.db.GetChatStatusByChatID(chatID)
 	if err != nil {
-		logger.Log.Error("Failed to get chat statuses: %v", err)
+		logger.FromContext(r.Context()).Error("Failed to get chat statuses: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ChatStatusResponse{
 			Success: false,
@@ -2145,7 +2532,7 @@ func (ch *ChatHandler) GetLatestChatStatus(w http.ResponseWriter, r *http.Reques
 			})
 			return
 		}
-		logger.Log.Error("Failed to get latest chat status: %v", err)
+		logger.FromContext(r.Context()).Error("Failed to get latest chat status: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ChatStatusResponse{
 			Success: false,
@@ -2224,7 +2611,7 @@ func (ch *ChatHandler) CreateChatStatus(w http.ResponseWriter, r *http.Request)
 
 	createdStatus, err := ch.db.AddChatStatus(chatStatus)
 	if err != nil {
-		logger.Log.Error("Failed to create chat status: %v", err)
+		logger.FromContext(r.Context()).Error("Failed to create chat status: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ChatStatusResponse{
 			Success: false,
@@ -2304,7 +2691,7 @@ func (ch *ChatHandler) UpdateChatStatus(w http.ResponseWriter, r *http.Request)
 			})
 			return
 		}
-		logger.Log.Error("Failed to update chat status: %v", err)
+		logger.FromContext(r.Context()).Error("Failed to update chat status: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ChatStatusResponse{
 			Success: false,
@@ -2365,7 +2752,7 @@ func (ch *ChatHandler) DeleteChatStatus(w http.ResponseWriter, r *http.Request)
 			})
 			return
 		}
-		logger.Log.Error("Failed to delete chat status: %v", err)
+		logger.FromContext(r.Context()).Error("Failed to delete chat status: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ChatStatusResponse{
 			Success: false,
@@ -2409,7 +2796,7 @@ func (ch *ChatHandler) HandleChatWebhook(w http.ResponseWriter, r *http.Request)
 	_, err := ch.db.GetChatByChatID(chatID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			logger.Log.Error("Chat not found for webhook: %s", chatID)
+			logger.FromContext(r.Context()).Error("Chat not found for webhook: %s", chatID)
 			w.WriteHeader(http.StatusNotFound)
 			json.NewEncoder(w).Encode(ChatStatusWebhookResponse{
 				Status:  "error",
@@ -2417,7 +2804,7 @@ func (ch *ChatHandler) HandleChatWebhook(w http.ResponseWriter, r *http.Request)
 			})
 			return
 		}
-		logger.Log.Error("Error fetching chat for webhook: %v", err)
+		logger.FromContext(r.Context()).Error("Error fetching chat for webhook: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ChatStatusWebhookResponse{
 			Status:  "error",
@@ -2428,7 +2815,7 @@ func (ch *ChatHandler) HandleChatWebhook(w http.ResponseWriter, r *http.Request)
 
 	var payload WebhookPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		logger.Log.Error("Error parsing webhook payload: %v", err)
+		logger.FromContext(r.Context()).Error("Error parsing webhook payload: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(ChatStatusWebhookResponse{
 			Status:  "error",
@@ -2438,7 +2825,7 @@ func (ch *ChatHandler) HandleChatWebhook(w http.ResponseWriter, r *http.Request)
 	}
 
 	payloadBytes, _ := json.Marshal(payload)
-	logger.Log.Info("Received webhook for chat %s: %s", chatID, string(payloadBytes))
+	logger.FromContext(r.Context()).Info("Received webhook for chat %s: %s", chatID, string(payloadBytes))
 
 	status := ""
 	message := ""
@@ -2464,7 +2851,7 @@ func (ch *ChatHandler) HandleChatWebhook(w http.ResponseWriter, r *http.Request)
 
 	createdStatus, err := ch.db.AddChatStatus(chatStatus)
 	if err != nil {
-		logger.Log.Error("Failed to create chat status: %v", err)
+		logger.FromContext(r.Context()).Error("Failed to create chat status: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ChatStatusWebhookResponse{
 			Status:  "error",
@@ -2473,8 +2860,7 @@ func (ch *ChatHandler) HandleChatWebhook(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	logger.Log.Info("Created chat status for chat %s: %s - %s", 
-		chatID, createdStatus.Status, createdStatus.Message)
+	logger.FromContext(r.Context()).Info("Created chat status for chat %s: %s - %s", chatID, createdStatus.Status, createdStatus.Message)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(ChatStatusWebhookResponse{
@@ -2512,7 +2898,7 @@ func (ch *ChatHandler) SSEMaintenance(w http.ResponseWriter, r *http.Request) {
 
 	if request.StopAllClients {
 		response.ClientsStopped = sse.ClientRegistry.StopAllClients()
-		logger.Log.Info("Stopped %d SSE clients during maintenance", response.ClientsStopped)
+		logger.FromContext(r.Context()).Info("Stopped %d SSE clients during maintenance", response.ClientsStopped)
 	}
 
 	if request.CleanupLogs {
@@ -2523,7 +2909,7 @@ func (ch *ChatHandler) SSEMaintenance(w http.ResponseWriter, r *http.Request) {
 		maxAge := time.Duration(request.LogMaxAgeHours) * time.Hour
 		logsRemoved, err := ch.db.DeleteOldSSEMessageLogs(maxAge)
 		if err != nil {
-			logger.Log.Error("Error cleaning up SSE logs: %v", err)
+			logger.FromContext(r.Context()).Error("Error cleaning up SSE logs: %v", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(ChatResponse{
 				Success: false,
@@ -2533,7 +2919,7 @@ func (ch *ChatHandler) SSEMaintenance(w http.ResponseWriter, r *http.Request) {
 		}
 
 		response.LogsRemoved = logsRemoved
-		logger.Log.Info("Removed %d SSE logs older than %v during maintenance", logsRemoved, maxAge)
+		logger.FromContext(r.Context()).Info("Removed %d SSE logs older than %v during maintenance", logsRemoved, maxAge)
 	}
 
 	response.Message = fmt.Sprintf("Maintenance completed: stopped %d clients, removed %d logs",
@@ -2541,4 +2927,37 @@ func (ch *ChatHandler) SSEMaintenance(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
-}
\ No newline at end of file
+}
+
+// GetSSEClients lists the SSE subscriptions and the clients running them
+//
+//	@Summary		Get SSE clients
+//	@Description	List the SSE clients running on this instance and the persisted subscriptions of every instance, ended ones only with all=true
+//	@Tags			Hive Chat
+//	@Produce		json
+//	@Security		SuperAdminAuth
+//	@Param			all	query		bool	false	"Include stopped and expired subscriptions"
+//	@Success		200	{object}	SSEClientsResponse
+//	@Failure		500	{object}	ChatResponse
+//	@Router			/hivechat/sse/clients [get]
+func (ch *ChatHandler) GetSSEClients(w http.ResponseWriter, r *http.Request) {
+	includeEnded := r.URL.Query().Get("all") == "true"
+
+	subscriptions, err := ch.db.GetSSESubscriptions(includeEnded)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Error fetching SSE subscriptions: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ChatResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to fetch SSE subscriptions: %v", err),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(SSEClientsResponse{
+		Instance:      sse.Instance,
+		Clients:       sse.ClientRegistry.Statuses(),
+		Subscriptions: subscriptions,
+	})
+}
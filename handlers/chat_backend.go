@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+)
+
+const (
+	StakworkChatBackendName = "stakwork"
+	LocalChatBackendName    = "local"
+)
+
+// LocalActionWebhook is the webhook of the action options the local
+// backend answers itself instead of posting them anywhere
+const LocalActionWebhook = "local://chat"
+
+// ChatBackend answers the messages sent in hive chat. A backend either
+// hands a message to a workflow that posts the reply to the response
+// endpoints later, or answers it right away.
+type ChatBackend interface {
+	Name() string
+	Send(ctx context.Context, request ChatBackendRequest) (ChatBackendResult, error)
+}
+
+// ChatBackendRequest is a message with the history and the context it is
+// answered with, Vars is everything a workflow is started with
+type ChatBackendRequest struct {
+	ChatID            string
+	MessageID         string
+	Message           string
+	Mode              string
+	WorkspaceUUID     string
+	SourceWebsocketID string
+	History           []map[string]string
+	Vars              map[string]interface{}
+}
+
+// ChatBackendResult links to the workflow run answering the message, or
+// holds the reply of a backend that answered right away
+type ChatBackendResult struct {
+	RunURL string
+	Reply  *ChatBackendReply
+}
+
+// ChatBackendReply is streamed chunk by chunk when it has chunks, the
+// response then replaces the streamed text when it is set
+type ChatBackendReply struct {
+	Response  string
+	Chunks    []string
+	Artifacts []ChatMessageArtifact
+}
+
+// NewChatBackends builds the backends a workspace can choose from, the
+// local one answers from the fixtures in CHAT_FIXTURES or from the
+// default fixtures
+func NewChatBackends(httpClient *http.Client) map[string]ChatBackend {
+	fixtures := DefaultChatFixtures
+	if config.ChatFixtures != "" {
+		loaded, err := LoadChatFixtures(config.ChatFixtures)
+		if err != nil {
+			logger.Log.Error("[chat_backend] could not load chat fixtures, using the default ones: %v", err)
+		} else {
+			fixtures = loaded
+		}
+	}
+
+	local, err := NewLocalChatBackend(fixtures)
+	if err != nil {
+		logger.Log.Error("[chat_backend] invalid chat fixtures, using the default ones: %v", err)
+		local, _ = NewLocalChatBackend(DefaultChatFixtures)
+	}
+
+	return map[string]ChatBackend{
+		StakworkChatBackendName: NewStakworkChatBackend(httpClient),
+		LocalChatBackendName:    local,
+	}
+}
+
+// StakworkChatBackend starts a Stakwork project for every message, the
+// project posts the reply to the response endpoints
+type StakworkChatBackend struct {
+	httpClient *http.Client
+	url        string
+}
+
+func NewStakworkChatBackend(httpClient *http.Client) *StakworkChatBackend {
+	return &StakworkChatBackend{
+		httpClient: httpClient,
+		url:        "https://api.stakwork.com/api/v1/projects",
+	}
+}
+
+func (b *StakworkChatBackend) Name() string {
+	return StakworkChatBackendName
+}
+
+func (b *StakworkChatBackend) Send(ctx context.Context, request ChatBackendRequest) (ChatBackendResult, error) {
+	payload := StakworkChatPayload{
+		Name:       "Hive Chat Processor",
+		WorkflowID: 38842,
+		WorkflowParams: map[string]interface{}{
+			"set_var": map[string]interface{}{
+				"attributes": map[string]interface{}{
+					"vars": request.Vars,
+				},
+			},
+		},
+		WebhookURL: fmt.Sprintf("https://community.sphinx.chat/hivechat/%s/update", request.ChatID),
+	}
+
+	apiKeyEnv := "SWWFKEY"
+	if request.Mode == "Build" {
+		payload.Name = "hive_autogen"
+		payload.WorkflowID = 43859
+		apiKeyEnv = "SWPR"
+	}
+
+	apiKey := os.Getenv(apiKeyEnv)
+	if apiKey == "" {
+		return ChatBackendResult{}, fmt.Errorf("%s environment variable is not set", apiKeyEnv)
+	}
+
+	projectID, err := b.createProject(ctx, payload, apiKey)
+	if err != nil {
+		return ChatBackendResult{}, err
+	}
+
+	return ChatBackendResult{
+		RunURL: fmt.Sprintf("https://jobs.stakwork.com/admin/projects/%d", projectID),
+	}, nil
+}
+
+func (b *StakworkChatBackend) createProject(ctx context.Context, payload StakworkChatPayload, apiKey string) (int64, error) {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("error marshaling payload: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewBuffer(payloadJSON))
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Token token="+apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("stakwork API error: %s", string(body))
+	}
+
+	var stakworkResp StakworkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&stakworkResp); err != nil {
+		return 0, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	return stakworkResp.Data.ProjectID, nil
+}
+
+// ChatFixture is a scripted answer of the local backend. Match is a
+// regular expression tried on the message without regard to case, an
+// empty one matches every message. {{message}}, {{chatId}}, {{messageId}},
+// {{workspace}} and {{host}} are filled in the response, the chunks and
+// the strings of the artifact contents.
+type ChatFixture struct {
+	Match     string                `json:"match"`
+	Mode      string                `json:"mode,omitempty"`
+	Response  string                `json:"response"`
+	Chunks    []string              `json:"chunks,omitempty"`
+	Artifacts []ChatMessageArtifact `json:"artifacts,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// DefaultChatFixtures answer when no fixture file is configured
+var DefaultChatFixtures = []ChatFixture{
+	{
+		Match:  `\bstream\b`,
+		Chunks: []string{"This reply ", "is streamed ", "chunk by chunk."},
+	},
+	{
+		Match:    `\b(code|snippet)\b`,
+		Response: "Here is a snippet for: {{message}}",
+		Artifacts: []ChatMessageArtifact{{
+			Type: db.TextArtifact,
+			Content: map[string]interface{}{
+				"text_type": "code",
+				"content":   "func main() {\n\tfmt.Println(\"{{message}}\")\n}",
+				"language":  "go",
+			},
+		}},
+	},
+	{
+		Match:    `\b(options|choose)\b`,
+		Response: "Which one should I go with?",
+		Artifacts: []ChatMessageArtifact{{
+			Type: db.ActionArtifact,
+			Content: map[string]interface{}{
+				"action_text": "Pick an option",
+				"options": []interface{}{
+					map[string]interface{}{
+						"action_type":     "button",
+						"option_label":    "The first one",
+						"option_response": "first",
+						"webhook":         LocalActionWebhook,
+					},
+					map[string]interface{}{
+						"action_type":     "button",
+						"option_label":    "The second one",
+						"option_response": "second",
+						"webhook":         LocalActionWebhook,
+					},
+				},
+			},
+		}},
+	},
+	{
+		Match:    "",
+		Response: "You said: {{message}}",
+	},
+}
+
+// LoadChatFixtures reads a JSON array of fixtures
+func LoadChatFixtures(path string) ([]ChatFixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat fixtures: %w", err)
+	}
+
+	var fixtures []ChatFixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("failed to parse chat fixtures: %w", err)
+	}
+	return fixtures, nil
+}
+
+// LocalChatBackend answers from scripted fixtures without leaving the
+// process, the first fixture matching a message answers it
+type LocalChatBackend struct {
+	fixtures []ChatFixture
+}
+
+func NewLocalChatBackend(fixtures []ChatFixture) (*LocalChatBackend, error) {
+	compiled := make([]ChatFixture, len(fixtures))
+	for i, fixture := range fixtures {
+		pattern, err := regexp.Compile("(?i)" + fixture.Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match %q of fixture %d: %w", fixture.Match, i, err)
+		}
+		fixture.pattern = pattern
+		compiled[i] = fixture
+	}
+	return &LocalChatBackend{fixtures: compiled}, nil
+}
+
+func (b *LocalChatBackend) Name() string {
+	return LocalChatBackendName
+}
+
+func (b *LocalChatBackend) Send(ctx context.Context, request ChatBackendRequest) (ChatBackendResult, error) {
+	for _, fixture := range b.fixtures {
+		if fixture.Mode != "" && fixture.Mode != request.Mode {
+			continue
+		}
+		if !fixture.pattern.MatchString(request.Message) {
+			continue
+		}
+		return ChatBackendResult{Reply: fixture.reply(request)}, nil
+	}
+	return ChatBackendResult{}, fmt.Errorf("no chat fixture matches %q", request.Message)
+}
+
+func (f ChatFixture) reply(request ChatBackendRequest) *ChatBackendReply {
+	fill := strings.NewReplacer(
+		"{{message}}", request.Message,
+		"{{chatId}}", request.ChatID,
+		"{{messageId}}", request.MessageID,
+		"{{workspace}}", request.WorkspaceUUID,
+		"{{host}}", os.Getenv("HOST"),
+	).Replace
+
+	reply := &ChatBackendReply{Response: fill(f.Response)}
+	for _, chunk := range f.Chunks {
+		reply.Chunks = append(reply.Chunks, fill(chunk))
+	}
+	for _, artifact := range f.Artifacts {
+		reply.Artifacts = append(reply.Artifacts, ChatMessageArtifact{
+			ID:      artifact.ID,
+			Type:    artifact.Type,
+			Content: fillContent(artifact.Content, fill),
+		})
+	}
+	return reply
+}
+
+// fillContent copies an artifact content with the placeholders of its
+// strings filled, the fixture itself is left as it is
+func fillContent(content interface{}, fill func(string) string) interface{} {
+	switch v := content.(type) {
+	case string:
+		return fill(v)
+	case map[string]interface{}:
+		filled := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			filled[key] = fillContent(value, fill)
+		}
+		return filled
+	case []interface{}:
+		filled := make([]interface{}, len(v))
+		for i, value := range v {
+			filled[i] = fillContent(value, fill)
+		}
+		return filled
+	}
+	return content
+}
+
+// chatBackend is the backend the workspace chose, or the one picked with
+// CHAT_BACKEND for workspaces that did not choose one
+func (ch *ChatHandler) chatBackend(workspaceUUID string) (ChatBackend, error) {
+	name := config.ChatBackend
+	if workflow, err := ch.db.GetChatWorkflowByWorkspaceID(workspaceUUID); err == nil && workflow.Backend != "" {
+		name = workflow.Backend
+	}
+	if name == "" {
+		name = StakworkChatBackendName
+	}
+
+	backend, ok := ch.backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown chat backend %q", name)
+	}
+	return backend, nil
+}
+
+// deliverReply saves the reply a backend answered with the way the
+// workflow replies posted to the response endpoints are saved
+func (ch *ChatHandler) deliverReply(ctx context.Context, chatID string, messageID string, sourceWebsocketID string, reply *ChatBackendReply) error {
+	if len(reply.Chunks) == 0 {
+		status, response := ch.processResponse(ctx, ChatResponseValue{
+			ChatID:            chatID,
+			MessageID:         messageID,
+			Response:          reply.Response,
+			SourceWebsocketID: sourceWebsocketID,
+			Artifacts:         reply.Artifacts,
+		})
+		if status != http.StatusOK {
+			return fmt.Errorf("failed to save reply: %s", response.Message)
+		}
+		return nil
+	}
+
+	for i, chunk := range reply.Chunks {
+		request := ChatResponseChunkRequest{
+			ChatID:            chatID,
+			MessageID:         messageID,
+			Index:             i,
+			Chunk:             chunk,
+			SourceWebsocketID: sourceWebsocketID,
+		}
+		if i == len(reply.Chunks)-1 {
+			request.Done = true
+			request.Response = reply.Response
+			request.Artifacts = reply.Artifacts
+		}
+
+		if status, response := ch.processChunk(ctx, request); status != http.StatusOK {
+			return fmt.Errorf("failed to save chunk %d of reply: %s", i, response.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/db"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var testChatFixtures = []ChatFixture{
+	{
+		Match:  `^stream`,
+		Chunks: []string{"Hel", "lo"},
+	},
+	{
+		Match:    `\bwatch\b`,
+		Response: "Watching {{chatId}}",
+		Artifacts: []ChatMessageArtifact{{
+			Type: db.SSEArtifact,
+			Content: map[string]interface{}{
+				"sse_url":     "http://events.local/{{chatId}}",
+				"webhook_url": "http://hooks.local/{{workspace}}",
+			},
+		}},
+	},
+	{
+		Match:    `deploy`,
+		Mode:     "Build",
+		Response: "Deploying",
+	},
+	{
+		Match:    `^hello`,
+		Response: "Hi, you said {{message}}",
+		Artifacts: []ChatMessageArtifact{{
+			Type:    db.TextArtifact,
+			Content: map[string]interface{}{"text_type": "plain", "content": "{{messageId}}"},
+		}},
+	},
+}
+
+func TestLocalChatBackend(t *testing.T) {
+	backend, err := NewLocalChatBackend(testChatFixtures)
+	require.NoError(t, err)
+
+	t.Run("should answer with the first fixture matching the message", func(t *testing.T) {
+		result, err := backend.Send(context.Background(), ChatBackendRequest{ChatID: "chat", MessageID: "msg", Message: "HELLO there"})
+
+		require.NoError(t, err)
+		assert.Empty(t, result.RunURL)
+		require.NotNil(t, result.Reply)
+		assert.Equal(t, "Hi, you said HELLO there", result.Reply.Response)
+		require.Len(t, result.Reply.Artifacts, 1)
+		assert.Equal(t, "msg", result.Reply.Artifacts[0].Content.(map[string]interface{})["content"])
+		assert.Equal(t, "{{messageId}}", testChatFixtures[3].Artifacts[0].Content.(map[string]interface{})["content"], "the fixture is left as it is")
+	})
+
+	t.Run("should only use a fixture in its mode", func(t *testing.T) {
+		_, err := backend.Send(context.Background(), ChatBackendRequest{Message: "deploy it", Mode: "Chat"})
+		assert.Error(t, err)
+
+		result, err := backend.Send(context.Background(), ChatBackendRequest{Message: "deploy it", Mode: "Build"})
+		require.NoError(t, err)
+		assert.Equal(t, "Deploying", result.Reply.Response)
+	})
+
+	t.Run("should refuse a fixture that does not compile", func(t *testing.T) {
+		_, err := NewLocalChatBackend([]ChatFixture{{Match: "("}})
+		assert.Error(t, err)
+	})
+
+	t.Run("should answer every message with the default fixtures", func(t *testing.T) {
+		defaults, err := NewLocalChatBackend(DefaultChatFixtures)
+		require.NoError(t, err)
+
+		result, err := defaults.Send(context.Background(), ChatBackendRequest{Message: "anything at all"})
+		require.NoError(t, err)
+		assert.Equal(t, "You said: anything at all", result.Reply.Response)
+
+		result, err = defaults.Send(context.Background(), ChatBackendRequest{Message: "show me the options"})
+		require.NoError(t, err)
+		require.Len(t, result.Reply.Artifacts, 1)
+		assert.Equal(t, db.ActionArtifact, result.Reply.Artifacts[0].Type)
+	})
+}
+
+func TestLoadChatFixtures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"match":"ping","response":"pong"}]`), 0644))
+
+	fixtures, err := LoadChatFixtures(path)
+	require.NoError(t, err)
+	assert.Equal(t, []ChatFixture{{Match: "ping", Response: "pong"}}, fixtures)
+
+	_, err = LoadChatFixtures(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestStakworkChatBackend(t *testing.T) {
+	original := os.Getenv("SWWFKEY")
+	defer os.Setenv("SWWFKEY", original)
+
+	var sent StakworkChatPayload
+	backend := NewStakworkChatBackend(&http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			json.NewDecoder(req.Body).Decode(&sent)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"success": true, "data": {"project_id": 12345}}`)),
+				Header:     make(http.Header),
+			}, nil
+		}),
+	})
+
+	os.Setenv("SWWFKEY", "")
+	_, err := backend.Send(context.Background(), ChatBackendRequest{ChatID: "chat"})
+	assert.Error(t, err)
+
+	os.Setenv("SWWFKEY", "test-key")
+	result, err := backend.Send(context.Background(), ChatBackendRequest{ChatID: "chat", Vars: map[string]interface{}{"message": "hi"}})
+	require.NoError(t, err)
+	assert.Equal(t, "https://jobs.stakwork.com/admin/projects/12345", result.RunURL)
+	assert.Nil(t, result.Reply)
+	assert.Equal(t, 38842, sent.WorkflowID)
+}
+
+// newLocalChatHandler is a chat handler every workspace of which uses the
+// local backend with the test fixtures
+func newLocalChatHandler(t *testing.T, mockDb *dbMocks.Database) (*ChatHandler, chan ChatMessageArtifact) {
+	h := NewChatHandler(&http.Client{}, mockDb)
+	local, err := NewLocalChatBackend(testChatFixtures)
+	require.NoError(t, err)
+	h.backends[LocalChatBackendName] = local
+
+	started := make(chan ChatMessageArtifact, 1)
+	h.startSSEClient = func(ctx context.Context, database db.Database, artifact ChatMessageArtifact, chatID string) {
+		started <- artifact
+	}
+
+	mockDb.On("GetChatWorkflowByWorkspaceID", "workspace").Return(&db.ChatWorkflow{WorkspaceID: "workspace", Backend: LocalChatBackendName}, nil)
+	return h, started
+}
+
+func sendLocalMessage(t *testing.T, h *ChatHandler, mockDb *dbMocks.Database, chatID string, message string) *httptest.ResponseRecorder {
+	mockDb.On("GetPersonByPubkey", "pubkey").Return(db.Person{OwnerPubKey: "pubkey", OwnerAlias: "alias"}).Once()
+	mockDb.On("GetProductBrief", "workspace").Return("brief", nil).Once()
+	mockDb.On("GetChatBranch", chatID, "").Return([]db.ChatMessage{}, nil).Once()
+	mockDb.On("GetChatMessagesForChatID", chatID).Return([]db.ChatMessage{}, nil).Maybe()
+	mockDb.On("GetCodeGraphByWorkspaceUuid", "workspace").Return(db.WorkspaceCodeGraph{}, gorm.ErrRecordNotFound).Once()
+	mockDb.On("GetCodeSpaceMapByWorkspaceAndUser", "workspace", "pubkey").Return(db.CodeSpaceMap{}, gorm.ErrRecordNotFound).Once()
+	mockDb.On("AddChatMessage", mock.MatchedBy(func(m *db.ChatMessage) bool {
+		return m.Role == "user" && m.Message == message
+	})).Return(func(m *db.ChatMessage) db.ChatMessage { return *m }, nil).Once()
+
+	payload, _ := json.Marshal(SendMessageRequest{ChatID: chatID, Message: message, WorkspaceUUID: "workspace", SourceWebsocketID: "ws"})
+	req := httptest.NewRequest(http.MethodPost, "/hivechat/send", bytes.NewReader(payload))
+	req = req.WithContext(context.WithValue(req.Context(), auth.ContextKey, "pubkey"))
+
+	rr := httptest.NewRecorder()
+	h.SendMessage(rr, req)
+	return rr
+}
+
+func TestSendMessageToLocalBackend(t *testing.T) {
+	t.Run("should save the scripted reply and its artifacts", func(t *testing.T) {
+		published := chatEvents(t)
+		mockDb := dbMocks.NewDatabase(t)
+		h, _ := newLocalChatHandler(t, mockDb)
+
+		mockDb.On("GetChatMessageStream", mock.Anything, mock.Anything).Return(db.ChatMessage{}, fmt.Errorf("not found: %w", gorm.ErrRecordNotFound)).Once()
+		mockDb.On("AddChatMessage", mock.MatchedBy(func(m *db.ChatMessage) bool {
+			return m.Role == "assistant" && m.Message == "Hi, you said hello" && m.ParentID != ""
+		})).Return(func(m *db.ChatMessage) db.ChatMessage { return *m }, nil).Once()
+		mockDb.On("CreateArtifact", mock.MatchedBy(func(artifact *db.Artifact) bool {
+			return artifact.Type == db.TextArtifact
+		})).Return(func(artifact *db.Artifact) *db.Artifact { return artifact }, nil).Once()
+
+		rr := sendLocalMessage(t, h, mockDb, "chat-local", "hello")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		events := published("chat-local")
+		require.Len(t, events, 2)
+		assert.Equal(t, "chat_message", events[0].Event)
+		assert.Equal(t, "chat_message", events[1].Event)
+		reply, _ := json.Marshal(events[1].Data)
+		assert.Contains(t, string(reply), `"Hi, you said hello"`)
+		assert.Contains(t, string(reply), `"artifacts":[{`)
+	})
+
+	t.Run("should stream a reply with chunks", func(t *testing.T) {
+		published := chatEvents(t)
+		mockDb := dbMocks.NewDatabase(t)
+		h, _ := newLocalChatHandler(t, mockDb)
+
+		reply := db.ChatMessage{ID: "reply", ChatID: "chat-stream", Role: db.AssistantRole, Status: db.SendingStatus}
+		mockDb.On("StartChatMessageStream", mock.MatchedBy(func(m *db.ChatMessage) bool {
+			return m.ChatID == "chat-stream" && m.Role == db.AssistantRole && m.Status == db.SendingStatus
+		})).Return(reply, true, nil).Once()
+		mockDb.On("StartChatMessageStream", mock.Anything).Return(reply, false, nil).Once()
+		mockDb.On("AppendChatMessageChunk", "reply", 0, "Hel").Return(true, nil).Once()
+		mockDb.On("AppendChatMessageChunk", "reply", 1, "lo").Return(true, nil).Once()
+		mockDb.On("FinishChatMessageStream", "reply", "").Return(db.ChatMessage{ID: "reply", ChatID: "chat-stream", Message: "Hello", Status: db.SentStatus}, nil).Once()
+
+		rr := sendLocalMessage(t, h, mockDb, "chat-stream", "stream please")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var names []string
+		for _, event := range published("chat-stream") {
+			names = append(names, event.Event)
+		}
+		assert.Equal(t, []string{"chat_message", "chat_message", "chat_message_chunk", "chat_message_chunk", "chat_message_done"}, names)
+	})
+
+	t.Run("should start the SSE client of a connection artifact", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h, started := newLocalChatHandler(t, mockDb)
+
+		mockDb.On("GetChatMessageStream", mock.Anything, mock.Anything).Return(db.ChatMessage{}, fmt.Errorf("not found: %w", gorm.ErrRecordNotFound)).Once()
+		mockDb.On("AddChatMessage", mock.MatchedBy(func(m *db.ChatMessage) bool {
+			return m.Role == "assistant"
+		})).Return(func(m *db.ChatMessage) db.ChatMessage { return *m }, nil).Once()
+		mockDb.On("CreateArtifact", mock.Anything).Return(func(artifact *db.Artifact) *db.Artifact { return artifact }, nil).Once()
+
+		rr := sendLocalMessage(t, h, mockDb, "chat-sse", "watch the deploy")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		artifact := <-started
+		assert.Equal(t, db.SSEArtifact, artifact.Type)
+		assert.Equal(t, "http://events.local/chat-sse", artifact.Content.(map[string]interface{})["sse_url"])
+		assert.Equal(t, "http://hooks.local/workspace", artifact.Content.(map[string]interface{})["webhook_url"])
+	})
+
+	t.Run("should mark the message as failed when no fixture matches", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h, _ := newLocalChatHandler(t, mockDb)
+
+		mockDb.On("UpdateChatMessage", mock.MatchedBy(func(m *db.ChatMessage) bool {
+			return m.Status == "error"
+		})).Return(db.ChatMessage{}, nil).Once()
+
+		rr := sendLocalMessage(t, h, mockDb, "chat-none", "nothing scripted")
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestChatBackendChoice(t *testing.T) {
+	mockDb := dbMocks.NewDatabase(t)
+	h := NewChatHandler(&http.Client{}, mockDb)
+
+	mockDb.On("GetChatWorkflowByWorkspaceID", "local").Return(&db.ChatWorkflow{Backend: LocalChatBackendName}, nil)
+	mockDb.On("GetChatWorkflowByWorkspaceID", "unset").Return(&db.ChatWorkflow{}, nil)
+	mockDb.On("GetChatWorkflowByWorkspaceID", "missing").Return(nil, fmt.Errorf("chat workflow not found for workspace: missing"))
+	mockDb.On("GetChatWorkflowByWorkspaceID", "unknown").Return(&db.ChatWorkflow{Backend: "carrier-pigeon"}, nil)
+
+	backend, err := h.chatBackend("local")
+	require.NoError(t, err)
+	assert.Equal(t, LocalChatBackendName, backend.Name())
+
+	for _, workspace := range []string{"unset", "missing"} {
+		backend, err = h.chatBackend(workspace)
+		require.NoError(t, err)
+		assert.Equal(t, StakworkChatBackendName, backend.Name())
+	}
+
+	_, err = h.chatBackend("unknown")
+	assert.Error(t, err)
+}
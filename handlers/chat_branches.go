@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+	"gorm.io/gorm"
+)
+
+type ChatBranchesResponse struct {
+	Success         bool          `json:"success"`
+	Message         string        `json:"message,omitempty"`
+	ActiveMessageID string        `json:"activeMessageId"`
+	Forks           []db.ChatFork `json:"forks"`
+}
+
+type SwitchChatBranchRequest struct {
+	MessageID string `json:"messageId"`
+}
+
+// chatForBranches returns the chat the branches of the request are asked
+// for, having written the error response when there is none
+func (ch *ChatHandler) chatForBranches(w http.ResponseWriter, r *http.Request) (db.Chat, bool) {
+	ctx := r.Context()
+	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
+	if pubKeyFromAuth == "" {
+		logger.FromContext(ctx).Info("no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return db.Chat{}, false
+	}
+
+	chatID := chi.URLParam(r, "chat_id")
+	if chatID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ChatResponse{
+			Success: false,
+			Message: "Chat ID is required",
+		})
+		return db.Chat{}, false
+	}
+
+	chat, err := ch.db.GetChatByChatID(chatID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ChatResponse{
+			Success: false,
+			Message: "Chat not found",
+		})
+		return db.Chat{}, false
+	}
+
+	if !auth.APIKeyAllowsWorkspace(ctx, chat.WorkspaceID) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ChatResponse{
+			Success: false,
+			Message: "API key does not have access to this workspace",
+		})
+		return db.Chat{}, false
+	}
+
+	return chat, true
+}
+
+// GetChatBranches lists the branches of a chat
+//
+//	@Summary		List chat branches
+//	@Description	List the points of a chat where editing a message started another branch, with a preview of the first message of every branch and the message it ends with. The branch shown in the chat is marked active.
+//	@Tags			Hive Chat
+//	@Produce		json
+//	@Security		PubKeyContextAuth
+//	@Param			chat_id	path		string	true	"Chat ID"
+//	@Success		200		{object}	ChatBranchesResponse
+//	@Failure		400		{object}	ChatResponse
+//	@Failure		401		{object}	ChatResponse
+//	@Failure		403		{object}	ChatResponse
+//	@Failure		404		{object}	ChatResponse
+//	@Failure		500		{object}	ChatResponse
+//	@Router			/hivechat/{chat_id}/branches [get]
+func (ch *ChatHandler) GetChatBranches(w http.ResponseWriter, r *http.Request) {
+	chat, ok := ch.chatForBranches(w, r)
+	if !ok {
+		return
+	}
+
+	messages, err := ch.db.GetChatMessagesForChatID(chat.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ChatResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to fetch chat messages: %v", err),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ChatBranchesResponse{
+		Success:         true,
+		ActiveMessageID: chat.ActiveMessageID,
+		Forks:           db.ChatForks(messages, chat.ActiveMessageID),
+	})
+}
+
+// SwitchChatBranch shows another branch of a chat
+//
+//	@Summary		Switch chat branch
+//	@Description	Make the branch going through the given message the active branch of a chat, down to its latest message, and return the messages of that branch. Messages sent afterwards continue it.
+//	@Tags			Hive Chat
+//	@Accept			json
+//	@Produce		json
+//	@Security		PubKeyContextAuth
+//	@Param			chat_id	path		string					true	"Chat ID"
+//	@Param			request	body		SwitchChatBranchRequest	true	"Message on the branch"
+//	@Success		200		{object}	HistoryChatResponse
+//	@Failure		400		{object}	ChatResponse
+//	@Failure		401		{object}	ChatResponse
+//	@Failure		403		{object}	ChatResponse
+//	@Failure		404		{object}	ChatResponse
+//	@Failure		500		{object}	ChatResponse
+//	@Router			/hivechat/{chat_id}/branch [put]
+func (ch *ChatHandler) SwitchChatBranch(w http.ResponseWriter, r *http.Request) {
+	chat, ok := ch.chatForBranches(w, r)
+	if !ok {
+		return
+	}
+
+	var request SwitchChatBranchRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.MessageID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ChatResponse{
+			Success: false,
+			Message: "messageId is required",
+		})
+		return
+	}
+
+	leaf, err := ch.db.SwitchChatBranch(chat.ID, request.MessageID)
+	if errors.Is(err, db.ErrChatMessageNotFound) || errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ChatResponse{
+			Success: false,
+			Message: "Message not found in chat",
+		})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ChatResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to switch branch: %v", err),
+		})
+		return
+	}
+
+	branch, err := ch.db.GetChatBranch(chat.ID, leaf.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ChatResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to fetch chat history: %v", err),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(HistoryChatResponse{
+		Success: true,
+		Data:    branch,
+	})
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/db"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// recordingChatBackend keeps the requests it is sent and does not answer
+type recordingChatBackend struct {
+	requests []ChatBackendRequest
+}
+
+func (b *recordingChatBackend) Name() string { return LocalChatBackendName }
+
+func (b *recordingChatBackend) Send(ctx context.Context, request ChatBackendRequest) (ChatBackendResult, error) {
+	b.requests = append(b.requests, request)
+	return ChatBackendResult{}, nil
+}
+
+var testChatBranch = []db.ChatMessage{
+	{ID: "q1", ChatID: "chat", Message: "first question", Role: db.UserRole},
+	{ID: "a1", ChatID: "chat", ParentID: "q1", Message: "first answer", Role: db.AssistantRole},
+	{ID: "q2", ChatID: "chat", ParentID: "a1", Message: "second question", Role: db.UserRole},
+}
+
+func TestSendMessageBranches(t *testing.T) {
+	newHandler := func(t *testing.T) (*ChatHandler, *dbMocks.Database, *recordingChatBackend) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+		backend := &recordingChatBackend{}
+		h.backends[LocalChatBackendName] = backend
+
+		mockDb.On("GetPersonByPubkey", "pubkey").Return(db.Person{OwnerPubKey: "pubkey"}).Once()
+		mockDb.On("GetProductBrief", "workspace").Return("brief", nil).Once()
+		return h, mockDb, backend
+	}
+	expectSend := func(mockDb *dbMocks.Database) {
+		mockDb.On("GetArtifactsByMessageID", mock.Anything).Return([]db.Artifact{}, nil)
+		mockDb.On("GetCodeGraphByWorkspaceUuid", "workspace").Return(db.WorkspaceCodeGraph{}, gorm.ErrRecordNotFound).Once()
+		mockDb.On("GetCodeSpaceMapByWorkspaceAndUser", "workspace", "pubkey").Return(db.CodeSpaceMap{}, gorm.ErrRecordNotFound).Once()
+		mockDb.On("GetChatWorkflowByWorkspaceID", "workspace").Return(&db.ChatWorkflow{Backend: LocalChatBackendName}, nil).Once()
+	}
+	send := func(h *ChatHandler, request SendMessageRequest) *httptest.ResponseRecorder {
+		request.ChatID = "chat"
+		request.WorkspaceUUID = "workspace"
+		payload, _ := json.Marshal(request)
+		req := httptest.NewRequest(http.MethodPost, "/hivechat/send", bytes.NewReader(payload))
+		req = req.WithContext(context.WithValue(req.Context(), auth.ContextKey, "pubkey"))
+		rr := httptest.NewRecorder()
+		h.SendMessage(rr, req)
+		return rr
+	}
+
+	t.Run("should send the history of the active branch", func(t *testing.T) {
+		h, mockDb, backend := newHandler(t)
+		expectSend(mockDb)
+		mockDb.On("GetChatBranch", "chat", "").Return(testChatBranch, nil).Once()
+		mockDb.On("AddChatMessage", mock.MatchedBy(func(m *db.ChatMessage) bool {
+			return m.Message == "third question" && m.ParentID == ""
+		})).Return(func(m *db.ChatMessage) db.ChatMessage { return *m }, nil).Once()
+
+		rr := send(h, SendMessageRequest{Message: "third question"})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		require.Len(t, backend.requests, 1)
+		assert.Len(t, backend.requests[0].History, 3)
+	})
+
+	t.Run("should fork a branch from the edited message", func(t *testing.T) {
+		h, mockDb, backend := newHandler(t)
+		expectSend(mockDb)
+		mockDb.On("GetChatBranch", "chat", "q2").Return(testChatBranch, nil).Once()
+		mockDb.On("AddChatMessageBranch", mock.MatchedBy(func(m *db.ChatMessage) bool {
+			return m.Message == "second question, edited" && m.ParentID == "a1" && m.Role == db.UserRole
+		})).Return(func(m *db.ChatMessage) db.ChatMessage { return *m }, nil).Once()
+
+		rr := send(h, SendMessageRequest{Message: "second question, edited", EditMessageID: "q2"})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		require.Len(t, backend.requests, 1)
+		history := backend.requests[0].History
+		require.Len(t, history, 2)
+		assert.Contains(t, history[1]["content"], "first answer")
+	})
+
+	t.Run("should only edit messages of the user", func(t *testing.T) {
+		h, mockDb, _ := newHandler(t)
+		mockDb.On("GetChatBranch", "chat", "a1").Return(testChatBranch[:2], nil).Once()
+
+		rr := send(h, SendMessageRequest{Message: "edited", EditMessageID: "a1"})
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("should refuse to edit a message of another chat", func(t *testing.T) {
+		h, mockDb, _ := newHandler(t)
+		mockDb.On("GetChatBranch", "chat", "elsewhere").Return(nil, fmt.Errorf("message elsewhere of chat chat: %w", db.ErrChatMessageNotFound)).Once()
+
+		rr := send(h, SendMessageRequest{Message: "edited", EditMessageID: "elsewhere"})
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func branchRequest(method string, chatID string, body interface{}) *http.Request {
+	payload, _ := json.Marshal(body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("chat_id", chatID)
+	req := httptest.NewRequest(method, "/hivechat/"+chatID+"/branch", bytes.NewReader(payload))
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	return req.WithContext(context.WithValue(ctx, auth.ContextKey, "pubkey"))
+}
+
+func TestGetChatBranches(t *testing.T) {
+	t.Run("should list the forks of the chat", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+
+		messages := append([]db.ChatMessage{}, testChatBranch...)
+		messages = append(messages, db.ChatMessage{ID: "q2-edit", ChatID: "chat", ParentID: "a1", Message: "edited", Role: db.UserRole})
+		mockDb.On("GetChatByChatID", "chat").Return(db.Chat{ID: "chat", WorkspaceID: "workspace", ActiveMessageID: "q2-edit"}, nil).Once()
+		mockDb.On("GetChatMessagesForChatID", "chat").Return(messages, nil).Once()
+
+		rr := httptest.NewRecorder()
+		h.GetChatBranches(rr, branchRequest(http.MethodGet, "chat", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response ChatBranchesResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "q2-edit", response.ActiveMessageID)
+		require.Len(t, response.Forks, 1)
+		assert.Equal(t, "a1", response.Forks[0].ParentID)
+		require.Len(t, response.Forks[0].Branches, 2)
+		assert.False(t, response.Forks[0].Branches[0].Active)
+		assert.True(t, response.Forks[0].Branches[1].Active)
+	})
+
+	t.Run("should return 404 for an unknown chat", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+		mockDb.On("GetChatByChatID", "missing").Return(db.Chat{}, gorm.ErrRecordNotFound).Once()
+
+		rr := httptest.NewRecorder()
+		h.GetChatBranches(rr, branchRequest(http.MethodGet, "missing", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestSwitchChatBranch(t *testing.T) {
+	t.Run("should return the messages of the branch switched to", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+		mockDb.On("GetChatByChatID", "chat").Return(db.Chat{ID: "chat", WorkspaceID: "workspace"}, nil).Once()
+		mockDb.On("SwitchChatBranch", "chat", "q1").Return(testChatBranch[2], nil).Once()
+		mockDb.On("GetChatBranch", "chat", "q2").Return(testChatBranch, nil).Once()
+
+		rr := httptest.NewRecorder()
+		h.SwitchChatBranch(rr, branchRequest(http.MethodPut, "chat", SwitchChatBranchRequest{MessageID: "q1"}))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response struct {
+			Data []db.ChatMessage `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response.Data, 3)
+	})
+
+	t.Run("should refuse a message of another chat", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+		mockDb.On("GetChatByChatID", "chat").Return(db.Chat{ID: "chat", WorkspaceID: "workspace"}, nil).Once()
+		mockDb.On("SwitchChatBranch", "chat", "elsewhere").Return(db.ChatMessage{}, fmt.Errorf("message elsewhere of chat chat: %w", db.ErrChatMessageNotFound)).Once()
+
+		rr := httptest.NewRecorder()
+		h.SwitchChatBranch(rr, branchRequest(http.MethodPut, "chat", SwitchChatBranchRequest{MessageID: "elsewhere"}))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("should require a message", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+		mockDb.On("GetChatByChatID", "chat").Return(db.Chat{ID: "chat", WorkspaceID: "workspace"}, nil).Once()
+
+		rr := httptest.NewRecorder()
+		h.SwitchChatBranch(rr, branchRequest(http.MethodPut, "chat", SwitchChatBranchRequest{}))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
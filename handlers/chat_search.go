@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+)
+
+type ChatSearchResponse struct {
+	Success bool                  `json:"success"`
+	Message string                `json:"message,omitempty"`
+	Results []db.ChatSearchResult `json:"results"`
+	Total   int64                 `json:"total"`
+	Limit   int                   `json:"limit"`
+	Offset  int                   `json:"offset"`
+}
+
+// chatMessageLink opens the chat at the message in the app
+func chatMessageLink(workspaceID string, chatID string, messageID string) string {
+	return fmt.Sprintf("%s/workspace/%s/hivechat/%s?message=%s",
+		os.Getenv("HOST"), url.PathEscape(workspaceID), url.PathEscape(chatID), url.QueryEscape(messageID))
+}
+
+// parseSearchTime reads a time as RFC 3339 or as a date, a date ending a
+// range takes in the whole day
+func parseSearchTime(value string, end bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if end {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
+// SearchChatHistory searches the chat history of a workspace
+//
+//	@Summary		Search chat history
+//	@Description	Full-text search over the messages and the artifacts of every chat of a workspace, archived chats included, for the owner and the members of the workspace. Results are ranked by relevance and carry a snippet of the matching text and a link to the message.
+//	@Tags			Hive Chat
+//	@Produce		json
+//	@Security		PubKeyContextAuth
+//	@Param			workspace_id	query		string	true	"Workspace ID"
+//	@Param			q				query		string	true	"Search terms, quoted phrases and -exclusions are supported"
+//	@Param			chat_id			query		string	false	"Only search this chat"
+//	@Param			role			query		string	false	"user or assistant"
+//	@Param			artifact_type	query		string	false	"Only search artifacts of this type"
+//	@Param			from			query		string	false	"Earliest time, RFC 3339 or a date"
+//	@Param			to				query		string	false	"Latest time, RFC 3339 or a date"
+//	@Param			limit			query		int		false	"Page size, 20 by default"
+//	@Param			offset			query		int		false	"Page offset"
+//	@Success		200				{object}	ChatSearchResponse
+//	@Failure		400				{object}	ChatSearchResponse
+//	@Failure		401				{object}	ChatSearchResponse
+//	@Failure		403				{object}	ChatSearchResponse
+//	@Failure		404				{object}	ChatSearchResponse
+//	@Failure		500				{object}	ChatSearchResponse
+//	@Router			/hivechat/search [get]
+func (ch *ChatHandler) SearchChatHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
+	if pubKeyFromAuth == "" {
+		logger.FromContext(ctx).Info("no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	badRequest := func(message string) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ChatSearchResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+
+	params := db.ChatSearchParams{
+		WorkspaceID:  query.Get("workspace_id"),
+		Query:        query.Get("q"),
+		ChatID:       query.Get("chat_id"),
+		Role:         db.ChatRole(query.Get("role")),
+		ArtifactType: db.ArtifactType(query.Get("artifact_type")),
+		Limit:        20,
+	}
+
+	if params.WorkspaceID == "" || params.Query == "" {
+		badRequest("workspace_id and q are required")
+		return
+	}
+	if !auth.APIKeyAllowsWorkspace(ctx, params.WorkspaceID) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ChatSearchResponse{
+			Success: false,
+			Message: "API key does not have access to this workspace",
+		})
+		return
+	}
+
+	switch params.Role {
+	case "", db.UserRole, db.AssistantRole:
+	default:
+		badRequest("role must be user or assistant")
+		return
+	}
+	switch params.ArtifactType {
+	case "", db.TextArtifact, db.VisualArtifact, db.ActionArtifact, db.SSEArtifact:
+	default:
+		badRequest(fmt.Sprintf("unknown artifact type %q", params.ArtifactType))
+		return
+	}
+
+	var err error
+	if params.From, err = parseSearchTime(query.Get("from"), false); err != nil {
+		badRequest("from must be an RFC 3339 time or a date")
+		return
+	}
+	if params.To, err = parseSearchTime(query.Get("to"), true); err != nil {
+		badRequest("to must be an RFC 3339 time or a date")
+		return
+	}
+
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= 100 {
+		params.Limit = l
+	}
+	if o, err := strconv.Atoi(query.Get("offset")); err == nil && o >= 0 {
+		params.Offset = o
+	}
+
+	// the chats of a workspace are read by its owner and its members
+	workspace := ch.db.GetWorkspaceByUuid(params.WorkspaceID)
+	if workspace.Uuid == "" {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ChatSearchResponse{
+			Success: false,
+			Message: "Workspace not found",
+		})
+		return
+	}
+	if workspace.OwnerPubKey != pubKeyFromAuth && ch.db.GetWorkspaceUser(pubKeyFromAuth, params.WorkspaceID).OwnerPubKey != pubKeyFromAuth {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ChatSearchResponse{
+			Success: false,
+			Message: "Not a member of this workspace",
+		})
+		return
+	}
+
+	results, total, err := ch.db.SearchChatHistory(params)
+	if err != nil {
+		logger.FromContext(ctx).Error("Failed to search chat history of workspace %s: %v", params.WorkspaceID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ChatSearchResponse{
+			Success: false,
+			Message: "Failed to search chat history",
+		})
+		return
+	}
+
+	for i := range results {
+		results[i].Link = chatMessageLink(params.WorkspaceID, results[i].ChatID, results[i].MessageID)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ChatSearchResponse{
+		Success: true,
+		Results: results,
+		Total:   total,
+		Limit:   params.Limit,
+		Offset:  params.Offset,
+	})
+}
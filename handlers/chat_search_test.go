@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/db"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchChatHistory(t *testing.T) {
+	member := func(mockDb *dbMocks.Database) {
+		mockDb.On("GetWorkspaceByUuid", "workspace").Return(db.Workspace{Uuid: "workspace", OwnerPubKey: "owner"}).Once()
+		mockDb.On("GetWorkspaceUser", "pubkey", "workspace").Return(db.WorkspaceUsers{OwnerPubKey: "pubkey", WorkspaceUuid: "workspace"}).Once()
+	}
+
+	search := func(h *ChatHandler, pubKey string, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/hivechat/search?"+query, nil)
+		if pubKey != "" {
+			req = req.WithContext(context.WithValue(req.Context(), auth.ContextKey, pubKey))
+		}
+		rr := httptest.NewRecorder()
+		h.SearchChatHistory(rr, req)
+		return rr
+	}
+
+	t.Run("should return ranked results with links to the messages", func(t *testing.T) {
+		t.Setenv("HOST", "https://community.sphinx.chat")
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+
+		member(mockDb)
+		mockDb.On("SearchChatHistory", mock.MatchedBy(func(params db.ChatSearchParams) bool {
+			return params.WorkspaceID == "workspace" && params.Query == "rate limit" &&
+				params.Role == db.AssistantRole && params.ChatID == "chat" &&
+				params.Limit == 5 && params.Offset == 10 &&
+				params.From.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) &&
+				params.To.Equal(time.Date(2026, 2, 1, 23, 59, 59, int(time.Second-time.Nanosecond), time.UTC))
+		})).Return([]db.ChatSearchResult{
+			{Kind: db.MessageSearchResult, ChatID: "chat", MessageID: "msg-1", Snippet: "the <mark>rate</mark> <mark>limit</mark>", Rank: 0.9},
+			{Kind: db.ArtifactSearchResult, ChatID: "chat", MessageID: "msg-2", ChatStatus: db.ArchiveStatus, Rank: 0.4},
+		}, int64(12), nil).Once()
+
+		rr := search(h, "pubkey", "workspace_id=workspace&q=rate+limit&chat_id=chat&role=assistant&from=2026-01-01&to=2026-02-01&limit=5&offset=10")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response ChatSearchResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, int64(12), response.Total)
+		require.Len(t, response.Results, 2)
+		assert.Equal(t, "https://community.sphinx.chat/workspace/workspace/hivechat/chat?message=msg-1", response.Results[0].Link)
+		assert.Equal(t, "https://community.sphinx.chat/workspace/workspace/hivechat/chat?message=msg-2", response.Results[1].Link)
+	})
+
+	t.Run("should only search artifacts of the type asked for", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+
+		member(mockDb)
+		mockDb.On("SearchChatHistory", mock.MatchedBy(func(params db.ChatSearchParams) bool {
+			return params.ArtifactType == db.TextArtifact && params.Limit == 20 && params.From == nil && params.To == nil
+		})).Return([]db.ChatSearchResult{}, int64(0), nil).Once()
+
+		rr := search(h, "pubkey", "workspace_id=workspace&q=deploy&artifact_type=text")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("should refuse invalid filters", func(t *testing.T) {
+		h := NewChatHandler(&http.Client{}, dbMocks.NewDatabase(t))
+
+		for _, query := range []string{
+			"q=deploy",
+			"workspace_id=workspace",
+			"workspace_id=workspace&q=deploy&role=system",
+			"workspace_id=workspace&q=deploy&artifact_type=audio",
+			"workspace_id=workspace&q=deploy&from=yesterday",
+			"workspace_id=workspace&q=deploy&to=2026-13-01",
+		} {
+			assert.Equal(t, http.StatusBadRequest, search(h, "pubkey", query).Code, query)
+		}
+	})
+
+	t.Run("should refuse a request without a pubkey", func(t *testing.T) {
+		h := NewChatHandler(&http.Client{}, dbMocks.NewDatabase(t))
+
+		assert.Equal(t, http.StatusUnauthorized, search(h, "", "workspace_id=workspace&q=deploy").Code)
+	})
+
+	t.Run("should let the owner search without a membership", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+
+		mockDb.On("GetWorkspaceByUuid", "workspace").Return(db.Workspace{Uuid: "workspace", OwnerPubKey: "pubkey"}).Once()
+		mockDb.On("SearchChatHistory", mock.Anything).Return([]db.ChatSearchResult{}, int64(0), nil).Once()
+
+		assert.Equal(t, http.StatusOK, search(h, "pubkey", "workspace_id=workspace&q=deploy").Code)
+	})
+
+	t.Run("should refuse a pubkey that is not a member", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+
+		mockDb.On("GetWorkspaceByUuid", "workspace").Return(db.Workspace{Uuid: "workspace", OwnerPubKey: "owner"}).Once()
+		mockDb.On("GetWorkspaceUser", "pubkey", "workspace").Return(db.WorkspaceUsers{}).Once()
+
+		assert.Equal(t, http.StatusForbidden, search(h, "pubkey", "workspace_id=workspace&q=deploy").Code)
+		mockDb.AssertNotCalled(t, "SearchChatHistory", mock.Anything)
+	})
+
+	t.Run("should report an unknown workspace", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+
+		mockDb.On("GetWorkspaceByUuid", "workspace").Return(db.Workspace{}).Once()
+
+		assert.Equal(t, http.StatusNotFound, search(h, "pubkey", "workspace_id=workspace&q=deploy").Code)
+	})
+
+	t.Run("should report a failed search", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+
+		member(mockDb)
+		mockDb.On("SearchChatHistory", mock.Anything).Return(nil, int64(0), errors.New("connection refused")).Once()
+
+		assert.Equal(t, http.StatusInternalServerError, search(h, "pubkey", "workspace_id=workspace&q=deploy").Code)
+	})
+}
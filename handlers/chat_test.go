@@ -22,9 +22,11 @@ import (
 	"github.com/google/uuid"
 	"github.com/stakwork/sphinx-tribes/auth"
 	"github.com/stakwork/sphinx-tribes/db"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
 	"github.com/stakwork/sphinx-tribes/sse"
 	"github.com/stakwork/sphinx-tribes/websocket"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -3314,3 +3316,289 @@ type RoundTripFunc func(req *http.Request) (*http.Response, error)
 func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return f(req)
 }
+
+// chatEvents records the events published to the chat topics during a test
+func chatEvents(t *testing.T) func(chatID string) []websocket.TopicEvent {
+	events := websocket.NewMemoryEventLog()
+	websocket.WebsocketPool.UseEventLog(events)
+	t.Cleanup(func() { websocket.WebsocketPool.UseEventLog(nil) })
+
+	return func(chatID string) []websocket.TopicEvent {
+		logged, _, err := events.Since(context.Background(), websocket.ChatTopic(chatID), 0)
+		require.NoError(t, err)
+
+		published := []websocket.TopicEvent{}
+		for _, event := range logged {
+			var topicEvent websocket.TopicEvent
+			require.NoError(t, json.Unmarshal(event.Payload, &topicEvent))
+			published = append(published, topicEvent)
+		}
+		return published
+	}
+}
+
+func postChunk(handler http.HandlerFunc, body interface{}) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/hivechat/response/stream", bytes.NewReader(payload))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	return rr
+}
+
+func TestProcessChatResponseChunk(t *testing.T) {
+	t.Run("should create the reply with the first chunk and push the chunk", func(t *testing.T) {
+		published := chatEvents(t)
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+		chatID := uuid.New().String()
+
+		mockDb.On("StartChatMessageStream", mock.MatchedBy(func(message *db.ChatMessage) bool {
+			return message.ChatID == chatID && message.ParentID == "user-message" && message.Role == db.AssistantRole && message.Status == db.SendingStatus
+		})).Return(func(message *db.ChatMessage) db.ChatMessage { return *message }, true, nil).Once()
+		mockDb.On("AppendChatMessageChunk", mock.Anything, 0, "Hel").Return(true, nil).Once()
+
+		rr := postChunk(h.ProcessChatResponseChunk, ChatResponseChunkRequest{ChatID: chatID, MessageID: "user-message", Index: 0, Chunk: "Hel"})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		events := published(chatID)
+		require.Len(t, events, 2)
+		assert.Equal(t, "chat_message", events[0].Event)
+		assert.Equal(t, "chat_message_chunk", events[1].Event)
+		chunk, _ := json.Marshal(events[1].Data)
+		assert.Contains(t, string(chunk), `"chunk":"Hel"`)
+		assert.Contains(t, string(chunk), `"parentId":"user-message"`)
+	})
+
+	t.Run("should not push a chunk that was appended before", func(t *testing.T) {
+		published := chatEvents(t)
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+		chatID := uuid.New().String()
+		reply := db.ChatMessage{ID: "reply", ChatID: chatID, Status: db.SendingStatus, ParentID: "user-message"}
+
+		mockDb.On("StartChatMessageStream", mock.Anything).Return(reply, false, nil).Once()
+		mockDb.On("AppendChatMessageChunk", "reply", 0, "Hel").Return(false, nil).Once()
+
+		rr := postChunk(h.ProcessChatResponseChunk, ChatResponseChunkRequest{ChatID: chatID, MessageID: "user-message", Index: 0, Chunk: "Hel"})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, published(chatID))
+	})
+
+	t.Run("should refuse a chunk ahead of the stream", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+		reply := db.ChatMessage{ID: "reply", Status: db.SendingStatus}
+
+		mockDb.On("StartChatMessageStream", mock.Anything).Return(reply, false, nil).Once()
+		mockDb.On("AppendChatMessageChunk", "reply", 3, "lo").Return(false, db.ErrChunkOutOfOrder).Once()
+
+		rr := postChunk(h.ProcessChatResponseChunk, ChatResponseChunkRequest{ChatID: "chat", MessageID: "user-message", Index: 3, Chunk: "lo"})
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("should complete the reply with its artifacts", func(t *testing.T) {
+		published := chatEvents(t)
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+		chatID := uuid.New().String()
+		reply := db.ChatMessage{ID: "reply", ChatID: chatID, Status: db.SendingStatus, ParentID: "user-message"}
+		finished := reply
+		finished.Message = "Hello"
+		finished.Status = db.SentStatus
+
+		mockDb.On("StartChatMessageStream", mock.Anything).Return(reply, false, nil).Once()
+		mockDb.On("AppendChatMessageChunk", "reply", 1, "lo").Return(true, nil).Once()
+		mockDb.On("FinishChatMessageStream", "reply", "").Return(finished, nil).Once()
+		mockDb.On("CreateArtifact", mock.MatchedBy(func(artifact *db.Artifact) bool {
+			return artifact.MessageID == "reply" && artifact.Type == db.TextArtifact
+		})).Return(func(artifact *db.Artifact) *db.Artifact { return artifact }, nil).Once()
+
+		rr := postChunk(h.ProcessChatResponseChunk, ChatResponseChunkRequest{
+			ChatID:    chatID,
+			MessageID: "user-message",
+			Index:     1,
+			Chunk:     "lo",
+			Done:      true,
+			Artifacts: []ChatMessageArtifact{{Type: db.TextArtifact, Content: map[string]interface{}{"text": "notes"}}},
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response ChatResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response.Artifacts, 1)
+
+		events := published(chatID)
+		require.Len(t, events, 2)
+		assert.Equal(t, "chat_message_chunk", events[0].Event)
+		assert.Equal(t, "chat_message_done", events[1].Event)
+		done, _ := json.Marshal(events[1].Data)
+		assert.Contains(t, string(done), `"status":"sent"`)
+		assert.Contains(t, string(done), `"notes"`)
+	})
+
+	t.Run("should accept a repeated final chunk", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+		reply := db.ChatMessage{ID: "reply", Status: db.SentStatus}
+
+		mockDb.On("StartChatMessageStream", mock.Anything).Return(reply, false, nil).Once()
+		mockDb.On("FinishChatMessageStream", "reply", "").Return(db.ChatMessage{}, db.ErrMessageNotStreaming).Once()
+
+		rr := postChunk(h.ProcessChatResponseChunk, ChatResponseChunkRequest{ChatID: "chat", MessageID: "user-message", Index: 2, Done: true})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("should refuse a message of another chat", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewChatHandler(&http.Client{}, mockDb)
+
+		mockDb.On("StartChatMessageStream", mock.Anything).Return(db.ChatMessage{}, false, fmt.Errorf("message user-message of chat chat: %w", db.ErrChatMessageNotFound)).Once()
+
+		rr := postChunk(h.ProcessChatResponseChunk, ChatResponseChunkRequest{ChatID: "chat", MessageID: "user-message", Index: 0, Chunk: "Hel"})
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockDb.AssertNotCalled(t, "AppendChatMessageChunk", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should require the chat and the message", func(t *testing.T) {
+		h := NewChatHandler(&http.Client{}, dbMocks.NewDatabase(t))
+
+		rr := postChunk(h.ProcessChatResponseChunk, ChatResponseChunkRequest{ChatID: "chat", Chunk: "Hel"})
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestProcessChatResponseCompletesStreamedReply(t *testing.T) {
+	mockDb := dbMocks.NewDatabase(t)
+	h := NewChatHandler(&http.Client{}, mockDb)
+	reply := db.ChatMessage{ID: "reply", ChatID: "chat", Status: db.SendingStatus, ParentID: "user-message"}
+	finished := reply
+	finished.Message = "Hello there"
+	finished.Status = db.SentStatus
+
+	mockDb.On("GetChatMessageStream", "chat", "user-message").Return(reply, nil).Once()
+	mockDb.On("FinishChatMessageStream", "reply", "Hello there").Return(finished, nil).Once()
+
+	var request ChatResponseRequest
+	request.Value.ChatID = "chat"
+	request.Value.MessageID = "user-message"
+	request.Value.Response = "Hello there"
+	payload, _ := json.Marshal(request)
+
+	rr := httptest.NewRecorder()
+	h.ProcessChatResponse(rr, httptest.NewRequest(http.MethodPost, "/hivechat/response", bytes.NewReader(payload)))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockDb.AssertNotCalled(t, "AddChatMessage", mock.Anything)
+}
+
+func TestCreateOrEditChatWorkflow(t *testing.T) {
+	post := func(h *ChatHandler, pubKey string, body ChatWorkflowRequest) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/hivechat/workflow", bytes.NewReader(payload))
+		if pubKey != "" {
+			req = req.WithContext(context.WithValue(req.Context(), auth.ContextKey, pubKey))
+		}
+		rr := httptest.NewRecorder()
+		h.CreateOrEditChatWorkflow(rr, req)
+		return rr
+	}
+	workspace := db.Workspace{Uuid: "workspace-uuid", OwnerPubKey: "owner"}
+
+	tests := []struct {
+		name     string
+		pubKey   string
+		request  ChatWorkflowRequest
+		setup    func(mockDb *dbMocks.Database)
+		expected int
+	}{
+		{
+			name:     "should reject a request without auth",
+			request:  ChatWorkflowRequest{WorkspaceID: workspace.Uuid, URL: "https://workflow.example.com"},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:    "should forbid a user who is not an admin of the workspace",
+			pubKey:  "member",
+			request: ChatWorkflowRequest{WorkspaceID: workspace.Uuid, URL: "https://workflow.example.com"},
+			setup: func(mockDb *dbMocks.Database) {
+				mockDb.On("GetWorkspaceByUuid", workspace.Uuid).Return(workspace).Once()
+				mockDb.On("UserHasManageBountyRoles", "member", workspace.Uuid).Return(false).Once()
+			},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "should reject an unknown backend",
+			pubKey:   "owner",
+			request:  ChatWorkflowRequest{WorkspaceID: workspace.Uuid, URL: "https://workflow.example.com", Backend: "elsewhere"},
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:    "should save the workflow of a workspace admin",
+			pubKey:  "owner",
+			request: ChatWorkflowRequest{WorkspaceID: workspace.Uuid, URL: "https://workflow.example.com"},
+			setup: func(mockDb *dbMocks.Database) {
+				mockDb.On("GetWorkspaceByUuid", workspace.Uuid).Return(workspace).Once()
+				mockDb.On("UserHasManageBountyRoles", "owner", workspace.Uuid).Return(true).Once()
+				mockDb.On("CreateOrEditChatWorkflow", mock.MatchedBy(func(workflow *db.ChatWorkflow) bool {
+					return workflow.WorkspaceID == workspace.Uuid && workflow.URL == "https://workflow.example.com"
+				})).Return(&db.ChatWorkflow{WorkspaceID: workspace.Uuid}, nil).Once()
+			},
+			expected: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := dbMocks.NewDatabase(t)
+			if tt.setup != nil {
+				tt.setup(mockDb)
+			}
+
+			rr := post(NewChatHandler(&http.Client{}, mockDb), tt.pubKey, tt.request)
+
+			assert.Equal(t, tt.expected, rr.Code)
+		})
+	}
+}
+
+func TestDeleteChatWorkflow(t *testing.T) {
+	del := func(h *ChatHandler, pubKey string) *httptest.ResponseRecorder {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("workspaceId", "workspace-uuid")
+		ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+		if pubKey != "" {
+			ctx = context.WithValue(ctx, auth.ContextKey, pubKey)
+		}
+		req := httptest.NewRequest(http.MethodDelete, "/hivechat/chatworkflow/workspace-uuid", nil).WithContext(ctx)
+		rr := httptest.NewRecorder()
+		h.DeleteChatWorkflow(rr, req)
+		return rr
+	}
+
+	t.Run("should reject a request without auth", func(t *testing.T) {
+		rr := del(NewChatHandler(&http.Client{}, dbMocks.NewDatabase(t)), "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should forbid a user who is not an admin of the workspace", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockDb.On("UserHasManageBountyRoles", "member", "workspace-uuid").Return(false).Once()
+
+		rr := del(NewChatHandler(&http.Client{}, mockDb), "member")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("should delete the workflow of a workspace admin", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockDb.On("UserHasManageBountyRoles", "owner", "workspace-uuid").Return(true).Once()
+		mockDb.On("DeleteChatWorkflow", "workspace-uuid").Return(nil).Once()
+
+		rr := del(NewChatHandler(&http.Client{}, mockDb), "owner")
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
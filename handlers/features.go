@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"time"
 
@@ -79,7 +78,7 @@ func (oh *featureHandler) CreateOrEditFeatures(w http.ResponseWriter, r *http.Re
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -90,7 +89,7 @@ func (oh *featureHandler) CreateOrEditFeatures(w http.ResponseWriter, r *http.Re
 	err := json.Unmarshal(body, &features)
 
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -145,7 +144,7 @@ func (oh *featureHandler) DeleteFeature(w http.ResponseWriter, r *http.Request)
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -176,7 +175,7 @@ func (oh *featureHandler) GetFeaturesByWorkspaceUuid(w http.ResponseWriter, r *h
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -202,13 +201,13 @@ func (oh *featureHandler) GetWorkspaceFeaturesCount(w http.ResponseWriter, r *ht
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
 	if !utils.ValidateUUID(r) {
-		logger.Log.Info("invalid or missing uuid")
+		logger.FromContext(r.Context()).Info("invalid or missing uuid")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "invalid or missing uuid"})
 		return
@@ -216,7 +215,7 @@ func (oh *featureHandler) GetWorkspaceFeaturesCount(w http.ResponseWriter, r *ht
 
 	uuid := chi.URLParam(r, "uuid")
 	if uuid == "" {
-		logger.Log.Info("missing or empty uuid")
+		logger.FromContext(r.Context()).Info("missing or empty uuid")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "missing or empty uuid"})
 		return
@@ -243,7 +242,7 @@ func (oh *featureHandler) GetFeatureByUuid(w http.ResponseWriter, r *http.Reques
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -251,7 +250,7 @@ func (oh *featureHandler) GetFeatureByUuid(w http.ResponseWriter, r *http.Reques
 	uuid := chi.URLParam(r, "uuid")
 
 	if uuid == "" {
-		logger.Log.Info("missing uuid parameter")
+		logger.FromContext(r.Context()).Info("missing uuid parameter")
 		http.Error(w, "uuid parameter is required", http.StatusBadRequest)
 		return
 	}
@@ -277,7 +276,7 @@ func (oh *featureHandler) UpdateFeatureBrief(w http.ResponseWriter, r *http.Requ
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -352,7 +351,7 @@ func (oh *featureHandler) CreateOrEditFeaturePhase(w http.ResponseWriter, r *htt
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -411,7 +410,7 @@ func (oh *featureHandler) GetFeaturePhases(w http.ResponseWriter, r *http.Reques
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -437,14 +436,14 @@ func (oh *featureHandler) GetFeaturePhaseByUUID(w http.ResponseWriter, r *http.R
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
 	person := oh.db.GetPersonByPubkey(pubKeyFromAuth)
 	if person.OwnerPubKey != pubKeyFromAuth {
-		logger.Log.Info("Invalid pubkey from auth")
+		logger.FromContext(r.Context()).Info("Invalid pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -476,7 +475,7 @@ func (oh *featureHandler) DeleteFeaturePhase(w http.ResponseWriter, r *http.Requ
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -485,7 +484,7 @@ func (oh *featureHandler) DeleteFeaturePhase(w http.ResponseWriter, r *http.Requ
 	phaseUuid := chi.URLParam(r, "phase_uuid")
 
 	if !isValidUUID(featureUuid) || !isValidUUID(phaseUuid) {
-		logger.Log.Info("Malformed UUIDs")
+		logger.FromContext(r.Context()).Info("Malformed UUIDs")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Malformed UUIDs"})
 		return
@@ -516,7 +515,7 @@ func (oh *featureHandler) CreateOrEditStory(w http.ResponseWriter, r *http.Reque
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -567,14 +566,14 @@ func (oh *featureHandler) GetStoriesByFeatureUuid(w http.ResponseWriter, r *http
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
 	featureUuid := chi.URLParam(r, "feature_uuid")
 	if featureUuid == "" {
-		logger.Log.Info("empty feature uuid")
+		logger.FromContext(r.Context()).Info("empty feature uuid")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -606,7 +605,7 @@ func (oh *featureHandler) GetStoryByUuid(w http.ResponseWriter, r *http.Request)
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -635,7 +634,7 @@ func (oh *featureHandler) DeleteStory(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -671,7 +670,7 @@ func (oh *featureHandler) GetBountiesByFeatureAndPhaseUuid(w http.ResponseWriter
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -705,7 +704,7 @@ func (oh *featureHandler) GetBountiesCountByFeatureAndPhaseUuid(w http.ResponseW
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -735,20 +734,20 @@ func (oh *featureHandler) GetFeatureStories(w http.ResponseWriter, r *http.Reque
 
 	if err != nil {
 		w.WriteHeader(http.StatusNotAcceptable)
-		log.Printf("Error decoding request body: %v", err)
+		logger.FromContext(r.Context()).Error("Error decoding request body: %v", err)
 		return
 	}
 
-	log.Println("Webhook Feature Uuid", featureUuid)
+	logger.FromContext(r.Context()).Info("Webhook Feature Uuid %v", featureUuid)
 
-	log.Println("Webhook Feature Stories === ", featureStories.Output.Stories)
+	logger.FromContext(r.Context()).Info("Webhook Feature Stories === %v", featureStories.Output.Stories)
 
 	// check if feature story exists
 	feature := oh.db.GetFeatureByUuid(featureUuid)
 
 	if feature.ID == 0 {
 		msg := "Feature ID does not exists"
-		log.Println(msg, featureUuid)
+		logger.FromContext(r.Context()).Info("%v %v", msg, featureUuid)
 		w.WriteHeader(http.StatusNotAcceptable)
 		json.NewEncoder(w).Encode(msg)
 		return
@@ -768,7 +767,7 @@ func (oh *featureHandler) GetFeatureStories(w http.ResponseWriter, r *http.Reque
 		}
 
 		oh.db.CreateOrEditFeatureStory(featureStory)
-		log.Println("Created user story for : ", featureStory.FeatureUuid)
+		logger.FromContext(r.Context()).Info("Created user story for : %v", featureStory.FeatureUuid)
 	}
 
 	ticketMsg := websocket.TicketMessage{
@@ -779,7 +778,7 @@ func (oh *featureHandler) GetFeatureStories(w http.ResponseWriter, r *http.Reque
 	}
 
 	if err := websocket.WebsocketPool.SendTicketMessage(ticketMsg); err != nil {
-		log.Printf("Failed to send websocket message: %v", err)
+		logger.FromContext(r.Context()).Error("Failed to send websocket message: %v", err)
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode("Failed to send websocket message")
 		return
@@ -804,7 +803,7 @@ func (oh *featureHandler) StoriesSend(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -812,7 +811,7 @@ func (oh *featureHandler) StoriesSend(w http.ResponseWriter, r *http.Request) {
 	user := oh.db.GetPersonByPubkey(pubKeyFromAuth)
 
 	if user.OwnerPubKey != pubKeyFromAuth {
-		logger.Log.Info("Person not exists")
+		logger.FromContext(r.Context()).Info("Person not exists")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -827,7 +826,7 @@ func (oh *featureHandler) StoriesSend(w http.ResponseWriter, r *http.Request) {
 	var postData PostData
 	err = json.Unmarshal(body, &postData)
 	if err != nil {
-		logger.Log.Error("[StoriesSend] JSON Unmarshal error: %v", err)
+		logger.FromContext(r.Context()).Error("[StoriesSend] JSON Unmarshal error: %v", err)
 		http.Error(w, "Invalid JSON format", http.StatusNotAcceptable)
 		return
 	}
@@ -856,7 +855,7 @@ func (oh *featureHandler) StoriesSend(w http.ResponseWriter, r *http.Request) {
 		panic("Failed to encode payload")
 	}
 
-	req, err := http.NewRequest("POST", "https://api.stakwork.com/api/v1/projects", bytes.NewBuffer(stakworkPayloadJSON))
+	req, err := http.NewRequestWithContext(r.Context(), "POST", "https://api.stakwork.com/api/v1/projects", bytes.NewBuffer(stakworkPayloadJSON))
 	if err != nil {
 		panic("Failed to create request to Stakwork API")
 	}
@@ -893,7 +892,7 @@ func (oh *featureHandler) BriefSend(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -901,7 +900,7 @@ func (oh *featureHandler) BriefSend(w http.ResponseWriter, r *http.Request) {
 	user := oh.db.GetPersonByPubkey(pubKeyFromAuth)
 
 	if user.OwnerPubKey != pubKeyFromAuth {
-		logger.Log.Info("Person not exists")
+		logger.FromContext(r.Context()).Info("Person not exists")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -916,14 +915,14 @@ func (oh *featureHandler) BriefSend(w http.ResponseWriter, r *http.Request) {
 	var postData AudioBriefPostData
 	err = json.Unmarshal(body, &postData)
 	if err != nil {
-		logger.Log.Error("[BriefSend] JSON Unmarshal error: %v", err)
+		logger.FromContext(r.Context()).Error("[BriefSend] JSON Unmarshal error: %v", err)
 		http.Error(w, "Invalid JSON format", http.StatusNotAcceptable)
 		return
 	}
 
 	host := os.Getenv("HOST")
 	if host == "" {
-		logger.Log.Error("[BriefSend] HOST environment variable not set")
+		logger.FromContext(r.Context()).Error("[BriefSend] HOST environment variable not set")
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -940,7 +939,7 @@ func (oh *featureHandler) BriefSend(w http.ResponseWriter, r *http.Request) {
 
 	apiKey := os.Getenv("SWWFKEY")
 	if apiKey == "" {
-		logger.Log.Error("[BriefSend] API key not set in environment")
+		logger.FromContext(r.Context()).Error("[BriefSend] API key not set in environment")
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -963,7 +962,7 @@ func (oh *featureHandler) BriefSend(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req, err := http.NewRequest(http.MethodPost, "https://api.stakwork.com/api/v1/projects", bytes.NewBuffer(stakworkPayloadJSON))
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, "https://api.stakwork.com/api/v1/projects", bytes.NewBuffer(stakworkPayloadJSON))
 	if err != nil {
 		panic("Failed to create request to Stakwork API")
 		return
@@ -1003,14 +1002,14 @@ func (oh *featureHandler) UpdateFeatureStatus(w http.ResponseWriter, r *http.Req
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
 	person := oh.db.GetPersonByPubkey(pubKeyFromAuth)
 	if person.OwnerPubKey == "" {
-		logger.Log.Info("invalid pubkey")
+		logger.FromContext(r.Context()).Info("invalid pubkey")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{
 			"error": "Unauthorized: invalid pubkey",
@@ -1021,7 +1020,7 @@ func (oh *featureHandler) UpdateFeatureStatus(w http.ResponseWriter, r *http.Req
 	uuid := chi.URLParam(r, "uuid")
 
 	if uuid == "" {
-		logger.Log.Info("uuid parameter is missing")
+		logger.FromContext(r.Context()).Info("uuid parameter is missing")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{
 			"error": "Missing uuid parameter",
@@ -1030,7 +1029,7 @@ func (oh *featureHandler) UpdateFeatureStatus(w http.ResponseWriter, r *http.Req
 	}
 
 	if r.Body == nil {
-		logger.Log.Info("request body is nil")
+		logger.FromContext(r.Context()).Info("request body is nil")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{
 			"error": "Request body is required",
@@ -1043,7 +1042,7 @@ func (oh *featureHandler) UpdateFeatureStatus(w http.ResponseWriter, r *http.Req
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Log.Error("invalid request body", err)
+		logger.FromContext(r.Context()).Error("invalid request body: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -1054,7 +1053,7 @@ func (oh *featureHandler) UpdateFeatureStatus(w http.ResponseWriter, r *http.Req
 		db.CompletedFeature: true,
 		db.BacklogFeature:   true,
 	}[req.Status]; !valid {
-		logger.Log.Info("invalid feature status")
+		logger.FromContext(r.Context()).Info("invalid feature status")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{
 			"error": "Invalid feature status. Allowed values are: active, archived, completed, backlog",
@@ -1064,7 +1063,7 @@ func (oh *featureHandler) UpdateFeatureStatus(w http.ResponseWriter, r *http.Req
 
 	updatedFeature, err := oh.db.UpdateFeatureStatus(uuid, req.Status)
 	if err != nil {
-		logger.Log.Error("failed to update feature status", err)
+		logger.FromContext(r.Context()).Error("failed to update feature status: %v", err)
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
@@ -1087,7 +1086,7 @@ func (oh *featureHandler) GetQuickBounties(w http.ResponseWriter, r *http.Reques
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1173,7 +1172,7 @@ func (oh *featureHandler) GetQuickTickets(w http.ResponseWriter, r *http.Request
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1243,7 +1242,7 @@ func (oh *featureHandler) CreateOrUpdateFeatureCall(w http.ResponseWriter, r *ht
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1251,7 +1250,7 @@ func (oh *featureHandler) CreateOrUpdateFeatureCall(w http.ResponseWriter, r *ht
 	var req FeatureCallRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Log.Error("invalid request body", err)
+		logger.FromContext(r.Context()).Error("invalid request body: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
 		return
@@ -1259,7 +1258,7 @@ func (oh *featureHandler) CreateOrUpdateFeatureCall(w http.ResponseWriter, r *ht
 
 	workspace := oh.db.GetWorkspaceByUuid(req.WorkspaceID)
 	if workspace.Uuid == "" {
-		logger.Log.Info("workspace not found")
+		logger.FromContext(r.Context()).Info("workspace not found")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Workspace not found"})
 		return
@@ -1267,7 +1266,7 @@ func (oh *featureHandler) CreateOrUpdateFeatureCall(w http.ResponseWriter, r *ht
 
 	featureCall, err := oh.db.CreateOrUpdateFeatureCall(req.WorkspaceID, req.URL)
 	if err != nil {
-		logger.Log.Error("failed to create/update feature call", err)
+		logger.FromContext(r.Context()).Error("failed to create/update feature call: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -1281,14 +1280,14 @@ func (oh *featureHandler) GetFeatureCall(w http.ResponseWriter, r *http.Request)
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
 	workspaceID := chi.URLParam(r, "workspace_uuid")
 	if workspaceID == "" {
-		logger.Log.Info("missing workspace_uuid parameter")
+		logger.FromContext(r.Context()).Info("missing workspace_uuid parameter")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "workspace_uuid parameter is required"})
 		return
@@ -1296,7 +1295,7 @@ func (oh *featureHandler) GetFeatureCall(w http.ResponseWriter, r *http.Request)
 
 	featureCall, err := oh.db.GetFeatureCallByWorkspaceID(workspaceID)
 	if err != nil {
-		logger.Log.Error("failed to get feature call", err)
+		logger.FromContext(r.Context()).Error("failed to get feature call: %v", err)
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -1310,14 +1309,14 @@ func (oh *featureHandler) DeleteFeatureCall(w http.ResponseWriter, r *http.Reque
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
 	workspaceID := chi.URLParam(r, "workspace_uuid")
 	if workspaceID == "" {
-		logger.Log.Info("missing workspace_uuid parameter")
+		logger.FromContext(r.Context()).Info("missing workspace_uuid parameter")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "workspace_uuid parameter is required"})
 		return
@@ -1325,7 +1324,7 @@ func (oh *featureHandler) DeleteFeatureCall(w http.ResponseWriter, r *http.Reque
 
 	err := oh.db.DeleteFeatureCall(workspaceID)
 	if err != nil {
-		logger.Log.Error("failed to delete feature call", err)
+		logger.FromContext(r.Context()).Error("failed to delete feature call: %v", err)
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
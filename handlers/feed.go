@@ -80,7 +80,7 @@ func DownloadYoutubeFeed(w http.ResponseWriter, r *http.Request) {
 	r.Body.Close()
 	err = json.Unmarshal(body, &youtube_download)
 	if err != nil {
-		logger.Log.Error("[feed] %v", err)
+		logger.FromContext(r.Context()).Error("[feed] %v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -108,15 +108,15 @@ func DownloadYoutubeFeed(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	processYoutubeDownload(youtube_download.YoutubeUrls)
+	processYoutubeDownload(r.Context(), youtube_download.YoutubeUrls)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode("Youtube download processed successfully")
 }
 
-func processYoutubeDownload(data []string) {
+func processYoutubeDownload(ctx context.Context, data []string) {
 	stakworkKey := fmt.Sprintf("Token token=%s", os.Getenv("STAKWORK_KEY"))
 	if stakworkKey == "" {
-		logger.Log.Error("[feed] Youtube Download Error: Stakwork key not found")
+		logger.FromContext(ctx).Error("[feed] Youtube Download Error: Stakwork key not found")
 	} else {
 		type Vars struct {
 			YoutubeContent []string `json:"youtube_content"`
@@ -150,26 +150,26 @@ func processYoutubeDownload(data []string) {
 
 		buf, err := json.Marshal(body)
 		if err != nil {
-			logger.Log.Error("[feed] Youtube error: Unable to parse message into byte buffer: %v", err)
+			logger.FromContext(ctx).Error("[feed] Youtube error: Unable to parse message into byte buffer: %v", err)
 			return
 		}
 
 		requestUrl := "https://jobs.stakwork.com/api/v1/projects"
-		request, err := http.NewRequest(http.MethodPost, requestUrl, bytes.NewBuffer(buf))
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost, requestUrl, bytes.NewBuffer(buf))
 		request.Header.Set("Content-Type", "application/json")
 		request.Header.Set("Authorization", stakworkKey)
 
 		client := &http.Client{}
 		response, err := client.Do(request)
 		if err != nil {
-			logger.Log.Error("[feed] Youtube Download Request Error: %v", err)
+			logger.FromContext(ctx).Error("[feed] Youtube Download Request Error: %v", err)
 		}
 		defer response.Body.Close()
 		res, err := io.ReadAll(response.Body)
 		if err != nil {
-			logger.Log.Error("[feed] Youtube Download Request Error: %v", err)
+			logger.FromContext(ctx).Error("[feed] Youtube Download Request Error: %v", err)
 		}
-		logger.Log.Info("[feed] Youtube Download Success: %s", string(res))
+		logger.FromContext(ctx).Info("[feed] Youtube Download Success: %s", string(res))
 	}
 }
 
@@ -187,11 +187,11 @@ func processYoutubeDownload(data []string) {
 func GetPodcast(w http.ResponseWriter, r *http.Request) {
 	url := r.URL.Query().Get("url")
 	feedid := r.URL.Query().Get("id")
-	podcast, err := getFeed(url, feedid)
-	episodes, err := getEpisodes(url, feedid)
+	podcast, err := getFeed(r.Context(), url, feedid)
+	episodes, err := getEpisodes(r.Context(), url, feedid)
 
 	if err != nil {
-		logger.Log.Error("[feed] %v", err)
+		logger.FromContext(r.Context()).Error("[feed] %v", err)
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
@@ -200,7 +200,7 @@ func GetPodcast(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	err = json.NewEncoder(w).Encode(podcast)
 	if err != nil {
-		logger.Log.Error("[feed] %v", err)
+		logger.FromContext(r.Context()).Error("[feed] %v", err)
 	}
 }
 
@@ -216,7 +216,7 @@ func GetPodcast(w http.ResponseWriter, r *http.Request) {
 //	@Router			/search_podcasts [get]
 func SearchPodcasts(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query().Get("q")
-	podcasts, err := searchPodcastIndex(q)
+	podcasts, err := searchPodcastIndex(r.Context(), q)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		return
@@ -328,7 +328,7 @@ func YoutubeVideosForChannel(w http.ResponseWriter, r *http.Request) {
 	err = json.NewEncoder(w).Encode(fs)
 }
 
-func getFeed(feedURL string, feedID string) (*feeds.Podcast, error) {
+func getFeed(ctx context.Context, feedURL string, feedID string) (*feeds.Podcast, error) {
 	client := &http.Client{}
 
 	url := ""
@@ -341,7 +341,7 @@ func getFeed(feedURL string, feedID string) (*feeds.Podcast, error) {
 		return nil, errors.New("no url or id supplied")
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	headers := feeds.PodcastIndexHeaders()
 	for k, v := range headers {
@@ -350,7 +350,7 @@ func getFeed(feedURL string, feedID string) (*feeds.Podcast, error) {
 	resp, err := client.Do(req)
 
 	if err != nil {
-		logger.Log.Error("[feed] GET error: %v", err)
+		logger.FromContext(ctx).Error("[feed] GET error: %v", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -359,7 +359,7 @@ func getFeed(feedURL string, feedID string) (*feeds.Podcast, error) {
 	body, err := io.ReadAll(resp.Body)
 	err = json.Unmarshal(body, &r)
 	if err != nil {
-		logger.Log.Error("[feed] json unmarshall error: %v", err)
+		logger.FromContext(ctx).Error("[feed] json unmarshall error: %v", err)
 		return nil, err
 	}
 
@@ -370,7 +370,7 @@ func getFeed(feedURL string, feedID string) (*feeds.Podcast, error) {
 	return &feed, nil
 }
 
-func getEpisodes(feedURL string, feedID string) ([]feeds.Episode, error) {
+func getEpisodes(ctx context.Context, feedURL string, feedID string) ([]feeds.Episode, error) {
 	client := &http.Client{}
 
 	url := ""
@@ -383,7 +383,7 @@ func getEpisodes(feedURL string, feedID string) ([]feeds.Episode, error) {
 		return nil, errors.New("no url or id supplied")
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	headers := feeds.PodcastIndexHeaders()
 	for k, v := range headers {
@@ -392,7 +392,7 @@ func getEpisodes(feedURL string, feedID string) ([]feeds.Episode, error) {
 	resp, err := client.Do(req)
 
 	if err != nil {
-		logger.Log.Error("[feed] GET error: %v", err)
+		logger.FromContext(ctx).Error("[feed] GET error: %v", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -401,14 +401,14 @@ func getEpisodes(feedURL string, feedID string) ([]feeds.Episode, error) {
 	body, err := io.ReadAll(resp.Body)
 	err = json.Unmarshal(body, &r)
 	if err != nil {
-		logger.Log.Error("[feed] json unmarshall error: %v", err)
+		logger.FromContext(ctx).Error("[feed] json unmarshall error: %v", err)
 		return nil, err
 	}
 
 	return r.Items, nil
 }
 
-func searchPodcastIndex(term string) ([]feeds.Podcast, error) {
+func searchPodcastIndex(ctx context.Context, term string) ([]feeds.Podcast, error) {
 	client := &http.Client{}
 
 	url := feeds.PodcastIndexBaseURL + "search/byterm?q=" + term
@@ -417,7 +417,7 @@ func searchPodcastIndex(term string) ([]feeds.Podcast, error) {
 		return nil, errors.New("no url or id supplied")
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	headers := feeds.PodcastIndexHeaders()
 	for k, v := range headers {
@@ -426,7 +426,7 @@ func searchPodcastIndex(term string) ([]feeds.Podcast, error) {
 	resp, err := client.Do(req)
 
 	if err != nil {
-		logger.Log.Error("[feed] GET error: %v", err)
+		logger.FromContext(ctx).Error("[feed] GET error: %v", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -435,7 +435,7 @@ func searchPodcastIndex(term string) ([]feeds.Podcast, error) {
 	body, err := io.ReadAll(resp.Body)
 	err = json.Unmarshal(body, &r)
 	if err != nil {
-		logger.Log.Error("[feed] json unmarshall error: %v", err)
+		logger.FromContext(ctx).Error("[feed] json unmarshall error: %v", err)
 		return nil, err
 	}
 
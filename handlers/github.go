@@ -3,15 +3,12 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"os"
 	"strconv"
-	"strings"
 
 	"github.com/go-chi/chi"
 	"github.com/google/go-github/v39/github"
-	"github.com/stakwork/sphinx-tribes/auth"
 	"github.com/stakwork/sphinx-tribes/db"
 	"github.com/stakwork/sphinx-tribes/logger"
 	"golang.org/x/oauth2"
@@ -40,7 +37,7 @@ func GetGithubIssue(w http.ResponseWriter, r *http.Request) {
 	}
 	issue, err := GetIssue(owner, repo, issueNum)
 	if err != nil {
-		logger.Log.Error("Github error: %v", err)
+		logger.FromContext(r.Context()).Error("Github error: %v", err)
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
@@ -114,27 +111,3 @@ func GetIssue(owner string, repo string, id int) (db.GithubIssue, error) {
 	}
 	return issue, err
 }
-
-func PubkeyForGithubUser(owner string) (string, error) {
-	client := githubClient()
-	gs, _, err := client.Gists.List(context.Background(), owner, nil)
-	if err == nil && gs != nil {
-		for _, g := range gs {
-			if g.Files != nil {
-				for k := range g.Files {
-					if strings.Contains(string(k), "Sphinx Verification") {
-						// get the actual gist
-						gist, _, err := client.Gists.Get(context.Background(), *g.ID)
-						gistFile := gist.Files[k]
-						pubkey, err := auth.VerifyArbitrary(*gistFile.Content, "Sphinx Verification")
-						if err != nil {
-							return "", err
-						}
-						return pubkey, nil
-					}
-				}
-			}
-		}
-	}
-	return "", errors.New("nope")
-}
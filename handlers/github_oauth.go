@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+	"golang.org/x/oauth2"
+)
+
+const githubOAuthStatePrefix = "github_oauth_state:"
+
+type githubOAuthHandler struct {
+	db         db.Database
+	httpClient *http.Client
+}
+
+func NewGithubOAuthHandler(database db.Database) *githubOAuthHandler {
+	return &githubOAuthHandler{
+		db:         database,
+		httpClient: http.DefaultClient,
+	}
+}
+
+type GithubOAuthResponse struct {
+	URL string `json:"url"`
+}
+
+// githubOAuthConfig reads the provider endpoints from config so a fake
+// provider can be used locally and in tests
+func githubOAuthConfig() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     config.GithubClientID,
+		ClientSecret: config.GithubClientSecret,
+		RedirectURL:  config.GithubOAuthRedirectURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  config.GithubOAuthAuthorizeURL,
+			TokenURL: config.GithubOAuthTokenURL,
+		},
+	}
+}
+
+// githubOAuthRedirect sends the browser back to the app with the outcome
+func githubOAuthRedirect(w http.ResponseWriter, r *http.Request, status string) {
+	returnURL, err := url.Parse(config.GithubOAuthReturnURL)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	query := returnURL.Query()
+	query.Set("github_oauth", status)
+	returnURL.RawQuery = query.Encode()
+
+	http.Redirect(w, r, returnURL.String(), http.StatusFound)
+}
+
+// fetchGithubUser exchanges the authorization code and returns the login and
+// id of the GitHub account that authorized the app
+func (gh *githubOAuthHandler) fetchGithubUser(ctx context.Context, code string) (string, int64, error) {
+	oauthConfig := githubOAuthConfig()
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, gh.httpClient)
+
+	token, err := oauthConfig.Exchange(ctx, code)
+	if err != nil {
+		return "", 0, err
+	}
+
+	client := github.NewClient(oauthConfig.Client(ctx, token))
+	client.BaseURL, err = url.Parse(strings.TrimSuffix(config.GithubAPIURL, "/") + "/")
+	if err != nil {
+		return "", 0, err
+	}
+
+	user, _, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return "", 0, err
+	}
+	return user.GetLogin(), user.GetID(), nil
+}
+
+// GetGithubOAuthURL godoc
+//
+//	@Summary		Start GitHub verification
+//	@Description	Get the GitHub authorization URL to verify the authenticated person's GitHub account
+//	@Tags			People
+//	@Produce		json
+//	@Security		PubKeyContextAuth
+//	@Success		200	{object}	GithubOAuthResponse
+//	@Failure		503	{object}	string
+//	@Router			/github/oauth/authorize [get]
+func (gh *githubOAuthHandler) GetGithubOAuthURL(w http.ResponseWriter, r *http.Request) {
+	pubKeyFromAuth, _ := r.Context().Value(auth.ContextKey).(string)
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[github_oauth] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if config.GithubClientID == "" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode("GitHub verification is not configured")
+		return
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		logger.FromContext(r.Context()).Error("[github_oauth] %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	state := hex.EncodeToString(b)
+
+	db.Store.SetChallengeCache(githubOAuthStatePrefix+state, pubKeyFromAuth)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(GithubOAuthResponse{URL: githubOAuthConfig().AuthCodeURL(state)})
+}
+
+// GithubOAuthCallback godoc
+//
+//	@Summary		GitHub verification callback
+//	@Description	Called by GitHub after authorization, stores the verified GitHub login and id on the person and redirects back to the app with a github_oauth status
+//	@Tags			People
+//	@Param			code	query	string	true	"Authorization code"
+//	@Param			state	query	string	true	"State from the authorization URL"
+//	@Success		302
+//	@Router			/github/oauth/callback [get]
+func (gh *githubOAuthHandler) GithubOAuthCallback(w http.ResponseWriter, r *http.Request) {
+	state := r.URL.Query().Get("state")
+	code := r.URL.Query().Get("code")
+
+	pubkey, err := db.Store.GetChallengeCache(githubOAuthStatePrefix + state)
+	if state == "" || err != nil || pubkey == "" {
+		githubOAuthRedirect(w, r, "invalid_state")
+		return
+	}
+	db.Store.DeleteCache(githubOAuthStatePrefix + state)
+
+	if code == "" {
+		githubOAuthRedirect(w, r, "denied")
+		return
+	}
+
+	login, githubID, err := gh.fetchGithubUser(r.Context(), code)
+	if err != nil || login == "" || githubID == 0 {
+		logger.FromContext(r.Context()).Error("[github_oauth] failed to fetch github user: %v", err)
+		githubOAuthRedirect(w, r, "failed")
+		return
+	}
+
+	if other := gh.db.GetPersonByGithubID(githubID); other.OwnerPubKey != "" && other.OwnerPubKey != pubkey {
+		githubOAuthRedirect(w, r, "already_linked")
+		return
+	}
+
+	if err := gh.db.UpdatePersonGithub(pubkey, login, githubID); err != nil {
+		logger.FromContext(r.Context()).Error("[github_oauth] %v", err)
+		githubOAuthRedirect(w, r, "failed")
+		return
+	}
+
+	// logins can be renamed and reused, the identity is the account id and
+	// the login on the person is only shown
+	identity := db.PersonIdentity{
+		OwnerPubKey: pubkey,
+		Type:        db.IdentityGithub,
+		Value:       strconv.FormatInt(githubID, 10),
+		Verified:    true,
+	}
+	if err := gh.db.CreatePersonIdentity(&identity); err != nil {
+		logger.FromContext(r.Context()).Warning("[github_oauth] github account %d (%s) for %s: %v", githubID, login, pubkey, err)
+	}
+
+	githubOAuthRedirect(w, r, "verified")
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/db"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newFakeGithubOAuthServer accepts the code "valid_code" and returns a fixed user
+func newFakeGithubOAuthServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		if r.Form.Get("code") != "valid_code" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "gho_token", "token_type": "bearer"})
+	})
+	mux.HandleFunc("/api/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gho_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"login": "octocat", "id": 583231})
+	})
+	return httptest.NewServer(mux)
+}
+
+func setGithubOAuthConfig(t *testing.T, serverURL string) {
+	previous := []string{config.GithubClientID, config.GithubClientSecret, config.GithubOAuthAuthorizeURL,
+		config.GithubOAuthTokenURL, config.GithubAPIURL, config.GithubOAuthReturnURL}
+	t.Cleanup(func() {
+		config.GithubClientID, config.GithubClientSecret, config.GithubOAuthAuthorizeURL = previous[0], previous[1], previous[2]
+		config.GithubOAuthTokenURL, config.GithubAPIURL, config.GithubOAuthReturnURL = previous[3], previous[4], previous[5]
+	})
+
+	config.GithubClientID = "client_id"
+	config.GithubClientSecret = "client_secret"
+	config.GithubOAuthAuthorizeURL = serverURL + "/login/oauth/authorize"
+	config.GithubOAuthTokenURL = serverURL + "/login/oauth/access_token"
+	config.GithubAPIURL = serverURL + "/api"
+	config.GithubOAuthReturnURL = "https://people.sphinx.chat/p"
+}
+
+func startGithubOAuth(t *testing.T, gh *githubOAuthHandler, pubkey string) string {
+	ctx := context.WithValue(context.Background(), auth.ContextKey, pubkey)
+	rr := httptest.NewRecorder()
+	gh.GetGithubOAuthURL(rr, httptest.NewRequest(http.MethodGet, "/github/oauth/authorize", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response GithubOAuthResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	authorizeURL, err := url.Parse(response.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "client_id", authorizeURL.Query().Get("client_id"))
+	return authorizeURL.Query().Get("state")
+}
+
+func githubOAuthCallback(gh *githubOAuthHandler, state string, code string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	query := url.Values{"state": {state}, "code": {code}}
+	gh.GithubOAuthCallback(rr, httptest.NewRequest(http.MethodGet, "/github/oauth/callback?"+query.Encode(), nil))
+	return rr
+}
+
+func githubOAuthStatus(t *testing.T, rr *httptest.ResponseRecorder) string {
+	assert.Equal(t, http.StatusFound, rr.Code)
+	location, err := url.Parse(rr.Header().Get("Location"))
+	assert.NoError(t, err)
+	return location.Query().Get("github_oauth")
+}
+
+func TestGithubOAuth(t *testing.T) {
+	db.InitCache()
+	server := newFakeGithubOAuthServer(t)
+	defer server.Close()
+	setGithubOAuthConfig(t, server.URL)
+
+	t.Run("should verify the github account", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		gh := NewGithubOAuthHandler(mockDb)
+
+		mockDb.On("GetPersonByGithubID", int64(583231)).Return(db.Person{})
+		mockDb.On("UpdatePersonGithub", "pubkey", "octocat", int64(583231)).Return(nil)
+		mockDb.On("CreatePersonIdentity", mock.MatchedBy(func(identity *db.PersonIdentity) bool {
+			return identity.Type == db.IdentityGithub && identity.Value == "583231" && identity.Verified
+		})).Return(nil)
+
+		state := startGithubOAuth(t, gh, "pubkey")
+		assert.Equal(t, "verified", githubOAuthStatus(t, githubOAuthCallback(gh, state, "valid_code")))
+	})
+
+	t.Run("should not accept a state twice", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		gh := NewGithubOAuthHandler(mockDb)
+
+		mockDb.On("GetPersonByGithubID", int64(583231)).Return(db.Person{})
+		mockDb.On("UpdatePersonGithub", "pubkey", "octocat", int64(583231)).Return(nil)
+		mockDb.On("CreatePersonIdentity", mock.AnythingOfType("*db.PersonIdentity")).Return(errors.New("identity is already linked to another person"))
+
+		state := startGithubOAuth(t, gh, "pubkey")
+		assert.Equal(t, "verified", githubOAuthStatus(t, githubOAuthCallback(gh, state, "valid_code")))
+		assert.Equal(t, "invalid_state", githubOAuthStatus(t, githubOAuthCallback(gh, state, "valid_code")))
+	})
+
+	t.Run("should reject an account verified by someone else", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		gh := NewGithubOAuthHandler(mockDb)
+
+		mockDb.On("GetPersonByGithubID", int64(583231)).Return(db.Person{OwnerPubKey: "other_pubkey"})
+
+		state := startGithubOAuth(t, gh, "pubkey")
+		assert.Equal(t, "already_linked", githubOAuthStatus(t, githubOAuthCallback(gh, state, "valid_code")))
+	})
+
+	t.Run("should fail on a bad code", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		gh := NewGithubOAuthHandler(mockDb)
+
+		state := startGithubOAuth(t, gh, "pubkey")
+		assert.Equal(t, "failed", githubOAuthStatus(t, githubOAuthCallback(gh, state, "bad_code")))
+	})
+
+	t.Run("should report a denied authorization", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		gh := NewGithubOAuthHandler(mockDb)
+
+		state := startGithubOAuth(t, gh, "pubkey")
+		assert.Equal(t, "denied", githubOAuthStatus(t, githubOAuthCallback(gh, state, "")))
+	})
+
+	t.Run("should need github to be configured", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		gh := NewGithubOAuthHandler(mockDb)
+		config.GithubClientID = ""
+		defer func() { config.GithubClientID = "client_id" }()
+
+		ctx := context.WithValue(context.Background(), auth.ContextKey, "pubkey")
+		rr := httptest.NewRecorder()
+		gh.GetGithubOAuthURL(rr, httptest.NewRequest(http.MethodGet, "/github/oauth/authorize", nil).WithContext(ctx))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	})
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+)
+
+const identityChallengeCachePrefix = "identity_challenge:"
+
+type identityHandler struct {
+	db db.Database
+}
+
+func NewIdentityHandler(database db.Database) *identityHandler {
+	return &identityHandler{db: database}
+}
+
+// KeyProof is a key and its signature of a link challenge, node keys send a
+// signature and nostr keys send a signed kind 22242 event
+type KeyProof struct {
+	Pubkey    string           `json:"pubkey"`
+	Signature string           `json:"signature,omitempty"`
+	Event     *auth.NostrEvent `json:"event,omitempty"`
+}
+
+// LinkIdentityRequest links Key to the authenticated person, Owner proves the
+// request is made by the person's own key
+type LinkIdentityRequest struct {
+	Challenge string   `json:"challenge"`
+	Key       KeyProof `json:"key"`
+	Owner     KeyProof `json:"owner"`
+}
+
+type IdentityChallengeResponse struct {
+	Challenge string `json:"challenge"`
+}
+
+// ResolveLinkedPubkey is used as auth.LinkedPubkeyResolver
+func (ih *identityHandler) ResolveLinkedPubkey(pubkey string) string {
+	identity, err := ih.db.GetPersonIdentity(auth.KeyType(pubkey), pubkey)
+	if err != nil {
+		return ""
+	}
+	return identity.OwnerPubKey
+}
+
+// GetIdentityChallenge godoc
+//
+//	@Summary		Get identity link challenge
+//	@Description	Get a challenge that both the person's key and the key being linked must sign
+//	@Tags			People
+//	@Produce		json
+//	@Security		PubKeyContextAuth
+//	@Success		200	{object}	IdentityChallengeResponse
+//	@Router			/identities/challenge [get]
+func (ih *identityHandler) GetIdentityChallenge(w http.ResponseWriter, r *http.Request) {
+	pubKeyFromAuth, _ := r.Context().Value(auth.ContextKey).(string)
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[identities] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		logger.FromContext(r.Context()).Error("[identities] %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	challenge := hex.EncodeToString(b)
+
+	db.Store.SetChallengeCache(identityChallengeCachePrefix+challenge, pubKeyFromAuth)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(IdentityChallengeResponse{Challenge: challenge})
+}
+
+// LinkIdentity godoc
+//
+//	@Summary		Link a key to a person
+//	@Description	Link a Lightning node or nostr key to the authenticated person. The challenge must be signed by both the new key and one of the person's keys.
+//	@Tags			People
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		LinkIdentityRequest	true	"Signed challenge"
+//	@Security		PubKeyContextAuth
+//	@Success		201		{object}	db.PersonIdentity
+//	@Failure		409		{object}	string
+//	@Router			/identities [post]
+func (ih *identityHandler) LinkIdentity(w http.ResponseWriter, r *http.Request) {
+	pubKeyFromAuth, _ := r.Context().Value(auth.ContextKey).(string)
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[identities] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var request LinkIdentityRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Invalid request body")
+		return
+	}
+
+	keyType := auth.KeyType(request.Key.Pubkey)
+	if keyType == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Only node and nostr keys can be linked")
+		return
+	}
+
+	challengeOwner, err := db.Store.GetChallengeCache(identityChallengeCachePrefix + request.Challenge)
+	if request.Challenge == "" || err != nil || challengeOwner != pubKeyFromAuth {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Unknown or expired challenge")
+		return
+	}
+	db.Store.DeleteCache(identityChallengeCachePrefix + request.Challenge)
+
+	if auth.ResolvePubkey(request.Owner.Pubkey) != pubKeyFromAuth {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Owner key does not belong to the authenticated person")
+		return
+	}
+
+	if err := auth.VerifyKeyProof(request.Owner.Pubkey, request.Owner.Signature, request.Owner.Event, request.Challenge); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Owner key: " + err.Error())
+		return
+	}
+	if err := auth.VerifyKeyProof(request.Key.Pubkey, request.Key.Signature, request.Key.Event, request.Challenge); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Linked key: " + err.Error())
+		return
+	}
+
+	if request.Key.Pubkey == pubKeyFromAuth {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Key is already the person's own key")
+		return
+	}
+
+	if existing := ih.db.GetPersonByPubkey(request.Key.Pubkey); existing.OwnerPubKey != "" {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode("Key already has its own account, ask an admin to merge the accounts")
+		return
+	}
+
+	identity := db.PersonIdentity{
+		OwnerPubKey: pubKeyFromAuth,
+		Type:        keyType,
+		Value:       request.Key.Pubkey,
+		Verified:    true,
+	}
+	if err := ih.db.CreatePersonIdentity(&identity); err != nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+	auth.ClearLinkedPubkeyCache()
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(identity)
+}
+
+// GetIdentities godoc
+//
+//	@Summary		List linked identities
+//	@Description	List the keys and handles linked to the authenticated person
+//	@Tags			People
+//	@Produce		json
+//	@Security		PubKeyContextAuth
+//	@Success		200	{array}	db.PersonIdentity
+//	@Router			/identities [get]
+func (ih *identityHandler) GetIdentities(w http.ResponseWriter, r *http.Request) {
+	pubKeyFromAuth, _ := r.Context().Value(auth.ContextKey).(string)
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[identities] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	identities, err := ih.db.GetPersonIdentities(pubKeyFromAuth)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("[identities] %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(identities)
+}
+
+// DeleteIdentity godoc
+//
+//	@Summary		Unlink identity
+//	@Description	Unlink a key or handle from the authenticated person
+//	@Tags			People
+//	@Param			id	path	string	true	"Identity ID"
+//	@Security		PubKeyContextAuth
+//	@Success		200
+//	@Router			/identities/{id} [delete]
+func (ih *identityHandler) DeleteIdentity(w http.ResponseWriter, r *http.Request) {
+	pubKeyFromAuth, _ := r.Context().Value(auth.ContextKey).(string)
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[identities] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if err := ih.db.DeletePersonIdentity(pubKeyFromAuth, id); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+	auth.ClearLinkedPubkeyCache()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode("Identity unlinked")
+}
+
+// MergePeople godoc
+//
+//	@Summary		Merge two accounts
+//	@Description	Move bounties, workspaces, roles and identities of the source person to the target person, link the source pubkey to the target and delete the source person
+//	@Tags			People
+//	@Accept			json
+//	@Param			request	body	db.MergePeopleRequest	true	"People to merge"
+//	@Security		SuperAdminAuth
+//	@Success		200
+//	@Router			/admin/people/merge [post]
+func (ih *identityHandler) MergePeople(w http.ResponseWriter, r *http.Request) {
+	pubKeyFromAuth, _ := r.Context().Value(auth.ContextKey).(string)
+
+	var request db.MergePeopleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Invalid request body")
+		return
+	}
+
+	if request.SourcePubKey == "" || request.TargetPubKey == "" || request.SourcePubKey == request.TargetPubKey {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Two different pubkeys are required")
+		return
+	}
+
+	keyType := auth.KeyType(request.SourcePubKey)
+	if keyType == "" {
+		keyType = db.IdentityNode
+	}
+
+	if err := ih.db.MergePeople(request.SourcePubKey, request.TargetPubKey, keyType); err != nil {
+		logger.FromContext(r.Context()).Error("[identities] %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+
+	if err := ih.db.RevokeAllUserSessions(request.SourcePubKey, db.SessionRevokedByAdmin); err != nil {
+		logger.FromContext(r.Context()).Error("[identities] %v", err)
+	}
+	auth.ClearLinkedPubkeyCache()
+	auth.ClearRevocationCache()
+
+	logger.FromContext(r.Context()).Info("[identities] %s merged %s into %s", pubKeyFromAuth, request.SourcePubKey, request.TargetPubKey)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode("People merged")
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	btcecdsa "github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/db"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newIdentityChallenge(t *testing.T, ih *identityHandler, ctx context.Context) string {
+	rr := httptest.NewRecorder()
+	ih.GetIdentityChallenge(rr, httptest.NewRequest(http.MethodGet, "/identities/challenge", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response IdentityChallengeResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return response.Challenge
+}
+
+func signNodeChallenge(key *btcec.PrivateKey, challenge string) string {
+	msg, _ := hex.DecodeString(challenge)
+	return hex.EncodeToString(btcecdsa.Sign(key, msg).Serialize())
+}
+
+func TestLinkIdentity(t *testing.T) {
+	db.InitCache()
+	ownerKey, _ := btcec.NewPrivateKey()
+	ownerPubkey := hex.EncodeToString(ownerKey.PubKey().SerializeCompressed())
+	nostrKey, _ := btcec.NewPrivateKey()
+	nostrPubkey := hex.EncodeToString(schnorr.SerializePubKey(nostrKey.PubKey()))
+	ctx := context.WithValue(context.Background(), auth.ContextKey, ownerPubkey)
+
+	newLinkRequest := func(request LinkIdentityRequest) *http.Request {
+		body, _ := json.Marshal(request)
+		return httptest.NewRequest(http.MethodPost, "/identities", bytes.NewReader(body)).WithContext(ctx)
+	}
+	signedRequest := func(challenge string) LinkIdentityRequest {
+		return LinkIdentityRequest{
+			Challenge: challenge,
+			Key:       KeyProof{Pubkey: nostrPubkey, Event: newNostrChallengeEvent(t, nostrKey, challenge)},
+			Owner:     KeyProof{Pubkey: ownerPubkey, Signature: signNodeChallenge(ownerKey, challenge)},
+		}
+	}
+
+	t.Run("should link a key signed by both keys", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		ih := NewIdentityHandler(mockDb)
+
+		mockDb.On("GetPersonByPubkey", nostrPubkey).Return(db.Person{})
+		mockDb.On("CreatePersonIdentity", mock.MatchedBy(func(identity *db.PersonIdentity) bool {
+			return identity.OwnerPubKey == ownerPubkey && identity.Type == db.IdentityNostr &&
+				identity.Value == nostrPubkey && identity.Verified
+		})).Return(nil)
+
+		rr := httptest.NewRecorder()
+		ih.LinkIdentity(rr, newLinkRequest(signedRequest(newIdentityChallenge(t, ih, ctx))))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	})
+
+	t.Run("should require the linked key signature", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		ih := NewIdentityHandler(mockDb)
+
+		request := signedRequest(newIdentityChallenge(t, ih, ctx))
+		request.Key.Event = newNostrChallengeEvent(t, nostrKey, "other_challenge")
+
+		rr := httptest.NewRecorder()
+		ih.LinkIdentity(rr, newLinkRequest(request))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should require the owner signature", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		ih := NewIdentityHandler(mockDb)
+
+		challenge := newIdentityChallenge(t, ih, ctx)
+		otherKey, _ := btcec.NewPrivateKey()
+		request := signedRequest(challenge)
+		request.Owner.Signature = signNodeChallenge(otherKey, challenge)
+
+		rr := httptest.NewRecorder()
+		ih.LinkIdentity(rr, newLinkRequest(request))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should reject an owner key of another person", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		ih := NewIdentityHandler(mockDb)
+
+		challenge := newIdentityChallenge(t, ih, ctx)
+		otherKey, _ := btcec.NewPrivateKey()
+		request := signedRequest(challenge)
+		request.Owner = KeyProof{
+			Pubkey:    hex.EncodeToString(otherKey.PubKey().SerializeCompressed()),
+			Signature: signNodeChallenge(otherKey, challenge),
+		}
+
+		rr := httptest.NewRecorder()
+		ih.LinkIdentity(rr, newLinkRequest(request))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should reject a challenge issued to someone else", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		ih := NewIdentityHandler(mockDb)
+
+		otherCtx := context.WithValue(context.Background(), auth.ContextKey, "other_pubkey")
+		challenge := newIdentityChallenge(t, ih, otherCtx)
+
+		rr := httptest.NewRecorder()
+		ih.LinkIdentity(rr, newLinkRequest(signedRequest(challenge)))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should ask for a merge when the key has its own account", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		ih := NewIdentityHandler(mockDb)
+
+		mockDb.On("GetPersonByPubkey", nostrPubkey).Return(db.Person{OwnerPubKey: nostrPubkey})
+
+		rr := httptest.NewRecorder()
+		ih.LinkIdentity(rr, newLinkRequest(signedRequest(newIdentityChallenge(t, ih, ctx))))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("should reject a key linked to another person", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		ih := NewIdentityHandler(mockDb)
+
+		mockDb.On("GetPersonByPubkey", nostrPubkey).Return(db.Person{})
+		mockDb.On("CreatePersonIdentity", mock.AnythingOfType("*db.PersonIdentity")).Return(errors.New("identity is already linked to another person"))
+
+		rr := httptest.NewRecorder()
+		ih.LinkIdentity(rr, newLinkRequest(signedRequest(newIdentityChallenge(t, ih, ctx))))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("should reject handles", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		ih := NewIdentityHandler(mockDb)
+
+		request := signedRequest(newIdentityChallenge(t, ih, ctx))
+		request.Key.Pubkey = "github_user"
+
+		rr := httptest.NewRecorder()
+		ih.LinkIdentity(rr, newLinkRequest(request))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestResolveLinkedPubkey(t *testing.T) {
+	mockDb := dbMocks.NewDatabase(t)
+	ih := NewIdentityHandler(mockDb)
+	linked := "02" + strings.Repeat("a", 64)
+	unlinked := strings.Repeat("b", 64)
+
+	mockDb.On("GetPersonIdentity", db.IdentityNode, linked).Return(db.PersonIdentity{OwnerPubKey: "canonical"}, nil)
+	mockDb.On("GetPersonIdentity", db.IdentityNostr, unlinked).Return(db.PersonIdentity{}, errors.New("identity not found"))
+
+	assert.Equal(t, "canonical", ih.ResolveLinkedPubkey(linked))
+	assert.Equal(t, "", ih.ResolveLinkedPubkey(unlinked))
+}
+
+func TestMergePeople(t *testing.T) {
+	source := "02" + strings.Repeat("a", 64)
+	target := "03" + strings.Repeat("b", 64)
+	ctx := context.WithValue(context.Background(), auth.ContextKey, "admin_pubkey")
+
+	newMergeRequest := func(request db.MergePeopleRequest) *http.Request {
+		body, _ := json.Marshal(request)
+		return httptest.NewRequest(http.MethodPost, "/admin/people/merge", bytes.NewReader(body)).WithContext(ctx)
+	}
+
+	t.Run("should merge and sign the source out", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		ih := NewIdentityHandler(mockDb)
+
+		mockDb.On("MergePeople", source, target, db.IdentityNode).Return(nil)
+		mockDb.On("RevokeAllUserSessions", source, db.SessionRevokedByAdmin).Return(nil)
+
+		rr := httptest.NewRecorder()
+		ih.MergePeople(rr, newMergeRequest(db.MergePeopleRequest{SourcePubKey: source, TargetPubKey: target}))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("should require two different people", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		ih := NewIdentityHandler(mockDb)
+
+		rr := httptest.NewRecorder()
+		ih.MergePeople(rr, newMergeRequest(db.MergePeopleRequest{SourcePubKey: source, TargetPubKey: source}))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("should return merge errors", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		ih := NewIdentityHandler(mockDb)
+
+		mockDb.On("MergePeople", source, target, db.IdentityNode).Return(errors.New("source person not found"))
+
+		rr := httptest.NewRecorder()
+		ih.MergePeople(rr, newMergeRequest(db.MergePeopleRequest{SourcePubKey: source, TargetPubKey: target}))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+	"github.com/stakwork/sphinx-tribes/utils"
+	"github.com/stakwork/sphinx-tribes/websocket"
+)
+
+type invoiceSettlementHandler struct {
+	db                  db.Database
+	getLightningInvoice func(ctx context.Context, payment_request string) (db.InvoiceResult, db.InvoiceError)
+	getInvoiceExpired   func(paymentRequest string) bool
+	sendInvoiceMessage  func(host string, message websocket.InvoiceMessage) error
+	publishEvent        func(topic string, event string, data interface{}) error
+}
+
+func NewInvoiceSettlementHandler(httpClient HttpClient, database db.Database) *invoiceSettlementHandler {
+	h := newInvoiceSettlement(database)
+	h.getLightningInvoice = NewBountyHandler(httpClient, database).GetLightningInvoice
+	return h
+}
+
+// newInvoiceSettlement is the settlement the pollers of the other handlers
+// share, it does not check invoices with the Lightning backend itself
+func newInvoiceSettlement(database db.Database) *invoiceSettlementHandler {
+	return &invoiceSettlementHandler{
+		db:                 database,
+		getInvoiceExpired:  utils.GetInvoiceExpired,
+		sendInvoiceMessage: websocket.WebsocketPool.SendInvoiceMessage,
+		publishEvent:       websocket.WebsocketPool.Publish,
+	}
+}
+
+// SettlementEvent is pushed by the Lightning backend when an invoice is paid
+type SettlementEvent struct {
+	PaymentRequest string `json:"payment_request"`
+	Settled        bool   `json:"settled"`
+}
+
+// subscribeToInvoice records the websocket the client waits on for the
+// invoice's settlement, clients without one keep polling
+func subscribeToInvoice(paymentRequest string, websocketToken string) {
+	if paymentRequest == "" || websocketToken == "" {
+		return
+	}
+	db.Store.SetInvoiceSubscription(paymentRequest, websocketToken)
+}
+
+// SettleInvoice marks a pending invoice as paid, credits the workspace
+// budget for budget invoices and tells the client waiting on the invoice
+// and the subscribers of the workspace.
+// It returns false when the invoice is unknown or was already settled.
+// The database settles an invoice once, whichever path or instance gets to
+// it first.
+func (h *invoiceSettlementHandler) SettleInvoice(ctx context.Context, paymentRequest string) bool {
+	invoice := h.db.GetInvoice(paymentRequest)
+	if invoice.PaymentRequest == "" || invoice.Status {
+		return false
+	}
+
+	msg := "invoice_success"
+	if invoice.Type == db.Budget {
+		if err := h.db.ProcessUpdateBudget(invoice); err != nil {
+			if !errors.Is(err, db.ErrInvoiceAlreadyPaid) {
+				logger.FromContext(ctx).Error("[invoice_settlement] could not credit budget for invoice %s: %v", paymentRequest, err)
+			}
+			return false
+		}
+		msg = "budget_success"
+	} else {
+		marked, err := h.db.MarkInvoicePaid(paymentRequest)
+		if err != nil {
+			logger.FromContext(ctx).Error("[invoice_settlement] could not settle invoice %s: %v", paymentRequest, err)
+			return false
+		}
+		if !marked {
+			return false
+		}
+	}
+
+	logger.FromContext(ctx).Info("[invoice_settlement] invoice %s settled", paymentRequest)
+
+	if invoice.WorkspaceUuid != "" {
+		event := websocket.InvoiceMessage{Msg: msg, Invoice: paymentRequest}
+		if err := h.publishEvent(websocket.WorkspaceTopic(invoice.WorkspaceUuid), "payment_settled", event); err != nil {
+			logger.FromContext(ctx).Warning("[invoice_settlement] could not publish settlement of %s: %v", paymentRequest, err)
+		}
+	}
+
+	host, err := db.Store.GetInvoiceSubscription(paymentRequest)
+	if err != nil {
+		// nobody is waiting on this invoice
+		return true
+	}
+	if err := h.sendInvoiceMessage(host, websocket.InvoiceMessage{Msg: msg, Invoice: paymentRequest}); err != nil {
+		logger.FromContext(ctx).Warning("[invoice_settlement] could not notify %s: %v", host, err)
+	}
+	db.Store.DeleteInvoiceSubscription(paymentRequest)
+
+	return true
+}
+
+// InvoiceSettledWebhook godoc
+//
+//	@Summary		Invoice settlement webhook
+//	@Description	Called by the Lightning backend when an invoice is paid. The invoice is settled once and the waiting client is notified over its websocket.
+//	@Tags			Payments
+//	@Accept			json
+//	@Produce		json
+//	@Param			x-webhook-secret	header		string					true	"Shared webhook secret"
+//	@Param			event				body		SettlementEvent			true	"Settlement event"
+//	@Success		200					{object}	map[string]bool
+//	@Router			/invoices/settled [post]
+func (h *invoiceSettlementHandler) InvoiceSettledWebhook(w http.ResponseWriter, r *http.Request) {
+	if config.InvoiceWebhookSecret == "" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode("Invoice webhook is not configured")
+		return
+	}
+
+	secret := r.Header.Get("x-webhook-secret")
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(config.InvoiceWebhookSecret)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	h.handleSettlementEvent(w, r)
+}
+
+// FakeSettleInvoice pushes a settlement event for an invoice without a
+// Lightning backend, for local development only
+func (h *invoiceSettlementHandler) FakeSettleInvoice(w http.ResponseWriter, r *http.Request) {
+	paymentRequest := chi.URLParam(r, "paymentRequest")
+
+	logger.FromContext(r.Context()).Info("[invoice_settlement] fake settlement for invoice %s", paymentRequest)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]bool{"settled": h.SettleInvoice(r.Context(), paymentRequest)})
+}
+
+func (h *invoiceSettlementHandler) handleSettlementEvent(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	event := SettlementEvent{}
+	if err := json.Unmarshal(body, &event); err != nil || event.PaymentRequest == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Invalid settlement event")
+		return
+	}
+
+	settled := false
+	if event.Settled {
+		settled = h.SettleInvoice(r.Context(), event.PaymentRequest)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]bool{"settled": settled})
+}
+
+// PollPendingInvoices is the safety net for settlement events that never
+// arrived, it checks every pending invoice with the Lightning backend and
+// deletes the ones that expired unpaid
+func (h *invoiceSettlementHandler) PollPendingInvoices() error {
+	var errs []error
+	ctx := logger.WithFields(context.Background(), "cron", "invoice_settlement")
+	cronLog := logger.FromContext(ctx)
+	invoices := h.db.GetPendingInvoices()
+
+	for _, inv := range invoices {
+		invoiceRes, invoiceErr := h.getLightningInvoice(ctx, inv.PaymentRequest)
+		if invoiceErr.Error != "" {
+			errs = append(errs, fmt.Errorf("could not check invoice %s: %s", inv.PaymentRequest, invoiceErr.Error))
+			continue
+		}
+
+		if invoiceRes.Response.Settled {
+			if h.SettleInvoice(ctx, inv.PaymentRequest) {
+				cronLog.Warning("[invoice_settlement] invoice %s was settled by the poll, its settlement event was missed", inv.PaymentRequest)
+			}
+		} else if h.getInvoiceExpired(inv.PaymentRequest) {
+			h.db.DeleteInvoice(inv.PaymentRequest)
+			db.Store.DeleteInvoiceSubscription(inv.PaymentRequest)
+		}
+	}
+	return errors.Join(errs...)
+}
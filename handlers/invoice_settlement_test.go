@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/handlers/mocks"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stakwork/sphinx-tribes/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+type sentInvoiceMessage struct {
+	host    string
+	message websocket.InvoiceMessage
+}
+
+func newTestSettlementHandler(t *testing.T, mockDb *dbMocks.Database, sent *[]sentInvoiceMessage) *invoiceSettlementHandler {
+	h := NewInvoiceSettlementHandler(mocks.NewHttpClient(t), mockDb)
+	h.sendInvoiceMessage = func(host string, message websocket.InvoiceMessage) error {
+		*sent = append(*sent, sentInvoiceMessage{host: host, message: message})
+		return nil
+	}
+	h.publishEvent = func(topic string, event string, data interface{}) error {
+		return nil
+	}
+	return h
+}
+
+func TestSettleInvoice(t *testing.T) {
+	db.InitCache()
+
+	t.Run("should credit the budget and notify the waiting client once", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		var sent []sentInvoiceMessage
+		h := newTestSettlementHandler(t, mockDb, &sent)
+		var published []string
+		h.publishEvent = func(topic string, event string, data interface{}) error {
+			published = append(published, topic+" "+event)
+			return nil
+		}
+
+		invoice := db.NewInvoiceList{PaymentRequest: "lnbc_budget", Type: db.Budget, WorkspaceUuid: "workspace_uuid"}
+		subscribeToInvoice("lnbc_budget", "websocket_token")
+
+		mockDb.On("GetInvoice", "lnbc_budget").Return(invoice).Once()
+		mockDb.On("ProcessUpdateBudget", invoice).Return(nil).Once()
+
+		assert.True(t, h.SettleInvoice(context.Background(), "lnbc_budget"))
+		assert.Equal(t, []sentInvoiceMessage{{
+			host:    "websocket_token",
+			message: websocket.InvoiceMessage{Msg: "budget_success", Invoice: "lnbc_budget"},
+		}}, sent)
+		assert.Equal(t, []string{"workspace:workspace_uuid payment_settled"}, published)
+
+		_, err := db.Store.GetInvoiceSubscription("lnbc_budget")
+		assert.Error(t, err)
+
+		invoice.Status = true
+		mockDb.On("GetInvoice", "lnbc_budget").Return(invoice).Once()
+
+		assert.False(t, h.SettleInvoice(context.Background(), "lnbc_budget"))
+		assert.Len(t, sent, 1)
+	})
+
+	t.Run("should mark other invoices as paid", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		var sent []sentInvoiceMessage
+		h := newTestSettlementHandler(t, mockDb, &sent)
+
+		subscribeToInvoice("lnbc_assign", "websocket_token")
+
+		mockDb.On("GetInvoice", "lnbc_assign").Return(db.NewInvoiceList{PaymentRequest: "lnbc_assign", Type: db.PayInvoice})
+		mockDb.On("MarkInvoicePaid", "lnbc_assign").Return(true, nil)
+
+		assert.True(t, h.SettleInvoice(context.Background(), "lnbc_assign"))
+		assert.Equal(t, "invoice_success", sent[0].message.Msg)
+	})
+
+	t.Run("should settle once when the webhook and a poll race", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		var sent []sentInvoiceMessage
+		var mu sync.Mutex
+		h := newTestSettlementHandler(t, mockDb, &sent)
+		h.sendInvoiceMessage = func(host string, message websocket.InvoiceMessage) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, sentInvoiceMessage{host: host, message: message})
+			return nil
+		}
+
+		subscribeToInvoice("lnbc_raced", "websocket_token")
+
+		mockDb.On("GetInvoice", "lnbc_raced").Return(db.NewInvoiceList{PaymentRequest: "lnbc_raced", Type: db.PayInvoice})
+		// the database lets only the first settlement mark the invoice
+		mockDb.On("MarkInvoicePaid", "lnbc_raced").Return(true, nil).Once()
+		mockDb.On("MarkInvoicePaid", "lnbc_raced").Return(false, nil).Once()
+
+		bHandler := NewBountyHandler(mocks.NewHttpClient(t), mockDb)
+		bHandler.settleInvoice = h.SettleInvoice
+
+		var wg sync.WaitGroup
+		results := make([]bool, 2)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			results[0] = h.SettleInvoice(context.Background(), "lnbc_raced")
+		}()
+		go func() {
+			defer wg.Done()
+			results[1] = bHandler.settleInvoice(context.Background(), "lnbc_raced")
+		}()
+		wg.Wait()
+
+		assert.ElementsMatch(t, []bool{true, false}, results)
+		assert.Len(t, sent, 1)
+	})
+
+	t.Run("should not credit a budget invoice another settlement paid", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		var sent []sentInvoiceMessage
+		h := newTestSettlementHandler(t, mockDb, &sent)
+
+		invoice := db.NewInvoiceList{PaymentRequest: "lnbc_paid", Type: db.Budget}
+		mockDb.On("GetInvoice", "lnbc_paid").Return(invoice)
+		mockDb.On("ProcessUpdateBudget", invoice).Return(db.ErrInvoiceAlreadyPaid)
+
+		assert.False(t, h.SettleInvoice(context.Background(), "lnbc_paid"))
+		assert.Empty(t, sent)
+	})
+
+	t.Run("should not notify when crediting the budget fails", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		var sent []sentInvoiceMessage
+		h := newTestSettlementHandler(t, mockDb, &sent)
+
+		invoice := db.NewInvoiceList{PaymentRequest: "lnbc_failed", Type: db.Budget}
+		subscribeToInvoice("lnbc_failed", "websocket_token")
+
+		mockDb.On("GetInvoice", "lnbc_failed").Return(invoice)
+		mockDb.On("ProcessUpdateBudget", invoice).Return(errors.New("no payment history"))
+
+		assert.False(t, h.SettleInvoice(context.Background(), "lnbc_failed"))
+		assert.Empty(t, sent)
+	})
+
+	t.Run("should ignore unknown invoices", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		var sent []sentInvoiceMessage
+		h := newTestSettlementHandler(t, mockDb, &sent)
+
+		mockDb.On("GetInvoice", "lnbc_unknown").Return(db.NewInvoiceList{})
+
+		assert.False(t, h.SettleInvoice(context.Background(), "lnbc_unknown"))
+		assert.Empty(t, sent)
+	})
+}
+
+func TestInvoiceSettledWebhook(t *testing.T) {
+	db.InitCache()
+	secret := config.InvoiceWebhookSecret
+	defer func() { config.InvoiceWebhookSecret = secret }()
+
+	t.Run("should be disabled without a secret", func(t *testing.T) {
+		config.InvoiceWebhookSecret = ""
+		var sent []sentInvoiceMessage
+		h := newTestSettlementHandler(t, dbMocks.NewDatabase(t), &sent)
+
+		req := httptest.NewRequest(http.MethodPost, "/invoices/settled", bytes.NewBufferString(`{"payment_request": "lnbc_invoice", "settled": true}`))
+		rr := httptest.NewRecorder()
+		h.InvoiceSettledWebhook(rr, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	})
+
+	t.Run("should reject a wrong secret", func(t *testing.T) {
+		config.InvoiceWebhookSecret = "webhook_secret"
+		var sent []sentInvoiceMessage
+		h := newTestSettlementHandler(t, dbMocks.NewDatabase(t), &sent)
+
+		req := httptest.NewRequest(http.MethodPost, "/invoices/settled", bytes.NewBufferString(`{"payment_request": "lnbc_invoice", "settled": true}`))
+		req.Header.Set("x-webhook-secret", "wrong")
+		rr := httptest.NewRecorder()
+		h.InvoiceSettledWebhook(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should settle the invoice of the event", func(t *testing.T) {
+		config.InvoiceWebhookSecret = "webhook_secret"
+		mockDb := dbMocks.NewDatabase(t)
+		var sent []sentInvoiceMessage
+		h := newTestSettlementHandler(t, mockDb, &sent)
+
+		mockDb.On("GetInvoice", "lnbc_invoice").Return(db.NewInvoiceList{PaymentRequest: "lnbc_invoice", Type: db.Keysend})
+		mockDb.On("MarkInvoicePaid", "lnbc_invoice").Return(true, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/invoices/settled", bytes.NewBufferString(`{"payment_request": "lnbc_invoice", "settled": true}`))
+		req.Header.Set("x-webhook-secret", "webhook_secret")
+		rr := httptest.NewRecorder()
+		h.InvoiceSettledWebhook(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"settled": true}`, rr.Body.String())
+	})
+
+	t.Run("should ignore events of unsettled invoices", func(t *testing.T) {
+		config.InvoiceWebhookSecret = "webhook_secret"
+		var sent []sentInvoiceMessage
+		h := newTestSettlementHandler(t, dbMocks.NewDatabase(t), &sent)
+
+		req := httptest.NewRequest(http.MethodPost, "/invoices/settled", bytes.NewBufferString(`{"payment_request": "lnbc_invoice", "settled": false}`))
+		req.Header.Set("x-webhook-secret", "webhook_secret")
+		rr := httptest.NewRecorder()
+		h.InvoiceSettledWebhook(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"settled": false}`, rr.Body.String())
+	})
+}
+
+func TestFakeSettleInvoice(t *testing.T) {
+	db.InitCache()
+	mockDb := dbMocks.NewDatabase(t)
+	var sent []sentInvoiceMessage
+	h := newTestSettlementHandler(t, mockDb, &sent)
+
+	subscribeToInvoice("lnbc_invoice", "websocket_token")
+	mockDb.On("GetInvoice", "lnbc_invoice").Return(db.NewInvoiceList{PaymentRequest: "lnbc_invoice", Type: db.PayInvoice})
+	mockDb.On("MarkInvoicePaid", "lnbc_invoice").Return(true, nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("paymentRequest", "lnbc_invoice")
+	req := httptest.NewRequest(http.MethodPost, "/test/invoices/lnbc_invoice/settle", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+	h.FakeSettleInvoice(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"settled": true}`, rr.Body.String())
+	assert.Equal(t, "websocket_token", sent[0].host)
+}
+
+func TestPollPendingInvoices(t *testing.T) {
+	db.InitCache()
+	mockDb := dbMocks.NewDatabase(t)
+	var sent []sentInvoiceMessage
+	h := newTestSettlementHandler(t, mockDb, &sent)
+
+	h.getLightningInvoice = func(ctx context.Context, paymentRequest string) (db.InvoiceResult, db.InvoiceError) {
+		switch paymentRequest {
+		case "lnbc_unreachable":
+			return db.InvoiceResult{}, db.InvoiceError{Error: "backend down"}
+		case "lnbc_settled":
+			return db.InvoiceResult{Success: true, Response: db.InvoiceCheckResponse{Settled: true}}, db.InvoiceError{}
+		}
+		return db.InvoiceResult{Success: true}, db.InvoiceError{}
+	}
+	h.getInvoiceExpired = func(paymentRequest string) bool {
+		return paymentRequest == "lnbc_expired"
+	}
+
+	mockDb.On("GetPendingInvoices").Return([]db.NewInvoiceList{
+		{PaymentRequest: "lnbc_unreachable"},
+		{PaymentRequest: "lnbc_settled", Type: db.PayInvoice},
+		{PaymentRequest: "lnbc_expired"},
+		{PaymentRequest: "lnbc_waiting"},
+	})
+	mockDb.On("GetInvoice", "lnbc_settled").Return(db.NewInvoiceList{PaymentRequest: "lnbc_settled", Type: db.PayInvoice})
+	mockDb.On("MarkInvoicePaid", "lnbc_settled").Return(true, nil)
+	mockDb.On("DeleteInvoice", "lnbc_expired").Return(db.NewInvoiceList{})
+
+	err := h.PollPendingInvoices()
+	assert.ErrorContains(t, err, "lnbc_unreachable")
+
+	mockDb.AssertNotCalled(t, "DeleteInvoice", "lnbc_waiting")
+}
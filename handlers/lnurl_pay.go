@@ -0,0 +1,423 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	lnurl "github.com/fiatjaf/go-lnurl"
+	"github.com/go-chi/chi"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+	"github.com/stakwork/sphinx-tribes/utils"
+)
+
+const (
+	lnurlPayMinSats           = 1
+	lnurlPayMaxSats           = 10000000
+	lnurlPayCommentLength     = 255
+	lightningAddressMaxLength = 64
+)
+
+var (
+	lightningAddressNameRegex  = regexp.MustCompile(`[^a-z0-9._-]`)
+	lightningAddressValidRegex = regexp.MustCompile(`^[a-z0-9._-]+$`)
+)
+
+type lnurlPayHandler struct {
+	db            db.Database
+	httpClient    HttpClient
+	createInvoice func(ctx context.Context, amount uint, metadata string) (string, error)
+}
+
+func NewLnurlPayHandler(httpClient HttpClient, database db.Database) *lnurlPayHandler {
+	h := &lnurlPayHandler{
+		db:         database,
+		httpClient: httpClient,
+	}
+	h.createInvoice = h.CreateLightningInvoice
+	return h
+}
+
+type WorkspaceLightningAddress struct {
+	LightningAddress string `json:"lightning_address"`
+	LNURL            string `json:"lnurl"`
+}
+
+type SetLightningAddressRequest struct {
+	Name string `json:"name"`
+}
+
+// LightningAddressName is the name a workspace's Lightning Address gets by
+// default, the workspace name lowercased with characters LUD-16 does not
+// allow replaced
+func LightningAddressName(workspaceName string) string {
+	return lightningAddressNameRegex.ReplaceAllString(strings.ToLower(workspaceName), "-")
+}
+
+// normalizeLightningAddressName lowercases a name chosen for a Lightning
+// Address, it is empty when the name has characters LUD-16 does not allow
+func normalizeLightningAddressName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) > lightningAddressMaxLength || !lightningAddressValidRegex.MatchString(name) {
+		return ""
+	}
+	return name
+}
+
+// lnurlPayURLs returns the LNURL-pay endpoint of a workspace and the domain
+// of its Lightning Address
+func lnurlPayURLs(host string, name string) (string, string) {
+	base := auth.LnurlHostURL(host)
+	domain := host
+	if parsed, err := url.Parse(base); err == nil && parsed.Host != "" {
+		domain = parsed.Host
+	}
+	return base + "/.well-known/lnurlp/" + name, domain
+}
+
+func workspacePayMetadata(workspace db.Workspace, address string) lnurl.Metadata {
+	return lnurl.Metadata{
+		Description:      fmt.Sprintf("Fund the %s workspace budget", workspace.Name),
+		LightningAddress: address,
+	}
+}
+
+// CreateLightningInvoice creates an invoice with the configured Lightning
+// backend that commits to the sha256 of the encoded LNURL-pay metadata, as
+// LUD-06 requires. Invoices the backend made without that description hash
+// are refused, wallets would reject them.
+func (h *lnurlPayHandler) CreateLightningInvoice(ctx context.Context, amount uint, metadata string) (string, error) {
+	hash := sha256.Sum256([]byte(metadata))
+	descriptionHash := hex.EncodeToString(hash[:])
+
+	var req *http.Request
+	if config.IsV2Payment {
+		body, _ := json.Marshal(map[string]interface{}{"amt_msat": amount * 1000, "description_hash": descriptionHash})
+		req, _ = http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/invoice", config.V2BotUrl), bytes.NewBuffer(body))
+		req.Header.Set("x-admin-token", config.V2BotToken)
+	} else {
+		body, _ := json.Marshal(map[string]interface{}{"amount": amount, "description_hash": descriptionHash})
+		req, _ = http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/invoices", config.RelayUrl), bytes.NewBuffer(body))
+		req.Header.Set("x-user-token", config.RelayAuthKey)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := h.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create invoice: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read invoice response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to create invoice, status %d", res.StatusCode)
+	}
+
+	var invoice string
+	if config.IsV2Payment {
+		invoiceRes := db.V2CreateInvoiceResponse{}
+		if err := json.Unmarshal(body, &invoiceRes); err != nil {
+			return "", fmt.Errorf("failed to parse invoice response: %w", err)
+		}
+		invoice = invoiceRes.Bolt11
+	} else {
+		invoiceRes := db.InvoiceResponse{}
+		if err := json.Unmarshal(body, &invoiceRes); err != nil {
+			return "", fmt.Errorf("failed to parse invoice response: %w", err)
+		}
+		invoice = invoiceRes.Response.Invoice
+	}
+	if invoice == "" {
+		return "", errors.New("backend returned no invoice")
+	}
+
+	if utils.GetInvoiceDescriptionHash(invoice) != descriptionHash {
+		return "", errors.New("backend returned an invoice without the metadata description hash")
+	}
+	return invoice, nil
+}
+
+// workspaceLightningAddress writes the Lightning Address of a workspace
+func workspaceLightningAddress(w http.ResponseWriter, r *http.Request, name string) {
+	payURL, domain := lnurlPayURLs(r.Host, name)
+
+	encoded, err := lnurl.Encode(payURL)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("[lnurl_pay] %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(WorkspaceLightningAddress{
+		LightningAddress: name + "@" + domain,
+		LNURL:            encoded,
+	})
+}
+
+// GetWorkspaceLightningAddress godoc
+//
+//	@Summary		Get a workspace's Lightning Address
+//	@Description	Get the Lightning Address and static LNURL-pay code anyone can use to fund the workspace budget
+//	@Tags			Workspace -  Payments
+//	@Produce		json
+//	@Param			uuid	path		string	true	"Workspace UUID"
+//	@Success		200		{object}	WorkspaceLightningAddress
+//	@Failure		404		{object}	string
+//	@Router			/workspaces/lightning-address/{uuid} [get]
+func (h *lnurlPayHandler) GetWorkspaceLightningAddress(w http.ResponseWriter, r *http.Request) {
+	workspace := h.db.GetWorkspaceByUuid(chi.URLParam(r, "uuid"))
+	if workspace.Uuid == "" || workspace.Deleted {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Workspace not found")
+		return
+	}
+
+	if workspace.LightningAddress == nil || *workspace.LightningAddress == "" {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Workspace has no Lightning Address")
+		return
+	}
+
+	workspaceLightningAddress(w, r, *workspace.LightningAddress)
+}
+
+// editableLightningAddressWorkspace returns the workspace of the request
+// when the authenticated user may change its Lightning Address, otherwise
+// it writes the error response
+func (h *lnurlPayHandler) editableLightningAddressWorkspace(w http.ResponseWriter, r *http.Request) (db.Workspace, bool) {
+	pubKeyFromAuth, _ := r.Context().Value(auth.ContextKey).(string)
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[lnurl_pay] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return db.Workspace{}, false
+	}
+
+	workspace := h.db.GetWorkspaceByUuid(chi.URLParam(r, "uuid"))
+	if workspace.Uuid == "" || workspace.Deleted {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Workspace not found")
+		return db.Workspace{}, false
+	}
+
+	if pubKeyFromAuth != workspace.OwnerPubKey && !h.db.UserHasAccess(pubKeyFromAuth, workspace.Uuid, db.EditOrg) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Don't have access to Edit workspace")
+		return db.Workspace{}, false
+	}
+	return workspace, true
+}
+
+// AssignWorkspaceLightningAddress godoc
+//
+//	@Summary		Assign a workspace's Lightning Address
+//	@Description	Give a workspace without a Lightning Address one from its name, unless another workspace has that name already. A workspace that has an address keeps it.
+//	@Tags			Workspace -  Payments
+//	@Produce		json
+//	@Security		PubKeyContextAuth
+//	@Param			uuid	path		string	true	"Workspace UUID"
+//	@Success		200		{object}	WorkspaceLightningAddress
+//	@Failure		401		{object}	string
+//	@Failure		404		{object}	string
+//	@Failure		409		{object}	string
+//	@Router			/workspaces/lightning-address/{uuid} [post]
+func (h *lnurlPayHandler) AssignWorkspaceLightningAddress(w http.ResponseWriter, r *http.Request) {
+	workspace, ok := h.editableLightningAddressWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	if workspace.LightningAddress != nil && *workspace.LightningAddress != "" {
+		workspaceLightningAddress(w, r, *workspace.LightningAddress)
+		return
+	}
+
+	updated, err := h.db.SetWorkspaceLightningAddress(workspace.Uuid, LightningAddressName(workspace.Name))
+	if errors.Is(err, db.ErrLightningAddressTaken) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode("Lightning Address is taken, choose another name")
+		return
+	}
+	if err != nil || updated.LightningAddress == nil {
+		logger.FromContext(r.Context()).Error("[lnurl_pay] failed to assign a lightning address: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	workspaceLightningAddress(w, r, *updated.LightningAddress)
+}
+
+// SetWorkspaceLightningAddress godoc
+//
+//	@Summary		Set a workspace's Lightning Address
+//	@Description	Choose the name of the workspace's Lightning Address. Names are lowercase letters, digits and ._- and cannot be used by two workspaces.
+//	@Tags			Workspace -  Payments
+//	@Accept			json
+//	@Produce		json
+//	@Security		PubKeyContextAuth
+//	@Param			uuid	path		string						true	"Workspace UUID"
+//	@Param			body	body		SetLightningAddressRequest	true	"Lightning Address name"
+//	@Success		200		{object}	WorkspaceLightningAddress
+//	@Failure		400		{object}	string
+//	@Failure		401		{object}	string
+//	@Failure		404		{object}	string
+//	@Failure		409		{object}	string
+//	@Router			/workspaces/lightning-address/{uuid} [put]
+func (h *lnurlPayHandler) SetWorkspaceLightningAddress(w http.ResponseWriter, r *http.Request) {
+	workspace, ok := h.editableLightningAddressWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	request := SetLightningAddressRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Invalid request body")
+		return
+	}
+	name := normalizeLightningAddressName(request.Name)
+	if name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(fmt.Sprintf("Lightning Address names are 1 to %d lowercase letters, digits, '.', '_' or '-'", lightningAddressMaxLength))
+		return
+	}
+
+	updated, err := h.db.SetWorkspaceLightningAddress(workspace.Uuid, name)
+	if errors.Is(err, db.ErrLightningAddressTaken) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode("Lightning Address is taken by another workspace")
+		return
+	}
+	if err != nil || updated.LightningAddress == nil {
+		logger.FromContext(r.Context()).Error("[lnurl_pay] failed to set the lightning address: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	workspaceLightningAddress(w, r, *updated.LightningAddress)
+}
+
+// LnurlPay godoc
+//
+//	@Summary		LNURL-pay request
+//	@Description	Lightning Address and LNURL-pay endpoint of a workspace, returns the payRequest wallets use to fund the workspace budget
+//	@Tags			Workspace -  Payments
+//	@Produce		json
+//	@Param			name	path		string	true	"Lightning Address name"
+//	@Success		200		{object}	lnurl.LNURLPayParams
+//	@Router			/.well-known/lnurlp/{name} [get]
+func (h *lnurlPayHandler) LnurlPay(w http.ResponseWriter, r *http.Request) {
+	name := strings.ToLower(chi.URLParam(r, "name"))
+	workspace := h.db.GetWorkspaceByLightningAddress(name)
+	if workspace.Uuid == "" {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(lnurl.ErrorResponse("Unknown Lightning Address"))
+		return
+	}
+
+	payURL, domain := lnurlPayURLs(r.Host, name)
+	metadata := workspacePayMetadata(workspace, name+"@"+domain)
+
+	json.NewEncoder(w).Encode(lnurl.LNURLPayParams{
+		Tag:             "payRequest",
+		Callback:        payURL + "/callback",
+		MinSendable:     lnurlPayMinSats * 1000,
+		MaxSendable:     lnurlPayMaxSats * 1000,
+		EncodedMetadata: metadata.Encode(),
+		CommentAllowed:  lnurlPayCommentLength,
+	})
+}
+
+// LnurlPayCallback godoc
+//
+//	@Summary		LNURL-pay callback
+//	@Description	Called by the payer's wallet with the amount, returns an invoice that credits the workspace budget once it is settled
+//	@Tags			Workspace -  Payments
+//	@Produce		json
+//	@Param			name	path		string	true	"Lightning Address name"
+//	@Param			amount	query		int		true	"Amount in millisatoshis"
+//	@Param			comment	query		string	false	"Payer comment"
+//	@Success		200		{object}	lnurl.LNURLPayValues
+//	@Router			/.well-known/lnurlp/{name}/callback [get]
+func (h *lnurlPayHandler) LnurlPayCallback(w http.ResponseWriter, r *http.Request) {
+	name := strings.ToLower(chi.URLParam(r, "name"))
+	workspace := h.db.GetWorkspaceByLightningAddress(name)
+	if workspace.Uuid == "" {
+		json.NewEncoder(w).Encode(lnurl.ErrorResponse("Unknown Lightning Address"))
+		return
+	}
+
+	msat, err := strconv.ParseInt(r.URL.Query().Get("amount"), 10, 64)
+	if err != nil || msat%1000 != 0 || msat < lnurlPayMinSats*1000 || msat > lnurlPayMaxSats*1000 {
+		json.NewEncoder(w).Encode(lnurl.ErrorResponse(fmt.Sprintf("Amount must be whole sats between %d and %d", lnurlPayMinSats, lnurlPayMaxSats)))
+		return
+	}
+	amount := uint(msat / 1000)
+
+	comment := r.URL.Query().Get("comment")
+	if len(comment) > lnurlPayCommentLength {
+		json.NewEncoder(w).Encode(lnurl.ErrorResponse(fmt.Sprintf("Comment must be at most %d characters", lnurlPayCommentLength)))
+		return
+	}
+
+	_, domain := lnurlPayURLs(r.Host, name)
+	metadata := workspacePayMetadata(workspace, name+"@"+domain)
+
+	paymentRequest, err := h.createInvoice(r.Context(), amount, metadata.Encode())
+	if err != nil {
+		logger.FromContext(r.Context()).Error("[lnurl_pay] %v", err)
+		json.NewEncoder(w).Encode(lnurl.ErrorResponse("Could not create an invoice"))
+		return
+	}
+
+	// recorded like a budget invoice, the budget is credited through the
+	// same settlement path once the invoice is paid
+	now := time.Now()
+	paymentHistory := db.NewPaymentHistory{
+		Amount:        amount,
+		WorkspaceUuid: workspace.Uuid,
+		PaymentType:   db.Deposit,
+		Comment:       comment,
+		Created:       &now,
+		Updated:       &now,
+		Status:        false,
+	}
+	newInvoice := db.NewInvoiceList{
+		PaymentRequest: paymentRequest,
+		Type:           db.Budget,
+		WorkspaceUuid:  workspace.Uuid,
+		Created:        &now,
+		Updated:        &now,
+		Status:         false,
+	}
+	if err := h.db.ProcessBudgetInvoice(paymentHistory, newInvoice); err != nil {
+		logger.FromContext(r.Context()).Error("[lnurl_pay] %v", err)
+		json.NewEncoder(w).Encode(lnurl.ErrorResponse("Could not create an invoice"))
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("[lnurl_pay] created %d sats invoice for workspace %s", amount, workspace.Uuid)
+
+	json.NewEncoder(w).Encode(lnurl.LNURLPayValues{
+		PR:            paymentRequest,
+		Routes:        []interface{}{},
+		SuccessAction: lnurl.Action(fmt.Sprintf("Thanks for funding %s", workspace.Name), ""),
+	})
+}
@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	lnurl "github.com/fiatjaf/go-lnurl"
+	"github.com/go-chi/chi"
+	"github.com/lightningnetwork/lnd/zpay32"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/handlers/mocks"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newLnurlPayRequest(target string, name string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("name", name)
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestLightningAddressName(t *testing.T) {
+	assert.Equal(t, "sphinx-tribes", LightningAddressName("Sphinx Tribes"))
+	assert.Equal(t, "stak.work_1", LightningAddressName("stak.work_1"))
+	assert.Equal(t, "caf--", LightningAddressName("Café!"))
+}
+
+func newLightningAddressRequest(method string, uuid string, body string, pubkey string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("uuid", uuid)
+	req := httptest.NewRequest(method, "http://example.com/workspaces/lightning-address/"+uuid, bytes.NewBufferString(body))
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	if pubkey != "" {
+		ctx = context.WithValue(ctx, auth.ContextKey, pubkey)
+	}
+	return req.WithContext(ctx)
+}
+
+// newTestInvoice encodes a signed invoice committing to a description hash
+func newTestInvoice(t *testing.T, descriptionHash [32]byte) string {
+	privKey, _ := btcec.NewPrivateKey()
+	invoice, err := zpay32.NewInvoice(&chaincfg.MainNetParams, [32]byte{1}, time.Now(), zpay32.DescriptionHash(descriptionHash))
+	assert.NoError(t, err)
+
+	encoded, err := invoice.Encode(zpay32.MessageSigner{
+		SignCompact: func(msg []byte) ([]byte, error) {
+			return ecdsa.SignCompact(privKey, chainhash.HashB(msg), true)
+		},
+	})
+	assert.NoError(t, err)
+	return encoded
+}
+
+func TestNormalizeLightningAddressName(t *testing.T) {
+	assert.Equal(t, "sphinx", normalizeLightningAddressName(" Sphinx "))
+	assert.Equal(t, "stak.work_1", normalizeLightningAddressName("stak.work_1"))
+	assert.Empty(t, normalizeLightningAddressName("café"))
+	assert.Empty(t, normalizeLightningAddressName(""))
+	assert.Empty(t, normalizeLightningAddressName(string(bytes.Repeat([]byte("a"), lightningAddressMaxLength+1))))
+}
+
+func TestGetWorkspaceLightningAddress(t *testing.T) {
+	assigned := "sphinx-tribes"
+
+	t.Run("should return the address and a static LNURL", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+
+		mockDb.On("GetWorkspaceByUuid", "workspace_uuid").Return(db.Workspace{Uuid: "workspace_uuid", Name: "Renamed", LightningAddress: &assigned})
+
+		rr := httptest.NewRecorder()
+		h.GetWorkspaceLightningAddress(rr, newLightningAddressRequest(http.MethodGet, "workspace_uuid", "", ""))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response WorkspaceLightningAddress
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "sphinx-tribes@example.com", response.LightningAddress)
+
+		decoded, err := lnurl.LNURLDecode(response.LNURL)
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/.well-known/lnurlp/sphinx-tribes", decoded)
+	})
+
+	t.Run("should not assign an address to a workspace without one", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+
+		mockDb.On("GetWorkspaceByUuid", "workspace_uuid").Return(db.Workspace{Uuid: "workspace_uuid", Name: "Sphinx Tribes"})
+
+		rr := httptest.NewRecorder()
+		h.GetWorkspaceLightningAddress(rr, newLightningAddressRequest(http.MethodGet, "workspace_uuid", "", ""))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockDb.AssertNotCalled(t, "SetWorkspaceLightningAddress", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should not return addresses of deleted workspaces", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+
+		mockDb.On("GetWorkspaceByUuid", "workspace_uuid").Return(db.Workspace{Uuid: "workspace_uuid", Name: "Sphinx", Deleted: true})
+
+		rr := httptest.NewRecorder()
+		h.GetWorkspaceLightningAddress(rr, newLightningAddressRequest(http.MethodGet, "workspace_uuid", "", ""))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestAssignWorkspaceLightningAddress(t *testing.T) {
+	workspace := db.Workspace{Uuid: "workspace_uuid", Name: "Sphinx Tribes", OwnerPubKey: "owner"}
+
+	t.Run("should assign an address from the workspace name", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+
+		assigned := "sphinx-tribes"
+		mockDb.On("GetWorkspaceByUuid", "workspace_uuid").Return(workspace)
+		mockDb.On("SetWorkspaceLightningAddress", "workspace_uuid", "sphinx-tribes").Return(db.Workspace{Uuid: "workspace_uuid", LightningAddress: &assigned}, nil)
+
+		rr := httptest.NewRecorder()
+		h.AssignWorkspaceLightningAddress(rr, newLightningAddressRequest(http.MethodPost, "workspace_uuid", "", "owner"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "sphinx-tribes@example.com")
+	})
+
+	t.Run("should keep the address a workspace has", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+
+		chosen := "sphinx.fund"
+		withAddress := workspace
+		withAddress.LightningAddress = &chosen
+		mockDb.On("GetWorkspaceByUuid", "workspace_uuid").Return(withAddress)
+
+		rr := httptest.NewRecorder()
+		h.AssignWorkspaceLightningAddress(rr, newLightningAddressRequest(http.MethodPost, "workspace_uuid", "", "owner"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "sphinx.fund@example.com")
+	})
+
+	t.Run("should not take the address of another workspace", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+
+		mockDb.On("GetWorkspaceByUuid", "workspace_uuid").Return(workspace)
+		mockDb.On("SetWorkspaceLightningAddress", "workspace_uuid", "sphinx-tribes").Return(db.Workspace{}, db.ErrLightningAddressTaken)
+
+		rr := httptest.NewRecorder()
+		h.AssignWorkspaceLightningAddress(rr, newLightningAddressRequest(http.MethodPost, "workspace_uuid", "", "owner"))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("should only let workspace admins assign the address", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+
+		mockDb.On("GetWorkspaceByUuid", "workspace_uuid").Return(workspace)
+		mockDb.On("UserHasAccess", "someone", "workspace_uuid", db.EditOrg).Return(false)
+
+		rr := httptest.NewRecorder()
+		h.AssignWorkspaceLightningAddress(rr, newLightningAddressRequest(http.MethodPost, "workspace_uuid", "", "someone"))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestSetWorkspaceLightningAddress(t *testing.T) {
+	workspace := db.Workspace{Uuid: "workspace_uuid", Name: "Sphinx", OwnerPubKey: "owner"}
+
+	t.Run("should set a normalized address for the owner", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+
+		assigned := "sphinx.fund"
+		mockDb.On("GetWorkspaceByUuid", "workspace_uuid").Return(workspace)
+		mockDb.On("SetWorkspaceLightningAddress", "workspace_uuid", "sphinx.fund").Return(db.Workspace{Uuid: "workspace_uuid", LightningAddress: &assigned}, nil)
+
+		rr := httptest.NewRecorder()
+		h.SetWorkspaceLightningAddress(rr, newLightningAddressRequest(http.MethodPut, "workspace_uuid", `{"name": "Sphinx.Fund"}`, "owner"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "sphinx.fund@example.com")
+	})
+
+	t.Run("should reject an address taken by another workspace", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+
+		mockDb.On("GetWorkspaceByUuid", "workspace_uuid").Return(workspace)
+		mockDb.On("SetWorkspaceLightningAddress", "workspace_uuid", "taken").Return(db.Workspace{}, db.ErrLightningAddressTaken)
+
+		rr := httptest.NewRecorder()
+		h.SetWorkspaceLightningAddress(rr, newLightningAddressRequest(http.MethodPut, "workspace_uuid", `{"name": "taken"}`, "owner"))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("should reject names LUD-16 does not allow", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+
+		mockDb.On("GetWorkspaceByUuid", "workspace_uuid").Return(workspace)
+
+		rr := httptest.NewRecorder()
+		h.SetWorkspaceLightningAddress(rr, newLightningAddressRequest(http.MethodPut, "workspace_uuid", `{"name": "sphinx tribes"}`, "owner"))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("should only let workspace admins set the address", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+
+		mockDb.On("GetWorkspaceByUuid", "workspace_uuid").Return(workspace)
+		mockDb.On("UserHasAccess", "someone", "workspace_uuid", db.EditOrg).Return(false)
+
+		rr := httptest.NewRecorder()
+		h.SetWorkspaceLightningAddress(rr, newLightningAddressRequest(http.MethodPut, "workspace_uuid", `{"name": "mine"}`, "someone"))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestLnurlPay(t *testing.T) {
+	t.Run("should return a pay request for the workspace", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+
+		mockDb.On("GetWorkspaceByLightningAddress", "sphinx-tribes").Return(db.Workspace{Uuid: "workspace_uuid", Name: "Sphinx Tribes"})
+
+		rr := httptest.NewRecorder()
+		h.LnurlPay(rr, newLnurlPayRequest("http://example.com/.well-known/lnurlp/sphinx-tribes", "sphinx-tribes"))
+
+		var response lnurl.LNURLPayParams
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "payRequest", response.Tag)
+		assert.Equal(t, "https://example.com/.well-known/lnurlp/sphinx-tribes/callback", response.Callback)
+		assert.Equal(t, int64(lnurlPayMinSats*1000), response.MinSendable)
+		assert.Equal(t, int64(lnurlPayCommentLength), response.CommentAllowed)
+		assert.Contains(t, response.EncodedMetadata, `["text/identifier","sphinx-tribes@example.com"]`)
+	})
+
+	t.Run("should return not found for unknown names", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+
+		mockDb.On("GetWorkspaceByLightningAddress", "unknown").Return(db.Workspace{})
+
+		rr := httptest.NewRecorder()
+		h.LnurlPay(rr, newLnurlPayRequest("/.well-known/lnurlp/unknown", "unknown"))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestLnurlPayCallback(t *testing.T) {
+	workspace := db.Workspace{Uuid: "workspace_uuid", Name: "Sphinx Tribes"}
+
+	t.Run("should create a budget invoice with the payer comment", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+		h.createInvoice = func(ctx context.Context, amount uint, metadata string) (string, error) {
+			assert.Equal(t, uint(2500), amount)
+			assert.Contains(t, metadata, `["text/identifier","sphinx-tribes@example.com"]`)
+			return "lnbc_invoice", nil
+		}
+
+		mockDb.On("GetWorkspaceByLightningAddress", "sphinx-tribes").Return(workspace)
+		mockDb.On("ProcessBudgetInvoice", mock.MatchedBy(func(payment db.NewPaymentHistory) bool {
+			return payment.Amount == 2500 && payment.WorkspaceUuid == "workspace_uuid" &&
+				payment.PaymentType == db.Deposit && payment.Comment == "keep building" && !payment.Status
+		}), mock.MatchedBy(func(invoice db.NewInvoiceList) bool {
+			return invoice.PaymentRequest == "lnbc_invoice" && invoice.Type == db.Budget &&
+				invoice.WorkspaceUuid == "workspace_uuid" && invoice.Created != nil
+		})).Return(nil)
+
+		rr := httptest.NewRecorder()
+		h.LnurlPayCallback(rr, newLnurlPayRequest("/.well-known/lnurlp/sphinx-tribes/callback?amount=2500000&comment=keep+building", "sphinx-tribes"))
+
+		var response lnurl.LNURLPayValues
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Empty(t, response.Status)
+		assert.Equal(t, "lnbc_invoice", response.PR)
+		assert.Equal(t, "message", response.SuccessAction.Tag)
+	})
+
+	t.Run("should reject amounts that are not whole sats", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+
+		mockDb.On("GetWorkspaceByLightningAddress", "sphinx-tribes").Return(workspace)
+
+		rr := httptest.NewRecorder()
+		h.LnurlPayCallback(rr, newLnurlPayRequest("/.well-known/lnurlp/sphinx-tribes/callback?amount=1500", "sphinx-tribes"))
+
+		var response lnurl.LNURLResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "ERROR", response.Status)
+	})
+
+	t.Run("should reject long comments", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+
+		mockDb.On("GetWorkspaceByLightningAddress", "sphinx-tribes").Return(workspace)
+
+		comment := string(bytes.Repeat([]byte("a"), lnurlPayCommentLength+1))
+		rr := httptest.NewRecorder()
+		h.LnurlPayCallback(rr, newLnurlPayRequest("/.well-known/lnurlp/sphinx-tribes/callback?amount=1000&comment="+comment, "sphinx-tribes"))
+
+		var response lnurl.LNURLResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "ERROR", response.Status)
+	})
+
+	t.Run("should not record anything when the invoice cannot be created", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		h := NewLnurlPayHandler(mocks.NewHttpClient(t), mockDb)
+		h.createInvoice = func(ctx context.Context, amount uint, metadata string) (string, error) {
+			return "", errors.New("backend down")
+		}
+
+		mockDb.On("GetWorkspaceByLightningAddress", "sphinx-tribes").Return(workspace)
+
+		rr := httptest.NewRecorder()
+		h.LnurlPayCallback(rr, newLnurlPayRequest("/.well-known/lnurlp/sphinx-tribes/callback?amount=1000", "sphinx-tribes"))
+
+		var response lnurl.LNURLResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "ERROR", response.Status)
+	})
+}
+
+func TestCreateLightningInvoice(t *testing.T) {
+	isV2Payment, relayUrl := config.IsV2Payment, config.RelayUrl
+	defer func() { config.IsV2Payment, config.RelayUrl = isV2Payment, relayUrl }()
+	config.IsV2Payment = false
+	config.RelayUrl = "http://relay"
+
+	metadata := `[["text/plain","Fund the Sphinx workspace budget"]]`
+	descriptionHash := sha256.Sum256([]byte(metadata))
+
+	invoiceResponse := func(invoice string) *http.Response {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"success": true, "response": {"invoice": "` + invoice + `"}}`))),
+		}
+	}
+
+	t.Run("should request an invoice committing to the metadata", func(t *testing.T) {
+		mockHttpClient := mocks.NewHttpClient(t)
+		h := NewLnurlPayHandler(mockHttpClient, dbMocks.NewDatabase(t))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		expected := newTestInvoice(t, descriptionHash)
+		mockHttpClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			if req.Context() != ctx {
+				return false
+			}
+			body, _ := io.ReadAll(req.Body)
+			var data map[string]interface{}
+			json.Unmarshal(body, &data)
+			return req.Method == http.MethodPost && req.URL.String() == "http://relay/invoices" &&
+				data["amount"] == float64(1000) && data["description_hash"] == hex.EncodeToString(descriptionHash[:]) &&
+				data["memo"] == nil
+		})).Return(invoiceResponse(expected), nil)
+
+		invoice, err := h.CreateLightningInvoice(ctx, 1000, metadata)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, invoice)
+	})
+
+	t.Run("should refuse invoices without the description hash", func(t *testing.T) {
+		mockHttpClient := mocks.NewHttpClient(t)
+		h := NewLnurlPayHandler(mockHttpClient, dbMocks.NewDatabase(t))
+
+		mockHttpClient.On("Do", mock.Anything).Return(invoiceResponse(newTestInvoice(t, sha256.Sum256([]byte("memo")))), nil)
+
+		_, err := h.CreateLightningInvoice(context.Background(), 1000, metadata)
+		assert.Error(t, err)
+	})
+}
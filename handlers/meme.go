@@ -2,16 +2,17 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/stakwork/sphinx-tribes/auth"
 	"github.com/stakwork/sphinx-tribes/config"
@@ -35,7 +36,7 @@ func MemeImageUpload(w http.ResponseWriter, r *http.Request) {
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -49,7 +50,7 @@ func MemeImageUpload(w http.ResponseWriter, r *http.Request) {
 	defer file.Close()
 
 	// Check if uploads directory exists or create it
-	CreateUploadsDirectory(dirName)
+	CreateUploadsDirectory(r.Context(), dirName)
 
 	// Saving the file
 	dst, err := os.Create(dirName + "/" + header.Filename)
@@ -67,17 +68,17 @@ func MemeImageUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	challenge := GetMemeChallenge()
-	signer := SignChallenge(challenge.Challenge)
-	mErr, mToken := GetMemeToken(challenge.Id, signer.Response.Sig)
+	challenge := GetMemeChallenge(r.Context())
+	signer := SignChallenge(r.Context(), challenge.Challenge)
+	mErr, mToken := GetMemeToken(r.Context(), challenge.Id, signer.Response.Sig)
 
 	if mErr != "" {
 		msg := "Could not get meme token"
-		logger.Log.Error("%s: %s", msg, mErr)
+		logger.FromContext(r.Context()).Error("%s: %s", msg, mErr)
 		w.WriteHeader(http.StatusNoContent)
 		json.NewEncoder(w).Encode(msg)
 	} else {
-		err, memeImgUrl := UploadMemeImage(file, mToken.Token, header.Filename)
+		err, memeImgUrl := UploadMemeImage(r.Context(), file, mToken.Token, header.Filename)
 		if err == nil {
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(memeImgUrl)
@@ -85,25 +86,25 @@ func MemeImageUpload(w http.ResponseWriter, r *http.Request) {
 		}
 
 		msg := "Could not get meme image"
-		logger.Log.Error("%s", msg)
+		logger.FromContext(r.Context()).Error("%s", msg)
 		w.WriteHeader(http.StatusNoContent)
 		json.NewEncoder(w).Encode(msg)
 	}
 }
 
-func GetMemeChallenge() db.MemeChallenge {
+func GetMemeChallenge(ctx context.Context) db.MemeChallenge {
 	memeChallenge := db.MemeChallenge{}
 
 	url := fmt.Sprintf("%s/ask", config.MemeUrl)
 
 	client := &http.Client{}
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 
 	req.Header.Set("Content-Type", "application/json")
 	res, _ := client.Do(req)
 
 	if err != nil {
-		log.Printf("Request Failed: %s", err)
+		logger.FromContext(ctx).Error("Request Failed: %s", err)
 	}
 
 	defer res.Body.Close()
@@ -114,7 +115,7 @@ func GetMemeChallenge() db.MemeChallenge {
 	err = json.Unmarshal(body, &memeChallenge)
 
 	if err != nil {
-		log.Printf("Reading Invoice body failed: %s", err)
+		logger.FromContext(ctx).Error("Reading Invoice body failed: %s", err)
 	}
 
 	return memeChallenge
@@ -128,7 +129,7 @@ type V2SignChallengeResponse struct {
 	Sig string `json:"sig"`
 }
 
-func SignChallenge(challenge string) db.RelaySignerResponse {
+func SignChallenge(ctx context.Context, challenge string) db.RelaySignerResponse {
 
 	if config.V2BotUrl != "" {
 		url := fmt.Sprintf("%s/sign_base64", config.V2BotUrl)
@@ -140,13 +141,13 @@ func SignChallenge(challenge string) db.RelaySignerResponse {
 
 		jsonBody, _ := json.Marshal(challengeBody)
 
-		req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonBody))
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonBody))
 		req.Header.Set("x-admin-token", config.V2BotToken)
 		req.Header.Set("Content-Type", "application/json")
 		res, _ := client.Do(req)
 
 		if err != nil {
-			log.Printf("[Sign Challenge for V2] Request Failed: %s", err)
+			logger.FromContext(ctx).Error("[Sign Challenge for V2] Request Failed: %s", err)
 			return db.RelaySignerResponse{
 				Success:  false,
 				Response: db.SignerResponse(db.SignerResponse{Sig: ""}),
@@ -158,7 +159,7 @@ func SignChallenge(challenge string) db.RelaySignerResponse {
 		body, err := io.ReadAll(res.Body)
 
 		if err != nil {
-			log.Printf("[Sign Challenge for V2] Reading sign challenge response body failed: %s", err)
+			logger.FromContext(ctx).Error("[Sign Challenge for V2] Reading sign challenge response body failed: %s", err)
 			return db.RelaySignerResponse{
 				Success:  false,
 				Response: db.SignerResponse(db.SignerResponse{Sig: ""}),
@@ -171,7 +172,7 @@ func SignChallenge(challenge string) db.RelaySignerResponse {
 		err = json.Unmarshal(body, &v2SignChallengeResponse)
 
 		if err != nil {
-			log.Printf("[Sign Challenge for V2] Unmarshalling response body failed: %s", err)
+			logger.FromContext(ctx).Error("[Sign Challenge for V2] Unmarshalling response body failed: %s", err)
 			return db.RelaySignerResponse{
 				Success:  false,
 				Response: db.SignerResponse(db.SignerResponse{Sig: ""}),
@@ -190,14 +191,14 @@ func SignChallenge(challenge string) db.RelaySignerResponse {
 	url := fmt.Sprintf("%s/signer/%s", config.RelayUrl, challenge)
 
 	client := &http.Client{}
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 
 	req.Header.Set("x-user-token", config.RelayAuthKey)
 	req.Header.Set("Content-Type", "application/json")
 	res, _ := client.Do(req)
 
 	if err != nil {
-		log.Printf("Request Failed: %s", err)
+		logger.FromContext(ctx).Error("Request Failed: %s", err)
 	}
 
 	defer res.Body.Close()
@@ -210,13 +211,13 @@ func SignChallenge(challenge string) db.RelaySignerResponse {
 	err = json.Unmarshal(body, &signerResponse)
 
 	if err != nil {
-		log.Printf("Reading Challenge body failed: %s", err)
+		logger.FromContext(ctx).Error("Reading Challenge body failed: %s", err)
 	}
 
 	return signerResponse
 }
 
-func GetMemeToken(id string, sig string) (string, db.MemeTokenSuccess) {
+func GetMemeToken(ctx context.Context, id string, sig string) (string, db.MemeTokenSuccess) {
 	memeUrl := fmt.Sprintf("%s/verify", config.MemeUrl)
 
 	var pubkey string
@@ -233,10 +234,16 @@ func GetMemeToken(id string, sig string) (string, db.MemeTokenSuccess) {
 		"pubkey": {pubkey},
 	}
 
-	res, err := http.PostForm(memeUrl, formData)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, memeUrl, strings.NewReader(formData.Encode()))
+	if err != nil {
+		logger.FromContext(ctx).Error("Request Failed: %s", err)
+		return "", db.MemeTokenSuccess{}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := http.DefaultClient.Do(req)
 
 	if err != nil {
-		log.Printf("Request Failed: %s", err)
+		logger.FromContext(ctx).Error("Request Failed: %s", err)
 		return "", db.MemeTokenSuccess{}
 	}
 
@@ -250,7 +257,7 @@ func GetMemeToken(id string, sig string) (string, db.MemeTokenSuccess) {
 		err = json.Unmarshal(body, &tokenSuccess)
 
 		if err != nil {
-			log.Printf("Reading token success body failed: %s", err)
+			logger.FromContext(ctx).Error("Reading token success body failed: %s", err)
 		}
 
 		return "", tokenSuccess
@@ -261,66 +268,85 @@ func GetMemeToken(id string, sig string) (string, db.MemeTokenSuccess) {
 		err = json.Unmarshal(body, &tokenError)
 
 		if err != nil {
-			log.Printf("Reading token error body failed: %s %d", err, res.StatusCode)
+			logger.FromContext(ctx).Error("Reading token error body failed: %s %d", err, res.StatusCode)
 		}
 
 		return tokenError, db.MemeTokenSuccess{}
 	}
 }
 
-func UploadMemeImage(file multipart.File, token string, fileName string) (error, string) {
-	url := fmt.Sprintf("%s/public", config.MemeUrl)
+func UploadMemeImage(ctx context.Context, file multipart.File, token string, fileName string) (error, string) {
 	filePath := path.Join("./uploads", fileName)
 	fileW, _ := os.Open(filePath)
 	defer file.Close()
 
+	memeUrl, err := UploadMemeFile(ctx, token, filepath.Base(filePath), fileW)
+	fileW.Close()
+
+	// Delete image from uploads folder
+	DeleteFileFromUploadsFolder(ctx, filePath)
+
+	if err != nil {
+		logger.FromContext(ctx).Error("meme request Error: %v", err)
+		return err, ""
+	}
+
+	return nil, memeUrl
+}
+
+// UploadMemeFile stores content on the meme server and returns its public URL
+func UploadMemeFile(ctx context.Context, token string, fileName string, content io.Reader) (string, error) {
+	url := fmt.Sprintf("%s/public", config.MemeUrl)
+
 	fileBody := &bytes.Buffer{}
 	writer := multipart.NewWriter(fileBody)
-	part, _ := writer.CreateFormFile("file", filepath.Base(filePath))
-	io.Copy(part, fileW)
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, content); err != nil {
+		return "", err
+	}
 	writer.Close()
 
-	client := &http.Client{}
-	req, err := http.NewRequest(http.MethodPost, url, fileBody)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, fileBody)
+	if err != nil {
+		return "", err
+	}
 	req.Header.Set("Authorization", "BEARER "+token)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
-	res, err := client.Do(req)
-
-	// Delete image from uploads folder
-	DeleteFileFromUploadsFolder(filePath)
-
+	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		logger.Log.Error("meme request Error: %v", err)
-		return err, ""
+		return "", err
 	}
 
 	defer res.Body.Close()
 	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
 
-	if err == nil {
-		memeSuccess := db.Meme{}
-		// Unmarshal result
-		err = json.Unmarshal(body, &memeSuccess)
-		if err != nil {
-			log.Printf("Reading meme error body failed: %s", err)
-		} else {
-			return nil, config.MemeUrl + "/public/" + memeSuccess.Muid
-		}
+	memeSuccess := db.Meme{}
+	if err := json.Unmarshal(body, &memeSuccess); err != nil {
+		return "", fmt.Errorf("reading meme body failed: %w", err)
+	}
+	if memeSuccess.Muid == "" {
+		return "", fmt.Errorf("meme server did not store the file: %s", res.Status)
 	}
 
-	return err, ""
+	return config.MemeUrl + "/public/" + memeSuccess.Muid, nil
 }
 
-func DeleteFileFromUploadsFolder(filePath string) {
+func DeleteFileFromUploadsFolder(ctx context.Context, filePath string) {
 	e := os.Remove(filePath)
 	if e != nil {
-		log.Printf("Could not delete Image %s %s", filePath, e)
+		logger.FromContext(ctx).Error("Could not delete Image %s %s", filePath, e)
 	}
 }
 
-func CreateUploadsDirectory(dirName string) {
+func CreateUploadsDirectory(ctx context.Context, dirName string) {
 	if _, err := os.Open(dirName); os.IsNotExist(err) {
-		logger.Log.Info("The directory named %s does not exist", dirName)
+		logger.FromContext(ctx).Info("The directory named %s does not exist", dirName)
 		os.Mkdir(dirName, 0755)
 	}
 }
@@ -50,7 +50,7 @@ func PaymentMetrics(w http.ResponseWriter, r *http.Request) {
 	workspace := keys.Get("workspace")
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -88,7 +88,7 @@ func WorkspacetMetrics(w http.ResponseWriter, r *http.Request) {
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -126,7 +126,7 @@ func PeopleMetrics(w http.ResponseWriter, r *http.Request) {
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -174,7 +174,7 @@ func (mh *metricHandler) BountyMetrics(w http.ResponseWriter, r *http.Request) {
 	workspace := keys.Get("workspace")
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -210,7 +210,7 @@ func (mh *metricHandler) BountyMetrics(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	} else {
-		logger.Log.Info("Redis client is not initialized or there is an error with Redis")
+		logger.FromContext(r.Context()).Info("Redis client is not initialized or there is an error with Redis")
 	}
 
 	totalBountiesPosted := mh.db.TotalBountiesPosted(request, workspace)
@@ -247,7 +247,7 @@ func (mh *metricHandler) BountyMetrics(w http.ResponseWriter, r *http.Request) {
 		metricsMap := structs.Map(bountyMetrics)
 		db.SetMap(metricsKey, metricsMap)
 	} else {
-		logger.Log.Info("Redis client is not initialized or there is an error with Redis")
+		logger.FromContext(r.Context()).Info("Redis client is not initialized or there is an error with Redis")
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -270,7 +270,7 @@ func (mh *metricHandler) MetricsBounties(w http.ResponseWriter, r *http.Request)
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -309,7 +309,7 @@ func (mh *metricHandler) MetricsBountiesCount(w http.ResponseWriter, r *http.Req
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -346,7 +346,7 @@ func (mh *metricHandler) MetricsBountiesProviders(w http.ResponseWriter, r *http
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -383,7 +383,7 @@ func MetricsCsv(w http.ResponseWriter, r *http.Request) {
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -406,10 +406,10 @@ func MetricsCsv(w http.ResponseWriter, r *http.Request) {
 	resultLength := len(result)
 
 	if resultLength > 0 {
-		err, url := UploadMetricsCsv(result, request)
+		err, url := UploadMetricsCsv(r.Context(), result, request)
 
 		if err != nil {
-			logger.Log.Error("Error uploading csv: %v", err)
+			logger.FromContext(r.Context()).Error("Error uploading csv: %v", err)
 		}
 
 		w.WriteHeader(http.StatusOK)
@@ -434,7 +434,7 @@ func GetAdminWorkspaces(w http.ResponseWriter, r *http.Request) {
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -526,9 +526,9 @@ func ConvertMetricsToCSV(metricBountiesData []db.MetricsBountyCsv) [][]string {
 	return result
 }
 
-func UploadMetricsCsv(data [][]string, request db.PaymentDateRange) (error, string) {
+func UploadMetricsCsv(ctx context.Context, data [][]string, request db.PaymentDateRange) (error, string) {
 	dirName := "uploads"
-	CreateUploadsDirectory(dirName)
+	CreateUploadsDirectory(ctx, dirName)
 
 	filePath := path.Join("./uploads", "metrics.csv")
 	csvFile, err := os.Create(filePath)
@@ -558,23 +558,23 @@ func UploadMetricsCsv(data [][]string, request db.PaymentDateRange) (error, stri
 	err, postPresignedUrl := createPresignedUrl(path)
 
 	if err != nil {
-		logger.Log.Error("Presigned Error: %v", err)
+		logger.FromContext(ctx).Error("Presigned Error: %v", err)
 	}
 
-	r, err := http.NewRequest(http.MethodPut, postPresignedUrl, bytes.NewReader(fileBuffer))
+	r, err := http.NewRequestWithContext(ctx, http.MethodPut, postPresignedUrl, bytes.NewReader(fileBuffer))
 	if err != nil {
-		logger.Log.Error("Posting presign s3 error: %v", err)
+		logger.FromContext(ctx).Error("Posting presign s3 error: %v", err)
 	}
 	r.Header.Set("Content-Type", "multipart/form-data")
 	client := &http.Client{}
 	_, err = client.Do(r)
 
 	if err != nil {
-		logger.Log.Error("Error occurred while posting presigned URL: %v", err)
+		logger.FromContext(ctx).Error("Error occurred while posting presigned URL: %v", err)
 	}
 
 	// Delete image from uploads folder
-	DeleteFileFromUploadsFolder(filePath)
+	DeleteFileFromUploadsFolder(ctx, filePath)
 
 	err, presignedUrlGet := getPresignedUrl(path)
 
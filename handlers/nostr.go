@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+)
+
+// nostrChallengeWindow matches how long challenges are kept in the store
+const nostrChallengeWindow = 120 * time.Second
+
+const nostrChallengeCachePrefix = "nostr_challenge:"
+
+type nostrHandler struct {
+	db           db.Database
+	startSession func(pubkey string, r *http.Request) (string, string, error)
+}
+
+func NewNostrHandler(database db.Database) *nostrHandler {
+	return &nostrHandler{
+		db:           database,
+		startSession: NewSessionHandler(database).StartSession,
+	}
+}
+
+type NostrChallengeResponse struct {
+	Challenge string `json:"challenge"`
+	Kind      int    `json:"kind"`
+}
+
+type NostrEventRequest struct {
+	Event *auth.NostrEvent `json:"event"`
+}
+
+// verifyChallengeEvent reads a signed challenge event from the body, each
+// challenge can only be answered once
+func (nh *nostrHandler) verifyChallengeEvent(w http.ResponseWriter, r *http.Request) (*auth.NostrEvent, bool) {
+	var request NostrEventRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Event == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("A signed nostr event is required")
+		return nil, false
+	}
+
+	challenge := request.Event.Tag("challenge")
+	if _, err := db.Store.GetCache(nostrChallengeCachePrefix + challenge); challenge == "" || err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Unknown or expired challenge")
+		return nil, false
+	}
+	db.Store.DeleteCache(nostrChallengeCachePrefix + challenge)
+
+	if err := auth.VerifyNostrChallengeEvent(request.Event, challenge, nostrChallengeWindow); err != nil {
+		logger.FromContext(r.Context()).Info("[nostr] %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+		return nil, false
+	}
+
+	return request.Event, true
+}
+
+// GetNostrChallenge godoc
+//
+//	@Summary		Get nostr challenge
+//	@Description	Get a challenge to sign as a NIP-42 auth event for nostr login or key linking
+//	@Tags			Auth
+//	@Produce		json
+//	@Success		200	{object}	NostrChallengeResponse
+//	@Router			/nostr/challenge [get]
+func (nh *nostrHandler) GetNostrChallenge(w http.ResponseWriter, r *http.Request) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		logger.FromContext(r.Context()).Error("[nostr] %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	challenge := hex.EncodeToString(b)
+
+	db.Store.SetCache(nostrChallengeCachePrefix+challenge, challenge)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NostrChallengeResponse{Challenge: challenge, Kind: auth.NostrAuthKind})
+}
+
+// NostrLogin godoc
+//
+//	@Summary		Nostr login
+//	@Description	Log in with a kind 22242 event signed by a nostr key answering a challenge. A key linked to a person logs in as that person, otherwise an account is created for the key.
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		NostrEventRequest	true	"Signed challenge event"
+//	@Success		200		{object}	SessionTokenResponse
+//	@Failure		401		{object}	string
+//	@Router			/nostr/login [post]
+func (nh *nostrHandler) NostrLogin(w http.ResponseWriter, r *http.Request) {
+	event, ok := nh.verifyChallengeEvent(w, r)
+	if !ok {
+		return
+	}
+
+	pubkey := event.Pubkey
+	if identity, err := nh.db.GetPersonIdentity(db.IdentityNostr, event.Pubkey); err == nil {
+		pubkey = identity.OwnerPubKey
+	} else {
+		nh.db.CreateLnUser(pubkey)
+	}
+
+	tokenString, refreshToken, err := nh.startSession(pubkey, r)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("[nostr] error creating session JWT: %v", err)
+		w.WriteHeader(http.StatusNotAcceptable)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(SessionTokenResponse{
+		Status:       true,
+		JWT:          tokenString,
+		RefreshToken: refreshToken,
+		User:         returnUserMap(nh.db.GetPersonByPubkey(pubkey)),
+	})
+}
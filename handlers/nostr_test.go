@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/db"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func newNostrChallenge(t *testing.T, nh *nostrHandler) string {
+	rr := httptest.NewRecorder()
+	nh.GetNostrChallenge(rr, httptest.NewRequest(http.MethodGet, "/nostr/challenge", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response NostrChallengeResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, auth.NostrAuthKind, response.Kind)
+	return response.Challenge
+}
+
+func newNostrChallengeEvent(t *testing.T, key *btcec.PrivateKey, challenge string) *auth.NostrEvent {
+	event := auth.NostrEvent{
+		Pubkey:    hex.EncodeToString(schnorr.SerializePubKey(key.PubKey())),
+		CreatedAt: time.Now().Unix(),
+		Kind:      auth.NostrAuthKind,
+		Tags:      [][]string{{"challenge", challenge}},
+	}
+	event.ID = event.ComputeID()
+	id, _ := hex.DecodeString(event.ID)
+	sig, err := schnorr.Sign(key, id)
+	assert.NoError(t, err)
+	event.Sig = hex.EncodeToString(sig.Serialize())
+	return &event
+}
+
+func newNostrEventRequest(t *testing.T, key *btcec.PrivateKey, target string, challenge string) *http.Request {
+	body, _ := json.Marshal(NostrEventRequest{Event: newNostrChallengeEvent(t, key, challenge)})
+	return httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+}
+
+func TestNostrLogin(t *testing.T) {
+	db.InitCache()
+	key, _ := btcec.NewPrivateKey()
+	nostrPubkey := hex.EncodeToString(schnorr.SerializePubKey(key.PubKey()))
+
+	t.Run("should log a linked nostr key in as its person", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		nh := NewNostrHandler(mockDb)
+		var sessionPubkey string
+		nh.startSession = func(pubkey string, r *http.Request) (string, string, error) {
+			sessionPubkey = pubkey
+			return "access_token", "refresh_token", nil
+		}
+
+		mockDb.On("GetPersonIdentity", db.IdentityNostr, nostrPubkey).Return(db.PersonIdentity{OwnerPubKey: "owner_pubkey"}, nil)
+		mockDb.On("GetPersonByPubkey", "owner_pubkey").Return(db.Person{OwnerPubKey: "owner_pubkey"})
+
+		rr := httptest.NewRecorder()
+		nh.NostrLogin(rr, newNostrEventRequest(t, key, "/nostr/login", newNostrChallenge(t, nh)))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "owner_pubkey", sessionPubkey)
+
+		var response SessionTokenResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "access_token", response.JWT)
+		assert.Equal(t, "refresh_token", response.RefreshToken)
+	})
+
+	t.Run("should create an account for a new nostr key", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		nh := NewNostrHandler(mockDb)
+		nh.startSession = func(pubkey string, r *http.Request) (string, string, error) {
+			return "access_token", "refresh_token", nil
+		}
+
+		mockDb.On("GetPersonIdentity", db.IdentityNostr, nostrPubkey).Return(db.PersonIdentity{}, errors.New("identity not found"))
+		mockDb.On("CreateLnUser", nostrPubkey).Return(db.Person{OwnerPubKey: nostrPubkey}, nil)
+		mockDb.On("GetPersonByPubkey", nostrPubkey).Return(db.Person{OwnerPubKey: nostrPubkey})
+
+		rr := httptest.NewRecorder()
+		nh.NostrLogin(rr, newNostrEventRequest(t, key, "/nostr/login", newNostrChallenge(t, nh)))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("should not accept a challenge twice", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		nh := NewNostrHandler(mockDb)
+		nh.startSession = func(pubkey string, r *http.Request) (string, string, error) {
+			return "access_token", "refresh_token", nil
+		}
+
+		mockDb.On("GetPersonIdentity", db.IdentityNostr, nostrPubkey).Return(db.PersonIdentity{OwnerPubKey: "owner_pubkey"}, nil)
+		mockDb.On("GetPersonByPubkey", "owner_pubkey").Return(db.Person{OwnerPubKey: "owner_pubkey"})
+
+		challenge := newNostrChallenge(t, nh)
+		rr := httptest.NewRecorder()
+		nh.NostrLogin(rr, newNostrEventRequest(t, key, "/nostr/login", challenge))
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = httptest.NewRecorder()
+		nh.NostrLogin(rr, newNostrEventRequest(t, key, "/nostr/login", challenge))
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should reject unknown challenges", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		nh := NewNostrHandler(mockDb)
+
+		rr := httptest.NewRecorder()
+		nh.NostrLogin(rr, newNostrEventRequest(t, key, "/nostr/login", "unknown"))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
@@ -2,10 +2,10 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
 	"strconv"
@@ -60,7 +60,7 @@ func (ph *peopleHandler) CreatePerson(w http.ResponseWriter, r *http.Request) {
 	r.Body.Close()
 	err = json.Unmarshal(body, &person)
 	if err != nil {
-		log.Println(err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -68,14 +68,14 @@ func (ph *peopleHandler) CreatePerson(w http.ResponseWriter, r *http.Request) {
 	now := time.Now()
 
 	if pubKeyFromAuth == "" {
-		log.Println("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 	if pubKeyFromAuth != person.OwnerPubKey {
-		log.Println(pubKeyFromAuth)
-		log.Println(person.OwnerPubKey)
-		log.Println("mismatched pubkey")
+		logger.FromContext(r.Context()).Info("%v", pubKeyFromAuth)
+		logger.FromContext(r.Context()).Info("%v", person.OwnerPubKey)
+		logger.FromContext(r.Context()).Info("mismatched pubkey")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -111,7 +111,7 @@ func (ph *peopleHandler) CreatePerson(w http.ResponseWriter, r *http.Request) {
 	decodeErr := json.NewEncoder(b).Encode(person.Extras)
 
 	if decodeErr != nil {
-		log.Printf("Could not encode extras json data")
+		logger.FromContext(r.Context()).Error("Could not encode extras json data")
 	}
 
 	p, err := ph.db.CreateOrEditPerson(person)
@@ -151,7 +151,7 @@ func (ph *peopleHandler) UpdatePerson(w http.ResponseWriter, r *http.Request) {
 	r.Body.Close()
 	err = json.Unmarshal(body, &person)
 	if err != nil {
-		log.Println(err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -159,15 +159,15 @@ func (ph *peopleHandler) UpdatePerson(w http.ResponseWriter, r *http.Request) {
 	now := time.Now()
 
 	if pubKeyFromAuth == "" {
-		log.Println("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
 	if pubKeyFromAuth != person.OwnerPubKey {
-		log.Println(pubKeyFromAuth)
-		log.Println(person.OwnerPubKey)
-		log.Println("mismatched pubkey")
+		logger.FromContext(r.Context()).Info("%v", pubKeyFromAuth)
+		logger.FromContext(r.Context()).Info("%v", person.OwnerPubKey)
+		logger.FromContext(r.Context()).Info("mismatched pubkey")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -175,14 +175,14 @@ func (ph *peopleHandler) UpdatePerson(w http.ResponseWriter, r *http.Request) {
 	existing := ph.db.GetPersonByPubkey(pubKeyFromAuth)
 	if existing.ID == 0 {
 		msg := fmt.Sprintf("User does not exists: %s", pubKeyFromAuth)
-		log.Println(msg)
+		logger.FromContext(r.Context()).Info("%v", msg)
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(msg)
 		return
 	} else {
 		if person.OwnerPubKey != existing.OwnerPubKey && person.OwnerAlias != existing.OwnerAlias {
 			// can't edit someone else's
-			logger.Log.Info("cant edit someone else")
+			logger.FromContext(r.Context()).Info("cant edit someone else")
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
@@ -198,7 +198,7 @@ func (ph *peopleHandler) UpdatePerson(w http.ResponseWriter, r *http.Request) {
 	decodeErr := json.NewEncoder(b).Encode(person.Extras)
 
 	if decodeErr != nil {
-		log.Printf("Could not encode extras json data")
+		logger.FromContext(r.Context()).Error("Could not encode extras json data")
 	}
 
 	p, err := ph.db.CreateOrEditPerson(person)
@@ -235,7 +235,7 @@ func (ph *peopleHandler) UpsertLogin(w http.ResponseWriter, r *http.Request) {
 	r.Body.Close()
 	err = json.Unmarshal(body, &person)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -248,7 +248,7 @@ func (ph *peopleHandler) UpsertLogin(w http.ResponseWriter, r *http.Request) {
 	if existing.ID == 0 {
 		if person.ID != 0 {
 			// cant try to "edit" if not exists already
-			logger.Log.Info("cant edit non existing")
+			logger.FromContext(r.Context()).Info("cant edit non existing")
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
@@ -258,7 +258,7 @@ func (ph *peopleHandler) UpsertLogin(w http.ResponseWriter, r *http.Request) {
 
 	} else { // editing! needs ID
 		if person.ID != 0 && person.ID != existing.ID { // can't edit someone else's
-			logger.Log.Info("cant edit someone else")
+			logger.FromContext(r.Context()).Info("cant edit someone else")
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
@@ -275,7 +275,7 @@ func (ph *peopleHandler) UpsertLogin(w http.ResponseWriter, r *http.Request) {
 	decodeErr := json.NewEncoder(b).Encode(person.Extras)
 
 	if decodeErr != nil {
-		log.Printf("Could not encode extras json data")
+		logger.FromContext(r.Context()).Error("Could not encode extras json data")
 	}
 
 	p, err := ph.db.CreateOrEditPerson(person)
@@ -287,10 +287,10 @@ func (ph *peopleHandler) UpsertLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	responseData := make(map[string]interface{})
-	tokenString, err := auth.EncodeJwt(person.OwnerPubKey)
+	tokenString, _, err := NewSessionHandler(ph.db).StartSession(person.OwnerPubKey, r)
 
 	if err != nil {
-		logger.Log.Info("Cannot generate jwt token")
+		logger.FromContext(r.Context()).Info("Cannot generate jwt token")
 	}
 
 	responseData["jwt"] = tokenString
@@ -332,41 +332,41 @@ func DeleteTicketByAdmin(w http.ResponseWriter, r *http.Request) {
 	createdStr := chi.URLParam(r, "created")
 	created, err := strconv.ParseInt(createdStr, 10, 64)
 	if err != nil {
-		logger.Log.Info("Unable to convert created to int64")
+		logger.FromContext(r.Context()).Info("Unable to convert created to int64")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 	if created == 0 || pubKey == "" {
-		logger.Log.Info("Insufficient details to delete ticket")
+		logger.FromContext(r.Context()).Info("Insufficient details to delete ticket")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 	existing := db.DB.GetPersonByPubkey(pubKeyFromAuth)
 	if existing.ID == 0 {
-		logger.Log.Info("Could not fetch admin details from db")
+		logger.FromContext(r.Context()).Info("Could not fetch admin details from db")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	} else if PersonIsAdmin(existing.OwnerPubKey) == false {
-		logger.Log.Info("Only admin is allowed to delete tickets")
+		logger.FromContext(r.Context()).Info("Only admin is allowed to delete tickets")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
 	person := db.DB.GetPersonByPubkey(pubKey)
 	if person.ID == 0 {
-		logger.Log.Info("Could not fetch person from db")
+		logger.FromContext(r.Context()).Info("Could not fetch person from db")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
 	wanteds, ok := person.Extras["wanted"].([]interface{})
 	if !ok {
-		logger.Log.Info("No tickets found for person")
+		logger.FromContext(r.Context()).Info("No tickets found for person")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -388,7 +388,7 @@ func DeleteTicketByAdmin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if index == -1 {
-		logger.Log.Info("Ticket to delete not found")
+		logger.FromContext(r.Context()).Info("Ticket to delete not found")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	} else {
@@ -399,7 +399,7 @@ func DeleteTicketByAdmin(w http.ResponseWriter, r *http.Request) {
 	decodeErr := json.NewEncoder(b).Encode(person.Extras)
 
 	if decodeErr != nil {
-		log.Printf("Could not encode extras json data")
+		logger.FromContext(r.Context()).Error("Could not encode extras json data")
 	}
 
 	_, err = db.DB.CreateOrEditPerson(person)
@@ -430,8 +430,14 @@ func ProcessTwitterConfirmationsLoop() {
 						pubkey, err := utils.ConfirmIdentityTweet(username)
 						// fmt.Println("Twitter err", err)
 						if err == nil && pubkey != "" {
-							if p.OwnerPubKey == pubkey {
+							if p.OwnerPubKey == auth.ResolvePubkey(pubkey) {
 								db.DB.UpdateTwitterConfirmed(p.ID, true)
+								db.DB.CreatePersonIdentity(&db.PersonIdentity{
+									OwnerPubKey: p.OwnerPubKey,
+									Type:        db.IdentityTwitter,
+									Value:       username,
+									Verified:    true,
+								})
 							}
 						}
 					}
@@ -510,31 +516,6 @@ func ProcessGithubIssuesLoop() {
 	ProcessGithubIssuesLoop()
 }
 
-func processGithubConfirmationsLoop() {
-	peeps := db.DB.GetUnconfirmedGithub()
-	for _, p := range peeps {
-		gitArray, ok := p.Extras["twitter"].([]interface{})
-		if ok {
-			if len(gitArray) > 0 {
-				gitValue, ok2 := gitArray[0].(map[string]interface{})
-				if ok2 {
-					username, _ := gitValue["value"].(string)
-					if username != "" {
-						pubkey, err := PubkeyForGithubUser(username)
-						if err == nil && pubkey != "" {
-							if p.OwnerPubKey == pubkey {
-								db.DB.UpdateGithubConfirmed(p.ID, true)
-							}
-						}
-					}
-				}
-			}
-		}
-	}
-	time.Sleep(30 * time.Second)
-	processGithubConfirmationsLoop()
-}
-
 // GetPersonByPubkey godoc
 //
 //	@Summary		Get Person by Pubkey
@@ -585,7 +566,7 @@ func (ph *peopleHandler) GetPersonById(w http.ResponseWriter, r *http.Request) {
 func (ph *peopleHandler) GetPersonByUuid(w http.ResponseWriter, r *http.Request) {
 	uuid := chi.URLParam(r, "uuid")
 	person := ph.db.GetPersonByUuid(uuid)
-	assetBalanceData, err := GetAssetByPubkey(person.OwnerPubKey)
+	assetBalanceData, err := GetAssetByPubkey(r.Context(), person.OwnerPubKey)
 
 	personResponse := make(map[string]interface{})
 	personResponse["id"] = person.ID
@@ -602,7 +583,7 @@ func (ph *peopleHandler) GetPersonByUuid(w http.ResponseWriter, r *http.Request)
 	personResponse["twitter_confirmed"] = person.TwitterConfirmed
 	personResponse["github_issues"] = person.GithubIssues
 	if err != nil {
-		logger.Log.Error("==> error: %v", err)
+		logger.FromContext(r.Context()).Error("==> error: %v", err)
 	} else {
 		var badgeSlice []uint
 		for i := 0; i < len(assetBalanceData); i++ {
@@ -610,7 +591,7 @@ func (ph *peopleHandler) GetPersonByUuid(w http.ResponseWriter, r *http.Request)
 		}
 		personResponse["badges"] = badgeSlice
 	}
-	logger.Log.Info("")
+	logger.FromContext(r.Context()).Info("")
 	// FIXME use http to hit sphinx-element server for badges
 	// Todo: response should include no pubKey
 	// FIXME also filter by the tribe "profile_filters"
@@ -631,13 +612,13 @@ func (ph *peopleHandler) GetPersonByUuid(w http.ResponseWriter, r *http.Request)
 func GetPersonAssetsByUuid(w http.ResponseWriter, r *http.Request) {
 	uuid := chi.URLParam(r, "uuid")
 	person := db.DB.GetPersonByUuid(uuid)
-	assetList, err := GetAssetList(person.OwnerPubKey)
+	assetList, err := GetAssetList(r.Context(), person.OwnerPubKey)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusServiceUnavailable)
 		return
 	}
-	logger.Log.Info("")
+	logger.FromContext(r.Context()).Info("")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(assetList)
 }
@@ -677,25 +658,25 @@ func (ph *peopleHandler) DeletePerson(w http.ResponseWriter, r *http.Request) {
 	idString := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(idString)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
 	if id == 0 {
-		logger.Log.Info("id is 0")
+		logger.FromContext(r.Context()).Info("id is 0")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
 	existing := ph.db.GetPerson(uint(id))
 	if existing.ID == 0 {
-		logger.Log.Info("existing id is 0")
+		logger.FromContext(r.Context()).Info("existing id is 0")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 	if existing.OwnerPubKey != pubKeyFromAuth {
-		logger.Log.Info("keys dont match")
+		logger.FromContext(r.Context()).Info("keys dont match")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -708,7 +689,7 @@ func (ph *peopleHandler) DeletePerson(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(true)
 }
 
-func GetAssetByPubkey(pubkey string) ([]db.AssetBalanceData, error) {
+func GetAssetByPubkey(ctx context.Context, pubkey string) ([]db.AssetBalanceData, error) {
 	client := &http.Client{}
 	testMode, err := strconv.ParseBool(os.Getenv("TEST_MODE"))
 	if err != nil {
@@ -722,12 +703,12 @@ func GetAssetByPubkey(pubkey string) ([]db.AssetBalanceData, error) {
 		url = "https://liquid.sphinx.chat/balances?pubkey=" + pubkey
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	resp, err := client.Do(req)
 
 	if err != nil {
-		logger.Log.Error("GET error: %v", err)
+		logger.FromContext(ctx).Error("GET error: %v", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -736,7 +717,7 @@ func GetAssetByPubkey(pubkey string) ([]db.AssetBalanceData, error) {
 	body, err := io.ReadAll(resp.Body)
 	err = json.Unmarshal(body, &r)
 	if err != nil {
-		logger.Log.Error("json unmarshall error: %v", err)
+		logger.FromContext(ctx).Error("json unmarshall error: %v", err)
 		return nil, err
 	}
 
@@ -745,7 +726,7 @@ func GetAssetByPubkey(pubkey string) ([]db.AssetBalanceData, error) {
 	return balances, nil
 }
 
-func GetAssetList(pubkey string) ([]db.AssetListData, error) {
+func GetAssetList(ctx context.Context, pubkey string) ([]db.AssetListData, error) {
 	client := &http.Client{}
 
 	url := os.Getenv("ASSET_LIST_URL")
@@ -755,12 +736,12 @@ func GetAssetList(pubkey string) ([]db.AssetListData, error) {
 
 	url = url + "?pubkey=" + pubkey
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	resp, err := client.Do(req)
 
 	if err != nil {
-		logger.Log.Error("GET error: %v", err)
+		logger.FromContext(ctx).Error("GET error: %v", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -770,7 +751,7 @@ func GetAssetList(pubkey string) ([]db.AssetListData, error) {
 
 	err = json.Unmarshal(body, &r)
 	if err != nil {
-		logger.Log.Error("json unmarshall error: %v", err)
+		logger.FromContext(ctx).Error("json unmarshall error: %v", err)
 		return nil, err
 	}
 
@@ -797,44 +778,44 @@ func AddOrRemoveBadge(w http.ResponseWriter, r *http.Request) {
 	r.Body.Close()
 	err = json.Unmarshal(body, &badgeCreationData)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
 
 	if badgeCreationData.Badge == "" {
-		logger.Log.Info("Badge cannot be Empty")
+		logger.FromContext(r.Context()).Info("Badge cannot be Empty")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
 	if badgeCreationData.Action == "" {
-		logger.Log.Info("Action cannot be Empty")
+		logger.FromContext(r.Context()).Info("Action cannot be Empty")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
 	if !(badgeCreationData.Action == "add" || badgeCreationData.Action == "remove") {
-		logger.Log.Info("Invalid action in Request")
+		logger.FromContext(r.Context()).Info("Invalid action in Request")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
 	if badgeCreationData.TribeUUID == "" {
-		logger.Log.Info("tribeId cannot be Empty")
+		logger.FromContext(r.Context()).Info("tribeId cannot be Empty")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
 	extractedPubkey, err := auth.VerifyTribeUUID(badgeCreationData.TribeUUID, false)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -842,8 +823,8 @@ func AddOrRemoveBadge(w http.ResponseWriter, r *http.Request) {
 	tribe := db.DB.GetTribeByIdAndPubkey(badgeCreationData.TribeUUID, extractedPubkey)
 
 	if pubKeyFromAuth != tribe.OwnerPubKey {
-		logger.Log.Info("%s", pubKeyFromAuth)
-		logger.Log.Info("mismatched pubkey")
+		logger.FromContext(r.Context()).Info("%s", pubKeyFromAuth)
+		logger.FromContext(r.Context()).Info("mismatched pubkey")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
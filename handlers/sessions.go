@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+)
+
+type sessionHandler struct {
+	db               db.Database
+	encodeSessionJwt func(pubkey string, sessionID string) (string, error)
+}
+
+func NewSessionHandler(database db.Database) *sessionHandler {
+	return &sessionHandler{
+		db:               database,
+		encodeSessionJwt: auth.EncodeSessionJwt,
+	}
+}
+
+type RefreshSessionRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type SessionTokenResponse struct {
+	Status       bool                   `json:"status"`
+	JWT          string                 `json:"jwt"`
+	RefreshToken string                 `json:"refresh_token"`
+	User         map[string]interface{} `json:"user,omitempty"`
+}
+
+func sessionExpiry(now time.Time) time.Time {
+	return now.Add(time.Duration(config.SessionDays) * 24 * time.Hour)
+}
+
+func requestIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return forwarded
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// CreateSession stores a new session for a login and returns it with its
+// plaintext refresh token, only the hash of the token is kept
+func (sh *sessionHandler) CreateSession(pubkey string, r *http.Request) (db.UserSession, string, error) {
+	session, refreshToken, err := db.NewUserSession(pubkey, r.UserAgent(), requestIP(r))
+	if err != nil {
+		return session, "", err
+	}
+
+	if err := sh.db.CreateUserSession(&session); err != nil {
+		return session, "", err
+	}
+
+	return session, refreshToken, nil
+}
+
+// StartSession creates a session for a login and returns its access and refresh tokens
+func (sh *sessionHandler) StartSession(pubkey string, r *http.Request) (string, string, error) {
+	session, refreshToken, err := sh.CreateSession(pubkey, r)
+	if err != nil {
+		return "", "", err
+	}
+
+	tokenString, err := sh.encodeSessionJwt(pubkey, session.ID.String())
+	if err != nil {
+		return "", "", err
+	}
+
+	return tokenString, refreshToken, nil
+}
+
+// RefreshSession godoc
+//
+//	@Summary		Rotate refresh token
+//	@Description	Exchange a refresh token for a new access token and refresh token. Reusing an already rotated refresh token revokes the session.
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		RefreshSessionRequest	true	"Refresh token"
+//	@Success		200		{object}	SessionTokenResponse
+//	@Failure		401		{object}	string
+//	@Router			/refresh_session [post]
+func (sh *sessionHandler) RefreshSession(w http.ResponseWriter, r *http.Request) {
+	var request RefreshSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.RefreshToken == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Refresh token is required")
+		return
+	}
+
+	sessionID, ok := auth.ParseRefreshToken(request.RefreshToken)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Invalid refresh token")
+		return
+	}
+
+	session, err := sh.db.GetUserSession(sessionID)
+	now := time.Now()
+	if err != nil || !session.IsActive(now) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Session is no longer active")
+		return
+	}
+
+	oldHash := auth.HashRefreshToken(request.RefreshToken)
+	if oldHash != session.RefreshTokenHash {
+		logger.FromContext(r.Context()).Warning("[sessions] refresh token reuse detected for session %s, revoking", session.ID)
+		if err := sh.db.RevokeUserSession(session.OwnerPubKey, sessionID, db.SessionRevokedForReuse); err != nil {
+			logger.FromContext(r.Context()).Error("[sessions] %v", err)
+		}
+		auth.ClearRevocationCache()
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Session is no longer active")
+		return
+	}
+
+	refreshToken, err := auth.GenerateRefreshToken(sessionID)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("[sessions] %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := sh.db.RotateUserSession(sessionID, oldHash, auth.HashRefreshToken(refreshToken), sessionExpiry(now)); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Session is no longer active")
+		return
+	}
+
+	tokenString, err := sh.encodeSessionJwt(session.OwnerPubKey, sessionID)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("[sessions] error creating session JWT: %v", err)
+		w.WriteHeader(http.StatusNotAcceptable)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(SessionTokenResponse{
+		Status:       true,
+		JWT:          tokenString,
+		RefreshToken: refreshToken,
+		User:         returnUserMap(sh.db.GetPersonByPubkey(session.OwnerPubKey)),
+	})
+}
+
+// GetSessions godoc
+//
+//	@Summary		List sessions
+//	@Description	List the active sessions of the authenticated user
+//	@Tags			Auth
+//	@Produce		json
+//	@Security		PubKeyContextAuth
+//	@Success		200	{array}	db.UserSession
+//	@Router			/sessions [get]
+func (sh *sessionHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[sessions] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := sh.db.GetActiveUserSessions(pubKeyFromAuth)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("[sessions] %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	currentSession, _ := ctx.Value(auth.SessionContextKey).(string)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.String() == currentSession
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// RevokeSession godoc
+//
+//	@Summary		Revoke session
+//	@Description	Revoke one of the authenticated user's sessions
+//	@Tags			Auth
+//	@Param			id	path	string	true	"Session ID"
+//	@Security		PubKeyContextAuth
+//	@Success		200
+//	@Router			/sessions/{id} [delete]
+func (sh *sessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	pubKeyFromAuth, _ := r.Context().Value(auth.ContextKey).(string)
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[sessions] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if err := sh.db.RevokeUserSession(pubKeyFromAuth, id, db.SessionRevokedByUser); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+	auth.ClearRevocationCache()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode("Session revoked")
+}
+
+// RevokeAllSessions godoc
+//
+//	@Summary		Revoke all sessions
+//	@Description	Sign the authenticated user out everywhere by revoking all their sessions and tokens
+//	@Tags			Auth
+//	@Security		PubKeyContextAuth
+//	@Success		200
+//	@Router			/sessions [delete]
+func (sh *sessionHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	pubKeyFromAuth, _ := r.Context().Value(auth.ContextKey).(string)
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[sessions] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if err := sh.db.RevokeAllUserSessions(pubKeyFromAuth, db.SessionRevokedByUser); err != nil {
+		logger.FromContext(r.Context()).Error("[sessions] %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	auth.ClearRevocationCache()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode("Sessions revoked")
+}
+
+// AdminRevokeSessions godoc
+//
+//	@Summary		Revoke all sessions for a pubkey
+//	@Description	Revoke every session and token issued to a pubkey
+//	@Tags			Auth
+//	@Param			pubkey	path	string	true	"Pubkey"
+//	@Security		SuperAdminAuth
+//	@Success		200
+//	@Router			/admin/sessions/{pubkey} [delete]
+func (sh *sessionHandler) AdminRevokeSessions(w http.ResponseWriter, r *http.Request) {
+	pubKeyFromAuth, _ := r.Context().Value(auth.ContextKey).(string)
+	pubkey := chi.URLParam(r, "pubkey")
+
+	if pubkey == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Pubkey is required")
+		return
+	}
+
+	if err := sh.db.RevokeAllUserSessions(pubkey, db.SessionRevokedByAdmin); err != nil {
+		logger.FromContext(r.Context()).Error("[sessions] %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	auth.ClearRevocationCache()
+
+	logger.FromContext(r.Context()).Info("[sessions] %s revoked all sessions for %s", pubKeyFromAuth, pubkey)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode("Sessions revoked")
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/db"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newRefreshSessionRequest(refreshToken string) *http.Request {
+	body, _ := json.Marshal(RefreshSessionRequest{RefreshToken: refreshToken})
+	req, _ := http.NewRequest(http.MethodPost, "/refresh_session", bytes.NewReader(body))
+	return req
+}
+
+func TestRefreshSession(t *testing.T) {
+	sessionID := uuid.New()
+	refreshToken, _ := auth.GenerateRefreshToken(sessionID.String())
+	activeSession := db.UserSession{
+		ID:               sessionID,
+		OwnerPubKey:      "pubkey",
+		RefreshTokenHash: auth.HashRefreshToken(refreshToken),
+		ExpiresAt:        time.Now().Add(time.Hour),
+	}
+
+	t.Run("should rotate the refresh token", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		sHandler := NewSessionHandler(mockDb)
+		sHandler.encodeSessionJwt = func(pubkey string, sessionID string) (string, error) {
+			return "access_token", nil
+		}
+
+		mockDb.On("GetUserSession", sessionID.String()).Return(activeSession, nil)
+		mockDb.On("RotateUserSession", sessionID.String(), activeSession.RefreshTokenHash, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+		mockDb.On("GetPersonByPubkey", "pubkey").Return(db.Person{OwnerPubKey: "pubkey"})
+
+		rr := httptest.NewRecorder()
+		sHandler.RefreshSession(rr, newRefreshSessionRequest(refreshToken))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response SessionTokenResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "access_token", response.JWT)
+		assert.NotEqual(t, refreshToken, response.RefreshToken)
+
+		rotatedID, ok := auth.ParseRefreshToken(response.RefreshToken)
+		assert.True(t, ok)
+		assert.Equal(t, sessionID.String(), rotatedID)
+	})
+
+	t.Run("should revoke the session when a rotated token is reused", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		sHandler := NewSessionHandler(mockDb)
+
+		staleToken, _ := auth.GenerateRefreshToken(sessionID.String())
+		mockDb.On("GetUserSession", sessionID.String()).Return(activeSession, nil)
+		mockDb.On("RevokeUserSession", "pubkey", sessionID.String(), db.SessionRevokedForReuse).Return(nil)
+
+		rr := httptest.NewRecorder()
+		sHandler.RefreshSession(rr, newRefreshSessionRequest(staleToken))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockDb.AssertNotCalled(t, "RotateUserSession", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject revoked sessions", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		sHandler := NewSessionHandler(mockDb)
+
+		revokedAt := time.Now()
+		revoked := activeSession
+		revoked.RevokedAt = &revokedAt
+		mockDb.On("GetUserSession", sessionID.String()).Return(revoked, nil)
+
+		rr := httptest.NewRecorder()
+		sHandler.RefreshSession(rr, newRefreshSessionRequest(refreshToken))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should reject malformed tokens", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		sHandler := NewSessionHandler(mockDb)
+
+		rr := httptest.NewRecorder()
+		sHandler.RefreshSession(rr, newRefreshSessionRequest("malformed"))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestGetSessions(t *testing.T) {
+	mockDb := dbMocks.NewDatabase(t)
+	sHandler := NewSessionHandler(mockDb)
+
+	current := db.UserSession{ID: uuid.New(), OwnerPubKey: "pubkey"}
+	other := db.UserSession{ID: uuid.New(), OwnerPubKey: "pubkey"}
+	mockDb.On("GetActiveUserSessions", "pubkey").Return([]db.UserSession{current, other}, nil)
+
+	ctx := context.WithValue(context.Background(), auth.ContextKey, "pubkey")
+	ctx = context.WithValue(ctx, auth.SessionContextKey, current.ID.String())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/sessions", nil)
+
+	rr := httptest.NewRecorder()
+	sHandler.GetSessions(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var sessions []db.UserSession
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &sessions))
+	assert.Len(t, sessions, 2)
+	assert.True(t, sessions[0].Current)
+	assert.False(t, sessions[1].Current)
+}
+
+func TestRevokeSession(t *testing.T) {
+	ctx := context.WithValue(context.Background(), auth.ContextKey, "pubkey")
+
+	t.Run("should revoke the user's session", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		sHandler := NewSessionHandler(mockDb)
+		mockDb.On("RevokeUserSession", "pubkey", "session-id", db.SessionRevokedByUser).Return(nil)
+
+		rr := httptest.NewRecorder()
+		sHandler.RevokeSession(rr, newURLParamRequest(ctx, http.MethodDelete, map[string]string{"id": "session-id"}, nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("should return 404 for another user's session", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		sHandler := NewSessionHandler(mockDb)
+		mockDb.On("RevokeUserSession", "pubkey", "session-id", db.SessionRevokedByUser).Return(errors.New("session not found"))
+
+		rr := httptest.NewRecorder()
+		sHandler.RevokeSession(rr, newURLParamRequest(ctx, http.MethodDelete, map[string]string{"id": "session-id"}, nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestAdminRevokeSessions(t *testing.T) {
+	mockDb := dbMocks.NewDatabase(t)
+	sHandler := NewSessionHandler(mockDb)
+	mockDb.On("RevokeAllUserSessions", "target", db.SessionRevokedByAdmin).Return(nil)
+
+	ctx := context.WithValue(context.Background(), auth.ContextKey, "admin")
+	rr := httptest.NewRecorder()
+	sHandler.AdminRevokeSessions(rr, newURLParamRequest(ctx, http.MethodDelete, map[string]string{"pubkey": "target"}, nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
@@ -28,7 +28,7 @@ func (sh *skillHandler) CreateSkill(w http.ResponseWriter, r *http.Request) {
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[skill] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[skill] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -36,7 +36,7 @@ func (sh *skillHandler) CreateSkill(w http.ResponseWriter, r *http.Request) {
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		logger.Log.Error("failed to read request body", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to read request body")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Error reading request body"})
 		return
@@ -45,7 +45,7 @@ func (sh *skillHandler) CreateSkill(w http.ResponseWriter, r *http.Request) {
 
 	var skill db.Skill
 	if err := json.Unmarshal(body, &skill); err != nil {
-		logger.Log.Error("failed to unmarshal skill data", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to unmarshal skill data")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid skill data format"})
 		return
@@ -55,7 +55,7 @@ func (sh *skillHandler) CreateSkill(w http.ResponseWriter, r *http.Request) {
 
 	createdSkill, err := sh.db.CreateSkill(&skill)
 	if err != nil {
-		logger.Log.Error("failed to create skill", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to create skill")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -68,7 +68,7 @@ func (sh *skillHandler) CreateSkill(w http.ResponseWriter, r *http.Request) {
 func (sh *skillHandler) GetAllSkills(w http.ResponseWriter, r *http.Request) {
 	skills, err := sh.db.GetAllSkills()
 	if err != nil {
-		logger.Log.Error("failed to get all skills", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to get all skills")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -95,7 +95,7 @@ func (sh *skillHandler) GetSkillByID(w http.ResponseWriter, r *http.Request) {
 
 	skill, err := sh.db.GetSkillByID(id)
 	if err != nil {
-		logger.Log.Error("failed to get skill by ID", "error", err, "id", id)
+		logger.FromContext(r.Context()).With("error", err, "id", id).Error("failed to get skill by ID")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -110,7 +110,7 @@ func (sh *skillHandler) UpdateSkillByID(w http.ResponseWriter, r *http.Request)
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[skill] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[skill] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -132,14 +132,14 @@ func (sh *skillHandler) UpdateSkillByID(w http.ResponseWriter, r *http.Request)
 
 	existingSkill, err := sh.db.GetSkillByID(id)
 	if err != nil {
-		logger.Log.Error("failed to get skill by ID", "error", err, "id", id)
+		logger.FromContext(r.Context()).With("error", err, "id", id).Error("failed to get skill by ID")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
 	if existingSkill.OwnerPubkey != pubKeyFromAuth {
-		logger.Log.Info("[skill] unauthorized update attempt", "pubkey", pubKeyFromAuth, "owner", existingSkill.OwnerPubkey)
+		logger.FromContext(r.Context()).With("pubkey", pubKeyFromAuth, "owner", existingSkill.OwnerPubkey).Info("[skill] unauthorized update attempt")
 		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode(map[string]string{"error": "You are not authorized to update this skill"})
 		return
@@ -147,7 +147,7 @@ func (sh *skillHandler) UpdateSkillByID(w http.ResponseWriter, r *http.Request)
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		logger.Log.Error("failed to read request body", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to read request body")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Error reading request body"})
 		return
@@ -156,7 +156,7 @@ func (sh *skillHandler) UpdateSkillByID(w http.ResponseWriter, r *http.Request)
 
 	var updatedSkill db.Skill
 	if err := json.Unmarshal(body, &updatedSkill); err != nil {
-		logger.Log.Error("failed to unmarshal skill data", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to unmarshal skill data")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid skill data format"})
 		return
@@ -167,7 +167,7 @@ func (sh *skillHandler) UpdateSkillByID(w http.ResponseWriter, r *http.Request)
 
 	result, err := sh.db.UpdateSkillByID(&updatedSkill)
 	if err != nil {
-		logger.Log.Error("failed to update skill", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to update skill")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -182,7 +182,7 @@ func (sh *skillHandler) DeleteSkillByID(w http.ResponseWriter, r *http.Request)
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[skill] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[skill] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -204,21 +204,21 @@ func (sh *skillHandler) DeleteSkillByID(w http.ResponseWriter, r *http.Request)
 
 	existingSkill, err := sh.db.GetSkillByID(id)
 	if err != nil {
-		logger.Log.Error("failed to get skill by ID", "error", err, "id", id)
+		logger.FromContext(r.Context()).With("error", err, "id", id).Error("failed to get skill by ID")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
 	if existingSkill.OwnerPubkey != pubKeyFromAuth {
-		logger.Log.Info("[skill] unauthorized delete attempt", "pubkey", pubKeyFromAuth, "owner", existingSkill.OwnerPubkey)
+		logger.FromContext(r.Context()).With("pubkey", pubKeyFromAuth, "owner", existingSkill.OwnerPubkey).Info("[skill] unauthorized delete attempt")
 		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode(map[string]string{"error": "You are not authorized to delete this skill"})
 		return
 	}
 
 	if err := sh.db.DeleteSkillByID(id); err != nil {
-		logger.Log.Error("failed to delete skill", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to delete skill")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -236,7 +236,7 @@ func (sh *skillHandler) CreateSkillInstall(w http.ResponseWriter, r *http.Reques
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[skill] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[skill] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -258,14 +258,14 @@ func (sh *skillHandler) CreateSkillInstall(w http.ResponseWriter, r *http.Reques
 
 	existingSkill, err := sh.db.GetSkillByID(skillID)
 	if err != nil {
-		logger.Log.Error("failed to get skill by ID", "error", err, "id", skillID)
+		logger.FromContext(r.Context()).With("error", err, "id", skillID).Error("failed to get skill by ID")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
 	if existingSkill.OwnerPubkey != pubKeyFromAuth {
-		logger.Log.Info("[skill] unauthorized install creation attempt", "pubkey", pubKeyFromAuth, "owner", existingSkill.OwnerPubkey)
+		logger.FromContext(r.Context()).With("pubkey", pubKeyFromAuth, "owner", existingSkill.OwnerPubkey).Info("[skill] unauthorized install creation attempt")
 		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode(map[string]string{"error": "You are not authorized to create installations for this skill"})
 		return
@@ -273,7 +273,7 @@ func (sh *skillHandler) CreateSkillInstall(w http.ResponseWriter, r *http.Reques
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		logger.Log.Error("failed to read request body", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to read request body")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Error reading request body"})
 		return
@@ -282,7 +282,7 @@ func (sh *skillHandler) CreateSkillInstall(w http.ResponseWriter, r *http.Reques
 
 	var install db.SkillInstall
 	if err := json.Unmarshal(body, &install); err != nil {
-		logger.Log.Error("failed to unmarshal installation data", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to unmarshal installation data")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid installation data format"})
 		return
@@ -292,7 +292,7 @@ func (sh *skillHandler) CreateSkillInstall(w http.ResponseWriter, r *http.Reques
 
 	createdInstall, err := sh.db.CreateSkillInstall(&install)
 	if err != nil {
-		logger.Log.Error("failed to create skill installation", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to create skill installation")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -319,7 +319,7 @@ func (sh *skillHandler) GetAllSkillInstallsBySkillID(w http.ResponseWriter, r *h
 
 	_, err = sh.db.GetSkillByID(skillID)
 	if err != nil {
-		logger.Log.Error("failed to get skill by ID", "error", err, "id", skillID)
+		logger.FromContext(r.Context()).With("error", err, "id", skillID).Error("failed to get skill by ID")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -327,7 +327,7 @@ func (sh *skillHandler) GetAllSkillInstallsBySkillID(w http.ResponseWriter, r *h
 
 	installs, err := sh.db.GetSkillInstallBySkillsID(skillID)
 	if err != nil {
-		logger.Log.Error("failed to get skill installations", "error", err, "skill_id", skillID)
+		logger.FromContext(r.Context()).With("error", err, "skill_id", skillID).Error("failed to get skill installations")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -342,7 +342,7 @@ func (sh *skillHandler) DeleteSkillInstallByID(w http.ResponseWriter, r *http.Re
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[skill] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[skill] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -364,7 +364,7 @@ func (sh *skillHandler) DeleteSkillInstallByID(w http.ResponseWriter, r *http.Re
 
 	install, err := sh.db.GetSkillInstallByID(id)
 	if err != nil {
-		logger.Log.Error("failed to get skill installation by ID", "error", err, "id", id)
+		logger.FromContext(r.Context()).With("error", err, "id", id).Error("failed to get skill installation by ID")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -372,21 +372,21 @@ func (sh *skillHandler) DeleteSkillInstallByID(w http.ResponseWriter, r *http.Re
 
 	skill, err := sh.db.GetSkillByID(install.SkillID)
 	if err != nil {
-		logger.Log.Error("failed to get skill by ID", "error", err, "id", install.SkillID)
+		logger.FromContext(r.Context()).With("error", err, "id", install.SkillID).Error("failed to get skill by ID")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
 	if skill.OwnerPubkey != pubKeyFromAuth {
-		logger.Log.Info("[skill] unauthorized delete attempt", "pubkey", pubKeyFromAuth, "owner", skill.OwnerPubkey)
+		logger.FromContext(r.Context()).With("pubkey", pubKeyFromAuth, "owner", skill.OwnerPubkey).Info("[skill] unauthorized delete attempt")
 		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode(map[string]string{"error": "You are not authorized to delete installations for this skill"})
 		return
 	}
 
 	if err := sh.db.DeleteSkillInstallByID(id); err != nil {
-		logger.Log.Error("failed to delete skill installation", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to delete skill installation")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -404,7 +404,7 @@ func (sh *skillHandler) UpdateSkillInstallByID(w http.ResponseWriter, r *http.Re
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[skill] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[skill] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -426,7 +426,7 @@ func (sh *skillHandler) UpdateSkillInstallByID(w http.ResponseWriter, r *http.Re
 
 	existingInstall, err := sh.db.GetSkillInstallByID(id)
 	if err != nil {
-		logger.Log.Error("failed to get skill installation by ID", "error", err, "id", id)
+		logger.FromContext(r.Context()).With("error", err, "id", id).Error("failed to get skill installation by ID")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -434,14 +434,14 @@ func (sh *skillHandler) UpdateSkillInstallByID(w http.ResponseWriter, r *http.Re
 
 	skill, err := sh.db.GetSkillByID(existingInstall.SkillID)
 	if err != nil {
-		logger.Log.Error("failed to get skill by ID", "error", err, "id", existingInstall.SkillID)
+		logger.FromContext(r.Context()).With("error", err, "id", existingInstall.SkillID).Error("failed to get skill by ID")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
 	if skill.OwnerPubkey != pubKeyFromAuth {
-		logger.Log.Info("[skill] unauthorized update attempt", "pubkey", pubKeyFromAuth, "owner", skill.OwnerPubkey)
+		logger.FromContext(r.Context()).With("pubkey", pubKeyFromAuth, "owner", skill.OwnerPubkey).Info("[skill] unauthorized update attempt")
 		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode(map[string]string{"error": "You are not authorized to update installations for this skill"})
 		return
@@ -449,7 +449,7 @@ func (sh *skillHandler) UpdateSkillInstallByID(w http.ResponseWriter, r *http.Re
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		logger.Log.Error("failed to read request body", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to read request body")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Error reading request body"})
 		return
@@ -458,7 +458,7 @@ func (sh *skillHandler) UpdateSkillInstallByID(w http.ResponseWriter, r *http.Re
 
 	var updatedInstall db.SkillInstall
 	if err := json.Unmarshal(body, &updatedInstall); err != nil {
-		logger.Log.Error("failed to unmarshal installation data", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to unmarshal installation data")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid installation data format"})
 		return
@@ -469,7 +469,7 @@ func (sh *skillHandler) UpdateSkillInstallByID(w http.ResponseWriter, r *http.Re
 
 	result, err := sh.db.UpdateSkillInstallByID(&updatedInstall)
 	if err != nil {
-		logger.Log.Error("failed to update skill installation", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("failed to update skill installation")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -496,7 +496,7 @@ func (sh *skillHandler) GetSkillInstallByID(w http.ResponseWriter, r *http.Reque
 
 	install, err := sh.db.GetSkillInstallByID(id)
 	if err != nil {
-		logger.Log.Error("failed to get skill installation by ID", "error", err, "id", id)
+		logger.FromContext(r.Context()).With("error", err, "id", id).Error("failed to get skill installation by ID")
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -47,7 +47,7 @@ func (sh *snippetHandler) CreateSnippet(w http.ResponseWriter, r *http.Request)
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[snippet] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[snippet] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -78,7 +78,7 @@ func (sh *snippetHandler) CreateSnippet(w http.ResponseWriter, r *http.Request)
 
 	createdSnippet, err := sh.db.CreateSnippet(snippet)
 	if err != nil {
-		logger.Log.Error(fmt.Sprintf("Failed to create snippet: %v", err))
+		logger.FromContext(r.Context()).Error(fmt.Sprintf("Failed to create snippet: %v", err))
 		http.Error(w, "Failed to create snippet", http.StatusInternalServerError)
 		return
 	}
@@ -103,7 +103,7 @@ func (sh *snippetHandler) GetSnippetsByWorkspace(w http.ResponseWriter, r *http.
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[snippet] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[snippet] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -117,7 +117,7 @@ func (sh *snippetHandler) GetSnippetsByWorkspace(w http.ResponseWriter, r *http.
 
 	snippets, err := sh.db.GetSnippetsByWorkspace(workspaceUUID)
 	if err != nil {
-		logger.Log.Error(fmt.Sprintf("Failed to fetch snippets: %v", err))
+		logger.FromContext(r.Context()).Error(fmt.Sprintf("Failed to fetch snippets: %v", err))
 		http.Error(w, "Failed to fetch snippets", http.StatusInternalServerError)
 		return
 	}
@@ -141,7 +141,7 @@ func (sh *snippetHandler) GetSnippetByID(w http.ResponseWriter, r *http.Request)
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[snippet] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[snippet] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -160,7 +160,7 @@ func (sh *snippetHandler) GetSnippetByID(w http.ResponseWriter, r *http.Request)
 			http.Error(w, "Snippet not found", http.StatusNotFound)
 			return
 		}
-		logger.Log.Error(fmt.Sprintf("Failed to fetch snippet: %v", err))
+		logger.FromContext(r.Context()).Error(fmt.Sprintf("Failed to fetch snippet: %v", err))
 		http.Error(w, "Failed to fetch snippet", http.StatusInternalServerError)
 		return
 	}
@@ -185,7 +185,7 @@ func (sh *snippetHandler) UpdateSnippet(w http.ResponseWriter, r *http.Request)
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[snippet] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[snippet] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -221,7 +221,7 @@ func (sh *snippetHandler) UpdateSnippet(w http.ResponseWriter, r *http.Request)
 			http.Error(w, "Snippet not found", http.StatusNotFound)
 			return
 		}
-		logger.Log.Error(fmt.Sprintf("Failed to update snippet: %v", err))
+		logger.FromContext(r.Context()).Error(fmt.Sprintf("Failed to update snippet: %v", err))
 		http.Error(w, "Failed to update snippet", http.StatusInternalServerError)
 		return
 	}
@@ -245,7 +245,7 @@ func (sh *snippetHandler) DeleteSnippet(w http.ResponseWriter, r *http.Request)
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[snippet] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[snippet] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -264,7 +264,7 @@ func (sh *snippetHandler) DeleteSnippet(w http.ResponseWriter, r *http.Request)
 			http.Error(w, "Snippet not found", http.StatusNotFound)
 			return
 		}
-		logger.Log.Error(fmt.Sprintf("Failed to delete snippet: %v", err))
+		logger.FromContext(r.Context()).Error(fmt.Sprintf("Failed to delete snippet: %v", err))
 		http.Error(w, "Failed to delete snippet", http.StatusInternalServerError)
 		return
 	}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 
+	"github.com/stakwork/sphinx-tribes/logger"
 	"github.com/stakwork/sphinx-tribes/websocket"
 )
 
@@ -10,3 +12,28 @@ func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	pool := websocket.WebsocketPool
 	websocket.ServeWs(pool, w, r)
 }
+
+// HandleEventStream godoc
+//
+//	@Summary		Stream topic events
+//	@Description	Streams the events of the topics, the same as the websocket subscriptions, as server-sent events. A client that reconnects with Last-Event-ID gets the events it missed first.
+//	@Tags			Realtime
+//	@Produce		text/event-stream
+//	@Security		PubKeyContextAuth
+//	@Param			topic			query	[]string	true	"Topics to follow, like workspace:<uuid> or chat:<id>"
+//	@Param			Last-Event-ID	header	string		false	"Id of the last event received"
+//	@Success		200
+//	@Failure		400	{object}	map[string]string
+//	@Failure		403	{object}	map[string]string
+//	@Router			/events [get]
+func HandleEventStream(w http.ResponseWriter, r *http.Request) {
+	websocket.ServeSSE(websocket.WebsocketPool, w, r)
+}
+
+// publishEvent tells the subscribers of a topic about a change, the change
+// is already saved so a failure is only logged
+func publishEvent(ctx context.Context, topic string, event string, data interface{}) {
+	if err := websocket.WebsocketPool.Publish(topic, event, data); err != nil {
+		logger.FromContext(ctx).Error("[websocket] could not publish %s to %s: %v", event, topic, err)
+	}
+}
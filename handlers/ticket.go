@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
 	"time"
@@ -140,7 +139,7 @@ func (th *ticketHandler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[ticket] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[ticket] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -223,6 +222,12 @@ func (th *ticketHandler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if auth.APIKeyFromContext(ctx) != nil && !auth.APIKeyAllowsWorkspace(ctx, th.db.GetFeatureByUuid(newTicket.FeatureUUID).WorkspaceUuid) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "API key does not have access to this workspace"})
+		return
+	}
+
 	createdTicket, err := th.db.CreateOrEditTicket(&newTicket)
 	if err != nil {
 		if err.Error() == "feature_uuid, phase_uuid, and name are required" {
@@ -235,6 +240,10 @@ func (th *ticketHandler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if createdTicket.TicketGroup != nil {
+		publishEvent(ctx, websocket.TicketGroupTopic(createdTicket.TicketGroup.String()), "ticket_updated", createdTicket)
+	}
+
 	if updateRequest.Metadata.Source == "websocket" && updateRequest.Metadata.ID != "" {
 		ticketMsg := websocket.TicketMessage{
 			BroadcastType:   "direct",
@@ -250,7 +259,7 @@ func (th *ticketHandler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if err := websocket.WebsocketPool.SendTicketMessage(ticketMsg); err != nil {
-			log.Printf("Failed to send websocket message: %v", err)
+			logger.FromContext(r.Context()).Error("Failed to send websocket message: %v", err)
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(map[string]interface{}{
 				"ticket":          createdTicket,
@@ -281,7 +290,7 @@ func (th *ticketHandler) UpdateTicketSequence(w http.ResponseWriter, r *http.Req
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[ticket] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[ticket] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -330,7 +339,7 @@ func (th *ticketHandler) UpdateTicketSequence(w http.ResponseWriter, r *http.Req
 
 		_, err := th.db.CreateOrEditTicket(&ticket)
 		if err != nil {
-			logger.Log.Error(fmt.Sprintf("Failed to update ticket UUID: %s, error: %v", ticket.UUID.String(), err))
+			logger.FromContext(r.Context()).Error(fmt.Sprintf("Failed to update ticket UUID: %s, error: %v", ticket.UUID.String(), err))
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("Failed to update ticket sequences: %v", err)})
 			return
@@ -357,7 +366,7 @@ func (th *ticketHandler) DeleteTicket(w http.ResponseWriter, r *http.Request) {
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[ticket] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[ticket] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -383,16 +392,14 @@ func (th *ticketHandler) DeleteTicket(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := th.db.DeleteTicketGroup(*ticket.TicketGroup); err != nil {
-		logger.Log.Error("failed to delete ticket group",
-			"error", err,
-			"ticket_group", ticket.TicketGroup)
+		logger.FromContext(r.Context()).With("error", err, "ticket_group", ticket.TicketGroup).Error("failed to delete ticket group")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("Failed to delete ticket group: %v", err)})
 		return
 	}
 
-	logger.Log.Info("ticket group deleted successfully",
-		"ticket_group", ticket.TicketGroup)
+	logger.FromContext(r.Context()).With("ticket_group", ticket.TicketGroup).Info("ticket group deleted successfully")
+	publishEvent(ctx, websocket.TicketGroupTopic(ticket.TicketGroup.String()), "ticket_deleted", ticket)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Ticket group deleted successfully"})
@@ -414,7 +421,7 @@ func (th *ticketHandler) PostTicketDataToStakwork(w http.ResponseWriter, r *http
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[ticket] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[ticket] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -423,7 +430,7 @@ func (th *ticketHandler) PostTicketDataToStakwork(w http.ResponseWriter, r *http
 	user := th.db.GetPersonByPubkey(pubKeyFromAuth)
 
 	if user.OwnerPubKey != pubKeyFromAuth {
-		logger.Log.Info("Person not exists")
+		logger.FromContext(r.Context()).Info("Person not exists")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -635,7 +642,7 @@ func (th *ticketHandler) PostTicketDataToStakwork(w http.ResponseWriter, r *http
 		return
 	}
 
-	req, err := http.NewRequest(http.MethodPost, "https://api.stakwork.com/api/v1/projects", bytes.NewBuffer(stakworkPayloadJSON))
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, "https://api.stakwork.com/api/v1/projects", bytes.NewBuffer(stakworkPayloadJSON))
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(TicketResponse{
@@ -708,7 +715,7 @@ func (th *ticketHandler) PostTicketDataToStakwork(w http.ResponseWriter, r *http
 		}
 
 		if err := websocket.WebsocketPool.SendTicketMessage(ticketMsg); err != nil {
-			log.Printf("Failed to send websocket message: %v", err)
+			logger.FromContext(r.Context()).Error("Failed to send websocket message: %v", err)
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(map[string]interface{}{
 				"ticket":          ticketRequest,
@@ -731,7 +738,7 @@ func (th *ticketHandler) PostTicketDataToStakwork(w http.ResponseWriter, r *http
 		}
 
 		if err := websocket.WebsocketPool.SendTicketMessage(projectMsg); err != nil {
-			log.Printf("Failed to send project ID websocket message: %v", err)
+			logger.FromContext(r.Context()).Error("Failed to send project ID websocket message: %v", err)
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(map[string]interface{}{
 				"ticket":          ticketRequest,
@@ -763,7 +770,7 @@ func (th *ticketHandler) ProcessTicketReview(w http.ResponseWriter, r *http.Requ
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		log.Printf("Error reading request body: %v", err)
+		logger.FromContext(r.Context()).Error("Error reading request body: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Error reading request body"})
 		return
@@ -772,14 +779,14 @@ func (th *ticketHandler) ProcessTicketReview(w http.ResponseWriter, r *http.Requ
 
 	var reviewReq utils.TicketReviewRequest
 	if err := json.Unmarshal(body, &reviewReq); err != nil {
-		log.Printf("Error parsing request JSON: %v", err)
+		logger.FromContext(r.Context()).Error("Error parsing request JSON: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Error parsing request body"})
 		return
 	}
 
 	if err := utils.ValidateTicketReviewRequest(&reviewReq); err != nil {
-		log.Printf("Invalid request data: %v", err)
+		logger.FromContext(r.Context()).Error("Invalid request data: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -787,7 +794,7 @@ func (th *ticketHandler) ProcessTicketReview(w http.ResponseWriter, r *http.Requ
 
 	existingTicket, err := th.db.GetTicket(reviewReq.Value.TicketUUID)
 	if err != nil {
-		log.Printf("Error fetching ticket: %v", err)
+		logger.FromContext(r.Context()).Error("Error fetching ticket: %v", err)
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Ticket not found"})
 		return
@@ -816,7 +823,7 @@ func (th *ticketHandler) ProcessTicketReview(w http.ResponseWriter, r *http.Requ
 
 	createdTicket, err := th.db.CreateOrEditTicket(&newTicket)
 	if err != nil {
-		log.Printf("Error creating new ticket: %v", err)
+		logger.FromContext(r.Context()).Error("Error creating new ticket: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create new ticket"})
 		return
@@ -837,7 +844,7 @@ func (th *ticketHandler) ProcessTicketReview(w http.ResponseWriter, r *http.Requ
 	}
 
 	if err := websocket.WebsocketPool.SendTicketMessage(ticketMsg); err != nil {
-		log.Printf("Failed to send websocket message: %v", err)
+		logger.FromContext(r.Context()).Error("Failed to send websocket message: %v", err)
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"ticket":          createdTicket,
@@ -846,7 +853,7 @@ func (th *ticketHandler) ProcessTicketReview(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	log.Printf("Successfully created new ticket version %s", createdTicket.UUID.String())
+	logger.FromContext(r.Context()).Info("Successfully created new ticket version %s", createdTicket.UUID.String())
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(createdTicket)
 }
@@ -867,7 +874,7 @@ func (th *ticketHandler) GetTicketsByPhaseUUID(w http.ResponseWriter, r *http.Re
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -876,13 +883,13 @@ func (th *ticketHandler) GetTicketsByPhaseUUID(w http.ResponseWriter, r *http.Re
 	phaseUUID := chi.URLParam(r, "phase_uuid")
 
 	if featureUUID == "" {
-		log.Println("feature uuid is missing")
+		logger.FromContext(r.Context()).Info("feature uuid is missing")
 		http.Error(w, "Missing feature uuid", http.StatusBadRequest)
 		return
 	}
 
 	if phaseUUID == "" {
-		log.Println("phase uuid is missing")
+		logger.FromContext(r.Context()).Info("phase uuid is missing")
 		http.Error(w, "Missing phase uuid", http.StatusBadRequest)
 		return
 	}
@@ -927,7 +934,7 @@ func (th *ticketHandler) TicketToBounty(w http.ResponseWriter, r *http.Request)
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Error("no pubkey from auth")
+		logger.FromContext(r.Context()).Error("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -945,34 +952,25 @@ func (th *ticketHandler) TicketToBounty(w http.ResponseWriter, r *http.Request)
 
 	ticket, err := th.db.GetTicket(ticketUUID)
 	if err != nil {
-		logger.Log.Error("failed to fetch ticket", "error", err, "ticket_uuid", ticketUUID)
+		logger.FromContext(r.Context()).With("error", err, "ticket_uuid", ticketUUID).Error("failed to fetch ticket")
 		http.Error(w, "failed to fetch ticket", http.StatusNotFound)
 		return
 	}
 
-	logger.Log.Info("creating bounty from ticket",
-		"ticket_uuid", ticketUUID,
-		"pubkey", pubKeyFromAuth)
+	logger.FromContext(r.Context()).With("ticket_uuid", ticketUUID, "pubkey", pubKeyFromAuth).Info("creating bounty from ticket")
 
 	bounty, err := th.db.CreateBountyFromTicket(ticket, pubKeyFromAuth)
 	if err != nil {
-		logger.Log.Error("failed to create bounty",
-			"error", err,
-			"ticket_uuid", ticketUUID,
-			"pubkey", pubKeyFromAuth)
+		logger.FromContext(r.Context()).With("error", err, "ticket_uuid", ticketUUID, "pubkey", pubKeyFromAuth).Error("failed to create bounty")
 		http.Error(w, "failed to create bounty", http.StatusInternalServerError)
 		return
 	}
 
-	logger.Log.Info("bounty created successfully",
-		"bounty_id", bounty.ID,
-		"owner_id", bounty.OwnerID)
+	logger.FromContext(r.Context()).With("bounty_id", bounty.ID, "owner_id", bounty.OwnerID).Info("bounty created successfully")
 
 	// Delete the ticket after successful bounty creation
 	if err := th.db.DeleteTicketGroup(*ticket.TicketGroup); err != nil {
-		logger.Log.Error("failed to delete ticket group after bounty creation",
-			"error", err,
-			"ticket_group", ticket.TicketGroup)
+		logger.FromContext(r.Context()).With("error", err, "ticket_group", ticket.TicketGroup).Error("failed to delete ticket group after bounty creation")
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
@@ -984,8 +982,7 @@ func (th *ticketHandler) TicketToBounty(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	logger.Log.Info("ticket deleted successfully after bounty creation",
-		"ticket_uuid", ticketUUID)
+	logger.FromContext(r.Context()).With("ticket_uuid", ticketUUID).Info("ticket deleted successfully after bounty creation")
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -1012,7 +1009,7 @@ func (th *ticketHandler) GetTicketsByGroup(w http.ResponseWriter, r *http.Reques
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Error("no pubkey from auth")
+		logger.FromContext(r.Context()).Error("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1031,7 +1028,7 @@ func (th *ticketHandler) GetTicketsByGroup(w http.ResponseWriter, r *http.Reques
 
 	tickets, err := th.db.GetTicketsByGroup(parsedUUID.String())
 	if err != nil {
-		logger.Log.Error("failed to fetch tickets by group", "error", err, "group_uuid", groupUUID)
+		logger.FromContext(r.Context()).With("error", err, "group_uuid", groupUUID).Error("failed to fetch tickets by group")
 		http.Error(w, "failed to fetch tickets", http.StatusInternalServerError)
 		return
 	}
@@ -1059,7 +1056,7 @@ func (th *ticketHandler) CreateWorkspaceDraftTicket(w http.ResponseWriter, r *ht
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[ticket] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[ticket] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -1130,7 +1127,7 @@ func (th *ticketHandler) GetWorkspaceDraftTicket(w http.ResponseWriter, r *http.
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[ticket] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[ticket] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -1178,7 +1175,7 @@ func (th *ticketHandler) UpdateWorkspaceDraftTicket(w http.ResponseWriter, r *ht
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[ticket] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[ticket] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -1276,7 +1273,7 @@ func (th *ticketHandler) DeleteWorkspaceDraftTicket(w http.ResponseWriter, r *ht
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[ticket] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[ticket] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -1317,7 +1314,7 @@ func (th *ticketHandler) DeleteWorkspaceDraftTicket(w http.ResponseWriter, r *ht
 	}
 
 	if err := websocket.WebsocketPool.SendTicketMessage(ticketMsg); err != nil {
-		logger.Log.Error("Failed to send websocket message", "error", err)
+		logger.FromContext(r.Context()).With("error", err).Error("Failed to send websocket message")
 	}
 
 	w.WriteHeader(http.StatusNoContent)
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
 	"time"
@@ -70,7 +69,7 @@ func (th *ticketHandler) CreateTicketPlan(w http.ResponseWriter, r *http.Request
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[ticket plan] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[ticket plan] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -151,7 +150,7 @@ func (th *ticketHandler) CreateTicketPlan(w http.ResponseWriter, r *http.Request
 		})
 
 		if websocketErr != nil {
-			logger.Log.Error("Failed to send websocket message", "error", websocketErr)
+			logger.FromContext(r.Context()).With("error", websocketErr).Error("Failed to send websocket message")
 		}
 	}
 
@@ -179,7 +178,7 @@ func (th *ticketHandler) GetTicketPlan(w http.ResponseWriter, r *http.Request) {
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[ticket plan] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[ticket plan] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -223,7 +222,7 @@ func (th *ticketHandler) DeleteTicketPlan(w http.ResponseWriter, r *http.Request
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[ticket plan] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[ticket plan] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -267,7 +266,7 @@ func (th *ticketHandler) GetTicketPlansByFeature(w http.ResponseWriter, r *http.
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[ticket plan] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[ticket plan] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -307,7 +306,7 @@ func (th *ticketHandler) GetTicketPlansByPhase(w http.ResponseWriter, r *http.Re
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[ticket plan] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[ticket plan] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -347,7 +346,7 @@ func (th *ticketHandler) GetTicketPlansByWorkspace(w http.ResponseWriter, r *htt
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[ticket plan] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[ticket plan] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -387,7 +386,7 @@ func (th *ticketHandler) SendTicketPlanToStakwork(w http.ResponseWriter, r *http
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[ticket plan] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[ticket plan] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
 		return
@@ -395,7 +394,7 @@ func (th *ticketHandler) SendTicketPlanToStakwork(w http.ResponseWriter, r *http
 
 	user := th.db.GetPersonByPubkey(pubKeyFromAuth)
 	if user.OwnerPubKey != pubKeyFromAuth {
-		logger.Log.Info("Person not exists")
+		logger.FromContext(r.Context()).Info("Person not exists")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -577,7 +576,7 @@ func (th *ticketHandler) SendTicketPlanToStakwork(w http.ResponseWriter, r *http
 		return
 	}
 
-	req, err := http.NewRequest(http.MethodPost, "https://api.stakwork.com/api/v1/projects", bytes.NewBuffer(stakworkPayloadJSON))
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, "https://api.stakwork.com/api/v1/projects", bytes.NewBuffer(stakworkPayloadJSON))
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(TicketPlanResponse{
@@ -649,7 +648,7 @@ func (th *ticketHandler) SendTicketPlanToStakwork(w http.ResponseWriter, r *http
 		}
 
 		if err := websocket.WebsocketPool.SendTicketPlanMessage(ticketMsg); err != nil {
-			log.Printf("Failed to send websocket message: %v", err)
+			logger.FromContext(r.Context()).Error("Failed to send websocket message: %v", err)
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(map[string]interface{}{
 				"plan":            planRequest,
@@ -671,7 +670,7 @@ func (th *ticketHandler) SendTicketPlanToStakwork(w http.ResponseWriter, r *http
 		}
 
 		if err := websocket.WebsocketPool.SendTicketPlanMessage(projectMsg); err != nil {
-			log.Printf("Failed to send project ID websocket message: %v", err)
+			logger.FromContext(r.Context()).Error("Failed to send project ID websocket message: %v", err)
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(map[string]interface{}{
 				"plan":            planRequest,
@@ -703,7 +702,7 @@ func (th *ticketHandler) ProcessTicketPlanReview(w http.ResponseWriter, r *http.
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		log.Printf("Error reading request body: %v", err)
+		logger.FromContext(r.Context()).Error("Error reading request body: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(db.TicketPlanReviewResponse{
 			Success: false,
@@ -716,7 +715,7 @@ func (th *ticketHandler) ProcessTicketPlanReview(w http.ResponseWriter, r *http.
 
 	var planReview db.TicketPlanReviewRequest
 	if err := json.Unmarshal(body, &planReview); err != nil {
-		log.Printf("Error parsing request JSON: %v", err)
+		logger.FromContext(r.Context()).Error("Error parsing request JSON: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(db.TicketPlanReviewResponse{
 			Success: false,
@@ -778,7 +777,7 @@ func (th *ticketHandler) ProcessTicketPlanReview(w http.ResponseWriter, r *http.
 
 		createdTicket, err := th.db.CreateOrEditTicket(&ticket)
 		if err != nil {
-			log.Printf("Error creating ticket: %v", err)
+			logger.FromContext(r.Context()).Error("Error creating ticket: %v", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(db.TicketPlanReviewResponse{
 				Success: false,
@@ -805,7 +804,7 @@ func (th *ticketHandler) ProcessTicketPlanReview(w http.ResponseWriter, r *http.
 			}
 
 			if err := websocket.WebsocketPool.SendTicketMessage(ticketMsg); err != nil {
-				log.Printf("Failed to send ticket websocket message: %v", err)
+				logger.FromContext(r.Context()).Error("Failed to send ticket websocket message: %v", err)
 			}
 		}
 	}
@@ -824,7 +823,7 @@ func (th *ticketHandler) ProcessTicketPlanReview(w http.ResponseWriter, r *http.
 		}
 
 		if err := websocket.WebsocketPool.SendTicketPlanMessage(completionMsg); err != nil {
-			log.Printf("Failed to send completion websocket message: %v", err)
+			logger.FromContext(r.Context()).Error("Failed to send completion websocket message: %v", err)
 		}
 	}
 
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -149,7 +148,7 @@ func PutTribeStats(w http.ResponseWriter, r *http.Request) {
 	r.Body.Close()
 	err = json.Unmarshal(body, &tribe)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -161,7 +160,7 @@ func PutTribeStats(w http.ResponseWriter, r *http.Request) {
 
 	extractedPubkey, err := auth.VerifyTribeUUID(tribe.UUID, false)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -208,7 +207,7 @@ func (th *tribeHandler) DeleteTribe(w http.ResponseWriter, r *http.Request) {
 
 	extractedPubkey, err := th.verifyTribeUUID(uuid, false)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -318,13 +317,13 @@ func (th *tribeHandler) CreateOrEditTribe(w http.ResponseWriter, r *http.Request
 	r.Body.Close()
 	err = json.Unmarshal(body, &tribe)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
 
 	if tribe.UUID == "" {
-		logger.Log.Info("createOrEditTribe no uuid")
+		logger.FromContext(r.Context()).Info("createOrEditTribe no uuid")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -333,7 +332,7 @@ func (th *tribeHandler) CreateOrEditTribe(w http.ResponseWriter, r *http.Request
 
 	extractedPubkey, err := th.verifyTribeUUID(tribe.UUID, false)
 	if err != nil {
-		logger.Log.Error("extract UUID error: %v", err)
+		logger.FromContext(r.Context()).Error("extract UUID error: %v", err)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -342,7 +341,7 @@ func (th *tribeHandler) CreateOrEditTribe(w http.ResponseWriter, r *http.Request
 		tribe.Created = &now
 	} else { // IF PUBKEY IN CONTEXT, MUST AUTH!
 		if pubKeyFromAuth != extractedPubkey {
-			logger.Log.Info("createOrEditTribe pubkeys dont match")
+			logger.FromContext(r.Context()).Info("createOrEditTribe pubkeys dont match")
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
@@ -353,9 +352,9 @@ func (th *tribeHandler) CreateOrEditTribe(w http.ResponseWriter, r *http.Request
 		tribe.UniqueName, _ = th.tribeUniqueNameFromName(tribe.Name)
 	} else { // already exists! make sure it's owned
 		if existing.OwnerPubKey != extractedPubkey {
-			logger.Log.Info("createOrEditTribe tribe.ownerPubKey not match")
-			logger.Log.Info("existing owner: %s", existing.OwnerPubKey)
-			logger.Log.Info("extracted pubkey: %s", extractedPubkey)
+			logger.FromContext(r.Context()).Info("createOrEditTribe tribe.ownerPubKey not match")
+			logger.FromContext(r.Context()).Info("existing owner: %s", existing.OwnerPubKey)
+			logger.FromContext(r.Context()).Info("extracted pubkey: %s", extractedPubkey)
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
@@ -367,7 +366,7 @@ func (th *tribeHandler) CreateOrEditTribe(w http.ResponseWriter, r *http.Request
 
 	_, err = th.db.CreateOrEditTribe(tribe)
 	if err != nil {
-		logger.Log.Error("=> ERR createOrEditTribe: %v", err)
+		logger.FromContext(r.Context()).Error("=> ERR createOrEditTribe: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -399,7 +398,7 @@ func PutTribeActivity(w http.ResponseWriter, r *http.Request) {
 
 	extractedPubkey, err := auth.VerifyTribeUUID(uuid, false)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -440,7 +439,7 @@ func (th *tribeHandler) SetTribePreview(w http.ResponseWriter, r *http.Request)
 
 	extractedPubkey, err := th.verifyTribeUUID(uuid, false)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -486,7 +485,7 @@ func CreateLeaderBoard(w http.ResponseWriter, r *http.Request) {
 
 	extractedPubkey, err := auth.VerifyTribeUUID(uuid, false)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -501,7 +500,7 @@ func CreateLeaderBoard(w http.ResponseWriter, r *http.Request) {
 	r.Body.Close()
 	err = json.Unmarshal(body, &leaderBoard)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -509,7 +508,7 @@ func CreateLeaderBoard(w http.ResponseWriter, r *http.Request) {
 	_, err = db.DB.CreateLeaderBoard(uuid, leaderBoard)
 
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -578,7 +577,7 @@ func UpdateLeaderBoard(w http.ResponseWriter, r *http.Request) {
 
 	extractedPubkey, err := auth.VerifyTribeUUID(uuid, false)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -595,7 +594,7 @@ func UpdateLeaderBoard(w http.ResponseWriter, r *http.Request) {
 	r.Body.Close()
 	err = json.Unmarshal(body, &leaderBoard)
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -647,7 +646,7 @@ func GenerateInvoice(w http.ResponseWriter, r *http.Request) {
 	r.Body.Close()
 
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -655,7 +654,7 @@ func GenerateInvoice(w http.ResponseWriter, r *http.Request) {
 	err = json.Unmarshal(body, &invoice)
 
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -688,6 +687,7 @@ func GenerateInvoice(w http.ResponseWriter, r *http.Request) {
 	}
 
 	db.DB.ProcessAddInvoice(newInvoice, newInvoiceData)
+	subscribeToInvoice(paymentRequest, invoice.Websocket_token)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(invoiceRes)
@@ -700,14 +700,14 @@ func GenerateV1Invoice(w http.ResponseWriter, r *http.Request) (db.InvoiceRespon
 	r.Body.Close()
 
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		return db.InvoiceResponse{}, db.InvoiceError{Success: false, Error: err.Error()}
 	}
 
 	err = json.Unmarshal(body, &invoice)
 
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		return db.InvoiceResponse{}, db.InvoiceError{Success: false, Error: err.Error()}
 	}
 
@@ -721,14 +721,14 @@ func GenerateV1Invoice(w http.ResponseWriter, r *http.Request) (db.InvoiceRespon
 	jsonBody := []byte(bodyData)
 
 	client := &http.Client{}
-	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonBody))
+	req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, url, bytes.NewBuffer(jsonBody))
 
 	req.Header.Set("x-user-token", config.RelayAuthKey)
 	req.Header.Set("Content-Type", "application/json")
 	res, _ := client.Do(req)
 
 	if err != nil {
-		log.Printf("Request Failed: %s", err)
+		logger.FromContext(r.Context()).Error("Request Failed: %s", err)
 		return db.InvoiceResponse{}, db.InvoiceError{Success: false, Error: err.Error()}
 	}
 
@@ -737,7 +737,7 @@ func GenerateV1Invoice(w http.ResponseWriter, r *http.Request) (db.InvoiceRespon
 	body, err = io.ReadAll(res.Body)
 
 	if err != nil {
-		log.Printf("Reading body failed: %s", err)
+		logger.FromContext(r.Context()).Error("Reading body failed: %s", err)
 		return db.InvoiceResponse{}, db.InvoiceError{Success: false, Error: err.Error()}
 	}
 
@@ -747,7 +747,7 @@ func GenerateV1Invoice(w http.ResponseWriter, r *http.Request) (db.InvoiceRespon
 	err = json.Unmarshal(body, &invoiceRes)
 
 	if err != nil {
-		log.Printf("Unmarshal body failed: %s", err)
+		logger.FromContext(r.Context()).Error("Unmarshal body failed: %s", err)
 		return db.InvoiceResponse{}, db.InvoiceError{Success: false, Error: err.Error()}
 	}
 
@@ -771,14 +771,14 @@ func GenerateV2Invoice(w http.ResponseWriter, r *http.Request) (db.InvoiceRespon
 	r.Body.Close()
 
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		return db.InvoiceResponse{}, db.InvoiceError{Success: false, Error: err.Error()}
 	}
 
 	err = json.Unmarshal(body, &invoice)
 
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		return db.InvoiceResponse{}, db.InvoiceError{Success: false, Error: err.Error()}
 	}
 
@@ -793,14 +793,14 @@ func GenerateV2Invoice(w http.ResponseWriter, r *http.Request) (db.InvoiceRespon
 	jsonBody := []byte(bodyData)
 
 	client := &http.Client{}
-	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonBody))
+	req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, url, bytes.NewBuffer(jsonBody))
 
 	req.Header.Set("x-admin-token", config.V2BotToken)
 	req.Header.Set("Content-Type", "application/json")
 	res, err := client.Do(req)
 
 	if err != nil {
-		log.Printf("Client Request Failed: %s", err)
+		logger.FromContext(r.Context()).Error("Client Request Failed: %s", err)
 		return db.InvoiceResponse{}, db.InvoiceError{Success: false, Error: err.Error()}
 	}
 
@@ -809,7 +809,7 @@ func GenerateV2Invoice(w http.ResponseWriter, r *http.Request) (db.InvoiceRespon
 	body, err = io.ReadAll(res.Body)
 
 	if err != nil {
-		log.Printf("Reading body failed: %s", err)
+		logger.FromContext(r.Context()).Error("Reading body failed: %s", err)
 		return db.InvoiceResponse{}, db.InvoiceError{Success: false, Error: err.Error()}
 	}
 
@@ -818,7 +818,7 @@ func GenerateV2Invoice(w http.ResponseWriter, r *http.Request) (db.InvoiceRespon
 	err = json.Unmarshal(body, &v2InvoiceRes)
 
 	if err != nil {
-		log.Printf("Json Unmarshal failed: %s", err)
+		logger.FromContext(r.Context()).Error("Json Unmarshal failed: %s", err)
 		return db.InvoiceResponse{}, db.InvoiceError{Success: false, Error: err.Error()}
 	}
 	return db.InvoiceResponse{
@@ -853,7 +853,7 @@ func (th *tribeHandler) GenerateV1BudgetInvoice(w http.ResponseWriter, r *http.R
 	r.Body.Close()
 
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -861,7 +861,7 @@ func (th *tribeHandler) GenerateV1BudgetInvoice(w http.ResponseWriter, r *http.R
 	err = json.Unmarshal(body, &invoice)
 
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -877,14 +877,14 @@ func (th *tribeHandler) GenerateV1BudgetInvoice(w http.ResponseWriter, r *http.R
 	jsonBody := []byte(bodyData)
 
 	client := &http.Client{}
-	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonBody))
+	req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, url, bytes.NewBuffer(jsonBody))
 
 	req.Header.Set("x-user-token", config.RelayAuthKey)
 	req.Header.Set("Content-Type", "application/json")
 	res, _ := client.Do(req)
 
 	if err != nil {
-		log.Printf("Request Failed: %s", err)
+		logger.FromContext(r.Context()).Error("Request Failed: %s", err)
 		return
 	}
 
@@ -893,7 +893,7 @@ func (th *tribeHandler) GenerateV1BudgetInvoice(w http.ResponseWriter, r *http.R
 	body, err = io.ReadAll(res.Body)
 
 	if err != nil {
-		log.Printf("Reading body failed: %s", err)
+		logger.FromContext(r.Context()).Error("Reading body failed: %s", err)
 		return
 	}
 
@@ -903,7 +903,7 @@ func (th *tribeHandler) GenerateV1BudgetInvoice(w http.ResponseWriter, r *http.R
 	err = json.Unmarshal(body, &invoiceRes)
 
 	if err != nil {
-		log.Printf("Json Unmarshal failed: %s", err)
+		logger.FromContext(r.Context()).Error("Json Unmarshal failed: %s", err)
 		return
 	}
 
@@ -931,6 +931,7 @@ func (th *tribeHandler) GenerateV1BudgetInvoice(w http.ResponseWriter, r *http.R
 	}
 
 	th.db.ProcessBudgetInvoice(paymentHistory, newInvoice)
+	subscribeToInvoice(newInvoice.PaymentRequest, invoice.Websocket_token)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(invoiceRes)
@@ -945,7 +946,7 @@ func (th *tribeHandler) GenerateV2BudgetInvoice(w http.ResponseWriter, r *http.R
 	r.Body.Close()
 
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -953,7 +954,7 @@ func (th *tribeHandler) GenerateV2BudgetInvoice(w http.ResponseWriter, r *http.R
 	err = json.Unmarshal(body, &invoice)
 
 	if err != nil {
-		logger.Log.Error("%v", err)
+		logger.FromContext(r.Context()).Error("%v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -971,14 +972,14 @@ func (th *tribeHandler) GenerateV2BudgetInvoice(w http.ResponseWriter, r *http.R
 	jsonBody := []byte(bodyData)
 
 	client := &http.Client{}
-	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonBody))
+	req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, url, bytes.NewBuffer(jsonBody))
 
 	req.Header.Set("x-admin-token", config.V2BotToken)
 	req.Header.Set("Content-Type", "application/json")
 	res, err := client.Do(req)
 
 	if err != nil {
-		log.Printf("Client Request Failed: %s", err)
+		logger.FromContext(r.Context()).Error("Client Request Failed: %s", err)
 		return
 	}
 
@@ -987,7 +988,7 @@ func (th *tribeHandler) GenerateV2BudgetInvoice(w http.ResponseWriter, r *http.R
 	body, err = io.ReadAll(res.Body)
 
 	if err != nil {
-		log.Printf("Reading body failed: %s", err)
+		logger.FromContext(r.Context()).Error("Reading body failed: %s", err)
 		return
 	}
 
@@ -996,7 +997,7 @@ func (th *tribeHandler) GenerateV2BudgetInvoice(w http.ResponseWriter, r *http.R
 	err = json.Unmarshal(body, &v2InvoiceRes)
 
 	if err != nil {
-		log.Printf("Json Unmarshal failed: %s", err)
+		logger.FromContext(r.Context()).Error("Json Unmarshal failed: %s", err)
 		return
 	}
 
@@ -1024,6 +1025,7 @@ func (th *tribeHandler) GenerateV2BudgetInvoice(w http.ResponseWriter, r *http.R
 	}
 
 	th.db.ProcessBudgetInvoice(paymentHistory, newInvoice)
+	subscribeToInvoice(newInvoice.PaymentRequest, invoice.Websocket_token)
 
 	invoiceRes := db.InvoiceResponse{
 		Succcess: true,
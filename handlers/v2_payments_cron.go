@@ -1,33 +1,38 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"time"
 
 	"github.com/stakwork/sphinx-tribes/config"
 	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
 	"github.com/stakwork/sphinx-tribes/utils"
 )
 
-func InitV2PaymentsCron() {
-	log.Println("Pending Invoice Cron Job Started")
+func InitV2PaymentsCron() error {
+	var errs []error
+	ctx := logger.WithFields(context.Background(), "cron", "v2_payments")
+	cronLog := logger.FromContext(ctx)
+	cronLog.Info("Pending Invoice Cron Job Started")
 	paymentHistories := db.DB.GetPendingPaymentHistory()
 	for _, payment := range paymentHistories {
 		bounty := db.DB.GetBounty(payment.BountyId)
-		log.Println("Bounty ID =========================", bounty.ID, bounty)
-		log.Println("Payment ID =========================", payment.ID, payment)
+		cronLog.Info("Bounty ID ========================= %v %v", bounty.ID, bounty)
+		cronLog.Info("Payment ID ========================= %v %v", payment.ID, payment)
 
 		if bounty.ID > 0 {
 			tag := payment.Tag
-			tagResult := GetInvoiceStatusByTag(tag)
-			log.Println("Tag Result =================", tagResult)
+			tagResult := GetInvoiceStatusByTag(ctx, tag)
+			cronLog.Info("Tag Result ================= %v", tagResult)
 
 			if tagResult.Status == db.PaymentComplete {
-				log.Println("Payment Status From V2 BOT IS Complete =================================", payment)
+				cronLog.Info("Payment Status From V2 BOT IS Complete ================================= %v", payment)
 				db.DB.SetPaymentAsComplete(tag)
 
 				now := time.Now()
@@ -41,55 +46,56 @@ func InitV2PaymentsCron() {
 				bounty.CompletionDate = &now
 
 				db.DB.UpdateBountyPaymentStatuses(bounty)
-				log.Println("Bounty Payment Statuses Updated =================================", bounty)
+				cronLog.Info("Bounty Payment Statuses Updated ================================= %v", bounty)
 			} else if tagResult.Status == db.PaymentPending {
-				log.Println("Payment Status From V2 BOT IS Pending =================================", payment)
+				cronLog.Info("Payment Status From V2 BOT IS Pending ================================= %v", payment)
 				if payment.PaymentStatus == db.PaymentPending {
-					log.Println("Payment Status From DB IS Pending =================================	", payment)
+					cronLog.Info("Payment Status From DB IS Pending ================================= %v", payment)
 					created := utils.ConvertTimeToTimestamp(payment.Created.String())
 
 					now := time.Now()
 					daysDiff := utils.GetDateDaysDifference(int64(created), &now)
 
-					log.Println("Payment Date Difference Is ================================================", daysDiff)
+					cronLog.Info("Payment Date Difference Is ================================================ %v", daysDiff)
 
 					if daysDiff >= 7 {
 
-						log.Println("Payment Date Difference Is Greater Or Equals 7 Days ================================================", payment)
+						cronLog.Info("Payment Date Difference Is Greater Or Equals 7 Days ================================================ %v", payment)
 
 						err := db.DB.ProcessReversePayments(payment.ID)
 						if err != nil {
-							log.Printf("Could not reverse bounty payment after 7 days : Bounty ID - %d, Payment ID - %d, Error - %s ================================================", bounty.ID, payment.ID, err)
+							errs = append(errs, fmt.Errorf("could not reverse payment %d of bounty %d after 7 days: %w", payment.ID, bounty.ID, err))
 						}
 
-						log.Println("Bounty Payment Statuses Updated After 7 Days ================================================", bounty)
+						cronLog.Info("Bounty Payment Statuses Updated After 7 Days ================================================ %v", bounty)
 					}
 				}
 			} else if tagResult.Status == db.PaymentFailed {
 				// Handle failed payments
 				err := db.DB.ProcessReversePayments(payment.ID)
 				if err != nil {
-					log.Printf("Could not reverse bounty payment : Bounty ID - %d, Payment ID - %d, Error - %s ================================================", bounty.ID, payment.ID, err)
+					errs = append(errs, fmt.Errorf("could not reverse payment %d of bounty %d: %w", payment.ID, bounty.ID, err))
 				}
 
-				log.Println("Bounty Payment Statuses Updated After Failed Payment ================================================", bounty)
+				cronLog.Error("Bounty Payment Statuses Updated After Failed Payment ================================================ %v", bounty)
 
 			} else {
-				log.Println("Payment Status From V2 BOT IS Unknown ================================================", payment, tagResult)
+				cronLog.Info("Payment Status From V2 BOT IS Unknown ================================================ %v %v", payment, tagResult)
 			}
 		}
 	}
+	return errors.Join(errs...)
 }
 
-func GetInvoiceStatusByTag(tag string) db.V2TagRes {
+func GetInvoiceStatusByTag(ctx context.Context, tag string) db.V2TagRes {
 	url := fmt.Sprintf("%s/sends/%s", config.V2BotUrl, tag)
 
 	client := http.Client{}
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 
 	if err != nil {
-		log.Printf("Error paying invoice: %s", err)
+		logger.FromContext(ctx).Error("Error paying invoice: %s", err)
 	}
 
 	req.Header.Set("x-admin-token", config.V2BotToken)
@@ -97,7 +103,7 @@ func GetInvoiceStatusByTag(tag string) db.V2TagRes {
 	res, err := client.Do(req)
 
 	if err != nil {
-		log.Printf("[Get Tag] Request Failed: %s", err)
+		logger.FromContext(ctx).Error("[Get Tag] Request Failed: %s", err)
 		return db.V2TagRes{}
 	}
 
@@ -106,14 +112,14 @@ func GetInvoiceStatusByTag(tag string) db.V2TagRes {
 	body, err := io.ReadAll(res.Body)
 
 	if err != nil {
-		log.Printf("Could not read body: %s", err)
+		logger.FromContext(ctx).Error("Could not read body: %s", err)
 	}
 
 	tagRes := []db.V2TagRes{}
 	err = json.Unmarshal(body, &tagRes)
 
 	if err != nil {
-		log.Printf("Could not unmarshal get tag result: %s", err)
+		logger.FromContext(ctx).Error("Could not unmarshal get tag result: %s", err)
 	}
 
 	resultLength := len(tagRes)
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+	"github.com/stakwork/sphinx-tribes/webhooks"
+)
+
+type webhookDeliveryHandler struct {
+	db           db.Database
+	replay       func(id uuid.UUID) (*db.WebhookDeadLetter, error)
+	openCircuits func() []string
+	pending      func() int64
+}
+
+func NewWebhookDeliveryHandler(database db.Database) *webhookDeliveryHandler {
+	return &webhookDeliveryHandler{
+		db:           database,
+		replay:       webhooks.Replay,
+		openCircuits: webhooks.OpenCircuits,
+		pending:      webhooks.Pending,
+	}
+}
+
+type WebhookDeadLettersResponse struct {
+	DeadLetters  []db.WebhookDeadLetter `json:"dead_letters"`
+	Total        int64                  `json:"total"`
+	Pending      int64                  `json:"pending"`
+	OpenCircuits []string               `json:"open_circuits"`
+}
+
+// GetDeadLetters godoc
+//
+//	@Summary		List webhook dead letters
+//	@Description	List the outbound webhook deliveries that failed every attempt, with the deliveries still pending and the destinations whose circuit is open
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Security		SuperAdminAuth
+//	@Param			status	query		string	false	"dead, replaying or delivered"
+//	@Param			limit	query		int		false	"Page size, 50 by default"
+//	@Param			offset	query		int		false	"Page offset"
+//	@Success		200		{object}	WebhookDeadLettersResponse
+//	@Failure		500		{object}	map[string]string
+//	@Router			/webhooks/dead-letters [get]
+func (h *webhookDeliveryHandler) GetDeadLetters(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	offset := 0
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 500 {
+		limit = l
+	}
+	if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	letters, total, err := h.db.GetWebhookDeadLetters(r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("[webhooks] could not list dead letters: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "could not list dead letters"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(WebhookDeadLettersResponse{
+		DeadLetters:  letters,
+		Total:        total,
+		Pending:      h.pending(),
+		OpenCircuits: h.openCircuits(),
+	})
+}
+
+// ReplayDeadLetter godoc
+//
+//	@Summary		Replay a webhook dead letter
+//	@Description	Send a dead-lettered delivery again with a fresh set of attempts, with the id it was first sent with
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Security		SuperAdminAuth
+//	@Param			id	path		string	true	"Dead letter ID"
+//	@Success		202	{object}	db.WebhookDeadLetter
+//	@Failure		400	{object}	map[string]string
+//	@Failure		404	{object}	map[string]string
+//	@Failure		409	{object}	map[string]string
+//	@Failure		503	{object}	map[string]string
+//	@Router			/webhooks/dead-letters/{id}/replay [post]
+func (h *webhookDeliveryHandler) ReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid dead letter id"})
+		return
+	}
+
+	letter, err := h.replay(id)
+	switch {
+	case err == nil:
+	case errors.Is(err, webhooks.ErrNotReplayable):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "dead letter is already " + string(letter.Status)})
+		return
+	case errors.Is(err, webhooks.ErrNotStarted), errors.Is(err, webhooks.ErrClosed):
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	case strings.Contains(err.Error(), "not found"):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "dead letter not found"})
+		return
+	default:
+		logger.FromContext(r.Context()).Error("[webhooks] could not replay dead letter %s: %v", id, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "could not replay dead letter"})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("[webhooks] replaying dead letter %s to %s", id, letter.URL)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(letter)
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/stakwork/sphinx-tribes/db"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stakwork/sphinx-tribes/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestWebhookDeliveryHandler(t *testing.T, mockDb *dbMocks.Database) *webhookDeliveryHandler {
+	h := NewWebhookDeliveryHandler(mockDb)
+	h.openCircuits = func() []string { return []string{"hooks.example.com"} }
+	h.pending = func() int64 { return 2 }
+	return h
+}
+
+func TestGetDeadLetters(t *testing.T) {
+	mockDb := dbMocks.NewDatabase(t)
+	h := newTestWebhookDeliveryHandler(t, mockDb)
+
+	letters := []db.WebhookDeadLetter{{ID: uuid.New(), Kind: SSEEventsWebhook, URL: "https://hooks.example.com", Status: db.WebhookDeadLetterDead}}
+	mockDb.On("GetWebhookDeadLetters", "dead", 10, 20).Return(letters, int64(21), nil).Once()
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/dead-letters?status=dead&limit=10&offset=20", nil)
+	h.GetDeadLetters(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response WebhookDeadLettersResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, int64(21), response.Total)
+	assert.Equal(t, int64(2), response.Pending)
+	assert.Equal(t, []string{"hooks.example.com"}, response.OpenCircuits)
+	assert.Len(t, response.DeadLetters, 1)
+}
+
+func TestReplayDeadLetter(t *testing.T) {
+	id := uuid.New()
+
+	replay := func(h *webhookDeliveryHandler, rawID string) *httptest.ResponseRecorder {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", rawID)
+		req := httptest.NewRequest(http.MethodPost, "/dead-letters/"+rawID+"/replay", nil)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		rr := httptest.NewRecorder()
+		h.ReplayDeadLetter(rr, req)
+		return rr
+	}
+
+	tests := []struct {
+		name     string
+		id       string
+		letter   *db.WebhookDeadLetter
+		err      error
+		expected int
+	}{
+		{name: "should replay a dead letter", id: id.String(), letter: &db.WebhookDeadLetter{ID: id, Status: db.WebhookDeadLetterReplaying}, expected: http.StatusAccepted},
+		{name: "should reject an invalid id", id: "not-a-uuid", expected: http.StatusBadRequest},
+		{name: "should not find an unknown dead letter", id: id.String(), err: fmt.Errorf("webhook dead letter with ID %s not found", id), expected: http.StatusNotFound},
+		{name: "should refuse a delivered dead letter", id: id.String(), letter: &db.WebhookDeadLetter{ID: id, Status: db.WebhookDeadLetterDelivered}, err: webhooks.ErrNotReplayable, expected: http.StatusConflict},
+		{name: "should report a dispatcher that is not running", id: id.String(), err: webhooks.ErrNotStarted, expected: http.StatusServiceUnavailable},
+		{name: "should report other failures", id: id.String(), err: errors.New("connection refused"), expected: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestWebhookDeliveryHandler(t, dbMocks.NewDatabase(t))
+			var replayed []uuid.UUID
+			h.replay = func(id uuid.UUID) (*db.WebhookDeadLetter, error) {
+				replayed = append(replayed, id)
+				return tt.letter, tt.err
+			}
+
+			rr := replay(h, tt.id)
+
+			assert.Equal(t, tt.expected, rr.Code)
+			if tt.expected == http.StatusBadRequest {
+				assert.Empty(t, replayed)
+			}
+		})
+	}
+}
+
+func TestMarkSSEEventsSent(t *testing.T) {
+	mockDb := dbMocks.NewDatabase(t)
+	first, second := uuid.New(), uuid.New()
+	mockDb.On("UpdateSSEMessageLogStatusBatch", []uuid.UUID{first, second}).Return(nil).Once()
+
+	hook := MarkSSEEventsSent(mockDb)
+
+	assert.NoError(t, hook(webhooks.Delivery{Kind: SSEEventsWebhook, Refs: []string{first.String(), second.String()}}))
+	assert.NoError(t, hook(webhooks.Delivery{Kind: SSEEventsWebhook}))
+	assert.Error(t, hook(webhooks.Delivery{Kind: SSEEventsWebhook, Refs: []string{"not-a-uuid"}}))
+}
+
+func newTestSSEWebhookServer(statuses ...int) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(ids) < len(statuses) {
+			w.WriteHeader(statuses[len(ids)])
+		}
+		ids = append(ids, r.Header.Get(webhooks.IDHeader))
+	}))
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ids...)
+	}
+}
+
+func startTestDispatcher(t *testing.T, mockDb *dbMocks.Database) func() {
+	d := webhooks.NewDispatcher(mockDb, &http.Client{}, "")
+	d.RetryInterval = time.Millisecond
+	d.MaxRetryInterval = 5 * time.Millisecond
+	webhooks.SetDispatcher(d)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		assert.NoError(t, d.Close(ctx))
+		webhooks.SetDispatcher(nil)
+	}
+}
+
+func TestSendEventPayloadToWebhook(t *testing.T) {
+	server, received := newTestSSEWebhookServer(http.StatusInternalServerError)
+	defer server.Close()
+
+	mockDb := dbMocks.NewDatabase(t)
+	stop := startTestDispatcher(t, mockDb)
+
+	first, second := uuid.New(), uuid.New()
+	var deliveryID uuid.UUID
+	mockDb.On("ClaimNewSSEMessageLogs", "chat-1", mock.AnythingOfType("uuid.UUID")).
+		Run(func(args mock.Arguments) { deliveryID = args.Get(1).(uuid.UUID) }).
+		Return([]db.SSEMessageLog{
+			{ID: first, ChatID: "chat-1", From: "https://sse.example.com", Status: db.SSEStatusSending},
+			{ID: second, ChatID: "chat-1", From: "https://sse.example.com", Status: db.SSEStatusSending},
+		}, nil).Once()
+	mockDb.On("UpdateSSEMessageLogStatusBatch", []uuid.UUID{first, second}).Return(nil).Once()
+	webhooks.OnDelivered(SSEEventsWebhook, MarkSSEEventsSent(mockDb))
+
+	SendEventPayloadToWebhook(mockDb, "chat-1", server.URL, 0)
+	assert.Eventually(t, func() bool { return len(received()) == 2 }, 2*time.Second, 5*time.Millisecond)
+	stop()
+
+	assert.NotEqual(t, uuid.Nil, deliveryID)
+	assert.Equal(t, []string{deliveryID.String(), deliveryID.String()}, received(), "the retry keeps the delivery ID of the batch")
+}
+
+func TestResendStaleSSEEvents(t *testing.T) {
+	server, received := newTestSSEWebhookServer()
+	defer server.Close()
+
+	mockDb := dbMocks.NewDatabase(t)
+	stop := startTestDispatcher(t, mockDb)
+
+	firstBatch, secondBatch := uuid.New(), uuid.New()
+	mockDb.On("GetStaleSendingSSEMessageLogs", mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= staleSSEDeliveryAfter
+	})).Return([]db.SSEMessageLog{
+		{ID: uuid.New(), ChatID: "chat-1", To: server.URL, Status: db.SSEStatusSending, DeliveryID: &firstBatch},
+		{ID: uuid.New(), ChatID: "chat-2", To: server.URL, Status: db.SSEStatusSending, DeliveryID: &secondBatch},
+		{ID: uuid.New(), ChatID: "chat-1", To: server.URL, Status: db.SSEStatusSending, DeliveryID: &firstBatch},
+	}, nil).Once()
+
+	assert.NoError(t, ResendStaleSSEEvents(mockDb))
+	stop()
+
+	assert.ElementsMatch(t, []string{firstBatch.String(), secondBatch.String()}, received(), "each batch is resent once with its delivery ID")
+}
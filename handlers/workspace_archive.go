@@ -0,0 +1,730 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+)
+
+const (
+	maxWorkspaceArchiveSize = 100 << 20
+
+	// limits on the uncompressed contents of an archive, a small upload
+	// could otherwise expand to more than the server can hold
+	maxWorkspaceArchiveEntrySize   = 20 << 20
+	maxWorkspaceArchiveContentSize = 200 << 20
+
+	workspaceArchiveFilesDir = "files/"
+
+	workspaceFileTimeout = 30 * time.Second
+)
+
+var errWorkspaceFileAddress = errors.New("file is not stored on a public address")
+
+// workspaceFilesClient downloads the files of an export. Assets only point
+// at the meme server, the client still refuses to connect to addresses of
+// this network whatever a name resolves to.
+var workspaceFilesClient = &http.Client{
+	Timeout: workspaceFileTimeout,
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			Control: func(network, address string, c syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+					return errWorkspaceFileAddress
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("too many redirects")
+		}
+		return checkWorkspaceFileURL(req.URL)
+	},
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// checkWorkspaceFileURL only lets through the public files of the meme
+// server, the only place uploads and imports store files
+func checkWorkspaceFileURL(u *url.URL) error {
+	meme, err := url.Parse(config.MemeUrl)
+	if err != nil {
+		return fmt.Errorf("invalid meme server url: %w", err)
+	}
+	if u.Scheme != meme.Scheme || u.Host != meme.Host || !strings.HasPrefix(u.Path, "/public/") {
+		return fmt.Errorf("file is not stored on the meme server: %s", u.Redacted())
+	}
+	return nil
+}
+
+type workspaceArchiveEntry struct {
+	name  string
+	value interface{}
+}
+
+func workspaceArchiveEntries(archive *db.WorkspaceArchive) []workspaceArchiveEntry {
+	return []workspaceArchiveEntry{
+		{"manifest.json", &archive.Manifest},
+		{"workspace.json", &archive.Workspace},
+		{"repositories.json", &archive.Repositories},
+		{"code_graphs.json", &archive.CodeGraphs},
+		{"features.json", &archive.Features},
+		{"phases.json", &archive.Phases},
+		{"stories.json", &archive.Stories},
+		{"tickets.json", &archive.Tickets},
+		{"ticket_plans.json", &archive.TicketPlans},
+		{"snippets.json", &archive.Snippets},
+		{"activities.json", &archive.Activities},
+		{"chats.json", &archive.Chats},
+		{"chat_messages.json", &archive.ChatMessages},
+		{"artifacts.json", &archive.Artifacts},
+		{"file_assets.json", &archive.FileAssets},
+	}
+}
+
+func encodeWorkspaceArchive(w io.Writer, archive db.WorkspaceArchive) error {
+	zw := zip.NewWriter(w)
+
+	for _, entry := range workspaceArchiveEntries(&archive) {
+		f, err := zw.Create(entry.name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entry.value); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", entry.name, err)
+		}
+	}
+
+	written := map[string]bool{}
+	for _, asset := range archive.FileAssets {
+		if written[asset.FileHash] {
+			continue
+		}
+		data, ok := archive.Files[asset.FileHash]
+		if !ok {
+			return fmt.Errorf("missing the contents of file %s", asset.FileHash)
+		}
+		f, err := zw.Create(workspaceArchiveFilesDir + asset.FileHash)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+		written[asset.FileHash] = true
+	}
+
+	return zw.Close()
+}
+
+func decodeWorkspaceArchive(data []byte) (db.WorkspaceArchive, error) {
+	archive := db.WorkspaceArchive{}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return archive, fmt.Errorf("invalid archive: %w", err)
+	}
+
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	remaining := int64(maxWorkspaceArchiveContentSize)
+	readEntry := func(f *zip.File) ([]byte, error) {
+		limit := int64(maxWorkspaceArchiveEntrySize)
+		if remaining < limit {
+			limit = remaining
+		}
+		if f.UncompressedSize64 > uint64(limit) {
+			return nil, fmt.Errorf("%s exceeds the archive size limit", f.Name)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		// the header sizes are not trusted, the read itself is capped
+		data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) > limit {
+			return nil, fmt.Errorf("%s exceeds the archive size limit", f.Name)
+		}
+		remaining -= int64(len(data))
+		return data, nil
+	}
+
+	for _, entry := range workspaceArchiveEntries(&archive) {
+		f, ok := files[entry.name]
+		if !ok {
+			if entry.name == "manifest.json" || entry.name == "workspace.json" {
+				return archive, fmt.Errorf("archive is missing %s", entry.name)
+			}
+			continue
+		}
+
+		data, err := readEntry(f)
+		if err != nil {
+			return archive, err
+		}
+		if err := json.Unmarshal(data, entry.value); err != nil {
+			return archive, fmt.Errorf("failed to decode %s: %w", entry.name, err)
+		}
+	}
+
+	archive.Files = map[string][]byte{}
+	for _, asset := range archive.FileAssets {
+		if _, ok := archive.Files[asset.FileHash]; ok {
+			continue
+		}
+		name := workspaceArchiveFilesDir + asset.FileHash
+		f, ok := files[name]
+		if !ok {
+			return archive, fmt.Errorf("archive is missing %s", name)
+		}
+		data, err := readEntry(f)
+		if err != nil {
+			return archive, err
+		}
+		// file assets are deduplicated by hash, a file must match the hash
+		// it is stored under
+		if hash := sha256.Sum256(data); hex.EncodeToString(hash[:]) != asset.FileHash {
+			return archive, fmt.Errorf("%s does not match its hash", name)
+		}
+		archive.Files[asset.FileHash] = data
+	}
+
+	if archive.Manifest.Version < 1 || archive.Manifest.Version > db.WorkspaceArchiveVersion {
+		return archive, fmt.Errorf("unsupported archive version %d", archive.Manifest.Version)
+	}
+
+	return archive, nil
+}
+
+// remapWorkspaceArchive gives every record a fresh identifier while keeping the
+// references between records intact, and maps owner pubkeys for the target instance
+func remapWorkspaceArchive(archive db.WorkspaceArchive, ownerPubkey string, pubkeyMap map[string]string) db.WorkspaceArchive {
+	ids := map[string]string{}
+	id := func(old string) string {
+		if old == "" {
+			return ""
+		}
+		if mapped, ok := ids[old]; ok {
+			return mapped
+		}
+		mapped := uuid.New().String()
+		ids[old] = mapped
+		return mapped
+	}
+	uid := func(old uuid.UUID) uuid.UUID {
+		if old == uuid.Nil {
+			return old
+		}
+		return uuid.MustParse(id(old.String()))
+	}
+
+	oldOwner := archive.Workspace.OwnerPubKey
+	pk := func(old string) string {
+		if mapped, ok := pubkeyMap[old]; ok {
+			return mapped
+		}
+		if old == oldOwner {
+			return ownerPubkey
+		}
+		return old
+	}
+
+	ws := &archive.Workspace
+	ws.ID = 0
+	ws.Uuid = id(ws.Uuid)
+	ws.OwnerPubKey = pk(ws.OwnerPubKey)
+	// the Lightning Address stays with the workspace it was exported from
+	ws.LightningAddress = nil
+
+	for i := range archive.Repositories {
+		r := &archive.Repositories[i]
+		r.ID = 0
+		r.Uuid = id(r.Uuid)
+		r.WorkspaceUuid = ws.Uuid
+		r.CreatedBy, r.UpdatedBy = pk(r.CreatedBy), pk(r.UpdatedBy)
+	}
+
+	for i := range archive.CodeGraphs {
+		c := &archive.CodeGraphs[i]
+		c.ID = 0
+		c.Uuid = id(c.Uuid)
+		c.WorkspaceUuid = ws.Uuid
+		c.CreatedBy, c.UpdatedBy = pk(c.CreatedBy), pk(c.UpdatedBy)
+	}
+
+	for i := range archive.Features {
+		f := &archive.Features[i]
+		f.ID = 0
+		f.Uuid = id(f.Uuid)
+		f.WorkspaceUuid = ws.Uuid
+		f.CreatedBy, f.UpdatedBy = pk(f.CreatedBy), pk(f.UpdatedBy)
+	}
+
+	for i := range archive.Phases {
+		p := &archive.Phases[i]
+		p.Uuid = id(p.Uuid)
+		p.FeatureUuid = id(p.FeatureUuid)
+		p.CreatedBy, p.UpdatedBy = pk(p.CreatedBy), pk(p.UpdatedBy)
+	}
+
+	for i := range archive.Stories {
+		s := &archive.Stories[i]
+		s.ID = 0
+		s.Uuid = id(s.Uuid)
+		s.FeatureUuid = id(s.FeatureUuid)
+		s.CreatedBy, s.UpdatedBy = pk(s.CreatedBy), pk(s.UpdatedBy)
+	}
+
+	for i := range archive.Tickets {
+		t := &archive.Tickets[i]
+		t.UUID = uid(t.UUID)
+		if t.TicketGroup != nil {
+			group := uid(*t.TicketGroup)
+			t.TicketGroup = &group
+		}
+		t.WorkspaceUuid = ws.Uuid
+		t.FeatureUUID = id(t.FeatureUUID)
+		t.PhaseUUID = id(t.PhaseUUID)
+		if t.AuthorID != nil && t.Author != nil && *t.Author == db.HumanAuthor {
+			author := pk(*t.AuthorID)
+			t.AuthorID = &author
+		}
+	}
+
+	for i := range archive.TicketPlans {
+		p := &archive.TicketPlans[i]
+		p.UUID = uid(p.UUID)
+		p.WorkspaceUuid = ws.Uuid
+		p.FeatureUUID = id(p.FeatureUUID)
+		p.PhaseUUID = id(p.PhaseUUID)
+		for j := range p.TicketGroups {
+			p.TicketGroups[j] = id(p.TicketGroups[j])
+		}
+		p.CreatedBy, p.UpdatedBy = pk(p.CreatedBy), pk(p.UpdatedBy)
+	}
+
+	for i := range archive.Snippets {
+		s := &archive.Snippets[i]
+		s.ID = 0
+		s.WorkspaceUUID = ws.Uuid
+	}
+
+	for i := range archive.Activities {
+		a := &archive.Activities[i]
+		a.ID = uid(a.ID)
+		a.ThreadID = uid(a.ThreadID)
+		a.Workspace = ws.Uuid
+		a.FeatureUUID = id(a.FeatureUUID)
+		a.PhaseUUID = id(a.PhaseUUID)
+		if a.Author == db.HumansAuthor {
+			a.AuthorRef = pk(a.AuthorRef)
+		}
+	}
+
+	for i := range archive.Chats {
+		c := &archive.Chats[i]
+		c.ID = id(c.ID)
+		c.WorkspaceID = ws.Uuid
+		c.ActiveMessageID = id(c.ActiveMessageID)
+	}
+
+	for i := range archive.ChatMessages {
+		m := &archive.ChatMessages[i]
+		m.ID = id(m.ID)
+		m.ChatID = id(m.ChatID)
+		m.ParentID = id(m.ParentID)
+		for j := range m.ContextTags {
+			m.ContextTags[j].ID = id(m.ContextTags[j].ID)
+		}
+	}
+
+	for i := range archive.Artifacts {
+		a := &archive.Artifacts[i]
+		a.ID = uid(a.ID)
+		a.MessageID = id(a.MessageID)
+	}
+
+	for i := range archive.FileAssets {
+		f := &archive.FileAssets[i]
+		f.ID = 0
+		f.UploadFilename = uuid.New().String() + filepath.Ext(f.UploadFilename)
+		f.WorkspaceID = ws.Uuid
+		f.UploadedBy = pk(f.UploadedBy)
+	}
+
+	archive.Manifest.WorkspaceUuid = ws.Uuid
+	archive.Manifest.Counts = archive.Counts()
+
+	return archive
+}
+
+// findWorkspaceImportConflicts checks a remapped archive against this instance,
+// assets of files already stored here are linked to the stored copy instead of
+// uploading the file again
+func (oh *workspaceHandler) findWorkspaceImportConflicts(archive *db.WorkspaceArchive) []db.WorkspaceImportConflict {
+	conflicts := []db.WorkspaceImportConflict{}
+
+	if err := validateWorkspaceName(archive.Workspace.Name); err != nil {
+		conflicts = append(conflicts, db.WorkspaceImportConflict{
+			Type:     "invalid_workspace_name",
+			Value:    archive.Workspace.Name,
+			Message:  err.Error() + ", provide a new name",
+			Blocking: true,
+		})
+	} else if existing := oh.db.GetWorkspaceByName(archive.Workspace.Name); existing.Uuid != "" {
+		conflicts = append(conflicts, db.WorkspaceImportConflict{
+			Type:     "workspace_name",
+			Value:    archive.Workspace.Name,
+			Message:  "a workspace with this name already exists, provide a new name",
+			Blocking: true,
+		})
+	}
+
+	pubkeys := map[string]bool{}
+	for _, f := range archive.Features {
+		pubkeys[f.CreatedBy] = true
+	}
+	for _, p := range archive.TicketPlans {
+		pubkeys[p.CreatedBy] = true
+	}
+	for _, a := range archive.Activities {
+		if a.Author == db.HumansAuthor {
+			pubkeys[a.AuthorRef] = true
+		}
+	}
+	delete(pubkeys, "")
+	delete(pubkeys, archive.Workspace.OwnerPubKey)
+
+	for pubkey := range pubkeys {
+		if person := oh.db.GetPersonByPubkey(pubkey); person.OwnerPubKey == "" {
+			conflicts = append(conflicts, db.WorkspaceImportConflict{
+				Type:    "unknown_pubkey",
+				Value:   pubkey,
+				Message: "pubkey has no account on this instance, map it with pubkey_map",
+			})
+		}
+	}
+
+	stored := map[string]*db.FileAsset{}
+	for i := range archive.FileAssets {
+		asset := &archive.FileAssets[i]
+		existing, ok := stored[asset.FileHash]
+		if !ok {
+			existing, _ = oh.db.GetFileAssetByHash(asset.FileHash)
+			stored[asset.FileHash] = existing
+			if existing != nil {
+				conflicts = append(conflicts, db.WorkspaceImportConflict{
+					Type:    "duplicate_file",
+					Value:   asset.FileHash,
+					Message: "file already exists on this instance, the imported asset links to it",
+				})
+			}
+		}
+		if existing != nil {
+			asset.StoragePath = existing.StoragePath
+			delete(archive.Files, asset.FileHash)
+		}
+	}
+
+	return conflicts
+}
+
+// fetchWorkspaceFiles downloads the contents of the file assets into the
+// archive. Assets are keyed by the hash of what was downloaded, so the hash
+// an import checks always matches the contents.
+func fetchWorkspaceFiles(ctx context.Context, archive *db.WorkspaceArchive) error {
+	archive.Files = map[string][]byte{}
+	downloaded := map[string][]byte{}
+	var total int64
+
+	for i := range archive.FileAssets {
+		asset := &archive.FileAssets[i]
+
+		data, ok := downloaded[asset.StoragePath]
+		if !ok {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, asset.StoragePath, nil)
+			if err != nil {
+				return fmt.Errorf("invalid location for file %s: %w", asset.UploadFilename, err)
+			}
+			if err := checkWorkspaceFileURL(req.URL); err != nil {
+				return fmt.Errorf("invalid location for file %s: %w", asset.UploadFilename, err)
+			}
+			res, err := workspaceFilesClient.Do(req)
+			if err != nil {
+				return fmt.Errorf("could not download file %s: %w", asset.UploadFilename, err)
+			}
+			data, err = io.ReadAll(io.LimitReader(res.Body, maxWorkspaceArchiveEntrySize+1))
+			res.Body.Close()
+			if err != nil {
+				return fmt.Errorf("could not download file %s: %w", asset.UploadFilename, err)
+			}
+			if res.StatusCode != http.StatusOK {
+				return fmt.Errorf("could not download file %s: %s", asset.UploadFilename, res.Status)
+			}
+			if len(data) > maxWorkspaceArchiveEntrySize {
+				return fmt.Errorf("file %s is larger than an archive entry can be", asset.UploadFilename)
+			}
+
+			total += int64(len(data))
+			if total > maxWorkspaceArchiveContentSize {
+				return errors.New("workspace files are larger than an archive can be")
+			}
+			downloaded[asset.StoragePath] = data
+		}
+
+		hash := sha256.Sum256(data)
+		asset.FileHash = hex.EncodeToString(hash[:])
+		asset.FileSize = int64(len(data))
+		archive.Files[asset.FileHash] = data
+	}
+
+	return nil
+}
+
+// storeWorkspaceFiles uploads the contents of imported file assets to the
+// meme server, the assets then point at their new copies
+func storeWorkspaceFiles(ctx context.Context, archive *db.WorkspaceArchive) error {
+	if len(archive.Files) == 0 {
+		return nil
+	}
+
+	challenge := GetMemeChallenge(ctx)
+	signer := SignChallenge(ctx, challenge.Challenge)
+	mErr, mToken := GetMemeToken(ctx, challenge.Id, signer.Response.Sig)
+	if mErr != "" {
+		return fmt.Errorf("could not get meme token: %s", mErr)
+	}
+
+	for i := range archive.FileAssets {
+		asset := &archive.FileAssets[i]
+		data, ok := archive.Files[asset.FileHash]
+		if !ok {
+			// linked to a copy already stored on this instance
+			continue
+		}
+
+		storagePath, err := UploadMemeFile(ctx, mToken.Token, asset.UploadFilename, bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("could not upload file %s: %w", asset.UploadFilename, err)
+		}
+		asset.StoragePath = storagePath
+		asset.FileSize = int64(len(data))
+	}
+
+	return nil
+}
+
+// ExportWorkspace godoc
+//
+//	@Summary		Export Workspace
+//	@Description	Export a workspace and its data as a versioned zip archive
+//	@Tags			Workspaces
+//	@Produce		application/zip
+//	@Param			workspace_uuid	path	string	true	"Workspace UUID"
+//	@Security		PubKeyContextAuth
+//	@Success		200	{file}	file
+//	@Router			/workspaces/{workspace_uuid}/export [get]
+func (oh *workspaceHandler) ExportWorkspace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
+	workspaceUuid := chi.URLParam(r, "workspace_uuid")
+
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if !oh.userHasAccess(pubKeyFromAuth, workspaceUuid, db.EditOrg) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Don't have access to export this workspace")
+		return
+	}
+
+	archive, err := oh.db.GetWorkspaceArchive(workspaceUuid)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("[workspaces] export of %s failed: %v", workspaceUuid, err)
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+	archive.Manifest.SourceHost = config.Host
+
+	if err := oh.fetchWorkspaceFiles(ctx, &archive); err != nil {
+		logger.FromContext(r.Context()).Error("[workspaces] export of %s failed: %v", workspaceUuid, err)
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode("Error downloading workspace files")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := encodeWorkspaceArchive(&buf, archive); err != nil {
+		logger.FromContext(r.Context()).Error("[workspaces] export of %s failed: %v", workspaceUuid, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Error creating workspace archive")
+		return
+	}
+
+	filename := fmt.Sprintf("workspace-%s-%s.zip", workspaceUuid, time.Now().Format("20060102150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// ImportWorkspace godoc
+//
+//	@Summary		Import Workspace
+//	@Description	Import a workspace archive, use dry_run to only report conflicts
+//	@Tags			Workspaces
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			file		formData	file	true	"Workspace archive"
+//	@Param			name		formData	string	false	"New workspace name"
+//	@Param			pubkey_map	formData	string	false	"JSON object mapping source pubkeys to target pubkeys"
+//	@Param			dry_run		query		bool	false	"Only report conflicts"
+//	@Security		PubKeyContextAuth
+//	@Success		200	{object}	db.WorkspaceImportReport
+//	@Success		201	{object}	db.WorkspaceImportReport
+//	@Failure		409	{object}	db.WorkspaceImportReport
+//	@Router			/workspaces/import [post]
+func (oh *workspaceHandler) ImportWorkspace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
+
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxWorkspaceArchiveSize)
+	if err := r.ParseMultipartForm(maxWorkspaceArchiveSize); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Invalid archive upload")
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Archive file is required")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Could not read archive")
+		return
+	}
+
+	pubkeyMap := map[string]string{}
+	if raw := r.FormValue("pubkey_map"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &pubkeyMap); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode("pubkey_map must be a JSON object")
+			return
+		}
+	}
+
+	archive, err := decodeWorkspaceArchive(data)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+
+	archive = remapWorkspaceArchive(archive, pubKeyFromAuth, pubkeyMap)
+	if name := strings.TrimSpace(r.FormValue("name")); name != "" {
+		archive.Workspace.Name = name
+	}
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	report := db.WorkspaceImportReport{
+		DryRun:        dryRun,
+		WorkspaceUuid: archive.Workspace.Uuid,
+		WorkspaceName: archive.Workspace.Name,
+		Conflicts:     oh.findWorkspaceImportConflicts(&archive),
+	}
+	report.Counts = archive.Counts()
+
+	if dryRun {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+
+	for _, conflict := range report.Conflicts {
+		if conflict.Blocking {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(report)
+			return
+		}
+	}
+
+	if err := oh.storeWorkspaceFiles(ctx, &archive); err != nil {
+		logger.FromContext(r.Context()).Error("[workspaces] import failed: %v", err)
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode("Error uploading workspace files")
+		return
+	}
+
+	if err := oh.db.ImportWorkspaceArchive(archive); err != nil {
+		logger.FromContext(r.Context()).Error("[workspaces] import failed: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Error importing workspace")
+		return
+	}
+
+	report.Imported = true
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
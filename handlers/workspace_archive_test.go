@@ -0,0 +1,425 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/db"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	testArchiveFile     = []byte("file contents")
+	testArchiveFileHash = fmt.Sprintf("%x", sha256.Sum256(testArchiveFile))
+)
+
+func testWorkspaceArchive() db.WorkspaceArchive {
+	ticketUuid := uuid.New()
+	human := db.HumanAuthor
+	author := "source-member"
+
+	return db.WorkspaceArchive{
+		Manifest:  db.WorkspaceArchiveManifest{Version: db.WorkspaceArchiveVersion},
+		Workspace: db.Workspace{ID: 7, Uuid: "ws-uuid", Name: "source", OwnerPubKey: "source-owner"},
+		Features: []db.WorkspaceFeatures{
+			{ID: 3, Uuid: "feature-uuid", WorkspaceUuid: "ws-uuid", CreatedBy: "source-owner"},
+		},
+		Phases: []db.FeaturePhase{
+			{Uuid: "phase-uuid", FeatureUuid: "feature-uuid"},
+		},
+		Tickets: []db.Tickets{
+			{UUID: ticketUuid, TicketGroup: &ticketUuid, WorkspaceUuid: "ws-uuid", FeatureUUID: "feature-uuid", PhaseUUID: "phase-uuid", Author: &human, AuthorID: &author},
+		},
+		TicketPlans: []db.TicketPlan{
+			{UUID: uuid.New(), WorkspaceUuid: "ws-uuid", FeatureUUID: "feature-uuid", PhaseUUID: "phase-uuid", TicketGroups: pq.StringArray{ticketUuid.String()}},
+		},
+		Chats: []db.Chat{
+			{ID: "chat-id", WorkspaceID: "ws-uuid", ActiveMessageID: "reply-id"},
+		},
+		ChatMessages: []db.ChatMessage{
+			{ID: "message-id", ChatID: "chat-id"},
+			{ID: "reply-id", ChatID: "chat-id", ParentID: "message-id"},
+		},
+		Artifacts: []db.Artifact{
+			{ID: uuid.New(), MessageID: "message-id"},
+		},
+		FileAssets: []db.FileAsset{
+			{ID: 2, FileHash: testArchiveFileHash, UploadFilename: "upload.png", WorkspaceID: "ws-uuid", UploadedBy: "source-owner"},
+		},
+		Files: map[string][]byte{testArchiveFileHash: testArchiveFile},
+	}
+}
+
+func TestWorkspaceArchiveRoundTrip(t *testing.T) {
+	archive := testWorkspaceArchive()
+
+	var buf bytes.Buffer
+	assert.NoError(t, encodeWorkspaceArchive(&buf, archive))
+
+	decoded, err := decodeWorkspaceArchive(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, archive.Workspace.Uuid, decoded.Workspace.Uuid)
+	assert.Equal(t, archive.Counts(), decoded.Counts())
+	assert.Equal(t, archive.Tickets[0].UUID, decoded.Tickets[0].UUID)
+	assert.Equal(t, archive.Files, decoded.Files)
+
+	t.Run("should reject unsupported versions", func(t *testing.T) {
+		future := testWorkspaceArchive()
+		future.Manifest.Version = db.WorkspaceArchiveVersion + 1
+
+		var buf bytes.Buffer
+		assert.NoError(t, encodeWorkspaceArchive(&buf, future))
+
+		_, err := decodeWorkspaceArchive(buf.Bytes())
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject data that is not an archive", func(t *testing.T) {
+		_, err := decodeWorkspaceArchive([]byte("not a zip"))
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject files that do not match their hash", func(t *testing.T) {
+		tampered := testWorkspaceArchive()
+		tampered.Files[testArchiveFileHash] = []byte("other contents")
+
+		var buf bytes.Buffer
+		assert.NoError(t, encodeWorkspaceArchive(&buf, tampered))
+
+		_, err := decodeWorkspaceArchive(buf.Bytes())
+		assert.ErrorContains(t, err, "does not match its hash")
+	})
+
+	t.Run("should reject entries over the size limit", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		f, _ := zw.Create("manifest.json")
+		f.Write(bytes.Repeat([]byte(" "), maxWorkspaceArchiveEntrySize+1))
+		zw.Close()
+
+		assert.Less(t, buf.Len(), 1<<20, "the entry compresses well below its size")
+		_, err := decodeWorkspaceArchive(buf.Bytes())
+		assert.ErrorContains(t, err, "exceeds the archive size limit")
+	})
+}
+
+func TestFetchWorkspaceFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/public/upload" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(testArchiveFile)
+	}))
+	defer server.Close()
+
+	memeUrl, client := config.MemeUrl, workspaceFilesClient
+	config.MemeUrl = server.URL
+	defer func() { config.MemeUrl, workspaceFilesClient = memeUrl, client }()
+
+	t.Run("should refuse to connect to an address of this network", func(t *testing.T) {
+		archive := db.WorkspaceArchive{FileAssets: []db.FileAsset{
+			{UploadFilename: "upload.png", StoragePath: server.URL + "/public/upload"},
+		}}
+
+		assert.ErrorIs(t, fetchWorkspaceFiles(context.Background(), &archive), errWorkspaceFileAddress)
+	})
+
+	t.Run("should refuse a file that is not on the meme server", func(t *testing.T) {
+		archive := db.WorkspaceArchive{FileAssets: []db.FileAsset{
+			{UploadFilename: "secret", StoragePath: "http://169.254.169.254/public/latest"},
+			{UploadFilename: "private", StoragePath: server.URL + "/private/upload"},
+		}}
+
+		for i := range archive.FileAssets {
+			single := db.WorkspaceArchive{FileAssets: archive.FileAssets[i : i+1]}
+			assert.ErrorContains(t, fetchWorkspaceFiles(context.Background(), &single), "not stored on the meme server")
+		}
+	})
+
+	// the test server listens on loopback
+	workspaceFilesClient = server.Client()
+
+	t.Run("should download the files keyed by their hash", func(t *testing.T) {
+		archive := db.WorkspaceArchive{FileAssets: []db.FileAsset{
+			{UploadFilename: "upload.png", FileHash: "stale", StoragePath: server.URL + "/public/upload"},
+		}}
+
+		assert.NoError(t, fetchWorkspaceFiles(context.Background(), &archive))
+		assert.Equal(t, testArchiveFileHash, archive.FileAssets[0].FileHash)
+		assert.Equal(t, int64(len(testArchiveFile)), archive.FileAssets[0].FileSize)
+		assert.Equal(t, map[string][]byte{testArchiveFileHash: testArchiveFile}, archive.Files)
+	})
+
+	t.Run("should fail when a file cannot be downloaded", func(t *testing.T) {
+		archive := db.WorkspaceArchive{FileAssets: []db.FileAsset{
+			{UploadFilename: "gone.png", StoragePath: server.URL + "/public/gone"},
+		}}
+
+		assert.Error(t, fetchWorkspaceFiles(context.Background(), &archive))
+	})
+}
+
+func TestRemapWorkspaceArchive(t *testing.T) {
+	archive := remapWorkspaceArchive(testWorkspaceArchive(), "importer", map[string]string{"source-member": "target-member"})
+
+	ws := archive.Workspace
+	assert.NotEqual(t, "ws-uuid", ws.Uuid)
+	assert.Equal(t, uint(0), ws.ID)
+	assert.Equal(t, "importer", ws.OwnerPubKey)
+	assert.Equal(t, ws.Uuid, archive.Manifest.WorkspaceUuid)
+
+	feature := archive.Features[0]
+	assert.NotEqual(t, "feature-uuid", feature.Uuid)
+	assert.Equal(t, uint(0), feature.ID)
+	assert.Equal(t, ws.Uuid, feature.WorkspaceUuid)
+	assert.Equal(t, "importer", feature.CreatedBy)
+	assert.Equal(t, feature.Uuid, archive.Phases[0].FeatureUuid)
+
+	ticket := archive.Tickets[0]
+	assert.Equal(t, ticket.UUID, *ticket.TicketGroup)
+	assert.Equal(t, feature.Uuid, ticket.FeatureUUID)
+	assert.Equal(t, archive.Phases[0].Uuid, ticket.PhaseUUID)
+	assert.Equal(t, "target-member", *ticket.AuthorID)
+	assert.Equal(t, ticket.UUID.String(), archive.TicketPlans[0].TicketGroups[0])
+
+	assert.NotEqual(t, "chat-id", archive.Chats[0].ID)
+	assert.Equal(t, archive.Chats[0].ID, archive.ChatMessages[0].ChatID)
+	assert.Equal(t, archive.ChatMessages[0].ID, archive.Artifacts[0].MessageID)
+	assert.Equal(t, "", archive.ChatMessages[0].ParentID)
+	assert.Equal(t, archive.ChatMessages[0].ID, archive.ChatMessages[1].ParentID)
+	assert.Equal(t, archive.ChatMessages[1].ID, archive.Chats[0].ActiveMessageID)
+
+	assert.Equal(t, uint(0), archive.FileAssets[0].ID)
+	assert.NotEqual(t, "upload.png", archive.FileAssets[0].UploadFilename)
+	assert.Equal(t, ".png", filepath.Ext(archive.FileAssets[0].UploadFilename))
+	assert.Equal(t, ws.Uuid, archive.FileAssets[0].WorkspaceID)
+	assert.Equal(t, "importer", archive.FileAssets[0].UploadedBy)
+}
+
+func newWorkspaceImportRequest(t *testing.T, archive db.WorkspaceArchive, fields map[string]string, query string) *http.Request {
+	var archiveBuf bytes.Buffer
+	assert.NoError(t, encodeWorkspaceArchive(&archiveBuf, archive))
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "workspace.zip")
+	assert.NoError(t, err)
+	part.Write(archiveBuf.Bytes())
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+	writer.Close()
+
+	ctx := context.WithValue(context.Background(), auth.ContextKey, "importer")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/import"+query, body)
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestImportWorkspace(t *testing.T) {
+	t.Run("should return 401 without a pubkey", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		oHandler := NewWorkspaceHandler(mockDb)
+
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/import", nil)
+		http.HandlerFunc(oHandler.ImportWorkspace).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should report conflicts on a dry run without importing", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		oHandler := NewWorkspaceHandler(mockDb)
+
+		mockDb.On("GetWorkspaceByName", "source").Return(db.Workspace{Uuid: "existing"})
+		mockDb.On("GetFileAssetByHash", testArchiveFileHash).Return(&db.FileAsset{FileHash: testArchiveFileHash}, nil)
+
+		rr := httptest.NewRecorder()
+		req := newWorkspaceImportRequest(t, testWorkspaceArchive(), nil, "?dry_run=true")
+		http.HandlerFunc(oHandler.ImportWorkspace).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var report db.WorkspaceImportReport
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.True(t, report.DryRun)
+		assert.False(t, report.Imported)
+		assert.Len(t, report.Conflicts, 2)
+		assert.True(t, report.Conflicts[0].Blocking)
+		assert.Equal(t, 1, report.Counts["file_assets"], "the duplicate file is linked, not dropped")
+		mockDb.AssertNotCalled(t, "ImportWorkspaceArchive", mock.Anything)
+	})
+
+	t.Run("should refuse a name that would not pass workspace validation", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		oHandler := NewWorkspaceHandler(mockDb)
+
+		mockDb.On("GetFileAssetByHash", testArchiveFileHash).Return(nil, assert.AnError)
+
+		for _, name := range []string{"a name that is far too long", "tab\tname"} {
+			rr := httptest.NewRecorder()
+			req := newWorkspaceImportRequest(t, testWorkspaceArchive(), map[string]string{"name": name}, "")
+			http.HandlerFunc(oHandler.ImportWorkspace).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusConflict, rr.Code, name)
+			var report db.WorkspaceImportReport
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+			assert.Equal(t, "invalid_workspace_name", report.Conflicts[0].Type)
+		}
+		mockDb.AssertNotCalled(t, "GetWorkspaceByName", mock.Anything)
+		mockDb.AssertNotCalled(t, "ImportWorkspaceArchive", mock.Anything)
+	})
+
+	t.Run("should link assets of files already stored to the stored copy", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		oHandler := NewWorkspaceHandler(mockDb)
+
+		mockDb.On("GetWorkspaceByName", "source").Return(db.Workspace{})
+		mockDb.On("GetFileAssetByHash", testArchiveFileHash).Return(&db.FileAsset{FileHash: testArchiveFileHash, StoragePath: "https://meme/public/stored"}, nil).Once()
+		mockDb.On("ImportWorkspaceArchive", mock.MatchedBy(func(archive db.WorkspaceArchive) bool {
+			return len(archive.FileAssets) == 1 && archive.FileAssets[0].StoragePath == "https://meme/public/stored" &&
+				archive.FileAssets[0].WorkspaceID == archive.Workspace.Uuid
+		})).Return(nil)
+
+		rr := httptest.NewRecorder()
+		req := newWorkspaceImportRequest(t, testWorkspaceArchive(), nil, "")
+		http.HandlerFunc(oHandler.ImportWorkspace).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	})
+
+	t.Run("should refuse to import when the name is taken", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		oHandler := NewWorkspaceHandler(mockDb)
+
+		mockDb.On("GetWorkspaceByName", "source").Return(db.Workspace{Uuid: "existing"})
+		mockDb.On("GetFileAssetByHash", testArchiveFileHash).Return(nil, assert.AnError)
+
+		rr := httptest.NewRecorder()
+		req := newWorkspaceImportRequest(t, testWorkspaceArchive(), nil, "")
+		http.HandlerFunc(oHandler.ImportWorkspace).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		mockDb.AssertNotCalled(t, "ImportWorkspaceArchive", mock.Anything)
+	})
+
+	t.Run("should import under a new name and owner", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		oHandler := NewWorkspaceHandler(mockDb)
+		oHandler.storeWorkspaceFiles = func(ctx context.Context, archive *db.WorkspaceArchive) error {
+			assert.Equal(t, testArchiveFile, archive.Files[archive.FileAssets[0].FileHash])
+			archive.FileAssets[0].StoragePath = "https://meme/public/copy"
+			return nil
+		}
+
+		mockDb.On("GetWorkspaceByName", "renamed").Return(db.Workspace{})
+		mockDb.On("GetFileAssetByHash", testArchiveFileHash).Return(nil, assert.AnError)
+		mockDb.On("ImportWorkspaceArchive", mock.MatchedBy(func(archive db.WorkspaceArchive) bool {
+			return archive.Workspace.Name == "renamed" && archive.Workspace.OwnerPubKey == "importer" && archive.Workspace.Uuid != "ws-uuid" &&
+				archive.FileAssets[0].StoragePath == "https://meme/public/copy"
+		})).Return(nil)
+
+		rr := httptest.NewRecorder()
+		req := newWorkspaceImportRequest(t, testWorkspaceArchive(), map[string]string{"name": "renamed"}, "")
+		http.HandlerFunc(oHandler.ImportWorkspace).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		var report db.WorkspaceImportReport
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.True(t, report.Imported)
+		assert.Equal(t, "renamed", report.WorkspaceName)
+	})
+}
+
+func TestExportWorkspace(t *testing.T) {
+	t.Run("should return 401 when the user has no access", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		oHandler := NewWorkspaceHandler(mockDb)
+		oHandler.userHasAccess = func(pubKeyFromAuth string, uuid string, role string) bool {
+			return false
+		}
+
+		rr := httptest.NewRecorder()
+		req := newWorkspaceExportRequest(t, "ws-uuid")
+		http.HandlerFunc(oHandler.ExportWorkspace).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should return a zip archive of the workspace", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		oHandler := NewWorkspaceHandler(mockDb)
+		oHandler.userHasAccess = func(pubKeyFromAuth string, uuid string, role string) bool {
+			return true
+		}
+
+		oHandler.fetchWorkspaceFiles = func(ctx context.Context, archive *db.WorkspaceArchive) error {
+			archive.Files = map[string][]byte{testArchiveFileHash: testArchiveFile}
+			return nil
+		}
+
+		source := testWorkspaceArchive()
+		source.Files = nil
+		mockDb.On("GetWorkspaceArchive", "ws-uuid").Return(source, nil)
+
+		rr := httptest.NewRecorder()
+		req := newWorkspaceExportRequest(t, "ws-uuid")
+		http.HandlerFunc(oHandler.ExportWorkspace).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+
+		archive, err := decodeWorkspaceArchive(rr.Body.Bytes())
+		assert.NoError(t, err)
+		assert.Equal(t, "ws-uuid", archive.Workspace.Uuid)
+		assert.Equal(t, testArchiveFile, archive.Files[testArchiveFileHash])
+	})
+
+	t.Run("should fail when the files cannot be downloaded", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		oHandler := NewWorkspaceHandler(mockDb)
+		oHandler.userHasAccess = func(pubKeyFromAuth string, uuid string, role string) bool {
+			return true
+		}
+		oHandler.fetchWorkspaceFiles = func(ctx context.Context, archive *db.WorkspaceArchive) error {
+			return assert.AnError
+		}
+
+		mockDb.On("GetWorkspaceArchive", "ws-uuid").Return(testWorkspaceArchive(), nil)
+
+		rr := httptest.NewRecorder()
+		req := newWorkspaceExportRequest(t, "ws-uuid")
+		http.HandlerFunc(oHandler.ExportWorkspace).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadGateway, rr.Code)
+	})
+}
+
+func newWorkspaceExportRequest(t *testing.T, workspaceUuid string) *http.Request {
+	ctx := context.WithValue(context.Background(), auth.ContextKey, "owner")
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("workspace_uuid", workspaceUuid)
+	req, err := http.NewRequestWithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx), http.MethodGet, "/"+workspaceUuid+"/export", nil)
+	assert.NoError(t, err)
+	return req
+}
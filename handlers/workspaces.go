@@ -1,19 +1,22 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"regexp"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/go-chi/chi"
 	"github.com/rs/xid"
 	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/config"
 	"github.com/stakwork/sphinx-tribes/db"
 	"github.com/stakwork/sphinx-tribes/logger"
 	"github.com/stakwork/sphinx-tribes/utils"
@@ -23,12 +26,15 @@ import (
 type workspaceHandler struct {
 	db                             db.Database
 	generateBountyHandler          func(bounties []db.NewBounty) []db.BountyResponse
-	getLightningInvoice            func(payment_request string) (db.InvoiceResult, db.InvoiceError)
+	getLightningInvoice            func(ctx context.Context, payment_request string) (db.InvoiceResult, db.InvoiceError)
 	userHasAccess                  func(pubKeyFromAuth string, uuid string, role string) bool
 	configUserHasAccess            func(pubKeyFromAuth string, uuid string, role string) bool
 	configUserHasManageBountyRoles func(pubKeyFromAuth string, uuid string) bool
 	userHasManageBountyRoles       func(pubKeyFromAuth string, uuid string) bool
 	getAllUserWorkspaces           func(pubKeyFromAuth string) []db.Workspace
+	fetchWorkspaceFiles            func(ctx context.Context, archive *db.WorkspaceArchive) error
+	storeWorkspaceFiles            func(ctx context.Context, archive *db.WorkspaceArchive) error
+	settleInvoice                  func(ctx context.Context, paymentRequest string) bool
 }
 
 func NewWorkspaceHandler(database db.Database) *workspaceHandler {
@@ -44,9 +50,26 @@ func NewWorkspaceHandler(database db.Database) *workspaceHandler {
 		configUserHasManageBountyRoles: configHandler.UserHasManageBountyRoles,
 		userHasManageBountyRoles:       dbConf.UserHasManageBountyRoles,
 		getAllUserWorkspaces:           GetAllUserWorkspaces,
+		fetchWorkspaceFiles:            fetchWorkspaceFiles,
+		storeWorkspaceFiles:            storeWorkspaceFiles,
+		settleInvoice:                  bHandler.settleInvoice,
 	}
 }
 
+// validateWorkspaceName checks a trimmed workspace name, names are shown
+// and searched for as they are so they may only hold printable characters
+func validateWorkspaceName(name string) error {
+	if len(name) == 0 || len(name) > 20 {
+		return errors.New("workspace name must be present and should not exceed 20 character")
+	}
+	for _, c := range name {
+		if !unicode.IsPrint(c) {
+			return errors.New("workspace name may only contain printable characters")
+		}
+	}
+	return nil
+}
+
 // CreateOrEditWorkspace godoc
 //
 //	@Summary		Create or Edit Workspace
@@ -62,7 +85,7 @@ func (oh *workspaceHandler) CreateOrEditWorkspace(w http.ResponseWriter, r *http
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -74,22 +97,22 @@ func (oh *workspaceHandler) CreateOrEditWorkspace(w http.ResponseWriter, r *http
 	err := json.Unmarshal(body, &workspace)
 
 	if err != nil {
-		logger.Log.Error("[workspaces] %v", err)
+		logger.FromContext(r.Context()).Error("[workspaces] %v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
 
 	workspace.Name = strings.TrimSpace(workspace.Name)
 
-	if len(workspace.Name) == 0 || len(workspace.Name) > 20 {
-		logger.Log.Info("[workspaces] invalid workspace name %s", workspace.Name)
+	if err := validateWorkspaceName(workspace.Name); err != nil {
+		logger.FromContext(r.Context()).Info("[workspaces] invalid workspace name %q", workspace.Name)
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode("Error: workspace name must be present and should not exceed 20 character")
+		json.NewEncoder(w).Encode("Error: " + err.Error())
 		return
 	}
 
 	if len(workspace.Description) > 120 {
-		logger.Log.Info("[workspaces] invalid workspace name %s", workspace.Description)
+		logger.FromContext(r.Context()).Info("[workspaces] invalid workspace name %s", workspace.Description)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode("Error: workspace description should not exceed 120 character")
 		return
@@ -98,9 +121,9 @@ func (oh *workspaceHandler) CreateOrEditWorkspace(w http.ResponseWriter, r *http
 	if pubKeyFromAuth != workspace.OwnerPubKey {
 		hasRole := db.UserHasAccess(pubKeyFromAuth, workspace.Uuid, db.EditOrg)
 		if !hasRole {
-			logger.Log.Info("[workspaces] mismatched pubkey")
-			logger.Log.Info("[workspaces] Auth pubkey: %s", pubKeyFromAuth)
-			logger.Log.Info("[workspaces] OwnerPubKey: %s", workspace.OwnerPubKey)
+			logger.FromContext(r.Context()).Info("[workspaces] mismatched pubkey")
+			logger.FromContext(r.Context()).Info("[workspaces] Auth pubkey: %s", pubKeyFromAuth)
+			logger.FromContext(r.Context()).Info("[workspaces] OwnerPubKey: %s", workspace.OwnerPubKey)
 			w.WriteHeader(http.StatusUnauthorized)
 			json.NewEncoder(w).Encode("Don't have access to Edit workspace")
 			return
@@ -126,7 +149,7 @@ func (oh *workspaceHandler) CreateOrEditWorkspace(w http.ResponseWriter, r *http
 	existing := oh.db.GetWorkspaceByUuid(workspace.Uuid)
 	if existing.ID == 0 { // new!
 		if workspace.ID != 0 { // can't try to "edit" if it does not exist already
-			logger.Log.Info("[workspaces] cant edit non existing")
+			logger.FromContext(r.Context()).Info("[workspaces] cant edit non existing")
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
@@ -157,6 +180,16 @@ func (oh *workspaceHandler) CreateOrEditWorkspace(w http.ResponseWriter, r *http
 		return
 	}
 
+	if existing.ID == 0 {
+		// a name another workspace has as its address is left for the admin
+		// to choose later
+		if updated, err := oh.db.SetWorkspaceLightningAddress(p.Uuid, LightningAddressName(p.Name)); err != nil {
+			logger.FromContext(r.Context()).Info("[workspaces] no lightning address for %s: %v", p.Uuid, err)
+		} else {
+			p.LightningAddress = updated.LightningAddress
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(p)
 }
@@ -232,7 +265,7 @@ func (oh *workspaceHandler) CreateWorkspaceUser(w http.ResponseWriter, r *http.R
 	r.Body.Close()
 
 	if err != nil {
-		logger.Log.Error("[body] %v", err)
+		logger.FromContext(r.Context()).Error("[body] %v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -247,13 +280,13 @@ func (oh *workspaceHandler) CreateWorkspaceUser(w http.ResponseWriter, r *http.R
 	workspace := oh.db.GetWorkspaceByUuid(workspaceUser.WorkspaceUuid)
 
 	if err != nil {
-		logger.Log.Error("[workspaces] %v", err)
+		logger.FromContext(r.Context()).Error("[workspaces] %v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -340,7 +373,7 @@ func GetWorkspaceUser(w http.ResponseWriter, r *http.Request) {
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -391,7 +424,7 @@ func DeleteWorkspaceUser(w http.ResponseWriter, r *http.Request) {
 	r.Body.Close()
 
 	if err != nil {
-		logger.Log.Error("[body] %v", err)
+		logger.FromContext(r.Context()).Error("[body] %v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -403,13 +436,13 @@ func DeleteWorkspaceUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		logger.Log.Error("[workspaces] %v", err)
+		logger.FromContext(r.Context()).Error("[workspaces] %v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -483,7 +516,7 @@ func (oh *workspaceHandler) AddUserRoles(w http.ResponseWriter, r *http.Request)
 	r.Body.Close()
 
 	if err != nil {
-		logger.Log.Error("[body] %v", err)
+		logger.FromContext(r.Context()).Error("[body] %v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -491,7 +524,7 @@ func (oh *workspaceHandler) AddUserRoles(w http.ResponseWriter, r *http.Request)
 	err = json.Unmarshal(body, &roles)
 
 	if err != nil {
-		logger.Log.Error("[workspaces]: %v", err)
+		logger.FromContext(r.Context()).Error("[workspaces]: %v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -608,7 +641,7 @@ func GetUserWorkspaces(w http.ResponseWriter, r *http.Request) {
 	userId, _ := utils.ConvertStringToUint(userIdParam)
 
 	if userId == 0 {
-		logger.Log.Info("[workspaces] provide user id")
+		logger.FromContext(r.Context()).Info("[workspaces] provide user id")
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -636,7 +669,7 @@ func (oh *workspaceHandler) GetUserDropdownWorkspaces(w http.ResponseWriter, r *
 	userId, _ := utils.ConvertStringToUint(userIdParam)
 
 	if userId == 0 {
-		logger.Log.Info("[workspaces] provide user id")
+		logger.FromContext(r.Context()).Info("[workspaces] provide user id")
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -784,7 +817,7 @@ func (oh *workspaceHandler) GetWorkspaceBudget(w http.ResponseWriter, r *http.Re
 	uuid := chi.URLParam(r, "uuid")
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -852,7 +885,7 @@ func GetPaymentHistory(w http.ResponseWriter, r *http.Request) {
 	uuid := chi.URLParam(r, "uuid")
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -903,7 +936,7 @@ func UpdateWorkspacePendingPayments(w http.ResponseWriter, r *http.Request) {
 	workspace_uuid := chi.URLParam(r, "workspace_uuid")
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("no pubkey from auth")
+		logger.FromContext(r.Context()).Info("no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -912,7 +945,7 @@ func UpdateWorkspacePendingPayments(w http.ResponseWriter, r *http.Request) {
 
 	for _, payment := range paymentsHistory {
 		tag := payment.Tag
-		tagResult := GetInvoiceStatusByTag(tag)
+		tagResult := GetInvoiceStatusByTag(ctx, tag)
 
 		if tagResult.Status == db.PaymentComplete {
 			db.DB.SetPaymentAsComplete(tag)
@@ -968,7 +1001,7 @@ func (oh *workspaceHandler) PollBudgetInvoices(w http.ResponseWriter, r *http.Re
 	uuid := chi.URLParam(r, "uuid")
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -976,7 +1009,7 @@ func (oh *workspaceHandler) PollBudgetInvoices(w http.ResponseWriter, r *http.Re
 	workInvoices := oh.db.GetWorkspaceInvoices(uuid)
 
 	for _, inv := range workInvoices {
-		invoiceRes, invoiceErr := oh.getLightningInvoice(inv.PaymentRequest)
+		invoiceRes, invoiceErr := oh.getLightningInvoice(ctx, inv.PaymentRequest)
 
 		if invoiceErr.Error != "" {
 			w.WriteHeader(http.StatusForbidden)
@@ -986,7 +1019,7 @@ func (oh *workspaceHandler) PollBudgetInvoices(w http.ResponseWriter, r *http.Re
 
 		if invoiceRes.Response.Settled {
 			if !inv.Status && inv.Type == "BUDGET" {
-				oh.db.ProcessUpdateBudget(inv)
+				oh.settleInvoice(r.Context(), inv.PaymentRequest)
 			}
 		} else {
 			// Cheeck if time has expired
@@ -1017,7 +1050,7 @@ func (oh *workspaceHandler) PollUserWorkspacesBudget(w http.ResponseWriter, r *h
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1031,7 +1064,7 @@ func (oh *workspaceHandler) PollUserWorkspacesBudget(w http.ResponseWriter, r *h
 		workInvoices := oh.db.GetWorkspaceInvoices(space.Uuid)
 
 		for _, inv := range workInvoices {
-			invoiceRes, invoiceErr := oh.getLightningInvoice(inv.PaymentRequest)
+			invoiceRes, invoiceErr := oh.getLightningInvoice(ctx, inv.PaymentRequest)
 
 			if invoiceErr.Error != "" {
 				w.WriteHeader(http.StatusForbidden)
@@ -1041,7 +1074,7 @@ func (oh *workspaceHandler) PollUserWorkspacesBudget(w http.ResponseWriter, r *h
 
 			if invoiceRes.Response.Settled {
 				if !inv.Status && inv.Type == "BUDGET" {
-					oh.db.ProcessUpdateBudget(inv)
+					oh.settleInvoice(r.Context(), inv.PaymentRequest)
 				}
 			} else {
 				// Cheeck if time has expired
@@ -1075,7 +1108,7 @@ func GetInvoicesCount(w http.ResponseWriter, r *http.Request) {
 	uuid := chi.URLParam(r, "uuid")
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1100,7 +1133,7 @@ func GetAllUserInvoicesCount(w http.ResponseWriter, r *http.Request) {
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1132,7 +1165,7 @@ func (oh *workspaceHandler) DeleteWorkspace(w http.ResponseWriter, r *http.Reque
 	uuid := chi.URLParam(r, "uuid")
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1140,25 +1173,162 @@ func (oh *workspaceHandler) DeleteWorkspace(w http.ResponseWriter, r *http.Reque
 	workspace := oh.db.GetWorkspaceByUuid(uuid)
 	if pubKeyFromAuth != workspace.OwnerPubKey {
 		msg := "only workspace admin can delete an workspace"
-		logger.Log.Info("[workspaces] %s", msg)
+		logger.FromContext(r.Context()).Info("[workspaces] %s", msg)
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(msg)
 		return
 	}
 
-	// Soft delete Workspace and delete user data
+	// Soft delete Workspace, it can be restored until the purge job runs
 	if err := oh.db.ProcessDeleteWorkspace(uuid); err != nil {
-		msg := "Error removing users from workspace"
-		logger.Log.Error("%s: %v", msg, err)
+		msg := "Error deleting workspace"
+		logger.FromContext(r.Context()).Error("%s: %v", msg, err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(msg)
 		return
 	}
 
+	workspace = oh.db.GetWorkspaceByUuid(uuid)
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(workspace)
 }
 
+// RestoreWorkspace godoc
+//
+//	@Summary		Restore Workspace
+//	@Description	Restore a deleted workspace while it is still inside the restore window
+//	@Tags			Workspaces
+//	@Accept			json
+//	@Produce		json
+//	@Param			uuid	path	string	true	"Workspace UUID"
+//	@Security		PubKeyContextAuth
+//	@Success		200	{object}	db.Workspace
+//	@Router			/workspaces/restore/{uuid} [post]
+func (oh *workspaceHandler) RestoreWorkspace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
+	uuid := chi.URLParam(r, "uuid")
+
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	workspace := oh.db.GetWorkspaceByUuid(uuid)
+	if workspace.Uuid == "" {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("workspace not found")
+		return
+	}
+
+	if pubKeyFromAuth != workspace.OwnerPubKey {
+		msg := "only workspace admin can restore a workspace"
+		logger.FromContext(r.Context()).Info("[workspaces] %s", msg)
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(msg)
+		return
+	}
+
+	if !workspace.Deleted {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("workspace is not deleted")
+		return
+	}
+
+	if workspace.PurgedAt != nil || workspaceRestoreExpired(workspace, time.Now()) {
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode("workspace restore window has expired")
+		return
+	}
+
+	restored, err := oh.db.RestoreWorkspace(uuid)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("[workspaces] error restoring workspace %s: %v", uuid, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Error restoring workspace")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(restored)
+}
+
+// GetDeletedWorkspaces godoc
+//
+//	@Summary		Get Deleted Workspaces
+//	@Description	Get the deleted workspaces of the user that can still be restored
+//	@Tags			Workspaces
+//	@Accept			json
+//	@Produce		json
+//	@Security		PubKeyContextAuth
+//	@Success		200	{array}	db.Workspace
+//	@Router			/workspaces/deleted [get]
+func (oh *workspaceHandler) GetDeletedWorkspaces(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
+
+	if pubKeyFromAuth == "" {
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// only owners can restore a workspace, so only their own are listed
+	now := time.Now()
+	workspaces := []db.Workspace{}
+	for _, workspace := range oh.db.GetUserDeletedWorkspaces(pubKeyFromAuth) {
+		if !workspaceRestoreExpired(workspace, now) {
+			workspaces = append(workspaces, workspace)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(workspaces)
+}
+
+func workspaceRestoreExpired(workspace db.Workspace, now time.Time) bool {
+	if workspace.DeletedAt == nil {
+		return false
+	}
+	window := time.Duration(config.WorkspaceRestoreDays) * 24 * time.Hour
+	return now.After(workspace.DeletedAt.Add(window))
+}
+
+// PurgeDeletedWorkspaces permanently removes the data of workspaces whose
+// restore window has expired. Workspaces that still hold budget or have
+// pending payments are flagged instead so the owner can withdraw first.
+func (oh *workspaceHandler) PurgeDeletedWorkspaces() error {
+	var errs []error
+	cronLog := logger.Log.With("cron", "purge_workspaces")
+	window := time.Duration(config.WorkspaceRestoreDays) * 24 * time.Hour
+	workspaces := oh.db.GetWorkspacesPendingPurge(time.Now().Add(-window))
+
+	for _, workspace := range workspaces {
+		budget := oh.db.GetWorkspaceBudget(workspace.Uuid)
+		pendingPayments := oh.db.GetWorkspacePendingPayments(workspace.Uuid)
+
+		if budget.TotalBudget > 0 || len(pendingPayments) > 0 {
+			cronLog.Warning("[workspaces] purge of %s blocked, budget: %d, pending payments: %d", workspace.Uuid, budget.TotalBudget, len(pendingPayments))
+			if !workspace.PurgeFlagged {
+				if err := oh.db.FlagWorkspacePurge(workspace.Uuid); err != nil {
+					errs = append(errs, fmt.Errorf("could not flag workspace %s: %w", workspace.Uuid, err))
+				}
+			}
+			continue
+		}
+
+		if err := oh.db.PurgeWorkspace(workspace.Uuid); err != nil {
+			errs = append(errs, fmt.Errorf("could not purge workspace %s: %w", workspace.Uuid, err))
+			continue
+		}
+
+		cronLog.Info("[workspaces] purged workspace %s", workspace.Uuid)
+	}
+	return errors.Join(errs...)
+}
+
 // UpdateWorkspace godoc
 //
 //	@Summary		Update Workspace
@@ -1176,7 +1346,7 @@ func (oh *workspaceHandler) UpdateWorkspace(w http.ResponseWriter, r *http.Reque
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1187,7 +1357,7 @@ func (oh *workspaceHandler) UpdateWorkspace(w http.ResponseWriter, r *http.Reque
 	err := json.Unmarshal(body, &workspace)
 
 	if err != nil {
-		logger.Log.Error("[workspaces] %v", err)
+		logger.FromContext(r.Context()).Error("[workspaces] %v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -1195,9 +1365,9 @@ func (oh *workspaceHandler) UpdateWorkspace(w http.ResponseWriter, r *http.Reque
 	if pubKeyFromAuth != workspace.OwnerPubKey {
 		hasRole := db.UserHasAccess(pubKeyFromAuth, workspace.Uuid, db.EditOrg)
 		if !hasRole {
-			logger.Log.Info("[workspaces] mismatched pubkey")
-			logger.Log.Info("Auth Pubkey: %s", pubKeyFromAuth)
-			logger.Log.Info("OwnerPubKey: %s", workspace.OwnerPubKey)
+			logger.FromContext(r.Context()).Info("[workspaces] mismatched pubkey")
+			logger.FromContext(r.Context()).Info("Auth Pubkey: %s", pubKeyFromAuth)
+			logger.FromContext(r.Context()).Info("OwnerPubKey: %s", workspace.OwnerPubKey)
 			w.WriteHeader(http.StatusUnauthorized)
 			json.NewEncoder(w).Encode("Don't have access to Edit workspace")
 			return
@@ -1238,7 +1408,7 @@ func (oh *workspaceHandler) CreateOrEditWorkspaceRepository(w http.ResponseWrite
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1249,7 +1419,7 @@ func (oh *workspaceHandler) CreateOrEditWorkspaceRepository(w http.ResponseWrite
 	err := json.Unmarshal(body, &workspaceRepo)
 
 	if err != nil {
-		logger.Log.Error("[workspaces] %v", err)
+		logger.FromContext(r.Context()).Error("[workspaces] %v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -1308,7 +1478,7 @@ func (oh *workspaceHandler) GetWorkspaceRepositorByWorkspaceUuid(w http.Response
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1336,7 +1506,7 @@ func (oh *workspaceHandler) GetWorkspaceRepoByWorkspaceUuidAndRepoUuid(w http.Re
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1345,7 +1515,7 @@ func (oh *workspaceHandler) GetWorkspaceRepoByWorkspaceUuidAndRepoUuid(w http.Re
 	uuid := chi.URLParam(r, "uuid")
 	WorkspaceRepository, err := oh.db.GetWorkspaceRepoByWorkspaceUuidAndRepoUuid(workspace_uuid, uuid)
 	if err != nil {
-		logger.Log.Error("[workspaces] workspace repository not found: %v", err)
+		logger.FromContext(r.Context()).Error("[workspaces] workspace repository not found: %v", err)
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Repository not found"})
 		return
@@ -1373,7 +1543,7 @@ func (oh *workspaceHandler) DeleteWorkspaceRepository(w http.ResponseWriter, r *
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1408,7 +1578,7 @@ func (oh *workspaceHandler) GetFeaturesByWorkspaceUuid(w http.ResponseWriter, r
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1416,13 +1586,13 @@ func (oh *workspaceHandler) GetFeaturesByWorkspaceUuid(w http.ResponseWriter, r
 	uuid := chi.URLParam(r, "workspace_uuid")
 
 	if uuid == "" {
-		logger.Log.Info("workspace_uuid parameter is missing")
+		logger.FromContext(r.Context()).Info("workspace_uuid parameter is missing")
 		http.Error(w, "Missing workspace_uuid parameter", http.StatusBadRequest)
 		return
 	}
 
 	if !isValidUUID(uuid) {
-		logger.Log.Info("invalid UUID format or contains special characters")
+		logger.FromContext(r.Context()).Info("invalid UUID format or contains special characters")
 		http.Error(w, "Invalid UUID format or contains special characters", http.StatusBadRequest)
 		return
 	}
@@ -1467,7 +1637,7 @@ func (oh *workspaceHandler) GetLastWithdrawal(w http.ResponseWriter, r *http.Req
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1475,7 +1645,7 @@ func (oh *workspaceHandler) GetLastWithdrawal(w http.ResponseWriter, r *http.Req
 	workspace_uuid := chi.URLParam(r, "workspace_uuid")
 	lastWithdrawal := oh.db.GetLastWithdrawal(workspace_uuid)
 
-	log.Println("This workspaces last withdrawal is", workspace_uuid, lastWithdrawal)
+	logger.FromContext(r.Context()).Info("This workspaces last withdrawal is %v %v", workspace_uuid, lastWithdrawal)
 
 	hoursDiff := int64(1)
 
@@ -1485,7 +1655,7 @@ func (oh *workspaceHandler) GetLastWithdrawal(w http.ResponseWriter, r *http.Req
 		withdrawTime := utils.ConvertTimeToTimestamp(withdrawCreated.String())
 
 		hoursDiff = utils.GetHoursDifference(int64(withdrawTime), &now)
-		log.Println("This workspaces last withdrawal hours difference is", hoursDiff)
+		logger.FromContext(r.Context()).Info("This workspaces last withdrawal hours difference is %v", hoursDiff)
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -1550,7 +1720,7 @@ func (oh *workspaceHandler) CreateOrEditWorkspaceCodeGraph(w http.ResponseWriter
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1561,7 +1731,7 @@ func (oh *workspaceHandler) CreateOrEditWorkspaceCodeGraph(w http.ResponseWriter
 	err := json.Unmarshal(body, &codeGraph)
 
 	if err != nil {
-		logger.Log.Error("[workspaces] %v", err)
+		logger.FromContext(r.Context()).Error("[workspaces] %v", err)
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
@@ -1612,7 +1782,7 @@ func (oh *workspaceHandler) GetWorkspaceCodeGraphByUUID(w http.ResponseWriter, r
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1620,7 +1790,7 @@ func (oh *workspaceHandler) GetWorkspaceCodeGraphByUUID(w http.ResponseWriter, r
 	uuid := chi.URLParam(r, "uuid")
 	codeGraph, err := oh.db.GetCodeGraphByUUID(uuid)
 	if err != nil {
-		logger.Log.Error("[workspaces] code graph not found: %v", err)
+		logger.FromContext(r.Context()).Error("[workspaces] code graph not found: %v", err)
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Code graph not found"})
 		return
@@ -1646,7 +1816,7 @@ func (oh *workspaceHandler) GetCodeGraphByWorkspaceUuid(w http.ResponseWriter, r
 	ctx := r.Context()
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1680,7 +1850,7 @@ func (oh *workspaceHandler) DeleteWorkspaceCodeGraph(w http.ResponseWriter, r *h
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1709,7 +1879,7 @@ func (oh *workspaceHandler) RefreshCodeGraph(w http.ResponseWriter, r *http.Requ
 	pubKeyFromAuth, _ := ctx.Value(auth.ContextKey).(string)
 
 	if pubKeyFromAuth == "" {
-		logger.Log.Info("[workspaces] no pubkey from auth")
+		logger.FromContext(r.Context()).Info("[workspaces] no pubkey from auth")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -1733,7 +1903,14 @@ func (oh *workspaceHandler) RefreshCodeGraph(w http.ResponseWriter, r *http.Requ
 
 	requestURL := fmt.Sprintf("%s/git/sync?source_link=%s", codeGraph.Url, reposStr)
 
-	response, err := http.Get(requestURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to sync repositories"})
+		return
+	}
+
+	response, err := http.DefaultClient.Do(req)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to sync repositories"})
@@ -18,7 +18,9 @@ import (
 	"github.com/stakwork/sphinx-tribes/auth"
 	"github.com/stakwork/sphinx-tribes/config"
 	"github.com/stakwork/sphinx-tribes/db"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"gorm.io/gorm"
 )
 
@@ -243,7 +245,7 @@ func TestDeleteWorkspace(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, rr.Code)
 	})
 
-	t.Run("should set workspace fields to null and delete users on successful delete", func(t *testing.T) {
+	t.Run("should soft delete the workspace and keep its details on successful delete", func(t *testing.T) {
 		workspaceUUID := workspace.Uuid
 
 		rr := httptest.NewRecorder()
@@ -262,9 +264,10 @@ func TestDeleteWorkspace(t *testing.T) {
 
 		updatedOrg := db.TestDB.GetWorkspaceByUuid(workspaceUUID)
 		assert.Equal(t, true, updatedOrg.Deleted)
-		assert.Equal(t, "", updatedOrg.Website)
-		assert.Equal(t, "", updatedOrg.Github)
-		assert.Equal(t, "", updatedOrg.Description)
+		assert.NotNil(t, updatedOrg.DeletedAt)
+		assert.Equal(t, workspace.Website, updatedOrg.Website)
+		assert.Equal(t, workspace.Github, updatedOrg.Github)
+		assert.Equal(t, workspace.Description, updatedOrg.Description)
 	})
 
 	t.Run("should handle failures in database updates", func(t *testing.T) {
@@ -310,7 +313,7 @@ func TestDeleteWorkspace(t *testing.T) {
 		assert.Equal(t, true, updatedOrg.Deleted)
 	})
 
-	t.Run("should set Website, Github, and Description to empty strings", func(t *testing.T) {
+	t.Run("should keep Website, Github, and Description for a later restore", func(t *testing.T) {
 		workspaceUUID := workspace.Uuid
 
 		rr := httptest.NewRecorder()
@@ -328,14 +331,19 @@ func TestDeleteWorkspace(t *testing.T) {
 		assert.Equal(t, http.StatusOK, rr.Code)
 
 		updatedOrg := db.TestDB.GetWorkspaceByUuid(workspaceUUID)
-		assert.Equal(t, "", updatedOrg.Website)
-		assert.Equal(t, "", updatedOrg.Github)
-		assert.Equal(t, "", updatedOrg.Description)
+		assert.Equal(t, workspace.Website, updatedOrg.Website)
+		assert.Equal(t, workspace.Github, updatedOrg.Github)
+		assert.Equal(t, workspace.Description, updatedOrg.Description)
 	})
 
-	t.Run("should delete all users from the workspace", func(t *testing.T) {
+	t.Run("should keep the workspace users for a later restore", func(t *testing.T) {
 		workspaceUUID := workspace.Uuid
 
+		db.TestDB.CreateWorkspaceUser(db.WorkspaceUsers{
+			OwnerPubKey:   "workspace-member-key",
+			WorkspaceUuid: workspaceUUID,
+		})
+
 		rr := httptest.NewRecorder()
 		handler := http.HandlerFunc(oHandler.DeleteWorkspace)
 
@@ -352,6 +360,151 @@ func TestDeleteWorkspace(t *testing.T) {
 
 		updatedOrg := db.TestDB.GetWorkspaceByUuid(workspaceUUID)
 		assert.Equal(t, true, updatedOrg.Deleted)
+
+		member := db.TestDB.GetWorkspaceUser("workspace-member-key", workspaceUUID)
+		assert.Equal(t, workspaceUUID, member.WorkspaceUuid)
+	})
+}
+
+func TestRestoreWorkspace(t *testing.T) {
+	teardownSuite := SetupSuite(t)
+	defer teardownSuite(t)
+	oHandler := NewWorkspaceHandler(db.TestDB)
+
+	workspace := db.Workspace{
+		Uuid:        uuid.New().String(),
+		Name:        fmt.Sprintf("Workspace %s", uuid.New().String()),
+		OwnerPubKey: "restore-owner-key",
+		Description: "Workspace Description",
+	}
+	db.TestDB.CreateOrEditWorkspace(workspace)
+	db.TestDB.CreateWorkspaceUser(db.WorkspaceUsers{
+		OwnerPubKey:   "restore-member-key",
+		WorkspaceUuid: workspace.Uuid,
+	})
+
+	ctx := context.WithValue(context.Background(), auth.ContextKey, workspace.OwnerPubKey)
+
+	restoreRequest := func(ctx context.Context, workspaceUUID string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(oHandler.RestoreWorkspace)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("uuid", workspaceUUID)
+		req, err := http.NewRequestWithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx), http.MethodPost, "/restore/"+workspaceUUID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should return 400 if the workspace is not deleted", func(t *testing.T) {
+		rr := restoreRequest(ctx, workspace.Uuid)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("should return 401 if the user is not the workspace owner", func(t *testing.T) {
+		assert.NoError(t, db.TestDB.ProcessDeleteWorkspace(workspace.Uuid))
+
+		otherCtx := context.WithValue(context.Background(), auth.ContextKey, "other-key")
+		rr := restoreRequest(otherCtx, workspace.Uuid)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should restore the workspace with its members inside the restore window", func(t *testing.T) {
+		rr := restoreRequest(ctx, workspace.Uuid)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		restored := db.TestDB.GetWorkspaceByUuid(workspace.Uuid)
+		assert.False(t, restored.Deleted)
+		assert.Nil(t, restored.DeletedAt)
+		assert.Equal(t, workspace.Description, restored.Description)
+
+		member := db.TestDB.GetWorkspaceUser("restore-member-key", workspace.Uuid)
+		assert.Equal(t, workspace.Uuid, member.WorkspaceUuid)
+	})
+
+	t.Run("should return 410 once the workspace has been purged", func(t *testing.T) {
+		assert.NoError(t, db.TestDB.ProcessDeleteWorkspace(workspace.Uuid))
+		assert.NoError(t, db.TestDB.PurgeWorkspace(workspace.Uuid))
+
+		rr := restoreRequest(ctx, workspace.Uuid)
+		assert.Equal(t, http.StatusGone, rr.Code)
+	})
+}
+
+func TestGetDeletedWorkspaces(t *testing.T) {
+	t.Run("should return 401 without a pubkey", func(t *testing.T) {
+		oHandler := NewWorkspaceHandler(dbMocks.NewDatabase(t))
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/deleted", nil)
+		http.HandlerFunc(oHandler.GetDeletedWorkspaces).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should list the workspaces of the owner still inside the restore window", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		oHandler := NewWorkspaceHandler(mockDb)
+
+		restoreDays := config.WorkspaceRestoreDays
+		config.WorkspaceRestoreDays = 30
+		defer func() { config.WorkspaceRestoreDays = restoreDays }()
+
+		recent := time.Now().Add(-time.Hour)
+		expired := time.Now().Add(-31 * 24 * time.Hour)
+		mockDb.On("GetUserDeletedWorkspaces", "owner").Return([]db.Workspace{
+			{Uuid: "restorable", OwnerPubKey: "owner", Deleted: true, DeletedAt: &recent},
+			{Uuid: "expired", OwnerPubKey: "owner", Deleted: true, DeletedAt: &expired},
+		}).Once()
+
+		rr := httptest.NewRecorder()
+		ctx := context.WithValue(context.Background(), auth.ContextKey, "owner")
+		req := httptest.NewRequest(http.MethodGet, "/deleted", nil).WithContext(ctx)
+		http.HandlerFunc(oHandler.GetDeletedWorkspaces).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var workspaces []db.Workspace
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &workspaces))
+		assert.Len(t, workspaces, 1)
+		assert.Equal(t, "restorable", workspaces[0].Uuid)
+	})
+}
+
+func TestPurgeDeletedWorkspaces(t *testing.T) {
+	deletedAt := time.Now().Add(-60 * 24 * time.Hour)
+
+	t.Run("should purge workspaces without remaining budget", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		oHandler := NewWorkspaceHandler(mockDb)
+
+		workspace := db.Workspace{Uuid: "purge-uuid", Deleted: true, DeletedAt: &deletedAt}
+
+		mockDb.On("GetWorkspacesPendingPurge", mock.AnythingOfType("time.Time")).Return([]db.Workspace{workspace})
+		mockDb.On("GetWorkspaceBudget", workspace.Uuid).Return(db.NewBountyBudget{})
+		mockDb.On("GetWorkspacePendingPayments", workspace.Uuid).Return([]db.NewPaymentHistory{})
+		mockDb.On("PurgeWorkspace", workspace.Uuid).Return(nil)
+
+		assert.NoError(t, oHandler.PurgeDeletedWorkspaces())
+	})
+
+	t.Run("should flag workspaces that still hold budget instead of purging", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		oHandler := NewWorkspaceHandler(mockDb)
+
+		workspace := db.Workspace{Uuid: "budget-uuid", Deleted: true, DeletedAt: &deletedAt}
+
+		mockDb.On("GetWorkspacesPendingPurge", mock.AnythingOfType("time.Time")).Return([]db.Workspace{workspace})
+		mockDb.On("GetWorkspaceBudget", workspace.Uuid).Return(db.NewBountyBudget{TotalBudget: 1000})
+		mockDb.On("GetWorkspacePendingPayments", workspace.Uuid).Return([]db.NewPaymentHistory{})
+		mockDb.On("FlagWorkspacePurge", workspace.Uuid).Return(nil)
+
+		assert.NoError(t, oHandler.PurgeDeletedWorkspaces())
+
+		mockDb.AssertNotCalled(t, "PurgeWorkspace", workspace.Uuid)
 	})
 }
 
@@ -2363,7 +2516,7 @@ func TestPollBudgetInvoices(t *testing.T) {
 
 		db.TestDB.AddInvoice(invoice)
 
-		mockGetLightningInvoice := func(payment_request string) (db.InvoiceResult, db.InvoiceError) {
+		mockGetLightningInvoice := func(ctx context.Context, payment_request string) (db.InvoiceResult, db.InvoiceError) {
 			return db.InvoiceResult{
 				Success: true,
 				Response: db.InvoiceCheckResponse{
@@ -2451,7 +2604,7 @@ func TestPollBudgetInvoices(t *testing.T) {
 			db.TestDB.AddPaymentHistory(paymentHistory)
 		}
 
-		mockGetLightningInvoice := func(payment_request string) (db.InvoiceResult, db.InvoiceError) {
+		mockGetLightningInvoice := func(ctx context.Context, payment_request string) (db.InvoiceResult, db.InvoiceError) {
 			return db.InvoiceResult{
 				Success: true,
 				Response: db.InvoiceCheckResponse{
@@ -2564,7 +2717,7 @@ func TestPollUserWorkspacesBudget(t *testing.T) {
 
 		db.TestDB.AddInvoice(invoice)
 
-		mockGetLightningInvoice := func(payment_request string) (db.InvoiceResult, db.InvoiceError) {
+		mockGetLightningInvoice := func(ctx context.Context, payment_request string) (db.InvoiceResult, db.InvoiceError) {
 			return db.InvoiceResult{
 				Success: true,
 				Response: db.InvoiceCheckResponse{
@@ -2664,7 +2817,7 @@ func TestPollUserWorkspacesBudget(t *testing.T) {
 			db.TestDB.AddPaymentHistory(paymentHistory)
 		}
 
-		mockGetLightningInvoice := func(payment_request string) (db.InvoiceResult, db.InvoiceError) {
+		mockGetLightningInvoice := func(ctx context.Context, payment_request string) (db.InvoiceResult, db.InvoiceError) {
 			return db.InvoiceResult{
 				Success: true,
 				Response: db.InvoiceCheckResponse{
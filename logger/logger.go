@@ -1,111 +1,213 @@
 package logger
 
 import (
-	"github.com/google/uuid"
-	"github.com/stakwork/sphinx-tribes/config"
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
-	"strconv"
 	"sync"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/stakwork/sphinx-tribes/config"
 )
 
+// LevelMachine is below debug, it is only logged with LOG_LEVEL=MACHINE
+const LevelMachine = slog.Level(-8)
+
+// levelOff is above every level, used for unknown log levels
+const levelOff = slog.Level(100)
+
+// Logger writes one JSON object per line. Its fields are added to every
+// line, loggers with request fields travel through the request context.
 type Logger struct {
-	infoLogger    *log.Logger
-	warningLogger *log.Logger
-	errorLogger   *log.Logger
-	debugLogger   *log.Logger
-	machineLogger *log.Logger
-	mu            sync.Mutex
-	requestUUID   string
+	fields []interface{}
 }
 
-var Log = Logger{
-	infoLogger:    log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime),
-	warningLogger: log.New(os.Stdout, "WARNING: ", log.Ldate|log.Ltime),
-	errorLogger:   log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime),
-	debugLogger:   log.New(os.Stdout, "DEBUG: ", log.Ldate|log.Ltime),
-	machineLogger: log.New(os.Stdout, "MACHINE: ", log.Ldate|log.Ltime),
+// Log has no fields, it is meant for code that runs outside of a request,
+// cron or SSE client, like startup
+var Log = Logger{}
+
+type contextKey struct{}
+
+var (
+	handlerMu sync.RWMutex
+	handler   = newHandler(os.Stdout)
+)
+
+func newHandler(w io.Writer) slog.Handler {
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{
+		// levels are filtered with config.LogLevel before a record is built
+		Level: LevelMachine,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.LevelKey && len(groups) == 0 {
+				level, _ := a.Value.Any().(slog.Level)
+				a.Value = slog.StringValue(LevelName(level))
+			}
+			return a
+		},
+	})
 }
 
-func (l *Logger) SetRequestUUID(uuidString string) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.requestUUID = uuidString
+// SetOutput changes where every logger writes, it is meant for tests
+func SetOutput(w io.Writer) {
+	handlerMu.Lock()
+	defer handlerMu.Unlock()
+	handler = newHandler(w)
 }
 
-func (l *Logger) ClearRequestUUID() {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.requestUUID = ""
+// LevelName is the name config.LogLevel uses for a level
+func LevelName(level slog.Level) string {
+	switch {
+	case level <= LevelMachine:
+		return "MACHINE"
+	case level <= slog.LevelDebug:
+		return "DEBUG"
+	case level <= slog.LevelInfo:
+		return "INFO"
+	case level <= slog.LevelWarn:
+		return "WARNING"
+	}
+	return "ERROR"
 }
 
-func RouteBasedUUIDMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		uuid := uuid.NewString()
-		Log.SetRequestUUID(uuid)
+func minLevel() slog.Level {
+	switch config.LogLevel {
+	case "MACHINE":
+		return LevelMachine
+	case "DEBUG":
+		return slog.LevelDebug
+	case "INFO":
+		return slog.LevelInfo
+	case "WARNING":
+		return slog.LevelWarn
+	case "ERROR":
+		return slog.LevelError
+	}
+	return levelOff
+}
 
-		defer Log.ClearRequestUUID()
+// Enabled reports whether config.LogLevel lets a level through
+func Enabled(level slog.Level) bool {
+	return level >= minLevel()
+}
 
-		next.ServeHTTP(w, r)
-	})
+// With returns a logger that adds the key value pairs to every line
+func (l *Logger) With(args ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(args))
+	fields = append(fields, l.fields...)
+	fields = append(fields, args...)
+	return &Logger{fields: fields}
 }
 
-func (l *Logger) logWithPrefix(logger *log.Logger, format string, v ...interface{}) {
-	l.mu.Lock()
+// WithFields returns a context whose logger has the key value pairs added
+func WithFields(ctx context.Context, args ...interface{}) context.Context {
+	return context.WithValue(ctx, contextKey{}, FromContext(ctx).With(args...))
+}
 
-	requestUUID := l.requestUUID
-	l.mu.Unlock()
+// FromContext returns the logger of a context, with the route and the
+// workspace of the request once chi has routed it
+func FromContext(ctx context.Context) *Logger {
+	l, ok := ctx.Value(contextKey{}).(*Logger)
+	if !ok {
+		l = &Log
+	}
 
-	var file string
-	var line int
-	var ok bool
+	rctx := chi.RouteContext(ctx)
+	if rctx == nil {
+		return l
+	}
 
-	// Use runtime.Caller with skip 3 to go to the caller of the method that called logWithPrefix
-	_, file, line, ok = runtime.Caller(2)
-	if !ok {
-		file = "???"
-		line = 0
+	var args []interface{}
+	if route := rctx.RoutePattern(); route != "" {
+		args = append(args, "route", route)
+	}
+	if workspace := rctx.URLParam("workspace_uuid"); workspace != "" {
+		args = append(args, "workspace", workspace)
+	}
+	if len(args) == 0 {
+		return l
+	}
+	return l.With(args...)
+}
+
+// RequestLogger adds the request ID set by chi's middleware.RequestID and
+// the request line to the logger of the request context, and logs every
+// request once it is served
+func RequestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		args := []interface{}{
+			"request_id", middleware.GetReqID(r.Context()),
+			"method", r.Method,
+			"path", r.URL.Path,
+		}
+		if workspace := r.URL.Query().Get("workspace_uuid"); workspace != "" {
+			args = append(args, "workspace", workspace)
+		}
+		r = r.WithContext(WithFields(r.Context(), args...))
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+
+		defer func() {
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			FromContext(r.Context()).With(
+				"status", status,
+				"bytes", ww.BytesWritten(),
+				"duration_ms", time.Since(start).Milliseconds(),
+			).Info("request served")
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
+
+func (l *Logger) log(level slog.Level, format string, v ...interface{}) {
+	if !Enabled(level) {
+		return
 	}
 
-	shortFile := filepath.Base(file)
-	line_str := strconv.Itoa(line)
+	// skip runtime.Callers, log and the level method
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
 
-	if requestUUID == "" {
-		logger.Printf("["+shortFile+":"+line_str+"] "+format, v...)
-	} else {
-		logger.Printf("["+shortFile+":"+line_str+"] ["+requestUUID+"] "+format, v...)
+	record := slog.NewRecord(time.Now(), level, fmt.Sprintf(format, v...), pcs[0])
+	if frame, _ := runtime.CallersFrames(pcs[:]).Next(); frame.File != "" {
+		record.AddAttrs(slog.String("caller", fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)))
 	}
+	record.Add(l.fields...)
+
+	handlerMu.RLock()
+	h := handler
+	handlerMu.RUnlock()
+
+	h.Handle(context.Background(), record)
 }
 
 func (l *Logger) Machine(format string, v ...interface{}) {
-	if config.LogLevel == "MACHINE" {
-		l.logWithPrefix(l.machineLogger, format, v...)
-	}
+	l.log(LevelMachine, format, v...)
 }
 
 func (l *Logger) Debug(format string, v ...interface{}) {
-	if config.LogLevel == "MACHINE" || config.LogLevel == "DEBUG" {
-		l.logWithPrefix(l.debugLogger, format, v...)
-	}
+	l.log(slog.LevelDebug, format, v...)
 }
 
 func (l *Logger) Info(format string, v ...interface{}) {
-	if config.LogLevel == "MACHINE" || config.LogLevel == "DEBUG" || config.LogLevel == "INFO" {
-		l.logWithPrefix(l.infoLogger, format, v...)
-	}
+	l.log(slog.LevelInfo, format, v...)
 }
 
 func (l *Logger) Warning(format string, v ...interface{}) {
-	if config.LogLevel == "MACHINE" || config.LogLevel == "DEBUG" || config.LogLevel == "INFO" || config.LogLevel == "WARNING" {
-		l.logWithPrefix(l.warningLogger, format, v...)
-	}
+	l.log(slog.LevelWarn, format, v...)
 }
 
 func (l *Logger) Error(format string, v ...interface{}) {
-	if config.LogLevel == "MACHINE" || config.LogLevel == "DEBUG" || config.LogLevel == "INFO" || config.LogLevel == "WARNING" || config.LogLevel == "ERROR" {
-		l.logWithPrefix(l.errorLogger, format, v...)
-	}
+	l.log(slog.LevelError, format, v...)
 }
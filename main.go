@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -15,7 +16,13 @@ import (
 	"github.com/stakwork/sphinx-tribes/db"
 	_ "github.com/stakwork/sphinx-tribes/docs"
 	"github.com/stakwork/sphinx-tribes/handlers"
+	"github.com/stakwork/sphinx-tribes/logger"
+	"github.com/stakwork/sphinx-tribes/monitoring"
+	"github.com/stakwork/sphinx-tribes/reporting"
 	"github.com/stakwork/sphinx-tribes/routes"
+	"github.com/stakwork/sphinx-tribes/sse"
+	"github.com/stakwork/sphinx-tribes/tracing"
+	"github.com/stakwork/sphinx-tribes/webhooks"
 	"github.com/stakwork/sphinx-tribes/websocket"
 	"gopkg.in/go-playground/validator.v9"
 )
@@ -55,25 +62,95 @@ func main() {
 	config.InitConfig()
 	auth.InitJwt()
 
+	shutdownTracing, err := tracing.Init(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	defer shutdownTracing(context.Background())
+
+	shutdownReporting, err := reporting.Init()
+	if err != nil {
+		panic(err)
+	}
+	defer shutdownReporting(context.Background())
+
+	shutdownWebhooks := webhooks.Init(db.DB)
+	defer shutdownWebhooks(context.Background())
+	webhooks.OnDelivered(handlers.SSEEventsWebhook, handlers.MarkSSEEventsSent(db.DB))
+	if err := handlers.ResendStaleSSEEvents(db.DB); err != nil {
+		logger.Log.Error("[webhooks] could not resend stale SSE events: %v", err)
+	}
+
+	apiKeyHandler := handlers.NewAPIKeyHandler(db.DB)
+	auth.APIKeyResolver = apiKeyHandler.ResolveAPIKey
+	auth.APIKeyWorkspaceResolver = apiKeyHandler.ResolveAPIKeyWorkspace
+	auth.SessionRevoked = db.DB.IsTokenRevoked
+	auth.LinkedPubkeyResolver = handlers.NewIdentityHandler(db.DB).ResolveLinkedPubkey
+
 	// validate
 	db.Validate = validator.New()
-	// Start websocket pool
+	// Start websocket pool, instances share their clients and the events
+	// kept for replay through redis
+	if db.RedisError == nil && db.RedisClient != nil {
+		websocket.WebsocketPool.UseBackplane(websocket.NewRedisBackplane(db.RedisClient))
+		websocket.WebsocketPool.UseEventLog(websocket.NewRedisEventLog(db.RedisClient))
+	} else {
+		websocket.WebsocketPool.UseEventLog(websocket.NewMemoryEventLog())
+	}
+	websocket.WebsocketPool.Authorizer = websocket.NewMembershipAuthorizer(db.DB)
 	go websocket.WebsocketPool.Start()
 
+	// resume the hive chat SSE subscriptions no running instance follows
+	if _, err := sse.ClientRegistry.Sync(db.DB); err != nil {
+		logger.Log.Error("[sse] %v", err)
+	}
+
 	skipLoops := os.Getenv("SKIP_LOOPS")
 	if skipLoops != "true" {
 		go handlers.ProcessTwitterConfirmationsLoop()
 		go handlers.ProcessGithubIssuesLoop()
 	}
 
+	registerOpsMetrics()
 	runCron()
 	run()
 }
 
+func registerOpsMetrics() {
+	if sqlDB, err := db.DB.SqlDB(); err == nil {
+		monitoring.RegisterDBStats(sqlDB)
+	}
+	monitoring.RegisterGaugeFunc("websocket_clients", "Clients connected to the websocket pool.", func() float64 {
+		return float64(websocket.WebsocketPool.Size())
+	})
+	monitoring.RegisterGaugeFunc("sse_clients", "Running hive chat SSE clients.", func() float64 {
+		return float64(sse.ClientRegistry.Count())
+	})
+	monitoring.RegisterGaugeFunc("pending_payments", "Bounty payments still pending.", func() float64 {
+		return float64(db.DB.GetPendingPaymentHistoryCount())
+	})
+	monitoring.RegisterGaugeFunc("notification_queue_depth", "Notifications waiting for a key exchange.", func() float64 {
+		return float64(db.DB.GetNotificationsByStatusCount(string(db.NotificationStatusWaitingKeyExchange)))
+	})
+	monitoring.RegisterCounterFunc("error_reports_dropped_total", "Error reports dropped because the reporter queue was full.", func() float64 {
+		return float64(reporting.Dropped())
+	})
+	monitoring.RegisterGaugeFunc("webhook_deliveries_pending", "Outbound webhook deliveries not yet delivered or dead-lettered.", func() float64 {
+		return float64(webhooks.Pending())
+	})
+}
+
 func runCron() {
 	c := cron.New()
-	c.AddFunc("@every 0h30m0s", handlers.InitV2PaymentsCron)
-	c.AddFunc("@every 0h0m30s", handlers.ProcessWaitingNotifications)
+	c.AddFunc("@every 0h30m0s", monitoring.Cron("v2_payments", handlers.InitV2PaymentsCron))
+	c.AddFunc("@every 0h0m30s", monitoring.Cron("notifications", handlers.ProcessWaitingNotifications))
+	c.AddFunc("@every 1h0m0s", monitoring.Cron("purge_workspaces", handlers.NewWorkspaceHandler(db.DB).PurgeDeletedWorkspaces))
+	c.AddFunc("@every 0h10m0s", monitoring.Cron("bounty_payouts", handlers.NewBountyHandler(http.DefaultClient, db.DB).ExpireBountyPayouts))
+	c.AddFunc("@every 0h5m0s", monitoring.Cron("invoice_settlement", handlers.NewInvoiceSettlementHandler(http.DefaultClient, db.DB).PollPendingInvoices))
+	c.AddFunc("@every 0h1m0s", monitoring.Cron("sse_subscriptions", func() error {
+		_, err := sse.ClientRegistry.Sync(db.DB)
+		return err
+	}))
 	c.Start()
 }
 
@@ -91,4 +168,7 @@ func run() {
 	if err := router.Shutdown(ctx); err != nil {
 		fmt.Printf("error shutting down server: %s", err.Error())
 	}
+
+	// hand the SSE subscriptions over to the next instance
+	sse.ClientRegistry.Release(db.DB)
 }
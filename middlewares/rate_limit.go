@@ -0,0 +1,350 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+)
+
+// RateLimit is a token bucket that holds Requests tokens and refills
+// completely over Window
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// DefaultRateLimits are the limits per route group, each one can be
+// overridden with RATE_LIMIT_<GROUP>, for example RATE_LIMIT_CHAT=60/1m
+var DefaultRateLimits = map[string]RateLimit{
+	"global":   {Requests: 600, Window: time.Minute},
+	"chat":     {Requests: 30, Window: time.Minute},
+	"upload":   {Requests: 10, Window: time.Minute},
+	"invoices": {Requests: 20, Window: time.Minute},
+	"login":    {Requests: 10, Window: time.Minute},
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit, now time.Time) (RateLimitResult, error)
+}
+
+func (l RateLimit) ratePerMs() float64 {
+	return float64(l.Requests) / float64(l.Window.Milliseconds())
+}
+
+// result turns the tokens left in a bucket into the values sent back to clients
+func (l RateLimit) result(allowed bool, tokens float64) RateLimitResult {
+	rate := l.ratePerMs()
+	res := RateLimitResult{
+		Allowed:   allowed,
+		Limit:     l.Requests,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(l.Requests)-tokens)/rate) * time.Millisecond,
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1-tokens)/rate) * time.Millisecond
+	}
+	return res
+}
+
+func ParseRateLimit(spec string) (RateLimit, error) {
+	parts := strings.Split(spec, "/")
+	if len(parts) != 2 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q, expected requests/window", spec)
+	}
+
+	requests, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || requests <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit requests %q", parts[0])
+	}
+
+	window, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil || window <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit window %q", parts[1])
+	}
+
+	return RateLimit{Requests: requests, Window: window}, nil
+}
+
+func rateLimitFor(group string) RateLimit {
+	limit, ok := DefaultRateLimits[group]
+	if !ok {
+		limit = DefaultRateLimits["global"]
+	}
+
+	if spec := os.Getenv("RATE_LIMIT_" + strings.ToUpper(group)); spec != "" {
+		parsed, err := ParseRateLimit(spec)
+		if err != nil {
+			logger.Log.Error("[rate_limit] %v, using the default for %s", err, group)
+			return limit
+		}
+		return parsed
+	}
+
+	return limit
+}
+
+type memoryBucket struct {
+	tokens float64
+	last   time.Time
+	window time.Duration
+}
+
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{buckets: map[string]*memoryBucket{}}
+}
+
+func (s *memoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: float64(limit.Requests), last: now, window: limit.Window}
+		s.buckets[key] = bucket
+	}
+
+	elapsed := now.Sub(bucket.last).Milliseconds()
+	if elapsed > 0 {
+		bucket.tokens = math.Min(float64(limit.Requests), bucket.tokens+float64(elapsed)*limit.ratePerMs())
+		bucket.last = now
+	}
+
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+
+	return limit.result(allowed, bucket.tokens), nil
+}
+
+// sweep drops buckets that have been idle long enough to be full again
+func (s *memoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.last) > bucket.window {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// tokenBucketScript refills and takes from a bucket atomically, tokens are
+// returned as a string because redis truncates lua numbers to integers
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local data = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], ttl)
+return {allowed, tostring(tokens)}
+`)
+
+type redisRateLimitStore struct {
+	client *redis.Client
+}
+
+func NewRedisRateLimitStore(client *redis.Client) *redisRateLimitStore {
+	return &redisRateLimitStore{client: client}
+}
+
+func (s *redisRateLimitStore) Take(ctx context.Context, key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	values, err := tokenBucketScript.Run(ctx, s.client, []string{"ratelimit:" + key},
+		limit.Requests, limit.ratePerMs(), now.UnixMilli(), limit.Window.Milliseconds()).Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	if len(values) != 2 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script result %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	tokensString, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokensString, 64)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	return limit.result(allowed == 1, tokens), nil
+}
+
+// fallbackRateLimitStore uses the shared store and keeps limiting locally
+// when it cannot be reached, instead of failing open or failing requests
+type fallbackRateLimitStore struct {
+	primary  RateLimitStore
+	fallback RateLimitStore
+}
+
+func (s *fallbackRateLimitStore) Take(ctx context.Context, key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	res, err := s.primary.Take(ctx, key, limit, now)
+	if err != nil {
+		logger.Log.Error("[rate_limit] shared store failed, limiting locally: %v", err)
+		return s.fallback.Take(ctx, key, limit, now)
+	}
+	return res, nil
+}
+
+var (
+	defaultStore     RateLimitStore
+	defaultStoreOnce sync.Once
+)
+
+// DefaultRateLimitStore shares counters through redis when it is configured
+// and falls back to memory otherwise
+func DefaultRateLimitStore() RateLimitStore {
+	defaultStoreOnce.Do(func() {
+		memory := NewMemoryRateLimitStore()
+		if db.RedisError == nil && db.RedisClient != nil {
+			defaultStore = &fallbackRateLimitStore{primary: NewRedisRateLimitStore(db.RedisClient), fallback: memory}
+		} else {
+			defaultStore = memory
+		}
+	})
+	return defaultStore
+}
+
+// clientIP is the address of the caller. Behind trusted proxies it walks
+// X-Forwarded-For from the right, past the hops the proxies appended, the
+// entries further left are set by the caller and cannot be trusted.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if config.RateLimitTrustedProxies <= 0 {
+		return host
+	}
+
+	hops := []string{}
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	// the nearest proxy is the remote address itself
+	hops = append(hops, host)
+
+	i := len(hops) - 1 - config.RateLimitTrustedProxies
+	if i < 0 {
+		i = 0
+	}
+	return hops[i]
+}
+
+// rateLimitKey identifies the caller by API key, then pubkey, then IP, so
+// authenticated callers behind a shared IP do not throttle each other. Only
+// keys and pubkeys the auth middleware resolved count, a header that was
+// not checked yet would let a caller pick a new bucket for every request.
+func rateLimitKey(r *http.Request) string {
+	if key := auth.APIKeyFromContext(r.Context()); key != nil {
+		return "key:" + key.ID
+	}
+	if pubkey, _ := r.Context().Value(auth.ContextKey).(string); pubkey != "" {
+		return "pubkey:" + pubkey
+	}
+	return "ip:" + clientIP(r)
+}
+
+type RateLimitResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// RateLimiter limits requests for a route group, it should be used after the
+// auth middleware of the group so callers are keyed by their pubkey
+func RateLimiter(group string) func(http.Handler) http.Handler {
+	return RateLimiterWithStore(group, rateLimitFor(group), nil)
+}
+
+func RateLimiterWithStore(group string, limit RateLimit, store RateLimitStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// preflights carry no credentials and must not use up the budget
+			if !config.RateLimitEnabled || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			s := store
+			if s == nil {
+				s = DefaultRateLimitStore()
+			}
+
+			res, err := s.Take(r.Context(), group+":"+rateLimitKey(r), limit, time.Now())
+			if err != nil {
+				logger.Log.Error("[rate_limit] %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(res.Reset.Seconds()))))
+
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(res.RetryAfter.Seconds())))))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				json.NewEncoder(w).Encode(RateLimitResponse{
+					Success: false,
+					Message: "Too many requests, please try again later.",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
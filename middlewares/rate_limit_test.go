@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/cors"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	limit, err := ParseRateLimit("60/1m")
+	assert.NoError(t, err)
+	assert.Equal(t, RateLimit{Requests: 60, Window: time.Minute}, limit)
+
+	for _, invalid := range []string{"", "60", "0/1m", "x/1m", "60/x", "60/-1s"} {
+		_, err := ParseRateLimit(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	limit := RateLimit{Requests: 2, Window: 2 * time.Second}
+	now := time.Now()
+	ctx := context.Background()
+
+	res, _ := store.Take(ctx, "key", limit, now)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+
+	res, _ = store.Take(ctx, "key", limit, now)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	res, _ = store.Take(ctx, "key", limit, now)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 2*time.Second, res.Reset)
+
+	res, _ = store.Take(ctx, "other", limit, now)
+	assert.True(t, res.Allowed, "buckets are separate per key")
+
+	res, _ = store.Take(ctx, "key", limit, now.Add(time.Second))
+	assert.True(t, res.Allowed, "one token refills after a second")
+
+	res, _ = store.Take(ctx, "key", limit, now.Add(time.Second))
+	assert.False(t, res.Allowed)
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, RateLimit, time.Time) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("redis unavailable")
+}
+
+func TestFallbackRateLimitStore(t *testing.T) {
+	store := &fallbackRateLimitStore{primary: failingRateLimitStore{}, fallback: NewMemoryRateLimitStore()}
+	limit := RateLimit{Requests: 1, Window: time.Minute}
+
+	res, err := store.Take(context.Background(), "key", limit, time.Now())
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	res, err = store.Take(context.Background(), "key", limit, time.Now())
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+}
+
+func TestRateLimiter(t *testing.T) {
+	originalEnabled := config.RateLimitEnabled
+	config.RateLimitEnabled = true
+	defer func() { config.RateLimitEnabled = originalEnabled }()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	newRequest := func(remoteAddr string, pubkey string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/hivechat/send", nil)
+		req.RemoteAddr = remoteAddr
+		if pubkey != "" {
+			req = req.WithContext(context.WithValue(req.Context(), auth.ContextKey, pubkey))
+		}
+		return req
+	}
+
+	t.Run("should throttle with standard headers", func(t *testing.T) {
+		handler := RateLimiterWithStore("test", RateLimit{Requests: 2, Window: time.Minute}, NewMemoryRateLimitStore())(next)
+
+		for i := 0; i < 2; i++ {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, newRequest("10.0.0.1:1234", ""))
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest("10.0.0.1:5678", ""))
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+		assert.NotEmpty(t, rr.Header().Get("RateLimit-Reset"))
+	})
+
+	t.Run("should key authenticated callers by pubkey", func(t *testing.T) {
+		handler := RateLimiterWithStore("test", RateLimit{Requests: 1, Window: time.Minute}, NewMemoryRateLimitStore())(next)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest("10.0.0.1:1234", "alice"))
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest("10.0.0.1:1234", "bob"))
+		assert.Equal(t, http.StatusOK, rr.Code, "a different pubkey on the same IP has its own bucket")
+
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest("10.0.0.2:1234", "alice"))
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code, "the same pubkey on another IP shares its bucket")
+	})
+
+	t.Run("should key API key callers by key", func(t *testing.T) {
+		key := &auth.APIKey{ID: "key-id", Pubkey: "alice"}
+		req := newRequest("10.0.0.1:1234", "alice")
+		req = req.WithContext(context.WithValue(req.Context(), auth.APIKeyContextKey, key))
+		assert.Equal(t, "key:key-id", rateLimitKey(req))
+	})
+
+	t.Run("should key unresolved API tokens by IP", func(t *testing.T) {
+		req := newRequest("10.0.0.1:1234", "")
+		req.Header.Set("x-api-token", "made-up")
+		assert.Equal(t, "ip:10.0.0.1", rateLimitKey(req))
+	})
+
+	t.Run("should not count preflights", func(t *testing.T) {
+		handler := RateLimiterWithStore("test", RateLimit{Requests: 1, Window: time.Minute}, NewMemoryRateLimitStore())(next)
+
+		for i := 0; i < 3; i++ {
+			rr := httptest.NewRecorder()
+			req := newRequest("10.0.0.1:1234", "")
+			req.Method = http.MethodOptions
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest("10.0.0.1:1234", ""))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("should keep the CORS headers on throttled responses", func(t *testing.T) {
+		limiter := RateLimiterWithStore("test", RateLimit{Requests: 1, Window: time.Minute}, NewMemoryRateLimitStore())
+		handler := cors.New(cors.Options{AllowedOrigins: []string{"*"}}).Handler(limiter(next))
+
+		var rr *httptest.ResponseRecorder
+		for i := 0; i < 2; i++ {
+			rr = httptest.NewRecorder()
+			req := newRequest("10.0.0.1:1234", "")
+			req.Header.Set("Origin", "http://example.com")
+			handler.ServeHTTP(rr, req)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("should not limit when disabled", func(t *testing.T) {
+		config.RateLimitEnabled = false
+		defer func() { config.RateLimitEnabled = true }()
+
+		handler := RateLimiterWithStore("test", RateLimit{Requests: 1, Window: time.Minute}, NewMemoryRateLimitStore())(next)
+		for i := 0; i < 3; i++ {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, newRequest("10.0.0.1:1234", ""))
+			assert.Equal(t, http.StatusOK, rr.Code)
+		}
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	trusted := config.RateLimitTrustedProxies
+	defer func() { config.RateLimitTrustedProxies = trusted }()
+
+	tests := []struct {
+		name      string
+		proxies   int
+		forwarded []string
+		expected  string
+	}{
+		{name: "ignores the header without trusted proxies", proxies: 0, forwarded: []string{"1.1.1.1"}, expected: "10.0.0.1"},
+		{name: "takes the hop the proxy appended", proxies: 1, forwarded: []string{"1.1.1.1"}, expected: "1.1.1.1"},
+		{name: "ignores hops the caller prepended", proxies: 1, forwarded: []string{"6.6.6.6, 7.7.7.7, 1.1.1.1"}, expected: "1.1.1.1"},
+		{name: "walks past every trusted proxy", proxies: 2, forwarded: []string{"6.6.6.6, 1.1.1.1", "10.0.0.2"}, expected: "1.1.1.1"},
+		{name: "falls back to the leftmost hop when the chain is short", proxies: 3, forwarded: []string{"1.1.1.1"}, expected: "1.1.1.1"},
+		{name: "uses the remote address without a header", proxies: 1, expected: "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.RateLimitTrustedProxies = tt.proxies
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			for _, header := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", header)
+			}
+			assert.Equal(t, tt.expected, clientIP(req))
+		})
+	}
+}
@@ -287,6 +287,62 @@ func (_c *Database_AddChatMessage_Call) RunAndReturn(run func(*db.ChatMessage) (
 	return _c
 }
 
+// AddChatMessageBranch provides a mock function with given fields: chatMessage
+func (_m *Database) AddChatMessageBranch(chatMessage *db.ChatMessage) (db.ChatMessage, error) {
+	ret := _m.Called(chatMessage)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddChatMessageBranch")
+	}
+
+	var r0 db.ChatMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*db.ChatMessage) (db.ChatMessage, error)); ok {
+		return rf(chatMessage)
+	}
+	if rf, ok := ret.Get(0).(func(*db.ChatMessage) db.ChatMessage); ok {
+		r0 = rf(chatMessage)
+	} else {
+		r0 = ret.Get(0).(db.ChatMessage)
+	}
+
+	if rf, ok := ret.Get(1).(func(*db.ChatMessage) error); ok {
+		r1 = rf(chatMessage)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_AddChatMessageBranch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddChatMessageBranch'
+type Database_AddChatMessageBranch_Call struct {
+	*mock.Call
+}
+
+// AddChatMessageBranch is a helper method to define mock.On call
+//   - chatMessage *db.ChatMessage
+func (_e *Database_Expecter) AddChatMessageBranch(chatMessage interface{}) *Database_AddChatMessageBranch_Call {
+	return &Database_AddChatMessageBranch_Call{Call: _e.mock.On("AddChatMessageBranch", chatMessage)}
+}
+
+func (_c *Database_AddChatMessageBranch_Call) Run(run func(chatMessage *db.ChatMessage)) *Database_AddChatMessageBranch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*db.ChatMessage))
+	})
+	return _c
+}
+
+func (_c *Database_AddChatMessageBranch_Call) Return(_a0 db.ChatMessage, _a1 error) *Database_AddChatMessageBranch_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_AddChatMessageBranch_Call) RunAndReturn(run func(*db.ChatMessage) (db.ChatMessage, error)) *Database_AddChatMessageBranch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // AddEndpoint provides a mock function with given fields: endpoint
 func (_m *Database) AddEndpoint(endpoint *db.Endpoint) (db.Endpoint, error) {
 	ret := _m.Called(endpoint)
@@ -537,6 +593,64 @@ func (_c *Database_AddUserInvoiceData_Call) RunAndReturn(run func(db.UserInvoice
 	return _c
 }
 
+// AppendChatMessageChunk provides a mock function with given fields: id, index, chunk
+func (_m *Database) AppendChatMessageChunk(id string, index int, chunk string) (bool, error) {
+	ret := _m.Called(id, index, chunk)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AppendChatMessageChunk")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int, string) (bool, error)); ok {
+		return rf(id, index, chunk)
+	}
+	if rf, ok := ret.Get(0).(func(string, int, string) bool); ok {
+		r0 = rf(id, index, chunk)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int, string) error); ok {
+		r1 = rf(id, index, chunk)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_AppendChatMessageChunk_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AppendChatMessageChunk'
+type Database_AppendChatMessageChunk_Call struct {
+	*mock.Call
+}
+
+// AppendChatMessageChunk is a helper method to define mock.On call
+//   - id string
+//   - index int
+//   - chunk string
+func (_e *Database_Expecter) AppendChatMessageChunk(id interface{}, index interface{}, chunk interface{}) *Database_AppendChatMessageChunk_Call {
+	return &Database_AppendChatMessageChunk_Call{Call: _e.mock.On("AppendChatMessageChunk", id, index, chunk)}
+}
+
+func (_c *Database_AppendChatMessageChunk_Call) Run(run func(id string, index int, chunk string)) *Database_AppendChatMessageChunk_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Database_AppendChatMessageChunk_Call) Return(_a0 bool, _a1 error) *Database_AppendChatMessageChunk_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_AppendChatMessageChunk_Call) RunAndReturn(run func(string, int, string) (bool, error)) *Database_AppendChatMessageChunk_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // AverageCompletedTime provides a mock function with given fields: r, workspace
 func (_m *Database) AverageCompletedTime(r db.PaymentDateRange, workspace string) uint {
 	ret := _m.Called(r, workspace)
@@ -773,6 +887,173 @@ func (_c *Database_ChangeWorkspaceDeleteStatus_Call) RunAndReturn(run func(strin
 	return _c
 }
 
+// ClaimBountyPayout provides a mock function with given fields: k1, paymentRequest, paymentHash, now
+func (_m *Database) ClaimBountyPayout(k1 string, paymentRequest string, paymentHash string, now time.Time) error {
+	ret := _m.Called(k1, paymentRequest, paymentHash, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimBountyPayout")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, time.Time) error); ok {
+		r0 = rf(k1, paymentRequest, paymentHash, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_ClaimBountyPayout_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimBountyPayout'
+type Database_ClaimBountyPayout_Call struct {
+	*mock.Call
+}
+
+// ClaimBountyPayout is a helper method to define mock.On call
+//   - k1 string
+//   - paymentRequest string
+//   - paymentHash string
+//   - now time.Time
+func (_e *Database_Expecter) ClaimBountyPayout(k1 interface{}, paymentRequest interface{}, paymentHash interface{}, now interface{}) *Database_ClaimBountyPayout_Call {
+	return &Database_ClaimBountyPayout_Call{Call: _e.mock.On("ClaimBountyPayout", k1, paymentRequest, paymentHash, now)}
+}
+
+func (_c *Database_ClaimBountyPayout_Call) Run(run func(k1 string, paymentRequest string, paymentHash string, now time.Time)) *Database_ClaimBountyPayout_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(string), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *Database_ClaimBountyPayout_Call) Return(_a0 error) *Database_ClaimBountyPayout_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_ClaimBountyPayout_Call) RunAndReturn(run func(string, string, string, time.Time) error) *Database_ClaimBountyPayout_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ClaimNewSSEMessageLogs provides a mock function with given fields: chatID, deliveryID
+func (_m *Database) ClaimNewSSEMessageLogs(chatID string, deliveryID uuid.UUID) ([]db.SSEMessageLog, error) {
+	ret := _m.Called(chatID, deliveryID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimNewSSEMessageLogs")
+	}
+
+	var r0 []db.SSEMessageLog
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, uuid.UUID) ([]db.SSEMessageLog, error)); ok {
+		return rf(chatID, deliveryID)
+	}
+	if rf, ok := ret.Get(0).(func(string, uuid.UUID) []db.SSEMessageLog); ok {
+		r0 = rf(chatID, deliveryID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.SSEMessageLog)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, uuid.UUID) error); ok {
+		r1 = rf(chatID, deliveryID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_ClaimNewSSEMessageLogs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimNewSSEMessageLogs'
+type Database_ClaimNewSSEMessageLogs_Call struct {
+	*mock.Call
+}
+
+// ClaimNewSSEMessageLogs is a helper method to define mock.On call
+//   - chatID string
+//   - deliveryID uuid.UUID
+func (_e *Database_Expecter) ClaimNewSSEMessageLogs(chatID interface{}, deliveryID interface{}) *Database_ClaimNewSSEMessageLogs_Call {
+	return &Database_ClaimNewSSEMessageLogs_Call{Call: _e.mock.On("ClaimNewSSEMessageLogs", chatID, deliveryID)}
+}
+
+func (_c *Database_ClaimNewSSEMessageLogs_Call) Run(run func(chatID string, deliveryID uuid.UUID)) *Database_ClaimNewSSEMessageLogs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Database_ClaimNewSSEMessageLogs_Call) Return(_a0 []db.SSEMessageLog, _a1 error) *Database_ClaimNewSSEMessageLogs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_ClaimNewSSEMessageLogs_Call) RunAndReturn(run func(string, uuid.UUID) ([]db.SSEMessageLog, error)) *Database_ClaimNewSSEMessageLogs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ClaimSSESubscriptions provides a mock function with given fields: owner, staleBefore
+func (_m *Database) ClaimSSESubscriptions(owner string, staleBefore time.Time) ([]db.SSESubscription, error) {
+	ret := _m.Called(owner, staleBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimSSESubscriptions")
+	}
+
+	var r0 []db.SSESubscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, time.Time) ([]db.SSESubscription, error)); ok {
+		return rf(owner, staleBefore)
+	}
+	if rf, ok := ret.Get(0).(func(string, time.Time) []db.SSESubscription); ok {
+		r0 = rf(owner, staleBefore)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.SSESubscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, time.Time) error); ok {
+		r1 = rf(owner, staleBefore)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_ClaimSSESubscriptions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimSSESubscriptions'
+type Database_ClaimSSESubscriptions_Call struct {
+	*mock.Call
+}
+
+// ClaimSSESubscriptions is a helper method to define mock.On call
+//   - owner string
+//   - staleBefore time.Time
+func (_e *Database_Expecter) ClaimSSESubscriptions(owner interface{}, staleBefore interface{}) *Database_ClaimSSESubscriptions_Call {
+	return &Database_ClaimSSESubscriptions_Call{Call: _e.mock.On("ClaimSSESubscriptions", owner, staleBefore)}
+}
+
+func (_c *Database_ClaimSSESubscriptions_Call) Run(run func(owner string, staleBefore time.Time)) *Database_ClaimSSESubscriptions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *Database_ClaimSSESubscriptions_Call) Return(_a0 []db.SSESubscription, _a1 error) *Database_ClaimSSESubscriptions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_ClaimSSESubscriptions_Call) RunAndReturn(run func(string, time.Time) ([]db.SSESubscription, error)) *Database_ClaimSSESubscriptions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CloseBountyTiming provides a mock function with given fields: bountyID
 func (_m *Database) CloseBountyTiming(bountyID uint) error {
 	ret := _m.Called(bountyID)
@@ -1143,6 +1424,52 @@ func (_c *Database_CreateBountyFromTicket_Call) RunAndReturn(run func(db.Tickets
 	return _c
 }
 
+// CreateBountyPayout provides a mock function with given fields: payout
+func (_m *Database) CreateBountyPayout(payout *db.BountyPayout) error {
+	ret := _m.Called(payout)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateBountyPayout")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*db.BountyPayout) error); ok {
+		r0 = rf(payout)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_CreateBountyPayout_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateBountyPayout'
+type Database_CreateBountyPayout_Call struct {
+	*mock.Call
+}
+
+// CreateBountyPayout is a helper method to define mock.On call
+//   - payout *db.BountyPayout
+func (_e *Database_Expecter) CreateBountyPayout(payout interface{}) *Database_CreateBountyPayout_Call {
+	return &Database_CreateBountyPayout_Call{Call: _e.mock.On("CreateBountyPayout", payout)}
+}
+
+func (_c *Database_CreateBountyPayout_Call) Run(run func(payout *db.BountyPayout)) *Database_CreateBountyPayout_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*db.BountyPayout))
+	})
+	return _c
+}
+
+func (_c *Database_CreateBountyPayout_Call) Return(_a0 error) *Database_CreateBountyPayout_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_CreateBountyPayout_Call) RunAndReturn(run func(*db.BountyPayout) error) *Database_CreateBountyPayout_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateBountyTiming provides a mock function with given fields: bountyID
 func (_m *Database) CreateBountyTiming(bountyID uint) (*db.BountyTiming, error) {
 	ret := _m.Called(bountyID)
@@ -2313,6 +2640,52 @@ func (_c *Database_CreateOrUpdateFeatureCall_Call) RunAndReturn(run func(string,
 	return _c
 }
 
+// CreatePersonIdentity provides a mock function with given fields: identity
+func (_m *Database) CreatePersonIdentity(identity *db.PersonIdentity) error {
+	ret := _m.Called(identity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePersonIdentity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*db.PersonIdentity) error); ok {
+		r0 = rf(identity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_CreatePersonIdentity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreatePersonIdentity'
+type Database_CreatePersonIdentity_Call struct {
+	*mock.Call
+}
+
+// CreatePersonIdentity is a helper method to define mock.On call
+//   - identity *db.PersonIdentity
+func (_e *Database_Expecter) CreatePersonIdentity(identity interface{}) *Database_CreatePersonIdentity_Call {
+	return &Database_CreatePersonIdentity_Call{Call: _e.mock.On("CreatePersonIdentity", identity)}
+}
+
+func (_c *Database_CreatePersonIdentity_Call) Run(run func(identity *db.PersonIdentity)) *Database_CreatePersonIdentity_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*db.PersonIdentity))
+	})
+	return _c
+}
+
+func (_c *Database_CreatePersonIdentity_Call) Return(_a0 error) *Database_CreatePersonIdentity_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_CreatePersonIdentity_Call) RunAndReturn(run func(*db.PersonIdentity) error) *Database_CreatePersonIdentity_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateProcessingMap provides a mock function with given fields: pm
 func (_m *Database) CreateProcessingMap(pm *db.WfProcessingMap) error {
 	ret := _m.Called(pm)
@@ -2513,17 +2886,17 @@ func (_c *Database_CreateUserRoles_Call) RunAndReturn(run func([]db.WorkspaceUse
 	return _c
 }
 
-// CreateWorkflowRequest provides a mock function with given fields: req
-func (_m *Database) CreateWorkflowRequest(req *db.WfRequest) error {
-	ret := _m.Called(req)
+// CreateUserSession provides a mock function with given fields: session
+func (_m *Database) CreateUserSession(session *db.UserSession) error {
+	ret := _m.Called(session)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateWorkflowRequest")
+		panic("no return value specified for CreateUserSession")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*db.WfRequest) error); ok {
-		r0 = rf(req)
+	if rf, ok := ret.Get(0).(func(*db.UserSession) error); ok {
+		r0 = rf(session)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -2531,20 +2904,66 @@ func (_m *Database) CreateWorkflowRequest(req *db.WfRequest) error {
 	return r0
 }
 
-// Database_CreateWorkflowRequest_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateWorkflowRequest'
-type Database_CreateWorkflowRequest_Call struct {
+// Database_CreateUserSession_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateUserSession'
+type Database_CreateUserSession_Call struct {
 	*mock.Call
 }
 
-// CreateWorkflowRequest is a helper method to define mock.On call
-//   - req *db.WfRequest
-func (_e *Database_Expecter) CreateWorkflowRequest(req interface{}) *Database_CreateWorkflowRequest_Call {
-	return &Database_CreateWorkflowRequest_Call{Call: _e.mock.On("CreateWorkflowRequest", req)}
+// CreateUserSession is a helper method to define mock.On call
+//   - session *db.UserSession
+func (_e *Database_Expecter) CreateUserSession(session interface{}) *Database_CreateUserSession_Call {
+	return &Database_CreateUserSession_Call{Call: _e.mock.On("CreateUserSession", session)}
 }
 
-func (_c *Database_CreateWorkflowRequest_Call) Run(run func(req *db.WfRequest)) *Database_CreateWorkflowRequest_Call {
+func (_c *Database_CreateUserSession_Call) Run(run func(session *db.UserSession)) *Database_CreateUserSession_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*db.WfRequest))
+		run(args[0].(*db.UserSession))
+	})
+	return _c
+}
+
+func (_c *Database_CreateUserSession_Call) Return(_a0 error) *Database_CreateUserSession_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_CreateUserSession_Call) RunAndReturn(run func(*db.UserSession) error) *Database_CreateUserSession_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateWorkflowRequest provides a mock function with given fields: req
+func (_m *Database) CreateWorkflowRequest(req *db.WfRequest) error {
+	ret := _m.Called(req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWorkflowRequest")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*db.WfRequest) error); ok {
+		r0 = rf(req)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_CreateWorkflowRequest_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateWorkflowRequest'
+type Database_CreateWorkflowRequest_Call struct {
+	*mock.Call
+}
+
+// CreateWorkflowRequest is a helper method to define mock.On call
+//   - req *db.WfRequest
+func (_e *Database_Expecter) CreateWorkflowRequest(req interface{}) *Database_CreateWorkflowRequest_Call {
+	return &Database_CreateWorkflowRequest_Call{Call: _e.mock.On("CreateWorkflowRequest", req)}
+}
+
+func (_c *Database_CreateWorkflowRequest_Call) Run(run func(req *db.WfRequest)) *Database_CreateWorkflowRequest_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*db.WfRequest))
 	})
 	return _c
 }
@@ -2559,6 +2978,52 @@ func (_c *Database_CreateWorkflowRequest_Call) RunAndReturn(run func(*db.WfReque
 	return _c
 }
 
+// CreateWorkspaceAPIKey provides a mock function with given fields: key
+func (_m *Database) CreateWorkspaceAPIKey(key *db.WorkspaceAPIKey) error {
+	ret := _m.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWorkspaceAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*db.WorkspaceAPIKey) error); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_CreateWorkspaceAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateWorkspaceAPIKey'
+type Database_CreateWorkspaceAPIKey_Call struct {
+	*mock.Call
+}
+
+// CreateWorkspaceAPIKey is a helper method to define mock.On call
+//   - key *db.WorkspaceAPIKey
+func (_e *Database_Expecter) CreateWorkspaceAPIKey(key interface{}) *Database_CreateWorkspaceAPIKey_Call {
+	return &Database_CreateWorkspaceAPIKey_Call{Call: _e.mock.On("CreateWorkspaceAPIKey", key)}
+}
+
+func (_c *Database_CreateWorkspaceAPIKey_Call) Run(run func(key *db.WorkspaceAPIKey)) *Database_CreateWorkspaceAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*db.WorkspaceAPIKey))
+	})
+	return _c
+}
+
+func (_c *Database_CreateWorkspaceAPIKey_Call) Return(_a0 error) *Database_CreateWorkspaceAPIKey_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_CreateWorkspaceAPIKey_Call) RunAndReturn(run func(*db.WorkspaceAPIKey) error) *Database_CreateWorkspaceAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateWorkspaceBudget provides a mock function with given fields: budget
 func (_m *Database) CreateWorkspaceBudget(budget db.NewBountyBudget) db.NewBountyBudget {
 	ret := _m.Called(budget)
@@ -3549,6 +4014,53 @@ func (_c *Database_DeleteNotification_Call) RunAndReturn(run func(string) error)
 	return _c
 }
 
+// DeletePersonIdentity provides a mock function with given fields: pubkey, id
+func (_m *Database) DeletePersonIdentity(pubkey string, id string) error {
+	ret := _m.Called(pubkey, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeletePersonIdentity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(pubkey, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_DeletePersonIdentity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeletePersonIdentity'
+type Database_DeletePersonIdentity_Call struct {
+	*mock.Call
+}
+
+// DeletePersonIdentity is a helper method to define mock.On call
+//   - pubkey string
+//   - id string
+func (_e *Database_Expecter) DeletePersonIdentity(pubkey interface{}, id interface{}) *Database_DeletePersonIdentity_Call {
+	return &Database_DeletePersonIdentity_Call{Call: _e.mock.On("DeletePersonIdentity", pubkey, id)}
+}
+
+func (_c *Database_DeletePersonIdentity_Call) Run(run func(pubkey string, id string)) *Database_DeletePersonIdentity_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Database_DeletePersonIdentity_Call) Return(_a0 error) *Database_DeletePersonIdentity_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_DeletePersonIdentity_Call) RunAndReturn(run func(string, string) error) *Database_DeletePersonIdentity_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // DeleteProcessingMap provides a mock function with given fields: id
 func (_m *Database) DeleteProcessingMap(id uint) error {
 	ret := _m.Called(id)
@@ -4105,29 +4617,29 @@ func (_c *Database_DeleteWorkspaceUser_Call) RunAndReturn(run func(db.WorkspaceU
 	return _c
 }
 
-// GetActivitiesByFeature provides a mock function with given fields: featureUUID
-func (_m *Database) GetActivitiesByFeature(featureUUID string) ([]db.Activity, error) {
-	ret := _m.Called(featureUUID)
+// ExpireBountyPayouts provides a mock function with given fields: now
+func (_m *Database) ExpireBountyPayouts(now time.Time) ([]db.BountyPayout, error) {
+	ret := _m.Called(now)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetActivitiesByFeature")
+		panic("no return value specified for ExpireBountyPayouts")
 	}
 
-	var r0 []db.Activity
+	var r0 []db.BountyPayout
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]db.Activity, error)); ok {
-		return rf(featureUUID)
+	if rf, ok := ret.Get(0).(func(time.Time) ([]db.BountyPayout, error)); ok {
+		return rf(now)
 	}
-	if rf, ok := ret.Get(0).(func(string) []db.Activity); ok {
-		r0 = rf(featureUUID)
+	if rf, ok := ret.Get(0).(func(time.Time) []db.BountyPayout); ok {
+		r0 = rf(now)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]db.Activity)
+			r0 = ret.Get(0).([]db.BountyPayout)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(featureUUID)
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(now)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -4135,57 +4647,55 @@ func (_m *Database) GetActivitiesByFeature(featureUUID string) ([]db.Activity, e
 	return r0, r1
 }
 
-// Database_GetActivitiesByFeature_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetActivitiesByFeature'
-type Database_GetActivitiesByFeature_Call struct {
+// Database_ExpireBountyPayouts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExpireBountyPayouts'
+type Database_ExpireBountyPayouts_Call struct {
 	*mock.Call
 }
 
-// GetActivitiesByFeature is a helper method to define mock.On call
-//   - featureUUID string
-func (_e *Database_Expecter) GetActivitiesByFeature(featureUUID interface{}) *Database_GetActivitiesByFeature_Call {
-	return &Database_GetActivitiesByFeature_Call{Call: _e.mock.On("GetActivitiesByFeature", featureUUID)}
+// ExpireBountyPayouts is a helper method to define mock.On call
+//   - now time.Time
+func (_e *Database_Expecter) ExpireBountyPayouts(now interface{}) *Database_ExpireBountyPayouts_Call {
+	return &Database_ExpireBountyPayouts_Call{Call: _e.mock.On("ExpireBountyPayouts", now)}
 }
 
-func (_c *Database_GetActivitiesByFeature_Call) Run(run func(featureUUID string)) *Database_GetActivitiesByFeature_Call {
+func (_c *Database_ExpireBountyPayouts_Call) Run(run func(now time.Time)) *Database_ExpireBountyPayouts_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(time.Time))
 	})
 	return _c
 }
 
-func (_c *Database_GetActivitiesByFeature_Call) Return(_a0 []db.Activity, _a1 error) *Database_GetActivitiesByFeature_Call {
+func (_c *Database_ExpireBountyPayouts_Call) Return(_a0 []db.BountyPayout, _a1 error) *Database_ExpireBountyPayouts_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Database_GetActivitiesByFeature_Call) RunAndReturn(run func(string) ([]db.Activity, error)) *Database_GetActivitiesByFeature_Call {
+func (_c *Database_ExpireBountyPayouts_Call) RunAndReturn(run func(time.Time) ([]db.BountyPayout, error)) *Database_ExpireBountyPayouts_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetActivitiesByPhase provides a mock function with given fields: phaseUUID
-func (_m *Database) GetActivitiesByPhase(phaseUUID string) ([]db.Activity, error) {
-	ret := _m.Called(phaseUUID)
+// FinishChatMessageStream provides a mock function with given fields: id, text
+func (_m *Database) FinishChatMessageStream(id string, text string) (db.ChatMessage, error) {
+	ret := _m.Called(id, text)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetActivitiesByPhase")
+		panic("no return value specified for FinishChatMessageStream")
 	}
 
-	var r0 []db.Activity
+	var r0 db.ChatMessage
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]db.Activity, error)); ok {
-		return rf(phaseUUID)
+	if rf, ok := ret.Get(0).(func(string, string) (db.ChatMessage, error)); ok {
+		return rf(id, text)
 	}
-	if rf, ok := ret.Get(0).(func(string) []db.Activity); ok {
-		r0 = rf(phaseUUID)
+	if rf, ok := ret.Get(0).(func(string, string) db.ChatMessage); ok {
+		r0 = rf(id, text)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]db.Activity)
-		}
+		r0 = ret.Get(0).(db.ChatMessage)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(phaseUUID)
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(id, text)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -4193,115 +4703,104 @@ func (_m *Database) GetActivitiesByPhase(phaseUUID string) ([]db.Activity, error
 	return r0, r1
 }
 
-// Database_GetActivitiesByPhase_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetActivitiesByPhase'
-type Database_GetActivitiesByPhase_Call struct {
+// Database_FinishChatMessageStream_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FinishChatMessageStream'
+type Database_FinishChatMessageStream_Call struct {
 	*mock.Call
 }
 
-// GetActivitiesByPhase is a helper method to define mock.On call
-//   - phaseUUID string
-func (_e *Database_Expecter) GetActivitiesByPhase(phaseUUID interface{}) *Database_GetActivitiesByPhase_Call {
-	return &Database_GetActivitiesByPhase_Call{Call: _e.mock.On("GetActivitiesByPhase", phaseUUID)}
+// FinishChatMessageStream is a helper method to define mock.On call
+//   - id string
+//   - text string
+func (_e *Database_Expecter) FinishChatMessageStream(id interface{}, text interface{}) *Database_FinishChatMessageStream_Call {
+	return &Database_FinishChatMessageStream_Call{Call: _e.mock.On("FinishChatMessageStream", id, text)}
 }
 
-func (_c *Database_GetActivitiesByPhase_Call) Run(run func(phaseUUID string)) *Database_GetActivitiesByPhase_Call {
+func (_c *Database_FinishChatMessageStream_Call) Run(run func(id string, text string)) *Database_FinishChatMessageStream_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(string), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *Database_GetActivitiesByPhase_Call) Return(_a0 []db.Activity, _a1 error) *Database_GetActivitiesByPhase_Call {
+func (_c *Database_FinishChatMessageStream_Call) Return(_a0 db.ChatMessage, _a1 error) *Database_FinishChatMessageStream_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Database_GetActivitiesByPhase_Call) RunAndReturn(run func(string) ([]db.Activity, error)) *Database_GetActivitiesByPhase_Call {
+func (_c *Database_FinishChatMessageStream_Call) RunAndReturn(run func(string, string) (db.ChatMessage, error)) *Database_FinishChatMessageStream_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetActivitiesByThread provides a mock function with given fields: threadID
-func (_m *Database) GetActivitiesByThread(threadID string) ([]db.Activity, error) {
-	ret := _m.Called(threadID)
+// FlagWorkspacePurge provides a mock function with given fields: workspace_uuid
+func (_m *Database) FlagWorkspacePurge(workspace_uuid string) error {
+	ret := _m.Called(workspace_uuid)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetActivitiesByThread")
-	}
-
-	var r0 []db.Activity
-	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]db.Activity, error)); ok {
-		return rf(threadID)
-	}
-	if rf, ok := ret.Get(0).(func(string) []db.Activity); ok {
-		r0 = rf(threadID)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]db.Activity)
-		}
+		panic("no return value specified for FlagWorkspacePurge")
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(threadID)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(workspace_uuid)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// Database_GetActivitiesByThread_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetActivitiesByThread'
-type Database_GetActivitiesByThread_Call struct {
+// Database_FlagWorkspacePurge_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FlagWorkspacePurge'
+type Database_FlagWorkspacePurge_Call struct {
 	*mock.Call
 }
 
-// GetActivitiesByThread is a helper method to define mock.On call
-//   - threadID string
-func (_e *Database_Expecter) GetActivitiesByThread(threadID interface{}) *Database_GetActivitiesByThread_Call {
-	return &Database_GetActivitiesByThread_Call{Call: _e.mock.On("GetActivitiesByThread", threadID)}
+// FlagWorkspacePurge is a helper method to define mock.On call
+//   - workspace_uuid string
+func (_e *Database_Expecter) FlagWorkspacePurge(workspace_uuid interface{}) *Database_FlagWorkspacePurge_Call {
+	return &Database_FlagWorkspacePurge_Call{Call: _e.mock.On("FlagWorkspacePurge", workspace_uuid)}
 }
 
-func (_c *Database_GetActivitiesByThread_Call) Run(run func(threadID string)) *Database_GetActivitiesByThread_Call {
+func (_c *Database_FlagWorkspacePurge_Call) Run(run func(workspace_uuid string)) *Database_FlagWorkspacePurge_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(string))
 	})
 	return _c
 }
 
-func (_c *Database_GetActivitiesByThread_Call) Return(_a0 []db.Activity, _a1 error) *Database_GetActivitiesByThread_Call {
-	_c.Call.Return(_a0, _a1)
+func (_c *Database_FlagWorkspacePurge_Call) Return(_a0 error) *Database_FlagWorkspacePurge_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *Database_GetActivitiesByThread_Call) RunAndReturn(run func(string) ([]db.Activity, error)) *Database_GetActivitiesByThread_Call {
+func (_c *Database_FlagWorkspacePurge_Call) RunAndReturn(run func(string) error) *Database_FlagWorkspacePurge_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetActivitiesByWorkspace provides a mock function with given fields: workspace
-func (_m *Database) GetActivitiesByWorkspace(workspace string) ([]db.Activity, error) {
-	ret := _m.Called(workspace)
+// GetActiveUserSessions provides a mock function with given fields: pubkey
+func (_m *Database) GetActiveUserSessions(pubkey string) ([]db.UserSession, error) {
+	ret := _m.Called(pubkey)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetActivitiesByWorkspace")
+		panic("no return value specified for GetActiveUserSessions")
 	}
 
-	var r0 []db.Activity
+	var r0 []db.UserSession
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]db.Activity, error)); ok {
-		return rf(workspace)
+	if rf, ok := ret.Get(0).(func(string) ([]db.UserSession, error)); ok {
+		return rf(pubkey)
 	}
-	if rf, ok := ret.Get(0).(func(string) []db.Activity); ok {
-		r0 = rf(workspace)
+	if rf, ok := ret.Get(0).(func(string) []db.UserSession); ok {
+		r0 = rf(pubkey)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]db.Activity)
+			r0 = ret.Get(0).([]db.UserSession)
 		}
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(workspace)
+		r1 = rf(pubkey)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -4309,57 +4808,57 @@ func (_m *Database) GetActivitiesByWorkspace(workspace string) ([]db.Activity, e
 	return r0, r1
 }
 
-// Database_GetActivitiesByWorkspace_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetActivitiesByWorkspace'
-type Database_GetActivitiesByWorkspace_Call struct {
+// Database_GetActiveUserSessions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetActiveUserSessions'
+type Database_GetActiveUserSessions_Call struct {
 	*mock.Call
 }
 
-// GetActivitiesByWorkspace is a helper method to define mock.On call
-//   - workspace string
-func (_e *Database_Expecter) GetActivitiesByWorkspace(workspace interface{}) *Database_GetActivitiesByWorkspace_Call {
-	return &Database_GetActivitiesByWorkspace_Call{Call: _e.mock.On("GetActivitiesByWorkspace", workspace)}
+// GetActiveUserSessions is a helper method to define mock.On call
+//   - pubkey string
+func (_e *Database_Expecter) GetActiveUserSessions(pubkey interface{}) *Database_GetActiveUserSessions_Call {
+	return &Database_GetActiveUserSessions_Call{Call: _e.mock.On("GetActiveUserSessions", pubkey)}
 }
 
-func (_c *Database_GetActivitiesByWorkspace_Call) Run(run func(workspace string)) *Database_GetActivitiesByWorkspace_Call {
+func (_c *Database_GetActiveUserSessions_Call) Run(run func(pubkey string)) *Database_GetActiveUserSessions_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(string))
 	})
 	return _c
 }
 
-func (_c *Database_GetActivitiesByWorkspace_Call) Return(_a0 []db.Activity, _a1 error) *Database_GetActivitiesByWorkspace_Call {
+func (_c *Database_GetActiveUserSessions_Call) Return(_a0 []db.UserSession, _a1 error) *Database_GetActiveUserSessions_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Database_GetActivitiesByWorkspace_Call) RunAndReturn(run func(string) ([]db.Activity, error)) *Database_GetActivitiesByWorkspace_Call {
+func (_c *Database_GetActiveUserSessions_Call) RunAndReturn(run func(string) ([]db.UserSession, error)) *Database_GetActiveUserSessions_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetActivity provides a mock function with given fields: id
-func (_m *Database) GetActivity(id string) (*db.Activity, error) {
-	ret := _m.Called(id)
+// GetActivitiesByFeature provides a mock function with given fields: featureUUID
+func (_m *Database) GetActivitiesByFeature(featureUUID string) ([]db.Activity, error) {
+	ret := _m.Called(featureUUID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetActivity")
+		panic("no return value specified for GetActivitiesByFeature")
 	}
 
-	var r0 *db.Activity
+	var r0 []db.Activity
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (*db.Activity, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(string) ([]db.Activity, error)); ok {
+		return rf(featureUUID)
 	}
-	if rf, ok := ret.Get(0).(func(string) *db.Activity); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(string) []db.Activity); ok {
+		r0 = rf(featureUUID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*db.Activity)
+			r0 = ret.Get(0).([]db.Activity)
 		}
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(id)
+		r1 = rf(featureUUID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -4367,18 +4866,250 @@ func (_m *Database) GetActivity(id string) (*db.Activity, error) {
 	return r0, r1
 }
 
-// Database_GetActivity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetActivity'
-type Database_GetActivity_Call struct {
+// Database_GetActivitiesByFeature_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetActivitiesByFeature'
+type Database_GetActivitiesByFeature_Call struct {
 	*mock.Call
 }
 
-// GetActivity is a helper method to define mock.On call
-//   - id string
-func (_e *Database_Expecter) GetActivity(id interface{}) *Database_GetActivity_Call {
-	return &Database_GetActivity_Call{Call: _e.mock.On("GetActivity", id)}
+// GetActivitiesByFeature is a helper method to define mock.On call
+//   - featureUUID string
+func (_e *Database_Expecter) GetActivitiesByFeature(featureUUID interface{}) *Database_GetActivitiesByFeature_Call {
+	return &Database_GetActivitiesByFeature_Call{Call: _e.mock.On("GetActivitiesByFeature", featureUUID)}
 }
 
-func (_c *Database_GetActivity_Call) Run(run func(id string)) *Database_GetActivity_Call {
+func (_c *Database_GetActivitiesByFeature_Call) Run(run func(featureUUID string)) *Database_GetActivitiesByFeature_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetActivitiesByFeature_Call) Return(_a0 []db.Activity, _a1 error) *Database_GetActivitiesByFeature_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetActivitiesByFeature_Call) RunAndReturn(run func(string) ([]db.Activity, error)) *Database_GetActivitiesByFeature_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetActivitiesByPhase provides a mock function with given fields: phaseUUID
+func (_m *Database) GetActivitiesByPhase(phaseUUID string) ([]db.Activity, error) {
+	ret := _m.Called(phaseUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetActivitiesByPhase")
+	}
+
+	var r0 []db.Activity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]db.Activity, error)); ok {
+		return rf(phaseUUID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []db.Activity); ok {
+		r0 = rf(phaseUUID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.Activity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(phaseUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetActivitiesByPhase_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetActivitiesByPhase'
+type Database_GetActivitiesByPhase_Call struct {
+	*mock.Call
+}
+
+// GetActivitiesByPhase is a helper method to define mock.On call
+//   - phaseUUID string
+func (_e *Database_Expecter) GetActivitiesByPhase(phaseUUID interface{}) *Database_GetActivitiesByPhase_Call {
+	return &Database_GetActivitiesByPhase_Call{Call: _e.mock.On("GetActivitiesByPhase", phaseUUID)}
+}
+
+func (_c *Database_GetActivitiesByPhase_Call) Run(run func(phaseUUID string)) *Database_GetActivitiesByPhase_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetActivitiesByPhase_Call) Return(_a0 []db.Activity, _a1 error) *Database_GetActivitiesByPhase_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetActivitiesByPhase_Call) RunAndReturn(run func(string) ([]db.Activity, error)) *Database_GetActivitiesByPhase_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetActivitiesByThread provides a mock function with given fields: threadID
+func (_m *Database) GetActivitiesByThread(threadID string) ([]db.Activity, error) {
+	ret := _m.Called(threadID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetActivitiesByThread")
+	}
+
+	var r0 []db.Activity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]db.Activity, error)); ok {
+		return rf(threadID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []db.Activity); ok {
+		r0 = rf(threadID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.Activity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(threadID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetActivitiesByThread_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetActivitiesByThread'
+type Database_GetActivitiesByThread_Call struct {
+	*mock.Call
+}
+
+// GetActivitiesByThread is a helper method to define mock.On call
+//   - threadID string
+func (_e *Database_Expecter) GetActivitiesByThread(threadID interface{}) *Database_GetActivitiesByThread_Call {
+	return &Database_GetActivitiesByThread_Call{Call: _e.mock.On("GetActivitiesByThread", threadID)}
+}
+
+func (_c *Database_GetActivitiesByThread_Call) Run(run func(threadID string)) *Database_GetActivitiesByThread_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetActivitiesByThread_Call) Return(_a0 []db.Activity, _a1 error) *Database_GetActivitiesByThread_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetActivitiesByThread_Call) RunAndReturn(run func(string) ([]db.Activity, error)) *Database_GetActivitiesByThread_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetActivitiesByWorkspace provides a mock function with given fields: workspace
+func (_m *Database) GetActivitiesByWorkspace(workspace string) ([]db.Activity, error) {
+	ret := _m.Called(workspace)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetActivitiesByWorkspace")
+	}
+
+	var r0 []db.Activity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]db.Activity, error)); ok {
+		return rf(workspace)
+	}
+	if rf, ok := ret.Get(0).(func(string) []db.Activity); ok {
+		r0 = rf(workspace)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.Activity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(workspace)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetActivitiesByWorkspace_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetActivitiesByWorkspace'
+type Database_GetActivitiesByWorkspace_Call struct {
+	*mock.Call
+}
+
+// GetActivitiesByWorkspace is a helper method to define mock.On call
+//   - workspace string
+func (_e *Database_Expecter) GetActivitiesByWorkspace(workspace interface{}) *Database_GetActivitiesByWorkspace_Call {
+	return &Database_GetActivitiesByWorkspace_Call{Call: _e.mock.On("GetActivitiesByWorkspace", workspace)}
+}
+
+func (_c *Database_GetActivitiesByWorkspace_Call) Run(run func(workspace string)) *Database_GetActivitiesByWorkspace_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetActivitiesByWorkspace_Call) Return(_a0 []db.Activity, _a1 error) *Database_GetActivitiesByWorkspace_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetActivitiesByWorkspace_Call) RunAndReturn(run func(string) ([]db.Activity, error)) *Database_GetActivitiesByWorkspace_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetActivity provides a mock function with given fields: id
+func (_m *Database) GetActivity(id string) (*db.Activity, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetActivity")
+	}
+
+	var r0 *db.Activity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*db.Activity, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *db.Activity); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*db.Activity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetActivity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetActivity'
+type Database_GetActivity_Call struct {
+	*mock.Call
+}
+
+// GetActivity is a helper method to define mock.On call
+//   - id string
+func (_e *Database_Expecter) GetActivity(id interface{}) *Database_GetActivity_Call {
+	return &Database_GetActivity_Call{Call: _e.mock.On("GetActivity", id)}
+}
+
+func (_c *Database_GetActivity_Call) Run(run func(id string)) *Database_GetActivity_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(string))
 	})
@@ -5858,24 +6589,136 @@ func (_c *Database_GetBountyIndexById_Call) RunAndReturn(run func(string) int64)
 	return _c
 }
 
-// GetBountyRoles provides a mock function with no fields
-func (_m *Database) GetBountyRoles() []db.BountyRoles {
-	ret := _m.Called()
+// GetBountyPayout provides a mock function with given fields: bountyID
+func (_m *Database) GetBountyPayout(bountyID uint) (db.BountyPayout, error) {
+	ret := _m.Called(bountyID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetBountyRoles")
+		panic("no return value specified for GetBountyPayout")
 	}
 
-	var r0 []db.BountyRoles
-	if rf, ok := ret.Get(0).(func() []db.BountyRoles); ok {
-		r0 = rf()
+	var r0 db.BountyPayout
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uint) (db.BountyPayout, error)); ok {
+		return rf(bountyID)
+	}
+	if rf, ok := ret.Get(0).(func(uint) db.BountyPayout); ok {
+		r0 = rf(bountyID)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]db.BountyRoles)
-		}
+		r0 = ret.Get(0).(db.BountyPayout)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(uint) error); ok {
+		r1 = rf(bountyID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetBountyPayout_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetBountyPayout'
+type Database_GetBountyPayout_Call struct {
+	*mock.Call
+}
+
+// GetBountyPayout is a helper method to define mock.On call
+//   - bountyID uint
+func (_e *Database_Expecter) GetBountyPayout(bountyID interface{}) *Database_GetBountyPayout_Call {
+	return &Database_GetBountyPayout_Call{Call: _e.mock.On("GetBountyPayout", bountyID)}
+}
+
+func (_c *Database_GetBountyPayout_Call) Run(run func(bountyID uint)) *Database_GetBountyPayout_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uint))
+	})
+	return _c
+}
+
+func (_c *Database_GetBountyPayout_Call) Return(_a0 db.BountyPayout, _a1 error) *Database_GetBountyPayout_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetBountyPayout_Call) RunAndReturn(run func(uint) (db.BountyPayout, error)) *Database_GetBountyPayout_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetBountyPayoutByK1 provides a mock function with given fields: k1
+func (_m *Database) GetBountyPayoutByK1(k1 string) (db.BountyPayout, error) {
+	ret := _m.Called(k1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBountyPayoutByK1")
+	}
+
+	var r0 db.BountyPayout
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (db.BountyPayout, error)); ok {
+		return rf(k1)
+	}
+	if rf, ok := ret.Get(0).(func(string) db.BountyPayout); ok {
+		r0 = rf(k1)
+	} else {
+		r0 = ret.Get(0).(db.BountyPayout)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(k1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetBountyPayoutByK1_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetBountyPayoutByK1'
+type Database_GetBountyPayoutByK1_Call struct {
+	*mock.Call
+}
+
+// GetBountyPayoutByK1 is a helper method to define mock.On call
+//   - k1 string
+func (_e *Database_Expecter) GetBountyPayoutByK1(k1 interface{}) *Database_GetBountyPayoutByK1_Call {
+	return &Database_GetBountyPayoutByK1_Call{Call: _e.mock.On("GetBountyPayoutByK1", k1)}
+}
+
+func (_c *Database_GetBountyPayoutByK1_Call) Run(run func(k1 string)) *Database_GetBountyPayoutByK1_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetBountyPayoutByK1_Call) Return(_a0 db.BountyPayout, _a1 error) *Database_GetBountyPayoutByK1_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetBountyPayoutByK1_Call) RunAndReturn(run func(string) (db.BountyPayout, error)) *Database_GetBountyPayoutByK1_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetBountyRoles provides a mock function with no fields
+func (_m *Database) GetBountyRoles() []db.BountyRoles {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBountyRoles")
+	}
+
+	var r0 []db.BountyRoles
+	if rf, ok := ret.Get(0).(func() []db.BountyRoles); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.BountyRoles)
+		}
+	}
+
+	return r0
 }
 
 // Database_GetBountyRoles_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetBountyRoles'
@@ -6057,6 +6900,65 @@ func (_c *Database_GetChannelsByTribe_Call) RunAndReturn(run func(string) []db.C
 	return _c
 }
 
+// GetChatBranch provides a mock function with given fields: chatID, leafID
+func (_m *Database) GetChatBranch(chatID string, leafID string) ([]db.ChatMessage, error) {
+	ret := _m.Called(chatID, leafID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetChatBranch")
+	}
+
+	var r0 []db.ChatMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) ([]db.ChatMessage, error)); ok {
+		return rf(chatID, leafID)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []db.ChatMessage); ok {
+		r0 = rf(chatID, leafID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.ChatMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(chatID, leafID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetChatBranch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetChatBranch'
+type Database_GetChatBranch_Call struct {
+	*mock.Call
+}
+
+// GetChatBranch is a helper method to define mock.On call
+//   - chatID string
+//   - leafID string
+func (_e *Database_Expecter) GetChatBranch(chatID interface{}, leafID interface{}) *Database_GetChatBranch_Call {
+	return &Database_GetChatBranch_Call{Call: _e.mock.On("GetChatBranch", chatID, leafID)}
+}
+
+func (_c *Database_GetChatBranch_Call) Run(run func(chatID string, leafID string)) *Database_GetChatBranch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetChatBranch_Call) Return(_a0 []db.ChatMessage, _a1 error) *Database_GetChatBranch_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetChatBranch_Call) RunAndReturn(run func(string, string) ([]db.ChatMessage, error)) *Database_GetChatBranch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetChatByChatID provides a mock function with given fields: chatID
 func (_m *Database) GetChatByChatID(chatID string) (db.Chat, error) {
 	ret := _m.Called(chatID)
@@ -6113,6 +7015,63 @@ func (_c *Database_GetChatByChatID_Call) RunAndReturn(run func(string) (db.Chat,
 	return _c
 }
 
+// GetChatMessageStream provides a mock function with given fields: chatID, parentID
+func (_m *Database) GetChatMessageStream(chatID string, parentID string) (db.ChatMessage, error) {
+	ret := _m.Called(chatID, parentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetChatMessageStream")
+	}
+
+	var r0 db.ChatMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (db.ChatMessage, error)); ok {
+		return rf(chatID, parentID)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) db.ChatMessage); ok {
+		r0 = rf(chatID, parentID)
+	} else {
+		r0 = ret.Get(0).(db.ChatMessage)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(chatID, parentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetChatMessageStream_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetChatMessageStream'
+type Database_GetChatMessageStream_Call struct {
+	*mock.Call
+}
+
+// GetChatMessageStream is a helper method to define mock.On call
+//   - chatID string
+//   - parentID string
+func (_e *Database_Expecter) GetChatMessageStream(chatID interface{}, parentID interface{}) *Database_GetChatMessageStream_Call {
+	return &Database_GetChatMessageStream_Call{Call: _e.mock.On("GetChatMessageStream", chatID, parentID)}
+}
+
+func (_c *Database_GetChatMessageStream_Call) Run(run func(chatID string, parentID string)) *Database_GetChatMessageStream_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetChatMessageStream_Call) Return(_a0 db.ChatMessage, _a1 error) *Database_GetChatMessageStream_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetChatMessageStream_Call) RunAndReturn(run func(string, string) (db.ChatMessage, error)) *Database_GetChatMessageStream_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetChatMessagesForChatID provides a mock function with given fields: chatID
 func (_m *Database) GetChatMessagesForChatID(chatID string) ([]db.ChatMessage, error) {
 	ret := _m.Called(chatID)
@@ -6230,6 +7189,64 @@ func (_c *Database_GetChatsForWorkspace_Call) RunAndReturn(run func(string, stri
 	return _c
 }
 
+// GetClaimedBountyPayouts provides a mock function with given fields: before
+func (_m *Database) GetClaimedBountyPayouts(before time.Time) ([]db.BountyPayout, error) {
+	ret := _m.Called(before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetClaimedBountyPayouts")
+	}
+
+	var r0 []db.BountyPayout
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time) ([]db.BountyPayout, error)); ok {
+		return rf(before)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time) []db.BountyPayout); ok {
+		r0 = rf(before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.BountyPayout)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetClaimedBountyPayouts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetClaimedBountyPayouts'
+type Database_GetClaimedBountyPayouts_Call struct {
+	*mock.Call
+}
+
+// GetClaimedBountyPayouts is a helper method to define mock.On call
+//   - before time.Time
+func (_e *Database_Expecter) GetClaimedBountyPayouts(before interface{}) *Database_GetClaimedBountyPayouts_Call {
+	return &Database_GetClaimedBountyPayouts_Call{Call: _e.mock.On("GetClaimedBountyPayouts", before)}
+}
+
+func (_c *Database_GetClaimedBountyPayouts_Call) Run(run func(before time.Time)) *Database_GetClaimedBountyPayouts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Time))
+	})
+	return _c
+}
+
+func (_c *Database_GetClaimedBountyPayouts_Call) Return(_a0 []db.BountyPayout, _a1 error) *Database_GetClaimedBountyPayouts_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetClaimedBountyPayouts_Call) RunAndReturn(run func(time.Time) ([]db.BountyPayout, error)) *Database_GetClaimedBountyPayouts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetCodeGraphByUUID provides a mock function with given fields: _a0
 func (_m *Database) GetCodeGraphByUUID(_a0 string) (db.WorkspaceCodeGraph, error) {
 	ret := _m.Called(_a0)
@@ -8586,6 +9603,52 @@ func (_c *Database_GetNotificationsByStatus_Call) RunAndReturn(run func(string)
 	return _c
 }
 
+// GetNotificationsByStatusCount provides a mock function with given fields: status
+func (_m *Database) GetNotificationsByStatusCount(status string) int64 {
+	ret := _m.Called(status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetNotificationsByStatusCount")
+	}
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(string) int64); ok {
+		r0 = rf(status)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	return r0
+}
+
+// Database_GetNotificationsByStatusCount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetNotificationsByStatusCount'
+type Database_GetNotificationsByStatusCount_Call struct {
+	*mock.Call
+}
+
+// GetNotificationsByStatusCount is a helper method to define mock.On call
+//   - status string
+func (_e *Database_Expecter) GetNotificationsByStatusCount(status interface{}) *Database_GetNotificationsByStatusCount_Call {
+	return &Database_GetNotificationsByStatusCount_Call{Call: _e.mock.On("GetNotificationsByStatusCount", status)}
+}
+
+func (_c *Database_GetNotificationsByStatusCount_Call) Run(run func(status string)) *Database_GetNotificationsByStatusCount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetNotificationsByStatusCount_Call) Return(_a0 int64) *Database_GetNotificationsByStatusCount_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_GetNotificationsByStatusCount_Call) RunAndReturn(run func(string) int64) *Database_GetNotificationsByStatusCount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetOpenGithubIssues provides a mock function with given fields: r
 func (_m *Database) GetOpenGithubIssues(r *http.Request) (int64, error) {
 	ret := _m.Called(r)
@@ -8784,55 +9847,102 @@ func (_c *Database_GetPaymentHistoryByCreated_Call) RunAndReturn(run func(*time.
 	return _c
 }
 
-// GetPendingNotifications provides a mock function with no fields
-func (_m *Database) GetPendingNotifications() ([]db.Notification, error) {
+// GetPendingInvoices provides a mock function with no fields
+func (_m *Database) GetPendingInvoices() []db.NewInvoiceList {
 	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetPendingNotifications")
+		panic("no return value specified for GetPendingInvoices")
 	}
 
-	var r0 []db.Notification
-	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]db.Notification, error)); ok {
-		return rf()
-	}
-	if rf, ok := ret.Get(0).(func() []db.Notification); ok {
+	var r0 []db.NewInvoiceList
+	if rf, ok := ret.Get(0).(func() []db.NewInvoiceList); ok {
 		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]db.Notification)
+			r0 = ret.Get(0).([]db.NewInvoiceList)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
-	} else {
-		r1 = ret.Error(1)
-	}
-
-	return r0, r1
+	return r0
 }
 
-// Database_GetPendingNotifications_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPendingNotifications'
-type Database_GetPendingNotifications_Call struct {
+// Database_GetPendingInvoices_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPendingInvoices'
+type Database_GetPendingInvoices_Call struct {
 	*mock.Call
 }
 
-// GetPendingNotifications is a helper method to define mock.On call
-func (_e *Database_Expecter) GetPendingNotifications() *Database_GetPendingNotifications_Call {
-	return &Database_GetPendingNotifications_Call{Call: _e.mock.On("GetPendingNotifications")}
+// GetPendingInvoices is a helper method to define mock.On call
+func (_e *Database_Expecter) GetPendingInvoices() *Database_GetPendingInvoices_Call {
+	return &Database_GetPendingInvoices_Call{Call: _e.mock.On("GetPendingInvoices")}
 }
 
-func (_c *Database_GetPendingNotifications_Call) Run(run func()) *Database_GetPendingNotifications_Call {
+func (_c *Database_GetPendingInvoices_Call) Run(run func()) *Database_GetPendingInvoices_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run()
 	})
 	return _c
 }
 
-func (_c *Database_GetPendingNotifications_Call) Return(_a0 []db.Notification, _a1 error) *Database_GetPendingNotifications_Call {
-	_c.Call.Return(_a0, _a1)
+func (_c *Database_GetPendingInvoices_Call) Return(_a0 []db.NewInvoiceList) *Database_GetPendingInvoices_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_GetPendingInvoices_Call) RunAndReturn(run func() []db.NewInvoiceList) *Database_GetPendingInvoices_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPendingNotifications provides a mock function with no fields
+func (_m *Database) GetPendingNotifications() ([]db.Notification, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPendingNotifications")
+	}
+
+	var r0 []db.Notification
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]db.Notification, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []db.Notification); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.Notification)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetPendingNotifications_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPendingNotifications'
+type Database_GetPendingNotifications_Call struct {
+	*mock.Call
+}
+
+// GetPendingNotifications is a helper method to define mock.On call
+func (_e *Database_Expecter) GetPendingNotifications() *Database_GetPendingNotifications_Call {
+	return &Database_GetPendingNotifications_Call{Call: _e.mock.On("GetPendingNotifications")}
+}
+
+func (_c *Database_GetPendingNotifications_Call) Run(run func()) *Database_GetPendingNotifications_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Database_GetPendingNotifications_Call) Return(_a0 []db.Notification, _a1 error) *Database_GetPendingNotifications_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
@@ -8888,6 +9998,51 @@ func (_c *Database_GetPendingPaymentHistory_Call) RunAndReturn(run func() []db.N
 	return _c
 }
 
+// GetPendingPaymentHistoryCount provides a mock function with no fields
+func (_m *Database) GetPendingPaymentHistoryCount() int64 {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPendingPaymentHistoryCount")
+	}
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func() int64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	return r0
+}
+
+// Database_GetPendingPaymentHistoryCount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPendingPaymentHistoryCount'
+type Database_GetPendingPaymentHistoryCount_Call struct {
+	*mock.Call
+}
+
+// GetPendingPaymentHistoryCount is a helper method to define mock.On call
+func (_e *Database_Expecter) GetPendingPaymentHistoryCount() *Database_GetPendingPaymentHistoryCount_Call {
+	return &Database_GetPendingPaymentHistoryCount_Call{Call: _e.mock.On("GetPendingPaymentHistoryCount")}
+}
+
+func (_c *Database_GetPendingPaymentHistoryCount_Call) Run(run func()) *Database_GetPendingPaymentHistoryCount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Database_GetPendingPaymentHistoryCount_Call) Return(_a0 int64) *Database_GetPendingPaymentHistoryCount_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_GetPendingPaymentHistoryCount_Call) RunAndReturn(run func() int64) *Database_GetPendingPaymentHistoryCount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetPendingWorkflowRequests provides a mock function with given fields: limit
 func (_m *Database) GetPendingWorkflowRequests(limit int) ([]db.WfRequest, error) {
 	ret := _m.Called(limit)
@@ -9088,6 +10243,52 @@ func (_c *Database_GetPerson_Call) RunAndReturn(run func(uint) db.Person) *Datab
 	return _c
 }
 
+// GetPersonByGithubID provides a mock function with given fields: githubID
+func (_m *Database) GetPersonByGithubID(githubID int64) db.Person {
+	ret := _m.Called(githubID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPersonByGithubID")
+	}
+
+	var r0 db.Person
+	if rf, ok := ret.Get(0).(func(int64) db.Person); ok {
+		r0 = rf(githubID)
+	} else {
+		r0 = ret.Get(0).(db.Person)
+	}
+
+	return r0
+}
+
+// Database_GetPersonByGithubID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPersonByGithubID'
+type Database_GetPersonByGithubID_Call struct {
+	*mock.Call
+}
+
+// GetPersonByGithubID is a helper method to define mock.On call
+//   - githubID int64
+func (_e *Database_Expecter) GetPersonByGithubID(githubID interface{}) *Database_GetPersonByGithubID_Call {
+	return &Database_GetPersonByGithubID_Call{Call: _e.mock.On("GetPersonByGithubID", githubID)}
+}
+
+func (_c *Database_GetPersonByGithubID_Call) Run(run func(githubID int64)) *Database_GetPersonByGithubID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *Database_GetPersonByGithubID_Call) Return(_a0 db.Person) *Database_GetPersonByGithubID_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_GetPersonByGithubID_Call) RunAndReturn(run func(int64) db.Person) *Database_GetPersonByGithubID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetPersonByGithubName provides a mock function with given fields: github_name
 func (_m *Database) GetPersonByGithubName(github_name string) db.Person {
 	ret := _m.Called(github_name)
@@ -9272,6 +10473,121 @@ func (_c *Database_GetPersonByUuid_Call) RunAndReturn(run func(string) db.Person
 	return _c
 }
 
+// GetPersonIdentities provides a mock function with given fields: pubkey
+func (_m *Database) GetPersonIdentities(pubkey string) ([]db.PersonIdentity, error) {
+	ret := _m.Called(pubkey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPersonIdentities")
+	}
+
+	var r0 []db.PersonIdentity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]db.PersonIdentity, error)); ok {
+		return rf(pubkey)
+	}
+	if rf, ok := ret.Get(0).(func(string) []db.PersonIdentity); ok {
+		r0 = rf(pubkey)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.PersonIdentity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(pubkey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetPersonIdentities_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPersonIdentities'
+type Database_GetPersonIdentities_Call struct {
+	*mock.Call
+}
+
+// GetPersonIdentities is a helper method to define mock.On call
+//   - pubkey string
+func (_e *Database_Expecter) GetPersonIdentities(pubkey interface{}) *Database_GetPersonIdentities_Call {
+	return &Database_GetPersonIdentities_Call{Call: _e.mock.On("GetPersonIdentities", pubkey)}
+}
+
+func (_c *Database_GetPersonIdentities_Call) Run(run func(pubkey string)) *Database_GetPersonIdentities_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetPersonIdentities_Call) Return(_a0 []db.PersonIdentity, _a1 error) *Database_GetPersonIdentities_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetPersonIdentities_Call) RunAndReturn(run func(string) ([]db.PersonIdentity, error)) *Database_GetPersonIdentities_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPersonIdentity provides a mock function with given fields: identityType, value
+func (_m *Database) GetPersonIdentity(identityType string, value string) (db.PersonIdentity, error) {
+	ret := _m.Called(identityType, value)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPersonIdentity")
+	}
+
+	var r0 db.PersonIdentity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (db.PersonIdentity, error)); ok {
+		return rf(identityType, value)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) db.PersonIdentity); ok {
+		r0 = rf(identityType, value)
+	} else {
+		r0 = ret.Get(0).(db.PersonIdentity)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(identityType, value)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetPersonIdentity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPersonIdentity'
+type Database_GetPersonIdentity_Call struct {
+	*mock.Call
+}
+
+// GetPersonIdentity is a helper method to define mock.On call
+//   - identityType string
+//   - value string
+func (_e *Database_Expecter) GetPersonIdentity(identityType interface{}, value interface{}) *Database_GetPersonIdentity_Call {
+	return &Database_GetPersonIdentity_Call{Call: _e.mock.On("GetPersonIdentity", identityType, value)}
+}
+
+func (_c *Database_GetPersonIdentity_Call) Run(run func(identityType string, value string)) *Database_GetPersonIdentity_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetPersonIdentity_Call) Return(_a0 db.PersonIdentity, _a1 error) *Database_GetPersonIdentity_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetPersonIdentity_Call) RunAndReturn(run func(string, string) (db.PersonIdentity, error)) *Database_GetPersonIdentity_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetPhaseByUuid provides a mock function with given fields: phaseUuid
 func (_m *Database) GetPhaseByUuid(phaseUuid string) (db.FeaturePhase, error) {
 	ret := _m.Called(phaseUuid)
@@ -9765,6 +11081,64 @@ func (_c *Database_GetProofsByBountyID_Call) RunAndReturn(run func(uint) []db.Pr
 	return _c
 }
 
+// GetSSESubscriptions provides a mock function with given fields: includeEnded
+func (_m *Database) GetSSESubscriptions(includeEnded bool) ([]db.SSESubscription, error) {
+	ret := _m.Called(includeEnded)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSSESubscriptions")
+	}
+
+	var r0 []db.SSESubscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(bool) ([]db.SSESubscription, error)); ok {
+		return rf(includeEnded)
+	}
+	if rf, ok := ret.Get(0).(func(bool) []db.SSESubscription); ok {
+		r0 = rf(includeEnded)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.SSESubscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(bool) error); ok {
+		r1 = rf(includeEnded)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetSSESubscriptions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSSESubscriptions'
+type Database_GetSSESubscriptions_Call struct {
+	*mock.Call
+}
+
+// GetSSESubscriptions is a helper method to define mock.On call
+//   - includeEnded bool
+func (_e *Database_Expecter) GetSSESubscriptions(includeEnded interface{}) *Database_GetSSESubscriptions_Call {
+	return &Database_GetSSESubscriptions_Call{Call: _e.mock.On("GetSSESubscriptions", includeEnded)}
+}
+
+func (_c *Database_GetSSESubscriptions_Call) Run(run func(includeEnded bool)) *Database_GetSSESubscriptions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(bool))
+	})
+	return _c
+}
+
+func (_c *Database_GetSSESubscriptions_Call) Return(_a0 []db.SSESubscription, _a1 error) *Database_GetSSESubscriptions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetSSESubscriptions_Call) RunAndReturn(run func(bool) ([]db.SSESubscription, error)) *Database_GetSSESubscriptions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetSnippetByID provides a mock function with given fields: id
 func (_m *Database) GetSnippetByID(id uint) (*db.TextSnippet, error) {
 	ret := _m.Called(id)
@@ -9881,30 +11255,88 @@ func (_c *Database_GetSnippetsByWorkspace_Call) RunAndReturn(run func(string) ([
 	return _c
 }
 
-// GetSumOfDeposits provides a mock function with given fields: workspace_uuid
-func (_m *Database) GetSumOfDeposits(workspace_uuid string) uint {
-	ret := _m.Called(workspace_uuid)
+// GetStaleSendingSSEMessageLogs provides a mock function with given fields: before
+func (_m *Database) GetStaleSendingSSEMessageLogs(before time.Time) ([]db.SSEMessageLog, error) {
+	ret := _m.Called(before)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetSumOfDeposits")
+		panic("no return value specified for GetStaleSendingSSEMessageLogs")
 	}
 
-	var r0 uint
-	if rf, ok := ret.Get(0).(func(string) uint); ok {
-		r0 = rf(workspace_uuid)
+	var r0 []db.SSEMessageLog
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time) ([]db.SSEMessageLog, error)); ok {
+		return rf(before)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time) []db.SSEMessageLog); ok {
+		r0 = rf(before)
 	} else {
-		r0 = ret.Get(0).(uint)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.SSEMessageLog)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// Database_GetSumOfDeposits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSumOfDeposits'
-type Database_GetSumOfDeposits_Call struct {
+// Database_GetStaleSendingSSEMessageLogs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetStaleSendingSSEMessageLogs'
+type Database_GetStaleSendingSSEMessageLogs_Call struct {
 	*mock.Call
 }
 
-// GetSumOfDeposits is a helper method to define mock.On call
+// GetStaleSendingSSEMessageLogs is a helper method to define mock.On call
+//   - before time.Time
+func (_e *Database_Expecter) GetStaleSendingSSEMessageLogs(before interface{}) *Database_GetStaleSendingSSEMessageLogs_Call {
+	return &Database_GetStaleSendingSSEMessageLogs_Call{Call: _e.mock.On("GetStaleSendingSSEMessageLogs", before)}
+}
+
+func (_c *Database_GetStaleSendingSSEMessageLogs_Call) Run(run func(before time.Time)) *Database_GetStaleSendingSSEMessageLogs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Time))
+	})
+	return _c
+}
+
+func (_c *Database_GetStaleSendingSSEMessageLogs_Call) Return(_a0 []db.SSEMessageLog, _a1 error) *Database_GetStaleSendingSSEMessageLogs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetStaleSendingSSEMessageLogs_Call) RunAndReturn(run func(time.Time) ([]db.SSEMessageLog, error)) *Database_GetStaleSendingSSEMessageLogs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetSumOfDeposits provides a mock function with given fields: workspace_uuid
+func (_m *Database) GetSumOfDeposits(workspace_uuid string) uint {
+	ret := _m.Called(workspace_uuid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSumOfDeposits")
+	}
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(string) uint); ok {
+		r0 = rf(workspace_uuid)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	return r0
+}
+
+// Database_GetSumOfDeposits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSumOfDeposits'
+type Database_GetSumOfDeposits_Call struct {
+	*mock.Call
+}
+
+// GetSumOfDeposits is a helper method to define mock.On call
 //   - workspace_uuid string
 func (_e *Database_Expecter) GetSumOfDeposits(workspace_uuid interface{}) *Database_GetSumOfDeposits_Call {
 	return &Database_GetSumOfDeposits_Call{Call: _e.mock.On("GetSumOfDeposits", workspace_uuid)}
@@ -10773,53 +12205,6 @@ func (_c *Database_GetTribesTotal_Call) RunAndReturn(run func() int64) *Database
 	return _c
 }
 
-// GetUnconfirmedGithub provides a mock function with no fields
-func (_m *Database) GetUnconfirmedGithub() []db.Person {
-	ret := _m.Called()
-
-	if len(ret) == 0 {
-		panic("no return value specified for GetUnconfirmedGithub")
-	}
-
-	var r0 []db.Person
-	if rf, ok := ret.Get(0).(func() []db.Person); ok {
-		r0 = rf()
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]db.Person)
-		}
-	}
-
-	return r0
-}
-
-// Database_GetUnconfirmedGithub_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUnconfirmedGithub'
-type Database_GetUnconfirmedGithub_Call struct {
-	*mock.Call
-}
-
-// GetUnconfirmedGithub is a helper method to define mock.On call
-func (_e *Database_Expecter) GetUnconfirmedGithub() *Database_GetUnconfirmedGithub_Call {
-	return &Database_GetUnconfirmedGithub_Call{Call: _e.mock.On("GetUnconfirmedGithub")}
-}
-
-func (_c *Database_GetUnconfirmedGithub_Call) Run(run func()) *Database_GetUnconfirmedGithub_Call {
-	_c.Call.Run(func(args mock.Arguments) {
-		run()
-	})
-	return _c
-}
-
-func (_c *Database_GetUnconfirmedGithub_Call) Return(_a0 []db.Person) *Database_GetUnconfirmedGithub_Call {
-	_c.Call.Return(_a0)
-	return _c
-}
-
-func (_c *Database_GetUnconfirmedGithub_Call) RunAndReturn(run func() []db.Person) *Database_GetUnconfirmedGithub_Call {
-	_c.Call.Return(run)
-	return _c
-}
-
 // GetUnconfirmedTwitter provides a mock function with no fields
 func (_m *Database) GetUnconfirmedTwitter() []db.Person {
 	ret := _m.Called()
@@ -11010,6 +12395,54 @@ func (_c *Database_GetUserCreatedWorkspaces_Call) RunAndReturn(run func(string)
 	return _c
 }
 
+// GetUserDeletedWorkspaces provides a mock function with given fields: pubkey
+func (_m *Database) GetUserDeletedWorkspaces(pubkey string) []db.Workspace {
+	ret := _m.Called(pubkey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserDeletedWorkspaces")
+	}
+
+	var r0 []db.Workspace
+	if rf, ok := ret.Get(0).(func(string) []db.Workspace); ok {
+		r0 = rf(pubkey)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.Workspace)
+		}
+	}
+
+	return r0
+}
+
+// Database_GetUserDeletedWorkspaces_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserDeletedWorkspaces'
+type Database_GetUserDeletedWorkspaces_Call struct {
+	*mock.Call
+}
+
+// GetUserDeletedWorkspaces is a helper method to define mock.On call
+//   - pubkey string
+func (_e *Database_Expecter) GetUserDeletedWorkspaces(pubkey interface{}) *Database_GetUserDeletedWorkspaces_Call {
+	return &Database_GetUserDeletedWorkspaces_Call{Call: _e.mock.On("GetUserDeletedWorkspaces", pubkey)}
+}
+
+func (_c *Database_GetUserDeletedWorkspaces_Call) Run(run func(pubkey string)) *Database_GetUserDeletedWorkspaces_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetUserDeletedWorkspaces_Call) Return(_a0 []db.Workspace) *Database_GetUserDeletedWorkspaces_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_GetUserDeletedWorkspaces_Call) RunAndReturn(run func(string) []db.Workspace) *Database_GetUserDeletedWorkspaces_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetUserInvoiceData provides a mock function with given fields: payment_request
 func (_m *Database) GetUserInvoiceData(payment_request string) db.UserInvoiceData {
 	ret := _m.Called(payment_request)
@@ -11105,6 +12538,187 @@ func (_c *Database_GetUserRoles_Call) RunAndReturn(run func(string, string) []db
 	return _c
 }
 
+// GetUserSession provides a mock function with given fields: id
+func (_m *Database) GetUserSession(id string) (db.UserSession, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserSession")
+	}
+
+	var r0 db.UserSession
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (db.UserSession, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) db.UserSession); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(db.UserSession)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetUserSession_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserSession'
+type Database_GetUserSession_Call struct {
+	*mock.Call
+}
+
+// GetUserSession is a helper method to define mock.On call
+//   - id string
+func (_e *Database_Expecter) GetUserSession(id interface{}) *Database_GetUserSession_Call {
+	return &Database_GetUserSession_Call{Call: _e.mock.On("GetUserSession", id)}
+}
+
+func (_c *Database_GetUserSession_Call) Run(run func(id string)) *Database_GetUserSession_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetUserSession_Call) Return(_a0 db.UserSession, _a1 error) *Database_GetUserSession_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetUserSession_Call) RunAndReturn(run func(string) (db.UserSession, error)) *Database_GetUserSession_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetWebhookDeadLetterByID provides a mock function with given fields: id
+func (_m *Database) GetWebhookDeadLetterByID(id uuid.UUID) (*db.WebhookDeadLetter, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhookDeadLetterByID")
+	}
+
+	var r0 *db.WebhookDeadLetter
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) (*db.WebhookDeadLetter, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID) *db.WebhookDeadLetter); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*db.WebhookDeadLetter)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetWebhookDeadLetterByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWebhookDeadLetterByID'
+type Database_GetWebhookDeadLetterByID_Call struct {
+	*mock.Call
+}
+
+// GetWebhookDeadLetterByID is a helper method to define mock.On call
+//   - id uuid.UUID
+func (_e *Database_Expecter) GetWebhookDeadLetterByID(id interface{}) *Database_GetWebhookDeadLetterByID_Call {
+	return &Database_GetWebhookDeadLetterByID_Call{Call: _e.mock.On("GetWebhookDeadLetterByID", id)}
+}
+
+func (_c *Database_GetWebhookDeadLetterByID_Call) Run(run func(id uuid.UUID)) *Database_GetWebhookDeadLetterByID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Database_GetWebhookDeadLetterByID_Call) Return(_a0 *db.WebhookDeadLetter, _a1 error) *Database_GetWebhookDeadLetterByID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetWebhookDeadLetterByID_Call) RunAndReturn(run func(uuid.UUID) (*db.WebhookDeadLetter, error)) *Database_GetWebhookDeadLetterByID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetWebhookDeadLetters provides a mock function with given fields: status, limit, offset
+func (_m *Database) GetWebhookDeadLetters(status string, limit int, offset int) ([]db.WebhookDeadLetter, int64, error) {
+	ret := _m.Called(status, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhookDeadLetters")
+	}
+
+	var r0 []db.WebhookDeadLetter
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string, int, int) ([]db.WebhookDeadLetter, int64, error)); ok {
+		return rf(status, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(string, int, int) []db.WebhookDeadLetter); ok {
+		r0 = rf(status, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.WebhookDeadLetter)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int, int) int64); ok {
+		r1 = rf(status, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(string, int, int) error); ok {
+		r2 = rf(status, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Database_GetWebhookDeadLetters_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWebhookDeadLetters'
+type Database_GetWebhookDeadLetters_Call struct {
+	*mock.Call
+}
+
+// GetWebhookDeadLetters is a helper method to define mock.On call
+//   - status string
+//   - limit int
+//   - offset int
+func (_e *Database_Expecter) GetWebhookDeadLetters(status interface{}, limit interface{}, offset interface{}) *Database_GetWebhookDeadLetters_Call {
+	return &Database_GetWebhookDeadLetters_Call{Call: _e.mock.On("GetWebhookDeadLetters", status, limit, offset)}
+}
+
+func (_c *Database_GetWebhookDeadLetters_Call) Run(run func(status string, limit int, offset int)) *Database_GetWebhookDeadLetters_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *Database_GetWebhookDeadLetters_Call) Return(_a0 []db.WebhookDeadLetter, _a1 int64, _a2 error) *Database_GetWebhookDeadLetters_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *Database_GetWebhookDeadLetters_Call) RunAndReturn(run func(string, int, int) ([]db.WebhookDeadLetter, int64, error)) *Database_GetWebhookDeadLetters_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetWorkflowRequest provides a mock function with given fields: requestID
 func (_m *Database) GetWorkflowRequest(requestID string) (*db.WfRequest, error) {
 	ret := _m.Called(requestID)
@@ -11337,26 +12951,196 @@ func (_c *Database_GetWorkflowRequestsByWorkflowID_Call) RunAndReturn(run func(s
 	return _c
 }
 
-// GetWorkspaceBounties provides a mock function with given fields: r, workspace_uuid
-func (_m *Database) GetWorkspaceBounties(r *http.Request, workspace_uuid string) []db.NewBounty {
-	ret := _m.Called(r, workspace_uuid)
+// GetWorkspaceAPIKeyByHash provides a mock function with given fields: hash
+func (_m *Database) GetWorkspaceAPIKeyByHash(hash string) (db.WorkspaceAPIKey, error) {
+	ret := _m.Called(hash)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetWorkspaceBounties")
+		panic("no return value specified for GetWorkspaceAPIKeyByHash")
 	}
 
-	var r0 []db.NewBounty
-	if rf, ok := ret.Get(0).(func(*http.Request, string) []db.NewBounty); ok {
-		r0 = rf(r, workspace_uuid)
+	var r0 db.WorkspaceAPIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (db.WorkspaceAPIKey, error)); ok {
+		return rf(hash)
+	}
+	if rf, ok := ret.Get(0).(func(string) db.WorkspaceAPIKey); ok {
+		r0 = rf(hash)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]db.NewBounty)
-		}
+		r0 = ret.Get(0).(db.WorkspaceAPIKey)
 	}
 
-	return r0
-}
-
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetWorkspaceAPIKeyByHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWorkspaceAPIKeyByHash'
+type Database_GetWorkspaceAPIKeyByHash_Call struct {
+	*mock.Call
+}
+
+// GetWorkspaceAPIKeyByHash is a helper method to define mock.On call
+//   - hash string
+func (_e *Database_Expecter) GetWorkspaceAPIKeyByHash(hash interface{}) *Database_GetWorkspaceAPIKeyByHash_Call {
+	return &Database_GetWorkspaceAPIKeyByHash_Call{Call: _e.mock.On("GetWorkspaceAPIKeyByHash", hash)}
+}
+
+func (_c *Database_GetWorkspaceAPIKeyByHash_Call) Run(run func(hash string)) *Database_GetWorkspaceAPIKeyByHash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetWorkspaceAPIKeyByHash_Call) Return(_a0 db.WorkspaceAPIKey, _a1 error) *Database_GetWorkspaceAPIKeyByHash_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetWorkspaceAPIKeyByHash_Call) RunAndReturn(run func(string) (db.WorkspaceAPIKey, error)) *Database_GetWorkspaceAPIKeyByHash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetWorkspaceAPIKeys provides a mock function with given fields: workspace_uuid
+func (_m *Database) GetWorkspaceAPIKeys(workspace_uuid string) ([]db.WorkspaceAPIKey, error) {
+	ret := _m.Called(workspace_uuid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWorkspaceAPIKeys")
+	}
+
+	var r0 []db.WorkspaceAPIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]db.WorkspaceAPIKey, error)); ok {
+		return rf(workspace_uuid)
+	}
+	if rf, ok := ret.Get(0).(func(string) []db.WorkspaceAPIKey); ok {
+		r0 = rf(workspace_uuid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.WorkspaceAPIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(workspace_uuid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetWorkspaceAPIKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWorkspaceAPIKeys'
+type Database_GetWorkspaceAPIKeys_Call struct {
+	*mock.Call
+}
+
+// GetWorkspaceAPIKeys is a helper method to define mock.On call
+//   - workspace_uuid string
+func (_e *Database_Expecter) GetWorkspaceAPIKeys(workspace_uuid interface{}) *Database_GetWorkspaceAPIKeys_Call {
+	return &Database_GetWorkspaceAPIKeys_Call{Call: _e.mock.On("GetWorkspaceAPIKeys", workspace_uuid)}
+}
+
+func (_c *Database_GetWorkspaceAPIKeys_Call) Run(run func(workspace_uuid string)) *Database_GetWorkspaceAPIKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetWorkspaceAPIKeys_Call) Return(_a0 []db.WorkspaceAPIKey, _a1 error) *Database_GetWorkspaceAPIKeys_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetWorkspaceAPIKeys_Call) RunAndReturn(run func(string) ([]db.WorkspaceAPIKey, error)) *Database_GetWorkspaceAPIKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetWorkspaceArchive provides a mock function with given fields: workspace_uuid
+func (_m *Database) GetWorkspaceArchive(workspace_uuid string) (db.WorkspaceArchive, error) {
+	ret := _m.Called(workspace_uuid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWorkspaceArchive")
+	}
+
+	var r0 db.WorkspaceArchive
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (db.WorkspaceArchive, error)); ok {
+		return rf(workspace_uuid)
+	}
+	if rf, ok := ret.Get(0).(func(string) db.WorkspaceArchive); ok {
+		r0 = rf(workspace_uuid)
+	} else {
+		r0 = ret.Get(0).(db.WorkspaceArchive)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(workspace_uuid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_GetWorkspaceArchive_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWorkspaceArchive'
+type Database_GetWorkspaceArchive_Call struct {
+	*mock.Call
+}
+
+// GetWorkspaceArchive is a helper method to define mock.On call
+//   - workspace_uuid string
+func (_e *Database_Expecter) GetWorkspaceArchive(workspace_uuid interface{}) *Database_GetWorkspaceArchive_Call {
+	return &Database_GetWorkspaceArchive_Call{Call: _e.mock.On("GetWorkspaceArchive", workspace_uuid)}
+}
+
+func (_c *Database_GetWorkspaceArchive_Call) Run(run func(workspace_uuid string)) *Database_GetWorkspaceArchive_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetWorkspaceArchive_Call) Return(_a0 db.WorkspaceArchive, _a1 error) *Database_GetWorkspaceArchive_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_GetWorkspaceArchive_Call) RunAndReturn(run func(string) (db.WorkspaceArchive, error)) *Database_GetWorkspaceArchive_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetWorkspaceBounties provides a mock function with given fields: r, workspace_uuid
+func (_m *Database) GetWorkspaceBounties(r *http.Request, workspace_uuid string) []db.NewBounty {
+	ret := _m.Called(r, workspace_uuid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWorkspaceBounties")
+	}
+
+	var r0 []db.NewBounty
+	if rf, ok := ret.Get(0).(func(*http.Request, string) []db.NewBounty); ok {
+		r0 = rf(r, workspace_uuid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.NewBounty)
+		}
+	}
+
+	return r0
+}
+
 // Database_GetWorkspaceBounties_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWorkspaceBounties'
 type Database_GetWorkspaceBounties_Call struct {
 	*mock.Call
@@ -11621,6 +13405,52 @@ func (_c *Database_GetWorkspaceBudgetHistory_Call) RunAndReturn(run func(string)
 	return _c
 }
 
+// GetWorkspaceByLightningAddress provides a mock function with given fields: name
+func (_m *Database) GetWorkspaceByLightningAddress(name string) db.Workspace {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWorkspaceByLightningAddress")
+	}
+
+	var r0 db.Workspace
+	if rf, ok := ret.Get(0).(func(string) db.Workspace); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Get(0).(db.Workspace)
+	}
+
+	return r0
+}
+
+// Database_GetWorkspaceByLightningAddress_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWorkspaceByLightningAddress'
+type Database_GetWorkspaceByLightningAddress_Call struct {
+	*mock.Call
+}
+
+// GetWorkspaceByLightningAddress is a helper method to define mock.On call
+//   - name string
+func (_e *Database_Expecter) GetWorkspaceByLightningAddress(name interface{}) *Database_GetWorkspaceByLightningAddress_Call {
+	return &Database_GetWorkspaceByLightningAddress_Call{Call: _e.mock.On("GetWorkspaceByLightningAddress", name)}
+}
+
+func (_c *Database_GetWorkspaceByLightningAddress_Call) Run(run func(name string)) *Database_GetWorkspaceByLightningAddress_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetWorkspaceByLightningAddress_Call) Return(_a0 db.Workspace) *Database_GetWorkspaceByLightningAddress_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_GetWorkspaceByLightningAddress_Call) RunAndReturn(run func(string) db.Workspace) *Database_GetWorkspaceByLightningAddress_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetWorkspaceByName provides a mock function with given fields: name
 func (_m *Database) GetWorkspaceByName(name string) db.Workspace {
 	ret := _m.Called(name)
@@ -12063,6 +13893,52 @@ func (_c *Database_GetWorkspaceRepositorByWorkspaceUuid_Call) RunAndReturn(run f
 	return _c
 }
 
+// GetWorkspaceReservedPayouts provides a mock function with given fields: workspaceUuid
+func (_m *Database) GetWorkspaceReservedPayouts(workspaceUuid string) uint {
+	ret := _m.Called(workspaceUuid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWorkspaceReservedPayouts")
+	}
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(string) uint); ok {
+		r0 = rf(workspaceUuid)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	return r0
+}
+
+// Database_GetWorkspaceReservedPayouts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWorkspaceReservedPayouts'
+type Database_GetWorkspaceReservedPayouts_Call struct {
+	*mock.Call
+}
+
+// GetWorkspaceReservedPayouts is a helper method to define mock.On call
+//   - workspaceUuid string
+func (_e *Database_Expecter) GetWorkspaceReservedPayouts(workspaceUuid interface{}) *Database_GetWorkspaceReservedPayouts_Call {
+	return &Database_GetWorkspaceReservedPayouts_Call{Call: _e.mock.On("GetWorkspaceReservedPayouts", workspaceUuid)}
+}
+
+func (_c *Database_GetWorkspaceReservedPayouts_Call) Run(run func(workspaceUuid string)) *Database_GetWorkspaceReservedPayouts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_GetWorkspaceReservedPayouts_Call) Return(_a0 uint) *Database_GetWorkspaceReservedPayouts_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_GetWorkspaceReservedPayouts_Call) RunAndReturn(run func(string) uint) *Database_GetWorkspaceReservedPayouts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetWorkspaceStatusBudget provides a mock function with given fields: workspace_uuid
 func (_m *Database) GetWorkspaceStatusBudget(workspace_uuid string) db.StatusBudget {
 	ret := _m.Called(workspace_uuid)
@@ -12353,50 +14229,65 @@ func (_c *Database_GetWorkspacesCount_Call) RunAndReturn(run func() int64) *Data
 	return _c
 }
 
-// IncrementNotificationRetry provides a mock function with given fields: notificationUUID
-func (_m *Database) IncrementNotificationRetry(notificationUUID string) {
-	_m.Called(notificationUUID)
+// GetWorkspacesPendingPurge provides a mock function with given fields: deletedBefore
+func (_m *Database) GetWorkspacesPendingPurge(deletedBefore time.Time) []db.Workspace {
+	ret := _m.Called(deletedBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWorkspacesPendingPurge")
+	}
+
+	var r0 []db.Workspace
+	if rf, ok := ret.Get(0).(func(time.Time) []db.Workspace); ok {
+		r0 = rf(deletedBefore)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.Workspace)
+		}
+	}
+
+	return r0
 }
 
-// Database_IncrementNotificationRetry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IncrementNotificationRetry'
-type Database_IncrementNotificationRetry_Call struct {
+// Database_GetWorkspacesPendingPurge_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWorkspacesPendingPurge'
+type Database_GetWorkspacesPendingPurge_Call struct {
 	*mock.Call
 }
 
-// IncrementNotificationRetry is a helper method to define mock.On call
-//   - notificationUUID string
-func (_e *Database_Expecter) IncrementNotificationRetry(notificationUUID interface{}) *Database_IncrementNotificationRetry_Call {
-	return &Database_IncrementNotificationRetry_Call{Call: _e.mock.On("IncrementNotificationRetry", notificationUUID)}
+// GetWorkspacesPendingPurge is a helper method to define mock.On call
+//   - deletedBefore time.Time
+func (_e *Database_Expecter) GetWorkspacesPendingPurge(deletedBefore interface{}) *Database_GetWorkspacesPendingPurge_Call {
+	return &Database_GetWorkspacesPendingPurge_Call{Call: _e.mock.On("GetWorkspacesPendingPurge", deletedBefore)}
 }
 
-func (_c *Database_IncrementNotificationRetry_Call) Run(run func(notificationUUID string)) *Database_IncrementNotificationRetry_Call {
+func (_c *Database_GetWorkspacesPendingPurge_Call) Run(run func(deletedBefore time.Time)) *Database_GetWorkspacesPendingPurge_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(time.Time))
 	})
 	return _c
 }
 
-func (_c *Database_IncrementNotificationRetry_Call) Return() *Database_IncrementNotificationRetry_Call {
-	_c.Call.Return()
+func (_c *Database_GetWorkspacesPendingPurge_Call) Return(_a0 []db.Workspace) *Database_GetWorkspacesPendingPurge_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *Database_IncrementNotificationRetry_Call) RunAndReturn(run func(string)) *Database_IncrementNotificationRetry_Call {
-	_c.Run(run)
+func (_c *Database_GetWorkspacesPendingPurge_Call) RunAndReturn(run func(time.Time) []db.Workspace) *Database_GetWorkspacesPendingPurge_Call {
+	_c.Call.Return(run)
 	return _c
 }
 
-// IncrementProofCount provides a mock function with given fields: bountyID
-func (_m *Database) IncrementProofCount(bountyID uint) error {
-	ret := _m.Called(bountyID)
+// ImportWorkspaceArchive provides a mock function with given fields: archive
+func (_m *Database) ImportWorkspaceArchive(archive db.WorkspaceArchive) error {
+	ret := _m.Called(archive)
 
 	if len(ret) == 0 {
-		panic("no return value specified for IncrementProofCount")
+		panic("no return value specified for ImportWorkspaceArchive")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uint) error); ok {
-		r0 = rf(bountyID)
+	if rf, ok := ret.Get(0).(func(db.WorkspaceArchive) error); ok {
+		r0 = rf(archive)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -12404,41 +14295,120 @@ func (_m *Database) IncrementProofCount(bountyID uint) error {
 	return r0
 }
 
-// Database_IncrementProofCount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IncrementProofCount'
-type Database_IncrementProofCount_Call struct {
+// Database_ImportWorkspaceArchive_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ImportWorkspaceArchive'
+type Database_ImportWorkspaceArchive_Call struct {
 	*mock.Call
 }
 
-// IncrementProofCount is a helper method to define mock.On call
-//   - bountyID uint
-func (_e *Database_Expecter) IncrementProofCount(bountyID interface{}) *Database_IncrementProofCount_Call {
-	return &Database_IncrementProofCount_Call{Call: _e.mock.On("IncrementProofCount", bountyID)}
+// ImportWorkspaceArchive is a helper method to define mock.On call
+//   - archive db.WorkspaceArchive
+func (_e *Database_Expecter) ImportWorkspaceArchive(archive interface{}) *Database_ImportWorkspaceArchive_Call {
+	return &Database_ImportWorkspaceArchive_Call{Call: _e.mock.On("ImportWorkspaceArchive", archive)}
 }
 
-func (_c *Database_IncrementProofCount_Call) Run(run func(bountyID uint)) *Database_IncrementProofCount_Call {
+func (_c *Database_ImportWorkspaceArchive_Call) Run(run func(archive db.WorkspaceArchive)) *Database_ImportWorkspaceArchive_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(uint))
+		run(args[0].(db.WorkspaceArchive))
 	})
 	return _c
 }
 
-func (_c *Database_IncrementProofCount_Call) Return(_a0 error) *Database_IncrementProofCount_Call {
+func (_c *Database_ImportWorkspaceArchive_Call) Return(_a0 error) *Database_ImportWorkspaceArchive_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *Database_IncrementProofCount_Call) RunAndReturn(run func(uint) error) *Database_IncrementProofCount_Call {
+func (_c *Database_ImportWorkspaceArchive_Call) RunAndReturn(run func(db.WorkspaceArchive) error) *Database_ImportWorkspaceArchive_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// IncrementRetryCount provides a mock function with given fields: _a0
-func (_m *Database) IncrementRetryCount(_a0 string) error {
-	ret := _m.Called(_a0)
+// IncrementNotificationRetry provides a mock function with given fields: notificationUUID
+func (_m *Database) IncrementNotificationRetry(notificationUUID string) {
+	_m.Called(notificationUUID)
+}
 
-	if len(ret) == 0 {
-		panic("no return value specified for IncrementRetryCount")
-	}
+// Database_IncrementNotificationRetry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IncrementNotificationRetry'
+type Database_IncrementNotificationRetry_Call struct {
+	*mock.Call
+}
+
+// IncrementNotificationRetry is a helper method to define mock.On call
+//   - notificationUUID string
+func (_e *Database_Expecter) IncrementNotificationRetry(notificationUUID interface{}) *Database_IncrementNotificationRetry_Call {
+	return &Database_IncrementNotificationRetry_Call{Call: _e.mock.On("IncrementNotificationRetry", notificationUUID)}
+}
+
+func (_c *Database_IncrementNotificationRetry_Call) Run(run func(notificationUUID string)) *Database_IncrementNotificationRetry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_IncrementNotificationRetry_Call) Return() *Database_IncrementNotificationRetry_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Database_IncrementNotificationRetry_Call) RunAndReturn(run func(string)) *Database_IncrementNotificationRetry_Call {
+	_c.Run(run)
+	return _c
+}
+
+// IncrementProofCount provides a mock function with given fields: bountyID
+func (_m *Database) IncrementProofCount(bountyID uint) error {
+	ret := _m.Called(bountyID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrementProofCount")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint) error); ok {
+		r0 = rf(bountyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_IncrementProofCount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IncrementProofCount'
+type Database_IncrementProofCount_Call struct {
+	*mock.Call
+}
+
+// IncrementProofCount is a helper method to define mock.On call
+//   - bountyID uint
+func (_e *Database_Expecter) IncrementProofCount(bountyID interface{}) *Database_IncrementProofCount_Call {
+	return &Database_IncrementProofCount_Call{Call: _e.mock.On("IncrementProofCount", bountyID)}
+}
+
+func (_c *Database_IncrementProofCount_Call) Run(run func(bountyID uint)) *Database_IncrementProofCount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uint))
+	})
+	return _c
+}
+
+func (_c *Database_IncrementProofCount_Call) Return(_a0 error) *Database_IncrementProofCount_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_IncrementProofCount_Call) RunAndReturn(run func(uint) error) *Database_IncrementProofCount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IncrementRetryCount provides a mock function with given fields: _a0
+func (_m *Database) IncrementRetryCount(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrementRetryCount")
+	}
 
 	var r0 error
 	if rf, ok := ret.Get(0).(func(string) error); ok {
@@ -12478,6 +14448,54 @@ func (_c *Database_IncrementRetryCount_Call) RunAndReturn(run func(string) error
 	return _c
 }
 
+// IsTokenRevoked provides a mock function with given fields: pubkey, sessionID, issuedAt
+func (_m *Database) IsTokenRevoked(pubkey string, sessionID string, issuedAt time.Time) bool {
+	ret := _m.Called(pubkey, sessionID, issuedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsTokenRevoked")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, string, time.Time) bool); ok {
+		r0 = rf(pubkey, sessionID, issuedAt)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Database_IsTokenRevoked_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsTokenRevoked'
+type Database_IsTokenRevoked_Call struct {
+	*mock.Call
+}
+
+// IsTokenRevoked is a helper method to define mock.On call
+//   - pubkey string
+//   - sessionID string
+//   - issuedAt time.Time
+func (_e *Database_Expecter) IsTokenRevoked(pubkey interface{}, sessionID interface{}, issuedAt interface{}) *Database_IsTokenRevoked_Call {
+	return &Database_IsTokenRevoked_Call{Call: _e.mock.On("IsTokenRevoked", pubkey, sessionID, issuedAt)}
+}
+
+func (_c *Database_IsTokenRevoked_Call) Run(run func(pubkey string, sessionID string, issuedAt time.Time)) *Database_IsTokenRevoked_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *Database_IsTokenRevoked_Call) Return(_a0 bool) *Database_IsTokenRevoked_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_IsTokenRevoked_Call) RunAndReturn(run func(string, string, time.Time) bool) *Database_IsTokenRevoked_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ListFileAssets provides a mock function with given fields: params
 func (_m *Database) ListFileAssets(params db.ListFileAssetsParams) ([]db.FileAsset, int64, error) {
 	ret := _m.Called(params)
@@ -12543,6 +14561,110 @@ func (_c *Database_ListFileAssets_Call) RunAndReturn(run func(db.ListFileAssetsP
 	return _c
 }
 
+// MarkInvoicePaid provides a mock function with given fields: payment_request
+func (_m *Database) MarkInvoicePaid(payment_request string) (bool, error) {
+	ret := _m.Called(payment_request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkInvoicePaid")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+		return rf(payment_request)
+	}
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(payment_request)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(payment_request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_MarkInvoicePaid_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkInvoicePaid'
+type Database_MarkInvoicePaid_Call struct {
+	*mock.Call
+}
+
+// MarkInvoicePaid is a helper method to define mock.On call
+//   - payment_request string
+func (_e *Database_Expecter) MarkInvoicePaid(payment_request interface{}) *Database_MarkInvoicePaid_Call {
+	return &Database_MarkInvoicePaid_Call{Call: _e.mock.On("MarkInvoicePaid", payment_request)}
+}
+
+func (_c *Database_MarkInvoicePaid_Call) Run(run func(payment_request string)) *Database_MarkInvoicePaid_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_MarkInvoicePaid_Call) Return(_a0 bool, _a1 error) *Database_MarkInvoicePaid_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_MarkInvoicePaid_Call) RunAndReturn(run func(string) (bool, error)) *Database_MarkInvoicePaid_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MergePeople provides a mock function with given fields: sourcePubkey, targetPubkey, sourceIdentityType
+func (_m *Database) MergePeople(sourcePubkey string, targetPubkey string, sourceIdentityType string) error {
+	ret := _m.Called(sourcePubkey, targetPubkey, sourceIdentityType)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MergePeople")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(sourcePubkey, targetPubkey, sourceIdentityType)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_MergePeople_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MergePeople'
+type Database_MergePeople_Call struct {
+	*mock.Call
+}
+
+// MergePeople is a helper method to define mock.On call
+//   - sourcePubkey string
+//   - targetPubkey string
+//   - sourceIdentityType string
+func (_e *Database_Expecter) MergePeople(sourcePubkey interface{}, targetPubkey interface{}, sourceIdentityType interface{}) *Database_MergePeople_Call {
+	return &Database_MergePeople_Call{Call: _e.mock.On("MergePeople", sourcePubkey, targetPubkey, sourceIdentityType)}
+}
+
+func (_c *Database_MergePeople_Call) Run(run func(sourcePubkey string, targetPubkey string, sourceIdentityType string)) *Database_MergePeople_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Database_MergePeople_Call) Return(_a0 error) *Database_MergePeople_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_MergePeople_Call) RunAndReturn(run func(string, string, string) error) *Database_MergePeople_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewHuntersPaid provides a mock function with given fields: r, workspace
 func (_m *Database) NewHuntersPaid(r db.PaymentDateRange, workspace string) int64 {
 	ret := _m.Called(r, workspace)
@@ -13036,17 +15158,17 @@ func (_c *Database_ProcessUpdateTicketsWithoutGroup_Call) RunAndReturn(run func(
 	return _c
 }
 
-// ResumeBountyTiming provides a mock function with given fields: bountyID
-func (_m *Database) ResumeBountyTiming(bountyID uint) error {
-	ret := _m.Called(bountyID)
+// PurgeWorkspace provides a mock function with given fields: workspace_uuid
+func (_m *Database) PurgeWorkspace(workspace_uuid string) error {
+	ret := _m.Called(workspace_uuid)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ResumeBountyTiming")
+		panic("no return value specified for PurgeWorkspace")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uint) error); ok {
-		r0 = rf(bountyID)
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(workspace_uuid)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -13054,111 +15176,506 @@ func (_m *Database) ResumeBountyTiming(bountyID uint) error {
 	return r0
 }
 
-// Database_ResumeBountyTiming_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResumeBountyTiming'
-type Database_ResumeBountyTiming_Call struct {
+// Database_PurgeWorkspace_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PurgeWorkspace'
+type Database_PurgeWorkspace_Call struct {
 	*mock.Call
 }
 
-// ResumeBountyTiming is a helper method to define mock.On call
-//   - bountyID uint
-func (_e *Database_Expecter) ResumeBountyTiming(bountyID interface{}) *Database_ResumeBountyTiming_Call {
-	return &Database_ResumeBountyTiming_Call{Call: _e.mock.On("ResumeBountyTiming", bountyID)}
+// PurgeWorkspace is a helper method to define mock.On call
+//   - workspace_uuid string
+func (_e *Database_Expecter) PurgeWorkspace(workspace_uuid interface{}) *Database_PurgeWorkspace_Call {
+	return &Database_PurgeWorkspace_Call{Call: _e.mock.On("PurgeWorkspace", workspace_uuid)}
 }
 
-func (_c *Database_ResumeBountyTiming_Call) Run(run func(bountyID uint)) *Database_ResumeBountyTiming_Call {
+func (_c *Database_PurgeWorkspace_Call) Run(run func(workspace_uuid string)) *Database_PurgeWorkspace_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(uint))
+		run(args[0].(string))
 	})
 	return _c
 }
 
-func (_c *Database_ResumeBountyTiming_Call) Return(_a0 error) *Database_ResumeBountyTiming_Call {
+func (_c *Database_PurgeWorkspace_Call) Return(_a0 error) *Database_PurgeWorkspace_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *Database_ResumeBountyTiming_Call) RunAndReturn(run func(uint) error) *Database_ResumeBountyTiming_Call {
+func (_c *Database_PurgeWorkspace_Call) RunAndReturn(run func(string) error) *Database_PurgeWorkspace_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SatsPaidPercentage provides a mock function with given fields: r, workspace
-func (_m *Database) SatsPaidPercentage(r db.PaymentDateRange, workspace string) uint {
-	ret := _m.Called(r, workspace)
+// ReleaseSSESubscriptions provides a mock function with given fields: owner
+func (_m *Database) ReleaseSSESubscriptions(owner string) error {
+	ret := _m.Called(owner)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SatsPaidPercentage")
+		panic("no return value specified for ReleaseSSESubscriptions")
 	}
 
-	var r0 uint
-	if rf, ok := ret.Get(0).(func(db.PaymentDateRange, string) uint); ok {
-		r0 = rf(r, workspace)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(owner)
 	} else {
-		r0 = ret.Get(0).(uint)
+		r0 = ret.Error(0)
 	}
 
 	return r0
 }
 
-// Database_SatsPaidPercentage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SatsPaidPercentage'
-type Database_SatsPaidPercentage_Call struct {
+// Database_ReleaseSSESubscriptions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReleaseSSESubscriptions'
+type Database_ReleaseSSESubscriptions_Call struct {
 	*mock.Call
 }
 
-// SatsPaidPercentage is a helper method to define mock.On call
-//   - r db.PaymentDateRange
-//   - workspace string
-func (_e *Database_Expecter) SatsPaidPercentage(r interface{}, workspace interface{}) *Database_SatsPaidPercentage_Call {
-	return &Database_SatsPaidPercentage_Call{Call: _e.mock.On("SatsPaidPercentage", r, workspace)}
+// ReleaseSSESubscriptions is a helper method to define mock.On call
+//   - owner string
+func (_e *Database_Expecter) ReleaseSSESubscriptions(owner interface{}) *Database_ReleaseSSESubscriptions_Call {
+	return &Database_ReleaseSSESubscriptions_Call{Call: _e.mock.On("ReleaseSSESubscriptions", owner)}
 }
 
-func (_c *Database_SatsPaidPercentage_Call) Run(run func(r db.PaymentDateRange, workspace string)) *Database_SatsPaidPercentage_Call {
+func (_c *Database_ReleaseSSESubscriptions_Call) Run(run func(owner string)) *Database_ReleaseSSESubscriptions_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(db.PaymentDateRange), args[1].(string))
+		run(args[0].(string))
 	})
 	return _c
 }
 
-func (_c *Database_SatsPaidPercentage_Call) Return(_a0 uint) *Database_SatsPaidPercentage_Call {
+func (_c *Database_ReleaseSSESubscriptions_Call) Return(_a0 error) *Database_ReleaseSSESubscriptions_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *Database_SatsPaidPercentage_Call) RunAndReturn(run func(db.PaymentDateRange, string) uint) *Database_SatsPaidPercentage_Call {
+func (_c *Database_ReleaseSSESubscriptions_Call) RunAndReturn(run func(string) error) *Database_ReleaseSSESubscriptions_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SaveNotification provides a mock function with given fields: pubkey, event, content, status
-func (_m *Database) SaveNotification(pubkey string, event string, content string, status string) error {
-	ret := _m.Called(pubkey, event, content, status)
+// ResetStaleWebhookDeadLetters provides a mock function with given fields: before
+func (_m *Database) ResetStaleWebhookDeadLetters(before time.Time) (int64, error) {
+	ret := _m.Called(before)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SaveNotification")
+		panic("no return value specified for ResetStaleWebhookDeadLetters")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, string, string) error); ok {
-		r0 = rf(pubkey, event, content, status)
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time) (int64, error)); ok {
+		return rf(before)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time) int64); ok {
+		r0 = rf(before)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(int64)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// Database_SaveNotification_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveNotification'
-type Database_SaveNotification_Call struct {
+// Database_ResetStaleWebhookDeadLetters_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResetStaleWebhookDeadLetters'
+type Database_ResetStaleWebhookDeadLetters_Call struct {
 	*mock.Call
 }
 
-// SaveNotification is a helper method to define mock.On call
-//   - pubkey string
-//   - event string
-//   - content string
-//   - status string
-func (_e *Database_Expecter) SaveNotification(pubkey interface{}, event interface{}, content interface{}, status interface{}) *Database_SaveNotification_Call {
-	return &Database_SaveNotification_Call{Call: _e.mock.On("SaveNotification", pubkey, event, content, status)}
+// ResetStaleWebhookDeadLetters is a helper method to define mock.On call
+//   - before time.Time
+func (_e *Database_Expecter) ResetStaleWebhookDeadLetters(before interface{}) *Database_ResetStaleWebhookDeadLetters_Call {
+	return &Database_ResetStaleWebhookDeadLetters_Call{Call: _e.mock.On("ResetStaleWebhookDeadLetters", before)}
+}
+
+func (_c *Database_ResetStaleWebhookDeadLetters_Call) Run(run func(before time.Time)) *Database_ResetStaleWebhookDeadLetters_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Time))
+	})
+	return _c
+}
+
+func (_c *Database_ResetStaleWebhookDeadLetters_Call) Return(_a0 int64, _a1 error) *Database_ResetStaleWebhookDeadLetters_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_ResetStaleWebhookDeadLetters_Call) RunAndReturn(run func(time.Time) (int64, error)) *Database_ResetStaleWebhookDeadLetters_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RestoreWorkspace provides a mock function with given fields: workspace_uuid
+func (_m *Database) RestoreWorkspace(workspace_uuid string) (db.Workspace, error) {
+	ret := _m.Called(workspace_uuid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreWorkspace")
+	}
+
+	var r0 db.Workspace
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (db.Workspace, error)); ok {
+		return rf(workspace_uuid)
+	}
+	if rf, ok := ret.Get(0).(func(string) db.Workspace); ok {
+		r0 = rf(workspace_uuid)
+	} else {
+		r0 = ret.Get(0).(db.Workspace)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(workspace_uuid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_RestoreWorkspace_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RestoreWorkspace'
+type Database_RestoreWorkspace_Call struct {
+	*mock.Call
+}
+
+// RestoreWorkspace is a helper method to define mock.On call
+//   - workspace_uuid string
+func (_e *Database_Expecter) RestoreWorkspace(workspace_uuid interface{}) *Database_RestoreWorkspace_Call {
+	return &Database_RestoreWorkspace_Call{Call: _e.mock.On("RestoreWorkspace", workspace_uuid)}
+}
+
+func (_c *Database_RestoreWorkspace_Call) Run(run func(workspace_uuid string)) *Database_RestoreWorkspace_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_RestoreWorkspace_Call) Return(_a0 db.Workspace, _a1 error) *Database_RestoreWorkspace_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_RestoreWorkspace_Call) RunAndReturn(run func(string) (db.Workspace, error)) *Database_RestoreWorkspace_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResumeBountyTiming provides a mock function with given fields: bountyID
+func (_m *Database) ResumeBountyTiming(bountyID uint) error {
+	ret := _m.Called(bountyID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResumeBountyTiming")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint) error); ok {
+		r0 = rf(bountyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_ResumeBountyTiming_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResumeBountyTiming'
+type Database_ResumeBountyTiming_Call struct {
+	*mock.Call
+}
+
+// ResumeBountyTiming is a helper method to define mock.On call
+//   - bountyID uint
+func (_e *Database_Expecter) ResumeBountyTiming(bountyID interface{}) *Database_ResumeBountyTiming_Call {
+	return &Database_ResumeBountyTiming_Call{Call: _e.mock.On("ResumeBountyTiming", bountyID)}
+}
+
+func (_c *Database_ResumeBountyTiming_Call) Run(run func(bountyID uint)) *Database_ResumeBountyTiming_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uint))
+	})
+	return _c
+}
+
+func (_c *Database_ResumeBountyTiming_Call) Return(_a0 error) *Database_ResumeBountyTiming_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_ResumeBountyTiming_Call) RunAndReturn(run func(uint) error) *Database_ResumeBountyTiming_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeAllUserSessions provides a mock function with given fields: pubkey, reason
+func (_m *Database) RevokeAllUserSessions(pubkey string, reason string) error {
+	ret := _m.Called(pubkey, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAllUserSessions")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(pubkey, reason)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_RevokeAllUserSessions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeAllUserSessions'
+type Database_RevokeAllUserSessions_Call struct {
+	*mock.Call
+}
+
+// RevokeAllUserSessions is a helper method to define mock.On call
+//   - pubkey string
+//   - reason string
+func (_e *Database_Expecter) RevokeAllUserSessions(pubkey interface{}, reason interface{}) *Database_RevokeAllUserSessions_Call {
+	return &Database_RevokeAllUserSessions_Call{Call: _e.mock.On("RevokeAllUserSessions", pubkey, reason)}
+}
+
+func (_c *Database_RevokeAllUserSessions_Call) Run(run func(pubkey string, reason string)) *Database_RevokeAllUserSessions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Database_RevokeAllUserSessions_Call) Return(_a0 error) *Database_RevokeAllUserSessions_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_RevokeAllUserSessions_Call) RunAndReturn(run func(string, string) error) *Database_RevokeAllUserSessions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeUserSession provides a mock function with given fields: pubkey, id, reason
+func (_m *Database) RevokeUserSession(pubkey string, id string, reason string) error {
+	ret := _m.Called(pubkey, id, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeUserSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(pubkey, id, reason)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_RevokeUserSession_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeUserSession'
+type Database_RevokeUserSession_Call struct {
+	*mock.Call
+}
+
+// RevokeUserSession is a helper method to define mock.On call
+//   - pubkey string
+//   - id string
+//   - reason string
+func (_e *Database_Expecter) RevokeUserSession(pubkey interface{}, id interface{}, reason interface{}) *Database_RevokeUserSession_Call {
+	return &Database_RevokeUserSession_Call{Call: _e.mock.On("RevokeUserSession", pubkey, id, reason)}
+}
+
+func (_c *Database_RevokeUserSession_Call) Run(run func(pubkey string, id string, reason string)) *Database_RevokeUserSession_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Database_RevokeUserSession_Call) Return(_a0 error) *Database_RevokeUserSession_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_RevokeUserSession_Call) RunAndReturn(run func(string, string, string) error) *Database_RevokeUserSession_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeWorkspaceAPIKey provides a mock function with given fields: workspace_uuid, id
+func (_m *Database) RevokeWorkspaceAPIKey(workspace_uuid string, id string) error {
+	ret := _m.Called(workspace_uuid, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeWorkspaceAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(workspace_uuid, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_RevokeWorkspaceAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeWorkspaceAPIKey'
+type Database_RevokeWorkspaceAPIKey_Call struct {
+	*mock.Call
+}
+
+// RevokeWorkspaceAPIKey is a helper method to define mock.On call
+//   - workspace_uuid string
+//   - id string
+func (_e *Database_Expecter) RevokeWorkspaceAPIKey(workspace_uuid interface{}, id interface{}) *Database_RevokeWorkspaceAPIKey_Call {
+	return &Database_RevokeWorkspaceAPIKey_Call{Call: _e.mock.On("RevokeWorkspaceAPIKey", workspace_uuid, id)}
+}
+
+func (_c *Database_RevokeWorkspaceAPIKey_Call) Run(run func(workspace_uuid string, id string)) *Database_RevokeWorkspaceAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Database_RevokeWorkspaceAPIKey_Call) Return(_a0 error) *Database_RevokeWorkspaceAPIKey_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_RevokeWorkspaceAPIKey_Call) RunAndReturn(run func(string, string) error) *Database_RevokeWorkspaceAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RotateUserSession provides a mock function with given fields: id, oldHash, newHash, expiresAt
+func (_m *Database) RotateUserSession(id string, oldHash string, newHash string, expiresAt time.Time) error {
+	ret := _m.Called(id, oldHash, newHash, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RotateUserSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, time.Time) error); ok {
+		r0 = rf(id, oldHash, newHash, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_RotateUserSession_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RotateUserSession'
+type Database_RotateUserSession_Call struct {
+	*mock.Call
+}
+
+// RotateUserSession is a helper method to define mock.On call
+//   - id string
+//   - oldHash string
+//   - newHash string
+//   - expiresAt time.Time
+func (_e *Database_Expecter) RotateUserSession(id interface{}, oldHash interface{}, newHash interface{}, expiresAt interface{}) *Database_RotateUserSession_Call {
+	return &Database_RotateUserSession_Call{Call: _e.mock.On("RotateUserSession", id, oldHash, newHash, expiresAt)}
+}
+
+func (_c *Database_RotateUserSession_Call) Run(run func(id string, oldHash string, newHash string, expiresAt time.Time)) *Database_RotateUserSession_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(string), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *Database_RotateUserSession_Call) Return(_a0 error) *Database_RotateUserSession_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_RotateUserSession_Call) RunAndReturn(run func(string, string, string, time.Time) error) *Database_RotateUserSession_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SatsPaidPercentage provides a mock function with given fields: r, workspace
+func (_m *Database) SatsPaidPercentage(r db.PaymentDateRange, workspace string) uint {
+	ret := _m.Called(r, workspace)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SatsPaidPercentage")
+	}
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(db.PaymentDateRange, string) uint); ok {
+		r0 = rf(r, workspace)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	return r0
+}
+
+// Database_SatsPaidPercentage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SatsPaidPercentage'
+type Database_SatsPaidPercentage_Call struct {
+	*mock.Call
+}
+
+// SatsPaidPercentage is a helper method to define mock.On call
+//   - r db.PaymentDateRange
+//   - workspace string
+func (_e *Database_Expecter) SatsPaidPercentage(r interface{}, workspace interface{}) *Database_SatsPaidPercentage_Call {
+	return &Database_SatsPaidPercentage_Call{Call: _e.mock.On("SatsPaidPercentage", r, workspace)}
+}
+
+func (_c *Database_SatsPaidPercentage_Call) Run(run func(r db.PaymentDateRange, workspace string)) *Database_SatsPaidPercentage_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(db.PaymentDateRange), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Database_SatsPaidPercentage_Call) Return(_a0 uint) *Database_SatsPaidPercentage_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_SatsPaidPercentage_Call) RunAndReturn(run func(db.PaymentDateRange, string) uint) *Database_SatsPaidPercentage_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveNotification provides a mock function with given fields: pubkey, event, content, status
+func (_m *Database) SaveNotification(pubkey string, event string, content string, status string) error {
+	ret := _m.Called(pubkey, event, content, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveNotification")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, string) error); ok {
+		r0 = rf(pubkey, event, content, status)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_SaveNotification_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveNotification'
+type Database_SaveNotification_Call struct {
+	*mock.Call
+}
+
+// SaveNotification is a helper method to define mock.On call
+//   - pubkey string
+//   - event string
+//   - content string
+//   - status string
+func (_e *Database_Expecter) SaveNotification(pubkey interface{}, event interface{}, content interface{}, status interface{}) *Database_SaveNotification_Call {
+	return &Database_SaveNotification_Call{Call: _e.mock.On("SaveNotification", pubkey, event, content, status)}
 }
 
 func (_c *Database_SaveNotification_Call) Run(run func(pubkey string, event string, content string, status string)) *Database_SaveNotification_Call {
@@ -13178,6 +15695,122 @@ func (_c *Database_SaveNotification_Call) RunAndReturn(run func(string, string,
 	return _c
 }
 
+// SaveSSESubscription provides a mock function with given fields: subscription
+func (_m *Database) SaveSSESubscription(subscription *db.SSESubscription) (*db.SSESubscription, error) {
+	ret := _m.Called(subscription)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveSSESubscription")
+	}
+
+	var r0 *db.SSESubscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*db.SSESubscription) (*db.SSESubscription, error)); ok {
+		return rf(subscription)
+	}
+	if rf, ok := ret.Get(0).(func(*db.SSESubscription) *db.SSESubscription); ok {
+		r0 = rf(subscription)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*db.SSESubscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*db.SSESubscription) error); ok {
+		r1 = rf(subscription)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_SaveSSESubscription_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveSSESubscription'
+type Database_SaveSSESubscription_Call struct {
+	*mock.Call
+}
+
+// SaveSSESubscription is a helper method to define mock.On call
+//   - subscription *db.SSESubscription
+func (_e *Database_Expecter) SaveSSESubscription(subscription interface{}) *Database_SaveSSESubscription_Call {
+	return &Database_SaveSSESubscription_Call{Call: _e.mock.On("SaveSSESubscription", subscription)}
+}
+
+func (_c *Database_SaveSSESubscription_Call) Run(run func(subscription *db.SSESubscription)) *Database_SaveSSESubscription_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*db.SSESubscription))
+	})
+	return _c
+}
+
+func (_c *Database_SaveSSESubscription_Call) Return(_a0 *db.SSESubscription, _a1 error) *Database_SaveSSESubscription_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_SaveSSESubscription_Call) RunAndReturn(run func(*db.SSESubscription) (*db.SSESubscription, error)) *Database_SaveSSESubscription_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveWebhookDeadLetter provides a mock function with given fields: letter
+func (_m *Database) SaveWebhookDeadLetter(letter *db.WebhookDeadLetter) (*db.WebhookDeadLetter, error) {
+	ret := _m.Called(letter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveWebhookDeadLetter")
+	}
+
+	var r0 *db.WebhookDeadLetter
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*db.WebhookDeadLetter) (*db.WebhookDeadLetter, error)); ok {
+		return rf(letter)
+	}
+	if rf, ok := ret.Get(0).(func(*db.WebhookDeadLetter) *db.WebhookDeadLetter); ok {
+		r0 = rf(letter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*db.WebhookDeadLetter)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*db.WebhookDeadLetter) error); ok {
+		r1 = rf(letter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_SaveWebhookDeadLetter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveWebhookDeadLetter'
+type Database_SaveWebhookDeadLetter_Call struct {
+	*mock.Call
+}
+
+// SaveWebhookDeadLetter is a helper method to define mock.On call
+//   - letter *db.WebhookDeadLetter
+func (_e *Database_Expecter) SaveWebhookDeadLetter(letter interface{}) *Database_SaveWebhookDeadLetter_Call {
+	return &Database_SaveWebhookDeadLetter_Call{Call: _e.mock.On("SaveWebhookDeadLetter", letter)}
+}
+
+func (_c *Database_SaveWebhookDeadLetter_Call) Run(run func(letter *db.WebhookDeadLetter)) *Database_SaveWebhookDeadLetter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*db.WebhookDeadLetter))
+	})
+	return _c
+}
+
+func (_c *Database_SaveWebhookDeadLetter_Call) Return(_a0 *db.WebhookDeadLetter, _a1 error) *Database_SaveWebhookDeadLetter_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_SaveWebhookDeadLetter_Call) RunAndReturn(run func(*db.WebhookDeadLetter) (*db.WebhookDeadLetter, error)) *Database_SaveWebhookDeadLetter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // SearchBots provides a mock function with given fields: s, limit, offset
 func (_m *Database) SearchBots(s string, limit int, offset int) []db.BotRes {
 	ret := _m.Called(s, limit, offset)
@@ -13228,6 +15861,71 @@ func (_c *Database_SearchBots_Call) RunAndReturn(run func(string, int, int) []db
 	return _c
 }
 
+// SearchChatHistory provides a mock function with given fields: params
+func (_m *Database) SearchChatHistory(params db.ChatSearchParams) ([]db.ChatSearchResult, int64, error) {
+	ret := _m.Called(params)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchChatHistory")
+	}
+
+	var r0 []db.ChatSearchResult
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(db.ChatSearchParams) ([]db.ChatSearchResult, int64, error)); ok {
+		return rf(params)
+	}
+	if rf, ok := ret.Get(0).(func(db.ChatSearchParams) []db.ChatSearchResult); ok {
+		r0 = rf(params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.ChatSearchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(db.ChatSearchParams) int64); ok {
+		r1 = rf(params)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(db.ChatSearchParams) error); ok {
+		r2 = rf(params)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Database_SearchChatHistory_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SearchChatHistory'
+type Database_SearchChatHistory_Call struct {
+	*mock.Call
+}
+
+// SearchChatHistory is a helper method to define mock.On call
+//   - params db.ChatSearchParams
+func (_e *Database_Expecter) SearchChatHistory(params interface{}) *Database_SearchChatHistory_Call {
+	return &Database_SearchChatHistory_Call{Call: _e.mock.On("SearchChatHistory", params)}
+}
+
+func (_c *Database_SearchChatHistory_Call) Run(run func(params db.ChatSearchParams)) *Database_SearchChatHistory_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(db.ChatSearchParams))
+	})
+	return _c
+}
+
+func (_c *Database_SearchChatHistory_Call) Return(_a0 []db.ChatSearchResult, _a1 int64, _a2 error) *Database_SearchChatHistory_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *Database_SearchChatHistory_Call) RunAndReturn(run func(db.ChatSearchParams) ([]db.ChatSearchResult, int64, error)) *Database_SearchChatHistory_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // SearchPeople provides a mock function with given fields: s, limit, offset
 func (_m *Database) SearchPeople(s string, limit int, offset int) []db.Person {
 	ret := _m.Called(s, limit, offset)
@@ -13414,53 +16112,230 @@ func (_c *Database_SetPaymentStatusByBountyId_Call) Return(_a0 bool) *Database_S
 	return _c
 }
 
-func (_c *Database_SetPaymentStatusByBountyId_Call) RunAndReturn(run func(uint, db.V2TagRes) bool) *Database_SetPaymentStatusByBountyId_Call {
+func (_c *Database_SetPaymentStatusByBountyId_Call) RunAndReturn(run func(uint, db.V2TagRes) bool) *Database_SetPaymentStatusByBountyId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetWorkspaceLightningAddress provides a mock function with given fields: _a0, name
+func (_m *Database) SetWorkspaceLightningAddress(_a0 string, name string) (db.Workspace, error) {
+	ret := _m.Called(_a0, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetWorkspaceLightningAddress")
+	}
+
+	var r0 db.Workspace
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (db.Workspace, error)); ok {
+		return rf(_a0, name)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) db.Workspace); ok {
+		r0 = rf(_a0, name)
+	} else {
+		r0 = ret.Get(0).(db.Workspace)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Database_SetWorkspaceLightningAddress_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWorkspaceLightningAddress'
+type Database_SetWorkspaceLightningAddress_Call struct {
+	*mock.Call
+}
+
+// SetWorkspaceLightningAddress is a helper method to define mock.On call
+//   - _a0 string
+//   - name string
+func (_e *Database_Expecter) SetWorkspaceLightningAddress(_a0 interface{}, name interface{}) *Database_SetWorkspaceLightningAddress_Call {
+	return &Database_SetWorkspaceLightningAddress_Call{Call: _e.mock.On("SetWorkspaceLightningAddress", _a0, name)}
+}
+
+func (_c *Database_SetWorkspaceLightningAddress_Call) Run(run func(_a0 string, name string)) *Database_SetWorkspaceLightningAddress_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Database_SetWorkspaceLightningAddress_Call) Return(_a0 db.Workspace, _a1 error) *Database_SetWorkspaceLightningAddress_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Database_SetWorkspaceLightningAddress_Call) RunAndReturn(run func(string, string) (db.Workspace, error)) *Database_SetWorkspaceLightningAddress_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StartBountyTiming provides a mock function with given fields: bountyID
+func (_m *Database) StartBountyTiming(bountyID uint) error {
+	ret := _m.Called(bountyID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StartBountyTiming")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint) error); ok {
+		r0 = rf(bountyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_StartBountyTiming_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StartBountyTiming'
+type Database_StartBountyTiming_Call struct {
+	*mock.Call
+}
+
+// StartBountyTiming is a helper method to define mock.On call
+//   - bountyID uint
+func (_e *Database_Expecter) StartBountyTiming(bountyID interface{}) *Database_StartBountyTiming_Call {
+	return &Database_StartBountyTiming_Call{Call: _e.mock.On("StartBountyTiming", bountyID)}
+}
+
+func (_c *Database_StartBountyTiming_Call) Run(run func(bountyID uint)) *Database_StartBountyTiming_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uint))
+	})
+	return _c
+}
+
+func (_c *Database_StartBountyTiming_Call) Return(_a0 error) *Database_StartBountyTiming_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_StartBountyTiming_Call) RunAndReturn(run func(uint) error) *Database_StartBountyTiming_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StartChatMessageStream provides a mock function with given fields: reply
+func (_m *Database) StartChatMessageStream(reply *db.ChatMessage) (db.ChatMessage, bool, error) {
+	ret := _m.Called(reply)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StartChatMessageStream")
+	}
+
+	var r0 db.ChatMessage
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(*db.ChatMessage) (db.ChatMessage, bool, error)); ok {
+		return rf(reply)
+	}
+	if rf, ok := ret.Get(0).(func(*db.ChatMessage) db.ChatMessage); ok {
+		r0 = rf(reply)
+	} else {
+		r0 = ret.Get(0).(db.ChatMessage)
+	}
+
+	if rf, ok := ret.Get(1).(func(*db.ChatMessage) bool); ok {
+		r1 = rf(reply)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(*db.ChatMessage) error); ok {
+		r2 = rf(reply)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Database_StartChatMessageStream_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StartChatMessageStream'
+type Database_StartChatMessageStream_Call struct {
+	*mock.Call
+}
+
+// StartChatMessageStream is a helper method to define mock.On call
+//   - reply *db.ChatMessage
+func (_e *Database_Expecter) StartChatMessageStream(reply interface{}) *Database_StartChatMessageStream_Call {
+	return &Database_StartChatMessageStream_Call{Call: _e.mock.On("StartChatMessageStream", reply)}
+}
+
+func (_c *Database_StartChatMessageStream_Call) Run(run func(reply *db.ChatMessage)) *Database_StartChatMessageStream_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*db.ChatMessage))
+	})
+	return _c
+}
+
+func (_c *Database_StartChatMessageStream_Call) Return(_a0 db.ChatMessage, _a1 bool, _a2 error) *Database_StartChatMessageStream_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *Database_StartChatMessageStream_Call) RunAndReturn(run func(*db.ChatMessage) (db.ChatMessage, bool, error)) *Database_StartChatMessageStream_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// StartBountyTiming provides a mock function with given fields: bountyID
-func (_m *Database) StartBountyTiming(bountyID uint) error {
-	ret := _m.Called(bountyID)
+// SwitchChatBranch provides a mock function with given fields: chatID, messageID
+func (_m *Database) SwitchChatBranch(chatID string, messageID string) (db.ChatMessage, error) {
+	ret := _m.Called(chatID, messageID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for StartBountyTiming")
+		panic("no return value specified for SwitchChatBranch")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(uint) error); ok {
-		r0 = rf(bountyID)
+	var r0 db.ChatMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (db.ChatMessage, error)); ok {
+		return rf(chatID, messageID)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) db.ChatMessage); ok {
+		r0 = rf(chatID, messageID)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(db.ChatMessage)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(chatID, messageID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// Database_StartBountyTiming_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StartBountyTiming'
-type Database_StartBountyTiming_Call struct {
+// Database_SwitchChatBranch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SwitchChatBranch'
+type Database_SwitchChatBranch_Call struct {
 	*mock.Call
 }
 
-// StartBountyTiming is a helper method to define mock.On call
-//   - bountyID uint
-func (_e *Database_Expecter) StartBountyTiming(bountyID interface{}) *Database_StartBountyTiming_Call {
-	return &Database_StartBountyTiming_Call{Call: _e.mock.On("StartBountyTiming", bountyID)}
+// SwitchChatBranch is a helper method to define mock.On call
+//   - chatID string
+//   - messageID string
+func (_e *Database_Expecter) SwitchChatBranch(chatID interface{}, messageID interface{}) *Database_SwitchChatBranch_Call {
+	return &Database_SwitchChatBranch_Call{Call: _e.mock.On("SwitchChatBranch", chatID, messageID)}
 }
 
-func (_c *Database_StartBountyTiming_Call) Run(run func(bountyID uint)) *Database_StartBountyTiming_Call {
+func (_c *Database_SwitchChatBranch_Call) Run(run func(chatID string, messageID string)) *Database_SwitchChatBranch_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(uint))
+		run(args[0].(string), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *Database_StartBountyTiming_Call) Return(_a0 error) *Database_StartBountyTiming_Call {
-	_c.Call.Return(_a0)
+func (_c *Database_SwitchChatBranch_Call) Return(_a0 db.ChatMessage, _a1 error) *Database_SwitchChatBranch_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Database_StartBountyTiming_Call) RunAndReturn(run func(uint) error) *Database_StartBountyTiming_Call {
+func (_c *Database_SwitchChatBranch_Call) RunAndReturn(run func(string, string) (db.ChatMessage, error)) *Database_SwitchChatBranch_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -13793,6 +16668,52 @@ func (_c *Database_TotalSatsPosted_Call) RunAndReturn(run func(db.PaymentDateRan
 	return _c
 }
 
+// TouchSSESubscriptions provides a mock function with given fields: owner
+func (_m *Database) TouchSSESubscriptions(owner string) error {
+	ret := _m.Called(owner)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TouchSSESubscriptions")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(owner)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_TouchSSESubscriptions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TouchSSESubscriptions'
+type Database_TouchSSESubscriptions_Call struct {
+	*mock.Call
+}
+
+// TouchSSESubscriptions is a helper method to define mock.On call
+//   - owner string
+func (_e *Database_Expecter) TouchSSESubscriptions(owner interface{}) *Database_TouchSSESubscriptions_Call {
+	return &Database_TouchSSESubscriptions_Call{Call: _e.mock.On("TouchSSESubscriptions", owner)}
+}
+
+func (_c *Database_TouchSSESubscriptions_Call) Run(run func(owner string)) *Database_TouchSSESubscriptions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Database_TouchSSESubscriptions_Call) Return(_a0 error) *Database_TouchSSESubscriptions_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_TouchSSESubscriptions_Call) RunAndReturn(run func(string) error) *Database_TouchSSESubscriptions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateActivity provides a mock function with given fields: activity
 func (_m *Database) UpdateActivity(activity *db.Activity) (*db.Activity, error) {
 	ret := _m.Called(activity)
@@ -14218,6 +17139,54 @@ func (_c *Database_UpdateBountyPaymentStatuses_Call) RunAndReturn(run func(db.Ne
 	return _c
 }
 
+// UpdateBountyPayoutStatus provides a mock function with given fields: id, status, errMsg
+func (_m *Database) UpdateBountyPayoutStatus(id uuid.UUID, status string, errMsg string) error {
+	ret := _m.Called(id, status, errMsg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateBountyPayoutStatus")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string, string) error); ok {
+		r0 = rf(id, status, errMsg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_UpdateBountyPayoutStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateBountyPayoutStatus'
+type Database_UpdateBountyPayoutStatus_Call struct {
+	*mock.Call
+}
+
+// UpdateBountyPayoutStatus is a helper method to define mock.On call
+//   - id uuid.UUID
+//   - status string
+//   - errMsg string
+func (_e *Database_Expecter) UpdateBountyPayoutStatus(id interface{}, status interface{}, errMsg interface{}) *Database_UpdateBountyPayoutStatus_Call {
+	return &Database_UpdateBountyPayoutStatus_Call{Call: _e.mock.On("UpdateBountyPayoutStatus", id, status, errMsg)}
+}
+
+func (_c *Database_UpdateBountyPayoutStatus_Call) Run(run func(id uuid.UUID, status string, errMsg string)) *Database_UpdateBountyPayoutStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Database_UpdateBountyPayoutStatus_Call) Return(_a0 error) *Database_UpdateBountyPayoutStatus_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_UpdateBountyPayoutStatus_Call) RunAndReturn(run func(uuid.UUID, string, string) error) *Database_UpdateBountyPayoutStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateBountyTiming provides a mock function with given fields: timing
 func (_m *Database) UpdateBountyTiming(timing *db.BountyTiming) error {
 	ret := _m.Called(timing)
@@ -14777,40 +17746,6 @@ func (_c *Database_UpdateFileAssetReference_Call) RunAndReturn(run func(uint) er
 	return _c
 }
 
-// UpdateGithubConfirmed provides a mock function with given fields: id, confirmed
-func (_m *Database) UpdateGithubConfirmed(id uint, confirmed bool) {
-	_m.Called(id, confirmed)
-}
-
-// Database_UpdateGithubConfirmed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateGithubConfirmed'
-type Database_UpdateGithubConfirmed_Call struct {
-	*mock.Call
-}
-
-// UpdateGithubConfirmed is a helper method to define mock.On call
-//   - id uint
-//   - confirmed bool
-func (_e *Database_Expecter) UpdateGithubConfirmed(id interface{}, confirmed interface{}) *Database_UpdateGithubConfirmed_Call {
-	return &Database_UpdateGithubConfirmed_Call{Call: _e.mock.On("UpdateGithubConfirmed", id, confirmed)}
-}
-
-func (_c *Database_UpdateGithubConfirmed_Call) Run(run func(id uint, confirmed bool)) *Database_UpdateGithubConfirmed_Call {
-	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(uint), args[1].(bool))
-	})
-	return _c
-}
-
-func (_c *Database_UpdateGithubConfirmed_Call) Return() *Database_UpdateGithubConfirmed_Call {
-	_c.Call.Return()
-	return _c
-}
-
-func (_c *Database_UpdateGithubConfirmed_Call) RunAndReturn(run func(uint, bool)) *Database_UpdateGithubConfirmed_Call {
-	_c.Run(run)
-	return _c
-}
-
 // UpdateGithubIssues provides a mock function with given fields: id, issues
 func (_m *Database) UpdateGithubIssues(id uint, issues map[string]interface{}) {
 	_m.Called(id, issues)
@@ -15067,6 +18002,54 @@ func (_c *Database_UpdatePerson_Call) RunAndReturn(run func(uint, map[string]int
 	return _c
 }
 
+// UpdatePersonGithub provides a mock function with given fields: pubkey, login, githubID
+func (_m *Database) UpdatePersonGithub(pubkey string, login string, githubID int64) error {
+	ret := _m.Called(pubkey, login, githubID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePersonGithub")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, int64) error); ok {
+		r0 = rf(pubkey, login, githubID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_UpdatePersonGithub_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdatePersonGithub'
+type Database_UpdatePersonGithub_Call struct {
+	*mock.Call
+}
+
+// UpdatePersonGithub is a helper method to define mock.On call
+//   - pubkey string
+//   - login string
+//   - githubID int64
+func (_e *Database_Expecter) UpdatePersonGithub(pubkey interface{}, login interface{}, githubID interface{}) *Database_UpdatePersonGithub_Call {
+	return &Database_UpdatePersonGithub_Call{Call: _e.mock.On("UpdatePersonGithub", pubkey, login, githubID)}
+}
+
+func (_c *Database_UpdatePersonGithub_Call) Run(run func(pubkey string, login string, githubID int64)) *Database_UpdatePersonGithub_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(int64))
+	})
+	return _c
+}
+
+func (_c *Database_UpdatePersonGithub_Call) Return(_a0 error) *Database_UpdatePersonGithub_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_UpdatePersonGithub_Call) RunAndReturn(run func(string, string, int64) error) *Database_UpdatePersonGithub_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateProcessingMap provides a mock function with given fields: pm
 func (_m *Database) UpdateProcessingMap(pm *db.WfProcessingMap) error {
 	ret := _m.Called(pm)
@@ -15160,6 +18143,54 @@ func (_c *Database_UpdateProofStatus_Call) RunAndReturn(run func(string, db.Proo
 	return _c
 }
 
+// UpdateSSESubscription provides a mock function with given fields: chatID, url, updates
+func (_m *Database) UpdateSSESubscription(chatID string, url string, updates map[string]interface{}) error {
+	ret := _m.Called(chatID, url, updates)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateSSESubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, map[string]interface{}) error); ok {
+		r0 = rf(chatID, url, updates)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_UpdateSSESubscription_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateSSESubscription'
+type Database_UpdateSSESubscription_Call struct {
+	*mock.Call
+}
+
+// UpdateSSESubscription is a helper method to define mock.On call
+//   - chatID string
+//   - url string
+//   - updates map[string]interface{}
+func (_e *Database_Expecter) UpdateSSESubscription(chatID interface{}, url interface{}, updates interface{}) *Database_UpdateSSESubscription_Call {
+	return &Database_UpdateSSESubscription_Call{Call: _e.mock.On("UpdateSSESubscription", chatID, url, updates)}
+}
+
+func (_c *Database_UpdateSSESubscription_Call) Run(run func(chatID string, url string, updates map[string]interface{})) *Database_UpdateSSESubscription_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(map[string]interface{}))
+	})
+	return _c
+}
+
+func (_c *Database_UpdateSSESubscription_Call) Return(_a0 error) *Database_UpdateSSESubscription_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_UpdateSSESubscription_Call) RunAndReturn(run func(string, string, map[string]interface{}) error) *Database_UpdateSSESubscription_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateSnippet provides a mock function with given fields: snippet
 func (_m *Database) UpdateSnippet(snippet *db.TextSnippet) (*db.TextSnippet, error) {
 	ret := _m.Called(snippet)
@@ -15435,6 +18466,53 @@ func (_c *Database_UpdateTwitterConfirmed_Call) RunAndReturn(run func(uint, bool
 	return _c
 }
 
+// UpdateWebhookDeadLetter provides a mock function with given fields: id, updates
+func (_m *Database) UpdateWebhookDeadLetter(id uuid.UUID, updates map[string]interface{}) error {
+	ret := _m.Called(id, updates)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateWebhookDeadLetter")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, map[string]interface{}) error); ok {
+		r0 = rf(id, updates)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_UpdateWebhookDeadLetter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateWebhookDeadLetter'
+type Database_UpdateWebhookDeadLetter_Call struct {
+	*mock.Call
+}
+
+// UpdateWebhookDeadLetter is a helper method to define mock.On call
+//   - id uuid.UUID
+//   - updates map[string]interface{}
+func (_e *Database_Expecter) UpdateWebhookDeadLetter(id interface{}, updates interface{}) *Database_UpdateWebhookDeadLetter_Call {
+	return &Database_UpdateWebhookDeadLetter_Call{Call: _e.mock.On("UpdateWebhookDeadLetter", id, updates)}
+}
+
+func (_c *Database_UpdateWebhookDeadLetter_Call) Run(run func(id uuid.UUID, updates map[string]interface{})) *Database_UpdateWebhookDeadLetter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(map[string]interface{}))
+	})
+	return _c
+}
+
+func (_c *Database_UpdateWebhookDeadLetter_Call) Return(_a0 error) *Database_UpdateWebhookDeadLetter_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_UpdateWebhookDeadLetter_Call) RunAndReturn(run func(uuid.UUID, map[string]interface{}) error) *Database_UpdateWebhookDeadLetter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateWorkflowRequest provides a mock function with given fields: req
 func (_m *Database) UpdateWorkflowRequest(req *db.WfRequest) error {
 	ret := _m.Called(req)
@@ -15529,6 +18607,53 @@ func (_c *Database_UpdateWorkflowRequestStatusAndResponse_Call) RunAndReturn(run
 	return _c
 }
 
+// UpdateWorkspaceAPIKeyLastUsed provides a mock function with given fields: id, lastUsed
+func (_m *Database) UpdateWorkspaceAPIKeyLastUsed(id string, lastUsed time.Time) error {
+	ret := _m.Called(id, lastUsed)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateWorkspaceAPIKeyLastUsed")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, time.Time) error); ok {
+		r0 = rf(id, lastUsed)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Database_UpdateWorkspaceAPIKeyLastUsed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateWorkspaceAPIKeyLastUsed'
+type Database_UpdateWorkspaceAPIKeyLastUsed_Call struct {
+	*mock.Call
+}
+
+// UpdateWorkspaceAPIKeyLastUsed is a helper method to define mock.On call
+//   - id string
+//   - lastUsed time.Time
+func (_e *Database_Expecter) UpdateWorkspaceAPIKeyLastUsed(id interface{}, lastUsed interface{}) *Database_UpdateWorkspaceAPIKeyLastUsed_Call {
+	return &Database_UpdateWorkspaceAPIKeyLastUsed_Call{Call: _e.mock.On("UpdateWorkspaceAPIKeyLastUsed", id, lastUsed)}
+}
+
+func (_c *Database_UpdateWorkspaceAPIKeyLastUsed_Call) Run(run func(id string, lastUsed time.Time)) *Database_UpdateWorkspaceAPIKeyLastUsed_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *Database_UpdateWorkspaceAPIKeyLastUsed_Call) Return(_a0 error) *Database_UpdateWorkspaceAPIKeyLastUsed_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Database_UpdateWorkspaceAPIKeyLastUsed_Call) RunAndReturn(run func(string, time.Time) error) *Database_UpdateWorkspaceAPIKeyLastUsed_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateWorkspaceBudget provides a mock function with given fields: budget
 func (_m *Database) UpdateWorkspaceBudget(budget db.NewBountyBudget) db.NewBountyBudget {
 	ret := _m.Called(budget)
@@ -0,0 +1,143 @@
+package monitoring
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/logger"
+)
+
+const namespace = "tribes"
+
+// Registry holds the operational metrics, it is kept apart from the
+// business metrics served under /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by chi route pattern and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	cronDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "cron",
+		Name:      "duration_seconds",
+		Help:      "Duration of cron job runs.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 300, 900},
+	}, []string{"job"})
+
+	cronFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cron",
+		Name:      "failures_total",
+		Help:      "Cron job runs that returned an error or panicked.",
+	}, []string{"job"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		cronDuration,
+		cronFailures,
+	)
+}
+
+// Handler serves the operational metrics. With OPS_METRICS_TOKEN set the
+// scraper has to send it as a bearer token.
+func Handler() http.Handler {
+	metrics := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if config.OpsMetricsToken != "" {
+			token := r.Header.Get("Authorization")
+			if subtle.ConstantTimeCompare([]byte(token), []byte("Bearer "+config.OpsMetricsToken)) != 1 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		metrics.ServeHTTP(w, r)
+	})
+}
+
+// Middleware observes the duration and status of every request, labelled
+// with the chi route pattern to keep the label values bounded
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+
+		defer func() {
+			route := "unmatched"
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			httpRequestDuration.WithLabelValues(r.Method, route, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
+
+// Cron wraps a cron job to observe how long it runs and count the runs
+// that failed or panicked, the panic is logged instead of taking the process
+// down
+func Cron(name string, job func() error) func() {
+	return func() {
+		start := time.Now()
+		defer func() {
+			cronDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+			if err := recover(); err != nil {
+				cronFailures.WithLabelValues(name).Inc()
+				logger.Log.With("cron", name).Error("cron job panicked: %v", err)
+			}
+		}()
+
+		if err := job(); err != nil {
+			cronFailures.WithLabelValues(name).Inc()
+			logger.Log.With("cron", name).Error("cron job failed: %v", err)
+		}
+	}
+}
+
+// RegisterGaugeFunc adds a gauge whose value is read on every scrape
+func RegisterGaugeFunc(name string, help string, value func() float64) {
+	Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, value))
+}
+
+// RegisterCounterFunc adds a counter whose value is read on every scrape,
+// the value must only ever go up
+func RegisterCounterFunc(name string, help string, value func() float64) {
+	Registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, value))
+}
+
+// RegisterDBStats adds the connection pool statistics of the database
+func RegisterDBStats(sqlDB *sql.DB) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(sqlDB, namespace))
+}
@@ -0,0 +1,86 @@
+package monitoring
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	httpRequestDuration.Reset()
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/workspaces/{uuid}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	r.Get("/ok", func(w http.ResponseWriter, r *http.Request) {})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/workspaces/workspace_1", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/workspaces/workspace_2", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+	assert.Equal(t, 3, testutil.CollectAndCount(httpRequestDuration))
+
+	expected := `
+		# HELP tribes_http_request_duration_seconds Duration of HTTP requests by chi route pattern and status.
+		# TYPE tribes_http_request_duration_seconds histogram
+		tribes_http_request_duration_seconds_count{method="GET",route="/ok",status="200"} 1
+		tribes_http_request_duration_seconds_count{method="GET",route="/workspaces/{uuid}",status="404"} 2
+		tribes_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1
+	`
+	assert.NoError(t, testutil.CollectAndCompare(httpRequestDuration, strings.NewReader(expected), "tribes_http_request_duration_seconds_count"))
+}
+
+func TestCron(t *testing.T) {
+	cronFailures.Reset()
+	cronDuration.Reset()
+
+	runs := 0
+	Cron("working", func() error { runs++; return nil })()
+	Cron("erroring", func() error { return errors.New("no database") })()
+	assert.NotPanics(t, Cron("panicking", func() error { panic("no database") }))
+
+	assert.Equal(t, 1, runs)
+	assert.Equal(t, float64(0), testutil.ToFloat64(cronFailures.WithLabelValues("working")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(cronFailures.WithLabelValues("erroring")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(cronFailures.WithLabelValues("panicking")))
+	assert.Equal(t, 3, testutil.CollectAndCount(cronDuration))
+}
+
+func TestHandler(t *testing.T) {
+	token := config.OpsMetricsToken
+	defer func() { config.OpsMetricsToken = token }()
+
+	t.Run("should serve the metrics without a token configured", func(t *testing.T) {
+		config.OpsMetricsToken = ""
+
+		rr := httptest.NewRecorder()
+		Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ops/metrics", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "go_goroutines")
+	})
+
+	t.Run("should require the configured token", func(t *testing.T) {
+		config.OpsMetricsToken = "ops_token"
+
+		rr := httptest.NewRecorder()
+		Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ops/metrics", nil))
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+		req := httptest.NewRequest(http.MethodGet, "/ops/metrics", nil)
+		req.Header.Set("Authorization", "Bearer ops_token")
+		rr = httptest.NewRecorder()
+		Handler().ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
@@ -0,0 +1,156 @@
+package reporting
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	modulePath = "github.com/stakwork/sphinx-tribes/"
+	// the start of a 5xx body is kept as the error of the report, handlers
+	// answer with the error they logged
+	errorBodyLimit = 512
+)
+
+// StatusRecorder remembers the status of a response and, for 5xx, the
+// code that wrote it and the start of the body
+type StatusRecorder struct {
+	http.ResponseWriter
+	status int
+	source string
+	body   []byte
+}
+
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	return &StatusRecorder{ResponseWriter: w}
+}
+
+func (s *StatusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+		if status >= http.StatusInternalServerError {
+			s.source = callerSource()
+		}
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *StatusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	if s.status >= http.StatusInternalServerError && len(s.body) < errorBodyLimit {
+		n := errorBodyLimit - len(s.body)
+		if n > len(b) {
+			n = len(b)
+		}
+		s.body = append(s.body, b[:n]...)
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Status is the status written so far, 0 when nothing was written
+func (s *StatusRecorder) Status() int {
+	return s.status
+}
+
+// Source is the file and line of the code that wrote a 5xx status
+func (s *StatusRecorder) Source() string {
+	return s.source
+}
+
+// ErrorBody is the start of the body of a 5xx response
+func (s *StatusRecorder) ErrorBody() string {
+	return strings.TrimSpace(string(s.body))
+}
+
+func (s *StatusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *StatusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := s.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, fmt.Errorf("the response writer does not support hijacking")
+}
+
+func (s *StatusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// RequestReport builds a report with the request it happened on
+func RequestReport(r *http.Request, kind string, message string, status int) Report {
+	report := Report{
+		Kind:      kind,
+		Error:     message,
+		Method:    r.Method,
+		Path:      r.URL.RequestURI(),
+		Status:    status,
+		RequestID: middleware.GetReqID(r.Context()),
+		SessionID: r.Header.Get("x-session-id"),
+	}
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		report.Route = rctx.RoutePattern()
+	}
+	if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.IsValid() {
+		report.TraceID = spanContext.TraceID().String()
+	}
+	return report
+}
+
+// PanicSource is the file and line that panicked, it has to be called
+// from the deferred function that recovered the panic
+func PanicSource() string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		if panicking && !strings.HasPrefix(frame.Function, "runtime.") {
+			return formatFrame(frame)
+		}
+		if frame.Function == "runtime.gopanic" {
+			panicking = true
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// callerSource is the first frame of tribes code above the recorder, the
+// handler or helper that wrote the response
+func callerSource() string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, modulePath) && !strings.HasPrefix(frame.Function, modulePath+"reporting.(*StatusRecorder)") {
+			return formatFrame(frame)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func formatFrame(frame runtime.Frame) string {
+	function := strings.TrimPrefix(frame.Function, modulePath)
+	return fmt.Sprintf("%s:%d %s", filepath.Base(frame.File), frame.Line, function)
+}
@@ -0,0 +1,86 @@
+package reporting
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func failingHandler(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, "could not save bounty", http.StatusInternalServerError)
+}
+
+func TestStatusRecorder(t *testing.T) {
+	t.Run("should record the source and body of a 5xx", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		recorder := NewStatusRecorder(rr)
+
+		failingHandler(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Status())
+		assert.Equal(t, "could not save bounty", recorder.ErrorBody())
+		assert.True(t, strings.HasPrefix(recorder.Source(), "http_test.go:"), recorder.Source())
+		assert.Contains(t, recorder.Source(), "reporting.failingHandler")
+		assert.Equal(t, "could not save bounty\n", rr.Body.String())
+	})
+
+	t.Run("should not record the body of other responses", func(t *testing.T) {
+		recorder := NewStatusRecorder(httptest.NewRecorder())
+
+		recorder.Write([]byte("ok"))
+
+		assert.Equal(t, http.StatusOK, recorder.Status())
+		assert.Empty(t, recorder.Source())
+		assert.Empty(t, recorder.ErrorBody())
+	})
+
+	t.Run("should keep the start of long bodies", func(t *testing.T) {
+		recorder := NewStatusRecorder(httptest.NewRecorder())
+
+		recorder.WriteHeader(http.StatusBadGateway)
+		recorder.Write([]byte(strings.Repeat("a", 400)))
+		recorder.Write([]byte(strings.Repeat("b", 400)))
+
+		assert.Len(t, recorder.ErrorBody(), errorBodyLimit)
+	})
+}
+
+func TestRequestReport(t *testing.T) {
+	var report Report
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Get("/workspaces/{uuid}", func(w http.ResponseWriter, r *http.Request) {
+		report = RequestReport(r, KindError, "failed", http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/workspaces/workspace_1?page=2", nil)
+	req.Header.Set("x-session-id", "session_1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, KindError, report.Kind)
+	assert.Equal(t, http.MethodGet, report.Method)
+	assert.Equal(t, "/workspaces/workspace_1?page=2", report.Path)
+	assert.Equal(t, "/workspaces/{uuid}", report.Route)
+	assert.Equal(t, "session_1", report.SessionID)
+	assert.NotEmpty(t, report.RequestID)
+}
+
+func TestPanicSource(t *testing.T) {
+	var source string
+	func() {
+		defer func() {
+			recover()
+			source = PanicSource()
+		}()
+		var bounties map[string]int
+		bounties["bounty_1"] = 1
+	}()
+
+	assert.True(t, strings.HasPrefix(source, "http_test.go:"), source)
+	assert.Contains(t, source, "TestPanicSource")
+}
@@ -0,0 +1,80 @@
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/logger"
+	"github.com/stakwork/sphinx-tribes/utils"
+)
+
+// JarvisReporter sends every report to Jarvis as an edge list
+type JarvisReporter struct{}
+
+func (JarvisReporter) Name() string {
+	return "jarvis"
+}
+
+func (JarvisReporter) Send(ctx context.Context, reports []Report) error {
+	for _, report := range reports {
+		// handled errors have no stack, the source is all Jarvis gets
+		message := report.Error
+		if report.Source != "" {
+			message = fmt.Sprintf("%s at %s", report.Error, report.Source)
+		}
+		edgeList := utils.FormatStacktraceToEdgeList(report.Stack, message)
+		if err := sendEdgeListToJarvis(ctx, edgeList); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sendEdgeListToJarvis(ctx context.Context, edgeList utils.EdgeList) error {
+	if config.JarvisUrl == "" || config.JarvisToken == "" {
+		logger.Log.Info("Jarvis configuration not found, skipping error reporting")
+		return nil
+	}
+
+	jarvisURL := fmt.Sprintf("%s/node/edge/bulk", config.JarvisUrl)
+
+	jsonData, err := json.Marshal(edgeList)
+	if err != nil {
+		logger.Log.Error("Failed to marshal edge list: %v", err)
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", jarvisURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		logger.Log.Error("Failed to create Jarvis request: %v", err)
+		return nil
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-token", config.JarvisToken)
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.Log.Error("Failed to send error to Jarvis: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		logger.Log.Info("Successfully sent error to Jarvis")
+		return nil
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("jarvis returned non-success status: %d, body: %s", resp.StatusCode, string(body))
+}
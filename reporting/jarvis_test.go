@@ -0,0 +1,420 @@
+package reporting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendEdgeListToJarvis(t *testing.T) {
+	originalURL := config.JarvisUrl
+	originalToken := config.JarvisToken
+	defer func() {
+		config.JarvisUrl = originalURL
+		config.JarvisToken = originalToken
+	}()
+
+	tests := []struct {
+		name          string
+		edgeList      utils.EdgeList
+		setupMock     func() *httptest.Server
+		setupConfig   func()
+		expectedError bool
+	}{
+		{
+			name: "Basic Functionality",
+			edgeList: utils.EdgeList{
+				EdgeList: []utils.Edge{
+					{
+						Edge: utils.EdgeInfo{
+							EdgeType: "test",
+							Weight:   1.0,
+						},
+						Source: utils.Node{
+							NodeType: "test",
+							NodeData: map[string]interface{}{"test": "data"},
+						},
+						Targets: []utils.Node{
+							{
+								NodeType: "test",
+								NodeData: map[string]interface{}{"test": "data"},
+							},
+						},
+					},
+				},
+			},
+			setupMock: func() *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+					assert.Equal(t, "test-token", r.Header.Get("x-api-token"))
+					w.WriteHeader(http.StatusOK)
+				}))
+			},
+			setupConfig: func() {
+				config.JarvisToken = "test-token"
+			},
+			expectedError: false,
+		},
+		{
+			name: "Empty EdgeList",
+			edgeList: utils.EdgeList{
+				EdgeList: []utils.Edge{},
+			},
+			setupMock: func() *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}))
+			},
+			setupConfig: func() {
+				config.JarvisToken = "test-token"
+			},
+			expectedError: false,
+		},
+		{
+			name: "Missing JarvisUrl",
+			edgeList: utils.EdgeList{
+				EdgeList: []utils.Edge{
+					{
+						Edge: utils.EdgeInfo{
+							EdgeType: "test",
+							Weight:   1.0,
+						},
+					},
+				},
+			},
+			setupMock: nil,
+			setupConfig: func() {
+				config.JarvisUrl = ""
+				config.JarvisToken = "test-token"
+			},
+			expectedError: false,
+		},
+		{
+			name: "Missing JarvisToken",
+			edgeList: utils.EdgeList{
+				EdgeList: []utils.Edge{
+					{
+						Edge: utils.EdgeInfo{
+							EdgeType: "test",
+							Weight:   1.0,
+						},
+					},
+				},
+			},
+			setupMock: nil,
+			setupConfig: func() {
+				config.JarvisUrl = "http://test.com"
+				config.JarvisToken = ""
+			},
+			expectedError: false,
+		},
+		{
+			name: "Invalid JSON Marshalling",
+			edgeList: utils.EdgeList{
+				EdgeList: []utils.Edge{
+					{
+						Edge: utils.EdgeInfo{
+							EdgeType: "test",
+							Weight:   1.0,
+						},
+						Source: utils.Node{
+							NodeType: "test",
+							NodeData: make(chan int),
+						},
+					},
+				},
+			},
+			setupMock: func() *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}))
+			},
+			setupConfig: func() {
+				config.JarvisToken = "test-token"
+			},
+			expectedError: false,
+		},
+		{
+			name: "HTTP Request Creation Failure",
+			edgeList: utils.EdgeList{
+				EdgeList: []utils.Edge{
+					{
+						Edge: utils.EdgeInfo{
+							EdgeType: "test",
+							Weight:   1.0,
+						},
+					},
+				},
+			},
+			setupMock: nil,
+			setupConfig: func() {
+				config.JarvisUrl = "http://[::1]:namedport"
+				config.JarvisToken = "test-token"
+			},
+			expectedError: false,
+		},
+		{
+			name: "Network Failure",
+			edgeList: utils.EdgeList{
+				EdgeList: []utils.Edge{
+					{
+						Edge: utils.EdgeInfo{
+							EdgeType: "test",
+							Weight:   1.0,
+						},
+					},
+				},
+			},
+			setupMock: func() *httptest.Server {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					panic("Force connection close")
+				}))
+				server.Close()
+				return server
+			},
+			setupConfig: func() {
+				config.JarvisToken = "test-token"
+			},
+			expectedError: true,
+		},
+		{
+			name: "Non-2xx HTTP Response",
+			edgeList: utils.EdgeList{
+				EdgeList: []utils.Edge{
+					{
+						Edge: utils.EdgeInfo{
+							EdgeType: "test",
+							Weight:   1.0,
+						},
+					},
+				},
+			},
+			setupMock: func() *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusInternalServerError)
+					json.NewEncoder(w).Encode(map[string]string{
+						"error": "Internal Server Error",
+					})
+				}))
+			},
+			setupConfig: func() {
+				config.JarvisToken = "test-token"
+			},
+			expectedError: true,
+		},
+		{
+			name: "Large Payload",
+			edgeList: utils.EdgeList{
+				EdgeList: func() []utils.Edge {
+					edges := make([]utils.Edge, 1000)
+					for i := 0; i < 1000; i++ {
+						edges[i] = utils.Edge{
+							Edge: utils.EdgeInfo{
+								EdgeType: fmt.Sprintf("test_%d", i),
+								Weight:   float64(i),
+							},
+							Source: utils.Node{
+								NodeType: "test",
+								NodeData: map[string]interface{}{
+									"data": strings.Repeat("large_payload", 100),
+								},
+							},
+						}
+					}
+					return edges
+				}(),
+			},
+			setupMock: func() *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}))
+			},
+			setupConfig: func() {
+				config.JarvisToken = "test-token"
+			},
+			expectedError: false,
+		},
+		{
+			name: "Slow Server Response",
+			edgeList: utils.EdgeList{
+				EdgeList: []utils.Edge{{
+					Edge: utils.EdgeInfo{
+						EdgeType: "test",
+						Weight:   1.0,
+					},
+				}},
+			},
+			setupMock: func() *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					time.Sleep(11 * time.Second) // Longer than client timeout
+					w.WriteHeader(http.StatusOK)
+				}))
+			},
+			setupConfig: func() {
+				config.JarvisToken = "test-token"
+			},
+			expectedError: true,
+		},
+		{
+			name: "Special Characters in EdgeType",
+			edgeList: utils.EdgeList{
+				EdgeList: []utils.Edge{{
+					Edge: utils.EdgeInfo{
+						EdgeType: "test!@#$%^&*()",
+						Weight:   1.0,
+					},
+				}},
+			},
+			setupMock: func() *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}))
+			},
+			setupConfig: func() {
+				config.JarvisToken = "test-token"
+			},
+			expectedError: false,
+		},
+		{
+			name: "Unicode Characters in NodeData",
+			edgeList: utils.EdgeList{
+				EdgeList: []utils.Edge{{
+					Edge: utils.EdgeInfo{
+						EdgeType: "test",
+						Weight:   1.0,
+					},
+					Source: utils.Node{
+						NodeType: "test",
+						NodeData: map[string]interface{}{
+							"data": "测试データ",
+						},
+					},
+				}},
+			},
+			setupMock: func() *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}))
+			},
+			setupConfig: func() {
+				config.JarvisToken = "test-token"
+			},
+			expectedError: false,
+		},
+		{
+			name: "Malformed Response Body",
+			edgeList: utils.EdgeList{
+				EdgeList: []utils.Edge{{
+					Edge: utils.EdgeInfo{
+						EdgeType: "test",
+						Weight:   1.0,
+					},
+				}},
+			},
+			setupMock: func() *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Length", "1000")
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte("Malformed JSON{"))
+				}))
+			},
+			setupConfig: func() {
+				config.JarvisToken = "test-token"
+			},
+			expectedError: true,
+		},
+		{
+			name: "Empty Response Body with Success Status",
+			edgeList: utils.EdgeList{
+				EdgeList: []utils.Edge{{
+					Edge: utils.EdgeInfo{
+						EdgeType: "test",
+						Weight:   1.0,
+					},
+				}},
+			},
+			setupMock: func() *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}))
+			},
+			setupConfig: func() {
+				config.JarvisToken = "test-token"
+			},
+			expectedError: false,
+		},
+		{
+			name: "Invalid Content-Type Response",
+			edgeList: utils.EdgeList{
+				EdgeList: []utils.Edge{{
+					Edge: utils.EdgeInfo{
+						EdgeType: "test",
+						Weight:   1.0,
+					},
+				}},
+			},
+			setupMock: func() *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "text/plain")
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte("Not JSON"))
+				}))
+			},
+			setupConfig: func() {
+				config.JarvisToken = "test-token"
+			},
+			expectedError: false,
+		},
+		{
+			name: "Redirect Response",
+			edgeList: utils.EdgeList{
+				EdgeList: []utils.Edge{{
+					Edge: utils.EdgeInfo{
+						EdgeType: "test",
+						Weight:   1.0,
+					},
+				}},
+			},
+			setupMock: func() *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					http.Redirect(w, r, "/new-location", http.StatusTemporaryRedirect)
+				}))
+			},
+			setupConfig: func() {
+				config.JarvisToken = "test-token"
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setupConfig != nil {
+				tt.setupConfig()
+			}
+
+			if tt.setupMock != nil {
+				server := tt.setupMock()
+				defer server.Close()
+				config.JarvisUrl = server.URL
+			}
+
+			err := sendEdgeListToJarvis(context.Background(), tt.edgeList)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
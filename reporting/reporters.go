@@ -0,0 +1,250 @@
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stakwork/sphinx-tribes/config"
+)
+
+var reportClient = &http.Client{Timeout: 10 * time.Second}
+
+// NoopReporter drops every report
+type NoopReporter struct{}
+
+func (NoopReporter) Name() string {
+	return "none"
+}
+
+func (NoopReporter) Send(ctx context.Context, reports []Report) error {
+	return nil
+}
+
+// FileReporter appends every report to a file as a JSON line
+type FileReporter struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileReporter(path string) *FileReporter {
+	return &FileReporter{path: path}
+}
+
+func (f *FileReporter) Name() string {
+	return "file"
+}
+
+func (f *FileReporter) Send(ctx context.Context, reports []Report) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, report := range reports {
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PosthogReporter sends a batch of reports as PostHog events in one call
+type PosthogReporter struct {
+	batchURL string
+	apiKey   string
+}
+
+func NewPosthogReporter(posthogURL string, apiKey string) (*PosthogReporter, error) {
+	if posthogURL == "" || apiKey == "" {
+		return nil, fmt.Errorf("the posthog reporter needs POSTHOG_URL and POSTHOG_KEY")
+	}
+	return &PosthogReporter{
+		batchURL: strings.TrimRight(posthogURL, "/") + "/batch/",
+		apiKey:   apiKey,
+	}, nil
+}
+
+func (p *PosthogReporter) Name() string {
+	return "posthog"
+}
+
+type posthogEvent struct {
+	Event      string                 `json:"event"`
+	DistinctID string                 `json:"distinct_id"`
+	Timestamp  time.Time              `json:"timestamp"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+func (p *PosthogReporter) Send(ctx context.Context, reports []Report) error {
+	events := make([]posthogEvent, 0, len(reports))
+	for _, report := range reports {
+		distinctID := report.SessionID
+		if distinctID == "" {
+			distinctID = config.OtelServiceName
+		}
+		properties := map[string]interface{}{
+			"$exception_message": report.Error,
+			"$exception_type":    report.Kind,
+			"$current_url":       report.Path,
+			"source":             report.Source,
+			"stack":              report.Stack,
+			"method":             report.Method,
+			"route":              report.Route,
+			"status":             report.Status,
+			"request_id":         report.RequestID,
+			"trace_id":           report.TraceID,
+		}
+		if report.SessionID != "" {
+			properties["$session_id"] = report.SessionID
+		}
+		for key, value := range report.Fields {
+			properties[key] = value
+		}
+		events = append(events, posthogEvent{
+			Event:      "$exception",
+			DistinctID: distinctID,
+			Timestamp:  report.Time,
+			Properties: properties,
+		})
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"api_key": p.apiKey,
+		"batch":   events,
+	})
+	if err != nil {
+		return err
+	}
+
+	return postReport(ctx, p.batchURL, body, nil)
+}
+
+// SentryReporter sends every report to the store endpoint of a Sentry
+// compatible server, like Sentry itself or GlitchTip
+type SentryReporter struct {
+	storeURL  string
+	publicKey string
+}
+
+func NewSentryReporter(dsn string) (*SentryReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return nil, fmt.Errorf("the sentry reporter needs a SENTRY_DSN like https://key@host/project")
+	}
+
+	path := strings.Trim(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	prefix, projectID := "", path
+	if i != -1 {
+		prefix, projectID = "/"+path[:i], path[i+1:]
+	}
+	if projectID == "" {
+		return nil, fmt.Errorf("the SENTRY_DSN has no project")
+	}
+
+	return &SentryReporter{
+		storeURL:  fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, projectID),
+		publicKey: u.User.Username(),
+	}, nil
+}
+
+func (s *SentryReporter) Name() string {
+	return "sentry"
+}
+
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Platform    string                 `json:"platform"`
+	Level       string                 `json:"level"`
+	Logger      string                 `json:"logger"`
+	ServerName  string                 `json:"server_name"`
+	Culprit     string                 `json:"culprit,omitempty"`
+	Message     string                 `json:"message"`
+	Tags        map[string]string      `json:"tags"`
+	Extra       map[string]interface{} `json:"extra"`
+	Transaction string                 `json:"transaction,omitempty"`
+}
+
+func (s *SentryReporter) Send(ctx context.Context, reports []Report) error {
+	header := http.Header{}
+	header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=sphinx-tribes/1.0, sentry_key=%s", s.publicKey))
+
+	// the store endpoint takes one event per call
+	for _, report := range reports {
+		extra := map[string]interface{}{
+			"stack":      report.Stack,
+			"path":       report.Path,
+			"request_id": report.RequestID,
+			"trace_id":   report.TraceID,
+		}
+		for key, value := range report.Fields {
+			extra[key] = value
+		}
+
+		body, err := json.Marshal(sentryEvent{
+			EventID:    strings.ReplaceAll(uuid.New().String(), "-", ""),
+			Timestamp:  report.Time.UTC().Format(time.RFC3339),
+			Platform:   "go",
+			Level:      "error",
+			Logger:     report.Kind,
+			ServerName: config.OtelServiceName,
+			Culprit:    report.Source,
+			Message:    report.Error,
+			Tags: map[string]string{
+				"kind":   report.Kind,
+				"method": report.Method,
+				"route":  report.Route,
+				"status": fmt.Sprint(report.Status),
+			},
+			Extra:       extra,
+			Transaction: report.Route,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := postReport(ctx, s.storeURL, body, header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func postReport(ctx context.Context, url string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key := range header {
+		req.Header.Set(key, header.Get(key))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := reportClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned status %d: %s", url, resp.StatusCode, string(respBody))
+	}
+	return nil
+}
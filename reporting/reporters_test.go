@@ -0,0 +1,138 @@
+package reporting
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testReport = Report{
+	Time:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	Kind:      KindError,
+	Error:     "could not get workspace",
+	Source:    "workspaces.go:120 handlers.(*workspaceHandler).GetWorkspaceByUuid",
+	Method:    http.MethodGet,
+	Path:      "/workspaces/workspace_1",
+	Route:     "/workspaces/{uuid}",
+	Status:    http.StatusInternalServerError,
+	SessionID: "session_1",
+}
+
+func TestFileReporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.jsonl")
+	reporter := NewFileReporter(path)
+
+	assert.NoError(t, reporter.Send(context.Background(), []Report{testReport}))
+	assert.NoError(t, reporter.Send(context.Background(), []Report{testReport}))
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var report Report
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &report))
+		assert.Equal(t, testReport, report)
+		lines++
+	}
+	assert.Equal(t, 2, lines)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestPosthogReporter(t *testing.T) {
+	_, err := NewPosthogReporter("", "")
+	assert.Error(t, err)
+
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/batch/", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer server.Close()
+
+	reporter, err := NewPosthogReporter(server.URL+"/", "phc_key")
+	assert.NoError(t, err)
+	assert.NoError(t, reporter.Send(context.Background(), []Report{testReport, testReport}))
+
+	assert.Equal(t, "phc_key", body["api_key"])
+	batch := body["batch"].([]interface{})
+	assert.Len(t, batch, 2)
+
+	event := batch[0].(map[string]interface{})
+	assert.Equal(t, "$exception", event["event"])
+	assert.Equal(t, "session_1", event["distinct_id"])
+	properties := event["properties"].(map[string]interface{})
+	assert.Equal(t, testReport.Error, properties["$exception_message"])
+	assert.Equal(t, testReport.Source, properties["source"])
+	assert.Equal(t, testReport.Route, properties["route"])
+}
+
+func TestSentryReporter(t *testing.T) {
+	t.Run("should parse the DSN", func(t *testing.T) {
+		reporter, err := NewSentryReporter("https://public_key@sentry.example.com/42")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://sentry.example.com/api/42/store/", reporter.storeURL)
+		assert.Equal(t, "public_key", reporter.publicKey)
+
+		reporter, err = NewSentryReporter("https://public_key@example.com/glitchtip/7")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/glitchtip/api/7/store/", reporter.storeURL)
+
+		for _, dsn := range []string{"", "https://sentry.example.com/42", "https://public_key@sentry.example.com/"} {
+			_, err = NewSentryReporter(dsn)
+			assert.Error(t, err, dsn)
+		}
+	})
+
+	t.Run("should send one event per report", func(t *testing.T) {
+		var events []sentryEvent
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/42/store/", r.URL.Path)
+			assert.Contains(t, r.Header.Get("X-Sentry-Auth"), "sentry_key=public_key")
+
+			var event sentryEvent
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+			events = append(events, event)
+		}))
+		defer server.Close()
+
+		reporter, err := NewSentryReporter("http://public_key@" + server.Listener.Addr().String() + "/42")
+		assert.NoError(t, err)
+		assert.NoError(t, reporter.Send(context.Background(), []Report{testReport, testReport}))
+
+		assert.Len(t, events, 2)
+		assert.Len(t, events[0].EventID, 32)
+		assert.NotEqual(t, events[0].EventID, events[1].EventID)
+		assert.Equal(t, testReport.Source, events[0].Culprit)
+		assert.Equal(t, testReport.Error, events[0].Message)
+		assert.Equal(t, "500", events[0].Tags["status"])
+		assert.Equal(t, "2024-01-02T03:04:05Z", events[0].Timestamp)
+	})
+
+	t.Run("should fail on an error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(io.Discard, r.Body)
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		reporter, err := NewSentryReporter("http://public_key@" + server.Listener.Addr().String() + "/42")
+		assert.NoError(t, err)
+		assert.ErrorContains(t, reporter.Send(context.Background(), []Report{testReport}), "429")
+	})
+}
@@ -0,0 +1,221 @@
+package reporting
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/logger"
+)
+
+const (
+	queueSize     = 1000
+	batchSize     = 50
+	flushInterval = 5 * time.Second
+	sendTimeout   = 30 * time.Second
+)
+
+// Report is one error that made it to the edge of the process, a panic or
+// a request answered with a 5xx
+type Report struct {
+	Time      time.Time              `json:"time"`
+	Kind      string                 `json:"kind"`
+	Error     string                 `json:"error"`
+	Source    string                 `json:"source,omitempty"`
+	Stack     string                 `json:"stack,omitempty"`
+	Method    string                 `json:"method,omitempty"`
+	Path      string                 `json:"path,omitempty"`
+	Route     string                 `json:"route,omitempty"`
+	Status    int                    `json:"status,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+	TraceID   string                 `json:"trace_id,omitempty"`
+	SessionID string                 `json:"session_id,omitempty"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+}
+
+const (
+	KindPanic = "panic"
+	KindError = "error"
+)
+
+// ErrorReporter sends a batch of scrubbed reports to where they are read
+type ErrorReporter interface {
+	Name() string
+	Send(ctx context.Context, reports []Report) error
+}
+
+// Dispatcher queues reports and hands them to its reporter in batches from
+// a single goroutine, reports are dropped when the queue is full so a
+// failing reporter never slows requests down
+type Dispatcher struct {
+	reporter      ErrorReporter
+	queue         chan Report
+	batchSize     int
+	flushInterval time.Duration
+	dropped       atomic.Int64
+	mu            sync.RWMutex
+	closed        bool
+	done          chan struct{}
+}
+
+func NewDispatcher(reporter ErrorReporter, queueSize int, batchSize int, flushInterval time.Duration) *Dispatcher {
+	d := &Dispatcher{
+		reporter:      reporter,
+		queue:         make(chan Report, queueSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		done:          make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// Report scrubs the report and queues it without blocking
+func (d *Dispatcher) Report(report Report) {
+	if report.Time.IsZero() {
+		report.Time = time.Now()
+	}
+	report = Scrub(report)
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return
+	}
+
+	select {
+	case d.queue <- report:
+	default:
+		if d.dropped.Add(1) == 1 {
+			logger.Log.Warning("[reporting] %s queue is full, dropping reports", d.reporter.Name())
+		}
+	}
+}
+
+// Dropped is the number of reports that did not fit in the queue
+func (d *Dispatcher) Dropped() int64 {
+	return d.dropped.Load()
+}
+
+// Close sends the queued reports and stops the dispatcher
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Report, 0, d.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		if err := d.reporter.Send(ctx, batch); err != nil {
+			logger.Log.Error("[reporting] %s could not send %d reports: %v", d.reporter.Name(), len(batch), err)
+		}
+		cancel()
+		batch = make([]Report, 0, d.batchSize)
+	}
+
+	for {
+		select {
+		case report, ok := <-d.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, report)
+			if len(batch) >= d.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+var (
+	dispatcherMu sync.RWMutex
+	dispatcher   *Dispatcher
+)
+
+// SetDispatcher replaces the dispatcher Capture queues on
+func SetDispatcher(d *Dispatcher) {
+	dispatcherMu.Lock()
+	defer dispatcherMu.Unlock()
+	dispatcher = d
+}
+
+// Capture queues a report on the dispatcher set up by Init, reports made
+// before Init are dropped
+func Capture(report Report) {
+	dispatcherMu.RLock()
+	d := dispatcher
+	dispatcherMu.RUnlock()
+
+	if d != nil {
+		d.Report(report)
+	}
+}
+
+// Dropped is the number of reports the dispatcher set up by Init dropped
+func Dropped() int64 {
+	dispatcherMu.RLock()
+	defer dispatcherMu.RUnlock()
+
+	if dispatcher == nil {
+		return 0
+	}
+	return dispatcher.Dropped()
+}
+
+// NewReporter builds the reporter picked with ERROR_REPORTER
+func NewReporter(name string) (ErrorReporter, error) {
+	switch name {
+	case "none":
+		return NoopReporter{}, nil
+	case "jarvis":
+		return JarvisReporter{}, nil
+	case "posthog":
+		return NewPosthogReporter(config.PosthogUrl, config.PosthogKey)
+	case "sentry":
+		return NewSentryReporter(config.SentryDsn)
+	case "file":
+		return NewFileReporter(config.ErrorReportFile), nil
+	}
+	return nil, fmt.Errorf("unknown ERROR_REPORTER %q, expected jarvis, posthog, sentry, file or none", name)
+}
+
+// Init starts the dispatcher of the reporter picked with ERROR_REPORTER.
+// The returned func sends the queued reports before the process exits.
+func Init() (func(context.Context) error, error) {
+	reporter, err := NewReporter(config.ErrorReporter)
+	if err != nil {
+		return nil, err
+	}
+
+	d := NewDispatcher(reporter, queueSize, batchSize, flushInterval)
+	SetDispatcher(d)
+
+	logger.Log.Info("[reporting] reporting errors with %s", reporter.Name())
+	return d.Close, nil
+}
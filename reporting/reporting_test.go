@@ -0,0 +1,148 @@
+package reporting
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingReporter struct {
+	mu      sync.Mutex
+	batches [][]Report
+	block   chan struct{}
+}
+
+func (r *recordingReporter) Name() string {
+	return "recording"
+}
+
+func (r *recordingReporter) Send(ctx context.Context, reports []Report) error {
+	if r.block != nil {
+		<-r.block
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, reports)
+	return nil
+}
+
+func (r *recordingReporter) reports() []Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var all []Report
+	for _, batch := range r.batches {
+		all = append(all, batch...)
+	}
+	return all
+}
+
+func TestDispatcher(t *testing.T) {
+	t.Run("should send full batches without waiting for the interval", func(t *testing.T) {
+		reporter := &recordingReporter{}
+		d := NewDispatcher(reporter, 10, 2, time.Hour)
+
+		d.Report(Report{Kind: KindError, Error: "first"})
+		d.Report(Report{Kind: KindError, Error: "second"})
+
+		assert.Eventually(t, func() bool { return len(reporter.reports()) == 2 }, time.Second, 10*time.Millisecond)
+		assert.NoError(t, d.Close(context.Background()))
+		assert.Len(t, reporter.batches, 1)
+	})
+
+	t.Run("should send a partial batch on the interval", func(t *testing.T) {
+		reporter := &recordingReporter{}
+		d := NewDispatcher(reporter, 10, 50, 20*time.Millisecond)
+		defer d.Close(context.Background())
+
+		d.Report(Report{Kind: KindError, Error: "alone"})
+
+		assert.Eventually(t, func() bool { return len(reporter.reports()) == 1 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should send queued reports on close", func(t *testing.T) {
+		reporter := &recordingReporter{}
+		d := NewDispatcher(reporter, 10, 50, time.Hour)
+
+		for i := 0; i < 3; i++ {
+			d.Report(Report{Kind: KindError, Error: fmt.Sprintf("report %d", i)})
+		}
+		assert.NoError(t, d.Close(context.Background()))
+
+		reports := reporter.reports()
+		assert.Len(t, reports, 3)
+		assert.False(t, reports[0].Time.IsZero())
+
+		// reports after close are ignored
+		assert.NotPanics(t, func() { d.Report(Report{Error: "late"}) })
+	})
+
+	t.Run("should drop reports when the queue is full", func(t *testing.T) {
+		reporter := &recordingReporter{block: make(chan struct{})}
+		d := NewDispatcher(reporter, 2, 1, time.Hour)
+
+		// the first report is taken by the blocked reporter, two fit in the queue
+		d.Report(Report{Error: "sending"})
+		assert.Eventually(t, func() bool { return len(d.queue) == 0 }, time.Second, time.Millisecond)
+		for i := 0; i < 5; i++ {
+			d.Report(Report{Error: "queued"})
+		}
+
+		assert.Equal(t, int64(3), d.Dropped())
+		close(reporter.block)
+		assert.NoError(t, d.Close(context.Background()))
+		assert.Len(t, reporter.reports(), 3)
+	})
+
+	t.Run("should scrub reports before they are sent", func(t *testing.T) {
+		reporter := &recordingReporter{}
+		d := NewDispatcher(reporter, 10, 50, time.Hour)
+
+		d.Report(Report{
+			Error:  "could not decode token=abc123",
+			Path:   "/bounty?token=abc123&page=1",
+			Fields: map[string]interface{}{"password": "hunter2", "workspace": "workspace_1"},
+		})
+		assert.NoError(t, d.Close(context.Background()))
+
+		report := reporter.reports()[0]
+		assert.Equal(t, "could not decode token="+redacted, report.Error)
+		assert.Equal(t, "/bounty?token="+redacted+"&page=1", report.Path)
+		assert.Equal(t, redacted, report.Fields["password"])
+		assert.Equal(t, "workspace_1", report.Fields["workspace"])
+	})
+}
+
+func TestCapture(t *testing.T) {
+	defer SetDispatcher(nil)
+
+	assert.NotPanics(t, func() { Capture(Report{Error: "before init"}) })
+	assert.Equal(t, int64(0), Dropped())
+
+	reporter := &recordingReporter{}
+	d := NewDispatcher(reporter, 10, 50, time.Hour)
+	SetDispatcher(d)
+
+	Capture(Report{Error: "after init"})
+	assert.NoError(t, d.Close(context.Background()))
+
+	reports := reporter.reports()
+	assert.Len(t, reports, 1)
+	assert.Equal(t, "after init", reports[0].Error)
+}
+
+func TestNewReporter(t *testing.T) {
+	reporter, err := NewReporter("none")
+	assert.NoError(t, err)
+	assert.Equal(t, "none", reporter.Name())
+
+	reporter, err = NewReporter("jarvis")
+	assert.NoError(t, err)
+	assert.Equal(t, "jarvis", reporter.Name())
+
+	_, err = NewReporter("datadog")
+	assert.Error(t, err)
+}
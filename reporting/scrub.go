@@ -0,0 +1,81 @@
+package reporting
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	// keys whose values are never sent, whatever they look like
+	sensitiveKey = regexp.MustCompile(`(?i)(token|secret|password|passwd|jwt|authorization|cookie|signature|api[_-]?key|private[_-]?key|preimage|macaroon|seed|mnemonic|(^|[_-])sig($|[_-]))`)
+
+	sensitiveValues = []*regexp.Regexp{
+		// JWTs
+		regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+		// bearer tokens
+		regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`),
+		// lightning invoices and LNURLs
+		regexp.MustCompile(`(?i)\b(lnbcrt|lntbs|lntb|lnbc|lnurl)[0-9a-z]{20,}\b`),
+		// 32 byte hex secrets like preimages, public keys are 33 bytes
+		regexp.MustCompile(`\b[0-9a-fA-F]{64}\b`),
+	}
+
+	// key=value pairs of query strings, headers and messages
+	sensitivePair = regexp.MustCompile(`(?i)\b([a-z_-]*(?:token|secret|password|jwt|signature|api[_-]?key|preimage|macaroon)[a-z_-]*)(["']?\s*[=:]\s*["']?)([^&\s"',}]+)`)
+
+	// argument words of goroutine stack frames, "main.pay(0xc000012345, 0x40)"
+	stackArguments = regexp.MustCompile(`(?m)^(\S.*)\((?:0x[0-9a-f]+|\.\.\.|\{[^)]*\}|\?|,|\s)+\)$`)
+)
+
+// ScrubText redacts the secrets found in a free form string
+func ScrubText(s string) string {
+	s = sensitivePair.ReplaceAllString(s, "${1}${2}"+redacted)
+	for _, re := range sensitiveValues {
+		s = re.ReplaceAllString(s, redacted)
+	}
+	return s
+}
+
+// ScrubStack drops the argument values of every frame of a stack trace,
+// they are raw words of memory that may point at secrets
+func ScrubStack(stack string) string {
+	return ScrubText(stackArguments.ReplaceAllString(stack, "${1}(...)"))
+}
+
+// ScrubFields redacts the values of sensitive keys and the secrets found
+// in the other values
+func ScrubFields(fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		return nil
+	}
+
+	scrubbed := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		if sensitiveKey.MatchString(key) {
+			scrubbed[key] = redacted
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			scrubbed[key] = ScrubText(v)
+		case map[string]interface{}:
+			scrubbed[key] = ScrubFields(v)
+		case bool, int, int64, uint, uint64, float64:
+			scrubbed[key] = v
+		default:
+			scrubbed[key] = ScrubText(fmt.Sprintf("%v", v))
+		}
+	}
+	return scrubbed
+}
+
+// Scrub redacts a report before it leaves the process
+func Scrub(report Report) Report {
+	report.Error = ScrubText(report.Error)
+	report.Stack = ScrubStack(report.Stack)
+	report.Path = ScrubText(report.Path)
+	report.Fields = ScrubFields(report.Fields)
+	return report
+}
@@ -51,6 +51,8 @@ func BountyRoutes() chi.Router {
 		r.Get("/payment/status/{id}", bountyHandler.GetBountyPaymentStatus)
 		r.Get("/payment/{bountyId}", handlers.GetPaymentByBountyId)
 		r.Put("/payment/status/{id}", bountyHandler.UpdateBountyPaymentStatus)
+		r.Post("/payout/{id}", bountyHandler.CreateBountyPayout)
+		r.Get("/payout/{id}", bountyHandler.GetBountyPayout)
 
 		r.Post("/{id}/proof", bountyHandler.AddProofOfWork)
 		r.Get("/{id}/proofs", bountyHandler.GetProofsByBounty)
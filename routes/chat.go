@@ -8,6 +8,7 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/stakwork/sphinx-tribes/db"
 	"github.com/stakwork/sphinx-tribes/handlers"
+	customMiddleware "github.com/stakwork/sphinx-tribes/middlewares"
 )
 
 func ChatRoutes() chi.Router {
@@ -15,8 +16,15 @@ func ChatRoutes() chi.Router {
 	chatHandler := handlers.NewChatHandler(http.DefaultClient, db.DB)
 
 	r.Post("/response", chatHandler.ProcessChatResponse)
+	r.Post("/response/stream", chatHandler.ProcessChatResponseChunk)
 	r.Post("/{chat_id}/update", chatHandler.HandleChatWebhook)
 
+	r.Group(func(r chi.Router) {
+		r.Use(auth.PubKeyContextSuperAdmin)
+
+		r.Get("/sse/clients", chatHandler.GetSSEClients)
+	})
+
 	r.Group(func(r chi.Router) {
 		r.Use(auth.CombinedAuthContext)
 
@@ -24,12 +32,15 @@ func ChatRoutes() chi.Router {
 		r.Post("/", chatHandler.CreateChat)
 		r.Put("/{chat_id}", chatHandler.UpdateChat)
 		r.Put("/{chat_id}/archive", chatHandler.ArchiveChat)
-		r.Post("/send", chatHandler.SendMessage)
+		r.Get("/{chat_id}/branches", chatHandler.GetChatBranches)
+		r.Put("/{chat_id}/branch", chatHandler.SwitchChatBranch)
+		r.With(customMiddleware.RateLimiter("chat")).Post("/send", chatHandler.SendMessage)
 		r.Get("/history/{uuid}", chatHandler.GetChatHistory)
-		r.Post("/send/build", chatHandler.SendBuildMessage)
-		r.Post("/send/action", chatHandler.SendActionMessage)
+		r.Get("/search", chatHandler.SearchChatHistory)
+		r.With(customMiddleware.RateLimiter("chat")).Post("/send/build", chatHandler.SendBuildMessage)
+		r.With(customMiddleware.RateLimiter("chat")).Post("/send/action", chatHandler.SendActionMessage)
 
-		r.Post("/upload", chatHandler.UploadFile)
+		r.With(customMiddleware.RateLimiter("upload")).Post("/upload", chatHandler.UploadFile)
 		r.Get("/file/{id}", chatHandler.GetFile)
 		r.Get("/file/all", chatHandler.ListFiles)
 		r.Delete("/file/{id}", chatHandler.DeleteFile)
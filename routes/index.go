@@ -1,35 +1,29 @@
 package routes
 
 import (
-	"bytes"
-	"compress/gzip"
-	"context"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"runtime"
-	"strings"
 	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
-	"github.com/posthog/posthog-go"
 	"github.com/rs/cors"
-	"github.com/urfave/negroni"
-	"github.com/xhd2015/xgo/runtime/core"
-	"github.com/xhd2015/xgo/runtime/trap"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/stakwork/sphinx-tribes/auth"
-	"github.com/stakwork/sphinx-tribes/config"
 	"github.com/stakwork/sphinx-tribes/db"
 	_ "github.com/stakwork/sphinx-tribes/docs"
 	"github.com/stakwork/sphinx-tribes/handlers"
 	"github.com/stakwork/sphinx-tribes/logger"
 	customMiddleware "github.com/stakwork/sphinx-tribes/middlewares"
-	"github.com/stakwork/sphinx-tribes/utils"
+	"github.com/stakwork/sphinx-tribes/monitoring"
+	"github.com/stakwork/sphinx-tribes/reporting"
+	"github.com/stakwork/sphinx-tribes/tracing"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
@@ -38,9 +32,15 @@ func NewRouter() *http.Server {
 	r := initChi()
 	tribeHandlers := handlers.NewTribeHandler(db.DB)
 	authHandler := handlers.NewAuthHandler(db.DB)
+	sessionHandler := handlers.NewSessionHandler(db.DB)
+	nostrHandler := handlers.NewNostrHandler(db.DB)
+	identityHandler := handlers.NewIdentityHandler(db.DB)
+	githubOAuthHandler := handlers.NewGithubOAuthHandler(db.DB)
 	channelHandler := handlers.NewChannelHandler(db.DB)
 	botHandler := handlers.NewBotHandler(db.DB)
 	bHandler := handlers.NewBountyHandler(http.DefaultClient, db.DB)
+	lnurlPayHandler := handlers.NewLnurlPayHandler(http.DefaultClient, db.DB)
+	settlementHandler := handlers.NewInvoiceSettlementHandler(http.DefaultClient, db.DB)
 
 	r.Mount("/tribes", TribeRoutes())
 	r.Mount("/bots", BotsRoutes())
@@ -52,6 +52,7 @@ func NewRouter() *http.Server {
 	r.Mount("/gobounties", BountyRoutes())
 	r.Mount("/workspaces", WorkspaceRoutes())
 	r.Mount("/metrics", MetricsRoutes())
+	r.Handle("/ops/metrics", monitoring.Handler())
 	r.Mount("/features", FeatureRoutes())
 	r.Mount("/workflows", WorkflowRoutes())
 	r.Mount("/bounties/ticket", TicketRoutes())
@@ -62,6 +63,7 @@ func NewRouter() *http.Server {
 	r.Mount("/activities", ActivityRoutes())
 	r.Mount("/skill", SkillRoutes())
 	r.Mount("/codespace", CodeSpaceRoutes())
+	r.Mount("/webhooks", WebhookRoutes())
 	r.Get("/docs/*", httpSwagger.WrapHandler)
 
 	r.Group(func(r chi.Router) {
@@ -86,7 +88,8 @@ func NewRouter() *http.Server {
 		r.Post("/save", db.PostSave)
 		r.Get("/save/{key}", db.PollSave)
 		r.Get("/migrate_bounties", handlers.MigrateBounties)
-		r.Get("/websocket", handlers.HandleWebSocket)
+		// a token is optional, it is required to subscribe to topics
+		r.With(auth.OptionalPubKeyContext).Get("/websocket", handlers.HandleWebSocket)
 	})
 
 	r.Group(func(r chi.Router) {
@@ -106,14 +109,40 @@ func NewRouter() *http.Server {
 		r.Get("/poll/invoice/{paymentRequest}", bHandler.PollInvoice)
 		r.Post("/meme_upload", handlers.MemeImageUpload)
 		r.Get("/admin/auth", authHandler.GetIsAdmin)
+		r.Get("/events", handlers.HandleEventStream)
+
+		r.Get("/sessions", sessionHandler.GetSessions)
+		r.Delete("/sessions", sessionHandler.RevokeAllSessions)
+		r.Delete("/sessions/{id}", sessionHandler.RevokeSession)
+
+		r.Get("/identities", identityHandler.GetIdentities)
+		r.Get("/identities/challenge", identityHandler.GetIdentityChallenge)
+		r.Post("/identities", identityHandler.LinkIdentity)
+		r.Delete("/identities/{id}", identityHandler.DeleteIdentity)
+		r.Get("/github/oauth/authorize", githubOAuthHandler.GetGithubOAuthURL)
 	})
 
 	r.Group(func(r chi.Router) {
-		r.Get("/lnauth_login", handlers.ReceiveLnAuthData)
+		r.Use(auth.PubKeyContextSuperAdmin)
+		r.Delete("/admin/sessions/{pubkey}", sessionHandler.AdminRevokeSessions)
+		r.Post("/admin/people/merge", identityHandler.MergePeople)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.With(customMiddleware.RateLimiter("login")).Get("/lnauth_login", handlers.ReceiveLnAuthData)
 		r.Get("/lnauth", handlers.GetLnurlAuth)
-		r.Get("/refresh_jwt", authHandler.RefreshToken)
-		r.Post("/invoices", handlers.GenerateInvoice)
-		r.Post("/budgetinvoices", tribeHandlers.GenerateBudgetInvoice)
+		r.Get("/nostr/challenge", nostrHandler.GetNostrChallenge)
+		r.Get("/github/oauth/callback", githubOAuthHandler.GithubOAuthCallback)
+		r.With(customMiddleware.RateLimiter("login")).Post("/nostr/login", nostrHandler.NostrLogin)
+		r.Get("/lnurl/withdraw", bHandler.LnurlWithdraw)
+		r.Get("/lnurl/withdraw/callback", bHandler.LnurlWithdrawCallback)
+		r.Get("/.well-known/lnurlp/{name}", lnurlPayHandler.LnurlPay)
+		r.With(customMiddleware.RateLimiter("invoices")).Get("/.well-known/lnurlp/{name}/callback", lnurlPayHandler.LnurlPayCallback)
+		r.With(customMiddleware.RateLimiter("login")).Get("/refresh_jwt", authHandler.RefreshToken)
+		r.With(customMiddleware.RateLimiter("login")).Post("/refresh_session", sessionHandler.RefreshSession)
+		r.With(customMiddleware.RateLimiter("invoices")).Post("/invoices", handlers.GenerateInvoice)
+		r.With(customMiddleware.RateLimiter("invoices")).Post("/budgetinvoices", tribeHandlers.GenerateBudgetInvoice)
+		r.Post("/invoices/settled", settlementHandler.InvoiceSettledWebhook)
 	})
 
 	PORT := os.Getenv("PORT")
@@ -162,189 +191,77 @@ func getFromAuth(path string) (*extractResponse, error) {
 	}, nil
 }
 
-func sendEdgeListToJarvis(edgeList utils.EdgeList) error {
-	if config.JarvisUrl == "" || config.JarvisToken == "" {
-		logger.Log.Info("Jarvis configuration not found, skipping error reporting")
-		return nil
-	}
-
-	jarvisURL := fmt.Sprintf("%s/node/edge/bulk", config.JarvisUrl)
-
-	jsonData, err := json.Marshal(edgeList)
-	if err != nil {
-		logger.Log.Error("Failed to marshal edge list: %v", err)
-		return nil
-	}
-
-	req, err := http.NewRequest("POST", jarvisURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		logger.Log.Error("Failed to create Jarvis request: %v", err)
-		return nil
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-token", config.JarvisToken)
-
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		logger.Log.Error("Failed to send error to Jarvis: %v", err)
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		logger.Log.Info("Successfully sent error to Jarvis")
-		return nil
-	}
-
-	body, _ := io.ReadAll(resp.Body)
-	return fmt.Errorf("jarvis returned non-success status: %d, body: %s", resp.StatusCode, string(body))
-}
-
-func compressToHex(input string) (string, error) {
-	// Create a buffer to hold the compressed data
-	var compressedBuffer bytes.Buffer
-
-	// Create a gzip writer
-	gzipWriter := gzip.NewWriter(&compressedBuffer)
-
-	// Write the input string to the gzip writer
-	_, err := gzipWriter.Write([]byte(input))
-	if err != nil {
-		return "", fmt.Errorf("failed to write to gzip writer: %w", err)
-	}
-
-	// Close the gzip writer to flush the data
-	err = gzipWriter.Close()
-	if err != nil {
-		return "", fmt.Errorf("failed to close gzip writer: %w", err)
-	}
-
-	// Encode the compressed data to hex
-	hexEncoded := hex.EncodeToString(compressedBuffer.Bytes())
-	return hexEncoded, nil
-}
-
-// Middleware to handle InternalServerError
+// Middleware to handle InternalServerError, panics and 5xx responses are
+// sent to the configured error reporter
 func internalServerErrorHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rr := negroni.NewResponseWriter(w)
-
-		var elements_chain strings.Builder
-		isExceedingLimit := false
-		trap.AddInterceptor(&trap.Interceptor{
-			Pre: func(ctx context.Context, f *core.FuncInfo, args core.Object, results core.Object) (interface{}, error) {
-				index := strings.Index(f.File, "sphinx-tribes")
-				trimmed := f.File
-				if index != -1 {
-					trimmed = f.File[index:]
-				}
-
-				newContent := fmt.Sprintf("%s:%d %s,\n", trimmed, f.Line, f.Name)
-				maxByteSize := 177000
-				if elements_chain.Len()+len(newContent) <= maxByteSize && isExceedingLimit == false {
-					elements_chain.WriteString(newContent)
-					if args != nil && args.NumField() != 0 {
-						for i := 0; i < args.NumField(); i++ {
-							thingWeWantToPrint := args.GetFieldIndex(i)
-							argNameAndValue := fmt.Sprintf("Name: %s Value: %#v\n", thingWeWantToPrint.Name(), thingWeWantToPrint.Value())
-							if elements_chain.Len()+len(argNameAndValue) <= maxByteSize {
-								elements_chain.WriteString(argNameAndValue)
-							} else {
-								fmt.Printf("elements_chain length exceeded 500KB, skipping further additions.\n")
-								isExceedingLimit = true
-							}
-						}
-					}
-				} else if isExceedingLimit == false {
-					fmt.Printf("elements_chain length exceeded 500KB, skipping further additions.\n")
-					isExceedingLimit = true
-				}
-
-				return nil, nil
-			},
-		})
-
-		defer func() {
-			posthog_key := os.Getenv("POSTHOG_KEY")
-			posthog_url := os.Getenv("POSTHOG_URL")
-			session_id := r.Header.Get("x-session-id")
-			if posthog_key != "" && posthog_url != "" && session_id != "" {
-				logger.Log.Info("Sending to Posthog")
-				client, _ := posthog.NewWithConfig(
-					posthog_key,
-					posthog.Config{
-						Endpoint: posthog_url,
-					},
-				)
-				defer client.Close()
-				elements_chain_string := elements_chain.String()
-				hexCompressed, _ := compressToHex(elements_chain_string)
-				_ = client.Enqueue(posthog.Capture{
-					DistinctId: session_id,         // Unique ID for the user
-					Event:      "backend_api_call", // The event name
-					Properties: map[string]interface{}{
-						"$session_id":     session_id,
-						"$event_type":     "backend_api_call",
-						"$elements_chain": hexCompressed,
-						"$current_url":    r.URL.Path,
-					},
-				})
-			}
-		}()
+		recorder := reporting.NewStatusRecorder(w)
 
 		defer func() {
 			if err := recover(); err != nil {
+				source := reporting.PanicSource()
+
 				// Get stack trace
 				buf := make([]byte, 4096)
-				n := runtime.Stack(buf, true)
+				n := runtime.Stack(buf, false)
 				stackTrace := string(buf[:n])
 
-				// Format stack trace to edge list
-				edgeList := utils.FormatStacktraceToEdgeList(stackTrace, err)
-
-				logger.Log.Error("Internal Server Error: %s %s\nError: %v\nStack Trace:\n%s\nEdge List:\n%+v\n",
+				logger.FromContext(r.Context()).Error("Internal Server Error: %s %s\nError: %v\nSource: %s\nStack Trace:\n%s\n",
 					r.Method,
 					r.URL.Path,
 					err,
+					source,
 					stackTrace,
-					utils.PrettyPrintEdgeList(edgeList),
 				)
 
-				go func() {
-					if err := sendEdgeListToJarvis(edgeList); err != nil {
-						logger.Log.Error("Error sending to Jarvis: %v\n", err)
-					}
-				}()
+				span := trace.SpanFromContext(r.Context())
+				span.RecordError(fmt.Errorf("panic: %v", err), trace.WithStackTrace(true))
+				span.SetStatus(codes.Error, "panic")
+
+				report := reporting.RequestReport(r, reporting.KindPanic, fmt.Sprint(err), http.StatusInternalServerError)
+				report.Source = source
+				report.Stack = stackTrace
+				reporting.Capture(report)
 
 				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			if status := recorder.Status(); status >= http.StatusInternalServerError {
+				message := recorder.ErrorBody()
+				if message == "" {
+					message = http.StatusText(status)
+				}
+				report := reporting.RequestReport(r, reporting.KindError, message, status)
+				report.Source = recorder.Source()
+				reporting.Capture(report)
 			}
 		}()
 
-		next.ServeHTTP(rr, r)
+		next.ServeHTTP(recorder, r)
 	})
 }
 
 func initChi() *chi.Mux {
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
-	r.Use(middleware.Logger)
+	r.Use(tracing.Middleware)
+	r.Use(monitoring.Middleware)
+	r.Use(logger.RequestLogger)
 	r.Use(middleware.Recoverer)
-	r.Use(logger.RouteBasedUUIDMiddleware)
 	r.Use(internalServerErrorHandler)
 	r.Use(customMiddleware.FeatureFlag(db.DB))
 	cors := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-User", "authorization", "x-jwt", "Referer", "User-Agent", "x-session-id"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-User", "authorization", "x-jwt", "Referer", "User-Agent", "x-session-id", "x-api-token", "Last-Event-ID"},
+		ExposedHeaders:   []string{"Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	})
 	r.Use(cors.Handler)
+	// after CORS so throttled responses still carry its headers
+	r.Use(customMiddleware.RateLimiter("global"))
 	r.Use(middleware.Timeout(60 * time.Second))
 	return r
 }
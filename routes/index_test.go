@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,417 +18,12 @@ import (
 	"github.com/go-chi/chi/middleware"
 	"github.com/rs/cors"
 	"github.com/stakwork/sphinx-tribes/auth"
-	"github.com/stakwork/sphinx-tribes/config"
-	"github.com/stakwork/sphinx-tribes/utils"
+	"github.com/stakwork/sphinx-tribes/reporting"
 	"github.com/stretchr/testify/assert"
 )
 
 const defaultAuthURL = "http://auth:9090"
 
-func TestSendEdgeListToJarvis(t *testing.T) {
-	originalURL := config.JarvisUrl
-	originalToken := config.JarvisToken
-	defer func() {
-		config.JarvisUrl = originalURL
-		config.JarvisToken = originalToken
-	}()
-
-	tests := []struct {
-		name          string
-		edgeList      utils.EdgeList
-		setupMock     func() *httptest.Server
-		setupConfig   func()
-		expectedError bool
-	}{
-		{
-			name: "Basic Functionality",
-			edgeList: utils.EdgeList{
-				EdgeList: []utils.Edge{
-					{
-						Edge: utils.EdgeInfo{
-							EdgeType: "test",
-							Weight:   1.0,
-						},
-						Source: utils.Node{
-							NodeType: "test",
-							NodeData: map[string]interface{}{"test": "data"},
-						},
-						Targets: []utils.Node{
-							{
-								NodeType: "test",
-								NodeData: map[string]interface{}{"test": "data"},
-							},
-						},
-					},
-				},
-			},
-			setupMock: func() *httptest.Server {
-				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
-					assert.Equal(t, "test-token", r.Header.Get("x-api-token"))
-					w.WriteHeader(http.StatusOK)
-				}))
-			},
-			setupConfig: func() {
-				config.JarvisToken = "test-token"
-			},
-			expectedError: false,
-		},
-		{
-			name: "Empty EdgeList",
-			edgeList: utils.EdgeList{
-				EdgeList: []utils.Edge{},
-			},
-			setupMock: func() *httptest.Server {
-				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					w.WriteHeader(http.StatusOK)
-				}))
-			},
-			setupConfig: func() {
-				config.JarvisToken = "test-token"
-			},
-			expectedError: false,
-		},
-		{
-			name: "Missing JarvisUrl",
-			edgeList: utils.EdgeList{
-				EdgeList: []utils.Edge{
-					{
-						Edge: utils.EdgeInfo{
-							EdgeType: "test",
-							Weight:   1.0,
-						},
-					},
-				},
-			},
-			setupMock: nil,
-			setupConfig: func() {
-				config.JarvisUrl = ""
-				config.JarvisToken = "test-token"
-			},
-			expectedError: false,
-		},
-		{
-			name: "Missing JarvisToken",
-			edgeList: utils.EdgeList{
-				EdgeList: []utils.Edge{
-					{
-						Edge: utils.EdgeInfo{
-							EdgeType: "test",
-							Weight:   1.0,
-						},
-					},
-				},
-			},
-			setupMock: nil,
-			setupConfig: func() {
-				config.JarvisUrl = "http://test.com"
-				config.JarvisToken = ""
-			},
-			expectedError: false,
-		},
-		{
-			name: "Invalid JSON Marshalling",
-			edgeList: utils.EdgeList{
-				EdgeList: []utils.Edge{
-					{
-						Edge: utils.EdgeInfo{
-							EdgeType: "test",
-							Weight:   1.0,
-						},
-						Source: utils.Node{
-							NodeType: "test",
-							NodeData: make(chan int),
-						},
-					},
-				},
-			},
-			setupMock: func() *httptest.Server {
-				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					w.WriteHeader(http.StatusOK)
-				}))
-			},
-			setupConfig: func() {
-				config.JarvisToken = "test-token"
-			},
-			expectedError: false,
-		},
-		{
-			name: "HTTP Request Creation Failure",
-			edgeList: utils.EdgeList{
-				EdgeList: []utils.Edge{
-					{
-						Edge: utils.EdgeInfo{
-							EdgeType: "test",
-							Weight:   1.0,
-						},
-					},
-				},
-			},
-			setupMock: nil,
-			setupConfig: func() {
-				config.JarvisUrl = "http://[::1]:namedport"
-				config.JarvisToken = "test-token"
-			},
-			expectedError: false,
-		},
-		{
-			name: "Network Failure",
-			edgeList: utils.EdgeList{
-				EdgeList: []utils.Edge{
-					{
-						Edge: utils.EdgeInfo{
-							EdgeType: "test",
-							Weight:   1.0,
-						},
-					},
-				},
-			},
-			setupMock: func() *httptest.Server {
-				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					panic("Force connection close")
-				}))
-				server.Close()
-				return server
-			},
-			setupConfig: func() {
-				config.JarvisToken = "test-token"
-			},
-			expectedError: true,
-		},
-		{
-			name: "Non-2xx HTTP Response",
-			edgeList: utils.EdgeList{
-				EdgeList: []utils.Edge{
-					{
-						Edge: utils.EdgeInfo{
-							EdgeType: "test",
-							Weight:   1.0,
-						},
-					},
-				},
-			},
-			setupMock: func() *httptest.Server {
-				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					w.WriteHeader(http.StatusInternalServerError)
-					json.NewEncoder(w).Encode(map[string]string{
-						"error": "Internal Server Error",
-					})
-				}))
-			},
-			setupConfig: func() {
-				config.JarvisToken = "test-token"
-			},
-			expectedError: true,
-		},
-		{
-			name: "Large Payload",
-			edgeList: utils.EdgeList{
-				EdgeList: func() []utils.Edge {
-					edges := make([]utils.Edge, 1000)
-					for i := 0; i < 1000; i++ {
-						edges[i] = utils.Edge{
-							Edge: utils.EdgeInfo{
-								EdgeType: fmt.Sprintf("test_%d", i),
-								Weight:   float64(i),
-							},
-							Source: utils.Node{
-								NodeType: "test",
-								NodeData: map[string]interface{}{
-									"data": strings.Repeat("large_payload", 100),
-								},
-							},
-						}
-					}
-					return edges
-				}(),
-			},
-			setupMock: func() *httptest.Server {
-				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					w.WriteHeader(http.StatusOK)
-				}))
-			},
-			setupConfig: func() {
-				config.JarvisToken = "test-token"
-			},
-			expectedError: false,
-		},
-		{
-			name: "Slow Server Response",
-			edgeList: utils.EdgeList{
-				EdgeList: []utils.Edge{{
-					Edge: utils.EdgeInfo{
-						EdgeType: "test",
-						Weight:   1.0,
-					},
-				}},
-			},
-			setupMock: func() *httptest.Server {
-				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					time.Sleep(11 * time.Second) // Longer than client timeout
-					w.WriteHeader(http.StatusOK)
-				}))
-			},
-			setupConfig: func() {
-				config.JarvisToken = "test-token"
-			},
-			expectedError: true,
-		},
-		{
-			name: "Special Characters in EdgeType",
-			edgeList: utils.EdgeList{
-				EdgeList: []utils.Edge{{
-					Edge: utils.EdgeInfo{
-						EdgeType: "test!@#$%^&*()",
-						Weight:   1.0,
-					},
-				}},
-			},
-			setupMock: func() *httptest.Server {
-				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					w.WriteHeader(http.StatusOK)
-				}))
-			},
-			setupConfig: func() {
-				config.JarvisToken = "test-token"
-			},
-			expectedError: false,
-		},
-		{
-			name: "Unicode Characters in NodeData",
-			edgeList: utils.EdgeList{
-				EdgeList: []utils.Edge{{
-					Edge: utils.EdgeInfo{
-						EdgeType: "test",
-						Weight:   1.0,
-					},
-					Source: utils.Node{
-						NodeType: "test",
-						NodeData: map[string]interface{}{
-							"data": "测试データ",
-						},
-					},
-				}},
-			},
-			setupMock: func() *httptest.Server {
-				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					w.WriteHeader(http.StatusOK)
-				}))
-			},
-			setupConfig: func() {
-				config.JarvisToken = "test-token"
-			},
-			expectedError: false,
-		},
-		{
-			name: "Malformed Response Body",
-			edgeList: utils.EdgeList{
-				EdgeList: []utils.Edge{{
-					Edge: utils.EdgeInfo{
-						EdgeType: "test",
-						Weight:   1.0,
-					},
-				}},
-			},
-			setupMock: func() *httptest.Server {
-				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					w.Header().Set("Content-Length", "1000")
-					w.WriteHeader(http.StatusBadRequest)
-					w.Write([]byte("Malformed JSON{"))
-				}))
-			},
-			setupConfig: func() {
-				config.JarvisToken = "test-token"
-			},
-			expectedError: true,
-		},
-		{
-			name: "Empty Response Body with Success Status",
-			edgeList: utils.EdgeList{
-				EdgeList: []utils.Edge{{
-					Edge: utils.EdgeInfo{
-						EdgeType: "test",
-						Weight:   1.0,
-					},
-				}},
-			},
-			setupMock: func() *httptest.Server {
-				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					w.WriteHeader(http.StatusOK)
-				}))
-			},
-			setupConfig: func() {
-				config.JarvisToken = "test-token"
-			},
-			expectedError: false,
-		},
-		{
-			name: "Invalid Content-Type Response",
-			edgeList: utils.EdgeList{
-				EdgeList: []utils.Edge{{
-					Edge: utils.EdgeInfo{
-						EdgeType: "test",
-						Weight:   1.0,
-					},
-				}},
-			},
-			setupMock: func() *httptest.Server {
-				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					w.Header().Set("Content-Type", "text/plain")
-					w.WriteHeader(http.StatusOK)
-					w.Write([]byte("Not JSON"))
-				}))
-			},
-			setupConfig: func() {
-				config.JarvisToken = "test-token"
-			},
-			expectedError: false,
-		},
-		{
-			name: "Redirect Response",
-			edgeList: utils.EdgeList{
-				EdgeList: []utils.Edge{{
-					Edge: utils.EdgeInfo{
-						EdgeType: "test",
-						Weight:   1.0,
-					},
-				}},
-			},
-			setupMock: func() *httptest.Server {
-				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					http.Redirect(w, r, "/new-location", http.StatusTemporaryRedirect)
-				}))
-			},
-			setupConfig: func() {
-				config.JarvisToken = "test-token"
-			},
-			expectedError: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if tt.setupConfig != nil {
-				tt.setupConfig()
-			}
-
-			if tt.setupMock != nil {
-				server := tt.setupMock()
-				defer server.Close()
-				config.JarvisUrl = server.URL
-			}
-
-			err := sendEdgeListToJarvis(tt.edgeList)
-
-			if tt.expectedError {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-			}
-		})
-	}
-}
-
 var currentAuthURL = defaultAuthURL
 
 var originalGetFromAuth = getFromAuth
@@ -1251,6 +847,75 @@ func TestInternalServerErrorHandler(t *testing.T) {
 	}
 }
 
+type capturedReports struct {
+	mu      sync.Mutex
+	reports []reporting.Report
+}
+
+func (c *capturedReports) Name() string {
+	return "captured"
+}
+
+func (c *capturedReports) Send(ctx context.Context, reports []reporting.Report) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reports = append(c.reports, reports...)
+	return nil
+}
+
+func TestInternalServerErrorHandlerReports(t *testing.T) {
+	serve := func(handler http.HandlerFunc) []reporting.Report {
+		captured := &capturedReports{}
+		dispatcher := reporting.NewDispatcher(captured, 10, 10, time.Hour)
+		reporting.SetDispatcher(dispatcher)
+		defer reporting.SetDispatcher(nil)
+
+		r := chi.NewRouter()
+		r.Use(internalServerErrorHandler)
+		r.Get("/bounties/{id}", handler)
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/bounties/1?token=secret", nil))
+
+		assert.NoError(t, dispatcher.Close(context.Background()))
+		return captured.reports
+	}
+
+	t.Run("should report panics with their source", func(t *testing.T) {
+		reports := serve(func(w http.ResponseWriter, r *http.Request) {
+			panic("test panic")
+		})
+
+		assert.Len(t, reports, 1)
+		assert.Equal(t, reporting.KindPanic, reports[0].Kind)
+		assert.Equal(t, "test panic", reports[0].Error)
+		assert.Equal(t, "/bounties/{id}", reports[0].Route)
+		assert.Equal(t, http.StatusInternalServerError, reports[0].Status)
+		assert.Contains(t, reports[0].Source, "index_test.go:")
+		assert.NotEmpty(t, reports[0].Stack)
+		assert.NotContains(t, reports[0].Path, "secret")
+	})
+
+	t.Run("should report handled 5xx with the code that wrote them", func(t *testing.T) {
+		reports := serve(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+			json.NewEncoder(w).Encode("stakwork is down")
+		})
+
+		assert.Len(t, reports, 1)
+		assert.Equal(t, reporting.KindError, reports[0].Kind)
+		assert.Equal(t, `"stakwork is down"`, reports[0].Error)
+		assert.Equal(t, http.StatusBadGateway, reports[0].Status)
+		assert.Contains(t, reports[0].Source, "index_test.go:")
+	})
+
+	t.Run("should not report other responses", func(t *testing.T) {
+		reports := serve(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		assert.Empty(t, reports)
+	})
+}
+
 func TestNewRouter(t *testing.T) {
 	t.Run("Basic Router Initialization", func(t *testing.T) {
 		server := NewRouter()
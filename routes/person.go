@@ -5,6 +5,7 @@ import (
 	"github.com/stakwork/sphinx-tribes/auth"
 	"github.com/stakwork/sphinx-tribes/db"
 	"github.com/stakwork/sphinx-tribes/handlers"
+	customMiddleware "github.com/stakwork/sphinx-tribes/middlewares"
 )
 
 func PersonRoutes() chi.Router {
@@ -20,7 +21,7 @@ func PersonRoutes() chi.Router {
 
 	r.Group(func(r chi.Router) {
 		r.Use(auth.CypressContext)
-		r.Post("/upsertlogin", peopleHandler.UpsertLogin)
+		r.With(customMiddleware.RateLimiter("login")).Post("/upsertlogin", peopleHandler.UpsertLogin)
 	})
 
 	r.Group(func(r chi.Router) {
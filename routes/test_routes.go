@@ -4,6 +4,9 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/handlers"
 )
 
 func TestRoutes() chi.Router {
@@ -13,5 +16,11 @@ func TestRoutes() chi.Router {
 		panic("Forced internal server error")
 	})
 
+	// stands in for the Lightning backend's settlement events in local development
+	if config.InvoiceSettlementFake {
+		settlementHandler := handlers.NewInvoiceSettlementHandler(http.DefaultClient, db.DB)
+		r.Post("/invoices/{paymentRequest}/settle", settlementHandler.FakeSettleInvoice)
+	}
+
 	return r
 }
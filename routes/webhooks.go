@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/handlers"
+)
+
+func WebhookRoutes() chi.Router {
+	r := chi.NewRouter()
+	webhookHandler := handlers.NewWebhookDeliveryHandler(db.DB)
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.PubKeyContextSuperAdmin)
+
+		r.Get("/dead-letters", webhookHandler.GetDeadLetters)
+		r.Post("/dead-letters/{id}/replay", webhookHandler.ReplayDeadLetter)
+	})
+	return r
+}
@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"net/http"
+
 	"github.com/go-chi/chi"
 	"github.com/stakwork/sphinx-tribes/auth"
 	"github.com/stakwork/sphinx-tribes/db"
@@ -10,6 +12,8 @@ import (
 func WorkspaceRoutes() chi.Router {
 	r := chi.NewRouter()
 	workspaceHandlers := handlers.NewWorkspaceHandler(db.DB)
+	apiKeyHandlers := handlers.NewAPIKeyHandler(db.DB)
+	lnurlPayHandler := handlers.NewLnurlPayHandler(http.DefaultClient, db.DB)
 	r.Group(func(r chi.Router) {
 		r.Get("/", handlers.GetWorkspaces)
 		r.Get("/count", handlers.GetWorkspacesCount)
@@ -20,6 +24,7 @@ func WorkspaceRoutes() chi.Router {
 		r.Get("/bounties/{uuid}/count", workspaceHandlers.GetWorkspaceBountiesCount)
 		r.Get("/user/{userId}", handlers.GetUserWorkspaces)
 		r.Get("/user/dropdown/{userId}", workspaceHandlers.GetUserDropdownWorkspaces)
+		r.Get("/lightning-address/{uuid}", lnurlPayHandler.GetWorkspaceLightningAddress)
 	})
 	r.Group(func(r chi.Router) {
 		r.Use(auth.CombinedAuthContext)
@@ -40,6 +45,16 @@ func WorkspaceRoutes() chi.Router {
 		r.Get("/invoices/count/{uuid}", handlers.GetInvoicesCount)
 		r.Get("/user/invoices/count", handlers.GetAllUserInvoicesCount)
 		r.Delete("/delete/{uuid}", workspaceHandlers.DeleteWorkspace)
+		r.Post("/restore/{uuid}", workspaceHandlers.RestoreWorkspace)
+		r.Get("/deleted", workspaceHandlers.GetDeletedWorkspaces)
+		r.Get("/{workspace_uuid}/export", workspaceHandlers.ExportWorkspace)
+		r.Post("/import", workspaceHandlers.ImportWorkspace)
+
+		r.Post("/{workspace_uuid}/api-keys", apiKeyHandlers.CreateWorkspaceAPIKey)
+		r.Get("/{workspace_uuid}/api-keys", apiKeyHandlers.GetWorkspaceAPIKeys)
+		r.Delete("/{workspace_uuid}/api-keys/{id}", apiKeyHandlers.RevokeWorkspaceAPIKey)
+		r.Post("/lightning-address/{uuid}", lnurlPayHandler.AssignWorkspaceLightningAddress)
+		r.Put("/lightning-address/{uuid}", lnurlPayHandler.SetWorkspaceLightningAddress)
 
 		r.Post("/mission", workspaceHandlers.UpdateWorkspace)
 		r.Post("/tactics", workspaceHandlers.UpdateWorkspace)
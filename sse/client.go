@@ -2,17 +2,38 @@ package sse
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stakwork/sphinx-tribes/db"
 	"github.com/stakwork/sphinx-tribes/logger"
 )
 
+const (
+	defaultRetryInterval = 3 * time.Second
+	maxRetryInterval     = 5 * time.Minute
+	// the shortest retry a server can ask for
+	minRetryInterval = time.Second
+	// a client that cannot reach its server for this long gives up
+	defaultMaxAge = 60 * time.Minute
+	// subscriptions whose instance missed heartbeats for this long are
+	// taken over by another one
+	claimAfter = 3 * time.Minute
+)
+
+// Instance names this process as the owner of the subscriptions it runs
+var Instance = uuid.New().String()
+
 var ClientRegistry = &Registry{
 	clients: make(map[string]*Client),
 	mutex:   &sync.RWMutex{},
@@ -27,19 +48,24 @@ func GenerateClientKey(chatID, sseURL string) string {
 	return fmt.Sprintf("%s:%s", chatID, sseURL)
 }
 
+// Register adds the client, a client already registered for the same chat
+// and URL is stopped without ending its subscription
 func (r *Registry) Register(client *Client) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	key := GenerateClientKey(client.ChatID, client.URL)
+	if previous, exists := r.clients[key]; exists && previous != client {
+		previous.release()
+	}
 	r.clients[key] = client
 }
 
-func (r *Registry) Unregister(sseURL, chatID string) bool {
+func (r *Registry) Unregister(ctx context.Context, sseURL, chatID string) bool {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
 	key := GenerateClientKey(chatID, sseURL)
-	logger.Log.Info("Attempting to unregister client with key: %s", key)
+	logger.FromContext(ctx).Debug("Attempting to unregister client with key: %s", key)
 
 	if client, exists := r.clients[key]; exists {
 		client.Stop()
@@ -47,84 +73,211 @@ func (r *Registry) Unregister(sseURL, chatID string) bool {
 		return true
 	}
 
-	logger.Log.Info("No client found. Currently registered clients:")
+	logger.FromContext(ctx).Debug("No client found. Currently registered clients:")
 	for k := range r.clients {
-		logger.Log.Info("- %s", k)
+		logger.FromContext(ctx).Debug("- %s", k)
 	}
 
 	return false
 }
 
+// remove forgets a client that ended by itself
+func (r *Registry) remove(client *Client) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := GenerateClientKey(client.ChatID, client.URL)
+	if r.clients[key] == client {
+		delete(r.clients, key)
+	}
+}
+
 type Client struct {
-	URL           string
-	ChatID        string
-	WebhookURL    string
-	LastEventID   string
-	RetryInterval time.Duration
-	Client        *http.Client
-	DB            db.Database
-	stopChan      chan struct{}
+	URL         string
+	ChatID      string
+	WebhookURL  string
+	LastEventID string
+	// RetryInterval is the first wait after a failure, every further
+	// failure doubles it up to MaxRetryInterval
+	RetryInterval    time.Duration
+	MaxRetryInterval time.Duration
+	// MaxAge is how long the client keeps retrying a server it cannot
+	// reach before the subscription expires
+	MaxAge time.Duration
+	Client *http.Client
+	DB     db.Database
+
+	ctx           context.Context
+	cancel        context.CancelFunc
 	firstFailTime time.Time
+	failures      int
+	log           *logger.Logger
+
+	mu     sync.Mutex
+	status ClientStatus
+}
+
+// ClientStatus is what the admin view shows of a running client
+type ClientStatus struct {
+	ChatID      string                  `json:"chat_id"`
+	URL         string                  `json:"url"`
+	WebhookURL  string                  `json:"webhook_url"`
+	State       db.SSESubscriptionState `json:"state"`
+	LastEventID string                  `json:"last_event_id"`
+	LastEventAt *time.Time              `json:"last_event_at"`
+	ConnectedAt *time.Time              `json:"connected_at"`
+	ErrorCount  int                     `json:"error_count"`
+	LastError   string                  `json:"last_error,omitempty"`
+	NextRetryAt *time.Time              `json:"next_retry_at,omitempty"`
+	StartedAt   time.Time               `json:"started_at"`
 }
 
 func NewClient(sseURL string, chatID string, webhookURL string, database db.Database) *Client {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Client{
-		URL:           sseURL,
-		ChatID:        chatID,
-		WebhookURL:    webhookURL,
-		RetryInterval: 3 * time.Second,
+		URL:              sseURL,
+		ChatID:           chatID,
+		WebhookURL:       webhookURL,
+		RetryInterval:    defaultRetryInterval,
+		MaxRetryInterval: maxRetryInterval,
+		MaxAge:           defaultMaxAge,
 		Client: &http.Client{
 			Timeout: 0,
 		},
-		DB:       database,
-		stopChan: make(chan struct{}),
+		DB:     database,
+		ctx:    ctx,
+		cancel: cancel,
+		log:    logger.Log.With("chat_id", chatID, "sse_url", sseURL),
+		status: ClientStatus{
+			ChatID:     chatID,
+			URL:        sseURL,
+			WebhookURL: webhookURL,
+			State:      db.SSESubscriptionConnecting,
+		},
 	}
 }
 
-func (c *Client) Start() {
+// newClientFromSubscription resumes a persisted subscription after the
+// last event it stored
+func newClientFromSubscription(subscription db.SSESubscription, database db.Database) *Client {
+	client := NewClient(subscription.URL, subscription.ChatID, subscription.WebhookURL, database)
+	client.LastEventID = subscription.LastEventID
+	client.status.LastEventID = subscription.LastEventID
+	client.status.LastEventAt = subscription.LastEventAt
+	client.status.ErrorCount = subscription.ErrorCount
+	return client
+}
 
+func (c *Client) Start() {
+	c.persistStart()
 	ClientRegistry.Register(c)
 
-	go func() {
-		defer func() {
+	c.mu.Lock()
+	c.status.StartedAt = time.Now()
+	c.mu.Unlock()
 
-			ClientRegistry.Unregister(c.URL, c.ChatID)
-		}()
+	go func() {
+		defer ClientRegistry.remove(c)
 
 		for {
-			select {
-			case <-c.stopChan:
-				logger.Log.Info("[ChatID: %s] SSE client stopped", c.ChatID)
+			if c.ctx.Err() != nil {
+				c.log.Info("[sse] client stopped")
 				return
-			default:
-				err := c.connect()
-				if err != nil {
-					if c.firstFailTime.IsZero() {
-						c.firstFailTime = time.Now()
-					} else if time.Since(c.firstFailTime) > 60*time.Minute {
-						logger.Log.Error("[ChatID: %s] Server unreachable for 60 minutes, stopping client", c.ChatID)
-						return
-					}
+			}
 
-					logger.Log.Error("[ChatID: %s] Connection error: %v. Retrying in %v...", c.ChatID, err, c.RetryInterval)
-					time.Sleep(c.RetryInterval)
-					continue
+			c.setState(db.SSESubscriptionConnecting, nil)
+			err := c.connect()
+			if c.ctx.Err() != nil {
+				c.log.Info("[sse] client stopped")
+				return
+			}
+
+			wait := c.RetryInterval
+			if err != nil {
+				if c.firstFailTime.IsZero() {
+					c.firstFailTime = time.Now()
+				} else if time.Since(c.firstFailTime) > c.MaxAge {
+					c.log.Error("[sse] server unreachable for %v, stopping client", c.MaxAge)
+					c.setState(db.SSESubscriptionExpired, map[string]interface{}{"owner": ""})
+					return
 				}
 
+				c.failures++
+				wait = c.backoff(c.failures)
+				c.recordError(err, wait)
+				c.log.Error("[sse] connection error: %v. Retrying in %v...", err, wait)
+			} else {
 				c.firstFailTime = time.Time{}
+				c.failures = 0
+			}
 
-				time.Sleep(c.RetryInterval)
+			select {
+			case <-c.ctx.Done():
+				c.log.Info("[sse] client stopped")
+				return
+			case <-time.After(wait):
 			}
 		}
 	}()
 }
 
+// Stop ends the client and its subscription, it is not resumed
 func (c *Client) Stop() {
-	close(c.stopChan)
+	c.cancel()
+	c.setState(db.SSESubscriptionStopped, map[string]interface{}{"owner": ""})
+}
+
+// release ends the client and leaves its subscription to be resumed, by
+// this instance or another one
+func (c *Client) release() {
+	c.cancel()
+}
+
+// Status is a snapshot of the state of the client
+func (c *Client) Status() ClientStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status
+}
+
+// backoff is the wait before the next attempt after failures in a row,
+// doubling from RetryInterval up to MaxRetryInterval with jitter so
+// clients that failed together do not retry together
+func (c *Client) backoff(failures int) time.Duration {
+	wait := c.RetryInterval
+	for i := 1; i < failures && wait < c.MaxRetryInterval; i++ {
+		wait *= 2
+	}
+	if wait > c.MaxRetryInterval {
+		wait = c.MaxRetryInterval
+	}
+	if half := int64(wait / 2); half > 0 {
+		wait = time.Duration(half + rand.Int63n(half+1))
+	}
+	return wait
+}
+
+// setRetry applies the retry field of the stream, in milliseconds. The
+// server can slow the client down but not make it reconnect in a tight
+// loop, values that are not positive are ignored.
+func (c *Client) setRetry(value string) {
+	ms, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || ms <= 0 {
+		return
+	}
+
+	retry := c.MaxRetryInterval
+	if ms < int64(c.MaxRetryInterval/time.Millisecond) {
+		retry = time.Duration(ms) * time.Millisecond
+	}
+	if retry < minRetryInterval {
+		retry = minRetryInterval
+	}
+	c.RetryInterval = retry
 }
 
 func (c *Client) connect() error {
-	req, err := http.NewRequest("GET", c.URL, nil)
+	req, err := http.NewRequestWithContext(c.ctx, "GET", c.URL, nil)
 	if err != nil {
 		return fmt.Errorf("error creating request: %w", err)
 	}
@@ -135,7 +288,7 @@ func (c *Client) connect() error {
 		req.Header.Set("Last-Event-ID", c.LastEventID)
 	}
 
-	logger.Log.Info("[ChatID: %s] Connecting to SSE endpoint: %s", c.ChatID, c.URL)
+	c.log.Info("[sse] connecting to SSE endpoint")
 	resp, err := c.Client.Do(req)
 	if err != nil {
 		return fmt.Errorf("connection failed: %w", err)
@@ -151,7 +304,8 @@ func (c *Client) connect() error {
 		return fmt.Errorf("invalid content type: %s", contentType)
 	}
 
-	logger.Log.Info("[ChatID: %s] Connected successfully, waiting for events...", c.ChatID, c.URL)
+	c.log.Info("[sse] connected, waiting for events...")
+	c.setConnected()
 	return c.processEvents(resp)
 }
 
@@ -165,7 +319,7 @@ func (c *Client) processEvents(resp *http.Response) error {
 
 	for scanner.Scan() {
 		select {
-		case <-c.stopChan:
+		case <-c.ctx.Done():
 			return nil
 		default:
 			line := scanner.Text()
@@ -175,12 +329,13 @@ func (c *Client) processEvents(resp *http.Response) error {
 
 					err := c.storeEvent(eventData)
 					if err != nil {
-						logger.Log.Error("[ChatID: %s] Error storing event: %v", c.ChatID, err)
+						c.log.Error("[sse] error storing event: %v", err)
 					}
 
 					if eventData["id"] != "" {
 						c.LastEventID = eventData["id"]
 					}
+					c.recordEvent()
 
 					eventData = map[string]string{
 						"id":    "",
@@ -207,14 +362,14 @@ func (c *Client) processEvents(resp *http.Response) error {
 			} else if strings.HasPrefix(line, "event:") {
 				eventData["event"] = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
 			} else if strings.HasPrefix(line, "retry:") {
-				retryStr := strings.TrimSpace(strings.TrimPrefix(line, "retry:"))
-				if retry, err := time.ParseDuration(retryStr + "ms"); err == nil {
-					c.RetryInterval = retry
-				}
+				c.setRetry(strings.TrimPrefix(line, "retry:"))
 			}
 		}
 	}
 
+	if c.ctx.Err() != nil {
+		return nil
+	}
 	if scanner.Err() != nil {
 		return fmt.Errorf("error reading events: %w", scanner.Err())
 	}
@@ -250,21 +405,134 @@ func (c *Client) storeEvent(eventData map[string]string) error {
 		return fmt.Errorf("failed to create SSE message log: %w", err)
 	}
 
-	logger.Log.Info("[ChatID: %s] Stored SSE event with ID: %s", c.ChatID, messageLog.ID)
+	c.log.Info("[sse] stored SSE event with ID: %s", messageLog.ID)
 	return nil
 }
 
-func (r *Registry) HasClient(sseURL, chatID string) bool {
+// persistStart records the subscription as run by this instance, a
+// subscription followed before hands over the last event it stored
+func (c *Client) persistStart() {
+	now := time.Now()
+	saved, err := c.DB.SaveSSESubscription(&db.SSESubscription{
+		ChatID:      c.ChatID,
+		URL:         c.URL,
+		WebhookURL:  c.WebhookURL,
+		State:       db.SSESubscriptionConnecting,
+		Owner:       Instance,
+		HeartbeatAt: &now,
+	})
+	if err != nil {
+		c.log.Error("[sse] could not save subscription, it will not survive a restart: %v", err)
+		return
+	}
+	if c.LastEventID == "" && saved.LastEventID != "" {
+		c.LastEventID = saved.LastEventID
+		c.mu.Lock()
+		c.status.LastEventID = saved.LastEventID
+		c.status.LastEventAt = saved.LastEventAt
+		c.mu.Unlock()
+	}
+}
+
+func (c *Client) persist(updates map[string]interface{}) {
+	if err := c.DB.UpdateSSESubscription(c.ChatID, c.URL, updates); err != nil {
+		c.log.Error("[sse] could not update subscription: %v", err)
+	}
+}
+
+func (c *Client) setState(state db.SSESubscriptionState, updates map[string]interface{}) {
+	c.mu.Lock()
+	changed := c.status.State != state
+	c.status.State = state
+	c.mu.Unlock()
+
+	if !changed && updates == nil {
+		return
+	}
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["state"] = state
+	c.persist(updates)
+}
+
+func (c *Client) setConnected() {
+	now := time.Now()
+	c.mu.Lock()
+	c.status.ConnectedAt = &now
+	c.status.NextRetryAt = nil
+	c.mu.Unlock()
+
+	c.setState(db.SSESubscriptionConnected, nil)
+}
+
+func (c *Client) recordEvent() {
+	now := time.Now()
+	c.mu.Lock()
+	c.status.LastEventID = c.LastEventID
+	c.status.LastEventAt = &now
+	c.mu.Unlock()
+
+	c.persist(map[string]interface{}{
+		"last_event_id": c.LastEventID,
+		"last_event_at": now,
+	})
+}
+
+func (c *Client) recordError(err error, wait time.Duration) {
+	next := time.Now().Add(wait)
+	c.mu.Lock()
+	c.status.State = db.SSESubscriptionRetrying
+	c.status.ErrorCount++
+	c.status.LastError = err.Error()
+	c.status.NextRetryAt = &next
+	errorCount := c.status.ErrorCount
+	c.mu.Unlock()
+
+	c.persist(map[string]interface{}{
+		"state":       db.SSESubscriptionRetrying,
+		"error_count": errorCount,
+		"last_error":  err.Error(),
+	})
+}
+
+func (r *Registry) HasClient(ctx context.Context, sseURL, chatID string) bool {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	key := GenerateClientKey(chatID, sseURL)
-	logger.Log.Info("Checking for client with key: %s", key)
+	logger.FromContext(ctx).Debug("Checking for client with key: %s", key)
 	_, exists := r.clients[key]
 
 	return exists
 }
 
+// Count is the number of running clients
+func (r *Registry) Count() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.clients)
+}
+
+// Statuses are the states of the running clients, by chat
+func (r *Registry) Statuses() []ClientStatus {
+	r.mutex.RLock()
+	statuses := make([]ClientStatus, 0, len(r.clients))
+	for _, client := range r.clients {
+		statuses = append(statuses, client.Status())
+	}
+	r.mutex.RUnlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].ChatID != statuses[j].ChatID {
+			return statuses[i].ChatID < statuses[j].ChatID
+		}
+		return statuses[i].URL < statuses[j].URL
+	})
+	return statuses
+}
+
 func (r *Registry) StopAllClients() int {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
@@ -277,3 +545,44 @@ func (r *Registry) StopAllClients() int {
 
 	return count
 }
+
+// Sync keeps the subscriptions of this instance claimed and resumes the
+// ones no instance runs, after a restart or when another instance died.
+// It returns the number of resumed subscriptions.
+func (r *Registry) Sync(database db.Database) (int, error) {
+	touchErr := database.TouchSSESubscriptions(Instance)
+	if touchErr != nil {
+		touchErr = fmt.Errorf("could not renew subscriptions: %w", touchErr)
+	}
+
+	subscriptions, err := database.ClaimSSESubscriptions(Instance, time.Now().Add(-claimAfter))
+	if err != nil {
+		return 0, errors.Join(touchErr, fmt.Errorf("could not claim subscriptions: %w", err))
+	}
+
+	resumed := 0
+	for _, subscription := range subscriptions {
+		if r.HasClient(context.Background(), subscription.URL, subscription.ChatID) {
+			continue
+		}
+		logger.Log.Info("[sse] resuming subscription of chat %s to %s after event %q", subscription.ChatID, subscription.URL, subscription.LastEventID)
+		newClientFromSubscription(subscription, database).Start()
+		resumed++
+	}
+	return resumed, touchErr
+}
+
+// Release stops the clients of this instance on shutdown and lets the
+// next instance resume their subscriptions right away
+func (r *Registry) Release(database db.Database) {
+	r.mutex.Lock()
+	for key, client := range r.clients {
+		client.release()
+		delete(r.clients, key)
+	}
+	r.mutex.Unlock()
+
+	if err := database.ReleaseSSESubscriptions(Instance); err != nil {
+		logger.Log.Error("[sse] could not release subscriptions: %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package sse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stakwork/sphinx-tribes/db"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// stubSubscriptions lets a client persist without a database
+func stubSubscriptions(mockDb *dbMocks.Database) {
+	mockDb.On("SaveSSESubscription", mock.Anything).Return(func(subscription *db.SSESubscription) *db.SSESubscription {
+		return subscription
+	}, nil).Maybe()
+	mockDb.On("UpdateSSESubscription", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockDb.On("CreateSSEMessageLog", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&db.SSEMessageLog{}, nil).Maybe()
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	client := NewClient("http://sse", "chat-id", "http://webhook", nil)
+	client.RetryInterval = time.Second
+	client.MaxRetryInterval = 10 * time.Second
+
+	for failures, max := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 30: 10 * time.Second} {
+		for i := 0; i < 20; i++ {
+			wait := client.backoff(failures)
+			assert.GreaterOrEqual(t, wait, max/2, "failures %d", failures)
+			assert.LessOrEqual(t, wait, max, "failures %d", failures)
+		}
+	}
+}
+
+func TestSetRetry(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"5000":       5 * time.Second,
+		" 2000":      2 * time.Second,
+		"10":         minRetryInterval,
+		"0":          defaultRetryInterval,
+		"-100":       defaultRetryInterval,
+		"soon":       defaultRetryInterval,
+		"":           defaultRetryInterval,
+		"9999999999": maxRetryInterval,
+	} {
+		client := NewClient("http://sse", "chat-id", "http://webhook", nil)
+		client.setRetry(value)
+		assert.Equal(t, want, client.RetryInterval, "retry: %q", value)
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	t.Run("should resume after the last event it received", func(t *testing.T) {
+		var mu sync.Mutex
+		var lastEventIDs []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+			attempt := len(lastEventIDs)
+			mu.Unlock()
+
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "id: event-%d\ndata: {\"attempt\":%d}\n\n", attempt, attempt)
+		}))
+		defer server.Close()
+
+		mockDb := dbMocks.NewDatabase(t)
+		stubSubscriptions(mockDb)
+
+		client := NewClient(server.URL, "chat-id", "http://webhook", mockDb)
+		client.RetryInterval = 10 * time.Millisecond
+		client.Start()
+		defer ClientRegistry.Unregister(context.Background(), server.URL, "chat-id")
+
+		waitFor(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(lastEventIDs) >= 3
+		})
+
+		mu.Lock()
+		assert.Equal(t, []string{"", "event-1", "event-2"}, lastEventIDs[:3])
+		mu.Unlock()
+		mockDb.AssertCalled(t, "UpdateSSESubscription", "chat-id", server.URL, mock.MatchedBy(func(updates map[string]interface{}) bool {
+			return updates["last_event_id"] == "event-1"
+		}))
+	})
+
+	t.Run("should start from the event stored for the subscription", func(t *testing.T) {
+		received := make(chan string, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case received <- r.Header.Get("Last-Event-ID"):
+			default:
+			}
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		mockDb := dbMocks.NewDatabase(t)
+		mockDb.On("SaveSSESubscription", mock.Anything).Return(&db.SSESubscription{LastEventID: "stored"}, nil)
+		mockDb.On("UpdateSSESubscription", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+		client := NewClient(server.URL, "chat-id", "http://webhook", mockDb)
+		client.Start()
+		defer ClientRegistry.Unregister(context.Background(), server.URL, "chat-id")
+
+		select {
+		case id := <-received:
+			assert.Equal(t, "stored", id)
+		case <-time.After(2 * time.Second):
+			t.Fatal("client did not connect")
+		}
+	})
+
+	t.Run("should expire a subscription whose server stays unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		mockDb := dbMocks.NewDatabase(t)
+		stubSubscriptions(mockDb)
+
+		client := NewClient(server.URL, "chat-id", "http://webhook", mockDb)
+		client.RetryInterval = time.Millisecond
+		client.MaxRetryInterval = 5 * time.Millisecond
+		client.MaxAge = 50 * time.Millisecond
+		client.Start()
+
+		waitFor(t, func() bool {
+			return client.Status().State == db.SSESubscriptionExpired
+		})
+		waitFor(t, func() bool {
+			return !ClientRegistry.HasClient(context.Background(), server.URL, "chat-id")
+		})
+
+		assert.Greater(t, client.Status().ErrorCount, 1)
+		mockDb.AssertCalled(t, "UpdateSSESubscription", "chat-id", server.URL, map[string]interface{}{
+			"state": db.SSESubscriptionExpired,
+			"owner": "",
+		})
+	})
+}
+
+func TestRegistry(t *testing.T) {
+	t.Run("should release a client replaced for the same chat and URL", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		stubSubscriptions(mockDb)
+
+		first := NewClient("http://sse", "chat-id", "http://webhook", mockDb)
+		second := NewClient("http://sse", "chat-id", "http://webhook", mockDb)
+		ClientRegistry.Register(first)
+		ClientRegistry.Register(second)
+		defer ClientRegistry.Unregister(context.Background(), "http://sse", "chat-id")
+
+		assert.Error(t, first.ctx.Err())
+		assert.NoError(t, second.ctx.Err())
+		mockDb.AssertNotCalled(t, "UpdateSSESubscription", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should resume the claimed subscriptions not running here", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		mockDb := dbMocks.NewDatabase(t)
+		stubSubscriptions(mockDb)
+		mockDb.On("TouchSSESubscriptions", Instance).Return(nil)
+		mockDb.On("ClaimSSESubscriptions", Instance, mock.Anything).Return([]db.SSESubscription{
+			{ChatID: "resumed-chat", URL: server.URL, WebhookURL: "http://webhook", LastEventID: "event-7"},
+		}, nil)
+		mockDb.On("ReleaseSSESubscriptions", Instance).Return(nil)
+
+		resumed, err := ClientRegistry.Sync(mockDb)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, resumed)
+		assert.True(t, ClientRegistry.HasClient(context.Background(), server.URL, "resumed-chat"))
+		resumed, err = ClientRegistry.Sync(mockDb)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, resumed)
+
+		ClientRegistry.Release(mockDb)
+		assert.False(t, ClientRegistry.HasClient(context.Background(), server.URL, "resumed-chat"))
+		mockDb.AssertNotCalled(t, "UpdateSSESubscription", "resumed-chat", server.URL, map[string]interface{}{
+			"state": db.SSESubscriptionStopped,
+			"owner": "",
+		})
+	})
+}
@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const parentContextKey = "tracing:parent_context"
+
+// GormPlugin starts a span for every query GORM runs as a child of the
+// span in the query's context. Queries made without one, through db.DB
+// without WithContext or from a cron, are not traced instead of starting
+// traces of their own.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+
+	for _, err := range []error{
+		callback.Create().Before("*").Register("tracing:before_create", startGormSpan("create")),
+		callback.Create().After("*").Register("tracing:after_create", endGormSpan("create")),
+		callback.Query().Before("*").Register("tracing:before_query", startGormSpan("query")),
+		callback.Query().After("*").Register("tracing:after_query", endGormSpan("query")),
+		callback.Update().Before("*").Register("tracing:before_update", startGormSpan("update")),
+		callback.Update().After("*").Register("tracing:after_update", endGormSpan("update")),
+		callback.Delete().Before("*").Register("tracing:before_delete", startGormSpan("delete")),
+		callback.Delete().After("*").Register("tracing:after_delete", endGormSpan("delete")),
+		callback.Row().Before("*").Register("tracing:before_row", startGormSpan("row")),
+		callback.Row().After("*").Register("tracing:after_row", endGormSpan("row")),
+		callback.Raw().Before("*").Register("tracing:before_raw", startGormSpan("raw")),
+		callback.Raw().After("*").Register("tracing:after_raw", endGormSpan("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startGormSpan(operation string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx := tx.Statement.Context
+		if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+			return
+		}
+		tx.InstanceSet(parentContextKey, ctx)
+
+		tx.Statement.Context, _ = StartSpan(ctx, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemPostgreSQL),
+		)
+	}
+}
+
+func endGormSpan(operation string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		parent, ok := tx.InstanceGet(parentContextKey)
+		if !ok {
+			return
+		}
+
+		span := trace.SpanFromContext(tx.Statement.Context)
+		tx.Statement.Context = parent.(context.Context)
+
+		if tx.Statement.Table != "" {
+			span.SetName("gorm." + operation + " " + tx.Statement.Table)
+			span.SetAttributes(semconv.DBCollectionName(tx.Statement.Table))
+		}
+		// the SQL keeps its placeholders, the values are never recorded
+		span.SetAttributes(
+			semconv.DBQueryText(tx.Statement.SQL.String()),
+			attribute.Int64("db.rows_affected", tx.RowsAffected),
+		)
+		if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			span.RecordError(tx.Error)
+			span.SetStatus(codes.Error, tx.Error.Error())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,156 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/logger"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/stakwork/sphinx-tribes"
+
+// Init sets up the exporter picked with OTEL_EXPORTER: "otlp" sends spans
+// to the collector of OTEL_EXPORTER_OTLP_ENDPOINT, "stdout" prints them and
+// "none" leaves tracing off. The returned func flushes the pending spans.
+func Init(ctx context.Context) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch config.OtelExporter {
+	case "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown OTEL_EXPORTER %q, expected otlp, stdout or none", config.OtelExporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(config.OtelServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	// every client without its own transport, http.DefaultClient included,
+	// sends its requests through http.DefaultTransport
+	http.DefaultTransport = Transport(http.DefaultTransport)
+
+	logger.Log.Info("[tracing] exporting spans with %s", config.OtelExporter)
+	return provider.Shutdown, nil
+}
+
+// Middleware starts a span for every request, named after the chi route
+// once the request is routed, and adds its trace ID to the request logger
+func Middleware(next http.Handler) http.Handler {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		if span.SpanContext().IsValid() {
+			r = r.WithContext(logger.WithFields(r.Context(), "trace_id", span.SpanContext().TraceID().String()))
+		}
+
+		next.ServeHTTP(w, r)
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if route := rctx.RoutePattern(); route != "" {
+				span.SetName(r.Method + " " + route)
+				span.SetAttributes(semconv.HTTPRoute(route))
+			}
+		}
+	})
+
+	return otelhttp.NewHandler(handler, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method
+		}),
+	)
+}
+
+// Transport starts a client span for every request sent through base,
+// named after the service it calls
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(peerTransport{base: base},
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			if peer := peerService(r.URL); peer != "" {
+				return peer + " " + r.Method
+			}
+			return r.Method
+		}),
+	)
+}
+
+// peerTransport tags the span otelhttp started with the service it calls
+type peerTransport struct {
+	base http.RoundTripper
+}
+
+func (t peerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if peer := peerService(r.URL); peer != "" {
+		trace.SpanFromContext(r.Context()).SetAttributes(semconv.PeerService(peer))
+	}
+	return t.base.RoundTrip(r)
+}
+
+// peerService names the services tribes calls, other hosts are left unnamed
+func peerService(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	host := u.Hostname()
+	if host == "stakwork.com" || strings.HasSuffix(host, ".stakwork.com") {
+		return "stakwork"
+	}
+
+	services := []struct {
+		name string
+		url  string
+	}{
+		{"relay", config.RelayUrl},
+		{"v2_bot", config.V2BotUrl},
+		{"meme", config.MemeUrl},
+		{"jarvis", config.JarvisUrl},
+	}
+	for _, service := range services {
+		if service.url == "" {
+			continue
+		}
+		if serviceURL, err := url.Parse(service.url); err == nil && serviceURL.Host == u.Host {
+			return service.name
+		}
+	}
+	return ""
+}
+
+// StartSpan starts a span on the tracer of tribes
+func StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
@@ -0,0 +1,175 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/logger"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	original := otel.GetTracerProvider()
+	propagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(original)
+		otel.SetTextMapPropagator(propagator)
+	})
+	return recorder
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestInit(t *testing.T) {
+	exporter := config.OtelExporter
+	defer func() { config.OtelExporter = exporter }()
+
+	t.Run("should leave tracing off with none", func(t *testing.T) {
+		config.OtelExporter = "none"
+		transport := http.DefaultTransport
+
+		shutdown, err := Init(context.Background())
+		assert.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+		assert.Equal(t, transport, http.DefaultTransport)
+	})
+
+	t.Run("should reject unknown exporters", func(t *testing.T) {
+		config.OtelExporter = "jaeger"
+
+		_, err := Init(context.Background())
+		assert.Error(t, err)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+
+	logLevel := config.LogLevel
+	logs := &bytes.Buffer{}
+	config.LogLevel = "INFO"
+	logger.SetOutput(logs)
+	defer func() {
+		config.LogLevel = logLevel
+		logger.SetOutput(os.Stdout)
+	}()
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/workspaces/{uuid}", func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).Info("handled")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/workspaces/workspace_uuid", nil))
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "GET /workspaces/{uuid}", spans[0].Name())
+	assert.Equal(t, "/workspaces/{uuid}", spanAttribute(spans[0], "http.route").AsString())
+
+	line := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(logs.Bytes(), &line))
+	assert.Equal(t, spans[0].SpanContext().TraceID().String(), line["trace_id"])
+}
+
+func TestTransport(t *testing.T) {
+	recorder := recordSpans(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NotEmpty(t, r.Header.Get("traceparent"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	relayUrl := config.RelayUrl
+	defer func() { config.RelayUrl = relayUrl }()
+	config.RelayUrl = server.URL
+
+	client := &http.Client{Transport: Transport(http.DefaultTransport)}
+	ctx, parent := StartSpan(context.Background(), "parent")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/invoices", nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "relay POST", spans[0].Name())
+	assert.Equal(t, "relay", spanAttribute(spans[0], "peer.service").AsString())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+}
+
+func TestPeerService(t *testing.T) {
+	relayUrl, jarvisUrl := config.RelayUrl, config.JarvisUrl
+	defer func() { config.RelayUrl, config.JarvisUrl = relayUrl, jarvisUrl }()
+	config.RelayUrl = "http://relay:3000"
+	config.JarvisUrl = ""
+
+	tests := map[string]string{
+		"https://api.stakwork.com/api/v1/projects": "stakwork",
+		"http://relay:3000/invoices":               "relay",
+		"http://relay:3001/invoices":               "",
+		"https://notstakwork.com":                  "",
+		"https://api.github.com/user":              "",
+	}
+	for rawURL, expected := range tests {
+		u, _ := url.Parse(rawURL)
+		assert.Equal(t, expected, peerService(u), rawURL)
+	}
+}
+
+func TestGormPlugin(t *testing.T) {
+	recorder := recordSpans(t)
+
+	// a dry run builds the SQL without a database
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "postgres://localhost/tribes"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Use(GormPlugin{}))
+
+	type Tribe struct {
+		UUID string
+	}
+
+	ctx, parent := StartSpan(context.Background(), "parent")
+	var tribes []Tribe
+	tx := db.WithContext(ctx).Where("uuid = ?", "secret_uuid").Find(&tribes)
+	parent.End()
+
+	assert.Equal(t, ctx, tx.Statement.Context)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "gorm.query tribes", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, `SELECT * FROM "tribes" WHERE uuid = $1`, spanAttribute(spans[0], "db.query.text").AsString())
+
+	db.Where("uuid = ?", "secret_uuid").Find(&tribes)
+	assert.Len(t, recorder.Ended(), 2, "a query without a parent span is not traced")
+}
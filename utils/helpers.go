@@ -57,6 +57,28 @@ func GetInvoiceAmount(paymentRequest string) uint {
 	return amount
 }
 
+// GetInvoiceDescriptionHash returns the hex description hash an invoice
+// commits to, empty when it has none or cannot be decoded
+func GetInvoiceDescriptionHash(paymentRequest string) string {
+	decodedInvoice, err := decodepay.Decodepay(paymentRequest)
+	if err != nil {
+		logger.Log.Error("Could not Decode Invoice: %v", err)
+		return ""
+	}
+	return decodedInvoice.DescriptionHash
+}
+
+// GetInvoicePaymentHash returns the hex payment hash of an invoice, empty
+// when it cannot be decoded
+func GetInvoicePaymentHash(paymentRequest string) string {
+	decodedInvoice, err := decodepay.Decodepay(paymentRequest)
+	if err != nil {
+		logger.Log.Error("Could not Decode Invoice: %v", err)
+		return ""
+	}
+	return decodedInvoice.PaymentHash
+}
+
 func GetInvoiceExpired(paymentRequest string) bool {
 	decodedInvoice, err := decodepay.Decodepay(paymentRequest)
 	if err != nil {
@@ -73,6 +73,13 @@ func TestGetInvoiceAmount(t *testing.T) {
 	assert.Equal(t, uint(0), amount2)
 }
 
+func TestGetInvoicePaymentHash(t *testing.T) {
+	invoice := "lnbc15u1p3xnhl2pp5jptserfk3zk4qy42tlucycrfwxhydvlemu9pqr93tuzlv9cc7g3sdqsvfhkcap3xyhx7un8cqzpgxqzjcsp5f8c52y2stc300gl6s4xswtjpc37hrnnr3c9wvtgjfuvqmpm35evq9qyyssqy4lgd8tj637qcjp05rdpxxykjenthxftej7a2zzmwrmrl70fyj9hvj0rewhzj7jfyuwkwcg9g2jpwtk3wkjtwnkdks84hsnu8xps5vsq4gj5hs"
+
+	assert.Equal(t, "90570c8d3688ad5012aa5ff982606971ae46b3f9df0a100cb15f05f61718f223", GetInvoicePaymentHash(invoice))
+	assert.Equal(t, "", GetInvoicePaymentHash(invoice[:len(invoice)-6]))
+}
+
 func TestGetInvoiceExpired(t *testing.T) {
 	expiredInvoice := "lnbcrt100u1pnr5gtzpp5r7ew6nzqd9y9w5ktsspftnckxdn3te0y04n9mw7c6hkkrznh4pgsdqhgf6kgem9wssyjmnkda5kxegcqzpgxqyz5vqsp5mc09mpl4l3rllnfl3y902yxa29flke8r4ertqswdcrk766z5nq4q9qyyssq7wteenxtwlxatsd8dqdncqnn6u23jmcpe0d7ne6dcpafwlx9ckr3dp6y4p7sl4j3pq6l93g6vc4w8z04ry9yzwjv6cggm06eecad9psp9dh6u5"
 	isInvoiceExpired := GetInvoiceExpired(expiredInvoice)
@@ -0,0 +1,98 @@
+package webhooks
+
+import (
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// breakers keep track of the failures of every destination, once one
+// fails too often in a row its deliveries wait for the cooldown and a
+// single trial delivery decides whether it is back
+type breakers struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	states    map[string]*breaker
+}
+
+type breaker struct {
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+func newBreakers(threshold int, cooldown time.Duration) *breakers {
+	return &breakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		states:    make(map[string]*breaker),
+	}
+}
+
+// destination is the host deliveries share a breaker by
+func destination(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Host
+}
+
+// wait is how long a delivery to the destination has to wait before it may
+// be attempted, zero when it may go now
+func (b *breakers) wait(dest string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, ok := b.states[dest]
+	if !ok || state.failures < b.threshold {
+		return 0
+	}
+	if wait := time.Until(state.openUntil); wait > 0 {
+		return wait
+	}
+	if state.trial {
+		// another delivery is finding out whether the destination is back
+		return b.cooldown / 10
+	}
+	state.trial = true
+	return 0
+}
+
+func (b *breakers) record(dest string, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ok {
+		delete(b.states, dest)
+		return
+	}
+
+	state, exists := b.states[dest]
+	if !exists {
+		state = &breaker{}
+		b.states[dest] = state
+	}
+	state.trial = false
+	state.failures++
+	if state.failures >= b.threshold {
+		state.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// open lists the destinations deliveries are held back for
+func (b *breakers) open() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	open := []string{}
+	for dest, state := range b.states {
+		if state.failures >= b.threshold {
+			open = append(open, dest)
+		}
+	}
+	sort.Strings(open)
+	return open
+}
@@ -0,0 +1,463 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+)
+
+const (
+	IDHeader        = "X-Webhook-ID"
+	EventHeader     = "X-Webhook-Event"
+	TimestampHeader = "X-Webhook-Timestamp"
+	// SignatureHeader is the HMAC-SHA256 of the timestamp, a dot and the
+	// body, keyed with WEBHOOK_SIGNING_SECRET
+	SignatureHeader = "X-Webhook-Signature"
+
+	defaultMaxAttempts   = 6
+	defaultRetryInterval = 2 * time.Second
+	maxRetryInterval     = 5 * time.Minute
+	// failures in a row that open the circuit of a destination
+	breakerThreshold = 5
+	breakerCooldown  = 30 * time.Second
+	maxConcurrent    = 16
+	sendTimeout      = 15 * time.Second
+	maxErrorBody     = 1024
+	// a replay still running after this was lost with its process, all
+	// its attempts take a few minutes at most
+	ReplayStaleAfter = 15 * time.Minute
+)
+
+var (
+	ErrNotStarted    = errors.New("webhook dispatcher is not started")
+	ErrClosed        = errors.New("webhook dispatcher is closed")
+	ErrNotReplayable = errors.New("only dead letters can be replayed")
+)
+
+// Delivery is one payload to POST to a webhook
+type Delivery struct {
+	// ID is sent in X-Webhook-ID so receivers can drop duplicates, it is
+	// kept by replays
+	ID uuid.UUID
+	// Kind names what the payload is, it is sent in X-Webhook-Event and
+	// picks the hook run once it is delivered
+	Kind    string
+	URL     string
+	Payload []byte
+	// Refs are the ids of the records the payload carries, for the hook
+	Refs []string
+
+	attempts int
+	replay   bool
+}
+
+// DeliveredFunc runs once a delivery of its kind was accepted, also when
+// that happens in a replay after a restart
+type DeliveredFunc func(delivery Delivery) error
+
+// Dispatcher POSTs signed deliveries, retries the ones that fail with
+// exponential backoff and keeps the ones that never make it as dead
+// letters. Destinations that keep failing are given a rest instead of
+// being hammered by every delivery.
+type Dispatcher struct {
+	db     db.Database
+	client *http.Client
+	secret string
+
+	MaxAttempts      int
+	RetryInterval    time.Duration
+	MaxRetryInterval time.Duration
+
+	breakers *breakers
+	sending  chan struct{}
+	hooksMu  sync.RWMutex
+	hooks    map[string]DeliveredFunc
+	pending  atomic.Int64
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewDispatcher(database db.Database, client *http.Client, secret string) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		db:               database,
+		client:           client,
+		secret:           secret,
+		MaxAttempts:      defaultMaxAttempts,
+		RetryInterval:    defaultRetryInterval,
+		MaxRetryInterval: maxRetryInterval,
+		breakers:         newBreakers(breakerThreshold, breakerCooldown),
+		sending:          make(chan struct{}, maxConcurrent),
+		hooks:            make(map[string]DeliveredFunc),
+		ctx:              ctx,
+		cancel:           cancel,
+	}
+}
+
+// OnDelivered sets the hook run for the deliveries of a kind
+func (d *Dispatcher) OnDelivered(kind string, fn DeliveredFunc) {
+	d.hooksMu.Lock()
+	defer d.hooksMu.Unlock()
+	d.hooks[kind] = fn
+}
+
+// Send queues the delivery, it is attempted right away
+func (d *Dispatcher) Send(delivery Delivery) error {
+	if delivery.URL == "" {
+		return errors.New("webhook URL is required")
+	}
+	if delivery.ID == uuid.Nil {
+		delivery.ID = uuid.New()
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return ErrClosed
+	}
+
+	d.wg.Add(1)
+	d.pending.Add(1)
+	go d.run(delivery)
+	return nil
+}
+
+// Replay sends a dead letter again with a fresh set of attempts
+func (d *Dispatcher) Replay(id uuid.UUID) (*db.WebhookDeadLetter, error) {
+	letter, err := d.db.GetWebhookDeadLetterByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if letter.Status != db.WebhookDeadLetterDead {
+		return letter, ErrNotReplayable
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":      db.WebhookDeadLetterReplaying,
+		"replays":     letter.Replays + 1,
+		"replayed_at": now,
+	}
+	if err := d.db.UpdateWebhookDeadLetter(id, updates); err != nil {
+		return nil, err
+	}
+	letter.Status = db.WebhookDeadLetterReplaying
+	letter.Replays++
+	letter.ReplayedAt = &now
+
+	err = d.Send(Delivery{
+		ID:      letter.ID,
+		Kind:    letter.Kind,
+		URL:     letter.URL,
+		Payload: []byte(letter.Payload),
+		Refs:    letter.Refs,
+		replay:  true,
+	})
+	if err != nil {
+		d.db.UpdateWebhookDeadLetter(id, map[string]interface{}{"status": db.WebhookDeadLetterDead})
+		return nil, err
+	}
+	return letter, nil
+}
+
+// ResetStaleReplays puts the dead letters replaying for longer than
+// ReplayStaleAfter back to dead so they can be replayed again
+func (d *Dispatcher) ResetStaleReplays() (int64, error) {
+	return d.db.ResetStaleWebhookDeadLetters(time.Now().Add(-ReplayStaleAfter))
+}
+
+// Pending is the number of deliveries not yet delivered or dead
+func (d *Dispatcher) Pending() int64 {
+	return d.pending.Load()
+}
+
+// OpenCircuits lists the destinations whose deliveries are held back
+func (d *Dispatcher) OpenCircuits() []string {
+	return d.breakers.open()
+}
+
+// Close stops the dispatcher, attempts under way are finished and
+// deliveries waiting for their next attempt are kept as dead letters to
+// be replayed
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+	d.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) run(delivery Delivery) {
+	defer d.wg.Done()
+	defer d.pending.Add(-1)
+
+	dest := destination(delivery.URL)
+	log := logger.Log.With("webhook_id", delivery.ID.String(), "kind", delivery.Kind, "destination", dest)
+
+	for {
+		if wait := d.breakers.wait(dest); wait > 0 {
+			if !d.sleep(wait) {
+				d.deadLetter(delivery, 0, ErrClosed)
+				return
+			}
+			continue
+		}
+
+		d.sending <- struct{}{}
+		status, err := d.post(delivery)
+		<-d.sending
+
+		delivery.attempts++
+		// a destination that answers, even with a refusal, is up
+		d.breakers.record(dest, err == nil || !retryable(status))
+		if err == nil {
+			log.Info("[webhooks] delivered after %d attempts", delivery.attempts)
+			d.delivered(delivery)
+			return
+		}
+
+		if !retryable(status) || delivery.attempts >= d.MaxAttempts || d.ctx.Err() != nil {
+			log.Error("[webhooks] giving up after %d attempts: %v", delivery.attempts, err)
+			d.deadLetter(delivery, status, err)
+			return
+		}
+
+		wait := d.backoff(delivery.attempts)
+		log.Warning("[webhooks] attempt %d failed: %v. Retrying in %v...", delivery.attempts, err, wait)
+		if !d.sleep(wait) {
+			d.deadLetter(delivery, status, err)
+			return
+		}
+	}
+}
+
+// post makes one attempt, the status is 0 when there was no response
+func (d *Dispatcher) post(delivery Delivery) (int, error) {
+	// an attempt under way is finished on Close, only the waits between
+	// attempts are cut short
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IDHeader, delivery.ID.String())
+	req.Header.Set(EventHeader, delivery.Kind)
+	req.Header.Set(TimestampHeader, timestamp)
+	if d.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(d.secret, timestamp, delivery.Payload))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) delivered(delivery Delivery) {
+	d.hooksMu.RLock()
+	hook := d.hooks[delivery.Kind]
+	d.hooksMu.RUnlock()
+
+	if hook != nil {
+		if err := hook(delivery); err != nil {
+			logger.Log.Error("[webhooks] hook of delivered %s %s failed: %v", delivery.Kind, delivery.ID, err)
+		}
+	}
+
+	if delivery.replay {
+		err := d.db.UpdateWebhookDeadLetter(delivery.ID, map[string]interface{}{
+			"status":       db.WebhookDeadLetterDelivered,
+			"delivered_at": time.Now(),
+		})
+		if err != nil {
+			logger.Log.Error("[webhooks] could not mark dead letter %s delivered: %v", delivery.ID, err)
+		}
+	}
+}
+
+func (d *Dispatcher) deadLetter(delivery Delivery, status int, cause error) {
+	_, err := d.db.SaveWebhookDeadLetter(&db.WebhookDeadLetter{
+		ID:         delivery.ID,
+		Kind:       delivery.Kind,
+		URL:        delivery.URL,
+		Payload:    string(delivery.Payload),
+		Refs:       delivery.Refs,
+		Attempts:   delivery.attempts,
+		LastStatus: status,
+		LastError:  cause.Error(),
+		Status:     db.WebhookDeadLetterDead,
+	})
+	if err != nil {
+		logger.Log.Error("[webhooks] could not keep dead letter %s to %s, it is lost: %v", delivery.ID, delivery.URL, err)
+	}
+}
+
+// backoff is the wait after a failed attempt, doubling from RetryInterval
+// up to MaxRetryInterval with jitter
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.RetryInterval
+	for i := 1; i < attempts && wait < d.MaxRetryInterval; i++ {
+		wait *= 2
+	}
+	if wait > d.MaxRetryInterval {
+		wait = d.MaxRetryInterval
+	}
+	if half := int64(wait / 2); half > 0 {
+		wait = time.Duration(half + rand.Int63n(half+1))
+	}
+	return wait
+}
+
+// sleep waits unless the dispatcher is closed first
+func (d *Dispatcher) sleep(wait time.Duration) bool {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-d.ctx.Done():
+		return false
+	}
+}
+
+// retryable tells failures worth another attempt, no response at all, a
+// timeout, rate limiting or a server error, from refusals of the payload
+func retryable(status int) bool {
+	return status == 0 ||
+		status == http.StatusRequestTimeout ||
+		status == http.StatusTooManyRequests ||
+		status >= 500
+}
+
+// Sign is the signature of a payload sent at timestamp, receivers compute
+// it with the shared secret and compare it with SignatureHeader
+func Sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+var (
+	dispatcherMu sync.RWMutex
+	dispatcher   *Dispatcher
+)
+
+// SetDispatcher replaces the dispatcher the package functions use
+func SetDispatcher(d *Dispatcher) {
+	dispatcherMu.Lock()
+	defer dispatcherMu.Unlock()
+	dispatcher = d
+}
+
+func current() *Dispatcher {
+	dispatcherMu.RLock()
+	defer dispatcherMu.RUnlock()
+	return dispatcher
+}
+
+// Init starts the dispatcher the package functions use. The returned func
+// stops it before the process exits.
+func Init(database db.Database) func(context.Context) error {
+	d := NewDispatcher(database, &http.Client{}, config.WebhookSigningSecret)
+	SetDispatcher(d)
+
+	if config.WebhookSigningSecret == "" {
+		logger.Log.Warning("[webhooks] WEBHOOK_SIGNING_SECRET is not set, webhooks are sent unsigned")
+	}
+	if reset, err := d.ResetStaleReplays(); err != nil {
+		logger.Log.Error("[webhooks] could not reset stale replays: %v", err)
+	} else if reset > 0 {
+		logger.Log.Info("[webhooks] reset %d stale replays to dead", reset)
+	}
+	return d.Close
+}
+
+// Send queues the delivery on the dispatcher started by Init
+func Send(delivery Delivery) error {
+	d := current()
+	if d == nil {
+		return ErrNotStarted
+	}
+	return d.Send(delivery)
+}
+
+// Replay sends a dead letter again on the dispatcher started by Init
+func Replay(id uuid.UUID) (*db.WebhookDeadLetter, error) {
+	d := current()
+	if d == nil {
+		return nil, ErrNotStarted
+	}
+	return d.Replay(id)
+}
+
+// OnDelivered sets a hook on the dispatcher started by Init
+func OnDelivered(kind string, fn DeliveredFunc) {
+	if d := current(); d != nil {
+		d.OnDelivered(kind, fn)
+	}
+}
+
+// Pending is the number of deliveries the dispatcher started by Init has
+// not delivered or given up on yet
+func Pending() int64 {
+	if d := current(); d != nil {
+		return d.Pending()
+	}
+	return 0
+}
+
+// OpenCircuits lists the destinations the dispatcher started by Init
+// holds deliveries back for
+func OpenCircuits() []string {
+	if d := current(); d != nil {
+		return d.OpenCircuits()
+	}
+	return []string{}
+}
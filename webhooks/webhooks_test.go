@@ -0,0 +1,234 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stakwork/sphinx-tribes/db"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestDispatcher(database db.Database) *Dispatcher {
+	d := NewDispatcher(database, &http.Client{}, "secret")
+	d.RetryInterval = time.Millisecond
+	d.MaxRetryInterval = 5 * time.Millisecond
+	return d
+}
+
+func closeDispatcher(t *testing.T, d *Dispatcher) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.NoError(t, d.Close(ctx))
+}
+
+func TestSend(t *testing.T) {
+	t.Run("should sign the payload with the timestamp it was sent at", func(t *testing.T) {
+		received := make(chan *http.Request, 1)
+		bodies := make(chan []byte, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			received <- r
+			bodies <- body
+		}))
+		defer server.Close()
+
+		d := newTestDispatcher(dbMocks.NewDatabase(t))
+		id := uuid.New()
+		assert.NoError(t, d.Send(Delivery{ID: id, Kind: "test.event", URL: server.URL, Payload: []byte(`{"a":1}`)}))
+		closeDispatcher(t, d)
+
+		r := <-received
+		assert.Equal(t, `{"a":1}`, string(<-bodies))
+		assert.Equal(t, id.String(), r.Header.Get(IDHeader))
+		assert.Equal(t, "test.event", r.Header.Get(EventHeader))
+		assert.Equal(t, Sign("secret", r.Header.Get(TimestampHeader), []byte(`{"a":1}`)), r.Header.Get(SignatureHeader))
+		assert.NotEqual(t, Sign("other", r.Header.Get(TimestampHeader), []byte(`{"a":1}`)), r.Header.Get(SignatureHeader))
+	})
+
+	t.Run("should retry server errors and run the hook once delivered", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		d := newTestDispatcher(dbMocks.NewDatabase(t))
+		delivered := make(chan Delivery, 1)
+		d.OnDelivered("test.event", func(delivery Delivery) error {
+			delivered <- delivery
+			return nil
+		})
+
+		assert.NoError(t, d.Send(Delivery{Kind: "test.event", URL: server.URL, Payload: []byte(`{}`), Refs: []string{"ref"}}))
+
+		select {
+		case delivery := <-delivered:
+			assert.Equal(t, []string{"ref"}, delivery.Refs)
+		case <-time.After(2 * time.Second):
+			t.Fatal("delivery did not succeed")
+		}
+		assert.Equal(t, int32(3), attempts.Load())
+		closeDispatcher(t, d)
+	})
+
+	t.Run("should dead-letter a delivery that failed every attempt", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		mockDb := dbMocks.NewDatabase(t)
+		saved := make(chan *db.WebhookDeadLetter, 1)
+		mockDb.On("SaveWebhookDeadLetter", mock.Anything).Run(func(args mock.Arguments) {
+			saved <- args.Get(0).(*db.WebhookDeadLetter)
+		}).Return(&db.WebhookDeadLetter{}, nil)
+
+		d := newTestDispatcher(mockDb)
+		d.MaxAttempts = 3
+		assert.NoError(t, d.Send(Delivery{Kind: "test.event", URL: server.URL, Payload: []byte(`{"a":1}`)}))
+
+		letter := <-saved
+		closeDispatcher(t, d)
+		assert.Equal(t, 3, letter.Attempts)
+		assert.Equal(t, http.StatusBadGateway, letter.LastStatus)
+		assert.Equal(t, `{"a":1}`, letter.Payload)
+		assert.Equal(t, db.WebhookDeadLetterDead, letter.Status)
+	})
+
+	t.Run("should not retry a payload the webhook refused", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}))
+		defer server.Close()
+
+		mockDb := dbMocks.NewDatabase(t)
+		mockDb.On("SaveWebhookDeadLetter", mock.MatchedBy(func(letter *db.WebhookDeadLetter) bool {
+			return letter.Attempts == 1 && letter.LastStatus == http.StatusUnprocessableEntity
+		})).Return(&db.WebhookDeadLetter{}, nil)
+
+		d := newTestDispatcher(mockDb)
+		assert.NoError(t, d.Send(Delivery{Kind: "test.event", URL: server.URL, Payload: []byte(`{}`)}))
+		closeDispatcher(t, d)
+
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("should keep deliveries waiting for a retry when closed", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		mockDb := dbMocks.NewDatabase(t)
+		mockDb.On("SaveWebhookDeadLetter", mock.MatchedBy(func(letter *db.WebhookDeadLetter) bool {
+			return letter.Attempts == 1 && letter.LastStatus == http.StatusInternalServerError
+		})).Return(&db.WebhookDeadLetter{}, nil)
+
+		d := newTestDispatcher(mockDb)
+		d.RetryInterval = time.Hour
+		d.MaxRetryInterval = time.Hour
+		assert.NoError(t, d.Send(Delivery{Kind: "test.event", URL: server.URL, Payload: []byte(`{}`)}))
+
+		assert.Eventually(t, func() bool {
+			return attempts.Load() == 1
+		}, time.Second, 5*time.Millisecond)
+		closeDispatcher(t, d)
+
+		assert.Equal(t, ErrClosed, d.Send(Delivery{URL: server.URL}))
+		assert.Equal(t, int64(0), d.Pending())
+	})
+}
+
+func TestReplay(t *testing.T) {
+	t.Run("should send a dead letter again and mark it delivered", func(t *testing.T) {
+		var mu sync.Mutex
+		var ids []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			ids = append(ids, r.Header.Get(IDHeader))
+			mu.Unlock()
+		}))
+		defer server.Close()
+
+		id := uuid.New()
+		mockDb := dbMocks.NewDatabase(t)
+		mockDb.On("GetWebhookDeadLetterByID", id).Return(&db.WebhookDeadLetter{
+			ID: id, Kind: "test.event", URL: server.URL, Payload: `{}`, Status: db.WebhookDeadLetterDead,
+		}, nil)
+		mockDb.On("UpdateWebhookDeadLetter", id, mock.MatchedBy(func(updates map[string]interface{}) bool {
+			return updates["status"] == db.WebhookDeadLetterReplaying && updates["replays"] == 1
+		})).Return(nil)
+		mockDb.On("UpdateWebhookDeadLetter", id, mock.MatchedBy(func(updates map[string]interface{}) bool {
+			return updates["status"] == db.WebhookDeadLetterDelivered
+		})).Return(nil)
+
+		d := newTestDispatcher(mockDb)
+		letter, err := d.Replay(id)
+		assert.NoError(t, err)
+		assert.Equal(t, db.WebhookDeadLetterReplaying, letter.Status)
+		closeDispatcher(t, d)
+
+		assert.Equal(t, []string{id.String()}, ids)
+	})
+
+	t.Run("should refuse a dead letter that is not dead", func(t *testing.T) {
+		id := uuid.New()
+		mockDb := dbMocks.NewDatabase(t)
+		mockDb.On("GetWebhookDeadLetterByID", id).Return(&db.WebhookDeadLetter{ID: id, Status: db.WebhookDeadLetterDelivered}, nil)
+
+		d := newTestDispatcher(mockDb)
+		defer closeDispatcher(t, d)
+
+		_, err := d.Replay(id)
+		assert.ErrorIs(t, err, ErrNotReplayable)
+	})
+
+	t.Run("should reset the replays started before the stale timeout", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockDb.On("ResetStaleWebhookDeadLetters", mock.MatchedBy(func(before time.Time) bool {
+			return time.Since(before) >= ReplayStaleAfter && time.Since(before) < ReplayStaleAfter+time.Minute
+		})).Return(int64(2), nil)
+
+		d := newTestDispatcher(mockDb)
+		defer closeDispatcher(t, d)
+
+		reset, err := d.ResetStaleReplays()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), reset)
+	})
+}
+
+func TestBreakers(t *testing.T) {
+	b := newBreakers(2, 50*time.Millisecond)
+
+	b.record("host", false)
+	assert.Zero(t, b.wait("host"))
+
+	b.record("host", false)
+	assert.Equal(t, []string{"host"}, b.open())
+	assert.Greater(t, b.wait("host"), time.Duration(0))
+	assert.Zero(t, b.wait("other"))
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Zero(t, b.wait("host"), "a trial delivery goes through after the cooldown")
+	assert.Greater(t, b.wait("host"), time.Duration(0), "one trial at a time")
+
+	b.record("host", true)
+	assert.Zero(t, b.wait("host"))
+	assert.Empty(t, b.open())
+}
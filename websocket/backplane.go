@@ -0,0 +1,73 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stakwork/sphinx-tribes/logger"
+)
+
+const backplaneChannel = "tribes:websocket"
+
+// Envelope is a message on its way to the clients of other instances. It
+// goes to the subscribers of Topic or to the client connected as Host, an
+// empty Host is a broadcast and an Alias makes Host reachable under a
+// second name. Seq is the number the payload got in the event log.
+type Envelope struct {
+	Origin  string          `json:"origin"`
+	Topic   string          `json:"topic,omitempty"`
+	Host    string          `json:"host,omitempty"`
+	Alias   string          `json:"alias,omitempty"`
+	Seq     uint64          `json:"seq,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Backplane carries envelopes between the instances serving websockets
+type Backplane interface {
+	Publish(ctx context.Context, envelope Envelope) error
+	// Subscribe hands every envelope to deliver until ctx is done
+	Subscribe(ctx context.Context, deliver func(Envelope))
+}
+
+// RedisBackplane shares envelopes over redis pub/sub
+type RedisBackplane struct {
+	client  *redis.Client
+	channel string
+}
+
+func NewRedisBackplane(client *redis.Client) *RedisBackplane {
+	return &RedisBackplane{client: client, channel: backplaneChannel}
+}
+
+func (b *RedisBackplane) Publish(ctx context.Context, envelope Envelope) error {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, data).Err()
+}
+
+func (b *RedisBackplane) Subscribe(ctx context.Context, deliver func(Envelope)) {
+	// the subscription reconnects by itself when redis goes away
+	sub := b.client.Subscribe(ctx, b.channel)
+	defer sub.Close()
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			var envelope Envelope
+			if err := json.Unmarshal([]byte(message.Payload), &envelope); err != nil {
+				logger.Log.Error("[websocket] invalid backplane message: %v", err)
+				continue
+			}
+			deliver(envelope)
+		}
+	}
+}
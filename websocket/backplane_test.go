@@ -0,0 +1,99 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryBackplane hands every envelope to the subscribers of the process,
+// like redis does for the subscribers of every instance
+type memoryBackplane struct {
+	mu          sync.Mutex
+	subscribers []func(Envelope)
+}
+
+func (b *memoryBackplane) Publish(ctx context.Context, envelope Envelope) error {
+	b.mu.Lock()
+	subscribers := append([]func(Envelope){}, b.subscribers...)
+	b.mu.Unlock()
+
+	for _, deliver := range subscribers {
+		deliver(envelope)
+	}
+	return nil
+}
+
+func (b *memoryBackplane) Subscribe(ctx context.Context, deliver func(Envelope)) {
+	b.mu.Lock()
+	b.subscribers = append(b.subscribers, deliver)
+	b.mu.Unlock()
+	<-ctx.Done()
+}
+
+func (b *memoryBackplane) subscribed() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+func TestBackplane(t *testing.T) {
+	backplane := &memoryBackplane{}
+	first, second := NewPool(), NewPool()
+	first.UseBackplane(backplane)
+	second.UseBackplane(backplane)
+	go first.Start()
+	go second.Start()
+	assert.Eventually(t, func() bool { return backplane.subscribed() == 2 }, time.Second, 10*time.Millisecond)
+
+	ws, server, received := setupRecordingWebsocket(t)
+	defer server.Close()
+	defer ws.Close()
+	second.add(NewClient("remote-client", ws, second, ""))
+
+	t.Run("should deliver direct messages to clients of other instances", func(t *testing.T) {
+		err := first.SendTicketMessage(TicketMessage{
+			BroadcastType:   "direct",
+			SourceSessionID: "remote-client",
+			Message:         "from the first instance",
+		})
+
+		assert.NoError(t, err)
+		assert.Contains(t, receive(t, received), "from the first instance")
+	})
+
+	t.Run("should deliver broadcasts to clients of other instances", func(t *testing.T) {
+		first.Broadcast <- Message{Type: 1, Body: "everyone"}
+
+		assert.Contains(t, receive(t, received), "everyone")
+	})
+
+	t.Run("should resolve aliases made on other instances", func(t *testing.T) {
+		assert.NoError(t, first.Alias("lnurl-k1", "remote-client"))
+
+		assert.NoError(t, first.SendJSON("lnurl-k1", InvoiceMessage{Msg: "lnauth_success"}))
+		assert.Contains(t, receive(t, received), "lnauth_success")
+	})
+
+	t.Run("should not deliver a message twice to a local client", func(t *testing.T) {
+		assert.NoError(t, second.SendJSON("remote-client", InvoiceMessage{Msg: "local"}))
+
+		assert.Contains(t, receive(t, received), "local")
+		select {
+		case message := <-received:
+			t.Fatalf("unexpected message %s", message)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
+
+func TestPoolWithoutBackplane(t *testing.T) {
+	pool := NewPool()
+
+	err := pool.SendJSON("remote-client", InvoiceMessage{Msg: "budget_success"})
+
+	assert.ErrorContains(t, err, "client not found")
+}
@@ -3,16 +3,59 @@ package websocket
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
 )
 
+const (
+	// messages queued for a client before it is dropped as too slow
+	sendBufferSize = 256
+	writeWait      = 10 * time.Second
+	// clients only send subscription requests
+	maxMessageSize = 4096
+)
+
+// a client that does not answer a ping within pongWait is gone, pings are
+// sent often enough to get the pong back before that
+var (
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait * 9 / 10
+)
+
+// Client is one websocket connection, every write goes through its own
+// writer goroutine so a slow connection never blocks the pool
 type Client struct {
 	Host string
 	Conn *websocket.Conn
 	Pool *Pool
+	// Pubkey is set for connections made with a token, only they can
+	// subscribe to topics
+	Pubkey string
+
+	// topics is guarded by the mutex of the pool
+	topics map[string]*subscription
+	// since is the last sequence number of its session the client saw
+	// before it reconnected
+	since     *uint64
+	send      chan []byte
+	done      chan struct{}
+	startOnce sync.Once
+	closeOnce sync.Once
+}
+
+func NewClient(host string, conn *websocket.Conn, pool *Pool, pubkey string) *Client {
+	client := &Client{
+		Host:   host,
+		Conn:   conn,
+		Pool:   pool,
+		Pubkey: pubkey,
+	}
+	client.start()
+	return client
 }
 
 type ClientData struct {
@@ -35,6 +78,7 @@ type TicketMessage struct {
 	TicketDetails   TicketData     `json:"ticketDetails"`
 	ChatMessage     db.ChatMessage `json:"chatMessage"`
 	Artifacts       []db.Artifact  `json:"artifacts"`
+	Seq             uint64         `json:"seq,omitempty"`
 }
 
 type TicketData struct {
@@ -51,6 +95,7 @@ type TicketPlanMessage struct {
     Message         string             `json:"message"`
     Action          string             `json:"action"`
     PlanDetails     TicketPlanDetails  `json:"plan_details"`
+    Seq             uint64             `json:"seq,omitempty"`
 }
 
 type TicketPlanDetails struct {
@@ -64,26 +109,144 @@ func (c *Client) Read() {
 		// ceck to acoid nil pointer
 		if c.Pool != nil {
 			c.Pool.Unregister <- c
-			c.Conn.Close()
+			c.Close()
 			db.Store.DeleteCache(c.Host)
 		}
 	}()
 
+	c.Conn.SetReadLimit(maxMessageSize)
+	c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.Conn.SetPongHandler(func(string) error {
+		return c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	// clients only send subscription requests, nothing they send is
+	// relayed to other clients
 	for {
-		var socketMsg db.LnHost
-		messageType, p, err := c.Conn.ReadMessage()
+		_, p, err := c.Conn.ReadMessage()
 		if err != nil {
-			log.Println(err)
+			// clients going away is how most connections end
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.Log.Debug("[websocket] %s disconnected: %v", c.Host, err)
+			} else {
+				logger.Log.Warning("[websocket] could not read from %s: %v", c.Host, err)
+			}
 			return
 		}
 
-		err = json.Unmarshal(p, &socketMsg)
-		if err != nil {
-			fmt.Println("Message Decode Error", err, string(p))
+		var request SubscriptionRequest
+		if err := json.Unmarshal(p, &request); err != nil || request.Action == "" {
+			logger.Log.Warning("[websocket] could not decode message from %s: %v", c.Host, err)
+			continue
 		}
-		message := Message{Type: messageType, Body: string(p)}
+		c.Pool.handle(c, request)
+	}
+}
+
+type InvoiceMessage struct {
+	Msg     string `json:"msg"`
+	Invoice string `json:"invoice"`
+}
 
-		fmt.Printf("Message Received: %+v\n", message)
-		c.Pool.Broadcast <- message
+// start creates the queue and writer of clients that were not made with
+// NewClient, clients without a websocket have their queue drained by the
+// stream they belong to
+func (c *Client) start() {
+	c.startOnce.Do(func() {
+		c.send = make(chan []byte, sendBufferSize)
+		c.done = make(chan struct{})
+		if c.Conn != nil {
+			go c.write(pingPeriod)
+		}
+	})
+}
+
+// Send queues a message for the client, a client whose queue is full is
+// dropped instead of making the sender wait
+func (c *Client) Send(payload []byte) error {
+	c.start()
+
+	select {
+	case <-c.done:
+		return fmt.Errorf("client closed: %s", c.Host)
+	default:
+	}
+
+	select {
+	case c.send <- payload:
+		return nil
+	default:
+		c.drop(fmt.Errorf("send queue full"))
+		return fmt.Errorf("client too slow, dropped: %s", c.Host)
+	}
+}
+
+// sendWait queues a message like Send but gives a full queue up to wait
+// to make room, a replay sends more messages than the queue holds
+func (c *Client) sendWait(payload []byte, wait time.Duration) error {
+	c.start()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-c.done:
+		return fmt.Errorf("client closed: %s", c.Host)
+	case c.send <- payload:
+		return nil
+	case <-timer.C:
+		c.drop(fmt.Errorf("send queue full"))
+		return fmt.Errorf("client too slow, dropped: %s", c.Host)
+	}
+}
+
+func (c *Client) SendJSON(message interface{}) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return c.Send(payload)
+}
+
+// Close stops the writer and closes the connection
+func (c *Client) Close() {
+	c.start()
+	c.closeOnce.Do(func() {
+		close(c.done)
+		if c.Conn != nil {
+			c.Conn.Close()
+		}
+	})
+}
+
+func (c *Client) drop(reason error) {
+	logger.Log.Warning("[websocket] dropping client %s: %v", c.Host, reason)
+	c.Close()
+	if c.Pool != nil {
+		c.Pool.remove(c)
+	}
+}
+
+func (c *Client) write(pingPeriod time.Duration) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case payload := <-c.send:
+			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.Conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				c.drop(err)
+				return
+			}
+		case <-ticker.C:
+			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.drop(err)
+				return
+			}
+		}
 	}
 }
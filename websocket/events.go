@@ -0,0 +1,277 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// events kept per topic for clients that reconnect
+	replayBufferSize = 500
+	// a topic nobody published to for this long is forgotten
+	replayTTL       = 24 * time.Hour
+	eventsKeyPrefix = "tribes:websocket:events:"
+	seqKeyPrefix    = "tribes:websocket:seq:"
+	ownerKeyPrefix  = "tribes:websocket:owner:"
+)
+
+// LoggedEvent is an event as it was sent to the clients, with the sequence
+// number it got in its topic
+type LoggedEvent struct {
+	Seq     uint64
+	Payload json.RawMessage
+}
+
+// EventLog numbers the events of every topic and keeps the latest of them
+// so clients that lost their connection can catch up
+type EventLog interface {
+	// Append gives the event the next sequence number of the topic, build
+	// encodes the event with that number
+	Append(ctx context.Context, topic string, build func(seq uint64) ([]byte, error)) (LoggedEvent, error)
+	// Since returns the kept events of the topic after since, oldest first.
+	// complete is false when some of them are no longer kept.
+	Since(ctx context.Context, topic string, since uint64) (events []LoggedEvent, complete bool, err error)
+	// Last is the sequence number of the latest event of the topic
+	Last(ctx context.Context, topic string) (uint64, error)
+	// Claim makes owner the owner of the topic unless it has another one,
+	// and returns the owner of the topic
+	Claim(ctx context.Context, topic string, owner string) (string, error)
+	// Owner is the owner of the topic, empty when nobody claimed it
+	Owner(ctx context.Context, topic string) (string, error)
+}
+
+// RedisEventLog keeps the events in a sorted set per topic so every
+// instance numbers and replays the same events
+type RedisEventLog struct {
+	client *redis.Client
+	size   int64
+	ttl    time.Duration
+}
+
+func NewRedisEventLog(client *redis.Client) *RedisEventLog {
+	return &RedisEventLog{client: client, size: replayBufferSize, ttl: replayTTL}
+}
+
+func (l *RedisEventLog) Append(ctx context.Context, topic string, build func(seq uint64) ([]byte, error)) (LoggedEvent, error) {
+	seqKey, eventsKey := seqKeyPrefix+topic, eventsKeyPrefix+topic
+
+	seq, err := l.client.Incr(ctx, seqKey).Uint64()
+	if err != nil {
+		return LoggedEvent{}, err
+	}
+	payload, err := build(seq)
+	if err != nil {
+		return LoggedEvent{}, err
+	}
+
+	_, err = l.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, eventsKey, redis.Z{Score: float64(seq), Member: payload})
+		pipe.ZRemRangeByRank(ctx, eventsKey, 0, -(l.size + 1))
+		pipe.Expire(ctx, eventsKey, l.ttl)
+		pipe.Expire(ctx, seqKey, l.ttl)
+		// the owner is kept as long as the events
+		pipe.Expire(ctx, ownerKeyPrefix+topic, l.ttl)
+		return nil
+	})
+	if err != nil {
+		return LoggedEvent{}, err
+	}
+	return LoggedEvent{Seq: seq, Payload: payload}, nil
+}
+
+func (l *RedisEventLog) Since(ctx context.Context, topic string, since uint64) ([]LoggedEvent, bool, error) {
+	current, err := l.client.Get(ctx, seqKeyPrefix+topic).Uint64()
+	if err != nil && err != redis.Nil {
+		return nil, false, err
+	}
+	if since == current {
+		return nil, true, nil
+	}
+
+	min := "(" + strconv.FormatUint(since, 10)
+	if since > current {
+		// the topic expired and is numbered from 1 again, the cursor
+		// belongs to the old numbering
+		min = "-inf"
+	}
+	results, err := l.client.ZRangeByScoreWithScores(ctx, eventsKeyPrefix+topic, &redis.ZRangeBy{Min: min, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, false, err
+	}
+
+	events := make([]LoggedEvent, 0, len(results))
+	for _, result := range results {
+		member, _ := result.Member.(string)
+		events = append(events, LoggedEvent{Seq: uint64(result.Score), Payload: json.RawMessage(member)})
+	}
+	complete := since < current && len(events) > 0 && events[0].Seq == since+1
+	return events, complete, nil
+}
+
+func (l *RedisEventLog) Last(ctx context.Context, topic string) (uint64, error) {
+	seq, err := l.client.Get(ctx, seqKeyPrefix+topic).Uint64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return seq, err
+}
+
+func (l *RedisEventLog) Claim(ctx context.Context, topic string, owner string) (string, error) {
+	key := ownerKeyPrefix + topic
+	if err := l.client.SetNX(ctx, key, owner, l.ttl).Err(); err != nil {
+		return "", err
+	}
+	current, err := l.client.Get(ctx, key).Result()
+	if err != nil {
+		return "", err
+	}
+	if current == owner {
+		l.client.Expire(ctx, key, l.ttl)
+	}
+	return current, nil
+}
+
+func (l *RedisEventLog) Owner(ctx context.Context, topic string) (string, error) {
+	owner, err := l.client.Get(ctx, ownerKeyPrefix+topic).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return owner, err
+}
+
+// MemoryEventLog keeps the events in the process, for a single instance
+// running without redis
+type MemoryEventLog struct {
+	mu     sync.Mutex
+	topics map[string]*memoryTopic
+	size   int
+	ttl    time.Duration
+	pruned time.Time
+}
+
+type memoryTopic struct {
+	seq     uint64
+	events  []LoggedEvent
+	owner   string
+	updated time.Time
+}
+
+func NewMemoryEventLog() *MemoryEventLog {
+	return &MemoryEventLog{
+		topics: make(map[string]*memoryTopic),
+		size:   replayBufferSize,
+		ttl:    replayTTL,
+	}
+}
+
+func (l *MemoryEventLog) Append(ctx context.Context, topic string, build func(seq uint64) ([]byte, error)) (LoggedEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+
+	t, ok := l.topics[topic]
+	if !ok {
+		t = &memoryTopic{}
+		l.topics[topic] = t
+	}
+
+	payload, err := build(t.seq + 1)
+	if err != nil {
+		return LoggedEvent{}, err
+	}
+	t.seq++
+	t.updated = now
+
+	event := LoggedEvent{Seq: t.seq, Payload: payload}
+	t.events = append(t.events, event)
+	if len(t.events) > l.size {
+		t.events = append([]LoggedEvent(nil), t.events[len(t.events)-l.size:]...)
+	}
+	return event, nil
+}
+
+func (l *MemoryEventLog) Since(ctx context.Context, topic string, since uint64) ([]LoggedEvent, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	t, ok := l.topics[topic]
+	if !ok {
+		return nil, since == 0, nil
+	}
+	if since == t.seq {
+		return nil, true, nil
+	}
+	if since > t.seq {
+		return append([]LoggedEvent(nil), t.events...), false, nil
+	}
+
+	var events []LoggedEvent
+	for _, event := range t.events {
+		if event.Seq > since {
+			events = append(events, event)
+		}
+	}
+	complete := len(events) > 0 && events[0].Seq == since+1
+	return events, complete, nil
+}
+
+func (l *MemoryEventLog) Last(ctx context.Context, topic string) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if t, ok := l.topics[topic]; ok {
+		return t.seq, nil
+	}
+	return 0, nil
+}
+
+func (l *MemoryEventLog) Claim(ctx context.Context, topic string, owner string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+
+	t, ok := l.topics[topic]
+	if !ok {
+		t = &memoryTopic{}
+		l.topics[topic] = t
+	}
+	if t.owner == "" {
+		t.owner = owner
+	}
+	if t.owner == owner {
+		t.updated = now
+	}
+	return t.owner, nil
+}
+
+func (l *MemoryEventLog) Owner(ctx context.Context, topic string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if t, ok := l.topics[topic]; ok {
+		return t.owner, nil
+	}
+	return "", nil
+}
+
+// prune forgets the topics that expired, at most once a minute
+func (l *MemoryEventLog) prune(now time.Time) {
+	if now.Sub(l.pruned) < time.Minute {
+		return
+	}
+	l.pruned = now
+	for topic, t := range l.topics {
+		if now.Sub(t.updated) > l.ttl {
+			delete(l.topics, topic)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func appendEvents(t *testing.T, log EventLog, topic string, n int) {
+	for i := 0; i < n; i++ {
+		_, err := log.Append(context.Background(), topic, func(seq uint64) ([]byte, error) {
+			return []byte(fmt.Sprintf(`{"seq":%d}`, seq)), nil
+		})
+		assert.NoError(t, err)
+	}
+}
+
+func seqs(events []LoggedEvent) []uint64 {
+	result := []uint64{}
+	for _, event := range events {
+		result = append(result, event.Seq)
+	}
+	return result
+}
+
+func TestMemoryEventLog(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should number the events of every topic from one", func(t *testing.T) {
+		log := NewMemoryEventLog()
+
+		first, err := log.Append(ctx, "chat:a", func(seq uint64) ([]byte, error) {
+			return json.Marshal(TopicEvent{Seq: seq})
+		})
+		assert.NoError(t, err)
+		appendEvents(t, log, "chat:b", 1)
+		second, _ := log.Append(ctx, "chat:a", func(seq uint64) ([]byte, error) {
+			return json.Marshal(TopicEvent{Seq: seq})
+		})
+
+		assert.Equal(t, uint64(1), first.Seq)
+		assert.Equal(t, uint64(2), second.Seq)
+		assert.Contains(t, string(second.Payload), `"seq":2`)
+	})
+
+	t.Run("should return the events after the cursor", func(t *testing.T) {
+		log := NewMemoryEventLog()
+		appendEvents(t, log, "chat:a", 5)
+
+		events, complete, err := log.Since(ctx, "chat:a", 2)
+
+		assert.NoError(t, err)
+		assert.True(t, complete)
+		assert.Equal(t, []uint64{3, 4, 5}, seqs(events))
+	})
+
+	t.Run("should have nothing to replay for an up to date cursor", func(t *testing.T) {
+		log := NewMemoryEventLog()
+		appendEvents(t, log, "chat:a", 3)
+
+		events, complete, err := log.Since(ctx, "chat:a", 3)
+
+		assert.NoError(t, err)
+		assert.True(t, complete)
+		assert.Empty(t, events)
+	})
+
+	t.Run("should report events that are no longer kept", func(t *testing.T) {
+		log := NewMemoryEventLog()
+		log.size = 3
+		appendEvents(t, log, "chat:a", 6)
+
+		events, complete, err := log.Since(ctx, "chat:a", 1)
+
+		assert.NoError(t, err)
+		assert.False(t, complete)
+		assert.Equal(t, []uint64{4, 5, 6}, seqs(events))
+	})
+
+	t.Run("should replay everything kept for a cursor of an older numbering", func(t *testing.T) {
+		log := NewMemoryEventLog()
+		appendEvents(t, log, "chat:a", 2)
+
+		events, complete, err := log.Since(ctx, "chat:a", 10)
+
+		assert.NoError(t, err)
+		assert.False(t, complete)
+		assert.Equal(t, []uint64{1, 2}, seqs(events))
+	})
+
+	t.Run("should keep the first owner of a topic", func(t *testing.T) {
+		log := NewMemoryEventLog()
+
+		owner, err := log.Claim(ctx, "session:a", "alice")
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", owner)
+
+		owner, _ = log.Claim(ctx, "session:a", "bob")
+		assert.Equal(t, "alice", owner)
+
+		owner, _ = log.Owner(ctx, "session:b")
+		assert.Empty(t, owner)
+	})
+
+	t.Run("should forget expired topics", func(t *testing.T) {
+		log := NewMemoryEventLog()
+		log.ttl = time.Millisecond
+		appendEvents(t, log, "chat:a", 1)
+		time.Sleep(2 * time.Millisecond)
+		log.pruned = time.Time{}
+
+		appendEvents(t, log, "chat:b", 1)
+
+		_, ok := log.topics["chat:a"]
+		assert.False(t, ok)
+	})
+}
+
+func TestReplay(t *testing.T) {
+	since := func(seq uint64) *uint64 { return &seq }
+
+	t.Run("should replay missed events before the live ones", func(t *testing.T) {
+		pool := NewPool()
+		pool.UseEventLog(NewMemoryEventLog())
+		pool.Authorizer = allowAuthorizer{"member": {ChatTopic("chat-id")}}
+		for i := 1; i <= 3; i++ {
+			assert.NoError(t, pool.Publish(ChatTopic("chat-id"), "chat_message", i))
+		}
+
+		ws, server, received := setupRecordingWebsocket(t)
+		defer server.Close()
+		defer ws.Close()
+		client := NewClient("member-client", ws, pool, "member")
+		pool.add(client)
+
+		pool.handle(client, SubscriptionRequest{Action: "subscribe", Topic: ChatTopic("chat-id"), Since: since(1)})
+		assert.NoError(t, pool.Publish(ChatTopic("chat-id"), "chat_message", 4))
+
+		response := receive(t, received)
+		assert.Contains(t, response, `"type":"subscribed"`)
+		assert.NotContains(t, response, "truncated")
+		assert.Contains(t, receive(t, received), `"seq":2`)
+		assert.Contains(t, receive(t, received), `"seq":3`)
+		assert.Contains(t, receive(t, received), `"seq":4`)
+	})
+
+	t.Run("should tell the client when events are no longer kept", func(t *testing.T) {
+		pool := NewPool()
+		pool.Authorizer = allowAuthorizer{"member": {ChatTopic("chat-id")}}
+
+		ws, server, received := setupRecordingWebsocket(t)
+		defer server.Close()
+		defer ws.Close()
+		client := NewClient("member-client", ws, pool, "member")
+		pool.add(client)
+
+		pool.handle(client, SubscriptionRequest{Action: "subscribe", Topic: ChatTopic("chat-id"), Since: since(5)})
+
+		assert.Contains(t, receive(t, received), `"truncated":true`)
+	})
+
+	t.Run("should hold live events back while replaying", func(t *testing.T) {
+		pool := NewPool()
+		pool.UseEventLog(NewMemoryEventLog())
+		pool.Authorizer = allowAuthorizer{"member": {ChatTopic("chat-id")}}
+		for i := 1; i <= 2; i++ {
+			assert.NoError(t, pool.Publish(ChatTopic("chat-id"), "chat_message", i))
+		}
+
+		ws, server, received := setupRecordingWebsocket(t)
+		defer server.Close()
+		defer ws.Close()
+		client := NewClient("member-client", ws, pool, "member")
+		pool.add(client)
+
+		assert.NoError(t, pool.subscribe(client, ChatTopic("chat-id"), true))
+		events, complete := pool.eventsSince(ChatTopic("chat-id"), 0)
+		assert.True(t, complete)
+
+		// published after the missed events were read, before they were sent
+		assert.NoError(t, pool.Publish(ChatTopic("chat-id"), "chat_message", 3))
+		select {
+		case message := <-received:
+			t.Fatalf("unexpected message %s", message)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		pool.replay(client, ChatTopic("chat-id"), events)
+
+		assert.Contains(t, receive(t, received), `"seq":1`)
+		assert.Contains(t, receive(t, received), `"seq":2`)
+		assert.Contains(t, receive(t, received), `"seq":3`)
+
+		assert.NoError(t, pool.Publish(ChatTopic("chat-id"), "chat_message", 4))
+		assert.Contains(t, receive(t, received), `"seq":4`)
+	})
+
+	t.Run("should keep direct messages for a reconnecting session", func(t *testing.T) {
+		pool := NewPool()
+		pool.UseEventLog(NewMemoryEventLog())
+		// claimed by the first connection of the session
+		assert.True(t, pool.claimSession(sessionTopic("session-id"), "owner"))
+
+		err := pool.SendTicketMessage(TicketMessage{
+			BroadcastType:   "direct",
+			SourceSessionID: "session-id",
+			Message:         "sent while offline",
+		})
+		assert.NoError(t, err)
+
+		ws, server, received := setupRecordingWebsocket(t)
+		defer server.Close()
+		defer ws.Close()
+		client := NewClient("session-id", ws, pool, "owner")
+		client.since = since(0)
+		client.topics = map[string]*subscription{sessionTopic("session-id"): {replaying: true}}
+		pool.add(client)
+
+		assert.NoError(t, pool.SendTicketMessage(TicketMessage{
+			BroadcastType:   "direct",
+			SourceSessionID: "session-id",
+			Message:         "sent after reconnecting",
+		}))
+		pool.resume(client)
+
+		assert.Contains(t, receive(t, received), `"type":"resumed"`)
+		first := receive(t, received)
+		assert.Contains(t, first, "sent while offline")
+		assert.Contains(t, first, `"seq":1`)
+		assert.Contains(t, receive(t, received), "sent after reconnecting")
+	})
+
+	t.Run("should only replay a session to the pubkey that owns it", func(t *testing.T) {
+		pool := NewPool()
+		pool.UseEventLog(NewMemoryEventLog())
+		assert.True(t, pool.claimSession(sessionTopic("session-id"), "owner"))
+
+		assert.NoError(t, pool.SendTicketMessage(TicketMessage{
+			BroadcastType:   "direct",
+			SourceSessionID: "session-id",
+			Message:         "sent while offline",
+		}))
+
+		ws, server, received := setupRecordingWebsocket(t)
+		defer server.Close()
+		defer ws.Close()
+		client := NewClient("session-id", ws, pool, "intruder")
+		client.since = since(0)
+		client.topics = map[string]*subscription{sessionTopic("session-id"): {replaying: true}}
+		pool.add(client)
+		pool.resume(client)
+
+		response := receive(t, received)
+		assert.Contains(t, response, `"type":"resumed"`)
+		assert.Contains(t, response, `"truncated":true`)
+		assert.NoError(t, pool.SendTicketMessage(TicketMessage{
+			BroadcastType:   "direct",
+			SourceSessionID: "session-id",
+			Message:         "sent live",
+		}))
+		assert.Contains(t, receive(t, received), "sent live")
+	})
+
+	t.Run("should not keep sessions nobody authenticated for", func(t *testing.T) {
+		pool := NewPool()
+		pool.UseEventLog(NewMemoryEventLog())
+
+		err := pool.SendTicketMessage(TicketMessage{
+			BroadcastType:   "direct",
+			SourceSessionID: "session-id",
+			Message:         "sent while offline",
+		})
+		assert.Error(t, err, "the message cannot be kept for the client")
+
+		assert.True(t, pool.claimSession(sessionTopic("session-id"), "owner"))
+		events, _ := pool.eventsSince(sessionTopic("session-id"), 0)
+		assert.Empty(t, events)
+	})
+}
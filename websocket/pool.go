@@ -1,9 +1,22 @@
 package websocket
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stakwork/sphinx-tribes/db"
+	"github.com/stakwork/sphinx-tribes/logger"
+)
+
+const (
+	publishTimeout = 5 * time.Second
+	// an alias lives as long as the LNURL-auth challenge it was made for
+	aliasTTL = 10 * time.Minute
 )
 
 type Pool struct {
@@ -11,6 +24,33 @@ type Pool struct {
 	Unregister chan *Client
 	Clients    map[string]*ClientData
 	Broadcast  chan Message
+	// size mirrors len(Clients) for readers outside of Start
+	size atomic.Int64
+	// mu guards Clients, aliases and topics, Start is not the only
+	// goroutine that reads them
+	mu      sync.RWMutex
+	aliases map[string]alias
+	topics  map[string]map[*Client]bool
+	// Authorizer decides who may subscribe to a topic, without one every
+	// subscription is refused
+	Authorizer TopicAuthorizer
+	// backplane delivers messages for clients connected to other instances
+	backplane  Backplane
+	instanceID string
+	// events numbers and keeps the events for clients that reconnect
+	events EventLog
+}
+
+// subscription is a topic followed by a client, while the client catches
+// up on the events it missed the live ones wait in pending
+type subscription struct {
+	replaying bool
+	pending   []LoggedEvent
+}
+
+type alias struct {
+	host    string
+	expires time.Time
 }
 
 func NewPool() *Pool {
@@ -22,48 +62,560 @@ func NewPool() *Pool {
 	}
 }
 
+// UseBackplane shares the messages of the pool with the other instances,
+// it has to be called before Start
+func (pool *Pool) UseBackplane(backplane Backplane) {
+	pool.backplane = backplane
+	pool.instanceID = uuid.New().String()
+}
+
+// UseEventLog numbers the events of the pool and keeps them for replay, it
+// has to be called before Start
+func (pool *Pool) UseEventLog(events EventLog) {
+	pool.events = events
+}
+
 func (pool *Pool) Start() {
+	if pool.backplane != nil {
+		go pool.backplane.Subscribe(context.Background(), pool.receive)
+	}
+
 	for {
 		select {
 		case client := <-pool.Register:
-			// ceck to acoid nil pointer
-			if pool.Clients == nil {
-				pool.Clients = make(map[string]*ClientData)
-			}
-
-			pool.Clients[client.Host] = &ClientData{
-				Client: client,
-				Status: true,
-			}
-			fmt.Println("Size of Websocket Connection Pool: ", len(pool.Clients))
+			pool.add(client)
+			logger.Log.Info("[websocket] client %s connected, pool size: %d", client.Host, pool.Size())
 			err := db.Store.SetSocketConnections(db.Client{
 				Host: client.Host,
 				Conn: client.Conn,
 			})
 			if err == nil {
-				pool.Clients[client.Host].Client.Conn.WriteJSON(Message{Type: 1, Msg: "user_connect", Body: client.Host})
+				client.SendJSON(Message{Type: 1, Msg: "user_connect", Body: client.Host})
 				go client.Read()
+				if client.Pubkey != "" {
+					go pool.resume(client)
+				}
 			} else {
-				fmt.Println("Websocket pool client save error")
+				logger.Log.Error("[websocket] could not save client %s: %v", client.Host, err)
 			}
 		case client := <-pool.Unregister:
-			// ceck to acoid nil pointer
-			if pool.Clients[client.Host] != nil {
-				pool.Clients[client.Host].Client.Conn.WriteJSON(Message{Type: 1, Body: "User Disconnected..."})
-				delete(pool.Clients, client.Host)
-				fmt.Println("Size of Connection Pool: ", len(pool.Clients))
+			if pool.remove(client) {
+				client.SendJSON(Message{Type: 1, Body: "User Disconnected..."})
+				logger.Log.Info("[websocket] client %s disconnected, pool size: %d", client.Host, pool.Size())
 			}
 
 		case message := <-pool.Broadcast:
-			fmt.Println("Sending message to all clients in Pool")
-			// ceck to acoid nil pointer
-			if pool.Clients != nil {
-				for client, _ := range pool.Clients {
-					if err := pool.Clients[client].Client.Conn.WriteJSON(message); err != nil {
-						fmt.Println(err)
-						return
-					}
-				}
+			payload, err := json.Marshal(message)
+			if err != nil {
+				logger.Log.Error("[websocket] could not encode broadcast: %v", err)
+				continue
+			}
+			pool.broadcast(payload)
+			if err := pool.publish(Envelope{Payload: payload}); err != nil {
+				logger.Log.Error("[websocket] could not publish broadcast: %v", err)
+			}
+		}
+	}
+}
+
+// Size is the number of connected clients
+func (pool *Pool) Size() int {
+	return int(pool.size.Load())
+}
+
+// add registers the client, a client already registered with the same
+// host is replaced and closed
+func (pool *Pool) add(client *Client) {
+	pool.mu.Lock()
+	// ceck to acoid nil pointer
+	if pool.Clients == nil {
+		pool.Clients = make(map[string]*ClientData)
+	}
+	previous := pool.Clients[client.Host]
+	pool.Clients[client.Host] = &ClientData{
+		Client: client,
+		Status: true,
+	}
+	pool.size.Store(int64(len(pool.Clients)))
+	pool.mu.Unlock()
+
+	if previous != nil && previous.Client != client {
+		previous.Client.Close()
+	}
+}
+
+// remove unregisters the client unless another connection took its host
+func (pool *Pool) remove(client *Client) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	for topic := range client.topics {
+		pool.unsubscribeLocked(client, topic)
+	}
+
+	data, ok := pool.Clients[client.Host]
+	if !ok || data.Client != client {
+		return false
+	}
+	delete(pool.Clients, client.Host)
+	pool.size.Store(int64(len(pool.Clients)))
+	return true
+}
+
+// handle answers a subscription request of the client
+func (pool *Pool) handle(client *Client, request SubscriptionRequest) {
+	response := SubscriptionResponse{Topic: request.Topic}
+
+	switch request.Action {
+	case "subscribe":
+		if err := pool.subscribe(client, request.Topic, request.Since != nil); err != nil {
+			response.Type = "error"
+			response.Error = err.Error()
+			break
+		}
+		response.Type = "subscribed"
+		if request.Since != nil {
+			events, complete := pool.eventsSince(request.Topic, *request.Since)
+			response.Truncated = !complete
+			if err := client.SendJSON(response); err != nil {
+				logger.Log.Error("[websocket] could not answer %s on %s: %v", request.Action, request.Topic, err)
+				return
+			}
+			pool.replay(client, request.Topic, events)
+			return
+		}
+	case "unsubscribe":
+		pool.mu.Lock()
+		pool.unsubscribeLocked(client, request.Topic)
+		pool.mu.Unlock()
+		response.Type = "unsubscribed"
+	default:
+		response.Type = "error"
+		response.Error = fmt.Sprintf("unknown action %q", request.Action)
+	}
+
+	if err := client.SendJSON(response); err != nil {
+		logger.Log.Error("[websocket] could not answer %s on %s: %v", request.Action, request.Topic, err)
+	}
+}
+
+// subscribe adds the client to the topic, a replaying client gets the live
+// events once replay is done
+func (pool *Pool) subscribe(client *Client, topic string, replaying bool) error {
+	if client.Pubkey == "" {
+		return fmt.Errorf("authentication required")
+	}
+	if _, _, err := ParseTopic(topic); err != nil {
+		return err
+	}
+	if pool.Authorizer == nil {
+		return ErrTopicForbidden
+	}
+	if err := pool.Authorizer.Authorize(client.Pubkey, topic); err != nil {
+		return err
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if client.topics == nil {
+		client.topics = make(map[string]*subscription)
+	}
+	sub, ok := client.topics[topic]
+	if !ok && len(client.topics) >= maxClientTopics {
+		return fmt.Errorf("too many topics, at most %d", maxClientTopics)
+	}
+	if !ok {
+		sub = &subscription{}
+		client.topics[topic] = sub
+	}
+	sub.replaying = sub.replaying || replaying
+	if pool.topics == nil {
+		pool.topics = make(map[string]map[*Client]bool)
+	}
+	if pool.topics[topic] == nil {
+		pool.topics[topic] = make(map[*Client]bool)
+	}
+	pool.topics[topic][client] = true
+	return nil
+}
+
+func (pool *Pool) unsubscribeLocked(client *Client, topic string) {
+	delete(client.topics, topic)
+	if subscribers, ok := pool.topics[topic]; ok {
+		delete(subscribers, client)
+		if len(subscribers) == 0 {
+			delete(pool.topics, topic)
+		}
+	}
+}
+
+// Publish sends an event to the clients subscribed to the topic, on every
+// instance
+func (pool *Pool) Publish(topic string, event string, data interface{}) error {
+	if pool == nil {
+		return fmt.Errorf("pool is nil")
+	}
+
+	logged, err := pool.record(topic, func(seq uint64) ([]byte, error) {
+		return json.Marshal(TopicEvent{
+			Type:  "event",
+			Topic: topic,
+			Event: event,
+			Seq:   seq,
+			Data:  data,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	pool.deliverTopic(topic, logged)
+	return pool.publish(Envelope{Topic: topic, Seq: logged.Seq, Payload: logged.Payload})
+}
+
+// record numbers and keeps the event of the topic, without an event log or
+// when it fails the event is still sent without a number
+func (pool *Pool) record(topic string, build func(seq uint64) ([]byte, error)) (LoggedEvent, error) {
+	if pool.events != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		defer cancel()
+
+		logged, err := pool.events.Append(ctx, topic, build)
+		if err == nil {
+			return logged, nil
+		}
+		logger.Log.Error("[websocket] could not keep event of %s for replay: %v", topic, err)
+	}
+
+	payload, err := build(0)
+	if err != nil {
+		return LoggedEvent{}, err
+	}
+	return LoggedEvent{Payload: payload}, nil
+}
+
+// eventsSince returns the kept events of the topic after since and whether
+// none of them is missing
+func (pool *Pool) eventsSince(topic string, since uint64) ([]LoggedEvent, bool) {
+	if pool.events == nil {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	events, complete, err := pool.events.Since(ctx, topic, since)
+	if err != nil {
+		logger.Log.Error("[websocket] could not read events of %s for replay: %v", topic, err)
+		return nil, false
+	}
+	return events, complete
+}
+
+// claimSession makes the pubkey the owner of the session topic unless
+// another pubkey has it, and reports whether the pubkey owns it
+func (pool *Pool) claimSession(topic string, pubkey string) bool {
+	if pool.events == nil || pubkey == "" {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	owner, err := pool.events.Claim(ctx, topic, pubkey)
+	if err != nil {
+		logger.Log.Error("[websocket] could not claim %s: %v", topic, err)
+		return false
+	}
+	return owner == pubkey
+}
+
+// sessionOwned is whether an authenticated client claimed the session topic
+func (pool *Pool) sessionOwned(topic string) bool {
+	if pool.events == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	owner, err := pool.events.Owner(ctx, topic)
+	if err != nil {
+		logger.Log.Error("[websocket] could not read the owner of %s: %v", topic, err)
+		return false
+	}
+	return owner != ""
+}
+
+// lastSeq is the sequence number of the latest event of the topic
+func (pool *Pool) lastSeq(topic string) (uint64, bool) {
+	if pool.events == nil {
+		return 0, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	seq, err := pool.events.Last(ctx, topic)
+	if err != nil {
+		logger.Log.Error("[websocket] could not read the last event of %s: %v", topic, err)
+		return 0, false
+	}
+	return seq, true
+}
+
+func (pool *Pool) deliverTopic(topic string, event LoggedEvent) {
+	pool.mu.Lock()
+	clients := make([]*Client, 0, len(pool.topics[topic]))
+	for client := range pool.topics[topic] {
+		if pool.holdLocked(client, topic, event) {
+			continue
+		}
+		clients = append(clients, client)
+	}
+	pool.mu.Unlock()
+
+	for _, client := range clients {
+		if err := client.Send(event.Payload); err != nil {
+			logger.Log.Error("[websocket] could not deliver event of %s: %v", topic, err)
+		}
+	}
+}
+
+// deliver sends an event of the topic to a single client
+func (pool *Pool) deliver(client *Client, topic string, event LoggedEvent) error {
+	pool.mu.Lock()
+	held := pool.holdLocked(client, topic, event)
+	pool.mu.Unlock()
+
+	if held {
+		return nil
+	}
+	return client.Send(event.Payload)
+}
+
+// holdLocked keeps the event back when the client is still replaying the
+// topic
+func (pool *Pool) holdLocked(client *Client, topic string, event LoggedEvent) bool {
+	sub := client.topics[topic]
+	if sub == nil || !sub.replaying {
+		return false
+	}
+	sub.pending = append(sub.pending, event)
+	return true
+}
+
+// replay sends the missed events of the topic to the client, then the live
+// events held back meanwhile, and switches the client to the live events
+func (pool *Pool) replay(client *Client, topic string, events []LoggedEvent) {
+	var last uint64
+	send := func(events []LoggedEvent) error {
+		for _, event := range events {
+			if event.Seq != 0 && event.Seq <= last {
+				// read from the log and held back as well
+				continue
+			}
+			if err := client.sendWait(event.Payload, writeWait); err != nil {
+				return err
+			}
+			if event.Seq > last {
+				last = event.Seq
+			}
+		}
+		return nil
+	}
+
+	err := send(events)
+	for {
+		pool.mu.Lock()
+		sub := client.topics[topic]
+		if sub == nil {
+			// unsubscribed or gone while replaying
+			pool.mu.Unlock()
+			return
+		}
+		pending := sub.pending
+		sub.pending = nil
+		if err != nil || len(pending) == 0 {
+			sub.replaying = false
+			pool.mu.Unlock()
+			if err != nil {
+				logger.Log.Error("[websocket] could not replay %s: %v", topic, err)
+			}
+			return
+		}
+		pool.mu.Unlock()
+
+		err = send(pending)
+	}
+}
+
+// resume claims the session of an authenticated client and, when it
+// reconnected, replays the direct messages it missed. Only the pubkey that
+// owns the session gets them.
+func (pool *Pool) resume(client *Client) {
+	topic := sessionTopic(client.Host)
+	owned := pool.claimSession(topic, client.Pubkey)
+	if client.since == nil {
+		return
+	}
+
+	var events []LoggedEvent
+	complete := false
+	if owned {
+		events, complete = pool.eventsSince(topic, *client.since)
+	} else {
+		logger.Log.Info("[websocket] session %s is not owned by the pubkey that resumed it", client.Host)
+	}
+
+	if err := client.SendJSON(SubscriptionResponse{Type: "resumed", Topic: topic, Truncated: !complete}); err != nil {
+		logger.Log.Error("[websocket] could not resume session %s: %v", client.Host, err)
+	}
+	pool.replay(client, topic, events)
+}
+
+func (pool *Pool) client(host string) *Client {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	if a, ok := pool.aliases[host]; ok && time.Now().Before(a.expires) {
+		host = a.host
+	}
+	if data, ok := pool.Clients[host]; ok {
+		return data.Client
+	}
+	return nil
+}
+
+func (pool *Pool) broadcast(payload []byte) {
+	pool.mu.RLock()
+	clients := make([]*Client, 0, len(pool.Clients))
+	for _, data := range pool.Clients {
+		clients = append(clients, data.Client)
+	}
+	pool.mu.RUnlock()
+
+	// a failing client is dropped by Send, the others still get the message
+	for _, client := range clients {
+		if err := client.Send(payload); err != nil {
+			logger.Log.Error("[websocket] could not broadcast: %v", err)
+		}
+	}
+}
+
+// Alias makes the client connected as host reachable as alias too, on
+// every instance
+func (pool *Pool) Alias(aliasHost string, host string) error {
+	if pool == nil {
+		return fmt.Errorf("pool is nil")
+	}
+	pool.setAlias(aliasHost, host)
+	return pool.publish(Envelope{Host: host, Alias: aliasHost})
+}
+
+func (pool *Pool) setAlias(aliasHost string, host string) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	now := time.Now()
+	if pool.aliases == nil {
+		pool.aliases = make(map[string]alias)
+	}
+	for key, a := range pool.aliases {
+		if now.After(a.expires) {
+			delete(pool.aliases, key)
+		}
+	}
+	pool.aliases[aliasHost] = alias{host: host, expires: now.Add(aliasTTL)}
+}
+
+// SendJSON sends the message to the client connected as host, on this
+// instance or, with a backplane, on any other
+func (pool *Pool) SendJSON(host string, message interface{}) error {
+	if pool == nil {
+		return fmt.Errorf("pool is nil")
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if client := pool.client(host); client != nil {
+		return client.Send(payload)
+	}
+	if pool.backplane != nil {
+		return pool.publish(Envelope{Host: host, Payload: payload})
+	}
+	return fmt.Errorf("client not found: %s", host)
+}
+
+// sendSession sends a direct message to the client connected as host and
+// keeps it under its session, a client that is not connected gets it when
+// it reconnects. Only sessions an authenticated client claimed are kept,
+// anybody else knowing the host could replay them.
+func (pool *Pool) sendSession(host string, build func(seq uint64) ([]byte, error)) error {
+	topic := sessionTopic(host)
+
+	var event LoggedEvent
+	if pool.sessionOwned(topic) {
+		logged, err := pool.record(topic, build)
+		if err != nil {
+			return err
+		}
+		event = logged
+	} else {
+		payload, err := build(0)
+		if err != nil {
+			return err
+		}
+		event = LoggedEvent{Payload: payload}
+	}
+
+	if client := pool.client(host); client != nil {
+		return pool.deliver(client, topic, event)
+	}
+	if pool.backplane != nil {
+		return pool.publish(Envelope{Host: host, Seq: event.Seq, Payload: event.Payload})
+	}
+	if event.Seq != 0 {
+		// kept for when the client reconnects
+		return nil
+	}
+	return fmt.Errorf("client not found: %s", host)
+}
+
+func (pool *Pool) publish(envelope Envelope) error {
+	if pool.backplane == nil {
+		return nil
+	}
+	envelope.Origin = pool.instanceID
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	return pool.backplane.Publish(ctx, envelope)
+}
+
+// receive delivers a message published by another instance
+func (pool *Pool) receive(envelope Envelope) {
+	if envelope.Origin == pool.instanceID {
+		return
+	}
+
+	switch {
+	case envelope.Topic != "":
+		pool.deliverTopic(envelope.Topic, LoggedEvent{Seq: envelope.Seq, Payload: envelope.Payload})
+	case envelope.Alias != "":
+		pool.setAlias(envelope.Alias, envelope.Host)
+	case envelope.Host == "":
+		pool.broadcast(envelope.Payload)
+	default:
+		// most instances do not hold the client
+		if client := pool.client(envelope.Host); client != nil {
+			event := LoggedEvent{Seq: envelope.Seq, Payload: envelope.Payload}
+			if err := pool.deliver(client, sessionTopic(envelope.Host), event); err != nil {
+				logger.Log.Error("[websocket] could not deliver to %s: %v", envelope.Host, err)
 			}
 		}
 	}
@@ -79,32 +631,34 @@ func (pool *Pool) SendTicketMessage(message TicketMessage) error {
 		if message.SourceSessionID == "" {
 			return fmt.Errorf("client not found")
 		}
-		// check if client
-		if client, ok := pool.Clients[message.SourceSessionID]; ok {
-			return client.Client.Conn.WriteJSON(message)
-		}
-		return fmt.Errorf("client not found: %s", message.SourceSessionID)
+		return pool.sendSession(message.SourceSessionID, func(seq uint64) ([]byte, error) {
+			message.Seq = seq
+			return json.Marshal(message)
+		})
 	}
 
 	return nil
 }
 
-
 func (pool *Pool) SendTicketPlanMessage(message TicketPlanMessage) error {
-    if pool == nil {
-        return fmt.Errorf("pool is nil")
-    }
-
-    if message.BroadcastType == "direct" {
-        if message.SourceSessionID == "" {
-            return fmt.Errorf("client not found")
-        }
-
-		if client, ok := pool.Clients[message.SourceSessionID]; ok {
-            return client.Client.Conn.WriteJSON(message)
-        }
-        return fmt.Errorf("client not found: %s", message.SourceSessionID)
-    }
-
-    return nil
-}
\ No newline at end of file
+	if pool == nil {
+		return fmt.Errorf("pool is nil")
+	}
+
+	if message.BroadcastType == "direct" {
+		if message.SourceSessionID == "" {
+			return fmt.Errorf("client not found")
+		}
+		return pool.sendSession(message.SourceSessionID, func(seq uint64) ([]byte, error) {
+			message.Seq = seq
+			return json.Marshal(message)
+		})
+	}
+
+	return nil
+}
+
+// SendInvoiceMessage tells the client waiting on an invoice that it was settled
+func (pool *Pool) SendInvoiceMessage(host string, message InvoiceMessage) error {
+	return pool.SendJSON(host, message)
+}
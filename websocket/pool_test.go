@@ -5,8 +5,10 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/stakwork/sphinx-tribes/db"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -209,6 +211,11 @@ func TestSendTicketMessage(t *testing.T) {
 		}
 
 		err := pool.SendTicketMessage(message)
+		// the writer fails on the closed connection and drops the client
+		assert.NoError(t, err)
+		assert.Eventually(t, func() bool { return pool.client("test-client") == nil }, time.Second, 10*time.Millisecond)
+
+		err = pool.SendTicketMessage(message)
 		assert.Error(t, err)
 	})
 
@@ -432,6 +439,11 @@ func TestSendTicketMessageScenarios(t *testing.T) {
 		}
 
 		err := pool.SendTicketMessage(message)
+		// the writer fails on the closed connection and drops the client
+		assert.NoError(t, err)
+		assert.Eventually(t, func() bool { return pool.client("test-client") == nil }, time.Second, 10*time.Millisecond)
+
+		err = pool.SendTicketMessage(message)
 		assert.Error(t, err)
 	})
 
@@ -599,3 +611,175 @@ func setupTestWebsocket(t *testing.T) (*websocket.Conn, *httptest.Server) {
 
 	return ws, server
 }
+
+// setupRecordingWebsocket connects a client conn to a server that hands
+// every message it reads to the returned channel
+func setupRecordingWebsocket(t *testing.T) (*websocket.Conn, *httptest.Server, <-chan string) {
+	received := make(chan string, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			_, p, err := conn.ReadMessage()
+			if err != nil {
+				break
+			}
+			received <- string(p)
+		}
+	}))
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ws, server, received
+}
+
+func receive(t *testing.T, received <-chan string) string {
+	select {
+	case message := <-received:
+		return message
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+		return ""
+	}
+}
+
+func TestPoolStart(t *testing.T) {
+	t.Run("should keep broadcasting after a client fails", func(t *testing.T) {
+		pool := NewPool()
+		go pool.Start()
+
+		deadWs, deadServer := setupTestWebsocket(t)
+		deadServer.Close()
+		deadWs.Close()
+		dead := NewClient("dead-client", deadWs, pool, "")
+
+		ws, server, received := setupRecordingWebsocket(t)
+		defer server.Close()
+		defer ws.Close()
+		live := NewClient("live-client", ws, pool, "")
+
+		pool.add(dead)
+		pool.add(live)
+
+		pool.Broadcast <- Message{Type: 1, Body: "first"}
+		pool.Broadcast <- Message{Type: 1, Body: "second"}
+
+		assert.Contains(t, receive(t, received), "first")
+		assert.Contains(t, receive(t, received), "second")
+		assert.Eventually(t, func() bool { return pool.client("dead-client") == nil }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, 1, pool.Size())
+	})
+
+	t.Run("should replace a client reconnecting with the same host", func(t *testing.T) {
+		pool := NewPool()
+
+		ws1, server1 := setupTestWebsocket(t)
+		defer server1.Close()
+		ws2, server2 := setupTestWebsocket(t)
+		defer server2.Close()
+		defer ws2.Close()
+
+		first := NewClient("same-host", ws1, pool, "")
+		second := NewClient("same-host", ws2, pool, "")
+		pool.add(first)
+		pool.add(second)
+
+		assert.Same(t, second, pool.client("same-host"))
+		assert.Error(t, first.Send([]byte("closed")))
+		// the first connection going away does not unregister the second
+		assert.False(t, pool.remove(first))
+		assert.Same(t, second, pool.client("same-host"))
+	})
+}
+
+func TestSlowClient(t *testing.T) {
+	pool := NewPool()
+	ws, server := setupTestWebsocket(t)
+	defer server.Close()
+
+	// a client whose writer is stuck, only one message fits in its queue
+	client := &Client{Host: "slow-client", Conn: ws, Pool: pool, send: make(chan []byte, 1), done: make(chan struct{})}
+	client.startOnce.Do(func() {})
+	pool.add(client)
+
+	assert.NoError(t, pool.SendJSON("slow-client", Message{Body: "first"}))
+	err := pool.SendJSON("slow-client", Message{Body: "second"})
+
+	assert.ErrorContains(t, err, "too slow")
+	assert.Nil(t, pool.client("slow-client"))
+	assert.Equal(t, 0, pool.Size())
+}
+
+func TestHeartbeat(t *testing.T) {
+	defaultPongWait, defaultPingPeriod := pongWait, pingPeriod
+	defer func() { pongWait, pingPeriod = defaultPongWait, defaultPingPeriod }()
+	pongWait, pingPeriod = 200*time.Millisecond, 50*time.Millisecond
+
+	t.Run("should ping the client", func(t *testing.T) {
+		pings := make(chan struct{}, 16)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			conn.SetPingHandler(func(string) error {
+				pings <- struct{}{}
+				return nil
+			})
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}))
+		defer server.Close()
+
+		ws, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:], nil)
+		assert.NoError(t, err)
+		client := NewClient("pinged-client", ws, NewPool(), "")
+		defer client.Close()
+
+		for i := 0; i < 2; i++ {
+			select {
+			case <-pings:
+			case <-time.After(time.Second):
+				t.Fatal("no ping received")
+			}
+		}
+	})
+
+	t.Run("should drop a client that stops answering pings", func(t *testing.T) {
+		// the peer never reads, so it never answers a ping
+		peerDone := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			<-peerDone
+		}))
+		defer server.Close()
+		defer close(peerDone)
+
+		db.InitCache()
+		pool := NewPool()
+		go pool.Start()
+
+		ws, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:], nil)
+		assert.NoError(t, err)
+		pool.Register <- NewClient("silent-client", ws, pool, "")
+
+		assert.Eventually(t, func() bool { return pool.Size() == 1 }, time.Second, 10*time.Millisecond)
+		assert.Eventually(t, func() bool { return pool.Size() == 0 }, 2*time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,214 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stakwork/sphinx-tribes/logger"
+	"github.com/stakwork/sphinx-tribes/utils"
+)
+
+const (
+	// comments keep proxies from closing an idle stream
+	keepAliveInterval = 15 * time.Second
+	// a stream ends this long before the deadline of its request, the
+	// browser reconnects with the id of the last event it got
+	streamDeadlineMargin = 5 * time.Second
+	// how long browsers wait before reconnecting, in milliseconds
+	streamRetry = 2000
+)
+
+// ServeSSE streams the events of the topics in the query to the client, as
+// an alternative to the websocket where proxies break it. Every event has
+// the position of the stream in each topic as its id, a client that
+// reconnects with it in Last-Event-ID gets the events it missed first.
+func ServeSSE(pool *Pool, w http.ResponseWriter, r *http.Request) {
+	pubkey, _ := r.Context().Value(auth.ContextKey).(string)
+
+	topics := r.URL.Query()["topic"]
+	if len(topics) == 0 {
+		writeStreamError(w, http.StatusBadRequest, "at least one topic is required")
+		return
+	}
+	if len(topics) > maxClientTopics {
+		writeStreamError(w, http.StatusBadRequest, fmt.Sprintf("too many topics, at most %d", maxClientTopics))
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		// EventSource polyfills send it in the query
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	cursors, err := parseStreamCursor(lastEventID)
+	if err != nil {
+		writeStreamError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	client := &Client{Host: "sse-" + utils.GetRandomToken(20), Pool: pool, Pubkey: pubkey}
+	client.start()
+	defer func() {
+		client.Close()
+		pool.remove(client)
+	}()
+
+	// authorized once per stream, streams are short so a member removed
+	// from a workspace stops getting its events at the next reconnect
+	resume := make(map[string]uint64)
+	for _, topic := range topics {
+		since, resuming := cursors[topic]
+		if err := pool.subscribe(client, topic, resuming); err != nil {
+			writeStreamError(w, subscriptionStatus(err), err.Error())
+			return
+		}
+		if resuming {
+			resume[topic] = since
+		}
+	}
+
+	// the id covers every topic of the stream, also those without events
+	// yet, so nothing published while the client reconnects is lost
+	cursors = make(map[string]uint64)
+	for _, topic := range topics {
+		if since, ok := resume[topic]; ok {
+			cursors[topic] = since
+		} else if seq, ok := pool.lastSeq(topic); ok {
+			cursors[topic] = seq
+		}
+	}
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// nginx buffers responses unless told otherwise
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n", streamRetry)
+	if len(cursors) > 0 {
+		fmt.Fprintf(w, "id: %s\n", encodeStreamCursor(cursors))
+	}
+	fmt.Fprint(w, "\n")
+	if err := controller.Flush(); err != nil {
+		logger.Log.Error("[websocket] event stream cannot be flushed: %v", err)
+		return
+	}
+
+	replays := make(map[string][]LoggedEvent)
+	for _, topic := range topics {
+		response := SubscriptionResponse{Type: "subscribed", Topic: topic}
+		if since, ok := resume[topic]; ok {
+			events, complete := pool.eventsSince(topic, since)
+			replays[topic] = events
+			response.Truncated = !complete
+		}
+		if err := client.SendJSON(response); err != nil {
+			return
+		}
+	}
+	go func() {
+		for topic, events := range replays {
+			pool.replay(client, topic, events)
+		}
+	}()
+
+	var end <-chan time.Time
+	if deadline, ok := r.Context().Deadline(); ok {
+		timer := time.NewTimer(time.Until(deadline) - streamDeadlineMargin)
+		defer timer.Stop()
+		end = timer.C
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-client.done:
+			return
+		case <-end:
+			return
+		case payload := <-client.send:
+			if id, ok := advanceStreamCursor(cursors, payload); ok {
+				fmt.Fprintf(w, "id: %s\n", id)
+			}
+			fmt.Fprintf(w, "data: %s\n\n", payload)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// parseStreamCursor reads the position in each topic out of an event id
+func parseStreamCursor(id string) (map[string]uint64, error) {
+	cursors := make(map[string]uint64)
+	if id == "" {
+		return cursors, nil
+	}
+
+	values, err := url.ParseQuery(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Last-Event-ID: %v", err)
+	}
+	for topic := range values {
+		seq, err := strconv.ParseUint(values.Get(topic), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Last-Event-ID: %v", err)
+		}
+		cursors[topic] = seq
+	}
+	return cursors, nil
+}
+
+// advanceStreamCursor moves the cursor past a numbered event and returns
+// the id that resumes the stream after it
+func advanceStreamCursor(cursors map[string]uint64, payload []byte) (string, bool) {
+	var event struct {
+		Topic string `json:"topic"`
+		Seq   uint64 `json:"seq"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil || event.Topic == "" || event.Seq == 0 {
+		return "", false
+	}
+	if event.Seq > cursors[event.Topic] {
+		cursors[event.Topic] = event.Seq
+	}
+	return encodeStreamCursor(cursors), true
+}
+
+func encodeStreamCursor(cursors map[string]uint64) string {
+	values := url.Values{}
+	for topic, seq := range cursors {
+		values.Set(topic, strconv.FormatUint(seq, 10))
+	}
+	return values.Encode()
+}
+
+func subscriptionStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrTopicForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrTopicNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
+func writeStreamError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,180 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stakwork/sphinx-tribes/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+// sseFrame is one event of a stream with the fields it was sent with
+type sseFrame struct {
+	id   string
+	data string
+}
+
+func startEventStream(t *testing.T, pool *Pool, pubkey string, query string, lastEventID string) (*http.Response, <-chan sseFrame, context.CancelFunc) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), auth.ContextKey, pubkey)
+		ServeSSE(pool, w, r.WithContext(ctx))
+	}))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?"+query, nil)
+	assert.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+
+	frames := make(chan sseFrame, 16)
+	go func() {
+		defer close(frames)
+		scanner := bufio.NewScanner(resp.Body)
+		var frame sseFrame
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if frame.id != "" || frame.data != "" {
+					frames <- frame
+				}
+				frame = sseFrame{}
+			case strings.HasPrefix(line, "id: "):
+				frame.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				frame.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		resp.Body.Close()
+	})
+	return resp, frames, cancel
+}
+
+func nextFrame(t *testing.T, frames <-chan sseFrame) sseFrame {
+	select {
+	case frame, ok := <-frames:
+		if !ok {
+			t.Fatal("stream closed")
+		}
+		return frame
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return sseFrame{}
+	}
+}
+
+func TestServeSSE(t *testing.T) {
+	topic := WorkspaceTopic("ws-uuid")
+	query := url.Values{"topic": {topic}}.Encode()
+
+	t.Run("should refuse topics the user is not authorized for", func(t *testing.T) {
+		pool := NewPool()
+		pool.Authorizer = allowAuthorizer{}
+
+		resp, _, _ := startEventStream(t, pool, "stranger", query, "")
+
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("should require a topic", func(t *testing.T) {
+		resp, _, _ := startEventStream(t, NewPool(), "member", "", "")
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("should stream the events of the topic with resumable ids", func(t *testing.T) {
+		pool := NewPool()
+		pool.UseEventLog(NewMemoryEventLog())
+		pool.Authorizer = allowAuthorizer{"member": {topic}}
+		assert.NoError(t, pool.Publish(topic, "activity_created", "before"))
+
+		resp, frames, _ := startEventStream(t, pool, "member", query, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		assert.Equal(t, url.Values{topic: {"1"}}.Encode(), nextFrame(t, frames).id)
+		assert.Contains(t, nextFrame(t, frames).data, `"type":"subscribed"`)
+
+		assert.NoError(t, pool.Publish(topic, "activity_created", "live"))
+
+		frame := nextFrame(t, frames)
+		assert.Contains(t, frame.data, `"live"`)
+		assert.Equal(t, url.Values{topic: {"2"}}.Encode(), frame.id)
+	})
+
+	t.Run("should replay the events missed since Last-Event-ID", func(t *testing.T) {
+		pool := NewPool()
+		pool.UseEventLog(NewMemoryEventLog())
+		pool.Authorizer = allowAuthorizer{"member": {topic}}
+		for _, data := range []string{"first", "second", "third"} {
+			assert.NoError(t, pool.Publish(topic, "activity_created", data))
+		}
+
+		_, frames, _ := startEventStream(t, pool, "member", query, url.Values{topic: {"1"}}.Encode())
+
+		nextFrame(t, frames)
+		assert.NotContains(t, nextFrame(t, frames).data, "truncated")
+		assert.Contains(t, nextFrame(t, frames).data, `"second"`)
+		frame := nextFrame(t, frames)
+		assert.Contains(t, frame.data, `"third"`)
+		assert.Equal(t, url.Values{topic: {"3"}}.Encode(), frame.id)
+	})
+
+	t.Run("should end the stream before the deadline of the request", func(t *testing.T) {
+		pool := NewPool()
+		pool.Authorizer = allowAuthorizer{"member": {topic}}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/events?"+query, nil)
+		ctx, cancel := context.WithTimeout(context.WithValue(req.Context(), auth.ContextKey, "member"), streamDeadlineMargin+50*time.Millisecond)
+		defer cancel()
+
+		done := make(chan struct{})
+		go func() {
+			ServeSSE(pool, rr, req.WithContext(ctx))
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("stream did not end")
+		}
+		assert.NoError(t, ctx.Err())
+		assert.Contains(t, rr.Body.String(), "retry: ")
+	})
+}
+
+func TestStreamCursor(t *testing.T) {
+	cursors, err := parseStreamCursor(url.Values{"chat:a": {"4"}, "workspace:b": {"9"}}.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"chat:a": 4, "workspace:b": 9}, cursors)
+
+	id, ok := advanceStreamCursor(cursors, []byte(`{"type":"event","topic":"chat:a","seq":5}`))
+	assert.True(t, ok)
+	assert.Equal(t, "chat%3Aa=5&workspace%3Ab=9", id)
+
+	_, ok = advanceStreamCursor(cursors, []byte(`{"type":"subscribed","topic":"chat:a"}`))
+	assert.False(t, ok)
+
+	_, err = parseStreamCursor("chat%3Aa=five")
+	assert.Error(t, err)
+}
@@ -0,0 +1,194 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/stakwork/sphinx-tribes/db"
+)
+
+// Topics are named kind:id, like workspace:<uuid> or bounty:<id>
+const (
+	TopicWorkspace   = "workspace"
+	TopicFeature     = "feature"
+	TopicPhase       = "phase"
+	TopicTicketGroup = "ticket_group"
+	TopicChat        = "chat"
+	TopicBounty      = "bounty"
+	// direct messages to a connection are kept under its session so they
+	// can be replayed, sessions cannot be subscribed to
+	topicSession = "session"
+)
+
+// topics a single connection may follow at once
+const maxClientTopics = 100
+
+var (
+	ErrTopicNotFound  = errors.New("topic not found")
+	ErrTopicForbidden = errors.New("not a member of the workspace of this topic")
+)
+
+func Topic(kind string, id string) string {
+	return kind + ":" + id
+}
+
+func WorkspaceTopic(workspaceUuid string) string {
+	return Topic(TopicWorkspace, workspaceUuid)
+}
+
+func FeatureTopic(featureUuid string) string {
+	return Topic(TopicFeature, featureUuid)
+}
+
+func PhaseTopic(phaseUuid string) string {
+	return Topic(TopicPhase, phaseUuid)
+}
+
+func TicketGroupTopic(ticketGroup string) string {
+	return Topic(TopicTicketGroup, ticketGroup)
+}
+
+func ChatTopic(chatID string) string {
+	return Topic(TopicChat, chatID)
+}
+
+func BountyTopic(bountyID uint) string {
+	return Topic(TopicBounty, strconv.FormatUint(uint64(bountyID), 10))
+}
+
+func sessionTopic(host string) string {
+	return Topic(topicSession, host)
+}
+
+func ParseTopic(topic string) (string, string, error) {
+	kind, id, found := strings.Cut(topic, ":")
+	if !found || id == "" {
+		return "", "", fmt.Errorf("invalid topic %q, expected kind:id", topic)
+	}
+	switch kind {
+	case TopicWorkspace, TopicFeature, TopicPhase, TopicTicketGroup, TopicChat, TopicBounty:
+		return kind, id, nil
+	}
+	return "", "", fmt.Errorf("unknown topic kind %q", kind)
+}
+
+// SubscriptionRequest is what clients send to follow or leave a topic.
+// Since is the last sequence number the client saw of the topic, the
+// events after it are replayed before the live ones.
+type SubscriptionRequest struct {
+	Action string  `json:"action"`
+	Topic  string  `json:"topic"`
+	Since  *uint64 `json:"since,omitempty"`
+}
+
+// SubscriptionResponse answers a SubscriptionRequest. Truncated tells a
+// client that asked for a replay that some events are no longer kept and
+// it has to reload what it shows.
+type SubscriptionResponse struct {
+	Type      string `json:"type"`
+	Topic     string `json:"topic"`
+	Error     string `json:"error,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// TopicEvent is sent to every client subscribed to its topic, Seq grows
+// by one with every event of the topic
+type TopicEvent struct {
+	Type  string      `json:"type"`
+	Topic string      `json:"topic"`
+	Event string      `json:"event"`
+	Seq   uint64      `json:"seq,omitempty"`
+	Data  interface{} `json:"data"`
+}
+
+// TopicAuthorizer decides whether the owner of pubkey may follow a topic
+type TopicAuthorizer interface {
+	Authorize(pubkey string, topic string) error
+}
+
+// MembershipAuthorizer lets members of a workspace follow the topics of
+// the workspace, bounties outside of a workspace are followed by their
+// owner and assignee
+type MembershipAuthorizer struct {
+	db db.Database
+}
+
+func NewMembershipAuthorizer(database db.Database) *MembershipAuthorizer {
+	return &MembershipAuthorizer{db: database}
+}
+
+func (a *MembershipAuthorizer) Authorize(pubkey string, topic string) error {
+	if pubkey == "" {
+		return ErrTopicForbidden
+	}
+
+	kind, id, err := ParseTopic(topic)
+	if err != nil {
+		return err
+	}
+
+	var workspaceUuid string
+	switch kind {
+	case TopicWorkspace:
+		workspaceUuid = id
+	case TopicFeature:
+		workspaceUuid = a.db.GetFeatureByUuid(id).WorkspaceUuid
+	case TopicPhase:
+		if phase, err := a.db.GetPhaseByUuid(id); err == nil {
+			workspaceUuid = a.db.GetFeatureByUuid(phase.FeatureUuid).WorkspaceUuid
+		}
+	case TopicTicketGroup:
+		group, err := uuid.Parse(id)
+		if err != nil {
+			return ErrTopicNotFound
+		}
+		if ticket, err := a.db.GetLatestTicketByGroup(group); err == nil {
+			workspaceUuid = ticket.WorkspaceUuid
+			if workspaceUuid == "" {
+				workspaceUuid = a.db.GetFeatureByUuid(ticket.FeatureUUID).WorkspaceUuid
+			}
+		}
+	case TopicChat:
+		if chat, err := a.db.GetChatByChatID(id); err == nil {
+			workspaceUuid = chat.WorkspaceID
+		}
+	case TopicBounty:
+		bountyID, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			return ErrTopicNotFound
+		}
+		bounty := a.db.GetBounty(uint(bountyID))
+		if bounty.ID == 0 {
+			return ErrTopicNotFound
+		}
+		if bounty.WorkspaceUuid == "" {
+			if pubkey == bounty.OwnerID || pubkey == bounty.Assignee {
+				return nil
+			}
+			return ErrTopicForbidden
+		}
+		workspaceUuid = bounty.WorkspaceUuid
+	}
+
+	if workspaceUuid == "" {
+		return ErrTopicNotFound
+	}
+	return a.authorizeWorkspace(pubkey, workspaceUuid)
+}
+
+func (a *MembershipAuthorizer) authorizeWorkspace(pubkey string, workspaceUuid string) error {
+	workspace := a.db.GetWorkspaceByUuid(workspaceUuid)
+	if workspace.Uuid == "" || workspace.Deleted {
+		return ErrTopicNotFound
+	}
+	if workspace.OwnerPubKey == pubkey {
+		return nil
+	}
+	if a.db.GetWorkspaceUser(pubkey, workspaceUuid).OwnerPubKey == pubkey {
+		return nil
+	}
+	return ErrTopicForbidden
+}
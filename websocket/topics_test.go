@@ -0,0 +1,245 @@
+package websocket
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stakwork/sphinx-tribes/db"
+	dbMocks "github.com/stakwork/sphinx-tribes/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTopic(t *testing.T) {
+	tests := []struct {
+		topic string
+		kind  string
+		id    string
+		err   bool
+	}{
+		{topic: "workspace:ws-uuid", kind: TopicWorkspace, id: "ws-uuid"},
+		{topic: BountyTopic(42), kind: TopicBounty, id: "42"},
+		{topic: ChatTopic("chat:with:colons"), kind: TopicChat, id: "chat:with:colons"},
+		{topic: "workspace", err: true},
+		{topic: "workspace:", err: true},
+		{topic: "people:abc", err: true},
+		{topic: "", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.topic, func(t *testing.T) {
+			kind, id, err := ParseTopic(tt.topic)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.kind, kind)
+			assert.Equal(t, tt.id, id)
+		})
+	}
+}
+
+func TestMembershipAuthorizer(t *testing.T) {
+	t.Run("should allow the owner of the workspace", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockDb.On("GetWorkspaceByUuid", "ws-uuid").Return(db.Workspace{Uuid: "ws-uuid", OwnerPubKey: "owner"})
+
+		err := NewMembershipAuthorizer(mockDb).Authorize("owner", WorkspaceTopic("ws-uuid"))
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should treat a deleted workspace as not found", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockDb.On("GetWorkspaceByUuid", "ws-uuid").Return(db.Workspace{Uuid: "ws-uuid", OwnerPubKey: "owner", Deleted: true})
+
+		err := NewMembershipAuthorizer(mockDb).Authorize("owner", WorkspaceTopic("ws-uuid"))
+
+		assert.ErrorIs(t, err, ErrTopicNotFound)
+	})
+
+	t.Run("should allow members of the workspace of a feature", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockDb.On("GetFeatureByUuid", "feature-uuid").Return(db.WorkspaceFeatures{Uuid: "feature-uuid", WorkspaceUuid: "ws-uuid"})
+		mockDb.On("GetWorkspaceByUuid", "ws-uuid").Return(db.Workspace{Uuid: "ws-uuid", OwnerPubKey: "owner"})
+		mockDb.On("GetWorkspaceUser", "member", "ws-uuid").Return(db.WorkspaceUsers{OwnerPubKey: "member", WorkspaceUuid: "ws-uuid"})
+
+		err := NewMembershipAuthorizer(mockDb).Authorize("member", FeatureTopic("feature-uuid"))
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should resolve the workspace of a phase", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockDb.On("GetPhaseByUuid", "phase-uuid").Return(db.FeaturePhase{Uuid: "phase-uuid", FeatureUuid: "feature-uuid"}, nil)
+		mockDb.On("GetFeatureByUuid", "feature-uuid").Return(db.WorkspaceFeatures{Uuid: "feature-uuid", WorkspaceUuid: "ws-uuid"})
+		mockDb.On("GetWorkspaceByUuid", "ws-uuid").Return(db.Workspace{Uuid: "ws-uuid", OwnerPubKey: "owner"})
+
+		err := NewMembershipAuthorizer(mockDb).Authorize("owner", PhaseTopic("phase-uuid"))
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should resolve the workspace of a ticket group", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		group := uuid.New()
+		mockDb.On("GetLatestTicketByGroup", group).Return(db.Tickets{TicketGroup: &group, WorkspaceUuid: "ws-uuid"}, nil)
+		mockDb.On("GetWorkspaceByUuid", "ws-uuid").Return(db.Workspace{Uuid: "ws-uuid", OwnerPubKey: "owner"})
+
+		err := NewMembershipAuthorizer(mockDb).Authorize("owner", TicketGroupTopic(group.String()))
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should refuse users outside of the workspace of a chat", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockDb.On("GetChatByChatID", "chat-id").Return(db.Chat{ID: "chat-id", WorkspaceID: "ws-uuid"}, nil)
+		mockDb.On("GetWorkspaceByUuid", "ws-uuid").Return(db.Workspace{Uuid: "ws-uuid", OwnerPubKey: "owner"})
+		mockDb.On("GetWorkspaceUser", "stranger", "ws-uuid").Return(db.WorkspaceUsers{})
+
+		err := NewMembershipAuthorizer(mockDb).Authorize("stranger", ChatTopic("chat-id"))
+
+		assert.ErrorIs(t, err, ErrTopicForbidden)
+	})
+
+	t.Run("should report topics of unknown chats", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockDb.On("GetChatByChatID", "missing").Return(db.Chat{}, errors.New("chat not found"))
+
+		err := NewMembershipAuthorizer(mockDb).Authorize("owner", ChatTopic("missing"))
+
+		assert.ErrorIs(t, err, ErrTopicNotFound)
+	})
+
+	t.Run("should let only the owner and assignee follow a bounty without workspace", func(t *testing.T) {
+		mockDb := dbMocks.NewDatabase(t)
+		mockDb.On("GetBounty", uint(7)).Return(db.NewBounty{ID: 7, OwnerID: "owner", Assignee: "assignee"})
+		authorizer := NewMembershipAuthorizer(mockDb)
+
+		assert.NoError(t, authorizer.Authorize("owner", BountyTopic(7)))
+		assert.NoError(t, authorizer.Authorize("assignee", BountyTopic(7)))
+		assert.ErrorIs(t, authorizer.Authorize("stranger", BountyTopic(7)), ErrTopicForbidden)
+	})
+
+	t.Run("should refuse anonymous users", func(t *testing.T) {
+		err := NewMembershipAuthorizer(dbMocks.NewDatabase(t)).Authorize("", WorkspaceTopic("ws-uuid"))
+
+		assert.ErrorIs(t, err, ErrTopicForbidden)
+	})
+}
+
+// allowAuthorizer lets pubkeys follow the topics they are listed with
+type allowAuthorizer map[string][]string
+
+func (a allowAuthorizer) Authorize(pubkey string, topic string) error {
+	for _, allowed := range a[pubkey] {
+		if allowed == topic {
+			return nil
+		}
+	}
+	return ErrTopicForbidden
+}
+
+func TestTopicSubscriptions(t *testing.T) {
+	pool := NewPool()
+	pool.Authorizer = allowAuthorizer{"member": {WorkspaceTopic("ws-uuid")}}
+
+	ws, server, received := setupRecordingWebsocket(t)
+	defer server.Close()
+	defer ws.Close()
+	member := NewClient("member-client", ws, pool, "member")
+	pool.add(member)
+
+	otherWs, otherServer, otherReceived := setupRecordingWebsocket(t)
+	defer otherServer.Close()
+	defer otherWs.Close()
+	anonymous := NewClient("anonymous-client", otherWs, pool, "")
+	pool.add(anonymous)
+
+	t.Run("should refuse anonymous subscriptions", func(t *testing.T) {
+		pool.handle(anonymous, SubscriptionRequest{Action: "subscribe", Topic: WorkspaceTopic("ws-uuid")})
+
+		response := receive(t, otherReceived)
+		assert.Contains(t, response, `"type":"error"`)
+		assert.Contains(t, response, "authentication required")
+	})
+
+	t.Run("should refuse topics the client is not authorized for", func(t *testing.T) {
+		pool.handle(member, SubscriptionRequest{Action: "subscribe", Topic: WorkspaceTopic("other-uuid")})
+
+		assert.Contains(t, receive(t, received), ErrTopicForbidden.Error())
+	})
+
+	t.Run("should deliver events to subscribers only", func(t *testing.T) {
+		pool.handle(member, SubscriptionRequest{Action: "subscribe", Topic: WorkspaceTopic("ws-uuid")})
+		assert.Contains(t, receive(t, received), `"type":"subscribed"`)
+
+		assert.NoError(t, pool.Publish(WorkspaceTopic("ws-uuid"), "activity_created", map[string]string{"id": "activity"}))
+
+		event := receive(t, received)
+		assert.Contains(t, event, `"event":"activity_created"`)
+		assert.Contains(t, event, `"topic":"workspace:ws-uuid"`)
+		select {
+		case message := <-otherReceived:
+			t.Fatalf("unexpected message %s", message)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("should stop delivering after unsubscribing", func(t *testing.T) {
+		pool.handle(member, SubscriptionRequest{Action: "unsubscribe", Topic: WorkspaceTopic("ws-uuid")})
+		assert.Contains(t, receive(t, received), `"type":"unsubscribed"`)
+
+		assert.NoError(t, pool.Publish(WorkspaceTopic("ws-uuid"), "activity_created", nil))
+
+		select {
+		case message := <-received:
+			t.Fatalf("unexpected message %s", message)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("should forget the topics of removed clients", func(t *testing.T) {
+		pool.handle(member, SubscriptionRequest{Action: "subscribe", Topic: WorkspaceTopic("ws-uuid")})
+		receive(t, received)
+
+		pool.remove(member)
+
+		pool.mu.RLock()
+		defer pool.mu.RUnlock()
+		assert.Empty(t, pool.topics)
+	})
+}
+
+func TestTopicBackplane(t *testing.T) {
+	backplane := &memoryBackplane{}
+	first, second := NewPool(), NewPool()
+	first.UseBackplane(backplane)
+	second.UseBackplane(backplane)
+	second.Authorizer = allowAuthorizer{"member": {ChatTopic("chat-id")}}
+	go first.Start()
+	go second.Start()
+	assert.Eventually(t, func() bool { return backplane.subscribed() == 2 }, time.Second, 10*time.Millisecond)
+
+	ws, server, received := setupRecordingWebsocket(t)
+	defer server.Close()
+	defer ws.Close()
+	client := NewClient("remote-client", ws, second, "member")
+	second.add(client)
+
+	second.handle(client, SubscriptionRequest{Action: "subscribe", Topic: ChatTopic("chat-id")})
+	assert.Contains(t, receive(t, received), `"type":"subscribed"`)
+
+	assert.NoError(t, first.Publish(ChatTopic("chat-id"), "chat_message", map[string]string{"message": "hello"}))
+
+	event := receive(t, received)
+	assert.Contains(t, event, `"event":"chat_message"`)
+	assert.Contains(t, event, "hello")
+	select {
+	case message := <-received:
+		t.Fatalf("unexpected message %s", message)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
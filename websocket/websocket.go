@@ -1,12 +1,13 @@
 package websocket
 
 import (
-	"fmt"
-	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/websocket"
+	"github.com/stakwork/sphinx-tribes/auth"
 	"github.com/stakwork/sphinx-tribes/config"
+	"github.com/stakwork/sphinx-tribes/logger"
 	"github.com/stakwork/sphinx-tribes/utils"
 )
 
@@ -28,7 +29,7 @@ var upgrader = websocket.Upgrader{
 func Upgrade(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Println(err)
+		logger.FromContext(r.Context()).Warning("[websocket] could not upgrade connection: %v", err)
 		return nil, err
 	}
 
@@ -47,18 +48,29 @@ func ServeWs(pool *Pool, w http.ResponseWriter, r *http.Request) { // get url qu
 
 	conn, err := Upgrade(w, r)
 	if err != nil {
-		fmt.Println("Error in ServeWs", err)
-		fmt.Fprintf(w, "%+v\n", err)
+		// the upgrader already answered with the error
+		logger.Log.Error("[websocket] could not upgrade connection: %v", err)
+		return
 	}
 
 	if uniqueId == "" {
 		return
 	}
 
-	client := &Client{
-		Host: uniqueId,
-		Conn: conn,
-		Pool: pool,
+	// set by auth.OptionalPubKeyContext when the client connected with a token
+	pubkey, _ := r.Context().Value(auth.ContextKey).(string)
+
+	client := NewClient(uniqueId, conn, pool, pubkey)
+
+	// an authenticated client that reconnects with the last sequence number
+	// it saw gets the direct messages it missed before the new ones, if its
+	// pubkey owns the session
+	if since, err := strconv.ParseUint(queryParams.Get("since"), 10, 64); err == nil && pubkey != "" {
+		client.since = &since
+		client.topics = map[string]*subscription{
+			sessionTopic(uniqueId): {replaying: true},
+		}
 	}
+
 	pool.Register <- client
 }